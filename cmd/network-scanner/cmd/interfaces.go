@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"network-scanner/internal/network"
+)
+
+// RunInterfaces печатает сетевые интерфейсы с адресами и шлюзами по умолчанию;
+// интерфейс, чью сеть сканирует scan без --network, отмечен "*".
+func RunInterfaces() error {
+	infos, err := network.ListInterfaces()
+	if err != nil {
+		return fmt.Errorf("list interfaces: %w", err)
+	}
+	printInterfaces(os.Stdout, infos)
+	return nil
+}
+
+func printInterfaces(out io.Writer, infos []network.InterfaceInfo) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\tINTERFACE\tSTATE\tMAC\tADDRESSES\tGATEWAY")
+	for _, info := range infos {
+		mark := ""
+		if info.Default {
+			mark = "*"
+		}
+		state := "down"
+		if info.Flags&net.FlagUp != 0 {
+			state = "up"
+		}
+		if info.Flags&net.FlagLoopback != 0 {
+			state += ",loopback"
+		}
+		addrs := make([]string, 0, len(info.Addrs))
+		for _, a := range info.Addrs {
+			addrs = append(addrs, a.String())
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", mark, info.Name, state,
+			dashIfEmpty(info.MAC.String()), dashIfEmpty(strings.Join(addrs, ", ")), dashIfEmpty(ipString(info.Gateway)))
+	}
+	w.Flush()
+	fmt.Fprintln(out, "\n* — сеть сканируется по умолчанию (scan без --network); выбрать другой: scan --interface <имя>")
+}
+
+func ipString(ip net.IP) string {
+	if ip == nil {
+		return ""
+	}
+	return ip.String()
+}
+
+func dashIfEmpty(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
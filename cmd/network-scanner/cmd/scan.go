@@ -1,14 +1,18 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"network-scanner/internal/builder"
 	"network-scanner/internal/contracts"
 	"network-scanner/internal/display"
+	apperrors "network-scanner/internal/errors"
 	"network-scanner/internal/gui"
 	"network-scanner/internal/network"
 	"network-scanner/internal/presenter"
@@ -23,6 +27,7 @@ func RunScan(cfg builder.Config, args ...string) error {
 	portRange := "1-1000"
 	timeout := 2
 	threads := 50
+	timeoutSet, threadsSet := false, false
 	showClosed := false
 	scanUDP := false
 	grabBanners := false
@@ -36,8 +41,24 @@ func RunScan(cfg builder.Config, args ...string) error {
 	snmptCommunity := "public"
 	snmptTimeout := 2
 	hostsFile := ""
+	var exclude []string
+	excludeFile := ""
+	excludePorts := ""
+	randomizeHosts := false
+	udpPorts := ""
+	scanType := scanner.ScanTypeConnect
+	sourceIface, sourceIP := "", ""
+	proxyURL := ""
+	var discovery []string
+	enrich := ""
+	lookupTimeoutArg := ""
+	timing := ""
+	maxRate := 0.0
+	maxHostInflight := 0
+	scanDelay := ""
 	exportHTML := false
 	exportXML := false
+	resumeID := ""
 
 	for i := 0; i < len(args); i++ {
 		switch args[i] {
@@ -54,11 +75,13 @@ func RunScan(cfg builder.Config, args ...string) error {
 		case "--timeout", "-t":
 			if i+1 < len(args) {
 				fmt.Sscanf(args[i+1], "%d", &timeout)
+				timeoutSet = true
 				i++
 			}
 		case "--threads":
 			if i+1 < len(args) {
 				fmt.Sscanf(args[i+1], "%d", &threads)
+				threadsSet = true
 				i++
 			}
 		case "--show-closed":
@@ -99,6 +122,88 @@ func RunScan(cfg builder.Config, args ...string) error {
 				hostsFile = args[i+1]
 				i++
 			}
+		case "--exclude":
+			if i+1 < len(args) {
+				exclude = append(exclude, network.SplitTargets(args[i+1])...)
+				i++
+			}
+		case "--exclude-file":
+			if i+1 < len(args) {
+				excludeFile = args[i+1]
+				i++
+			}
+		case "--exclude-ports":
+			if i+1 < len(args) {
+				excludePorts = args[i+1]
+				i++
+			}
+		case "--randomize-hosts":
+			randomizeHosts = true
+		case "--udp-ports":
+			if i+1 < len(args) {
+				udpPorts = args[i+1]
+				i++
+			}
+		case "--scan-type":
+			if i+1 < len(args) {
+				scanType = strings.ToLower(args[i+1])
+				i++
+			}
+		case "--interface", "-i":
+			if i+1 < len(args) {
+				sourceIface = args[i+1]
+				i++
+			}
+		case "--source-ip":
+			if i+1 < len(args) {
+				sourceIP = args[i+1]
+				i++
+			}
+		case "--proxy":
+			if i+1 < len(args) {
+				proxyURL = args[i+1]
+				i++
+			}
+		case "--discovery":
+			if i+1 < len(args) {
+				discovery = strings.Split(strings.ToLower(args[i+1]), ",")
+				i++
+			}
+		case "--enrich":
+			if i+1 < len(args) {
+				enrich = args[i+1]
+				i++
+			}
+		case "--lookup-timeout":
+			if i+1 < len(args) {
+				lookupTimeoutArg = args[i+1]
+				i++
+			}
+		case "--timing":
+			if i+1 < len(args) {
+				timing = strings.ToLower(args[i+1])
+				i++
+			}
+		case "--max-rate":
+			if i+1 < len(args) {
+				fmt.Sscanf(args[i+1], "%g", &maxRate)
+				i++
+			}
+		case "--max-host-inflight":
+			if i+1 < len(args) {
+				fmt.Sscanf(args[i+1], "%d", &maxHostInflight)
+				i++
+			}
+		case "--scan-delay":
+			if i+1 < len(args) {
+				scanDelay = args[i+1]
+				i++
+			}
+		case "--resume":
+			if i+1 < len(args) {
+				resumeID = args[i+1]
+				i++
+			}
 		case "--export-html":
 			exportHTML = true
 		case "--export-xml":
@@ -110,24 +215,103 @@ func RunScan(cfg builder.Config, args ...string) error {
 	var targets []string
 	var err error
 
+	// При продолжении цели и параметры берутся из контрольной точки
+	if resumeID != "" {
+		cp, err := scanner.LoadCheckpoint(scanner.CheckpointPath("", resumeID))
+		if err != nil {
+			if apperrors.IsNotFound(err) {
+				return fmt.Errorf("контрольная точка %s не найдена в %s", resumeID, scanner.DefaultCheckpointDir)
+			}
+			return err
+		}
+		networkCIDR = cp.Config.Network
+		exclude = cp.Config.Exclude
+		excludePorts = cp.Config.ExcludePorts
+		sourceIface, sourceIP = cp.Config.Interface, cp.Config.SourceIP
+		proxyURL = cp.Config.Proxy
+		enrich = cp.Config.Enrich
+		lookupTimeoutArg = cp.Config.LookupTimeout.String()
+		hostsFile, excludeFile = "", ""
+		fmt.Printf("Продолжение сканирования %s: найдено хостов %d, проверено адресов %d\n", resumeID, len(cp.Completed), cp.Issued-len(cp.Pending))
+	}
+
 	if hostsFile != "" {
-		// Чтение целей из файла
+		// Чтение целей из файла: все записи сканируются вместе с --network
 		fmt.Printf("Чтение целей из файла: %s\n", hostsFile)
-		targets, err = network.ParseTargetsFromFile(hostsFile)
+		targets, err = network.ReadTargetsFile(hostsFile)
 		if err != nil {
 			return fmt.Errorf("ошибка чтения файла целей: %w", err)
 		}
 		fmt.Printf("Найдено %d целей в файле\n", len(targets))
+	}
 
-		// Если networkCIDR не указан, используем первый CIDR из файла
-		if networkCIDR == "" && len(targets) > 0 {
-			// Проверяем, есть ли CIDR в файле
-			networkCIDR = targets[0] // Используем первый IP как точку отсчёта
+	if excludeFile != "" {
+		fileExclude, err := network.ReadTargetsFile(excludeFile)
+		if err != nil {
+			return fmt.Errorf("ошибка чтения файла исключений: %w", err)
+		}
+		exclude = append(exclude, fileExclude...)
+	}
+	if err := network.ValidateTargets(exclude); err != nil {
+		return fmt.Errorf("некорректный список исключений: %w", err)
+	}
+
+	if scanType != scanner.ScanTypeConnect && scanType != scanner.ScanTypeSYN {
+		return fmt.Errorf("неизвестный --scan-type %q (ожидается connect или syn)", scanType)
+	}
+	source, err := network.ResolveSource(sourceIface, sourceIP)
+	if err != nil {
+		return fmt.Errorf("некорректный --interface/--source-ip: %w", err)
+	}
+	if proxyURL != "" {
+		if _, err := network.ParseProxyURL(proxyURL); err != nil {
+			return fmt.Errorf("некорректный --proxy: %w", err)
+		}
+	}
+	if udpPorts != "" {
+		if _, err := network.ParsePortRange(udpPorts); err != nil {
+			return fmt.Errorf("некорректный --udp-ports: %w", err)
+		}
+	}
+	if err := scanner.ValidateDiscovery(discovery); err != nil {
+		return fmt.Errorf("некорректный --discovery: %w", err)
+	}
+	if err := scanner.ValidateEnrich(enrich); err != nil {
+		return fmt.Errorf("некорректный --enrich: %w", err)
+	}
+	var lookupTimeout time.Duration
+	if lookupTimeoutArg != "" {
+		if lookupTimeout, err = time.ParseDuration(lookupTimeoutArg); err != nil || lookupTimeout < 0 {
+			return fmt.Errorf("некорректный --lookup-timeout %q (например 3s или 500ms)", lookupTimeoutArg)
+		}
+	}
+	if timing != "" {
+		if _, ok := scanner.LookupTimingTemplate(timing); !ok {
+			return fmt.Errorf("неизвестный --timing %q (ожидается %s)", timing, strings.Join(scanner.TimingTemplateNames(), ", "))
+		}
+		// Незаданные явно таймаут и потоки берутся из профиля
+		if !timeoutSet {
+			timeout = 0
+		}
+		if !threadsSet {
+			threads = 0
+		}
+	}
+	if maxRate < 0 || maxHostInflight < 0 {
+		return fmt.Errorf("--max-rate и --max-host-inflight не могут быть отрицательными")
+	}
+	var probeDelay time.Duration
+	if scanDelay != "" {
+		if probeDelay, err = time.ParseDuration(scanDelay); err != nil || probeDelay < 0 {
+			return fmt.Errorf("некорректный --scan-delay %q (например 50ms или 1s)", scanDelay)
 		}
 	}
 
-	if networkCIDR == "" {
-		auto, err := network.DetectLocalNetwork()
+	if networkCIDR == "" && len(targets) == 0 {
+		if proxyURL != "" {
+			return fmt.Errorf("с --proxy укажите --network или --hosts-file: сеть за прокси не определяется автоматически")
+		}
+		auto, err := network.DetectSourceNetwork(source)
 		if err != nil {
 			return fmt.Errorf("не удалось определить сеть: %w", err)
 		}
@@ -139,23 +323,72 @@ func RunScan(cfg builder.Config, args ...string) error {
 	scannerService := container.GetScanner()
 
 	// Запуск сканирования
-	fmt.Printf("Сканирование сети: %s\n", networkCIDR)
+	scanLabel := strings.Join(append(network.SplitTargets(networkCIDR), targets...), ", ")
+	fmt.Printf("Сканирование сети: %s\n", scanLabel)
+	if !source.IsZero() {
+		fmt.Printf("Источник проб: %s\n", source)
+	}
+	if proxyURL != "" {
+		fmt.Printf("Сканирование через прокси: %s (только TCP)\n", network.RedactProxyURL(proxyURL))
+	}
+	if len(exclude) > 0 || excludePorts != "" {
+		fmt.Printf("Исключения: хосты [%s], порты [%s]\n", strings.Join(exclude, ", "), excludePorts)
+	}
+
+	// Ctrl+C прерывает сканирование; уже найденные хосты всё равно выводятся.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 
-	results, err := scannerService.Scan(nil, contracts.ScanConfig{
-		NetworkCIDR: networkCIDR,
-		PortRange:   portRange,
-		Timeout:     time.Duration(timeout) * time.Second,
-		Threads:     threads,
-		ShowClosed:  showClosed,
-		ScanUDP:     scanUDP,
-		GrabBanners: grabBanners,
-		OSActive:    osDetectActive,
-		VerboseLogs: verboseLogs,
-	}, func(stage string, current, total int, message string) {
+	// Состояние запуска сохраняется в контрольную точку: прерванное сканирование
+	// можно продолжить через --resume.
+	checkpointID := resumeID
+	if checkpointID == "" {
+		checkpointID = "scan-" + time.Now().Format("20060102-150405")
+	}
+	scanCfg := contracts.ScanConfig{
+		NetworkCIDR:     networkCIDR,
+		Targets:         targets,
+		Exclude:         exclude,
+		ExcludePorts:    excludePorts,
+		RandomizeHosts:  randomizeHosts,
+		ScanType:        scanType,
+		Interface:       sourceIface,
+		SourceIP:        sourceIP,
+		Proxy:           proxyURL,
+		Discovery:       discovery,
+		Enrich:          enrich,
+		LookupTimeout:   lookupTimeout,
+		Timing:          timing,
+		MaxRate:         maxRate,
+		MaxHostInflight: maxHostInflight,
+		ScanDelay:       probeDelay,
+		PortRange:       portRange,
+		Timeout:         time.Duration(timeout) * time.Second,
+		Threads:         threads,
+		ShowClosed:      showClosed,
+		ScanUDP:         scanUDP,
+		UDPPorts:        udpPorts,
+		GrabBanners:     grabBanners,
+		OSActive:        osDetectActive,
+		VerboseLogs:     verboseLogs,
+		CheckpointID:    checkpointID,
+		OnHost:          printHostLine,
+	}
+	if resumeID != "" {
+		scanCfg = contracts.ScanConfig{CheckpointID: resumeID, Resume: true, OnHost: printHostLine}
+	}
+	results, err := scannerService.Scan(ctx, scanCfg, func(stage string, current, total int, message string) {
 		fmt.Printf("[%s] %s: %d/%d\n", stage, message, current, total)
 	})
 	if err != nil {
-		return fmt.Errorf("сканирование завершено ошибкой: %w", err)
+		interrupted := apperrors.IsCancelled(err) || apperrors.IsTimeout(err)
+		if interrupted {
+			fmt.Fprintf(os.Stderr, "Продолжить сканирование: network-scanner scan --resume %s\n", checkpointID)
+		}
+		if !interrupted || len(results) == 0 {
+			return fmt.Errorf("сканирование завершено ошибкой: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Сканирование прервано (%v), показаны частичные результаты\n", err)
 	}
 
 	// Вывод результатов
@@ -198,16 +431,26 @@ func RunScan(cfg builder.Config, args ...string) error {
 		if inventoryID == "" {
 			inventoryID = fmt.Sprintf("scan-%d", time.Now().Unix())
 		}
-		if err := RunInventorySave(cfg, results, inventoryID); err != nil {
+		meta := contracts.ScanMetadata{
+			Targets:      append(network.SplitTargets(networkCIDR), targets...),
+			Exclude:      exclude,
+			ExcludePorts: excludePorts,
+		}
+		if proxyURL != "" {
+			meta.Proxy = network.RedactProxyURL(proxyURL)
+		}
+		if err := RunInventorySave(cfg, results, inventoryID, meta); err != nil {
 			fmt.Fprintf(os.Stderr, "Inventory save error: %v\n", err)
 		}
 	}
 
-	if runSNMP {
+	if runSNMP && proxyURL != "" {
+		fmt.Fprintln(os.Stderr, "SNMP через прокси недоступен (только TCP), опрос пропущен")
+	} else if runSNMP {
 		fmt.Println("SNMP опрос устройств...")
 		communities := []string{snmptCommunity}
 		devices := convertToScannerResults(results)
-		snmpDevices, report, err := snmpcollector.CollectWithReport(devices, communities, snmptTimeout)
+		snmpDevices, report, err := snmpcollector.CollectFromContext(context.Background(), source, devices, communities, snmptTimeout, nil)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "SNMP error: %v\n", err)
 		} else {
@@ -224,6 +467,25 @@ func RunScan(cfg builder.Config, args ...string) error {
 	return nil
 }
 
+// printHostLine печатает краткую строку по хосту сразу после завершения его сканирования.
+func printHostLine(r contracts.ScanResult) {
+	open := make([]string, 0, len(r.Ports))
+	for _, p := range r.Ports {
+		if p.State == "open" {
+			open = append(open, fmt.Sprintf("%d/%s", p.Port, p.Protocol))
+		}
+	}
+	name := r.Hostname
+	if name == "" {
+		name = "-"
+	}
+	method := ""
+	if r.DiscoveryMethod != "" {
+		method = " [" + r.DiscoveryMethod + "]"
+	}
+	fmt.Printf("[host] %s (%s)%s открытые порты: %s\n", r.IP, name, method, strings.Join(open, ", "))
+}
+
 // convertToScannerResults конвертирует contracts.ScanResult в scanner.Result
 func convertToScannerResults(results []contracts.ScanResult) []scanner.Result {
 	out := make([]scanner.Result, 0, len(results))
@@ -237,16 +499,31 @@ func convertToScannerResults(results []contracts.ScanResult) []scanner.Result {
 				Service:  p.Service,
 				Banner:   p.Banner,
 				Version:  p.Version,
+				Product:  p.Product,
+				Info:     p.Info,
+				CPE:      p.CPE,
+				TLS:      scanner.FromContractTLS(p.TLS),
+				SSH:      scanner.FromContractSSH(p.SSH),
+				HTTP:     scanner.FromContractHTTP(p.HTTP),
+				SMB:      scanner.FromContractSMB(p.SMB),
+				Reason:   p.Reason,
+				Latency:  p.Latency,
 			})
 		}
 		out = append(out, scanner.Result{
-			IP:           r.IP,
-			Hostname:     r.Hostname,
-			MAC:          r.MAC,
-			Ports:        ports,
-			DeviceType:   r.DeviceType,
-			DeviceVendor: r.DeviceVendor,
-			GuessOS:      r.GuessOS,
+			IP:              r.IP,
+			Hostname:        r.Hostname,
+			MAC:             r.MAC,
+			Ports:           ports,
+			DeviceType:      r.DeviceType,
+			DeviceVendor:    r.DeviceVendor,
+			GuessOS:         r.GuessOS,
+			DiscoveryMethod: r.DiscoveryMethod,
+			Addresses:       r.Addresses,
+			Via:             r.Via,
+			NetBIOS:         scanner.FromContractNetBIOS(r.NetBIOS),
+			MDNS:            scanner.FromContractMDNS(r.MDNS),
+			UPnP:            scanner.FromContractUPnP(r.UPnP),
 		})
 	}
 	return out
@@ -270,6 +547,11 @@ func ExecuteCLI() {
 			fmt.Fprintf(os.Stderr, "Ошибка: %v\n", err)
 			os.Exit(1)
 		}
+	case "interfaces":
+		if err := RunInterfaces(); err != nil {
+			fmt.Fprintf(os.Stderr, "Ошибка: %v\n", err)
+			os.Exit(1)
+		}
 	case "security":
 		fmt.Println("Security: требуется результат сканирования (используйте --security в scan)")
 	case "topology":
@@ -306,7 +588,7 @@ func ExecuteCLI() {
 			}
 		case "save":
 			var results []contracts.ScanResult
-			if err := RunInventorySave(cfg, results, "manual"); err != nil {
+			if err := RunInventorySave(cfg, results, "manual", contracts.ScanMetadata{}); err != nil {
 				fmt.Fprintf(os.Stderr, "Ошибка: %v\n", err)
 				os.Exit(1)
 			}
@@ -335,14 +617,21 @@ func printUsage() {
 	fmt.Println("  remote-exec      Удалённое выполнение команд")
 	fmt.Println("  device-control   Управление устройствами")
 	fmt.Println("  inventory        Управление инвентаризацией (list|diff|save)")
+	fmt.Println("  interfaces       Сетевые интерфейсы: адреса, шлюзы, сеть по умолчанию")
 	fmt.Println()
 	fmt.Println("Scan options:")
-	fmt.Println("  --network        CIDR сеть (например, 192.168.1.0/24)")
+	fmt.Println("  --network        Цели через запятую: CIDR, диапазоны, IP, имена (например, 192.168.1.0/24,10.0.0.5-20)")
 	fmt.Println("  --ports          Диапазон портов (по умолчанию 1-1000)")
 	fmt.Println("  --timeout        Таймаут в секундах (по умолчанию 2)")
 	fmt.Println("  --threads        Количество потоков (по умолчанию 50)")
+	fmt.Println("  --timing         Профиль скорости: polite, normal, aggressive (задаёт таймаут, потоки и лимиты,")
+	fmt.Println("                   если они не указаны явно)")
+	fmt.Println("  --max-rate       Не более N проб в секунду на весь запуск")
+	fmt.Println("  --max-host-inflight  Не более N одновременных проб к одному хосту")
+	fmt.Println("  --scan-delay     Пауза между пробами к одному хосту (например 100ms)")
 	fmt.Println("  --show-closed    Показывать закрытые порты")
 	fmt.Println("  --udp            Включить UDP сканирование")
+	fmt.Println("  --udp-ports      UDP-порты для --udp (по умолчанию 53,67-69,123,137,161-162,500,514,1194,1900,5353)")
 	fmt.Println("  --grab-banners   Собирать баннеры")
 	fmt.Println("  --os-detect-active   Активные эвристики ОС")
 	fmt.Println("  --verbose-port-logs  Детальные логи по портам")
@@ -353,7 +642,28 @@ func printUsage() {
 	fmt.Println("  --snmp           Включить SNMP опрос устройств")
 	fmt.Println("  --snmp-community SNMP community (по умолчанию public)")
 	fmt.Println("  --snmp-timeout   Таймаут SNMP в секундах (по умолчанию 2)")
-	fmt.Println("  --hosts-file     Файл с целями (IP, CIDR, ranges)")
+	fmt.Println("  --hosts-file     Файл с целями (IP, CIDR, ranges, hostnames)")
+	fmt.Println("  --randomize-hosts  Проверять адреса в случайном порядке (нагрузка распределяется по подсетям)")
+	fmt.Println("  --resume         Продолжить прерванное сканирование по ID (параметры берутся из контрольной точки)")
+	fmt.Println("  --exclude        Не сканировать цели (тот же формат, что у --network)")
+	fmt.Println("  --exclude-file   Файл с исключаемыми целями (формат --hosts-file)")
+	fmt.Println("  --exclude-ports  Не опрашивать порты (например 23,135-139)")
+	fmt.Println("  --scan-type      connect (по умолчанию) или syn — half-open, нужны права root/CAP_NET_RAW;")
+	fmt.Println("                   без прав выполняется connect-сканирование")
+	fmt.Println("  --interface, -i  Интерфейс, с которого уходят все пробы (см. network-scanner interfaces);")
+	fmt.Println("                   без --network сканируется его сеть")
+	fmt.Println("  --source-ip      Адрес источника проб (должен быть назначен интерфейсу)")
+	fmt.Println("  --proxy          TCP-пробы, баннеры и TLS через прокси: socks5://[user:pass@]host[:1080]")
+	fmt.Println("                   или ssh://user@bastion[:22][?key=<ключ>]; обнаружение — TCP connect,")
+	fmt.Println("                   UDP, SNMP и MAC недоступны")
+	fmt.Println("  --discovery      Способы обнаружения хостов по порядку, например arp,icmp,tcp")
+	fmt.Println("                   (по умолчанию tcp; arp — только подключённые подсети, нужны права root/CAP_NET_RAW;")
+	fmt.Println("                   ndp — IPv6-соседи: echo на ff02::1 и кэш соседей;")
+	fmt.Println("                   mdns — устройства, объявившие службы mDNS/DNS-SD;")
+	fmt.Println("                   ssdp — устройства UPnP, ответившие на SSDP M-SEARCH)")
+	fmt.Println("  --enrich         Стадии обогащения хостов (" + strings.Join(scanner.EnricherNames(), ", ") + "):")
+	fmt.Println("                   -имя выключает стадию, имя=1s задаёт её таймаут, например -hostname,snmp=1s")
+	fmt.Println("  --lookup-timeout Предел завершающего прохода MAC и имён после сканирования портов (по умолчанию 5s)")
 	fmt.Println("  --export-html    Экспорт результатов в HTML")
 	fmt.Println("  --export-xml     Экспорт результатов в XML")
 	fmt.Println()
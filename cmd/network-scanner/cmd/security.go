@@ -23,6 +23,10 @@ func RunSecurity(cfg builder.Config, results []contracts.ScanResult) error {
 	fmt.Println("=== Security Report ===")
 	fmt.Printf("Security Score: %d/100\n", report.Score)
 	fmt.Printf("Port Audit Findings: %d\n", len(report.PortAudit))
+	fmt.Printf("TLS Audit Findings: %d\n", len(report.TLSAudit))
+	fmt.Printf("SSH Audit Findings: %d\n", len(report.SSHAudit))
+	fmt.Printf("SMB Audit Findings: %d\n", len(report.SMBAudit))
+	fmt.Printf("UPnP Audit Findings: %d\n", len(report.UPnPAudit))
 	fmt.Printf("Risk Signature Findings: %d\n", len(report.RiskSig))
 
 	if len(report.PortAudit) > 0 {
@@ -35,6 +39,46 @@ func RunSecurity(cfg builder.Config, results []contracts.ScanResult) error {
 		}
 	}
 
+	if len(report.TLSAudit) > 0 {
+		fmt.Println("\n--- TLS Audit ---")
+		for _, f := range report.TLSAudit {
+			fmt.Printf("[%s] %s (host: %s)\n", f.Severity, f.Title, f.Host)
+			if f.Recommendation != "" {
+				fmt.Printf("  Recommendation: %s\n", f.Recommendation)
+			}
+		}
+	}
+
+	if len(report.SSHAudit) > 0 {
+		fmt.Println("\n--- SSH Audit ---")
+		for _, f := range report.SSHAudit {
+			fmt.Printf("[%s] %s (host: %s)\n", f.Severity, f.Title, f.Host)
+			if f.Recommendation != "" {
+				fmt.Printf("  Recommendation: %s\n", f.Recommendation)
+			}
+		}
+	}
+
+	if len(report.SMBAudit) > 0 {
+		fmt.Println("\n--- SMB Audit ---")
+		for _, f := range report.SMBAudit {
+			fmt.Printf("[%s] %s (host: %s)\n", f.Severity, f.Title, f.Host)
+			if f.Recommendation != "" {
+				fmt.Printf("  Recommendation: %s\n", f.Recommendation)
+			}
+		}
+	}
+
+	if len(report.UPnPAudit) > 0 {
+		fmt.Println("\n--- UPnP Audit ---")
+		for _, f := range report.UPnPAudit {
+			fmt.Printf("[%s] %s (host: %s)\n", f.Severity, f.Title, f.Host)
+			if f.Recommendation != "" {
+				fmt.Printf("  Recommendation: %s\n", f.Recommendation)
+			}
+		}
+	}
+
 	if len(report.RiskSig) > 0 {
 		fmt.Println("\n--- Risk Signatures ---")
 		for _, f := range report.RiskSig {
@@ -115,12 +159,12 @@ func RunRemoteExec(cfg builder.Config, transport, target, user, pass, command st
 	return nil
 }
 
-// RunInventorySave сохраняет снапшот инвентаризации
-func RunInventorySave(cfg builder.Config, results []contracts.ScanResult, id string) error {
+// RunInventorySave сохраняет снапшот инвентаризации вместе с параметрами сканирования
+func RunInventorySave(cfg builder.Config, results []contracts.ScanResult, id string, meta contracts.ScanMetadata) error {
 	container := builder.NewContainer(cfg)
 	inventoryService := container.GetInventory()
 
-	if err := inventoryService.SaveSnapshot(context.Background(), id, results); err != nil {
+	if err := inventoryService.SaveSnapshotWithMetadata(context.Background(), id, results, meta); err != nil {
 		return fmt.Errorf("сохранение снапшота: %w", err)
 	}
 
@@ -141,6 +185,7 @@ func RunInventoryDiff(cfg builder.Config, idA, idB string) error {
 	fmt.Printf("Inventory diff: %s -> %s\n", diff.ScanIDA, diff.ScanIDB)
 	fmt.Printf("- New: %d\n", len(diff.New))
 	fmt.Printf("- Missing: %d\n", len(diff.Missing))
+	fmt.Printf("- Excluded: %d\n", len(diff.Excluded))
 	fmt.Printf("- Changed: %d\n", len(diff.Changed))
 
 	for _, c := range diff.Changed {
@@ -181,16 +226,31 @@ func ConvertToInternalResults(results []contracts.ScanResult) []scanner.Result {
 				Service:  p.Service,
 				Banner:   p.Banner,
 				Version:  p.Version,
+				Product:  p.Product,
+				Info:     p.Info,
+				CPE:      p.CPE,
+				TLS:      scanner.FromContractTLS(p.TLS),
+				SSH:      scanner.FromContractSSH(p.SSH),
+				HTTP:     scanner.FromContractHTTP(p.HTTP),
+				SMB:      scanner.FromContractSMB(p.SMB),
+				Reason:   p.Reason,
+				Latency:  p.Latency,
 			})
 		}
 		out = append(out, scanner.Result{
-			IP:           r.IP,
-			Hostname:     r.Hostname,
-			MAC:          r.MAC,
-			Ports:        ports,
-			DeviceType:   r.DeviceType,
-			DeviceVendor: r.DeviceVendor,
-			GuessOS:      r.GuessOS,
+			IP:              r.IP,
+			Hostname:        r.Hostname,
+			MAC:             r.MAC,
+			Ports:           ports,
+			DeviceType:      r.DeviceType,
+			DeviceVendor:    r.DeviceVendor,
+			GuessOS:         r.GuessOS,
+			DiscoveryMethod: r.DiscoveryMethod,
+			Addresses:       r.Addresses,
+			Via:             r.Via,
+			NetBIOS:         scanner.FromContractNetBIOS(r.NetBIOS),
+			MDNS:            scanner.FromContractMDNS(r.MDNS),
+			UPnP:            scanner.FromContractUPnP(r.UPnP),
 		})
 	}
 	return out
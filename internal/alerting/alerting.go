@@ -10,6 +10,7 @@ import (
 
 	"network-scanner/internal/comparator"
 	"network-scanner/internal/scanner"
+	"network-scanner/internal/tlsinspect"
 )
 
 // Severity уровень алерта
@@ -42,6 +43,7 @@ const (
 	RuleTypeDeviceRemoved RuleType = "device_removed"
 	RuleTypeOSChanged     RuleType = "os_changed"
 	RuleTypeHostnameChanged RuleType = "hostname_changed"
+	RuleTypeCertExpiring  RuleType = "cert_expiring"
 )
 
 // Alert предупреждение
@@ -64,6 +66,8 @@ type Engine struct {
 	alerts   []Alert
 	logFile  string
 	handlers []AlertHandler
+	// certExpiryWindow — за сколько до окончания действия сертификата TLS поднимается алерт
+	certExpiryWindow time.Duration
 }
 
 // AlertHandler обработчик алертов
@@ -90,6 +94,7 @@ func NewEngine(logFile string) *Engine {
 			&FileHandler{Path: logFile},
 			&ConsoleHandler{},
 		},
+		certExpiryWindow: tlsinspect.ExpiryWarning,
 	}
 }
 
@@ -144,6 +149,14 @@ func defaultRules() []Rule {
 			Enabled:     true,
 			Description: "Alert when device hostname changes",
 		},
+		{
+			ID:          "rule-007",
+			Name:        "TLS Certificate Expiring",
+			Type:        RuleTypeCertExpiring,
+			Severity:    SeverityHigh,
+			Enabled:     true,
+			Description: "Alert when a TLS certificate has expired or expires within 30 days",
+		},
 	}
 }
 
@@ -246,6 +259,11 @@ func (e *Engine) CheckAlerts(oldHosts, newHosts []scanner.Result) []Alert {
 		}
 	}
 
+	// Проверка сроков сертификатов TLS
+	if e.isRuleEnabled(RuleTypeCertExpiring) {
+		alerts = append(alerts, e.certExpiryAlerts(newHosts, time.Now())...)
+	}
+
 	// Сохранение алертов
 	e.alerts = append(e.alerts, alerts...)
 
@@ -261,6 +279,35 @@ func (e *Engine) CheckAlerts(oldHosts, newHosts []scanner.Result) []Alert {
 	return alerts
 }
 
+// certExpiryAlerts создаёт алерты по сертификатам TLS текущего снимка, которые истекли
+// или истекают в пределах certExpiryWindow к моменту now.
+func (e *Engine) certExpiryAlerts(hosts []scanner.Result, now time.Time) []Alert {
+	alerts := make([]Alert, 0)
+	for _, host := range hosts {
+		for _, p := range host.Ports {
+			leaf := p.TLS.Leaf()
+			if leaf == nil {
+				continue
+			}
+			var message string
+			switch {
+			case leaf.Expired(now):
+				message = fmt.Sprintf("TLS certificate %s on %s:%d/%s expired on %s",
+					leaf.Subject, host.IP, p.Port, p.Protocol, leaf.NotAfter.Format("2006-01-02"))
+			case leaf.ExpiresWithin(now, e.certExpiryWindow):
+				message = fmt.Sprintf("TLS certificate %s on %s:%d/%s expires in %d days (%s)",
+					leaf.Subject, host.IP, p.Port, p.Protocol, int(leaf.NotAfter.Sub(now).Hours()/24), leaf.NotAfter.Format("2006-01-02"))
+			default:
+				continue
+			}
+			alert := e.createAlert("rule-007", "TLS Certificate Expiring", SeverityHigh, message, host.IP, p.Port)
+			alert.Data = leaf.SHA256
+			alerts = append(alerts, alert)
+		}
+	}
+	return alerts
+}
+
 // createAlert создаёт новое предупреждение
 func (e *Engine) createAlert(ruleID, ruleName string, severity Severity, message, host string, port int) Alert {
 	return Alert{
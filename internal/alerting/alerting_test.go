@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"network-scanner/internal/scanner"
+	"network-scanner/internal/tlsinspect"
 )
 
 func TestNewEngine(t *testing.T) {
@@ -13,8 +14,8 @@ func TestNewEngine(t *testing.T) {
 	if engine == nil {
 		t.Fatal("expected non-nil engine")
 	}
-	if len(engine.rules) != 6 {
-		t.Errorf("expected 6 default rules, got %d", len(engine.rules))
+	if len(engine.rules) != 7 {
+		t.Errorf("expected 7 default rules, got %d", len(engine.rules))
 	}
 }
 
@@ -109,6 +110,38 @@ func TestCheckAlerts_NoChanges(t *testing.T) {
 	}
 }
 
+func TestCheckAlerts_CertExpiring(t *testing.T) {
+	engine := NewEngine(t.TempDir() + "/alerts.log")
+
+	now := time.Now()
+	withCert := func(port int, notAfter time.Time) scanner.PortInfo {
+		return scanner.PortInfo{Port: port, Protocol: "tcp", State: "open", TLS: &tlsinspect.Info{
+			Chain: []tlsinspect.Certificate{{Subject: "CN=host", NotAfter: notAfter, SHA256: "ab"}},
+		}}
+	}
+	hosts := []scanner.Result{
+		{IP: "192.168.1.1", Ports: []scanner.PortInfo{
+			withCert(443, now.AddDate(0, 0, 10)),
+			withCert(8443, now.AddDate(0, 0, -1)),
+			withCert(9443, now.AddDate(1, 0, 0)),
+			{Port: 80, Protocol: "tcp", State: "open"},
+		}},
+	}
+
+	alerts := engine.CheckAlerts(hosts, hosts)
+
+	ports := map[int]bool{}
+	for _, alert := range alerts {
+		if alert.RuleID != "rule-007" || alert.Severity != SeverityHigh || alert.Host != "192.168.1.1" || alert.Data != "ab" {
+			t.Errorf("unexpected alert: %+v", alert)
+		}
+		ports[alert.Port] = true
+	}
+	if len(alerts) != 2 || !ports[443] || !ports[8443] {
+		t.Errorf("expected expiry alerts for 443 and 8443, got %+v", alerts)
+	}
+}
+
 func TestGetAlertsBySeverity(t *testing.T) {
 	engine := NewEngine("")
 
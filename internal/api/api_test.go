@@ -5,7 +5,13 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
+
+	"network-scanner/internal/contracts"
+	"network-scanner/internal/mock"
 )
 
 func TestHandleHealth(t *testing.T) {
@@ -33,7 +39,9 @@ func TestHandleHealth(t *testing.T) {
 
 func TestHandleScan(t *testing.T) {
 	cfg := DefaultConfig()
-	router := NewRouter(cfg)
+	cfg.CheckpointDir = t.TempDir()
+	scans := mock.NewMockScannerService()
+	router := NewRouter(cfg, WithScanService(scans))
 
 	body, _ := json.Marshal(map[string]interface{}{
 		"network": "192.168.1.0/24",
@@ -58,6 +66,51 @@ func TestHandleScan(t *testing.T) {
 	if resp.Status != "running" {
 		t.Errorf("expected status 'running', got %s", resp.Status)
 	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for scans.ScanCallCount() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if scans.ScanCallCount() != 1 {
+		t.Errorf("expected 1 scan call, got %d", scans.ScanCallCount())
+	}
+}
+
+func TestHandleScan_FinalResults(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.CheckpointDir = t.TempDir()
+	scans := mock.NewMockScannerService()
+	scans.SetResults([]contracts.ScanResult{{IP: "10.0.0.1", MAC: "aa:bb:cc:dd:ee:ff", Hostname: "nas"}})
+	router := NewRouter(cfg, WithScanService(scans))
+
+	body, _ := json.Marshal(map[string]interface{}{"network": "10.0.0.1"})
+	req := httptest.NewRequest("POST", "/api/v1/scan", bytes.NewBuffer(body))
+	w := httptest.NewRecorder()
+	router.GetRouter().ServeHTTP(w, req)
+	var started scanResponse
+	if err := json.NewDecoder(w.Body).Decode(&started); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	var resp struct {
+		Status  string                 `json:"status"`
+		Results []contracts.ScanResult `json:"results"`
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for resp.Status != "completed" && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		w = httptest.NewRecorder()
+		router.GetRouter().ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/scan/"+started.ID+"/results", nil))
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode results: %v", err)
+		}
+	}
+	if resp.Status != "completed" {
+		t.Fatalf("expected status 'completed', got %q", resp.Status)
+	}
+	if len(resp.Results) != 1 || resp.Results[0].MAC != "aa:bb:cc:dd:ee:ff" || resp.Results[0].Hostname != "nas" {
+		t.Errorf("expected final scan results, got %+v", resp.Results)
+	}
 }
 
 func TestHandleScan_MissingNetwork(t *testing.T) {
@@ -79,6 +132,159 @@ func TestHandleScan_MissingNetwork(t *testing.T) {
 	}
 }
 
+func TestHandleScan_InvalidTargets(t *testing.T) {
+	cfg := DefaultConfig()
+	router := NewRouter(cfg)
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"targets":    []string{"10.0.0.0/24", "192.168.1.20-10"},
+		"port_range": "80",
+	})
+
+	req := httptest.NewRequest("POST", "/api/v1/scan", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.GetRouter().ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", w.Code)
+	}
+}
+
+func TestHandleScan_InvalidExclusions(t *testing.T) {
+	cfg := DefaultConfig()
+	router := NewRouter(cfg)
+
+	for _, payload := range []map[string]interface{}{
+		{"network": "10.0.0.0/24", "exclude": []string{"10.0.0.300"}},
+		{"network": "10.0.0.0/24", "exclude_ports": "abc"},
+	} {
+		body, _ := json.Marshal(payload)
+		req := httptest.NewRequest("POST", "/api/v1/scan", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		router.GetRouter().ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("payload %v: expected status 400, got %d", payload, w.Code)
+		}
+	}
+}
+
+func TestHandleScan_InvalidSource(t *testing.T) {
+	cfg := DefaultConfig()
+	router := NewRouter(cfg)
+
+	for _, payload := range []map[string]interface{}{
+		{"network": "10.0.0.0/24", "interface": "no-such-iface0"},
+		{"network": "10.0.0.0/24", "source_ip": "not-an-ip"},
+		{"network": "10.0.0.0/24", "source_ip": "198.51.100.254"},
+	} {
+		body, _ := json.Marshal(payload)
+		req := httptest.NewRequest("POST", "/api/v1/scan", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		router.GetRouter().ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("payload %v: expected status 400, got %d", payload, w.Code)
+		}
+	}
+}
+
+func TestHandleScan_InvalidProxy(t *testing.T) {
+	cfg := DefaultConfig()
+	router := NewRouter(cfg)
+
+	for _, proxy := range []string{"http://10.0.0.1:3128", "ssh://bastion.example", "socks5://:1080", "socks5://proxy?key=id_rsa"} {
+		body, _ := json.Marshal(map[string]interface{}{"network": "10.0.0.0/24", "proxy": proxy})
+		req := httptest.NewRequest("POST", "/api/v1/scan", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		router.GetRouter().ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("proxy %q: expected status 400, got %d", proxy, w.Code)
+		}
+	}
+}
+
+func TestHandleScan_InvalidEnrich(t *testing.T) {
+	cfg := DefaultConfig()
+	router := NewRouter(cfg)
+
+	for _, enrich := range []string{"-dns", "snmp=fast", "mac=-1s"} {
+		body, _ := json.Marshal(map[string]interface{}{"network": "10.0.0.0/24", "enrich": enrich})
+		req := httptest.NewRequest("POST", "/api/v1/scan", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		router.GetRouter().ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("enrich %q: expected status 400, got %d", enrich, w.Code)
+		}
+	}
+}
+
+func TestHandleScan_InvalidDiscovery(t *testing.T) {
+	cfg := DefaultConfig()
+	router := NewRouter(cfg)
+
+	for _, discovery := range [][]string{{"udp"}, {"tcp", "llmnr"}} {
+		body, _ := json.Marshal(map[string]interface{}{"network": "10.0.0.0/24", "discovery": discovery})
+		req := httptest.NewRequest("POST", "/api/v1/scan", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		router.GetRouter().ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("discovery %q: expected status 400, got %d", discovery, w.Code)
+		}
+	}
+}
+
+func TestHandleScan_InvalidTiming(t *testing.T) {
+	cfg := DefaultConfig()
+	router := NewRouter(cfg)
+
+	for _, timing := range []string{"insane", "paranoid"} {
+		body, _ := json.Marshal(map[string]interface{}{"network": "10.0.0.0/24", "timing": timing})
+		req := httptest.NewRequest("POST", "/api/v1/scan", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		router.GetRouter().ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("timing %q: expected status 400, got %d", timing, w.Code)
+		}
+	}
+}
+
+func TestHandleInterfaces(t *testing.T) {
+	cfg := DefaultConfig()
+	router := NewRouter(cfg)
+
+	req := httptest.NewRequest("GET", "/api/v1/interfaces", nil)
+	w := httptest.NewRecorder()
+
+	router.GetRouter().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	var ifaces []interfaceResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &ifaces); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+}
+
 func TestHandleScanStatus_NotFound(t *testing.T) {
 	cfg := DefaultConfig()
 	router := NewRouter(cfg)
@@ -93,6 +299,39 @@ func TestHandleScanStatus_NotFound(t *testing.T) {
 	}
 }
 
+func TestHandleScanResume_NotFound(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.CheckpointDir = t.TempDir()
+	router := NewRouter(cfg)
+
+	req := httptest.NewRequest("POST", "/api/v1/scan/scan-missing/resume", nil)
+	w := httptest.NewRecorder()
+
+	router.GetRouter().ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", w.Code)
+	}
+}
+
+func TestHandleScanResume_CheckpointDir(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.CheckpointDir = t.TempDir()
+	if err := os.WriteFile(filepath.Join(cfg.CheckpointDir, "scan-saved.json"), []byte(`{"id":"scan-saved"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	router := NewRouter(cfg, WithScanService(mock.NewMockScannerService()))
+
+	req := httptest.NewRequest("POST", "/api/v1/scan/scan-saved/resume", nil)
+	w := httptest.NewRecorder()
+
+	router.GetRouter().ServeHTTP(w, req)
+
+	if w.Code != http.StatusAccepted {
+		t.Errorf("expected status 202, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
 func TestHandleInventoryList(t *testing.T) {
 	cfg := DefaultConfig()
 	router := NewRouter(cfg)
@@ -139,4 +378,4 @@ func TestCorsMiddleware(t *testing.T) {
 	if w.Header().Get("Access-Control-Allow-Origin") == "" {
 		t.Error("expected Access-Control-Allow-Origin header")
 	}
-}
+}
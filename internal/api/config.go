@@ -15,6 +15,7 @@ type Config struct {
 	AllowedOrigins []string
 	RateLimitPerSecond int
 	InventoryPath  string
+	CheckpointDir  string
 }
 
 // DefaultConfig РІРѕР·РІСЂР°С‰Р°РµС‚ РєРѕРЅС„РёРіСѓСЂР°С†РёСЋ РїРѕ СѓРјРѕР»С‡Р°РЅРёСЋ
@@ -29,6 +30,7 @@ func DefaultConfig() Config {
 		AllowedOrigins:     []string{"http://localhost:3000", "http://localhost:8080"},
 		RateLimitPerSecond: 10,
 		InventoryPath:  "inventory.db",
+		CheckpointDir:  "checkpoints",
 	}
 }
 
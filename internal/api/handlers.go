@@ -4,16 +4,37 @@ import (
 	"encoding/json"
 	"net/http"
 	"time"
+
+	"network-scanner/internal/contracts"
+	"network-scanner/internal/scanner"
 )
 
 // Handler оборачивает HTTP handler с общей логикой
 type Handler struct {
-	config Config
+	config      Config
+	scanService contracts.ScannerService
+}
+
+// HandlerOption настраивает Handler.
+type HandlerOption func(*Handler)
+
+// WithScanService задаёт сервис сканирования вместо сканера по умолчанию.
+func WithScanService(s contracts.ScannerService) HandlerOption {
+	return func(h *Handler) {
+		h.scanService = s
+	}
 }
 
 // NewHandler создаёт новый Handler
-func NewHandler(config Config) *Handler {
-	return &Handler{config: config}
+func NewHandler(config Config, opts ...HandlerOption) *Handler {
+	h := &Handler{
+		config:      config,
+		scanService: scanner.NewService("info"),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
 // writeJSON записывает JSON ответ
@@ -46,7 +67,10 @@ func (h *Handler) handleDocs(w http.ResponseWriter, r *http.Request) {
 		"endpoints": []string{
 			"POST /api/v1/scan - Запустить сканирование",
 			"GET /api/v1/scan/{id} - Статус сканирования",
+			"GET /api/v1/scan/{id}/results - Хосты, готовые на текущий момент",
+			"POST /api/v1/scan/{id}/resume - Продолжить прерванное сканирование",
 			"GET /api/v1/results - Получить результаты",
+			"GET /api/v1/interfaces - Сетевые интерфейсы (для interface/source_ip)",
 			"GET /api/v1/inventory - Список снапшотов",
 			"POST /api/v1/inventory - Сохранить снапшот",
 			"GET /api/v1/inventory/{id}/diff - Сравнить снапшоты",
@@ -14,8 +14,8 @@ type Router struct {
 }
 
 // NewRouter создаёт новый Router
-func NewRouter(config Config) *Router {
-	h := NewHandler(config)
+func NewRouter(config Config, opts ...HandlerOption) *Router {
+	h := NewHandler(config, opts...)
 	r := &Router{
 		router:  mux.NewRouter(),
 		config:  config,
@@ -42,7 +42,10 @@ func (r *Router) setupRoutes() {
 	// Routes
 	api.HandleFunc("/scan", r.handler.handleScan).Methods("POST")
 	api.HandleFunc("/scan/{id}", r.handler.handleScanStatus).Methods("GET")
+	api.HandleFunc("/scan/{id}/results", r.handler.handleScanResults).Methods("GET")
+	api.HandleFunc("/scan/{id}/resume", r.handler.handleScanResume).Methods("POST")
 	api.HandleFunc("/results", r.handler.handleResults).Methods("GET")
+	api.HandleFunc("/interfaces", r.handler.handleInterfaces).Methods("GET")
 	api.HandleFunc("/inventory", r.handler.handleInventoryList).Methods("GET")
 	api.HandleFunc("/inventory", r.handler.handleInventorySave).Methods("POST")
 	api.HandleFunc("/inventory/{id}/diff", r.handler.handleInventoryDiff).Methods("GET")
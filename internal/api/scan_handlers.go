@@ -1,27 +1,49 @@
 ﻿package api
 
 import (
+	"context"
 	"encoding/json"
+	"net"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/gorilla/mux"
 	"network-scanner/internal/contracts"
+	apperrors "network-scanner/internal/errors"
+	"network-scanner/internal/network"
+	"network-scanner/internal/scanner"
 )
 
 // scanRequest запрос на сканирование
 type scanRequest struct {
-	NetworkCIDR  string            `json:"network"`
-	PortRange    string            `json:"port_range"`
-	Timeout      int               `json:"timeout"`
-	Threads      int               `json:"threads"`
-	ScanUDP      bool              `json:"scan_udp"`
-	GrabBanners  bool              `json:"grab_banners"`
-	OSActive     bool              `json:"os_active"`
-	VerboseLogs  bool              `json:"verbose_logs"`
-	Security     bool              `json:"security"`
-	Topology     bool              `json:"topology"`
+	NetworkCIDR     string   `json:"network"`
+	Targets         []string `json:"targets"`
+	Exclude         []string `json:"exclude"`
+	ExcludePorts    string   `json:"exclude_ports"`
+	RandomizeHosts  bool     `json:"randomize_hosts"`
+	ScanType        string   `json:"scan_type"`
+	Interface       string   `json:"interface"`
+	SourceIP        string   `json:"source_ip"`
+	Proxy           string   `json:"proxy"`
+	Discovery       []string `json:"discovery"`
+	PortRange       string   `json:"port_range"`
+	Timeout         int      `json:"timeout"`
+	Threads         int      `json:"threads"`
+	ScanUDP         bool     `json:"scan_udp"`
+	UDPPorts        string   `json:"udp_ports"`
+	Timing          string   `json:"timing"`
+	MaxRate         float64  `json:"max_rate"`
+	MaxHostInflight int      `json:"max_host_inflight"`
+	ScanDelayMs     int      `json:"scan_delay_ms"`
+	GrabBanners     bool     `json:"grab_banners"`
+	OSActive        bool     `json:"os_active"`
+	VerboseLogs     bool     `json:"verbose_logs"`
+	Enrich          string   `json:"enrich"`
+	LookupTimeoutMs int      `json:"lookup_timeout_ms"`
+	Security        bool     `json:"security"`
+	Topology        bool     `json:"topology"`
 }
 
 // scanResponse ответ на сканирование
@@ -65,6 +87,36 @@ var scanStoreInstance = &scanStore{
 	scans: make(map[string]*scanState),
 }
 
+// update применяет fn к состоянию сканирования под блокировкой.
+func (s *scanStore) update(id string, fn func(*scanState)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if st, ok := s.scans[id]; ok {
+		fn(st)
+	}
+}
+
+// scanProgressPercent переводит прогресс этапов сканера в общий процент:
+// проверка доступности — первая половина, сканирование портов — вторая.
+func scanProgressPercent(stage string, current, total int) int {
+	if total <= 0 {
+		if stage == "complete" {
+			return 100
+		}
+		return 0
+	}
+	part := current * 50 / total
+	switch stage {
+	case "ping":
+		return part
+	case "ports":
+		return 50 + part
+	case "complete":
+		return 100
+	}
+	return 0
+}
+
 // handleScan запускает сканирование
 func (h *Handler) handleScan(w http.ResponseWriter, r *http.Request) {
 	var req scanRequest
@@ -74,17 +126,72 @@ func (h *Handler) handleScan(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Validate required fields
-	if req.NetworkCIDR == "" {
-		h.writeError(w, http.StatusBadRequest, "network is required")
+	if req.NetworkCIDR == "" && len(req.Targets) == 0 {
+		h.writeError(w, http.StatusBadRequest, "network or targets is required")
+		return
+	}
+	if err := network.ValidateTargets(append(network.SplitTargets(req.NetworkCIDR), req.Targets...)); err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := network.ValidateTargets(req.Exclude); err != nil {
+		h.writeError(w, http.StatusBadRequest, "exclude: "+err.Error())
+		return
+	}
+	if req.ExcludePorts != "" {
+		if _, err := network.ParsePortRange(req.ExcludePorts); err != nil {
+			h.writeError(w, http.StatusBadRequest, "exclude_ports: "+err.Error())
+			return
+		}
+	}
+	if req.UDPPorts != "" {
+		if _, err := network.ParsePortRange(req.UDPPorts); err != nil {
+			h.writeError(w, http.StatusBadRequest, "udp_ports: "+err.Error())
+			return
+		}
+	}
+	switch req.ScanType {
+	case "", "connect", "syn":
+	default:
+		h.writeError(w, http.StatusBadRequest, "scan_type must be connect or syn")
+		return
+	}
+	if _, err := network.ResolveSource(req.Interface, req.SourceIP); err != nil {
+		h.writeError(w, http.StatusBadRequest, "interface/source_ip: "+err.Error())
+		return
+	}
+	if req.Proxy != "" {
+		if _, err := network.ParseProxyURL(req.Proxy); err != nil {
+			h.writeError(w, http.StatusBadRequest, "proxy: "+err.Error())
+			return
+		}
+	}
+	if err := scanner.ValidateDiscovery(req.Discovery); err != nil {
+		h.writeError(w, http.StatusBadRequest, "discovery: "+err.Error())
+		return
+	}
+	if err := scanner.ValidateEnrich(req.Enrich); err != nil {
+		h.writeError(w, http.StatusBadRequest, "enrich: "+err.Error())
+		return
+	}
+	if req.Timing != "" {
+		if _, ok := scanner.LookupTimingTemplate(req.Timing); !ok {
+			h.writeError(w, http.StatusBadRequest, "timing must be one of "+strings.Join(scanner.TimingTemplateNames(), ", "))
+			return
+		}
+	}
+	if req.MaxRate < 0 || req.MaxHostInflight < 0 || req.ScanDelayMs < 0 || req.LookupTimeoutMs < 0 {
+		h.writeError(w, http.StatusBadRequest, "max_rate, max_host_inflight, scan_delay_ms and lookup_timeout_ms must not be negative")
 		return
 	}
 	if req.PortRange == "" {
 		req.PortRange = "1-1000"
 	}
-	if req.Timeout <= 0 {
+	// С профилем скорости незаданные timeout и threads берутся из профиля
+	if req.Timeout <= 0 && req.Timing == "" {
 		req.Timeout = 2
 	}
-	if req.Threads <= 0 {
+	if req.Threads <= 0 && req.Timing == "" {
 		req.Threads = 50
 	}
 
@@ -102,46 +209,162 @@ func (h *Handler) handleScan(w http.ResponseWriter, r *http.Request) {
 	}
 	scanStoreInstance.mu.Unlock()
 
-	// Run scan in background
-	go func() {
-		// TODO: integrate with real scanner service
-		// For now, simulate scan
-		time.Sleep(2 * time.Second)
-
-		results := []contracts.ScanResult{
-			{
-				IP:       "192.168.1.1",
-				Hostname: "router",
-				Ports: []contracts.PortInfo{
-					{Port: 80, State: "open", Protocol: "tcp", Service: "http"},
-				},
-			},
+	cfg := contracts.ScanConfig{
+		NetworkCIDR:     req.NetworkCIDR,
+		Targets:         req.Targets,
+		Exclude:         req.Exclude,
+		ExcludePorts:    req.ExcludePorts,
+		RandomizeHosts:  req.RandomizeHosts,
+		ScanType:        req.ScanType,
+		Interface:       req.Interface,
+		SourceIP:        req.SourceIP,
+		Proxy:           req.Proxy,
+		Discovery:       req.Discovery,
+		PortRange:       req.PortRange,
+		Timeout:         time.Duration(req.Timeout) * time.Second,
+		Threads:         req.Threads,
+		ScanUDP:         req.ScanUDP,
+		UDPPorts:        req.UDPPorts,
+		Timing:          req.Timing,
+		MaxRate:         req.MaxRate,
+		MaxHostInflight: req.MaxHostInflight,
+		ScanDelay:       time.Duration(req.ScanDelayMs) * time.Millisecond,
+		GrabBanners:     req.GrabBanners,
+		OSActive:        req.OSActive,
+		VerboseLogs:     req.VerboseLogs,
+		Enrich:          req.Enrich,
+		LookupTimeout:   time.Duration(req.LookupTimeoutMs) * time.Millisecond,
+		// Состояние сохраняется в контрольную точку: прерванный запуск
+		// продолжается через POST /scan/{id}/resume.
+		CheckpointID:  scanID,
+		CheckpointDir: h.config.CheckpointDir,
+	}
+	h.runScan(scanID, cfg)
+
+	// Return immediate response
+	h.writeJSON(w, http.StatusAccepted, scanResponse{
+		ID:        scanID,
+		Status:    "running",
+		Message:   "scan started",
+		StartedAt: time.Now(),
+	})
+}
+
+// interfaceResponse — сетевой интерфейс для выбора источника проб (interface/source_ip).
+type interfaceResponse struct {
+	Name      string   `json:"name"`
+	MAC       string   `json:"mac,omitempty"`
+	Up        bool     `json:"up"`
+	Loopback  bool     `json:"loopback"`
+	Addresses []string `json:"addresses"`
+	Gateway   string   `json:"gateway,omitempty"`
+	Default   bool     `json:"default"` // сеть интерфейса сканируется, если сеть не указана
+}
+
+// handleInterfaces возвращает интерфейсы с адресами и шлюзами по умолчанию.
+func (h *Handler) handleInterfaces(w http.ResponseWriter, r *http.Request) {
+	infos, err := network.ListInterfaces()
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	out := make([]interfaceResponse, 0, len(infos))
+	for _, info := range infos {
+		resp := interfaceResponse{
+			Name:      info.Name,
+			MAC:       info.MAC.String(),
+			Up:        info.Flags&net.FlagUp != 0,
+			Loopback:  info.Flags&net.FlagLoopback != 0,
+			Addresses: make([]string, 0, len(info.Addrs)),
+			Default:   info.Default,
+		}
+		for _, a := range info.Addrs {
+			resp.Addresses = append(resp.Addresses, a.String())
+		}
+		if info.Gateway != nil {
+			resp.Gateway = info.Gateway.String()
 		}
+		out = append(out, resp)
+	}
+	h.writeJSON(w, http.StatusOK, out)
+}
 
-		completedAt := time.Now()
+// handleScanResume продолжает прерванное сканирование с контрольной точки:
+// проверяются только незавершённые хосты, итоговый результат совпадает с непрерванным запуском.
+func (h *Handler) handleScanResume(w http.ResponseWriter, r *http.Request) {
+	scanID := mux.Vars(r)["id"]
 
-		scanStoreInstance.mu.Lock()
-		scanStoreInstance.scans[scanID] = &scanState{
-			ID:          scanID,
-			Status:      "completed",
-			Message:     "scan completed successfully",
-			Results:     results,
-			StartedAt:   time.Now().Add(-2 * time.Second),
-			CompletedAt: &completedAt,
-			Progress:    100,
+	if _, err := scanner.LoadCheckpoint(scanner.CheckpointPath(h.config.CheckpointDir, scanID)); err != nil {
+		if apperrors.IsNotFound(err) {
+			h.writeError(w, http.StatusNotFound, "checkpoint not found")
+			return
 		}
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	scanStoreInstance.mu.Lock()
+	if st, ok := scanStoreInstance.scans[scanID]; ok && st.Status == "running" {
 		scanStoreInstance.mu.Unlock()
-	}()
+		h.writeError(w, http.StatusConflict, "scan is already running")
+		return
+	}
+	// Завершённые до прерывания хосты заново приходят через OnHost
+	startedAt := time.Now()
+	scanStoreInstance.scans[scanID] = &scanState{
+		ID:        scanID,
+		Status:    "running",
+		Message:   "scan resumed",
+		StartedAt: startedAt,
+	}
+	scanStoreInstance.mu.Unlock()
+
+	h.runScan(scanID, contracts.ScanConfig{CheckpointID: scanID, CheckpointDir: h.config.CheckpointDir, Resume: true})
 
-	// Return immediate response
 	h.writeJSON(w, http.StatusAccepted, scanResponse{
 		ID:        scanID,
 		Status:    "running",
-		Message:   "scan started",
-		StartedAt: time.Now(),
+		Message:   "scan resumed",
+		StartedAt: startedAt,
 	})
 }
 
+// runScan запускает сканирование scanID в фоне и обновляет его состояние.
+func (h *Handler) runScan(scanID string, cfg contracts.ScanConfig) {
+	// Хосты попадают в состояние сразу после завершения, поэтому
+	// GET /scan/{id}/results отдаёт частичный результат во время сканирования.
+	cfg.OnHost = func(result contracts.ScanResult) {
+		scanStoreInstance.update(scanID, func(st *scanState) {
+			st.Results = append(st.Results, result)
+		})
+	}
+
+	go func() {
+		results, err := h.scanService.Scan(context.Background(), cfg, func(stage string, current, total int, message string) {
+			scanStoreInstance.update(scanID, func(st *scanState) {
+				st.Message = message
+				st.Progress = scanProgressPercent(stage, current, total)
+			})
+		})
+
+		completedAt := time.Now()
+		scanStoreInstance.update(scanID, func(st *scanState) {
+			st.CompletedAt = &completedAt
+			if err != nil {
+				st.Status = "failed"
+				st.Message = err.Error()
+				return
+			}
+			// Итоговый результат полнее снимков OnHost: MAC и имена
+			// заполняются после сканирования портов
+			st.Results = results
+			st.Status = "completed"
+			st.Message = "scan completed successfully"
+			st.Progress = 100
+		})
+	}()
+}
+
 // handleScanStatus возвращает статус сканирования
 func (h *Handler) handleScanStatus(w http.ResponseWriter, r *http.Request) {
 	// Extract ID from URL
@@ -150,6 +373,41 @@ func (h *Handler) handleScanStatus(w http.ResponseWriter, r *http.Request) {
 
 	scanStoreInstance.mu.RLock()
 	scan, exists := scanStoreInstance.scans[scanID]
+	var status scanStatus
+	if exists {
+		status = scanStatus{
+			ID:          scan.ID,
+			Status:      scan.Status,
+			Message:     scan.Message,
+			Progress:    scan.Progress,
+			Results:     len(scan.Results),
+			StartedAt:   scan.StartedAt,
+			CompletedAt: scan.CompletedAt,
+		}
+	}
+	scanStoreInstance.mu.RUnlock()
+
+	if !exists {
+		h.writeError(w, http.StatusNotFound, "scan not found")
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, status)
+}
+
+// handleScanResults возвращает хосты сканирования, готовые на текущий момент
+func (h *Handler) handleScanResults(w http.ResponseWriter, r *http.Request) {
+	scanID := mux.Vars(r)["id"]
+
+	scanStoreInstance.mu.RLock()
+	scan, exists := scanStoreInstance.scans[scanID]
+	var results []contracts.ScanResult
+	var status string
+	if exists {
+		results = make([]contracts.ScanResult, len(scan.Results))
+		copy(results, scan.Results)
+		status = scan.Status
+	}
 	scanStoreInstance.mu.RUnlock()
 
 	if !exists {
@@ -157,14 +415,10 @@ func (h *Handler) handleScanStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.writeJSON(w, http.StatusOK, scanStatus{
-		ID:          scan.ID,
-		Status:      scan.Status,
-		Message:     scan.Message,
-		Progress:    scan.Progress,
-		Results:     len(scan.Results),
-		StartedAt:   scan.StartedAt,
-		CompletedAt: scan.CompletedAt,
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"scan_id": scanID,
+		"status":  status,
+		"results": results,
 	})
 }
 
@@ -194,4 +448,4 @@ func (h *Handler) handleResults(w http.ResponseWriter, r *http.Request) {
 		"results": lastScan.Results,
 		"scan_id": lastScan.ID,
 	})
-}
+}
@@ -67,7 +67,13 @@ func EvaluateOpenPorts(results []scanner.Result) []Finding {
 			})
 		}
 	}
-	sort.Slice(out, func(i, j int) bool {
+	sortFindings(out)
+	return out
+}
+
+// sortFindings упорядочивает находки: сначала более критичные, затем по хосту и порту.
+func sortFindings(out []Finding) {
+	sort.SliceStable(out, func(i, j int) bool {
 		if out[i].Severity != out[j].Severity {
 			return severityWeight(out[i].Severity) > severityWeight(out[j].Severity)
 		}
@@ -76,7 +82,6 @@ func EvaluateOpenPorts(results []scanner.Result) []Finding {
 		}
 		return out[i].Port < out[j].Port
 	})
-	return out
 }
 
 func FormatFindings(findings []Finding) string {
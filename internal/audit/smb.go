@@ -0,0 +1,46 @@
+package audit
+
+import (
+	"strings"
+
+	"network-scanner/internal/scanner"
+)
+
+// Заголовки находок по SMB.
+const (
+	TitleSMBv1              = "SMB: включён SMBv1"
+	TitleSMBSigningOptional = "SMB: подпись не обязательна"
+)
+
+// EvaluateSMB строит находки по сведениям SMB-серверов (стадия smb): согласование SMBv1
+// и необязательная подпись, открывающая путь ретрансляции NTLM.
+func EvaluateSMB(results []scanner.Result) []Finding {
+	out := make([]Finding, 0)
+	for _, host := range results {
+		ip := strings.TrimSpace(host.IP)
+		for _, p := range host.Ports {
+			info := p.SMB
+			if info == nil {
+				continue
+			}
+			add := func(severity, title, rec string) {
+				out = append(out, Finding{
+					Host:           ip,
+					Port:           p.Port,
+					Protocol:       strings.TrimSpace(p.Protocol),
+					Severity:       severity,
+					Title:          title,
+					Recommendation: rec,
+				})
+			}
+			if info.SMB1 {
+				add("high", TitleSMBv1, "Отключить SMBv1 (EternalBlue, WannaCry), оставить SMB 2/3.")
+			}
+			if !info.SigningRequired {
+				add("medium", TitleSMBSigningOptional, "Включить обязательную подпись SMB (RequireSecuritySignature): без неё возможна ретрансляция NTLM.")
+			}
+		}
+	}
+	sortFindings(out)
+	return out
+}
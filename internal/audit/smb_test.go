@@ -0,0 +1,41 @@
+package audit
+
+import (
+	"testing"
+
+	"network-scanner/internal/scanner"
+	"network-scanner/internal/smbinspect"
+)
+
+func TestEvaluateSMB(t *testing.T) {
+	results := []scanner.Result{
+		{
+			IP: "10.0.0.1",
+			Ports: []scanner.PortInfo{
+				{Port: 445, Protocol: "tcp", State: "open", SMB: &smbinspect.Info{Dialects: []string{"2.0.2", "2.1"}, SMB1: true, SigningEnabled: true}},
+				{Port: 80, Protocol: "tcp", State: "open"},
+			},
+		},
+		{
+			IP: "10.0.0.2",
+			Ports: []scanner.PortInfo{
+				{Port: 445, Protocol: "tcp", State: "open", SMB: &smbinspect.Info{Dialects: []string{"3.1.1"}, SigningEnabled: true, SigningRequired: true}},
+			},
+		},
+	}
+
+	got := map[string][]string{}
+	for _, f := range EvaluateSMB(results) {
+		if f.Port != 445 || f.Protocol != "tcp" || f.Recommendation == "" {
+			t.Errorf("неполная находка: %+v", f)
+		}
+		got[f.Host] = append(got[f.Host], f.Severity+" "+f.Title)
+	}
+	want := []string{"high " + TitleSMBv1, "medium " + TitleSMBSigningOptional}
+	if len(got["10.0.0.1"]) != 2 || got["10.0.0.1"][0] != want[0] || got["10.0.0.1"][1] != want[1] {
+		t.Errorf("10.0.0.1: %v, want %v", got["10.0.0.1"], want)
+	}
+	if len(got["10.0.0.2"]) != 0 {
+		t.Errorf("10.0.0.2: лишние находки %v", got["10.0.0.2"])
+	}
+}
@@ -0,0 +1,103 @@
+package audit
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"network-scanner/internal/scanner"
+)
+
+// Заголовки находок по SSH.
+const (
+	TitleSSHProtocol1        = "SSH: протокол SSH-1"
+	TitleSSHWeakHostKey      = "SSH: слабый ключ хоста"
+	TitleSSHReusedHostKey    = "SSH: один ключ хоста на нескольких устройствах"
+	TitleSSHWeakKex          = "SSH: устаревший обмен ключами"
+	TitleSSHWeakCiphers      = "SSH: устаревшие шифры"
+	TitleSSHWeakMACs         = "SSH: устаревшие MAC"
+	TitleSSHWeakHostKeyAlgos = "SSH: подписи ключа хоста на SHA-1/DSA"
+)
+
+// EvaluateSSH строит находки по отпечаткам SSH-серверов (стадия ssh): SSH-1, слабые ключи
+// хоста (DSA, RSA короче 2048 бит), устаревшие алгоритмы обмена ключами, шифры, MAC и
+// подписи ключа хоста, а также ключи хоста, найденные на нескольких адресах — признак
+// клонированного образа ВМ или прошивки с общими ключами.
+func EvaluateSSH(results []scanner.Result) []Finding {
+	// Адреса, на которых встречен каждый отпечаток ключа хоста
+	seenOn := make(map[string][]string)
+	for _, host := range results {
+		ip := strings.TrimSpace(host.IP)
+		for _, p := range host.Ports {
+			if p.SSH == nil {
+				continue
+			}
+			for _, k := range p.SSH.HostKeys {
+				if hosts := seenOn[k.Fingerprint]; len(hosts) == 0 || hosts[len(hosts)-1] != ip {
+					seenOn[k.Fingerprint] = append(hosts, ip)
+				}
+			}
+		}
+	}
+
+	out := make([]Finding, 0)
+	for _, host := range results {
+		ip := strings.TrimSpace(host.IP)
+		for _, p := range host.Ports {
+			info := p.SSH
+			if info == nil {
+				continue
+			}
+			add := func(severity, title, rec string) {
+				out = append(out, Finding{
+					Host:           ip,
+					Port:           p.Port,
+					Protocol:       strings.TrimSpace(p.Protocol),
+					Severity:       severity,
+					Title:          title,
+					Recommendation: rec,
+				})
+			}
+			if info.Protocol1() {
+				add("high", TitleSSHProtocol1, "Отключить SSH-1, оставить только SSH-2.")
+				continue
+			}
+			for _, k := range info.HostKeys {
+				if k.WeakKey() {
+					add("high", TitleSSHWeakHostKey, fmt.Sprintf("Ключ %s %d бит (%s): заменить ключом Ed25519 или RSA от 3072 бит.",
+						k.Type, k.Bits, k.Fingerprint))
+				}
+				if others := otherHosts(seenOn[k.Fingerprint], ip); len(others) > 0 {
+					add("medium", TitleSSHReusedHostKey, fmt.Sprintf("Ключ %s %s также у %s: перегенерировать ключи хоста на каждом устройстве.",
+						k.Type, k.Fingerprint, strings.Join(others, ", ")))
+				}
+			}
+			if weak := info.WeakKex(); len(weak) > 0 {
+				add("medium", TitleSSHWeakKex, "Отключить: "+strings.Join(weak, ", ")+".")
+			}
+			if weak := info.WeakCiphers(); len(weak) > 0 {
+				add("medium", TitleSSHWeakCiphers, "Отключить: "+strings.Join(weak, ", ")+".")
+			}
+			if weak := info.WeakMACs(); len(weak) > 0 {
+				add("low", TitleSSHWeakMACs, "Отключить: "+strings.Join(weak, ", ")+".")
+			}
+			if weak := info.WeakHostKeyAlgorithms(); len(weak) > 0 {
+				add("low", TitleSSHWeakHostKeyAlgos, "Отключить: "+strings.Join(weak, ", ")+".")
+			}
+		}
+	}
+	sortFindings(out)
+	return out
+}
+
+// otherHosts возвращает адреса из hosts, кроме self, по возрастанию.
+func otherHosts(hosts []string, self string) []string {
+	var out []string
+	for _, h := range hosts {
+		if h != self {
+			out = append(out, h)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,72 @@
+package audit
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"network-scanner/internal/scanner"
+	"network-scanner/internal/sshinspect"
+)
+
+func TestEvaluateSSH(t *testing.T) {
+	shared := sshinspect.HostKey{Type: "ssh-ed25519", Fingerprint: "SHA256:shared"}
+	results := []scanner.Result{
+		{
+			IP: "10.0.0.1",
+			Ports: []scanner.PortInfo{
+				{Port: 22, Protocol: "tcp", State: "open", SSH: &sshinspect.Info{
+					Banner:            "SSH-2.0-OpenSSH_7.4",
+					KexAlgorithms:     []string{"curve25519-sha256", "diffie-hellman-group1-sha1"},
+					HostKeyAlgorithms: []string{"rsa-sha2-512", "ssh-ed25519"},
+					Ciphers:           []string{"aes128-ctr"},
+					MACs:              []string{"hmac-sha2-256", "hmac-md5"},
+					HostKeys:          []sshinspect.HostKey{shared, {Type: "ssh-rsa", Bits: 1024, Fingerprint: "SHA256:rsa"}},
+				}},
+				{Port: 80, Protocol: "tcp", State: "open"},
+			},
+		},
+		{
+			IP: "10.0.0.2",
+			Ports: []scanner.PortInfo{
+				{Port: 2222, Protocol: "tcp", State: "open", SSH: &sshinspect.Info{
+					Banner:            "SSH-2.0-OpenSSH_9.6",
+					KexAlgorithms:     []string{"curve25519-sha256"},
+					HostKeyAlgorithms: []string{"ssh-ed25519"},
+					Ciphers:           []string{"chacha20-poly1305@openssh.com"},
+					MACs:              []string{"hmac-sha2-256-etm@openssh.com"},
+					HostKeys:          []sshinspect.HostKey{shared},
+				}},
+			},
+		},
+		{
+			IP:    "10.0.0.3",
+			Ports: []scanner.PortInfo{{Port: 22, Protocol: "tcp", State: "open", SSH: &sshinspect.Info{Banner: "SSH-1.5-Cisco-1.25"}}},
+		},
+	}
+
+	got := map[string][]string{}
+	for _, f := range EvaluateSSH(results) {
+		if f.Protocol != "tcp" || f.Recommendation == "" {
+			t.Errorf("неполная находка: %+v", f)
+		}
+		if f.Title == TitleSSHReusedHostKey && !strings.Contains(f.Recommendation, "SHA256:shared") {
+			t.Errorf("в рекомендации нет отпечатка: %q", f.Recommendation)
+		}
+		key := fmt.Sprintf("%s:%d", f.Host, f.Port)
+		got[key] = append(got[key], f.Title)
+	}
+	want := map[string][]string{
+		"10.0.0.1:22":   {TitleSSHWeakHostKey, TitleSSHReusedHostKey, TitleSSHWeakKex, TitleSSHWeakMACs},
+		"10.0.0.2:2222": {TitleSSHReusedHostKey},
+		"10.0.0.3:22":   {TitleSSHProtocol1},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("находки по портам: %v, want %v", got, want)
+	}
+	for port, titles := range want {
+		if strings.Join(got[port], "|") != strings.Join(titles, "|") {
+			t.Errorf("%s: %v, want %v", port, got[port], titles)
+		}
+	}
+}
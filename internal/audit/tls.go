@@ -0,0 +1,65 @@
+package audit
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"network-scanner/internal/scanner"
+	"network-scanner/internal/tlsinspect"
+)
+
+// Заголовки находок по TLS.
+const (
+	TitleTLSExpired    = "TLS: сертификат истёк"
+	TitleTLSExpiring   = "TLS: сертификат скоро истекает"
+	TitleTLSSelfSigned = "TLS: самоподписанный сертификат"
+	TitleTLSWeakKey    = "TLS: слабый ключ сертификата"
+	TitleTLSLegacyOnly = "TLS: только TLS 1.0/1.1"
+)
+
+// EvaluateTLS строит находки по сведениям о TLS портов (стадия tls): истёкший или
+// истекающий в пределах tlsinspect.ExpiryWarning сертификат, самоподписанный сертификат,
+// слабый ключ и службы, поддерживающие только TLS 1.0/1.1. Сроки сравниваются с now.
+func EvaluateTLS(results []scanner.Result, now time.Time) []Finding {
+	out := make([]Finding, 0)
+	for _, host := range results {
+		for _, p := range host.Ports {
+			if p.TLS == nil {
+				continue
+			}
+			add := func(severity, title, rec string) {
+				out = append(out, Finding{
+					Host:           strings.TrimSpace(host.IP),
+					Port:           p.Port,
+					Protocol:       strings.TrimSpace(p.Protocol),
+					Severity:       severity,
+					Title:          title,
+					Recommendation: rec,
+				})
+			}
+			if leaf := p.TLS.Leaf(); leaf != nil {
+				switch {
+				case leaf.Expired(now):
+					add("high", TitleTLSExpired, fmt.Sprintf("Сертификат %s истёк %s: выпустить новый и заменить на службе.",
+						leaf.Subject, leaf.NotAfter.Format("2006-01-02")))
+				case leaf.ExpiresWithin(now, tlsinspect.ExpiryWarning):
+					add("medium", TitleTLSExpiring, fmt.Sprintf("Сертификат %s действует до %s: продлить заранее.",
+						leaf.Subject, leaf.NotAfter.Format("2006-01-02")))
+				}
+				if leaf.SelfSigned {
+					add("medium", TitleTLSSelfSigned, "Использовать сертификат доверенного (в том числе внутреннего) УЦ.")
+				}
+				if leaf.WeakKey() {
+					add("high", TitleTLSWeakKey, fmt.Sprintf("Ключ %s %d бит: перевыпустить сертификат с RSA от 2048 бит или ECDSA P-256.",
+						leaf.KeyType, leaf.KeyBits))
+				}
+			}
+			if p.TLS.LegacyOnly() {
+				add("high", TitleTLSLegacyOnly, "Включить TLS 1.2 и 1.3, отключить устаревшие версии протокола.")
+			}
+		}
+	}
+	sortFindings(out)
+	return out
+}
@@ -0,0 +1,59 @@
+package audit
+
+import (
+	"testing"
+	"time"
+
+	"network-scanner/internal/scanner"
+	"network-scanner/internal/tlsinspect"
+)
+
+func TestEvaluateTLS(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	cert := func(notAfter time.Time, bits int, selfSigned bool) []tlsinspect.Certificate {
+		return []tlsinspect.Certificate{{Subject: "CN=host", NotAfter: notAfter, KeyType: "RSA", KeyBits: bits, SelfSigned: selfSigned}}
+	}
+	modern := []tlsinspect.Protocol{{Version: "TLS 1.3"}, {Version: "TLS 1.2"}}
+	results := []scanner.Result{
+		{
+			IP: "10.0.0.1",
+			Ports: []scanner.PortInfo{
+				{Port: 443, Protocol: "tcp", State: "open", TLS: &tlsinspect.Info{Protocols: modern, Chain: cert(now.AddDate(1, 0, 0), 2048, false)}},
+				{Port: 8443, Protocol: "tcp", State: "open", TLS: &tlsinspect.Info{Protocols: modern, Chain: cert(now.AddDate(0, 0, -1), 2048, true)}},
+				{Port: 25, Protocol: "tcp", State: "open", TLS: &tlsinspect.Info{
+					STARTTLS:  "smtp",
+					Protocols: []tlsinspect.Protocol{{Version: "TLS 1.1"}, {Version: "TLS 1.0"}},
+					Chain:     cert(now.AddDate(0, 0, 10), 1024, false),
+				}},
+				{Port: 80, Protocol: "tcp", State: "open"},
+			},
+		},
+	}
+
+	got := map[int][]string{}
+	for _, f := range EvaluateTLS(results, now) {
+		if f.Host != "10.0.0.1" || f.Protocol != "tcp" || f.Recommendation == "" {
+			t.Errorf("неполная находка: %+v", f)
+		}
+		got[f.Port] = append(got[f.Port], f.Title)
+	}
+	want := map[int][]string{
+		8443: {TitleTLSExpired, TitleTLSSelfSigned},
+		25:   {TitleTLSWeakKey, TitleTLSLegacyOnly, TitleTLSExpiring},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("находки по портам: %v, want %v", got, want)
+	}
+	for port, titles := range want {
+		if len(got[port]) != len(titles) {
+			t.Errorf("порт %d: %v, want %v", port, got[port], titles)
+			continue
+		}
+		for i := range titles {
+			if got[port][i] != titles[i] {
+				t.Errorf("порт %d: %v, want %v", port, got[port], titles)
+				break
+			}
+		}
+	}
+}
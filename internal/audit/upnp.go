@@ -0,0 +1,56 @@
+package audit
+
+import (
+	"fmt"
+	"strings"
+
+	"network-scanner/internal/scanner"
+)
+
+// Заголовки находок по UPnP.
+const (
+	TitleUPnPPortMapping      = "UPnP: проброс порта на шлюзе"
+	TitleUPnPRiskyPortMapping = "UPnP: проброс опасной службы на шлюзе"
+)
+
+// EvaluateUPnP строит находки по таблицам пробросов портов шлюзов UPnP IGD (стадия upnp):
+// каждый включённый проброс создан программой без участия администратора и открывает
+// внутренний хост из интернета. Проброс на порт из списка опасных служб аудита портов
+// (SMB, RDP, VNC, Telnet, FTP, СУБД) — high, остальные — medium.
+func EvaluateUPnP(results []scanner.Result) []Finding {
+	out := make([]Finding, 0)
+	for _, host := range results {
+		ip := strings.TrimSpace(host.IP)
+		for _, d := range host.UPnP {
+			for _, m := range d.PortMappings {
+				if !m.Enabled {
+					continue
+				}
+				severity, title := "medium", TitleUPnPPortMapping
+				rec := fmt.Sprintf("Проброс %s", m)
+				if m.Description != "" {
+					rec += fmt.Sprintf(" (%q)", m.Description)
+				}
+				if m.RemoteHost != "" {
+					rec += " только для " + m.RemoteHost
+				}
+				if rule, ok := riskyPorts[m.InternalPort]; ok {
+					severity, title = "high", TitleUPnPRiskyPortMapping
+					rec += fmt.Sprintf(": %s из интернета. Удалить проброс.", rule.title)
+				} else {
+					rec += ": удалить, если не нужен."
+				}
+				out = append(out, Finding{
+					Host:           ip,
+					Port:           m.ExternalPort,
+					Protocol:       strings.ToLower(m.Protocol),
+					Severity:       severity,
+					Title:          title,
+					Recommendation: rec + " Отключить UPnP IGD на шлюзе, если пробросы не используются.",
+				})
+			}
+		}
+	}
+	sortFindings(out)
+	return out
+}
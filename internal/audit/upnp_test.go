@@ -0,0 +1,38 @@
+package audit
+
+import (
+	"strings"
+	"testing"
+
+	"network-scanner/internal/scanner"
+	"network-scanner/internal/upnp"
+)
+
+func TestEvaluateUPnP(t *testing.T) {
+	results := []scanner.Result{
+		{
+			IP: "192.168.1.1",
+			UPnP: []upnp.Device{{
+				DeviceType: "urn:schemas-upnp-org:device:InternetGatewayDevice:1",
+				PortMappings: []upnp.PortMapping{
+					{ExternalPort: 3389, Protocol: "TCP", InternalClient: "192.168.1.10", InternalPort: 3389, Enabled: true, Description: "RDP"},
+					{ExternalPort: 51413, Protocol: "UDP", InternalClient: "192.168.1.11", InternalPort: 51413, Enabled: true},
+					{ExternalPort: 8080, Protocol: "TCP", InternalClient: "192.168.1.12", InternalPort: 80},
+				},
+			}},
+		},
+		{IP: "192.168.1.20", UPnP: []upnp.Device{{DeviceType: "urn:schemas-upnp-org:device:MediaRenderer:1"}}},
+	}
+
+	findings := EvaluateUPnP(results)
+	if len(findings) != 2 {
+		t.Fatalf("находок %d, want 2: %+v", len(findings), findings)
+	}
+	rdp, torrent := findings[0], findings[1]
+	if rdp.Severity != "high" || rdp.Title != TitleUPnPRiskyPortMapping || rdp.Port != 3389 || rdp.Protocol != "tcp" || !strings.Contains(rdp.Recommendation, "192.168.1.10:3389") {
+		t.Errorf("проброс RDP: %+v", rdp)
+	}
+	if torrent.Severity != "medium" || torrent.Title != TitleUPnPPortMapping || torrent.Host != "192.168.1.1" || torrent.Protocol != "udp" {
+		t.Errorf("проброс 51413: %+v", torrent)
+	}
+}
@@ -10,23 +10,35 @@ import (
 	"time"
 )
 
+// DialFunc открывает соединение (сигнатура net.DialTimeout); позволяет выбрать
+// интерфейс и адрес источника.
+type DialFunc func(network, address string, timeout time.Duration) (net.Conn, error)
+
 // GrabTCP читает первые байты с открытого TCP-порта (баннер).
 func GrabTCP(host string, port int, readTimeout time.Duration) (string, error) {
+	return GrabTCPWith(nil, host, port, readTimeout)
+}
+
+// GrabTCPWith — GrabTCP с соединениями через dial (nil — net.DialTimeout).
+func GrabTCPWith(dial DialFunc, host string, port int, readTimeout time.Duration) (string, error) {
 	if readTimeout <= 0 {
 		readTimeout = 2 * time.Second
 	}
+	if dial == nil {
+		dial = net.DialTimeout
+	}
 	// Для HTTP-портов инициируем запрос HEAD, иначе часть сервисов молчит.
 	if isTLSHTTPPort(port) {
-		if s, err := grabTLSHTTP(host, port, readTimeout); err == nil && s != "" {
+		if s, err := grabTLSHTTP(dial, host, port, readTimeout); err == nil && s != "" {
 			return s, nil
 		}
 	} else if isPlainHTTPPort(port) {
-		if s, err := grabPlainHTTP(host, port, readTimeout); err == nil && s != "" {
+		if s, err := grabPlainHTTP(dial, host, port, readTimeout); err == nil && s != "" {
 			return s, nil
 		}
 	}
 
-	raw, err := readFirstTCPBytes(host, port, readTimeout)
+	raw, err := readFirstTCPBytes(dial, host, port, readTimeout)
 	if err != nil {
 		return "", err
 	}
@@ -49,9 +61,9 @@ func sanitizeBanner(b []byte) string {
 	return strings.TrimSpace(sb.String())
 }
 
-func readFirstTCPBytes(host string, port int, readTimeout time.Duration) (string, error) {
+func readFirstTCPBytes(dial DialFunc, host string, port int, readTimeout time.Duration) (string, error) {
 	addr := net.JoinHostPort(host, fmt.Sprintf("%d", port))
-	conn, err := net.DialTimeout("tcp", addr, readTimeout)
+	conn, err := dial("tcp", addr, readTimeout)
 	if err != nil {
 		return "", err
 	}
@@ -68,9 +80,9 @@ func readFirstTCPBytes(host string, port int, readTimeout time.Duration) (string
 	return "", nil
 }
 
-func grabPlainHTTP(host string, port int, timeout time.Duration) (string, error) {
+func grabPlainHTTP(dial DialFunc, host string, port int, timeout time.Duration) (string, error) {
 	addr := net.JoinHostPort(host, fmt.Sprintf("%d", port))
-	conn, err := net.DialTimeout("tcp", addr, timeout)
+	conn, err := dial("tcp", addr, timeout)
 	if err != nil {
 		return "", err
 	}
@@ -83,20 +95,23 @@ func grabPlainHTTP(host string, port int, timeout time.Duration) (string, error)
 	return parseHTTPResponse(conn), nil
 }
 
-func grabTLSHTTP(host string, port int, timeout time.Duration) (string, error) {
+func grabTLSHTTP(dial DialFunc, host string, port int, timeout time.Duration) (string, error) {
 	addr := net.JoinHostPort(host, fmt.Sprintf("%d", port))
-	dialer := &net.Dialer{Timeout: timeout}
 	cfg := &tls.Config{
 		ServerName:         host,
 		InsecureSkipVerify: true, // Для баннер-граббинга не валидируем сертификат.
 		MinVersion:         tls.VersionTLS10,
 	}
-	conn, err := tls.DialWithDialer(dialer, "tcp", addr, cfg)
+	raw, err := dial("tcp", addr, timeout)
 	if err != nil {
 		return "", err
 	}
+	conn := tls.Client(raw, cfg)
 	defer conn.Close()
 	_ = conn.SetDeadline(time.Now().Add(timeout))
+	if err := conn.Handshake(); err != nil {
+		return "", err
+	}
 	req := "HEAD / HTTP/1.0\r\nHost: " + host + "\r\nUser-Agent: network-scanner\r\nConnection: close\r\n\r\n"
 	if _, err = conn.Write([]byte(req)); err != nil {
 		return "", err
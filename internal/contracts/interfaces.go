@@ -7,15 +7,67 @@ import (
 
 // ScanConfig конфигурация сканирования
 type ScanConfig struct {
+	// NetworkCIDR — сеть или список целей через запятую (CIDR, диапазоны a.b.c.d-e, IP, имена хостов).
 	NetworkCIDR string
+	// Targets — дополнительные цели того же формата (например, из --hosts-file);
+	// сканируются вместе с NetworkCIDR, пересечения сканируются один раз.
+	Targets []string
+	// Exclude — цели того же формата, которые не сканируются (применяются до проверки доступности).
+	Exclude []string
+	// ExcludePorts — порты в формате PortRange, которые не опрашиваются ни по TCP, ни по UDP.
+	ExcludePorts string
+	// RandomizeHosts — проверять адреса в псевдослучайном порядке, распределяя пробы по подсетям.
+	RandomizeHosts bool
+	// Discovery — способы обнаружения хостов по порядку: "arp", "icmp", "ndp", "mdns", "ssdp", "tcp";
+	// пусто — только TCP.
+	Discovery []string
+	// Timing — профиль скорости: "polite", "normal", "aggressive"; пусто — без профиля.
+	// Профиль задаёт ограничения интенсивности, а также Timeout и Threads, если они не заданы.
+	Timing string
+	// MaxRate — общий лимит проб в секунду; MaxHostInflight — одновременных проб к хосту;
+	// ScanDelay — пауза между пробами к хосту. Нулевые значения берутся из профиля.
+	MaxRate         float64
+	MaxHostInflight int
+	ScanDelay       time.Duration
+	// ScanType — способ TCP-сканирования: "connect" (по умолчанию) или "syn"
+	// (half-open, нужны права на raw-сокеты; без них выполняется connect).
+	ScanType string
+	// Interface и SourceIP — интерфейс и адрес, с которых уходят все пробы;
+	// пусто — выбор ОС. Адрес определяет свой интерфейс, интерфейс — свои адреса.
+	Interface   string
+	SourceIP    string
+	// Proxy — прокси для TCP-проб, баннеров и TLS: "socks5://[user:pass@]host[:port]" или
+	// "ssh://user@bastion[:port]"; пусто — напрямую. Через прокси обнаружение хостов
+	// выполняется TCP connect, UDP и SNMP пропускаются.
+	Proxy       string
 	PortRange   string
 	Timeout     time.Duration
 	Threads     int
 	ShowClosed  bool
 	ScanUDP     bool
+	UDPPorts    string // UDP-порты в формате PortRange; пусто — набор по умолчанию
 	GrabBanners bool
 	OSActive    bool
 	VerboseLogs bool
+	// Enrich — настройка стадий обогащения хостов после сканирования портов (mac, hostname,
+	// snmp, device, os, выключенная по умолчанию service и зарегистрированные расширения):
+	// "-hostname,snmp=1s" выключает обратный DNS и задаёт таймаут стадии snmp, "service"
+	// включает определение версий служб; пусто — стадии по умолчанию с их таймаутами.
+	Enrich string
+	// LookupTimeout — предел завершающего прохода после сканирования портов, который
+	// дочитывает MAC из ARP-таблицы и имена обратного DNS хостам, не получившим их вовремя;
+	// 0 — 5 секунд.
+	LookupTimeout time.Duration
+	// CheckpointID — идентификатор запуска для контрольных точек: состояние периодически
+	// сохраняется в CheckpointDir/<CheckpointID>.json; пусто — без контрольных точек.
+	CheckpointID  string
+	CheckpointDir string // пусто — каталог по умолчанию ("checkpoints")
+	// Resume — продолжить прерванный запуск CheckpointID: параметры сканирования берутся
+	// из контрольной точки, остальные поля, кроме OnHost, игнорируются.
+	Resume bool
+	// OnHost вызывается для каждого хоста сразу после завершения его сканирования
+	// (сериализованно, в порядке итогового списка результатов). Опционально.
+	OnHost HostHandler
 }
 
 // ScanResult результат сканирования (упрощённый интерфейс для сервисов)
@@ -27,6 +79,19 @@ type ScanResult struct {
 	DeviceType   string
 	DeviceVendor string
 	GuessOS      string
+	// DiscoveryMethod — способ, которым хост обнаружен: "arp", "icmp", "ndp", "mdns", "ssdp" или "tcp".
+	DiscoveryMethod string
+	// Addresses — все адреса устройства (IPv4 и IPv6, первый — IP), если их больше одного.
+	Addresses []string
+	// Via — прокси, через который сканировался хост (адрес без пароля); пусто — напрямую.
+	Via string
+	// NetBIOS — таблица имён NetBIOS хоста (стадия netbios); nil — хост не ответил или не проверялся.
+	NetBIOS *NetBIOSInfo
+	// MDNS — имя, службы DNS-SD и метаданные TXT устройства (стадия mdns); nil — устройство
+	// не объявляло служб или обнаружение mdns не выбрано.
+	MDNS *MDNSHost
+	// UPnP — описания устройств UPnP хоста и пробросы портов шлюза (стадия upnp).
+	UPnP []UPnPDevice
 }
 
 // PortInfo информация о порте
@@ -37,9 +102,160 @@ type PortInfo struct {
 	Service  string
 	Banner   string
 	Version  string
+	Product  string        // продукт службы ("OpenSSH", "nginx")
+	Info     string        // дополнительные сведения о службе
+	CPE      string        // CPE 2.3 продукта и версии
+	TLS      *TLSInfo      // сведения о TLS порта; nil — не проверялся
+	SSH      *SSHInfo      // отпечаток SSH-сервера; nil — не проверялся
+	HTTP     *HTTPInfo     // отпечаток веб-приложения; nil — не проверялся
+	SMB      *SMBInfo      // сведения SMB-сервера; nil — не проверялся
+	Reason   string        // причина состояния порта (syn-ack, conn-refused, no-response, ...)
+	Latency  time.Duration // время до ответа на пробу
 }
 
-// ScannerService интерфейс для сканирования
+// TLSInfo сведения о TLS порта: версии протокола и цепочка сертификатов.
+// Поля и теги JSON повторяют tlsinspect.Info, поэтому ответ API не зависит от пакета проверки.
+type TLSInfo struct {
+	STARTTLS  string           `json:"starttls,omitempty"` // протокол, которым включён TLS
+	Protocols []TLSProtocol    `json:"protocols"`          // поддерживаемые версии, от новой к старой
+	Chain     []TLSCertificate `json:"chain"`              // цепочка сервера, первым — сертификат узла
+}
+
+// TLSProtocol версия TLS и шифр, согласованный для неё
+type TLSProtocol struct {
+	Version     string `json:"version"`
+	CipherSuite string `json:"cipher_suite"`
+}
+
+// TLSCertificate сертификат цепочки TLS
+type TLSCertificate struct {
+	Subject            string    `json:"subject"`
+	SANs               []string  `json:"sans,omitempty"`
+	Issuer             string    `json:"issuer"`
+	Serial             string    `json:"serial"`
+	NotBefore          time.Time `json:"not_before"`
+	NotAfter           time.Time `json:"not_after"`
+	KeyType            string    `json:"key_type"`
+	KeyBits            int       `json:"key_bits"`
+	SignatureAlgorithm string    `json:"signature_algorithm"`
+	SelfSigned         bool      `json:"self_signed,omitempty"`
+	SHA256             string    `json:"sha256"`
+}
+
+// SSHInfo отпечаток SSH-сервера: предлагаемые алгоритмы и ключи хоста (поля sshinspect.Info).
+type SSHInfo struct {
+	Banner            string       `json:"banner"`                        // строка версии сервера
+	KexAlgorithms     []string     `json:"kex_algorithms,omitempty"`      // обмен ключами
+	HostKeyAlgorithms []string     `json:"host_key_algorithms,omitempty"` // подписи ключа хоста
+	Ciphers           []string     `json:"ciphers,omitempty"`             // шифры клиент → сервер
+	MACs              []string     `json:"macs,omitempty"`                // MAC клиент → сервер
+	Compression       []string     `json:"compression,omitempty"`         // сжатие клиент → сервер
+	HostKeys          []SSHHostKey `json:"host_keys,omitempty"`
+}
+
+// SSHHostKey ключ хоста SSH одного типа
+type SSHHostKey struct {
+	Type        string `json:"type"`           // формат ключа ("ssh-ed25519", "ssh-rsa")
+	Bits        int    `json:"bits,omitempty"` // длина ключа RSA, DSA и ECDSA; 0 — Ed25519
+	Fingerprint string `json:"fingerprint"`    // SHA256:…, как у ssh-keygen -l
+}
+
+// HTTPInfo отпечаток веб-приложения порта (поля httpinspect.Info).
+type HTTPInfo struct {
+	URL         string            `json:"url"`                    // адрес страницы после переадресаций
+	Status      int               `json:"status"`                 // код ответа главной страницы
+	Title       string            `json:"title,omitempty"`        // <title> страницы
+	Headers     map[string]string `json:"headers,omitempty"`      // заголовки ответа, кроме Set-Cookie
+	Cookies     []string          `json:"cookies,omitempty"`      // имена cookie (значения не сохраняются)
+	Realm       string            `json:"realm,omitempty"`        // realm из WWW-Authenticate
+	Generator   string            `json:"generator,omitempty"`    // <meta name="generator">
+	FaviconHash int32             `json:"favicon_hash,omitempty"` // MurmurHash3 favicon в форме Shodan; 0 — нет favicon
+	Products    []HTTPProduct     `json:"products,omitempty"`     // совпавшие правила продуктов
+}
+
+// HTTPProduct продукт, распознанный по правилам HTTP
+type HTTPProduct struct {
+	Name     string `json:"name"`
+	Vendor   string `json:"vendor,omitempty"`
+	Category string `json:"category,omitempty"` // категория deviceclassifier ("NAS", "Printer", ...)
+	Version  string `json:"version,omitempty"`
+}
+
+// SMBInfo сведения SMB-сервера из NEGOTIATE и NTLM (поля smbinspect.Info).
+type SMBInfo struct {
+	Dialects        []string `json:"dialects,omitempty"`          // принимаемые диалекты SMB2/3 по возрастанию ("2.0.2", "3.1.1")
+	SMB1            bool     `json:"smb1"`                        // сервер согласует SMBv1 (NT LM 0.12)
+	SigningEnabled  bool     `json:"signing_enabled"`             // сервер поддерживает подпись
+	SigningRequired bool     `json:"signing_required"`            // сервер требует подпись
+	ServerGUID      string   `json:"server_guid,omitempty"`       // из ответа NEGOTIATE SMB2
+	ComputerName    string   `json:"computer_name,omitempty"`     // NetBIOS-имя из NTLM
+	Domain          string   `json:"domain,omitempty"`            // NetBIOS-имя домена или рабочей группы из NTLM
+	DNSComputerName string   `json:"dns_computer_name,omitempty"` // полное DNS-имя из NTLM
+	DNSDomain       string   `json:"dns_domain,omitempty"`        // DNS-имя домена из NTLM
+	OSVersion       string   `json:"os_version,omitempty"`        // версия ОС из NTLM ("10.0.19045")
+}
+
+// NetBIOSInfo таблица имён NetBIOS хоста (поля smbinspect.NetBIOS).
+type NetBIOSInfo struct {
+	Name      string        `json:"name"`                // имя компьютера
+	Workgroup string        `json:"workgroup,omitempty"` // рабочая группа или домен
+	MAC       string        `json:"mac,omitempty"`       // адрес адаптера из статистики; пусто — нули (Samba)
+	Names     []NetBIOSName `json:"names,omitempty"`     // все зарегистрированные имена
+}
+
+// NetBIOSName запись таблицы имён NetBIOS
+type NetBIOSName struct {
+	Name   string `json:"name"`
+	Suffix byte   `json:"suffix"`          // тип имени: 0x00 рабочая станция, 0x20 файловый сервер, 0x1C контроллер домена
+	Group  bool   `json:"group,omitempty"` // групповое имя (рабочая группа, домен)
+}
+
+// MDNSHost устройство, объявившее службы DNS-SD (поля mdns.Host).
+type MDNSHost struct {
+	Hostname  string        `json:"hostname"`            // имя из SRV ("printer.local")
+	Addresses []string      `json:"addresses,omitempty"` // адреса A/AAAA, IPv4 первыми
+	Services  []MDNSService `json:"services,omitempty"`
+}
+
+// MDNSService экземпляр службы DNS-SD
+type MDNSService struct {
+	Instance string            `json:"instance"`      // имя экземпляра ("Office Printer")
+	Type     string            `json:"type"`          // тип службы ("_ipp._tcp")
+	Port     int               `json:"port"`          // порт из SRV
+	TXT      map[string]string `json:"txt,omitempty"` // пары TXT, ключи в нижнем регистре
+}
+
+// UPnPDevice описание корневого устройства UPnP (поля upnp.Device).
+type UPnPDevice struct {
+	Location     string            `json:"location"`                // адрес описания
+	Server       string            `json:"server,omitempty"`        // заголовок SERVER ответа SSDP
+	DeviceType   string            `json:"device_type,omitempty"`   // urn:schemas-upnp-org:device:...
+	FriendlyName string            `json:"friendly_name,omitempty"` // имя, заданное владельцем
+	Manufacturer string            `json:"manufacturer,omitempty"`
+	ModelName    string            `json:"model_name,omitempty"`
+	ModelNumber  string            `json:"model_number,omitempty"`
+	SerialNumber string            `json:"serial_number,omitempty"`
+	Firmware     string            `json:"firmware,omitempty"` // firmwareVersion/softwareVersion (расширения производителей)
+	UDN          string            `json:"udn,omitempty"`      // uuid устройства
+	Services     []string          `json:"services,omitempty"` // типы служб устройства и вложенных устройств
+	PortMappings []UPnPPortMapping `json:"port_mappings,omitempty"`
+}
+
+// UPnPPortMapping запись таблицы пробросов портов Internet Gateway Device
+type UPnPPortMapping struct {
+	RemoteHost     string `json:"remote_host,omitempty"` // разрешённый внешний адрес; пусто — любой
+	ExternalPort   int    `json:"external_port"`
+	Protocol       string `json:"protocol"` // TCP или UDP
+	InternalClient string `json:"internal_client"`
+	InternalPort   int    `json:"internal_port"`
+	Enabled        bool   `json:"enabled"`
+	Description    string `json:"description,omitempty"`
+	LeaseDuration  int    `json:"lease_duration,omitempty"` // секунд; 0 — бессрочно
+}
+
+// ScannerService интерфейс для сканирования.
+// Scan возвращает типизированные ошибки из internal/errors (InvalidInput, Permission,
+// Timeout, Cancelled); при отмене и таймауте вместе с ошибкой отдаются частичные результаты.
 type ScannerService interface {
 	Scan(ctx context.Context, cfg ScanConfig, onProgress ProgressHandler) ([]ScanResult, error)
 	Stop()
@@ -48,6 +264,9 @@ type ScannerService interface {
 // ProgressHandler обработчик прогресса сканирования
 type ProgressHandler func(stage string, current, total int, message string)
 
+// HostHandler обработчик хоста, завершённого во время сканирования
+type HostHandler func(result ScanResult)
+
 // TopologyOptions опции построения топологии
 type TopologyOptions struct {
 	SNMPEnabled     bool
@@ -90,6 +309,10 @@ type Link struct {
 // SecurityReport отчёт безопасности
 type SecurityReport struct {
 	PortAudit   []Finding
+	TLSAudit    []Finding // сертификаты и версии TLS (стадия tls)
+	SSHAudit    []Finding // алгоритмы и ключи хоста SSH (стадия ssh)
+	SMBAudit    []Finding // SMBv1 и подпись SMB (стадия smb)
+	UPnPAudit   []Finding // пробросы портов шлюзов UPnP IGD (стадия upnp)
 	RiskSig     []Finding
 	CVEs        []CVE
 	Score       int
@@ -151,6 +374,9 @@ type RemoteExecService interface {
 // InventoryService интерфейс для инвентаризации
 type InventoryService interface {
 	SaveSnapshot(ctx context.Context, id string, data []ScanResult) error
+	// SaveSnapshotWithMetadata сохраняет снапшот вместе с параметрами сканирования
+	// (цели и исключения), которые учитываются в Diff.
+	SaveSnapshotWithMetadata(ctx context.Context, id string, data []ScanResult, meta ScanMetadata) error
 	ListSnapshots(ctx context.Context, limit int) ([]Snapshot, error)
 	Diff(ctx context.Context, idA, idB string) (*Diff, error)
 }
@@ -161,13 +387,23 @@ type Snapshot struct {
 	Timestamp time.Time
 }
 
+// ScanMetadata параметры сканирования, сохраняемые вместе со снапшотом
+type ScanMetadata struct {
+	Targets      []string
+	Exclude      []string
+	ExcludePorts string
+	Proxy        string // прокси сканирования (адрес без пароля); пусто — напрямую
+}
+
 // Diff разница между снапшотами
 type Diff struct {
 	ScanIDA string
 	ScanIDB string
 	New     []ScanResult
 	Missing []ScanResult
-	Changed []Change
+	// Excluded — хосты, отсутствующие в B из-за списка исключений сканирования B
+	Excluded []ScanResult
+	Changed  []Change
 }
 
 // Change изменённое поле в снапшоте
@@ -3,11 +3,14 @@ package cve
 import (
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
 	"network-scanner/internal/redact"
 	"network-scanner/internal/scanner"
+	"network-scanner/internal/servicedetect"
 )
 
 // Entry describes a vulnerability record from a CVE source.
@@ -19,6 +22,13 @@ type Entry struct {
 	PublishedAt time.Time
 	Service     string
 	VersionHint string
+	// CPE is the affected "vendor:product" pair. When both the entry and the port carry
+	// a CPE, the match is decided by product and version range instead of VersionHint.
+	CPE string
+	// VersionStartIncluding and VersionEndExcluding bound the affected versions of CPE;
+	// an empty bound is open.
+	VersionStartIncluding string
+	VersionEndExcluding   string
 }
 
 // Match describes a concrete CVE finding on a host/port.
@@ -28,6 +38,7 @@ type Match struct {
 	Port        int
 	Service     string
 	VersionHint string
+	CPE         string // CPE of the detected product, if the port had one
 	Entry       Entry
 }
 
@@ -52,22 +63,27 @@ func NewDefaultCatalog() Catalog {
 	return Catalog{
 		entries: []Entry{
 			{
-				ID:          "CVE-2023-44487",
-				Description: "HTTP/2 Rapid Reset DoS vulnerability.",
-				URL:         "https://nvd.nist.gov/vuln/detail/CVE-2023-44487",
-				CVSS:        7.5,
-				PublishedAt: parse("2023-10-10"),
-				Service:     "http",
-				VersionHint: "nginx/1.25",
+				ID:                    "CVE-2023-44487",
+				Description:           "HTTP/2 Rapid Reset DoS vulnerability.",
+				URL:                   "https://nvd.nist.gov/vuln/detail/CVE-2023-44487",
+				CVSS:                  7.5,
+				PublishedAt:           parse("2023-10-10"),
+				Service:               "http",
+				VersionHint:           "nginx/1.25",
+				CPE:                   "f5:nginx",
+				VersionStartIncluding: "1.9.5",
+				VersionEndExcluding:   "1.25.3",
 			},
 			{
-				ID:          "CVE-2023-38408",
-				Description: "OpenSSH agent forwarding remote code execution issue.",
-				URL:         "https://nvd.nist.gov/vuln/detail/CVE-2023-38408",
-				CVSS:        9.8,
-				PublishedAt: parse("2023-07-19"),
-				Service:     "ssh",
-				VersionHint: "openssh_9.3",
+				ID:                  "CVE-2023-38408",
+				Description:         "OpenSSH agent forwarding remote code execution issue.",
+				URL:                 "https://nvd.nist.gov/vuln/detail/CVE-2023-38408",
+				CVSS:                9.8,
+				PublishedAt:         parse("2023-07-19"),
+				Service:             "ssh",
+				VersionHint:         "openssh_9.3",
+				CPE:                 "openbsd:openssh",
+				VersionEndExcluding: "9.3p2",
 			},
 			{
 				ID:          "CVE-2021-44228",
@@ -78,11 +94,38 @@ func NewDefaultCatalog() Catalog {
 				Service:     "http",
 				VersionHint: "log4j/2.14",
 			},
+			{
+				ID:                    "CVE-2021-41773",
+				Description:           "Apache HTTP Server 2.4.49 path traversal and file disclosure.",
+				URL:                   "https://nvd.nist.gov/vuln/detail/CVE-2021-41773",
+				CVSS:                  7.5,
+				PublishedAt:           parse("2021-10-05"),
+				Service:               "http",
+				VersionHint:           "apache/2.4.49",
+				CPE:                   "apache:http_server",
+				VersionStartIncluding: "2.4.49",
+				VersionEndExcluding:   "2.4.50",
+			},
+			{
+				ID:                    "CVE-2011-2523",
+				Description:           "vsftpd 2.3.4 backdoor opens a root shell on port 6200.",
+				URL:                   "https://nvd.nist.gov/vuln/detail/CVE-2011-2523",
+				CVSS:                  9.8,
+				PublishedAt:           parse("2019-11-27"),
+				Service:               "ftp",
+				VersionHint:           "vsftpd 2.3.4",
+				CPE:                   "vsftpd_project:vsftpd",
+				VersionStartIncluding: "2.3.4",
+				VersionEndExcluding:   "2.3.5",
+			},
 		},
 	}
 }
 
 // AnalyzeResults matches scan results with the catalog and applies filters.
+// Ports with a CPE (service detection) are matched against entries with a CPE by
+// vendor, product and version range; other ports and entries fall back to a substring
+// search of VersionHint in the port version and banner.
 func AnalyzeResults(results []scanner.Result, catalog Catalog, opts Options) []Match {
 	now := opts.Now
 	if now.IsZero() {
@@ -96,14 +139,18 @@ func AnalyzeResults(results []scanner.Result, catalog Catalog, opts Options) []M
 			}
 			service := normalizeService(port)
 			version := strings.ToLower(strings.TrimSpace(port.Version + " " + port.Banner))
-			if service == "" || version == "" {
+			cpe, hasCPE := servicedetect.ParseCPE(port.CPE)
+			if !hasCPE && (service == "" || version == "") {
 				continue
 			}
 			for _, e := range catalog.entries {
-				if !strings.EqualFold(service, e.Service) {
-					continue
-				}
-				if !strings.Contains(version, strings.ToLower(e.VersionHint)) {
+				if hasCPE && e.CPE != "" {
+					if !matchCPE(cpe, e) {
+						continue
+					}
+				} else if service == "" || version == "" || e.VersionHint == "" ||
+					!strings.EqualFold(service, e.Service) ||
+					!strings.Contains(version, strings.ToLower(e.VersionHint)) {
 					continue
 				}
 				if opts.MinCVSS > 0 && e.CVSS < opts.MinCVSS {
@@ -118,8 +165,9 @@ func AnalyzeResults(results []scanner.Result, catalog Catalog, opts Options) []M
 					HostIP:      host.IP,
 					HostName:    host.Hostname,
 					Port:        port.Port,
-					Service:     service,
+					Service:     firstNonEmpty(service, strings.ToLower(strings.TrimSpace(port.Service))),
 					VersionHint: strings.TrimSpace(port.Version),
+					CPE:         port.CPE,
 					Entry:       e,
 				})
 			}
@@ -143,8 +191,12 @@ func normalizeService(p scanner.PortInfo) string {
 		return "ssh"
 	case "http":
 		return "http"
+	case "ftp":
+		return "ftp"
 	default:
 		switch p.Port {
+		case 21:
+			return "ftp"
 		case 22:
 			return "ssh"
 		case 80, 443, 8080, 8443:
@@ -154,6 +206,97 @@ func normalizeService(p scanner.PortInfo) string {
 	return ""
 }
 
+// matchCPE reports whether the detected product is e.CPE and its version lies in the
+// entry's range. A port without a version does not match.
+func matchCPE(cpe servicedetect.CPE, e Entry) bool {
+	if cpe.Vendor+":"+cpe.Product != strings.ToLower(e.CPE) || cpe.Version == "" {
+		return false
+	}
+	if e.VersionStartIncluding != "" && compareVersions(cpe.Version, e.VersionStartIncluding) < 0 {
+		return false
+	}
+	if e.VersionEndExcluding != "" && compareVersions(cpe.Version, e.VersionEndExcluding) >= 0 {
+		return false
+	}
+	return true
+}
+
+// compareVersions compares versions like "9.3p1" and "2.4.49" by runs of digits
+// (numerically) and letters (lexically); a version that is a prefix of the other is
+// smaller, so "9.3" < "9.3p2".
+func compareVersions(a, b string) int {
+	ta, tb := versionTokens(a), versionTokens(b)
+	for i := 0; i < len(ta) && i < len(tb); i++ {
+		na, errA := strconv.Atoi(ta[i])
+		nb, errB := strconv.Atoi(tb[i])
+		switch {
+		case errA == nil && errB == nil:
+			if na != nb {
+				if na < nb {
+					return -1
+				}
+				return 1
+			}
+		case errA == nil:
+			return 1 // "1.0.1" > "1.0rc1"
+		case errB == nil:
+			return -1
+		default:
+			if c := strings.Compare(ta[i], tb[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	switch {
+	case len(ta) < len(tb):
+		return -1
+	case len(ta) > len(tb):
+		return 1
+	}
+	return 0
+}
+
+func versionTokens(v string) []string {
+	var tokens []string
+	var cur []rune
+	digit := false
+	flush := func() {
+		if len(cur) > 0 {
+			tokens = append(tokens, string(cur))
+			cur = cur[:0]
+		}
+	}
+	for _, r := range strings.ToLower(v) {
+		switch {
+		case unicode.IsDigit(r):
+			if !digit {
+				flush()
+			}
+			digit = true
+			cur = append(cur, r)
+		case unicode.IsLetter(r):
+			if digit {
+				flush()
+			}
+			digit = false
+			cur = append(cur, r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return tokens
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
 // FormatMatches formats findings for CLI output.
 func FormatMatches(matches []Match) string {
 	if len(matches) == 0 {
@@ -32,6 +32,60 @@ func TestAnalyzeResults_AppliesServiceVersionAndFilters(t *testing.T) {
 	}
 }
 
+func TestAnalyzeResults_MatchesCPEVersionRange(t *testing.T) {
+	results := []scanner.Result{
+		{
+			IP: "192.168.1.20",
+			Ports: []scanner.PortInfo{
+				// Non-standard port: the product is known from the detected CPE only
+				{Port: 2222, State: "open", Service: "ssh", Product: "OpenSSH", Version: "9.3p1", CPE: "cpe:2.3:a:openbsd:openssh:9.3p1:*:*:*:*:*:*:*"},
+				// Fixed release: the banner contains nginx/1.25, but the CPE is out of range
+				{Port: 80, State: "open", Service: "http", Version: "1.25.3", Banner: "server: nginx/1.25.3", CPE: "cpe:2.3:a:f5:nginx:1.25.3:*:*:*:*:*:*:*"},
+				{Port: 8080, State: "open", Service: "http", Version: "2.4.49", CPE: "cpe:2.3:a:apache:http_server:2.4.49:*:*:*:*:*:*:*"},
+				{Port: 2121, State: "open", Service: "ftp", Version: "2.3.4", CPE: "cpe:2.3:a:vsftpd_project:vsftpd:2.3.4:*:*:*:*:*:*:*"},
+				// Unknown version: the range cannot be checked
+				{Port: 8443, State: "open", Service: "https", CPE: "cpe:2.3:a:f5:nginx:*:*:*:*:*:*:*:*"},
+			},
+		},
+	}
+	matches := AnalyzeResults(results, NewDefaultCatalog(), Options{})
+	got := make(map[string]int)
+	for _, m := range matches {
+		got[m.Entry.ID] = m.Port
+	}
+	want := map[string]int{"CVE-2023-38408": 2222, "CVE-2021-41773": 8080, "CVE-2011-2523": 2121}
+	if len(got) != len(want) {
+		t.Fatalf("matches = %+v, want %v", matches, want)
+	}
+	for id, port := range want {
+		if got[id] != port {
+			t.Errorf("%s: port %d, want %d", id, got[id], port)
+		}
+	}
+	if matches[0].CPE == "" {
+		t.Errorf("match without CPE: %+v", matches[0])
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"9.3p1", "9.3p2", -1},
+		{"9.3", "9.3p2", -1},
+		{"9.4p1", "9.3p2", 1},
+		{"1.25.10", "1.25.3", 1},
+		{"2.4.49", "2.4.49", 0},
+		{"1.0.1", "1.0rc1", 1},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
 func TestFormatMatches_Empty(t *testing.T) {
 	got := FormatMatches(nil)
 	if !strings.Contains(got, "не найдено") {
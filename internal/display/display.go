@@ -11,9 +11,15 @@ import (
 
 	"github.com/jedib0t/go-pretty/v6/table"
 
+	"network-scanner/internal/httpinspect"
+	"network-scanner/internal/mdns"
 	"network-scanner/internal/network"
 	portdb "network-scanner/internal/ports"
 	"network-scanner/internal/scanner"
+	"network-scanner/internal/smbinspect"
+	"network-scanner/internal/sshinspect"
+	"network-scanner/internal/tlsinspect"
+	"network-scanner/internal/upnp"
 )
 
 var showRawBanners bool
@@ -120,20 +126,36 @@ func formatPorts(ports []scanner.PortInfo) string {
 			if p.Service != "Unknown" {
 				portStr += fmt.Sprintf(" (%s)", p.Service)
 			}
-			if strings.TrimSpace(p.Version) != "" {
-				portStr += fmt.Sprintf(" [version: %s]", truncateString(strings.TrimSpace(p.Version), 80))
+			if version := strings.TrimSpace(p.Product + " " + p.Version); version != "" {
+				portStr += fmt.Sprintf(" [version: %s]", truncateString(version, 80))
+			}
+			if p.TLS != nil {
+				portStr += fmt.Sprintf(" [tls: %s]", p.TLS.Summary())
+			}
+			if p.SSH != nil {
+				portStr += fmt.Sprintf(" [ssh: %s]", p.SSH.Summary())
+			}
+			if p.HTTP != nil {
+				portStr += fmt.Sprintf(" [http: %s]", truncateString(p.HTTP.Summary(), 80))
+			}
+			if p.SMB != nil {
+				portStr += fmt.Sprintf(" [smb: %s]", p.SMB.Summary())
 			}
 			if showRawBanners && strings.TrimSpace(p.Banner) != "" {
 				portStr += fmt.Sprintf(" [banner: %s]", truncateString(strings.TrimSpace(p.Banner), 80))
 			}
 			portStrs = append(portStrs, portStr)
 			openPortsCount++
-		} else if p.State == "closed" {
-			// Для закрытых портов тоже ограничиваем, но отдельно
+		} else if p.State != "" {
+			// Для закрытых и фильтруемых портов тоже ограничиваем, но отдельно
 			if len(portStrs) >= maxPorts*2 { // Учитываем и открытые, и закрытые
 				break
 			}
-			portStr := fmt.Sprintf("%d/%s (closed)", p.Port, p.Protocol)
+			state := p.State
+			if p.Reason != "" {
+				state += ": " + p.Reason
+			}
+			portStr := fmt.Sprintf("%d/%s (%s)", p.Port, p.Protocol, state)
 			portStrs = append(portStrs, portStr)
 		}
 	}
@@ -621,7 +643,16 @@ func SaveResultsToJSON(results []scanner.Result, filename string) error {
 		Protocol string `json:"protocol"`
 		Service  string `json:"service"`
 		Version  string `json:"version,omitempty"`
+		Product  string `json:"product,omitempty"`
+		Info     string `json:"info,omitempty"`
+		CPE      string `json:"cpe,omitempty"`
+		TLS      *tlsinspect.Info `json:"tls,omitempty"`
+		SSH      *sshinspect.Info `json:"ssh,omitempty"`
+		HTTP     *httpinspect.Info `json:"http,omitempty"`
+		SMB      *smbinspect.Info `json:"smb,omitempty"`
 		Banner   string `json:"banner,omitempty"`
+		Reason   string `json:"reason,omitempty"`
+		LatencyMs float64 `json:"latency_ms,omitempty"` // время до ответа на пробу, мс
 	}
 
 	type JSONResult struct {
@@ -636,6 +667,9 @@ func SaveResultsToJSON(results []scanner.Result, filename string) error {
 		GuessOS      string     `json:"guess_os,omitempty"`
 		GuessOSConfidence string `json:"guess_os_confidence,omitempty"`
 		GuessOSReason string    `json:"guess_os_reason,omitempty"`
+		NetBIOS      *smbinspect.NetBIOS `json:"netbios,omitempty"`
+		MDNS         *mdns.Host          `json:"mdns,omitempty"`
+		UPnP         []upnp.Device       `json:"upnp,omitempty"`
 	}
 
 	type JSONAnalytics struct {
@@ -672,7 +706,16 @@ func SaveResultsToJSON(results []scanner.Result, filename string) error {
 				Protocol: port.Protocol,
 				Service:  port.Service,
 				Version:  strings.TrimSpace(port.Version),
+				Product:  port.Product,
+				Info:     port.Info,
+				CPE:      port.CPE,
+				TLS:      port.TLS,
+				SSH:      port.SSH,
+				HTTP:     port.HTTP,
+				SMB:      port.SMB,
 				Banner:   strings.TrimSpace(port.Banner),
+				Reason:   port.Reason,
+				LatencyMs: float64(port.Latency.Microseconds()) / 1000,
 			})
 			if port.State == "open" {
 				portStats[port.Port]++
@@ -705,6 +748,9 @@ func SaveResultsToJSON(results []scanner.Result, filename string) error {
 			GuessOS:      strings.TrimSpace(result.GuessOS),
 			GuessOSConfidence: strings.TrimSpace(result.GuessOSConfidence),
 			GuessOSReason: strings.TrimSpace(result.GuessOSReason),
+			NetBIOS:      result.NetBIOS,
+			MDNS:         result.MDNS,
+			UPnP:         result.UPnP,
 		})
 	}
 
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"network-scanner/internal/scanner"
 )
@@ -191,6 +192,43 @@ func TestFormatPorts_ShowRawBannerToggle(t *testing.T) {
 	}
 }
 
+func TestFormatPorts_StateReason(t *testing.T) {
+	ports := []scanner.PortInfo{
+		{Port: 22, State: "open", Protocol: "tcp", Service: "SSH", Reason: "syn-ack"},
+		{Port: 23, State: "closed", Protocol: "tcp", Reason: "conn-refused"},
+		{Port: 445, State: "filtered", Protocol: "tcp", Reason: "no-response"},
+		{Port: 161, State: "open|filtered", Protocol: "udp"},
+	}
+	got := formatPorts(ports)
+	for _, want := range []string{"22/tcp (SSH)", "23/tcp (closed: conn-refused)", "445/tcp (filtered: no-response)", "161/udp (open|filtered)"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("formatPorts() = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestSaveResultsToJSON_PortReason(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.json")
+	results := []scanner.Result{{
+		IP: "192.168.1.10",
+		Ports: []scanner.PortInfo{
+			{Port: 445, State: "filtered", Protocol: "tcp", Reason: "host-unreach", Latency: 1500 * time.Microsecond},
+		},
+	}}
+	if err := SaveResultsToJSON(results, path); err != nil {
+		t.Fatalf("SaveResultsToJSON() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"reason": "host-unreach"`, `"latency_ms": 1.5`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("JSON не содержит %s:\n%s", want, data)
+		}
+	}
+}
+
 func TestFormatResultsAsText_Golden(t *testing.T) {
 	t.Setenv("TZ", "UTC")
 	SetShowRawBanners(false)
@@ -15,6 +15,7 @@ var (
 	ErrAlreadyExists  = errors.New("resource already exists")
 	ErrConflict       = errors.New("conflict detected")
 	ErrInternal       = errors.New("internal server error")
+	ErrCancelled      = errors.New("operation cancelled")
 )
 
 // NotFoundError represents a resource not found error
@@ -74,6 +75,19 @@ func (e *InvalidInputError) Unwrap() error {
 	return ErrInvalidInput
 }
 
+// CancelledError represents an operation cancelled by the caller
+type CancelledError struct {
+	Operation string
+}
+
+func (e *CancelledError) Error() string {
+	return fmt.Sprintf("operation '%s' cancelled", e.Operation)
+}
+
+func (e *CancelledError) Unwrap() error {
+	return ErrCancelled
+}
+
 // Helper functions
 
 // NewNotFoundError creates a new NotFoundError
@@ -120,6 +134,17 @@ func IsInvalidInput(err error) bool {
 	return errors.As(err, &input) || errors.Is(err, ErrInvalidInput)
 }
 
+// NewCancelledError creates a new CancelledError
+func NewCancelledError(operation string) error {
+	return &CancelledError{Operation: operation}
+}
+
+// IsCancelled checks if error is a CancelledError
+func IsCancelled(err error) bool {
+	var cancelled *CancelledError
+	return errors.As(err, &cancelled) || errors.Is(err, ErrCancelled)
+}
+
 // WrapError wraps an error with context
 func WrapError(err error, message string) error {
 	if err == nil {
@@ -61,6 +61,24 @@ func TestErrorTypes(t *testing.T) {
 			checkFn:  IsInvalidInput,
 			expected: true,
 		},
+		{
+			name:     "ErrCancelled",
+			err:      ErrCancelled,
+			checkFn:  IsCancelled,
+			expected: true,
+		},
+		{
+			name:     "CancelledError",
+			err:      NewCancelledError("scan"),
+			checkFn:  IsCancelled,
+			expected: true,
+		},
+		{
+			name:     "TimeoutError is not cancelled",
+			err:      NewTimeoutError("scan", time.Second),
+			checkFn:  IsCancelled,
+			expected: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -111,6 +129,19 @@ func TestPermissionError(t *testing.T) {
 	}
 }
 
+func TestCancelledError(t *testing.T) {
+	err := NewCancelledError("scan")
+
+	expectedMsg := "operation 'scan' cancelled"
+	if err.Error() != expectedMsg {
+		t.Errorf("unexpected error message: %s", err.Error())
+	}
+
+	if !errors.Is(err, ErrCancelled) {
+		t.Error("expected error to wrap ErrCancelled")
+	}
+}
+
 func TestInvalidInputError(t *testing.T) {
 	err := NewInvalidInputError("cidr", "invalid format")
 
@@ -71,6 +71,7 @@ type App struct {
 	myWindow                    fyne.Window
 	scanResults                 []scanner.Result
 	scanRunner                  *scand.Runner
+	scanTiming                  string // профиль скорости выбранного пресета (scanner.Timing*); пусто — без профиля
 	networkEntry                *widget.Entry
 	portRangeEntry              *widget.Entry
 	timeoutEntry                *widget.Entry
@@ -79,6 +80,10 @@ type App struct {
 	scanBannersCheck            *widget.Check
 	scanOSActiveCheck           *widget.Check
 	scanVerboseLogsCheck        *widget.Check
+	scanShowClosedCheck         *widget.Check
+	scanInterfaceSelect         *widget.Select // интерфейс источника проб (interfaceAutoOption — выбор ОС)
+	scanSourceIPEntry           *widget.Entry  // адрес источника проб; пусто — адрес интерфейса
+	scanProxyEntry              *widget.Entry  // прокси для TCP-проб (socks5:// или ssh://); пусто — напрямую
 	scanVerboseInfoBtn          *widget.Button
 	autoProfileCheck            *widget.Check
 	autoProfileInfoBtn          *widget.Button
@@ -87,6 +92,7 @@ type App struct {
 	presetQuickBtn              *widget.Button
 	presetBalBtn                *widget.Button
 	presetDeepBtn               *widget.Button
+	presetPoliteBtn             *widget.Button
 	recommendedProfileBtn       *widget.Button
 	recommendedProfileInfoBtn   *widget.Button
 	recommendedProfileBadge     *canvas.Text
@@ -267,6 +273,10 @@ const (
 	prefScanBanners             = "scan.grab_banners"
 	prefScanOSActive            = "scan.os_detect_active"
 	prefScanVerbosePortLogs     = "scan.verbose_port_logs"
+	prefScanShowClosed          = "scan.show_closed"
+	prefScanInterface           = "scan.interface"
+	prefScanSourceIP            = "scan.source_ip"
+	prefScanProxy               = "scan.proxy"
 	prefScanTCPPorts            = "scan.scan_tcp_ports"
 	prefAutoProfile             = "scan.auto_profile"
 	prefPreset                  = "scan.preset"
@@ -753,6 +763,9 @@ func (a *App) setupEventHandlers() {
 	a.presetDeepBtn.OnTapped = func() {
 		a.applyScanPreset("deep")
 	}
+	a.presetPoliteBtn.OnTapped = func() {
+		a.applyScanPreset("polite")
+	}
 	if a.recommendedProfileBtn != nil {
 		a.recommendedProfileBtn.OnTapped = func() {
 			a.applyRecommendedScanProfile()
@@ -775,6 +788,21 @@ func (a *App) setupEventHandlers() {
 	a.networkEntry.OnChanged = func(_ string) {
 		a.saveScanSettings()
 	}
+	if a.scanInterfaceSelect != nil {
+		a.scanInterfaceSelect.OnChanged = func(_ string) {
+			a.saveScanSettings()
+		}
+	}
+	if a.scanSourceIPEntry != nil {
+		a.scanSourceIPEntry.OnChanged = func(_ string) {
+			a.saveScanSettings()
+		}
+	}
+	if a.scanProxyEntry != nil {
+		a.scanProxyEntry.OnChanged = func(_ string) {
+			a.saveScanSettings()
+		}
+	}
 	a.portRangeEntry.OnChanged = func(_ string) {
 		a.saveScanSettings()
 	}
@@ -808,6 +836,11 @@ func (a *App) setupEventHandlers() {
 			a.saveScanSettings()
 		}
 	}
+	if a.scanShowClosedCheck != nil {
+		a.scanShowClosedCheck.OnChanged = func(_ bool) {
+			a.saveScanSettings()
+		}
+	}
 	if a.scanVerboseInfoBtn != nil {
 		a.scanVerboseInfoBtn.OnTapped = func() {
 			dialog.ShowInformation(
@@ -1048,6 +1081,7 @@ func (a *App) applyScanPreset(mode string) {
 		a.scanUDPCheck.SetChecked(false)
 		a.scanBannersCheck.SetChecked(false)
 		a.scanOSActiveCheck.SetChecked(false)
+		a.scanTiming = scanner.TimingAggressive
 		a.statusLabel.SetText("Пресет: Быстро (обзор)")
 	case "deep":
 		// Глубокий анализ: больше портов и выше таймаут для точности.
@@ -1057,7 +1091,19 @@ func (a *App) applyScanPreset(mode string) {
 		a.scanUDPCheck.SetChecked(true)
 		a.scanBannersCheck.SetChecked(true)
 		a.scanOSActiveCheck.SetChecked(true)
+		a.scanTiming = scanner.TimingNormal
 		a.statusLabel.SetText("Пресет: Глубоко (детальный анализ)")
+	case "polite":
+		// Бережный режим для сетей с IDS и слабых маршрутизаторов: лимит проб в секунду и на хост.
+		polite := scanner.TimingTemplates[scanner.TimingPolite]
+		a.portRangeEntry.SetText("1-1000")
+		a.timeoutEntry.SetText(strconv.Itoa(int(polite.Timeout / time.Second)))
+		a.threadsEntry.SetText(strconv.Itoa(polite.Threads))
+		a.scanUDPCheck.SetChecked(false)
+		a.scanBannersCheck.SetChecked(false)
+		a.scanOSActiveCheck.SetChecked(false)
+		a.scanTiming = scanner.TimingPolite
+		a.statusLabel.SetText("Пресет: Бережно (ограничение скорости)")
 	default:
 		// Баланс между скоростью и полнотой.
 		a.portRangeEntry.SetText("1-1000")
@@ -1066,6 +1112,7 @@ func (a *App) applyScanPreset(mode string) {
 		a.scanUDPCheck.SetChecked(false)
 		a.scanBannersCheck.SetChecked(false)
 		a.scanOSActiveCheck.SetChecked(false)
+		a.scanTiming = scanner.TimingNormal
 		a.statusLabel.SetText("Пресет: Баланс")
 	}
 	a.myApp.Preferences().SetString(prefPreset, mode)
@@ -1086,7 +1133,7 @@ func (a *App) applyRecommendedScanProfile() {
 	}
 	hosts := 0
 	if networkStr != "" {
-		if h, err := network.EstimateHostCount(networkStr); err == nil && h > 0 {
+		if h, err := network.EstimateTargetCount(network.SplitTargets(networkStr)); err == nil && h > 0 {
 			hosts = h
 		}
 	}
@@ -1140,6 +1187,7 @@ func (a *App) applyRecommendedScanProfile() {
 		a.recommendedProfileBadge.Color = color.RGBA{R: 55, G: 130, B: 200, A: 255}
 		a.recommendedProfileBadge.Refresh()
 	}
+	a.scanTiming = ""
 	if a.myApp != nil {
 		a.myApp.Preferences().SetString(prefPreset, "recommended")
 		a.myApp.Preferences().SetString(prefRecommendedBadgeClass, badgeClass)
@@ -1190,6 +1238,12 @@ func (a *App) saveScanSettings() {
 	}
 	p := a.myApp.Preferences()
 	p.SetString(prefNetwork, strings.TrimSpace(a.networkEntry.Text))
+	iface, sourceIP := a.scanSourceSettings()
+	p.SetString(prefScanInterface, iface)
+	p.SetString(prefScanSourceIP, sourceIP)
+	if a.scanProxyEntry != nil {
+		p.SetString(prefScanProxy, strings.TrimSpace(a.scanProxyEntry.Text))
+	}
 	p.SetString(prefPortRange, strings.TrimSpace(a.portRangeEntry.Text))
 	p.SetString(prefTimeout, strings.TrimSpace(a.timeoutEntry.Text))
 	p.SetString(prefThreads, strings.TrimSpace(a.threadsEntry.Text))
@@ -1213,6 +1267,11 @@ func (a *App) saveScanSettings() {
 	} else {
 		p.SetString(prefScanVerbosePortLogs, "false")
 	}
+	if a.scanShowClosedCheck != nil && a.scanShowClosedCheck.Checked {
+		p.SetString(prefScanShowClosed, "true")
+	} else {
+		p.SetString(prefScanShowClosed, "false")
+	}
 	if a.scanTCPPortsCheck != nil {
 		if a.scanTCPPortsCheck.Checked {
 			p.SetString(prefScanTCPPorts, "true")
@@ -1281,7 +1340,7 @@ func (a *App) setPortRangeControlsEnabled(enabled bool) {
 		}
 	}
 	for _, b := range []*widget.Button{
-		a.presetQuickBtn, a.presetBalBtn, a.presetDeepBtn,
+		a.presetQuickBtn, a.presetBalBtn, a.presetDeepBtn, a.presetPoliteBtn,
 		a.portWellKnownBtn, a.portRegisteredBtn, a.portDynamicBtn,
 	} {
 		if b != nil {
@@ -1439,6 +1498,24 @@ func (a *App) loadScanSettings() {
 	if v := strings.TrimSpace(p.String(prefNetwork)); v != "" {
 		a.networkEntry.SetText(v)
 	}
+	if a.scanInterfaceSelect != nil {
+		// Сохранённый интерфейс мог исчезнуть — тогда остаётся выбор ОС
+		a.scanInterfaceSelect.SetSelected(interfaceAutoOption)
+		if name := strings.TrimSpace(p.String(prefScanInterface)); name != "" {
+			for _, option := range a.scanInterfaceSelect.Options {
+				if interfaceFromOption(option) == name {
+					a.scanInterfaceSelect.SetSelected(option)
+					break
+				}
+			}
+		}
+	}
+	if a.scanSourceIPEntry != nil {
+		a.scanSourceIPEntry.SetText(strings.TrimSpace(p.String(prefScanSourceIP)))
+	}
+	if a.scanProxyEntry != nil {
+		a.scanProxyEntry.SetText(strings.TrimSpace(p.String(prefScanProxy)))
+	}
 	if v := strings.TrimSpace(p.String(prefPortRange)); v != "" {
 		a.portRangeEntry.SetText(v)
 	}
@@ -1458,6 +1535,9 @@ func (a *App) loadScanSettings() {
 	if a.scanVerboseLogsCheck != nil {
 		a.scanVerboseLogsCheck.SetChecked(strings.EqualFold(strings.TrimSpace(p.String(prefScanVerbosePortLogs)), "true"))
 	}
+	if a.scanShowClosedCheck != nil {
+		a.scanShowClosedCheck.SetChecked(strings.EqualFold(strings.TrimSpace(p.String(prefScanShowClosed)), "true"))
+	}
 	if a.scanTCPPortsCheck != nil {
 		tcpPref := strings.TrimSpace(p.String(prefScanTCPPorts))
 		if tcpPref == "" || strings.EqualFold(tcpPref, "true") {
@@ -1479,11 +1559,17 @@ func (a *App) loadScanSettings() {
 
 	switch strings.TrimSpace(p.String(prefPreset)) {
 	case "quick":
+		a.scanTiming = scanner.TimingAggressive
 		a.statusLabel.SetText("Пресет: Быстро (восстановлен)")
 	case "deep":
+		a.scanTiming = scanner.TimingNormal
 		a.statusLabel.SetText("Пресет: Глубоко (восстановлен)")
 	case "balanced":
+		a.scanTiming = scanner.TimingNormal
 		a.statusLabel.SetText("Пресет: Баланс (восстановлен)")
+	case "polite":
+		a.scanTiming = scanner.TimingPolite
+		a.statusLabel.SetText("Пресет: Бережно (восстановлен)")
 	case "recommended":
 		a.statusLabel.SetText("Пресет: Рекомендуемые настройки (восстановлен)")
 	}
@@ -1616,6 +1702,16 @@ func (a *App) loadScanSettings() {
 		if a.resultsPortStateSel != nil {
 			a.resultsPortStateSel.SetSelected("Есть фильтруемые")
 		}
+	case "has_no_response":
+		a.resultsPortStateMode = mode
+		if a.resultsPortStateSel != nil {
+			a.resultsPortStateSel.SetSelected("Есть без ответа (DROP)")
+		}
+	case "has_rejected":
+		a.resultsPortStateMode = mode
+		if a.resultsPortStateSel != nil {
+			a.resultsPortStateSel.SetSelected("Есть отклонённые (RST/ICMP)")
+		}
 	default:
 		a.resultsPortStateMode = "all"
 		if a.resultsPortStateSel != nil {
@@ -1795,6 +1891,14 @@ func (a *App) applyFilterPreset(slot string) {
 		if a.resultsPortStateSel != nil {
 			a.resultsPortStateSel.SetSelected("Есть фильтруемые")
 		}
+	case "has_no_response":
+		if a.resultsPortStateSel != nil {
+			a.resultsPortStateSel.SetSelected("Есть без ответа (DROP)")
+		}
+	case "has_rejected":
+		if a.resultsPortStateSel != nil {
+			a.resultsPortStateSel.SetSelected("Есть отклонённые (RST/ICMP)")
+		}
 	default:
 		a.resultsPortStateMode = "all"
 		if a.resultsPortStateSel != nil {
@@ -2072,7 +2176,10 @@ func (a *App) runWiFiTool() {
 
 func (a *App) runPortAuditTool() {
 	a.runToolOperation("Port Audit", "Выполняется аудит портов...", func(ctx context.Context) (string, error) {
-		findings := audit.EvaluateOpenPorts(a.scanResults)
+		findings := append(audit.EvaluateOpenPorts(a.scanResults), audit.EvaluateTLS(a.scanResults, time.Now())...)
+		findings = append(findings, audit.EvaluateSSH(a.scanResults)...)
+		findings = append(findings, audit.EvaluateSMB(a.scanResults)...)
+		findings = append(findings, audit.EvaluateUPnP(a.scanResults)...)
 		minSeverity := "all"
 		if a.toolsAuditMinSeveritySel != nil {
 			if norm, ok := audit.NormalizeSeverity(strings.TrimSpace(a.toolsAuditMinSeveritySel.Selected)); ok {
@@ -2395,14 +2502,35 @@ func (a *App) startScan() {
 	logger.Log("Запуск сканирования из GUI")
 	logger.LogDebug("Пользователь нажал кнопку 'Запустить сканирование'")
 
+	sourceIface, sourceIP := a.scanSourceSettings()
+	source, err := network.ResolveSource(sourceIface, sourceIP)
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("источник проб: %v", err), a.myWindow)
+		return
+	}
+	proxyURL := ""
+	if a.scanProxyEntry != nil {
+		proxyURL = strings.TrimSpace(a.scanProxyEntry.Text)
+	}
+	if proxyURL != "" {
+		if _, err := network.ParseProxyURL(proxyURL); err != nil {
+			dialog.ShowError(fmt.Errorf("прокси: %v", err), a.myWindow)
+			return
+		}
+	}
+
 	// Определяем сеть
 	networkStr := a.networkEntry.Text
+	if networkStr == "" && proxyURL != "" {
+		dialog.ShowError(fmt.Errorf("при сканировании через прокси укажите сеть: сеть за прокси не определяется автоматически"), a.myWindow)
+		return
+	}
 	if networkStr == "" {
 		logger.Log("Автоматическое определение сети...")
 		logger.LogDebug("Поле сети пустое, начинаем автоматическое определение")
 		detectStartTime := time.Now()
 		var err error
-		networkStr, err = network.DetectLocalNetwork()
+		networkStr, err = network.DetectSourceNetwork(source)
 		detectDuration := time.Since(detectStartTime)
 		if err != nil {
 			logger.LogError(err, "Определение сети в GUI")
@@ -2417,7 +2545,7 @@ func (a *App) startScan() {
 		logger.Log("Использована указанная сеть: %s", networkStr)
 		logger.LogDebug("Сеть указана пользователем в поле ввода")
 	}
-	if hosts, err := network.EstimateHostCount(networkStr); err == nil && hosts >= largeSubnetWarnHostGUI && !a.confirmLargeScanBypass {
+	if hosts, err := network.EstimateTargetCount(network.SplitTargets(networkStr)); err == nil && hosts >= largeSubnetWarnHostGUI && !a.confirmLargeScanBypass {
 		dialog.NewConfirm(
 			"Предупреждение о крупной подсети",
 			fmt.Sprintf("Подсеть %s содержит примерно %d хостов.\nСканирование может занять продолжительное время и повлиять на отзывчивость интерфейса.\n\nПродолжить?", networkStr, hosts),
@@ -2500,12 +2628,16 @@ func (a *App) startScan() {
 		Timeout:        time.Duration(timeoutSec) * time.Second,
 		PortRange:      portRange,
 		Threads:        threads,
-		ShowClosed:     false,
+		ShowClosed:     a.scanShowClosedCheck != nil && a.scanShowClosedCheck.Checked,
+		Interface:      sourceIface,
+		SourceIP:       sourceIP,
+		Proxy:          proxyURL,
 		ScanTCPPorts:   a.scanTCPPortsCheck.Checked,
 		ScanUDP:        a.scanUDPCheck.Checked,
 		GrabBanners:    a.scanBannersCheck != nil && a.scanBannersCheck.Checked,
 		OSDetectActive: a.scanOSActiveCheck != nil && a.scanOSActiveCheck.Checked,
 		VerbosePortLog: a.scanVerboseLogsCheck != nil && a.scanVerboseLogsCheck.Checked,
+		Timing:         a.scanTiming,
 	}
 	runner := scand.NewRunner()
 	a.scanRunner = runner
@@ -2532,7 +2664,7 @@ func autoScanProfile(networkStr string, portRange string, threads int) (string,
 	if threads < 1 {
 		threads = 1
 	}
-	hosts, err := network.EstimateHostCount(strings.TrimSpace(networkStr))
+	hosts, err := network.EstimateTargetCount(network.SplitTargets(networkStr))
 	if err != nil || hosts < autoProfileHostWarn {
 		return portRange, threads, ""
 	}
@@ -2608,7 +2740,7 @@ func estimateScanUITimeout(networkStr, portRange, timeoutText, threadsText strin
 	}
 
 	hosts := 256
-	if h, err := network.EstimateHostCount(strings.TrimSpace(networkStr)); err == nil && h > 0 {
+	if h, err := network.EstimateTargetCount(network.SplitTargets(networkStr)); err == nil && h > 0 {
 		hosts = h
 	}
 
@@ -2770,10 +2902,15 @@ func (a *App) buildTopology() {
 	snmpStartedAt := time.Now()
 	ctx, cancel := context.WithCancel(context.Background())
 	a.topologyCancel = cancel
+	// SNMP-запросы уходят с того же источника, что и пробы сканирования
+	source, err := network.ResolveSource(a.scanSourceSettings())
+	if err != nil {
+		logger.Log("Источник проб для SNMP не применён: %v", err)
+	}
 
 	go func() {
 		snmpPhaseStartedAt := time.Now()
-		snmpData, report, err := snmpcollector.CollectWithReportProgressContext(ctx, a.scanResults, communities, timeoutSec, func(current int, total int, ip string, message string) {
+		snmpData, report, err := snmpcollector.CollectFromContext(ctx, source, a.scanResults, communities, timeoutSec, func(current int, total int, ip string, message string) {
 			etaText := ""
 			progressValue := 0.0
 			if total > 0 && current > 0 && current < total {
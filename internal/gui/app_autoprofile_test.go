@@ -50,3 +50,14 @@ func TestAutoScanProfile_CapsVeryLargeSubnet(t *testing.T) {
 	}
 }
 
+
+func TestAutoScanProfile_SumsTargetList(t *testing.T) {
+	// Две /22 (~2040 хостов) вместе — крупный набор целей, хотя каждая подсеть по отдельности меньше.
+	portRange, threads, _ := autoScanProfile("10.0.0.0/22, 10.0.4.0/22", "1-65535", 120)
+	if portRange != "1-1024" {
+		t.Fatalf("expected capped range 1-1024, got %s", portRange)
+	}
+	if threads != 40 {
+		t.Fatalf("expected capped threads 40, got %d", threads)
+	}
+}
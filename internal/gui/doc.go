@@ -31,7 +31,8 @@
 //
 //   - Text filter: поиск по hostname, IP, MAC, device type
 //   - CIDR filter: фильтрация по подсети (например 192.168.1.0/24)
-//   - Port state filter: "has_open", "has_closed", "has_filtered"
+//   - Port state filter: "has_open", "has_closed", "has_filtered", "has_no_response"
+//     (DROP: проба без ответа), "has_rejected" (REJECT: RST или ICMP unreachable)
 //   - Type filter: "Network Device", "Computer", "Server", "Unknown"
 //   - Open ports only: только устройства с открытыми портами
 //
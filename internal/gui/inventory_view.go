@@ -108,6 +108,9 @@ func (a *App) inventoryDiffMarkdown() string {
 	sb.WriteString(fmt.Sprintf("- Snapshot B: `%s`\n", diff.ScanIDB))
 	sb.WriteString(fmt.Sprintf("- New devices: `%d`\n", len(diff.New)))
 	sb.WriteString(fmt.Sprintf("- Missing devices: `%d`\n", len(diff.Missing)))
+	if len(diff.Excluded) > 0 {
+		sb.WriteString(fmt.Sprintf("- Excluded devices: `%d`\n", len(diff.Excluded)))
+	}
 	sb.WriteString(fmt.Sprintf("- Changed devices: `%d`\n", len(diff.Changed)))
 	if len(diff.New) > 0 {
 		sb.WriteString("\n#### New\n")
@@ -121,6 +124,12 @@ func (a *App) inventoryDiffMarkdown() string {
 			sb.WriteString(fmt.Sprintf("- `%s` (%s)\n", nullDash(h.IP), nullDash(h.Hostname)))
 		}
 	}
+	if len(diff.Excluded) > 0 {
+		sb.WriteString("\n#### Excluded (не сканировались в B)\n")
+		for _, h := range diff.Excluded {
+			sb.WriteString(fmt.Sprintf("- `%s` (%s)\n", nullDash(h.IP), nullDash(h.Hostname)))
+		}
+	}
 	if len(diff.Changed) > 0 {
 		sb.WriteString("\n#### Changed\n")
 		for _, ch := range diff.Changed {
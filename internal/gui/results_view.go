@@ -15,6 +15,7 @@ import (
 	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/widget"
 
+	"network-scanner/internal/network"
 	"network-scanner/internal/scanner"
 )
 
@@ -158,6 +159,22 @@ func (a *App) passesPortStateMode(r scanner.Result) bool {
 			}
 		}
 		return false
+	case "has_no_response":
+		// Пробы отброшены без ответа (DROP)
+		for _, p := range r.Ports {
+			if p.Reason == network.ReasonNoResponse {
+				return true
+			}
+		}
+		return false
+	case "has_rejected":
+		// Пробы отклонены: RST от хоста или ICMP unreachable от хоста/межсетевого экрана (REJECT)
+		for _, p := range r.Ports {
+			if p.Protocol == "tcp" && p.State != "open" && p.Reason != "" && p.Reason != network.ReasonNoResponse {
+				return true
+			}
+		}
+		return false
 	default:
 		return true
 	}
@@ -284,12 +301,16 @@ func (a *App) renderScanResultsView() {
 		a.clearResultsMainSplitRef()
 		return
 	case resultsStateScanning:
-		a.resultsBody.Objects = []fyne.CanvasObject{
-			container.NewCenter(widget.NewLabel("Сканирование...")),
+		// Пока не пришёл ни один хост — показываем заглушку; дальше таблица
+		// наполняется по мере завершения хостов.
+		if len(a.scanResults) == 0 {
+			a.resultsBody.Objects = []fyne.CanvasObject{
+				container.NewCenter(widget.NewLabel("Сканирование...")),
+			}
+			a.resultsBody.Refresh()
+			a.clearResultsMainSplitRef()
+			return
 		}
-		a.resultsBody.Refresh()
-		a.clearResultsMainSplitRef()
-		return
 	}
 
 	filtered := a.filteredSortedResults()
@@ -622,6 +643,9 @@ func (a *App) hostDetailsMarkdown(r scanner.Result) string {
 		r.SNMPEnabled,
 		countOpenPorts(r.Ports),
 	)
+	if line := nonOpenPortsLine(r.Ports, 16); line != "" {
+		md += "\n- Closed/filtered: " + line
+	}
 	a.hostDetailsCacheMu.Lock()
 	if a.hostDetailsCache == nil {
 		a.hostDetailsCache = make(map[string]string)
@@ -717,6 +741,31 @@ func countOpenPorts(ports []scanner.PortInfo) int {
 	return n
 }
 
+// nonOpenPortsLine перечисляет до limit неоткрытых портов с состоянием и причиной
+// (например, "`445/tcp` filtered: no-response"); пусто, если таких портов нет.
+func nonOpenPortsLine(ports []scanner.PortInfo, limit int) string {
+	parts := make([]string, 0, limit+1)
+	total := 0
+	for _, p := range ports {
+		if p.State == "open" || p.State == "" {
+			continue
+		}
+		total++
+		if len(parts) >= limit {
+			continue
+		}
+		part := fmt.Sprintf("`%d/%s` %s", p.Port, p.Protocol, p.State)
+		if p.Reason != "" {
+			part += ": " + p.Reason
+		}
+		parts = append(parts, part)
+	}
+	if total > limit {
+		parts = append(parts, fmt.Sprintf("+%d", total-limit))
+	}
+	return strings.Join(parts, ", ")
+}
+
 func (a *App) buildPortChips(r scanner.Result) fyne.CanvasObject {
 	var open []scanner.PortInfo
 	for _, p := range r.Ports {
@@ -743,8 +792,17 @@ func (a *App) buildPortChips(r scanner.Result) fyne.CanvasObject {
 		} else {
 			lbl = fmt.Sprintf("%d %s", p.Port, lbl)
 		}
-		if strings.TrimSpace(p.Version) != "" {
-			lbl += " · " + truncateStr(p.Version, 40)
+		if version := strings.TrimSpace(p.Product + " " + p.Version); version != "" {
+			lbl += " · " + truncateStr(version, 40)
+		}
+		if p.TLS != nil && len(p.TLS.Protocols) > 0 {
+			lbl += " · " + p.TLS.Protocols[0].Version
+		}
+		if p.HTTP != nil && len(p.HTTP.Products) > 0 {
+			lbl += " · " + truncateStr(p.HTTP.Products[0].Name, 30)
+		}
+		if p.SMB != nil && p.SMB.MaxDialect() != "" {
+			lbl += " · SMB " + p.SMB.MaxDialect()
 		}
 		if a.showRawBanners && strings.TrimSpace(p.Banner) != "" {
 			lbl += " · " + truncateStr(p.Banner, 40)
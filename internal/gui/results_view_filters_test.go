@@ -61,3 +61,35 @@ func TestCurrentDisplayedResultsCombinedFilters(t *testing.T) {
 		t.Fatalf("expected router-main, got %s", got[0].Hostname)
 	}
 }
+
+func TestPassesPortStateModeReasons(t *testing.T) {
+	dropped := scanner.Result{IP: "192.168.1.20", Ports: []scanner.PortInfo{
+		{Port: 445, Protocol: "tcp", State: "filtered", Reason: "no-response"},
+	}}
+	rejected := scanner.Result{IP: "192.168.1.21", Ports: []scanner.PortInfo{
+		{Port: 445, Protocol: "tcp", State: "filtered", Reason: "admin-prohibited"},
+		{Port: 23, Protocol: "tcp", State: "closed", Reason: "conn-refused"},
+	}}
+	a := &App{}
+	for _, tt := range []struct {
+		mode     string
+		r        scanner.Result
+		expected bool
+	}{
+		{"has_no_response", dropped, true},
+		{"has_no_response", rejected, false},
+		{"has_rejected", rejected, true},
+		{"has_rejected", dropped, false},
+		{"has_filtered", rejected, true},
+	} {
+		a.resultsPortStateMode = tt.mode
+		if got := a.passesPortStateMode(tt.r); got != tt.expected {
+			t.Errorf("%s for %s: got %v, expected %v", tt.mode, tt.r.IP, got, tt.expected)
+		}
+	}
+
+	line := nonOpenPortsLine(rejected.Ports, 1)
+	if line != "`445/tcp` filtered: admin-prohibited, +1" {
+		t.Fatalf("unexpected non-open ports line: %q", line)
+	}
+}
@@ -1,11 +1,14 @@
 package gui
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
+	apperrors "network-scanner/internal/errors"
 	"network-scanner/internal/logger"
+	"network-scanner/internal/scanner"
 	scand "network-scanner/internal/scanner/daemon"
 
 	"fyne.io/fyne/v2"
@@ -119,6 +122,43 @@ func (a *App) applyScanCompletion(update scanUpdate) {
 	a.myWindow.Content().Refresh()
 }
 
+// applyScanHosts добавляет в таблицу хосты, завершённые во время сканирования.
+func (a *App) applyScanHosts(hosts []scanner.Result) {
+	if a == nil || len(hosts) == 0 || a.resultsState != resultsStateScanning {
+		return
+	}
+	a.scanResults = append(a.scanResults, hosts...)
+	a.scanResultsVersion++
+	a.invalidateResultsPipelineCache()
+	a.renderScanResultsView()
+}
+
+// scanErrorText формирует понятное пользователю описание ошибки ScanContext.
+func scanErrorText(err error) string {
+	var input *apperrors.InvalidInputError
+	switch {
+	case errors.As(err, &input):
+		switch input.Field {
+		case "network":
+			return "некорректная сеть: " + input.Message
+		case "ports":
+			return "некорректный диапазон портов: " + input.Message
+		case "source":
+			return "некорректный источник проб: " + input.Message
+		case "proxy":
+			return "прокси недоступен: " + input.Message
+		}
+		return "некорректные параметры: " + input.Message
+	case apperrors.IsPermission(err):
+		return "недостаточно прав для проверки доступности хостов (запустите с правами администратора)"
+	case apperrors.IsTimeout(err):
+		return "превышено время ожидания сканирования"
+	case apperrors.IsCancelled(err):
+		return "сканирование отменено"
+	}
+	return err.Error()
+}
+
 func (a *App) applyScanTimeout(scanUITimeout time.Duration) {
 	if a == nil {
 		return
@@ -161,6 +201,20 @@ func (a *App) observeScanRunner(runner *scand.Runner, scanStartTime time.Time, s
 		stageStartedAt := map[string]time.Time{}
 		var latestProgress progressUpdate
 		hasPendingProgress := false
+		var pendingHosts []scanner.Result
+
+		// Хосты копятся между тиками и применяются пачкой, чтобы не перерисовывать
+		// таблицу на каждый хост при сканировании крупных подсетей.
+		flushHosts := func() {
+			if len(pendingHosts) == 0 {
+				return
+			}
+			hosts := pendingHosts
+			pendingHosts = nil
+			fyne.Do(func() {
+				a.applyScanHosts(hosts)
+			})
+		}
 
 		applyProgress := func(progress progressUpdate) {
 			etaText := ""
@@ -204,7 +258,13 @@ func (a *App) observeScanRunner(runner *scand.Runner, scanStartTime time.Time, s
 		for {
 			select {
 			case ev := <-runner.Events():
-				if ev.Kind != scand.EventProgress && ev.Kind != scand.EventDone && ev.Kind != scand.EventStopped && ev.Kind != scand.EventError {
+				if ev.Kind != scand.EventProgress && ev.Kind != scand.EventHost && ev.Kind != scand.EventDone && ev.Kind != scand.EventStopped && ev.Kind != scand.EventError {
+					continue
+				}
+				if ev.Kind == scand.EventHost {
+					if ev.Host != nil {
+						pendingHosts = append(pendingHosts, *ev.Host)
+					}
 					continue
 				}
 				if ev.Kind == scand.EventProgress {
@@ -239,8 +299,8 @@ func (a *App) observeScanRunner(runner *scand.Runner, scanStartTime time.Time, s
 							a.mainToolbar.Hide()
 						}
 						msg := strings.TrimSpace(ev.Message)
-						if msg == "" && ev.Err != nil {
-							msg = ev.Err.Error()
+						if ev.Err != nil {
+							msg = scanErrorText(ev.Err)
 						}
 						if msg == "" {
 							msg = "внутренняя ошибка scan runner"
@@ -273,6 +333,7 @@ func (a *App) observeScanRunner(runner *scand.Runner, scanStartTime time.Time, s
 				return
 
 			case <-ticker.C:
+				flushHosts()
 				if hasPendingProgress {
 					applyProgress(latestProgress)
 					hasPendingProgress = false
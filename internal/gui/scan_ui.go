@@ -3,6 +3,7 @@ package gui
 import (
 	"fmt"
 	"image/color"
+	"net"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -12,8 +13,51 @@ import (
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/widget"
+
+	"network-scanner/internal/logger"
+	"network-scanner/internal/network"
 )
 
+// interfaceAutoOption — вариант выбора интерфейса, при котором его выбирает ОС.
+const interfaceAutoOption = "Автоматически"
+
+// interfaceOptions возвращает варианты выбора интерфейса источника: interfaceAutoOption
+// и поднятые не-loopback интерфейсы с адресами ("eth0 — 192.168.1.10/24").
+func interfaceOptions(infos []network.InterfaceInfo) []string {
+	options := []string{interfaceAutoOption}
+	for _, info := range infos {
+		if info.Flags&net.FlagUp == 0 || info.Flags&net.FlagLoopback != 0 || len(info.Addrs) == 0 {
+			continue
+		}
+		addrs := make([]string, 0, len(info.Addrs))
+		for _, a := range info.Addrs {
+			addrs = append(addrs, a.String())
+		}
+		options = append(options, info.Name+" — "+strings.Join(addrs, ", "))
+	}
+	return options
+}
+
+// interfaceFromOption возвращает имя интерфейса варианта выбора; "" — выбор ОС.
+func interfaceFromOption(option string) string {
+	if option == interfaceAutoOption {
+		return ""
+	}
+	name, _, _ := strings.Cut(option, " — ")
+	return strings.TrimSpace(name)
+}
+
+// scanSourceSettings возвращает выбранные интерфейс и адрес источника проб.
+func (a *App) scanSourceSettings() (iface, sourceIP string) {
+	if a.scanInterfaceSelect != nil {
+		iface = interfaceFromOption(a.scanInterfaceSelect.Selected)
+	}
+	if a.scanSourceIPEntry != nil {
+		sourceIP = strings.TrimSpace(a.scanSourceIPEntry.Text)
+	}
+	return iface, sourceIP
+}
+
 // initScanUI инициализирует UI сканирования
 func (a *App) initScanUI() {
 	// Поле ввода сети
@@ -39,6 +83,17 @@ func (a *App) initScanUI() {
 	a.scanBannersCheck = widget.NewCheck("Собирать баннеры/версии служб (медленнее)", nil)
 	a.scanOSActiveCheck = widget.NewCheck("Активные эвристики определения ОС (может замедлить)", nil)
 	a.scanVerboseLogsCheck = widget.NewCheck("Детальные логи по портам (debug, шумно)", nil)
+	a.scanShowClosedCheck = widget.NewCheck("Сохранять закрытые и фильтруемые порты с причиной (проверка межсетевого экрана)", nil)
+	ifaces, err := network.ListInterfaces()
+	if err != nil {
+		logger.LogDebug("Список интерфейсов недоступен: %v", err)
+	}
+	a.scanInterfaceSelect = widget.NewSelect(interfaceOptions(ifaces), nil)
+	a.scanInterfaceSelect.SetSelected(interfaceAutoOption)
+	a.scanSourceIPEntry = widget.NewEntry()
+	a.scanSourceIPEntry.SetPlaceHolder("Адрес источника (необязательно)")
+	a.scanProxyEntry = widget.NewEntry()
+	a.scanProxyEntry.SetPlaceHolder("socks5://host:1080 или ssh://user@bastion (необязательно)")
 	a.scanVerboseInfoBtn = widget.NewButton("Подробнее", nil)
 	a.autoProfileCheck = widget.NewCheck("Автопрофиль сканирования (рекомендуется)", nil)
 	a.autoProfileCheck.SetChecked(true)
@@ -58,6 +113,7 @@ func (a *App) initScanUI() {
 	a.presetQuickBtn = widget.NewButton("Быстро", nil)
 	a.presetBalBtn = widget.NewButton("Баланс", nil)
 	a.presetDeepBtn = widget.NewButton("Глубоко", nil)
+	a.presetPoliteBtn = widget.NewButton("Бережно", nil)
 	a.recommendedProfileBtn = widget.NewButton("Рекомендуемые настройки", nil)
 	a.recommendedProfileInfoBtn = widget.NewButton("Почему?", nil)
 	a.recommendedProfileBadge = canvas.NewText("Профиль: не выбран", color.RGBA{R: 110, G: 110, B: 110, A: 255})
@@ -121,8 +177,12 @@ func (a *App) buildScanControlsContainer() *container.Scroll {
 	portClassHint.Wrapping = fyne.TextWrapWord
 
 	scanControlsContainer := container.NewVBox(
-		widget.NewLabel("Сеть (CIDR, например 192.168.1.0/24):"),
+		widget.NewLabel("Сеть или цели через запятую (CIDR, диапазон, IP, имя; например 192.168.1.0/24, 10.0.0.5-20):"),
 		a.networkEntry,
+		widget.NewLabel("Интерфейс и адрес, с которых уходят пробы (пустая сеть — сеть интерфейса):"),
+		container.NewGridWithColumns(2, a.scanInterfaceSelect, a.scanSourceIPEntry),
+		widget.NewLabel("Прокси для удалённых сетей (только TCP: обнаружение — TCP connect, без UDP и SNMP):"),
+		a.scanProxyEntry,
 		a.scanTCPPortsCheck,
 		widget.NewLabel("Диапазон TCP портов (например 1-65535 или 80,443):"),
 		a.portRangeEntry,
@@ -134,10 +194,11 @@ func (a *App) buildScanControlsContainer() *container.Scroll {
 		),
 		widget.NewLabel("Пресет:"),
 		container.NewGridWithColumns(
-			3,
+			4,
 			a.presetQuickBtn,
 			a.presetBalBtn,
 			a.presetDeepBtn,
+			a.presetPoliteBtn,
 		),
 		widget.NewLabel("Онбординг:"),
 		container.NewGridWithColumns(
@@ -156,6 +217,7 @@ func (a *App) buildScanControlsContainer() *container.Scroll {
 		a.scanUDPCheck,
 		a.scanBannersCheck,
 		a.scanOSActiveCheck,
+		a.scanShowClosedCheck,
 		container.NewGridWithColumns(2, a.scanVerboseLogsCheck, a.scanVerboseInfoBtn),
 		container.NewGridWithColumns(2, a.autoProfileCheck, a.autoProfileInfoBtn),
 		a.autoProfileStateText,
@@ -261,7 +323,7 @@ func (a *App) buildResultsContainer() *fyne.Container {
 		a.scheduleResultsRender(false)
 	}
 	a.resultsPortStateMode = "all"
-	a.resultsPortStateSel = widget.NewSelect([]string{"Все", "Есть открытые", "Есть закрытые", "Есть фильтруемые"}, func(value string) {
+	a.resultsPortStateSel = widget.NewSelect([]string{"Все", "Есть открытые", "Есть закрытые", "Есть фильтруемые", "Есть без ответа (DROP)", "Есть отклонённые (RST/ICMP)"}, func(value string) {
 		switch strings.TrimSpace(value) {
 		case "Есть открытые":
 			a.resultsPortStateMode = "has_open"
@@ -269,6 +331,10 @@ func (a *App) buildResultsContainer() *fyne.Container {
 			a.resultsPortStateMode = "has_closed"
 		case "Есть фильтруемые":
 			a.resultsPortStateMode = "has_filtered"
+		case "Есть без ответа (DROP)":
+			a.resultsPortStateMode = "has_no_response"
+		case "Есть отклонённые (RST/ICMP)":
+			a.resultsPortStateMode = "has_rejected"
 		default:
 			a.resultsPortStateMode = "all"
 		}
@@ -1,8 +1,14 @@
 package gui
 
 import (
+	"errors"
+	"net"
+	"strings"
 	"testing"
 	"time"
+
+	apperrors "network-scanner/internal/errors"
+	"network-scanner/internal/network"
 )
 
 // --- Test initScanUI ---
@@ -421,3 +427,51 @@ func TestSNMPSettingsDefaults(t *testing.T) {
 		t.Errorf("snmpTimeoutEnt.Text = %v, want 2", app.snmpTimeoutEnt.Text)
 	}
 }
+
+// --- Test scanErrorText ---
+
+func TestScanErrorText(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"invalid network", apperrors.NewInvalidInputError("network", "bad cidr"), "некорректная сеть: bad cidr"},
+		{"invalid ports", apperrors.NewInvalidInputError("ports", "bad range"), "некорректный диапазон портов: bad range"},
+		{"invalid source", apperrors.NewInvalidInputError("source", "no eth9"), "некорректный источник проб: no eth9"},
+		{"proxy", apperrors.NewInvalidInputError("proxy", "connection refused"), "прокси недоступен: connection refused"},
+		{"permission", apperrors.NewPermissionError("user", "10.0.0.0/24", "probe"), "недостаточно прав"},
+		{"timeout", apperrors.NewTimeoutError("scan", time.Second), "превышено время ожидания"},
+		{"cancelled", apperrors.NewCancelledError("scan"), "сканирование отменено"},
+		{"plain", errors.New("boom"), "boom"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scanErrorText(tt.err); !strings.HasPrefix(got, tt.want) {
+				t.Errorf("scanErrorText() = %q, want prefix %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInterfaceOptions(t *testing.T) {
+	_, lan, _ := net.ParseCIDR("192.168.1.10/24")
+	lan.IP = net.ParseIP("192.168.1.10")
+	_, lo, _ := net.ParseCIDR("127.0.0.1/8")
+	infos := []network.InterfaceInfo{
+		{Name: "lo", Flags: net.FlagUp | net.FlagLoopback, Addrs: []*net.IPNet{lo}},
+		{Name: "eth0", Flags: net.FlagUp, Addrs: []*net.IPNet{lan}},
+		{Name: "eth1", Addrs: []*net.IPNet{lan}},
+	}
+	options := interfaceOptions(infos)
+	if len(options) != 2 || options[0] != interfaceAutoOption || options[1] != "eth0 — 192.168.1.10/24" {
+		t.Fatalf("interfaceOptions() = %q", options)
+	}
+	if got := interfaceFromOption(options[1]); got != "eth0" {
+		t.Errorf("interfaceFromOption(%q) = %q, want eth0", options[1], got)
+	}
+	if got := interfaceFromOption(interfaceAutoOption); got != "" {
+		t.Errorf("interfaceFromOption(auto) = %q, want пусто", got)
+	}
+}
@@ -20,7 +20,10 @@ import (
 )
 
 func (a *App) buildSecurityDashboardView(data []scanner.Result) fyne.CanvasObject {
-	portFindings := audit.EvaluateOpenPorts(data)
+	portFindings := append(audit.EvaluateOpenPorts(data), audit.EvaluateTLS(data, time.Now())...)
+	portFindings = append(portFindings, audit.EvaluateSSH(data)...)
+	portFindings = append(portFindings, audit.EvaluateSMB(data)...)
+	portFindings = append(portFindings, audit.EvaluateUPnP(data)...)
 	db, dbErr := risksignature.LoadDefault()
 	signatureFindings := make([]risksignature.Finding, 0)
 	if dbErr == nil {
@@ -0,0 +1,333 @@
+// Package httpinspect снимает отпечаток веб-приложения на порту: загружает главную
+// страницу (с переадресациями в пределах хоста), извлекает заголовок страницы, хэш
+// favicon, заголовки ответа, имена cookie, realm Basic/Digest и meta generator и
+// сопоставляет их с правилами известных продуктов (rules.go).
+package httpinspect
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// DialFunc открывает соединение; совпадает с banner.DialFunc, чтобы сканер подставлял
+// свой диалер (прокси, адрес источника, симулятор).
+type DialFunc func(network, address string, timeout time.Duration) (net.Conn, error)
+
+// DefaultTimeout — ожидание одного запроса (вместе с переадресациями) по умолчанию.
+const DefaultTimeout = 5 * time.Second
+
+const (
+	maxRedirects = 5         // переадресаций в пределах хоста
+	maxBodySize  = 256 << 10 // читаемая часть главной страницы
+	maxIconSize  = 256 << 10 // читаемая часть favicon
+	userAgent    = "network-scanner"
+)
+
+// Options — настройки снятия отпечатка.
+type Options struct {
+	Timeout time.Duration // ожидание запроса; 0 — DefaultTimeout
+	Dial    DialFunc      // nil — net.DialTimeout
+	TLS     bool          // HTTPS (без проверки сертификата) вместо HTTP
+}
+
+// Info — отпечаток веб-приложения порта.
+type Info struct {
+	URL         string            `json:"url"`                    // адрес страницы после переадресаций
+	Status      int               `json:"status"`                 // код ответа главной страницы
+	Title       string            `json:"title,omitempty"`        // <title> страницы
+	Headers     map[string]string `json:"headers,omitempty"`      // заголовки ответа, кроме Set-Cookie
+	Cookies     []string          `json:"cookies,omitempty"`      // имена cookie (значения не сохраняются)
+	Realm       string            `json:"realm,omitempty"`        // realm из WWW-Authenticate
+	Generator   string            `json:"generator,omitempty"`    // <meta name="generator">
+	FaviconHash int32             `json:"favicon_hash,omitempty"` // MurmurHash3 favicon в форме Shodan; 0 — нет favicon
+	Products    []Product         `json:"products,omitempty"`     // совпавшие правила продуктов
+
+	body string // начало главной страницы для правил; не сохраняется
+}
+
+// Product — продукт, распознанный по правилам.
+type Product struct {
+	Name     string `json:"name"`
+	Vendor   string `json:"vendor,omitempty"`
+	Category string `json:"category,omitempty"` // категория deviceclassifier ("NAS", "Printer", ...)
+	Version  string `json:"version,omitempty"`
+}
+
+// Header возвращает значение заголовка ответа name без учёта регистра.
+func (i *Info) Header(name string) string {
+	if i == nil {
+		return ""
+	}
+	return i.Headers[http.CanonicalHeaderKey(name)]
+}
+
+// Summary кратко описывает отпечаток для текстового вывода: распознанные продукты, иначе
+// заголовок страницы и код ответа ("Grafana 10.2.0" или "200 Welcome").
+func (i *Info) Summary() string {
+	if i == nil {
+		return ""
+	}
+	if len(i.Products) > 0 {
+		names := make([]string, 0, len(i.Products))
+		for _, p := range i.Products {
+			names = append(names, strings.TrimSpace(p.Name+" "+p.Version))
+		}
+		return strings.Join(names, ", ")
+	}
+	return strings.TrimSpace(strconv.Itoa(i.Status) + " " + i.Title)
+}
+
+// WantsTLS сообщает, что на запрос без TLS сервер ответил отказом и просьбой использовать
+// HTTPS (nginx: "The plain HTTP request was sent to HTTPS port").
+func (i *Info) WantsTLS() bool {
+	return i != nil && i.Status == http.StatusBadRequest && strings.Contains(strings.ToLower(i.body), "https")
+}
+
+// Inspect загружает главную страницу порта port хоста host методом GET, следуя
+// переадресациям в пределах хоста, затем favicon (ссылка со страницы или /favicon.ico).
+// Ошибка — порт не ответил на HTTP-запрос. Продукты не распознаются: их заполняет
+// RuleDB.Match.
+func Inspect(ctx context.Context, host string, port int, opts Options) (*Info, error) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.Dial == nil {
+		opts.Dial = net.DialTimeout
+	}
+	scheme := "http"
+	if opts.TLS {
+		scheme = "https"
+	}
+	start := &url.URL{Scheme: scheme, Host: net.JoinHostPort(host, strconv.Itoa(port)), Path: "/"}
+
+	info := &Info{}
+	cookies := make(map[string]bool)
+	client := newClient(opts)
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		// Cookie, выставленные ответами-переадресациями (типично для страниц входа)
+		if req.Response != nil {
+			for _, c := range req.Response.Cookies() {
+				cookies[c.Name] = true
+			}
+		}
+		if len(via) > maxRedirects || req.URL.Hostname() != host {
+			return http.ErrUseLastResponse
+		}
+		return nil
+	}
+
+	resp, body, err := get(ctx, client, start.String(), maxBodySize)
+	if err != nil {
+		return nil, err
+	}
+	info.URL = resp.Request.URL.String()
+	info.Status = resp.StatusCode
+	info.Headers = make(map[string]string, len(resp.Header))
+	for name, values := range resp.Header {
+		if name != "Set-Cookie" {
+			info.Headers[name] = strings.Join(values, ", ")
+		}
+	}
+	for _, c := range resp.Cookies() {
+		cookies[c.Name] = true
+	}
+	for name := range cookies {
+		info.Cookies = append(info.Cookies, name)
+	}
+	sort.Strings(info.Cookies)
+	info.Realm = authRealm(resp.Header.Values("WWW-Authenticate"))
+	info.body = string(body)
+
+	page := parsePage(body)
+	info.Title = page.title
+	info.Generator = page.generator
+
+	icon := resp.Request.URL.ResolveReference(&url.URL{Path: "/favicon.ico"})
+	if page.icon != "" {
+		if ref, err := url.Parse(page.icon); err == nil {
+			if u := resp.Request.URL.ResolveReference(ref); u.Hostname() == host && (u.Scheme == "http" || u.Scheme == "https") {
+				icon = u
+			}
+		}
+	}
+	if ctx.Err() == nil {
+		if r, data, err := get(ctx, client, icon.String(), maxIconSize); err == nil && r.StatusCode == http.StatusOK && len(data) > 0 {
+			info.FaviconHash = FaviconHash(data)
+		}
+	}
+	return info, nil
+}
+
+// newClient создаёт клиента без пула соединений, прокси окружения и проверки сертификата.
+func newClient(opts Options) *http.Client {
+	transport := &http.Transport{
+		Proxy: nil,
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return opts.Dial(network, addr, opts.Timeout)
+		},
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: true, // Отпечаток приложения, а не проверка сертификата.
+			MinVersion:         tls.VersionTLS10,
+		},
+		TLSHandshakeTimeout:   opts.Timeout,
+		ResponseHeaderTimeout: opts.Timeout,
+		DisableKeepAlives:     true,
+	}
+	return &http.Client{Transport: transport, Timeout: opts.Timeout}
+}
+
+// get выполняет GET и читает не более limit байт тела.
+func get(ctx context.Context, client *http.Client, target string, limit int64) (*http.Response, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit))
+	if err != nil && len(body) == 0 && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, nil, err
+	}
+	return resp, body, nil
+}
+
+var realmPattern = regexp.MustCompile(`(?i)\brealm\s*=\s*(?:"([^"]*)"|([^\s,]+))`)
+
+// authRealm извлекает realm из первого заголовка WWW-Authenticate, где он указан.
+func authRealm(values []string) string {
+	for _, v := range values {
+		if m := realmPattern.FindStringSubmatch(v); m != nil {
+			return strings.TrimSpace(m[1] + m[2])
+		}
+	}
+	return ""
+}
+
+// page — сведения, извлечённые из HTML главной страницы.
+type page struct {
+	title     string
+	generator string
+	icon      string // href первого <link rel="icon">
+}
+
+// parsePage разбирает HTML токенизатором: первый <title>, meta generator и ссылку на
+// favicon. Разбор нестрогий, битая разметка не мешает.
+func parsePage(body []byte) page {
+	var p page
+	z := html.NewTokenizer(bytes.NewReader(body))
+	inTitle := false
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			return p
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tok := z.Token()
+			switch tok.Data {
+			case "title":
+				inTitle = p.title == "" && tt == html.StartTagToken
+			case "meta":
+				if strings.EqualFold(attr(tok, "name"), "generator") && p.generator == "" {
+					p.generator = strings.TrimSpace(attr(tok, "content"))
+				}
+			case "link":
+				rel := strings.Fields(strings.ToLower(attr(tok, "rel")))
+				for _, r := range rel {
+					if r == "icon" && p.icon == "" {
+						p.icon = strings.TrimSpace(attr(tok, "href"))
+					}
+				}
+			}
+		case html.TextToken:
+			if inTitle {
+				p.title = strings.Join(strings.Fields(string(z.Text())), " ")
+				inTitle = false
+			}
+		case html.EndTagToken:
+			inTitle = false
+		}
+	}
+}
+
+func attr(tok html.Token, name string) string {
+	for _, a := range tok.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// FaviconHash вычисляет хэш favicon так же, как Shodan (http.favicon.hash): MurmurHash3
+// (x86, 32 бита, seed 0) от base64 с переносом строк через 76 символов и в конце.
+func FaviconHash(data []byte) int32 {
+	enc := base64.StdEncoding.EncodeToString(data)
+	var b strings.Builder
+	for len(enc) > 76 {
+		b.WriteString(enc[:76])
+		b.WriteByte('\n')
+		enc = enc[76:]
+	}
+	b.WriteString(enc)
+	b.WriteByte('\n')
+	return int32(murmur3([]byte(b.String())))
+}
+
+// murmur3 — MurmurHash3_x86_32 с seed 0.
+func murmur3(data []byte) uint32 {
+	const (
+		c1 = 0xcc9e2d51
+		c2 = 0x1b873593
+	)
+	var h uint32
+	n := len(data) / 4 * 4
+	for i := 0; i < n; i += 4 {
+		k := uint32(data[i]) | uint32(data[i+1])<<8 | uint32(data[i+2])<<16 | uint32(data[i+3])<<24
+		k *= c1
+		k = k<<15 | k>>17
+		k *= c2
+		h ^= k
+		h = h<<13 | h>>19
+		h = h*5 + 0xe6546b64
+	}
+	var k uint32
+	switch len(data) - n {
+	case 3:
+		k ^= uint32(data[n+2]) << 16
+		fallthrough
+	case 2:
+		k ^= uint32(data[n+1]) << 8
+		fallthrough
+	case 1:
+		k ^= uint32(data[n])
+		k *= c1
+		k = k<<15 | k>>17
+		k *= c2
+		h ^= k
+	}
+	h ^= uint32(len(data))
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+	return h
+}
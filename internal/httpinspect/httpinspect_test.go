@@ -0,0 +1,148 @@
+package httpinspect
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// serve запускает тестовый сервер и возвращает его хост и порт.
+func serve(t *testing.T, tlsServer bool, h http.Handler) (string, int) {
+	t.Helper()
+	srv := httptest.NewUnstartedServer(h)
+	if tlsServer {
+		srv.StartTLS()
+	} else {
+		srv.Start()
+	}
+	t.Cleanup(srv.Close)
+	host, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+	p, _ := strconv.Atoi(port)
+	return host, p
+}
+
+func TestInspect(t *testing.T) {
+	icon := []byte("\x00\x00\x01\x00fake-icon")
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "grafana_session", Value: "secret"})
+		http.Redirect(w, r, "/login", http.StatusFound)
+	})
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Frame-Options", "deny")
+		w.Write([]byte(`<!DOCTYPE html><html><head>
+<meta name="generator" content="Grafana">
+<title>
+  Grafana  </title>
+<link rel="shortcut icon" href="/public/img/fav32.png">
+</head><body><script>window.grafanaBootData = {"settings":{"buildInfo":{"commit":"abc","version":"10.2.0"}}}</script></body></html>`))
+	})
+	mux.HandleFunc("/public/img/fav32.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(icon)
+	})
+	host, port := serve(t, true, mux)
+
+	info, err := Inspect(context.Background(), host, port, Options{Timeout: 2 * time.Second, TLS: true})
+	if err != nil {
+		t.Fatalf("Inspect: %v", err)
+	}
+	if info.Status != http.StatusOK || info.URL != "https://"+net.JoinHostPort(host, strconv.Itoa(port))+"/login" {
+		t.Errorf("status=%d url=%q", info.Status, info.URL)
+	}
+	if info.Title != "Grafana" || info.Generator != "Grafana" || info.Header("x-frame-options") != "deny" {
+		t.Errorf("title=%q generator=%q headers=%v", info.Title, info.Generator, info.Headers)
+	}
+	if !slices.Equal(info.Cookies, []string{"grafana_session"}) {
+		t.Errorf("Cookies = %v", info.Cookies)
+	}
+	if info.FaviconHash != FaviconHash(icon) {
+		t.Errorf("FaviconHash = %d, want %d", info.FaviconHash, FaviconHash(icon))
+	}
+
+	db, err := LoadDefault()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := db.Match(info)
+	if len(got) != 1 || got[0].Name != "Grafana" || got[0].Version != "10.2.0" || got[0].Category != "Server" {
+		t.Errorf("Match = %+v", got)
+	}
+}
+
+func TestInspectRealmAndForeignRedirect(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://login.example.invalid/", http.StatusFound)
+	})
+	mux.HandleFunc("/favicon.ico", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="NETGEAR R7000"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	host, port := serve(t, false, mux)
+
+	info, err := Inspect(context.Background(), host, port, Options{Timeout: 2 * time.Second})
+	if err != nil {
+		t.Fatalf("Inspect: %v", err)
+	}
+	// Переадресация на другой хост не выполняется
+	if info.Status != http.StatusFound || info.Header("Location") != "http://login.example.invalid/" || info.FaviconHash != 0 {
+		t.Errorf("info = %+v", info)
+	}
+
+	mux2 := http.NewServeMux()
+	mux2.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="NETGEAR R7000"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	host, port = serve(t, false, mux2)
+	info, err = Inspect(context.Background(), host, port, Options{Timeout: 2 * time.Second})
+	if err != nil {
+		t.Fatalf("Inspect: %v", err)
+	}
+	db, _ := LoadDefault()
+	if got := db.Match(info); info.Realm != "NETGEAR R7000" || len(got) != 1 || got[0].Category != "Router/Switch" {
+		t.Errorf("realm=%q products=%+v", info.Realm, got)
+	}
+}
+
+func TestInspectNotHTTP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		conn.Write([]byte("SSH-2.0-OpenSSH_9.6\r\n"))
+		conn.Close()
+	}()
+	if info, err := Inspect(context.Background(), "127.0.0.1", ln.Addr().(*net.TCPAddr).Port, Options{Timeout: time.Second}); err == nil {
+		t.Fatalf("ожидалась ошибка, получено %+v", info)
+	}
+}
+
+func TestMurmur3(t *testing.T) {
+	// Контрольные значения mmh3.hash из Python
+	for in, want := range map[string]int32{"": 0, "foo": -156908512} {
+		if got := int32(murmur3([]byte(in))); got != want {
+			t.Errorf("murmur3(%q) = %d, want %d", in, got, want)
+		}
+	}
+}
+
+func TestLoadRejectsBadVersionPattern(t *testing.T) {
+	if _, err := Load([]byte(`{"version":"v1","products":[{"name":"X","version_pattern":"[0-9]+"}]}`)); err == nil {
+		t.Fatal("ожидалась ошибка для version_pattern без группы")
+	}
+	if _, err := Load([]byte(`{"products":[]}`)); err == nil {
+		t.Fatal("ожидалась ошибка без version")
+	}
+}
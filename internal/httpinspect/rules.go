@@ -0,0 +1,147 @@
+package httpinspect
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+//go:embed rules/http-products.v1.json
+var defaultRulesRaw []byte
+
+// RuleDB — версионированный список правил распознавания веб-приложений.
+type RuleDB struct {
+	Version string `json:"version"`
+	Rules   []Rule `json:"products"`
+}
+
+// Rule описывает продукт и признаки, по которым он узнаётся. Продукт распознан, если
+// совпал хотя бы один признак; строки сравниваются как подстроки без учёта регистра.
+type Rule struct {
+	Name     string `json:"name"`
+	Vendor   string `json:"vendor,omitempty"`
+	Category string `json:"category,omitempty"` // категория deviceclassifier
+
+	MatchTitle     []string          `json:"match_title,omitempty"`
+	MatchBody      []string          `json:"match_body,omitempty"`
+	MatchHeader    map[string]string `json:"match_header,omitempty"` // заголовок → подстрока; "" — заголовок есть
+	MatchCookie    []string          `json:"match_cookie,omitempty"` // подстрока имени cookie
+	MatchRealm     []string          `json:"match_realm,omitempty"`
+	MatchGenerator []string          `json:"match_generator,omitempty"`
+	MatchFavicon   []int32           `json:"match_favicon_hash,omitempty"`
+
+	// VersionHeader — заголовок, значение которого — версия продукта (X-Jenkins).
+	VersionHeader string `json:"version_header,omitempty"`
+	// VersionPattern — регулярное выражение с группой версии; применяется к meta
+	// generator, заголовку страницы и телу по очереди.
+	VersionPattern string `json:"version_pattern,omitempty"`
+
+	version *regexp.Regexp
+}
+
+var (
+	defaultOnce sync.Once
+	defaultDB   RuleDB
+	defaultErr  error
+)
+
+// LoadDefault возвращает встроенные правила; разбираются один раз на процесс.
+func LoadDefault() (RuleDB, error) {
+	defaultOnce.Do(func() {
+		defaultDB, defaultErr = Load(defaultRulesRaw)
+	})
+	return defaultDB, defaultErr
+}
+
+// Load разбирает правила из JSON.
+func Load(raw []byte) (RuleDB, error) {
+	var db RuleDB
+	if err := json.Unmarshal(raw, &db); err != nil {
+		return RuleDB{}, fmt.Errorf("parse http rules: %w", err)
+	}
+	if strings.TrimSpace(db.Version) == "" {
+		return RuleDB{}, fmt.Errorf("http rules version is required")
+	}
+	for i := range db.Rules {
+		r := &db.Rules[i]
+		if strings.TrimSpace(r.Name) == "" {
+			return RuleDB{}, fmt.Errorf("products[%d]: name is required", i)
+		}
+		if r.VersionPattern != "" {
+			re, err := regexp.Compile(r.VersionPattern)
+			if err != nil || re.NumSubexp() < 1 {
+				return RuleDB{}, fmt.Errorf("products[%d] %s: version_pattern needs a capture group: %v", i, r.Name, err)
+			}
+			r.version = re
+		}
+	}
+	return db, nil
+}
+
+// Match возвращает продукты, правила которых совпали с отпечатком, в порядке правил.
+func (db RuleDB) Match(info *Info) []Product {
+	if info == nil {
+		return nil
+	}
+	var out []Product
+	for _, r := range db.Rules {
+		if !r.matches(info) {
+			continue
+		}
+		out = append(out, Product{Name: r.Name, Vendor: r.Vendor, Category: r.Category, Version: r.versionOf(info)})
+	}
+	return out
+}
+
+func (r Rule) matches(info *Info) bool {
+	if containsAny(info.Title, r.MatchTitle) || containsAny(info.body, r.MatchBody) ||
+		containsAny(info.Realm, r.MatchRealm) || containsAny(info.Generator, r.MatchGenerator) {
+		return true
+	}
+	for name, want := range r.MatchHeader {
+		if v, ok := info.Headers[http.CanonicalHeaderKey(name)]; ok && strings.Contains(strings.ToLower(v), strings.ToLower(want)) {
+			return true
+		}
+	}
+	for _, c := range info.Cookies {
+		if containsAny(c, r.MatchCookie) {
+			return true
+		}
+	}
+	return info.FaviconHash != 0 && slices.Contains(r.MatchFavicon, info.FaviconHash)
+}
+
+func (r Rule) versionOf(info *Info) string {
+	if r.VersionHeader != "" {
+		if v := strings.TrimSpace(info.Header(r.VersionHeader)); v != "" {
+			return v
+		}
+	}
+	if r.version == nil {
+		return ""
+	}
+	for _, text := range []string{info.Generator, info.Title, info.body} {
+		if m := r.version.FindStringSubmatch(text); m != nil && m[1] != "" {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+func containsAny(s string, patterns []string) bool {
+	if s == "" {
+		return false
+	}
+	s = strings.ToLower(s)
+	for _, p := range patterns {
+		if p = strings.ToLower(strings.TrimSpace(p)); p != "" && strings.Contains(s, p) {
+			return true
+		}
+	}
+	return false
+}
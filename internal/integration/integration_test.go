@@ -11,13 +11,15 @@ import (
 
 	"network-scanner/internal/api"
 	"network-scanner/internal/contracts"
+	"network-scanner/internal/mock"
 	"network-scanner/internal/report"
 )
 
 func TestFullScanWorkflow(t *testing.T) {
-	// 1. Create API router
+	// 1. Create API router with a mock scanner: no real network is probed
 	cfg := api.DefaultConfig()
-	router := api.NewRouter(cfg)
+	cfg.CheckpointDir = t.TempDir()
+	router := api.NewRouter(cfg, api.WithScanService(mock.NewMockScannerService()))
 
 	// 2. Start a scan
 	body, _ := json.Marshal(map[string]interface{}{
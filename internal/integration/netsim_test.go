@@ -0,0 +1,327 @@
+package integration
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"network-scanner/internal/contracts"
+	"network-scanner/internal/cve"
+	"network-scanner/internal/netsim"
+	"network-scanner/internal/scanner"
+	"network-scanner/internal/security"
+	"network-scanner/internal/services"
+	"network-scanner/internal/snmpcollector"
+	"network-scanner/internal/topology"
+)
+
+const (
+	simTCPPorts = "21,22,23,25,53,80,88,110,135,139,143,389,443,445,587,631,3306,3389,5000,5432,5900,6379,8001,9100"
+	simUDPPorts = "53,67,123,137,161,1900"
+)
+
+// scanSimulated сканирует симулированную сеть n полным конвейером сканера.
+func scanSimulated(t *testing.T, n *netsim.Network, targets string) []scanner.Result {
+	t.Helper()
+	ns := scanner.NewScanner(targets, 300*time.Millisecond, simTCPPorts, 32, false, n, n, nil)
+	ns.SetUDPPortScanner(n)
+	ns.SetScanUDP(true)
+	ns.SetUDPPorts(simUDPPorts)
+	ns.SetGrabBanners(true)
+	summary, err := ns.ScanContext(context.Background())
+	if err != nil {
+		t.Fatalf("ScanContext(%s) error = %v", n.Name, err)
+	}
+	results := summary.Results
+	sort.Slice(results, func(i, j int) bool { return results[i].IP < results[j].IP })
+	return results
+}
+
+// toContractResults переводит результаты сканера в контрактный формат сервисов.
+func toContractResults(results []scanner.Result) []contracts.ScanResult {
+	out := make([]contracts.ScanResult, 0, len(results))
+	for _, r := range results {
+		ports := make([]contracts.PortInfo, 0, len(r.Ports))
+		for _, p := range r.Ports {
+			ports = append(ports, contracts.PortInfo{
+				Port:     p.Port,
+				State:    p.State,
+				Protocol: p.Protocol,
+				Service:  p.Service,
+				Banner:   p.Banner,
+				Version:  p.Version,
+				Product:  p.Product,
+				Info:     p.Info,
+				CPE:      p.CPE,
+				TLS:      scanner.ToContractTLS(p.TLS),
+				SSH:      scanner.ToContractSSH(p.SSH),
+				HTTP:     scanner.ToContractHTTP(p.HTTP),
+				SMB:      scanner.ToContractSMB(p.SMB),
+				Reason:   p.Reason,
+				Latency:  p.Latency,
+			})
+		}
+		out = append(out, contracts.ScanResult{
+			IP:           r.IP,
+			Hostname:     r.Hostname,
+			MAC:          r.MAC,
+			Ports:        ports,
+			DeviceType:   r.DeviceType,
+			DeviceVendor: r.DeviceVendor,
+			GuessOS:      r.GuessOS,
+		})
+	}
+	return out
+}
+
+func findResult(results []scanner.Result, ip string) *scanner.Result {
+	for i := range results {
+		if results[i].IP == ip {
+			return &results[i]
+		}
+	}
+	return nil
+}
+
+func hasFinding(findings []contracts.Finding, host, title string) bool {
+	for _, f := range findings {
+		if f.Host == host && f.Title == title {
+			return true
+		}
+	}
+	return false
+}
+
+func countLinks(topo *topology.Topology, source topology.LinkSourceType) int {
+	n := 0
+	for _, l := range topo.Links {
+		if l.SourceType == source {
+			n++
+		}
+	}
+	return n
+}
+
+func TestSimulatedNetworkPipeline(t *testing.T) {
+	tests := []struct {
+		fixture     string
+		cidr        string
+		communities []string
+		minHosts    int
+		snmpDevices int
+		lldpLinks   int
+		fdbLinks    int
+		finding     [2]string // хост и заголовок ожидаемой находки аудита
+		banner      [3]string // хост, порт, фрагмент баннера
+	}{
+		{
+			fixture: "home", cidr: "192.168.1.0/24", communities: []string{"public"},
+			minHosts: 5, snmpDevices: 1,
+			finding: [2]string{"192.168.1.1", "Telnet без шифрования"},
+			banner:  [3]string{"192.168.1.1", "22", "dropbear"},
+		},
+		{
+			fixture: "office", cidr: "10.10.0.0/24", communities: []string{"public", "office-ro"},
+			minHosts: 11, snmpDevices: 3, fdbLinks: 11,
+			finding: [2]string{"10.10.0.30", "Redis доступен"},
+			banner:  [3]string{"10.10.0.20", "25", "Postfix"},
+		},
+		{
+			fixture: "campus", cidr: "10.20.0.0/24", communities: []string{"campus-ro"},
+			minHosts: 10, snmpDevices: 3, lldpLinks: 2, fdbLinks: 8,
+			finding: [2]string{"10.20.0.102", "VNC доступен"},
+			banner:  [3]string{"10.20.0.10", "80", "Apache/2.4.37"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			ctx := context.Background()
+			n, err := netsim.LoadFixture(tt.fixture)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// scan
+			results := scanSimulated(t, n, tt.cidr)
+			if len(results) < tt.minHosts || len(results) > len(n.Hosts) {
+				t.Fatalf("найдено %d хостов, want %d..%d", len(results), tt.minHosts, len(n.Hosts))
+			}
+			for _, r := range results {
+				h := n.Host(r.IP)
+				if h == nil {
+					t.Fatalf("найден несуществующий хост %s", r.IP)
+				}
+				if !strings.EqualFold(r.MAC, h.MAC) || r.Hostname != h.Hostname {
+					t.Errorf("%s: MAC %q, имя %q; want %q, %q", r.IP, r.MAC, r.Hostname, h.MAC, h.Hostname)
+				}
+			}
+			if r := findResult(results, tt.banner[0]); r == nil || !portBannerContains(r, tt.banner[1], tt.banner[2]) {
+				t.Errorf("баннер %s:%s должен содержать %q: %+v", tt.banner[0], tt.banner[1], tt.banner[2], r)
+			}
+
+			// snmp
+			snmpData, report, err := snmpcollector.CollectWith(ctx, n.NewSNMPClient, results, tt.communities, nil)
+			if err != nil {
+				t.Fatalf("CollectWith() error = %v", err)
+			}
+			if report.Connected != tt.snmpDevices {
+				t.Errorf("SNMP: подключено %d, want %d (%+v)", report.Connected, tt.snmpDevices, report.Failures)
+			}
+
+			// topology
+			topo, err := topology.BuildTopology(results, snmpData)
+			if err != nil {
+				t.Fatalf("BuildTopology() error = %v", err)
+			}
+			if got := countLinks(topo, topology.LinkSourceLLDP); got < tt.lldpLinks {
+				t.Errorf("LLDP-связей %d, want >= %d", got, tt.lldpLinks)
+			}
+			if got := countLinks(topo, topology.LinkSourceFDB); got < tt.fdbLinks {
+				t.Errorf("FDB-связей %d, want >= %d", got, tt.fdbLinks)
+			}
+
+			// security
+			contractResults := toContractResults(results)
+			sec, err := security.NewService().AnalyzeRun(ctx, contractResults)
+			if err != nil {
+				t.Fatalf("AnalyzeRun() error = %v", err)
+			}
+			if !hasFinding(sec.PortAudit, tt.finding[0], tt.finding[1]) {
+				t.Errorf("нет находки %q для %s: %+v", tt.finding[1], tt.finding[0], sec.PortAudit)
+			}
+
+			// inventory: второй запуск без первого хоста и с новым портом на втором
+			inv := services.NewInventoryService(filepath.Join(t.TempDir(), "inventory.db"))
+			if err := inv.SaveSnapshot(ctx, "run-1", contractResults); err != nil {
+				t.Fatalf("SaveSnapshot() error = %v", err)
+			}
+			// Новая загрузка сети повторяет потери первого запуска
+			n2, err := netsim.LoadFixture(tt.fixture)
+			if err != nil {
+				t.Fatal(err)
+			}
+			changed := n2.Host(results[1].IP)
+			changed.TCP = append(changed.TCP, netsim.Port{Port: 5900})
+			second := scanSimulated(t, n2, strings.Join(addressesExcept(results, results[0].IP), ","))
+			if err := inv.SaveSnapshot(ctx, "run-2", toContractResults(second)); err != nil {
+				t.Fatalf("SaveSnapshot() error = %v", err)
+			}
+			diff, err := inv.Diff(ctx, "run-1", "run-2")
+			if err != nil {
+				t.Fatalf("Diff() error = %v", err)
+			}
+			if len(diff.Missing) != 1 || diff.Missing[0].IP != results[0].IP {
+				t.Errorf("Diff.Missing = %+v, want %s", diff.Missing, results[0].IP)
+			}
+			if !diffChanged(diff, "mac:"+changed.MAC) {
+				t.Errorf("Diff.Changed = %+v, want изменение %s", diff.Changed, results[1].IP)
+			}
+		})
+	}
+}
+
+func TestSimulatedScanIsDeterministic(t *testing.T) {
+	snapshot := func() map[string][]string {
+		n, err := netsim.LoadFixture("campus")
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := make(map[string][]string)
+		for _, r := range scanSimulated(t, n, "10.20.0.0/24") {
+			ports := make([]string, 0, len(r.Ports))
+			for _, p := range r.Ports {
+				ports = append(ports, p.Protocol+"/"+p.State+"/"+p.Banner+"/"+strconv.Itoa(p.Port))
+			}
+			sort.Strings(ports)
+			out[r.IP] = ports
+		}
+		return out
+	}
+	a, b := snapshot(), snapshot()
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("два запуска по одной сети различаются:\n%v\n%v", a, b)
+	}
+}
+
+func TestSimulatedServiceDetection(t *testing.T) {
+	n, err := netsim.LoadFixture("office")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Уязвимые службы на нестандартных портах: по номеру порта их не распознать
+	host := n.Host("10.10.0.21")
+	host.TCP = append(host.TCP,
+		netsim.Port{Port: 2121, Banner: "220 (vsFTPd 2.3.4)\r\n"},
+		netsim.Port{Port: 2222, Banner: "SSH-2.0-OpenSSH_9.3p1 Debian-1\r\n"},
+	)
+	ns := scanner.NewScanner("10.10.0.21,10.10.0.30", 300*time.Millisecond, "21,22,80,2121,2222", 8, false, n, n, nil)
+	ns.SetEnrich("service")
+	summary, err := ns.ScanContext(context.Background())
+	if err != nil {
+		t.Fatalf("ScanContext() error = %v", err)
+	}
+	ports := make(map[string]scanner.PortInfo)
+	for _, r := range summary.Results {
+		for _, p := range r.Ports {
+			ports[r.IP+":"+strconv.Itoa(p.Port)] = p
+		}
+	}
+	for addr, want := range map[string][3]string{
+		"10.10.0.21:21":   {"ftp", "vsftpd", "3.0.3"},
+		"10.10.0.21:2121": {"ftp", "vsftpd", "2.3.4"},
+		"10.10.0.21:2222": {"ssh", "OpenSSH", "9.3p1"},
+		"10.10.0.30:80":   {"http", "nginx", "1.18.0"},
+	} {
+		p := ports[addr]
+		if p.Service != want[0] || p.Product != want[1] || p.Version != want[2] || p.CPE == "" {
+			t.Errorf("%s: служба %q, продукт %q, версия %q, CPE %q; want %v", addr, p.Service, p.Product, p.Version, p.CPE, want)
+		}
+	}
+
+	found := make(map[string]bool)
+	for _, m := range cve.AnalyzeResults(summary.Results, cve.NewDefaultCatalog(), cve.Options{}) {
+		found[m.Entry.ID+"@"+m.HostIP+":"+strconv.Itoa(m.Port)] = true
+	}
+	for _, want := range []string{"CVE-2011-2523@10.10.0.21:2121", "CVE-2023-38408@10.10.0.21:2222"} {
+		if !found[want] {
+			t.Errorf("нет совпадения %s: %v", want, found)
+		}
+	}
+	// vsftpd 3.0.3 вне диапазона уязвимой версии
+	if found["CVE-2011-2523@10.10.0.21:21"] {
+		t.Errorf("лишнее совпадение по vsftpd 3.0.3: %v", found)
+	}
+}
+
+func portBannerContains(r *scanner.Result, port, fragment string) bool {
+	for _, p := range r.Ports {
+		if strconv.Itoa(p.Port) == port && p.Protocol == "tcp" && strings.Contains(p.Banner, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+func addressesExcept(results []scanner.Result, skip string) []string {
+	out := make([]string, 0, len(results))
+	for _, r := range results {
+		if r.IP != skip {
+			out = append(out, r.IP)
+		}
+	}
+	return out
+}
+
+func diffChanged(diff *contracts.Diff, key string) bool {
+	for _, c := range diff.Changed {
+		if strings.EqualFold(c.Key, key) {
+			return true
+		}
+	}
+	return false
+}
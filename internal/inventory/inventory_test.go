@@ -1,11 +1,14 @@
 package inventory
 
 import (
+	"database/sql"
 	"path/filepath"
 	"testing"
 	"time"
 
 	"network-scanner/internal/scanner"
+	"network-scanner/internal/sshinspect"
+	"network-scanner/internal/tlsinspect"
 )
 
 func TestSaveLoadAndDiff(t *testing.T) {
@@ -54,3 +57,175 @@ func TestSaveLoadAndDiff(t *testing.T) {
 		t.Fatalf("expected 1 changed host, got %d", len(diff.Changed))
 	}
 }
+
+func TestDiffHonoursExclusions(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "inventory.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer store.Close()
+
+	snapA := []scanner.Result{
+		{IP: "192.168.1.10", Hostname: "cam-1", Ports: []scanner.PortInfo{{Port: 554, Protocol: "tcp", State: "open"}, {Port: 23, Protocol: "tcp", State: "open"}}},
+		{IP: "192.168.1.20", Hostname: "pc-1"},
+		{IP: "192.168.1.30", Hostname: "printer"},
+		{IP: "192.168.1.40", Hostname: "nas"},
+	}
+	snapB := []scanner.Result{
+		{IP: "192.168.1.10", Hostname: "cam-1", Ports: []scanner.PortInfo{{Port: 554, Protocol: "tcp", State: "open"}}},
+	}
+	meta := ScanMetadata{
+		Targets:      []string{"192.168.1.0/24"},
+		Exclude:      []string{"192.168.1.20-25", "printer"},
+		ExcludePorts: "23",
+	}
+	if err := store.SaveSnapshot("scan-a", time.Now().UTC(), snapA); err != nil {
+		t.Fatalf("save snapshot A: %v", err)
+	}
+	if err := store.SaveSnapshotWithMetadata("scan-b", time.Now().UTC(), snapB, meta); err != nil {
+		t.Fatalf("save snapshot B: %v", err)
+	}
+
+	loadedB, err := store.LoadSnapshot("scan-b")
+	if err != nil {
+		t.Fatalf("load snapshot B: %v", err)
+	}
+	if loadedB.Metadata.ExcludePorts != "23" || len(loadedB.Metadata.Exclude) != 2 {
+		t.Fatalf("metadata not persisted: %+v", loadedB.Metadata)
+	}
+
+	diff, err := store.Diff("scan-a", "scan-b")
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	if len(diff.Missing) != 1 || diff.Missing[0].IP != "192.168.1.40" {
+		t.Fatalf("expected only 192.168.1.40 missing, got %+v", diff.Missing)
+	}
+	if len(diff.Excluded) != 2 {
+		t.Fatalf("expected 2 excluded hosts, got %+v", diff.Excluded)
+	}
+	if len(diff.Changed) != 0 {
+		t.Fatalf("excluded port 23 must not be reported as change, got %+v", diff.Changed)
+	}
+}
+
+func TestOpenMigratesLegacySchema(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "inventory.db")
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if _, err := db.Exec(`CREATE TABLE snapshots (id TEXT PRIMARY KEY, created_at TEXT NOT NULL, data TEXT NOT NULL)`); err != nil {
+		t.Fatalf("create legacy schema: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO snapshots(id, created_at, data) VALUES('old', ?, '[]')`, time.Now().UTC().Format(time.RFC3339Nano)); err != nil {
+		t.Fatalf("insert legacy snapshot: %v", err)
+	}
+	_ = db.Close()
+
+	store, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer store.Close()
+	snap, err := store.LoadSnapshot("old")
+	if err != nil {
+		t.Fatalf("load legacy snapshot: %v", err)
+	}
+	if len(snap.Metadata.Exclude) != 0 {
+		t.Fatalf("legacy snapshot must have empty metadata, got %+v", snap.Metadata)
+	}
+}
+
+func TestDiffTracksTLS(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "inventory.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer store.Close()
+
+	notAfter := time.Date(2027, 1, 15, 0, 0, 0, 0, time.UTC)
+	withTLS := func(sha string) []scanner.PortInfo {
+		return []scanner.PortInfo{{Port: 443, Protocol: "tcp", State: "open", TLS: &tlsinspect.Info{
+			Protocols: []tlsinspect.Protocol{{Version: "TLS 1.3", CipherSuite: "TLS_AES_128_GCM_SHA256"}},
+			Chain:     []tlsinspect.Certificate{{Subject: "CN=web", NotAfter: notAfter, SHA256: sha}},
+		}}}
+	}
+	snapA := []scanner.Result{
+		{IP: "192.168.1.10", MAC: "AA:AA:AA:AA:AA:10", Ports: withTLS("aaaa")},
+		{IP: "192.168.1.20", MAC: "AA:AA:AA:AA:AA:20", Ports: withTLS("bbbb")},
+	}
+	snapB := []scanner.Result{
+		{IP: "192.168.1.10", MAC: "AA:AA:AA:AA:AA:10", Ports: withTLS("cccc")},
+		// Скан без стадии tls: отсутствие сведений о TLS изменением не считается
+		{IP: "192.168.1.20", MAC: "AA:AA:AA:AA:AA:20", Ports: []scanner.PortInfo{{Port: 443, Protocol: "tcp", State: "open"}}},
+	}
+	if err := store.SaveSnapshot("scan-a", time.Now().UTC(), snapA); err != nil {
+		t.Fatalf("save snapshot A: %v", err)
+	}
+	if err := store.SaveSnapshot("scan-b", time.Now().UTC(), snapB); err != nil {
+		t.Fatalf("save snapshot B: %v", err)
+	}
+
+	loaded, err := store.LoadSnapshot("scan-a")
+	if err != nil {
+		t.Fatalf("load snapshot A: %v", err)
+	}
+	leaf := loaded.Hosts[0].Ports[0].TLS.Leaf()
+	if leaf == nil || !leaf.NotAfter.Equal(notAfter) || leaf.Subject != "CN=web" {
+		t.Fatalf("TLS not persisted: %+v", loaded.Hosts[0].Ports[0].TLS)
+	}
+
+	diff, err := store.Diff("scan-a", "scan-b")
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	if len(diff.Changed) != 1 || diff.Changed[0].After.IP != "192.168.1.10" {
+		t.Fatalf("expected only 192.168.1.10 changed, got %+v", diff.Changed)
+	}
+	if fields := diff.Changed[0].ChangedField; len(fields) != 1 || fields[0] != "tls" {
+		t.Fatalf("expected tls change, got %v", fields)
+	}
+}
+
+func TestDiffTracksSSHHostKey(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "inventory.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer store.Close()
+
+	withSSH := func(fingerprints ...string) []scanner.PortInfo {
+		info := &sshinspect.Info{Banner: "SSH-2.0-OpenSSH_9.6"}
+		for _, fp := range fingerprints {
+			info.HostKeys = append(info.HostKeys, sshinspect.HostKey{Type: "ssh-ed25519", Fingerprint: fp})
+		}
+		return []scanner.PortInfo{{Port: 22, Protocol: "tcp", State: "open", SSH: info}}
+	}
+	snapA := []scanner.Result{
+		{IP: "192.168.1.10", MAC: "AA:AA:AA:AA:AA:10", Ports: withSSH("SHA256:a", "SHA256:b")},
+		{IP: "192.168.1.20", MAC: "AA:AA:AA:AA:AA:20", Ports: withSSH("SHA256:c")},
+	}
+	snapB := []scanner.Result{
+		{IP: "192.168.1.10", MAC: "AA:AA:AA:AA:AA:10", Ports: withSSH("SHA256:b", "SHA256:a")},
+		{IP: "192.168.1.20", MAC: "AA:AA:AA:AA:AA:20", Ports: withSSH("SHA256:d")},
+	}
+	if err := store.SaveSnapshot("scan-a", time.Now().UTC(), snapA); err != nil {
+		t.Fatalf("save snapshot A: %v", err)
+	}
+	if err := store.SaveSnapshot("scan-b", time.Now().UTC(), snapB); err != nil {
+		t.Fatalf("save snapshot B: %v", err)
+	}
+
+	diff, err := store.Diff("scan-a", "scan-b")
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	// Порядок ключей не важен: изменился только ключ 192.168.1.20
+	if len(diff.Changed) != 1 || diff.Changed[0].After.IP != "192.168.1.20" {
+		t.Fatalf("expected only 192.168.1.20 changed, got %+v", diff.Changed)
+	}
+	if fields := diff.Changed[0].ChangedField; len(fields) != 1 || fields[0] != "ssh_host_key" {
+		t.Fatalf("expected ssh_host_key change, got %v", fields)
+	}
+}
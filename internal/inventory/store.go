@@ -1,19 +1,20 @@
 ﻿package inventory
 
-import (
-	"database/sql"
-	"encoding/json"
-	"fmt"
-	"os"
-	"path/filepath"
-	"sort"
-	"strings"
-	"time"
-
-	_ "modernc.org/sqlite"
-
-	"network-scanner/internal/comparator"
-	"network-scanner/internal/scanner"
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"network-scanner/internal/comparator"
+	"network-scanner/internal/network"
+	"network-scanner/internal/scanner"
 )
 
 type Store struct {
@@ -24,6 +25,17 @@ type Snapshot struct {
 	ID        string
 	Timestamp time.Time
 	Hosts     []scanner.Result
+	Metadata  ScanMetadata
+}
+
+// ScanMetadata описывает параметры сканирования, по которому снят снапшот.
+// Exclude и ExcludePorts учитываются в Diff: исключённые хосты и порты
+// не считаются пропавшими или изменившимися.
+type ScanMetadata struct {
+	Targets      []string `json:"targets,omitempty"`
+	Exclude      []string `json:"exclude,omitempty"`
+	ExcludePorts string   `json:"exclude_ports,omitempty"`
+	Proxy        string   `json:"proxy,omitempty"` // прокси сканирования (адрес без пароля)
 }
 
 type ChangedHost struct {
@@ -38,7 +50,9 @@ type DiffResult struct {
 	ScanIDB string
 	New     []scanner.Result
 	Missing []scanner.Result
-	Changed []ChangedHost
+	// Excluded — хосты из A, отсутствующие в B, потому что B сканировался с их исключением.
+	Excluded []scanner.Result
+	Changed  []ChangedHost
 }
 
 func Open(path string) (*Store, error) {
@@ -69,6 +83,11 @@ func (s *Store) Close() error {
 }
 
 func (s *Store) SaveSnapshot(scanID string, ts time.Time, hosts []scanner.Result) error {
+	return s.SaveSnapshotWithMetadata(scanID, ts, hosts, ScanMetadata{})
+}
+
+// SaveSnapshotWithMetadata сохраняет снапшот вместе с параметрами сканирования.
+func (s *Store) SaveSnapshotWithMetadata(scanID string, ts time.Time, hosts []scanner.Result, meta ScanMetadata) error {
 	if s == nil || s.db == nil {
 		return fmt.Errorf("inventory store is not initialized")
 	}
@@ -83,11 +102,16 @@ func (s *Store) SaveSnapshot(scanID string, ts time.Time, hosts []scanner.Result
 	if err != nil {
 		return fmt.Errorf("marshal snapshot: %w", err)
 	}
+	metaPayload, err := json.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("marshal snapshot metadata: %w", err)
+	}
 	_, err = s.db.Exec(
-		`INSERT OR REPLACE INTO snapshots(id, created_at, data) VALUES(?, ?, ?)`,
+		`INSERT OR REPLACE INTO snapshots(id, created_at, data, metadata) VALUES(?, ?, ?, ?)`,
 		scanID,
 		ts.UTC().Format(time.RFC3339Nano),
 		string(payload),
+		string(metaPayload),
 	)
 	if err != nil {
 		return fmt.Errorf("insert snapshot: %w", err)
@@ -104,9 +128,9 @@ func (s *Store) LoadSnapshot(scanID string) (Snapshot, error) {
 		return Snapshot{}, fmt.Errorf("scanID is required")
 	}
 	var createdAtRaw string
-	var payload string
-	row := s.db.QueryRow(`SELECT created_at, data FROM snapshots WHERE id = ?`, scanID)
-	if err := row.Scan(&createdAtRaw, &payload); err != nil {
+	var payload, metaPayload string
+	row := s.db.QueryRow(`SELECT created_at, data, metadata FROM snapshots WHERE id = ?`, scanID)
+	if err := row.Scan(&createdAtRaw, &payload, &metaPayload); err != nil {
 		if err == sql.ErrNoRows {
 			return Snapshot{}, fmt.Errorf("snapshot %q not found", scanID)
 		}
@@ -120,10 +144,15 @@ func (s *Store) LoadSnapshot(scanID string) (Snapshot, error) {
 	if err := json.Unmarshal([]byte(payload), &hosts); err != nil {
 		return Snapshot{}, fmt.Errorf("decode snapshot payload: %w", err)
 	}
+	var meta ScanMetadata
+	if err := json.Unmarshal([]byte(metaPayload), &meta); err != nil {
+		return Snapshot{}, fmt.Errorf("decode snapshot metadata: %w", err)
+	}
 	return Snapshot{
 		ID:        scanID,
 		Timestamp: createdAt,
 		Hosts:     hosts,
+		Metadata:  meta,
 	}, nil
 }
 
@@ -131,7 +160,7 @@ func (s *Store) ListSnapshots(limit int) ([]Snapshot, error) {
 	if s == nil || s.db == nil {
 		return nil, fmt.Errorf("inventory store is not initialized")
 	}
-	q := `SELECT id, created_at, data FROM snapshots ORDER BY created_at DESC`
+	q := `SELECT id, created_at, data, metadata FROM snapshots ORDER BY created_at DESC`
 	args := make([]interface{}, 0)
 	if limit > 0 {
 		q += ` LIMIT ?`
@@ -144,8 +173,8 @@ func (s *Store) ListSnapshots(limit int) ([]Snapshot, error) {
 	defer rows.Close()
 	out := make([]Snapshot, 0)
 	for rows.Next() {
-		var id, createdAtRaw, payload string
-		if err := rows.Scan(&id, &createdAtRaw, &payload); err != nil {
+		var id, createdAtRaw, payload, metaPayload string
+		if err := rows.Scan(&id, &createdAtRaw, &payload, &metaPayload); err != nil {
 			return nil, fmt.Errorf("scan snapshot row: %w", err)
 		}
 		t, _ := time.Parse(time.RFC3339Nano, createdAtRaw)
@@ -153,7 +182,11 @@ func (s *Store) ListSnapshots(limit int) ([]Snapshot, error) {
 		if err := json.Unmarshal([]byte(payload), &hosts); err != nil {
 			return nil, fmt.Errorf("decode snapshot payload: %w", err)
 		}
-		out = append(out, Snapshot{ID: id, Timestamp: t, Hosts: hosts})
+		var meta ScanMetadata
+		if err := json.Unmarshal([]byte(metaPayload), &meta); err != nil {
+			return nil, fmt.Errorf("decode snapshot metadata: %w", err)
+		}
+		out = append(out, Snapshot{ID: id, Timestamp: t, Hosts: hosts, Metadata: meta})
 	}
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("iterate snapshots: %w", err)
@@ -172,13 +205,24 @@ func (s *Store) Diff(scanIDA, scanIDB string) (DiffResult, error) {
 	}
 	aMap := hostsByKey(a.Hosts)
 	bMap := hostsByKey(b.Hosts)
+	// Хосты, исключённые из скана B, не считаются пропавшими; порты, исключённые
+	// в любом из сканов, не участвуют в сравнении портов.
+	excludedB, err := network.NewTargetMatcher(b.Metadata.Exclude)
+	if err != nil {
+		return DiffResult{}, fmt.Errorf("snapshot %q exclusions: %w", b.ID, err)
+	}
+	skipPorts, err := excludedPortSet(a.Metadata.ExcludePorts, b.Metadata.ExcludePorts)
+	if err != nil {
+		return DiffResult{}, err
+	}
 
 	res := DiffResult{
-		ScanIDA: a.ID,
-		ScanIDB: b.ID,
-		New:     make([]scanner.Result, 0),
-		Missing: make([]scanner.Result, 0),
-		Changed: make([]ChangedHost, 0),
+		ScanIDA:  a.ID,
+		ScanIDB:  b.ID,
+		New:      make([]scanner.Result, 0),
+		Missing:  make([]scanner.Result, 0),
+		Excluded: make([]scanner.Result, 0),
+		Changed:  make([]ChangedHost, 0),
 	}
 
 	for key, hostB := range bMap {
@@ -187,7 +231,7 @@ func (s *Store) Diff(scanIDA, scanIDB string) (DiffResult, error) {
 			res.New = append(res.New, hostB)
 			continue
 		}
-		fields := changedFields(hostA, hostB)
+		fields := changedFields(withoutPorts(hostA, skipPorts), withoutPorts(hostB, skipPorts))
 		if len(fields) > 0 {
 			res.Changed = append(res.Changed, ChangedHost{
 				Key:          key,
@@ -198,16 +242,55 @@ func (s *Store) Diff(scanIDA, scanIDB string) (DiffResult, error) {
 		}
 	}
 	for key, hostA := range aMap {
-		if _, ok := bMap[key]; !ok {
-			res.Missing = append(res.Missing, hostA)
+		if _, ok := bMap[key]; ok {
+			continue
+		}
+		if excludedB.ContainsHost(hostA.IP, hostA.Hostname) {
+			res.Excluded = append(res.Excluded, hostA)
+			continue
 		}
+		res.Missing = append(res.Missing, hostA)
 	}
 	sort.Slice(res.New, func(i, j int) bool { return res.New[i].IP < res.New[j].IP })
 	sort.Slice(res.Missing, func(i, j int) bool { return res.Missing[i].IP < res.Missing[j].IP })
+	sort.Slice(res.Excluded, func(i, j int) bool { return res.Excluded[i].IP < res.Excluded[j].IP })
 	sort.Slice(res.Changed, func(i, j int) bool { return res.Changed[i].Key < res.Changed[j].Key })
 	return res, nil
 }
 
+// excludedPortSet объединяет списки исключённых портов (формат диапазона портов).
+func excludedPortSet(ranges ...string) (map[int]struct{}, error) {
+	out := make(map[int]struct{})
+	for _, r := range ranges {
+		if strings.TrimSpace(r) == "" {
+			continue
+		}
+		ports, err := network.ParsePortRange(r)
+		if err != nil {
+			return nil, fmt.Errorf("excluded ports %q: %w", r, err)
+		}
+		for _, p := range ports {
+			out[p] = struct{}{}
+		}
+	}
+	return out, nil
+}
+
+// withoutPorts возвращает копию хоста без портов из skip.
+func withoutPorts(h scanner.Result, skip map[int]struct{}) scanner.Result {
+	if len(skip) == 0 {
+		return h
+	}
+	ports := make([]scanner.PortInfo, 0, len(h.Ports))
+	for _, p := range h.Ports {
+		if _, ok := skip[p.Port]; !ok {
+			ports = append(ports, p)
+		}
+	}
+	h.Ports = ports
+	return h
+}
+
 func hostsByKey(hosts []scanner.Result) map[string]scanner.Result {
 	out := make(map[string]scanner.Result, len(hosts))
 	for _, h := range hosts {
@@ -255,9 +338,68 @@ func changedFields(a, b scanner.Result) []string {
 	if !portsEqual(a.Ports, b.Ports) {
 		fields = append(fields, "ports")
 	}
+	if !portDetailsEqual(a.Ports, b.Ports, tlsFingerprint) {
+		fields = append(fields, "tls")
+	}
+	if !portDetailsEqual(a.Ports, b.Ports, sshHostKeyFingerprint) {
+		fields = append(fields, "ssh_host_key")
+	}
 	return fields
 }
 
+// portDetailsEqual сравнивает сведения, снятые стадиями обогащения с одних и тех же портов
+// обоих снапшотов. fingerprint возвращает ok=false, если сведений о порте нет (стадия не
+// запускалась): такие порты не сравниваются, чтобы скан без стадии не давал изменений.
+func portDetailsEqual(a, b []scanner.PortInfo, fingerprint func(scanner.PortInfo) (string, bool)) bool {
+	before := make(map[string]string)
+	for _, p := range a {
+		if fp, ok := fingerprint(p); ok {
+			before[fmt.Sprintf("%d/%s", p.Port, strings.ToLower(p.Protocol))] = fp
+		}
+	}
+	for _, p := range b {
+		fp, ok := fingerprint(p)
+		if !ok {
+			continue
+		}
+		if prev, ok := before[fmt.Sprintf("%d/%s", p.Port, strings.ToLower(p.Protocol))]; ok && prev != fp {
+			return false
+		}
+	}
+	return true
+}
+
+// tlsFingerprint сводит TLS порта (стадия tls) к отпечатку SHA-256 сертификата узла и
+// списку поддерживаемых версий.
+func tlsFingerprint(p scanner.PortInfo) (string, bool) {
+	if p.TLS == nil {
+		return "", false
+	}
+	versions := make([]string, 0, len(p.TLS.Protocols))
+	for _, v := range p.TLS.Protocols {
+		versions = append(versions, v.Version)
+	}
+	leaf := ""
+	if c := p.TLS.Leaf(); c != nil {
+		leaf = c.SHA256
+	}
+	return leaf + "|" + strings.Join(versions, ","), true
+}
+
+// sshHostKeyFingerprint сводит SSH порта (стадия ssh) к отсортированному набору отпечатков
+// ключей хоста. Смена ключа — переустановка устройства или подмена сервера.
+func sshHostKeyFingerprint(p scanner.PortInfo) (string, bool) {
+	if p.SSH == nil || len(p.SSH.HostKeys) == 0 {
+		return "", false
+	}
+	keys := make([]string, 0, len(p.SSH.HostKeys))
+	for _, k := range p.SSH.HostKeys {
+		keys = append(keys, k.Fingerprint)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ","), true
+}
+
 func portsEqual(a, b []scanner.PortInfo) bool {
 	if len(a) != len(b) {
 		return false
@@ -285,67 +427,96 @@ func (s *Store) ensureSchema() error {
 CREATE TABLE IF NOT EXISTS snapshots (
 	id TEXT PRIMARY KEY,
 	created_at TEXT NOT NULL,
-	data TEXT NOT NULL
+	data TEXT NOT NULL,
+	metadata TEXT NOT NULL DEFAULT '{}'
 );
 `)
 	if err != nil {
 		return fmt.Errorf("create inventory schema: %w", err)
 	}
+	// Базы, созданные до появления метаданных сканирования, дополняем колонкой metadata.
+	hasMetadata, err := s.hasColumn("snapshots", "metadata")
+	if err != nil {
+		return err
+	}
+	if !hasMetadata {
+		if _, err := s.db.Exec(`ALTER TABLE snapshots ADD COLUMN metadata TEXT NOT NULL DEFAULT '{}'`); err != nil {
+			return fmt.Errorf("migrate inventory schema: %w", err)
+		}
+	}
 	return nil
 }
 
-
-
-// GetScanHistory возвращает историю сканирований с metadata
-func (s *Store) GetScanHistory(limit int) ([]comparator.ScanHistoryEntry, []scanner.Result, error) {
-	if s == nil || s.db == nil {
-		return nil, nil, fmt.Errorf("inventory store is not initialized")
-	}
-	snapshots, err := s.ListSnapshots(limit)
-	if err != nil {
-		return nil, nil, err
-	}
-	history := make([]comparator.ScanHistoryEntry, 0, len(snapshots))
-	allHosts := make([]scanner.Result, 0)
-	for _, snap := range snapshots {
-		entry := comparator.ScanHistoryEntry{
-			ID:        snap.ID,
-			HostCount: len(snap.Hosts),
-			StartedAt: snap.Timestamp,
-			Completed: snap.Timestamp,
-			Ports:     make(map[string]int),
-			OSMap:     make(map[string]int),
-			VendorMap: make(map[string]int),
-		}
-		for _, h := range snap.Hosts {
-			if h.GuessOS != "" {
-				entry.OSMap[h.GuessOS]++
-			}
-			if h.DeviceVendor != "" {
-				entry.VendorMap[h.DeviceVendor]++
-			}
-			for _, p := range h.Ports {
-				if strings.EqualFold(p.State, "open") {
-					key := fmt.Sprintf("%d/%s", p.Port, p.Protocol)
-					entry.Ports[key]++
-				}
-			}
-		}
-		history = append(history, entry)
-		allHosts = append(allHosts, snap.Hosts...)
-	}
-	return history, allHosts, nil
-}
-
-// CompareSnapshotsByName сравнивает два снапшота по ID и возвращает ComparisonResult
-func (s *Store) CompareSnapshotsByName(scanIDA, scanIDB string) (*comparator.ComparisonResult, error) {
-	a, err := s.LoadSnapshot(scanIDA)
-	if err != nil {
-		return nil, fmt.Errorf("load snapshot A: %w", err)
-	}
-	b, err := s.LoadSnapshot(scanIDB)
-	if err != nil {
-		return nil, fmt.Errorf("load snapshot B: %w", err)
-	}
-	return comparator.CompareSnapshots(scanIDA, scanIDB, a.Hosts, b.Hosts), nil
+func (s *Store) hasColumn(table, column string) (bool, error) {
+	rows, err := s.db.Query(`SELECT name FROM pragma_table_info(?)`, table)
+	if err != nil {
+		return false, fmt.Errorf("inspect inventory schema: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return false, fmt.Errorf("inspect inventory schema: %w", err)
+		}
+		if strings.EqualFold(name, column) {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
+
+
+
+// GetScanHistory возвращает историю сканирований с metadata
+func (s *Store) GetScanHistory(limit int) ([]comparator.ScanHistoryEntry, []scanner.Result, error) {
+	if s == nil || s.db == nil {
+		return nil, nil, fmt.Errorf("inventory store is not initialized")
+	}
+	snapshots, err := s.ListSnapshots(limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	history := make([]comparator.ScanHistoryEntry, 0, len(snapshots))
+	allHosts := make([]scanner.Result, 0)
+	for _, snap := range snapshots {
+		entry := comparator.ScanHistoryEntry{
+			ID:        snap.ID,
+			HostCount: len(snap.Hosts),
+			StartedAt: snap.Timestamp,
+			Completed: snap.Timestamp,
+			Ports:     make(map[string]int),
+			OSMap:     make(map[string]int),
+			VendorMap: make(map[string]int),
+		}
+		for _, h := range snap.Hosts {
+			if h.GuessOS != "" {
+				entry.OSMap[h.GuessOS]++
+			}
+			if h.DeviceVendor != "" {
+				entry.VendorMap[h.DeviceVendor]++
+			}
+			for _, p := range h.Ports {
+				if strings.EqualFold(p.State, "open") {
+					key := fmt.Sprintf("%d/%s", p.Port, p.Protocol)
+					entry.Ports[key]++
+				}
+			}
+		}
+		history = append(history, entry)
+		allHosts = append(allHosts, snap.Hosts...)
+	}
+	return history, allHosts, nil
+}
+
+// CompareSnapshotsByName сравнивает два снапшота по ID и возвращает ComparisonResult
+func (s *Store) CompareSnapshotsByName(scanIDA, scanIDB string) (*comparator.ComparisonResult, error) {
+	a, err := s.LoadSnapshot(scanIDA)
+	if err != nil {
+		return nil, fmt.Errorf("load snapshot A: %w", err)
+	}
+	b, err := s.LoadSnapshot(scanIDB)
+	if err != nil {
+		return nil, fmt.Errorf("load snapshot B: %w", err)
+	}
+	return comparator.CompareSnapshots(scanIDA, scanIDB, a.Hosts, b.Hosts), nil
 }
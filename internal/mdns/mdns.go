@@ -0,0 +1,399 @@
+// Package mdns находит устройства локальной сети по multicast DNS и DNS-SD (RFC 6762,
+// RFC 6763): просматривает типы служб _services._dns-sd._udp.local, разрешает экземпляры
+// (SRV, TXT, A, AAAA) и собирает по каждому устройству имя, адреса, службы и метаданные TXT.
+package mdns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+	"golang.org/x/net/ipv4"
+)
+
+// Port — порт multicast DNS.
+const Port = 5353
+
+// DefaultWindow — окно сбора ответов по умолчанию.
+const DefaultWindow = 2 * time.Second
+
+const (
+	servicesName = "_services._dns-sd._udp.local." // перечисление типов служб (RFC 6763, 9)
+	maxQueries   = 512                             // предел запросов за один просмотр
+	maxMessage   = 9000                            // предел размера ответа mDNS
+)
+
+// groupIPv4 — группа multicast DNS.
+var groupIPv4 = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: Port}
+
+// Options — параметры просмотра.
+type Options struct {
+	// Window — сколько ждать ответов; 0 — DefaultWindow.
+	Window time.Duration
+	// Interface — интерфейс рассылки запросов; пусто — выбор ОС.
+	Interface string
+	// Group — адрес запросов; nil — 224.0.0.251:5353.
+	Group *net.UDPAddr
+}
+
+// Host — устройство, объявившее службы DNS-SD.
+type Host struct {
+	Hostname  string    `json:"hostname"`            // имя из SRV ("printer.local")
+	Addresses []string  `json:"addresses,omitempty"` // адреса A/AAAA, IPv4 первыми
+	Services  []Service `json:"services,omitempty"`
+}
+
+// Service — экземпляр службы DNS-SD.
+type Service struct {
+	Instance string            `json:"instance"`      // имя экземпляра ("Office Printer")
+	Type     string            `json:"type"`          // тип службы ("_ipp._tcp")
+	Port     int               `json:"port"`          // порт из SRV
+	TXT      map[string]string `json:"txt,omitempty"` // пары TXT, ключи в нижнем регистре
+}
+
+// Ключи TXT с моделью и прошивкой устройства в порядке предпочтения: Apple (model, am,
+// srcvers, osxvers), принтеры (ty, usb_mdl, product), Chromecast (md) и IoT.
+var (
+	modelKeys    = []string{"model", "md", "ty", "usb_mdl", "product", "am", "modelname"}
+	firmwareKeys = []string{"fv", "fw", "firmware", "fwversion", "fwvers", "srcvers", "osxvers"}
+)
+
+// Model возвращает модель устройства из TXT его служб; пусто — не объявлена.
+func (h *Host) Model() string {
+	v := h.txtValue(modelKeys)
+	// product принтеров записывается в скобках: "(HP LaserJet 400)"
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(v, "("), ")"))
+}
+
+// Firmware возвращает версию прошивки из TXT его служб; пусто — не объявлена.
+func (h *Host) Firmware() string {
+	return h.txtValue(firmwareKeys)
+}
+
+// ServiceTypes возвращает типы объявленных служб без повторов в порядке сортировки.
+func (h *Host) ServiceTypes() []string {
+	if h == nil {
+		return nil
+	}
+	types := make([]string, 0, len(h.Services))
+	for _, s := range h.Services {
+		types = append(types, s.Type)
+	}
+	slices.Sort(types)
+	return slices.Compact(types)
+}
+
+// Summary — краткое описание для текстового вывода: "Chromecast, fw 1.56, _googlecast._tcp".
+func (h *Host) Summary() string {
+	if h == nil {
+		return ""
+	}
+	var parts []string
+	if m := h.Model(); m != "" {
+		parts = append(parts, m)
+	}
+	if fw := h.Firmware(); fw != "" {
+		parts = append(parts, "fw "+fw)
+	}
+	if types := h.ServiceTypes(); len(types) > 0 {
+		parts = append(parts, strings.Join(types, " "))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// txtValue возвращает первое непустое значение по ключам keys среди TXT всех служб.
+func (h *Host) txtValue(keys []string) string {
+	if h == nil {
+		return ""
+	}
+	for _, key := range keys {
+		for _, s := range h.Services {
+			if v := strings.TrimSpace(s.TXT[key]); v != "" {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+// Browse просматривает службы DNS-SD в течение окна Options.Window: запрашивает типы служб,
+// затем экземпляры каждого типа, их SRV и TXT и адреса целей SRV. Запросы отправляются с
+// временного порта (legacy unicast, RFC 6762, 6.7), ответы приходят на него же. Возвращает
+// устройства, для которых известны адреса, в порядке имён.
+func Browse(ctx context.Context, opts Options) ([]Host, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	window := opts.Window
+	if window <= 0 {
+		window = DefaultWindow
+	}
+	group := opts.Group
+	if group == nil {
+		group = groupIPv4
+	}
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{})
+	if err != nil {
+		return nil, fmt.Errorf("mdns: %w", err)
+	}
+	defer conn.Close()
+	if group.IP.IsMulticast() {
+		pc := ipv4.NewPacketConn(conn)
+		if opts.Interface != "" {
+			ifi, err := net.InterfaceByName(opts.Interface)
+			if err != nil {
+				return nil, fmt.Errorf("mdns: %w", err)
+			}
+			if err := pc.SetMulticastInterface(ifi); err != nil {
+				return nil, fmt.Errorf("mdns: %s: %w", opts.Interface, err)
+			}
+		}
+		_ = pc.SetMulticastTTL(255)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(window))
+	stop := context.AfterFunc(ctx, func() { _ = conn.SetReadDeadline(time.Now()) })
+	defer stop()
+
+	b := newBrowser(func(msg []byte) error {
+		_, err := conn.WriteToUDP(msg, group)
+		return err
+	})
+	if err := b.query(servicesName, dnsmessage.TypePTR); err != nil {
+		return nil, fmt.Errorf("mdns: %w", err)
+	}
+	buf := make([]byte, maxMessage)
+	for {
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				break
+			}
+			return nil, fmt.Errorf("mdns: %w", err)
+		}
+		b.handle(buf[:n])
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return b.hosts(), nil
+}
+
+// instance — экземпляр службы, найденный по PTR типа.
+type instance struct {
+	name string // полное имя экземпляра в исходном регистре
+	typ  string // тип службы в нижнем регистре ("_ipp._tcp.local.")
+}
+
+// srvRecord — цель и порт экземпляра.
+type srvRecord struct {
+	target string // имя цели в исходном регистре
+	port   int
+}
+
+// browser накапливает записи ответов и отправляет уточняющие запросы по мере их появления.
+// Ключи карт — имена в нижнем регистре (имена DNS сравниваются без учёта регистра).
+type browser struct {
+	send      func(msg []byte) error
+	sent      map[string]bool
+	types     map[string]bool
+	instances map[string]instance
+	srv       map[string]srvRecord
+	txt       map[string]map[string]string
+	addrs     map[string][]net.IP
+}
+
+func newBrowser(send func(msg []byte) error) *browser {
+	return &browser{
+		send:      send,
+		sent:      make(map[string]bool),
+		types:     make(map[string]bool),
+		instances: make(map[string]instance),
+		srv:       make(map[string]srvRecord),
+		txt:       make(map[string]map[string]string),
+		addrs:     make(map[string][]net.IP),
+	}
+}
+
+// query отправляет запрос name/qtype, если он ещё не отправлялся и предел не исчерпан.
+func (b *browser) query(name string, qtype dnsmessage.Type) error {
+	key := strings.ToLower(name) + "/" + qtype.String()
+	if b.sent[key] || len(b.sent) >= maxQueries {
+		return nil
+	}
+	qname, err := dnsmessage.NewName(name)
+	if err != nil {
+		return err
+	}
+	b.sent[key] = true
+	msg := dnsmessage.Message{
+		Questions: []dnsmessage.Question{{
+			Name:  qname,
+			Type:  qtype,
+			Class: dnsmessage.ClassINET | 0x8000, // QU: ответ unicast
+		}},
+	}
+	packed, err := msg.Pack()
+	if err != nil {
+		return err
+	}
+	return b.send(packed)
+}
+
+// handle разбирает ответ и запрашивает недостающие записи найденных типов, экземпляров и целей.
+func (b *browser) handle(packet []byte) {
+	var msg dnsmessage.Message
+	if err := msg.Unpack(packet); err != nil || !msg.Header.Response {
+		return
+	}
+	records := append(append(msg.Answers, msg.Authorities...), msg.Additionals...)
+	for _, rr := range records {
+		name := strings.ToLower(rr.Header.Name.String())
+		switch body := rr.Body.(type) {
+		case *dnsmessage.PTRResource:
+			target := body.PTR.String()
+			if name == servicesName {
+				if isServiceType(strings.ToLower(target)) {
+					b.types[strings.ToLower(target)] = true
+				}
+			} else if isServiceType(name) {
+				b.instances[strings.ToLower(target)] = instance{name: target, typ: name}
+			}
+		case *dnsmessage.SRVResource:
+			b.srv[name] = srvRecord{target: body.Target.String(), port: int(body.Port)}
+		case *dnsmessage.TXTResource:
+			b.txt[name] = parseTXT(body.TXT)
+		case *dnsmessage.AResource:
+			b.addAddr(name, net.IP(body.A[:]))
+		case *dnsmessage.AAAAResource:
+			b.addAddr(name, net.IP(body.AAAA[:]))
+		}
+	}
+
+	for typ := range b.types {
+		_ = b.query(typ, dnsmessage.TypePTR)
+	}
+	for key, inst := range b.instances {
+		if _, ok := b.srv[key]; !ok {
+			_ = b.query(inst.name, dnsmessage.TypeSRV)
+		}
+		if _, ok := b.txt[key]; !ok {
+			_ = b.query(inst.name, dnsmessage.TypeTXT)
+		}
+	}
+	for _, s := range b.srv {
+		if len(b.addrs[strings.ToLower(s.target)]) == 0 {
+			_ = b.query(s.target, dnsmessage.TypeA)
+			_ = b.query(s.target, dnsmessage.TypeAAAA)
+		}
+	}
+}
+
+// addAddr добавляет адрес имени без повторов.
+func (b *browser) addAddr(name string, ip net.IP) {
+	for _, known := range b.addrs[name] {
+		if known.Equal(ip) {
+			return
+		}
+	}
+	b.addrs[name] = append(b.addrs[name], ip)
+}
+
+// hosts собирает устройства по целям SRV: у каждого — адреса цели и её экземпляры служб.
+func (b *browser) hosts() []Host {
+	byTarget := make(map[string]*Host)
+	keys := make([]string, 0, len(b.instances))
+	for key := range b.instances {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		inst := b.instances[key]
+		s, ok := b.srv[key]
+		if !ok {
+			continue
+		}
+		target := strings.ToLower(s.target)
+		addrs := b.addrs[target]
+		if len(addrs) == 0 {
+			continue
+		}
+		h := byTarget[target]
+		if h == nil {
+			h = &Host{Hostname: strings.TrimSuffix(s.target, "."), Addresses: sortAddrs(addrs)}
+			byTarget[target] = h
+		}
+		h.Services = append(h.Services, Service{
+			Instance: instanceLabel(inst.name, inst.typ),
+			Type:     strings.TrimSuffix(inst.typ, ".local."),
+			Port:     s.port,
+			TXT:      b.txt[key],
+		})
+	}
+
+	targets := make([]string, 0, len(byTarget))
+	for target := range byTarget {
+		targets = append(targets, target)
+	}
+	slices.Sort(targets)
+	out := make([]Host, 0, len(targets))
+	for _, target := range targets {
+		out = append(out, *byTarget[target])
+	}
+	return out
+}
+
+// isServiceType сообщает, что имя — тип службы DNS-SD ("_ipp._tcp.local.", без подтипов _sub).
+func isServiceType(name string) bool {
+	if strings.Contains(name, "._sub.") {
+		return false
+	}
+	return strings.HasSuffix(name, "._tcp.local.") || strings.HasSuffix(name, "._udp.local.")
+}
+
+// instanceLabel отрезает тип службы от имени экземпляра: "Office Printer._ipp._tcp.local." →
+// "Office Printer".
+func instanceLabel(name, typ string) string {
+	if len(name) > len(typ) && strings.EqualFold(name[len(name)-len(typ):], typ) {
+		name = name[:len(name)-len(typ)]
+	}
+	return strings.TrimSuffix(name, ".")
+}
+
+// parseTXT разбирает строки TXT "ключ=значение" (RFC 6763, 6.3); ключ без "=" — логический
+// признак с пустым значением. Повторный ключ игнорируется.
+func parseTXT(items []string) map[string]string {
+	var out map[string]string
+	for _, item := range items {
+		key, value, _ := strings.Cut(item, "=")
+		key = strings.ToLower(strings.TrimSpace(key))
+		if key == "" {
+			continue
+		}
+		if out == nil {
+			out = make(map[string]string)
+		}
+		if _, ok := out[key]; !ok {
+			out[key] = value
+		}
+	}
+	return out
+}
+
+// sortAddrs возвращает адреса строками, IPv4 первыми.
+func sortAddrs(ips []net.IP) []string {
+	var v4, v6 []string
+	for _, ip := range ips {
+		if ip.To4() != nil {
+			v4 = append(v4, ip.String())
+		} else {
+			v6 = append(v6, ip.String())
+		}
+	}
+	return append(v4, v6...)
+}
@@ -0,0 +1,158 @@
+package mdns
+
+import (
+	"context"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// serveMDNS запускает ответчик, который объявляет принтер и Chromecast: на каждый вопрос
+// отвечает записями своей зоны, подходящими по имени и типу. Адрес Chromecast приходит только
+// в ответ на запрос A, адрес принтера — в дополнительных записях SRV.
+func serveMDNS(t *testing.T) *net.UDPAddr {
+	t.Helper()
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	name := dnsmessage.MustNewName
+	hdr := func(n string, typ dnsmessage.Type) dnsmessage.ResourceHeader {
+		return dnsmessage.ResourceHeader{Name: name(n), Type: typ, Class: dnsmessage.ClassINET | 0x8000, TTL: 120}
+	}
+	printerA := dnsmessage.Resource{Header: hdr("Office-Printer.local.", dnsmessage.TypeA), Body: &dnsmessage.AResource{A: [4]byte{192, 168, 1, 20}}}
+	zone := []dnsmessage.Resource{
+		{Header: hdr(servicesName, dnsmessage.TypePTR), Body: &dnsmessage.PTRResource{PTR: name("_ipp._tcp.local.")}},
+		{Header: hdr(servicesName, dnsmessage.TypePTR), Body: &dnsmessage.PTRResource{PTR: name("_googlecast._tcp.local.")}},
+		{Header: hdr("_ipp._tcp.local.", dnsmessage.TypePTR), Body: &dnsmessage.PTRResource{PTR: name("Office Printer._ipp._tcp.local.")}},
+		{Header: hdr("_googlecast._tcp.local.", dnsmessage.TypePTR), Body: &dnsmessage.PTRResource{PTR: name("Living Room._googlecast._tcp.local.")}},
+		{Header: hdr("Office Printer._ipp._tcp.local.", dnsmessage.TypeSRV), Body: &dnsmessage.SRVResource{Port: 631, Target: name("Office-Printer.local.")}},
+		{Header: hdr("Office Printer._ipp._tcp.local.", dnsmessage.TypeTXT), Body: &dnsmessage.TXTResource{TXT: []string{"txtvers=1", "ty=HP LaserJet 400", "product=(HP LaserJet 400 M401dn)"}}},
+		{Header: hdr("Living Room._googlecast._tcp.local.", dnsmessage.TypeSRV), Body: &dnsmessage.SRVResource{Port: 8009, Target: name("chromecast-1.local.")}},
+		{Header: hdr("Living Room._googlecast._tcp.local.", dnsmessage.TypeTXT), Body: &dnsmessage.TXTResource{TXT: []string{"md=Chromecast", "fn=Living Room", "bs"}}},
+		{Header: hdr("chromecast-1.local.", dnsmessage.TypeA), Body: &dnsmessage.AResource{A: [4]byte{192, 168, 1, 30}}},
+		printerA,
+	}
+
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, from, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			var q dnsmessage.Message
+			if q.Unpack(buf[:n]) != nil || len(q.Questions) != 1 {
+				continue
+			}
+			question := q.Questions[0]
+			resp := dnsmessage.Message{Header: dnsmessage.Header{Response: true, Authoritative: true}, Questions: q.Questions}
+			for _, rr := range zone {
+				if strings.EqualFold(rr.Header.Name.String(), question.Name.String()) && rr.Header.Type == question.Type {
+					resp.Answers = append(resp.Answers, rr)
+					if rr.Header.Type == dnsmessage.TypeSRV && rr.Body.(*dnsmessage.SRVResource).Target == printerA.Header.Name {
+						resp.Additionals = append(resp.Additionals, printerA)
+					}
+				}
+			}
+			if len(resp.Answers) == 0 {
+				continue
+			}
+			packed, err := resp.Pack()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			_, _ = conn.WriteToUDP(packed, from)
+		}
+	}()
+	return conn.LocalAddr().(*net.UDPAddr)
+}
+
+func TestBrowse(t *testing.T) {
+	group := serveMDNS(t)
+	hosts, err := Browse(context.Background(), Options{Window: 500 * time.Millisecond, Group: group})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hosts) != 2 {
+		t.Fatalf("устройств %d, want 2: %+v", len(hosts), hosts)
+	}
+
+	cast, printer := hosts[0], hosts[1]
+	if printer.Hostname != "Office-Printer.local" || !slices.Equal(printer.Addresses, []string{"192.168.1.20"}) {
+		t.Errorf("принтер: %+v", printer)
+	}
+	if len(printer.Services) != 1 || printer.Services[0].Instance != "Office Printer" || printer.Services[0].Type != "_ipp._tcp" || printer.Services[0].Port != 631 {
+		t.Errorf("службы принтера: %+v", printer.Services)
+	}
+	if got := printer.Model(); got != "HP LaserJet 400" {
+		t.Errorf("модель принтера %q", got)
+	}
+
+	if cast.Hostname != "chromecast-1.local" || !slices.Equal(cast.Addresses, []string{"192.168.1.30"}) {
+		t.Errorf("Chromecast: %+v", cast)
+	}
+	if got := cast.Summary(); got != "Chromecast, _googlecast._tcp" {
+		t.Errorf("Summary = %q", got)
+	}
+	if v, ok := cast.Services[0].TXT["bs"]; !ok || v != "" {
+		t.Errorf("логический ключ TXT: %q, %v", v, ok)
+	}
+}
+
+func TestBrowseCancelled(t *testing.T) {
+	group := serveMDNS(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	if _, err := Browse(ctx, Options{Window: 5 * time.Second, Group: group}); err == nil {
+		t.Fatal("ожидалась ошибка отмены")
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("отмена не прервала окно: %v", time.Since(start))
+	}
+}
+
+func TestHostFirmware(t *testing.T) {
+	h := Host{Services: []Service{
+		{Type: "_airplay._tcp", TXT: map[string]string{"model": "AppleTV6,2", "srcvers": "670.6.2"}},
+		{Type: "_hap._tcp", TXT: map[string]string{"md": "Apple TV"}},
+	}}
+	if h.Model() != "AppleTV6,2" || h.Firmware() != "670.6.2" {
+		t.Errorf("модель %q, прошивка %q", h.Model(), h.Firmware())
+	}
+	if got := h.ServiceTypes(); !slices.Equal(got, []string{"_airplay._tcp", "_hap._tcp"}) {
+		t.Errorf("ServiceTypes = %v", got)
+	}
+}
+
+func TestProber(t *testing.T) {
+	calls := 0
+	p := NewProber(time.Second)
+	p.Browse = func(ctx context.Context, opts Options) ([]Host, error) {
+		calls++
+		return []Host{{Hostname: "nas.local", Addresses: []string{"10.0.0.5", "fe80::1"}}}, nil
+	}
+	for ip, want := range map[string]bool{"10.0.0.5": true, "fe80::1%eth0": true, "10.0.0.6": false} {
+		alive, err := p.Ping(ip)
+		if err != nil || alive != want {
+			t.Errorf("Ping(%s) = %v, %v; want %v", ip, alive, err, want)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("просмотров %d, want 1", calls)
+	}
+	if h, ok := p.Lookup("10.0.0.5"); !ok || h.Hostname != "nas.local" {
+		t.Errorf("Lookup: %+v, %v", h, ok)
+	}
+	if _, err := p.ResolveMAC("10.0.0.5"); err != ErrNoMAC {
+		t.Errorf("ResolveMAC: %v", err)
+	}
+}
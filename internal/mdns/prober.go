@@ -0,0 +1,137 @@
+package mdns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoMAC — mDNS не сообщает MAC-адреса устройств.
+var ErrNoMAC = errors.New("mdns: MAC is not announced")
+
+// Prober — обнаружение хостов по mDNS/DNS-SD для сканера: просмотр выполняется один раз,
+// Ping и Lookup отвечают по его результатам.
+type Prober struct {
+	// Window — окно сбора ответов; 0 — DefaultWindow.
+	Window time.Duration
+	// Interface — интерфейс рассылки запросов; пусто — выбор ОС.
+	Interface string
+	// Browse выполняет просмотр; nil — Browse пакета.
+	Browse func(ctx context.Context, opts Options) ([]Host, error)
+
+	mu         sync.Mutex
+	discovered bool
+	err        error
+	found      []Host
+	byIP       map[string]int
+}
+
+// NewProber создаёт Prober с окном сбора ответов window.
+func NewProber(window time.Duration) *Prober {
+	return &Prober{Window: window}
+}
+
+// Discover возвращает устройства, объявившие службы DNS-SD. Первый вызов выполняет просмотр,
+// последующие возвращают тот же результат (и ту же ошибку, если просмотр не удался не из-за
+// отмены ctx).
+func (p *Prober) Discover(ctx context.Context) ([]Host, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovered {
+		return p.found, p.err
+	}
+	browse := p.Browse
+	if browse == nil {
+		browse = Browse
+	}
+	hosts, err := browse(ctx, Options{Window: p.Window, Interface: p.Interface})
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	p.discovered = true
+	if err != nil {
+		p.err = err
+		return nil, err
+	}
+	p.found = hosts
+	p.byIP = make(map[string]int)
+	for i, h := range hosts {
+		for _, addr := range h.Addresses {
+			if _, ok := p.byIP[addr]; !ok {
+				p.byIP[addr] = i
+			}
+		}
+	}
+	return p.found, nil
+}
+
+// Ping сообщает, что ip объявил службы DNS-SD.
+func (p *Prober) Ping(ip string) (bool, error) {
+	return p.PingContext(ip, nil)
+}
+
+// PingContext — Ping с отменой через done.
+func (p *Prober) PingContext(ip string, done <-chan struct{}) (bool, error) {
+	key, err := ipKey(ip)
+	if err != nil {
+		return false, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if done != nil {
+		stop := make(chan struct{})
+		defer close(stop)
+		go func() {
+			select {
+			case <-done:
+				cancel()
+			case <-stop:
+			}
+		}()
+	}
+	if _, err := p.Discover(ctx); err != nil {
+		return false, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.byIP[key]
+	return ok, nil
+}
+
+// ResolveMAC всегда возвращает ErrNoMAC: MAC определяют другие способы обнаружения.
+func (p *Prober) ResolveMAC(ip string) (net.HardwareAddr, error) {
+	return nil, ErrNoMAC
+}
+
+// Lookup возвращает устройство с адресом ip из уже выполненного просмотра.
+func (p *Prober) Lookup(ip string) (*Host, bool) {
+	key, err := ipKey(ip)
+	if err != nil {
+		return nil, false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	i, ok := p.byIP[key]
+	if !ok {
+		return nil, false
+	}
+	h := p.found[i]
+	return &h, true
+}
+
+// ipKey приводит адрес (возможно, с зоной IPv6) к ключу карты адресов.
+func ipKey(ip string) (string, error) {
+	host, _, _ := strings.Cut(ip, "%")
+	parsed := net.ParseIP(host)
+	if parsed == nil {
+		return "", fmt.Errorf("invalid IP: %s", ip)
+	}
+	return parsed.String(), nil
+}
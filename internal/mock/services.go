@@ -209,6 +209,12 @@ func (m *MockInventoryService) SaveSnapshot(ctx context.Context, id string, data
 	return m.Error
 }
 
+func (m *MockInventoryService) SaveSnapshotWithMetadata(ctx context.Context, id string, data []contracts.ScanResult, meta contracts.ScanMetadata) error {
+	atomic.AddInt64(&m.saveCnt, 1)
+	m.Called = true
+	return m.Error
+}
+
 func (m *MockInventoryService) ListSnapshots(ctx context.Context, limit int) ([]contracts.Snapshot, error) {
 	atomic.AddInt64(&m.listCnt, 1)
 	m.Called = true
@@ -0,0 +1,41 @@
+package netsim
+
+import (
+	"embed"
+	"path"
+	"sort"
+	"strings"
+
+	apperrors "network-scanner/internal/errors"
+)
+
+// fixtures — эталонные сети: home (домашняя сеть), office (сеть небольшого офиса),
+// campus (кампус с ядром и коммутаторами доступа, связанными LLDP).
+//
+//go:embed fixtures/*.yaml
+var fixtures embed.FS
+
+// Fixtures возвращает имена эталонных сетей в алфавитном порядке.
+func Fixtures() []string {
+	entries, _ := fixtures.ReadDir("fixtures")
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), path.Ext(e.Name())))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadFixture загружает эталонную сеть по имени (см. Fixtures). Каждый вызов возвращает
+// новую сеть с исходным состоянием потерь.
+func LoadFixture(name string) (*Network, error) {
+	data, err := fixtures.ReadFile("fixtures/" + name + ".yaml")
+	if err != nil {
+		return nil, apperrors.NewNotFoundError("netsim fixture", name)
+	}
+	n, err := Parse(data)
+	if err != nil {
+		return nil, apperrors.WrapErrorf(err, "netsim: fixture %s", name)
+	}
+	return n, nil
+}
@@ -0,0 +1,283 @@
+// Package netsim — симулированная сеть для детерминированных сквозных тестов.
+//
+// Сеть описывается в YAML или JSON (JSON — подмножество YAML): хосты с IP, MAC, именем,
+// открытыми TCP/UDP-портами и баннерами, SNMP-данными (интерфейсы, FDB, соседи LLDP),
+// задержкой и потерями. *Network реализует scanner.NetworkProber (с PingContext и
+// LookupAddr), scanner.PortScanner для TCP и UDP (TCPProber, UDPProber), banner.DialFunc
+// (DialTimeout) и фабрику snmpcollector.SNMPClient, поэтому цепочка
+// scan → snmp → topology → security → inventory выполняется в процессе без сети.
+//
+// Потери детерминированы: решение для каждой пробы вычисляется из Seed, ключа пробы
+// (хост, протокол, порт) и номера попытки по этому ключу, поэтому не зависит от порядка
+// проб между хостами и портами.
+package netsim
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	apperrors "network-scanner/internal/errors"
+)
+
+// Состояния портов в описании сети.
+const (
+	StateOpen     = "open"     // соединение принимается (по умолчанию для перечисленных портов)
+	StateClosed   = "closed"   // RST / ICMP port unreachable
+	StateFiltered = "filtered" // ответа нет до таймаута
+)
+
+// DefaultTimeout — таймаут проб, если вызывающий его не задал.
+const DefaultTimeout = time.Second
+
+// Network — симулированная сеть. Создаётся Load, Parse или LoadFixture; методы
+// безопасны для одновременного вызова.
+type Network struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	// Seed определяет последовательность потерь; одинаковый Seed — одинаковые потери.
+	Seed  int64  `yaml:"seed"`
+	Hosts []Host `yaml:"hosts"`
+
+	byIP     map[string]*Host
+	mu       sync.Mutex
+	attempts map[string]uint64 // ключ пробы -> число попыток (для потерь)
+}
+
+// Host — узел симулированной сети.
+type Host struct {
+	IP       string   `yaml:"ip"`
+	MAC      string   `yaml:"mac"`
+	Hostname string   `yaml:"hostname"`
+	Latency  Duration `yaml:"latency"` // время ответа на пробы
+	Loss     float64  `yaml:"loss"`    // доля потерянных проб, 0..1
+	// Firewall — неперечисленные порты фильтруются (TCP filtered, UDP open|filtered)
+	// вместо закрытых.
+	Firewall bool   `yaml:"firewall"`
+	TCP      []Port `yaml:"tcp"`
+	UDP      []Port `yaml:"udp"`
+	SNMP     *SNMP  `yaml:"snmp"`
+
+	ip  net.IP
+	mac net.HardwareAddr
+}
+
+// Port — порт хоста. Баннер TCP-порта отправляется сразу после соединения; баннер,
+// начинающийся с "HTTP/", — в ответ на первый запрос клиента. Service и Version
+// UDP-порта возвращаются как распознанная служба.
+type Port struct {
+	Port    int    `yaml:"port"`
+	State   string `yaml:"state"` // StateOpen (по умолчанию), StateClosed или StateFiltered
+	Banner  string `yaml:"banner"`
+	Service string `yaml:"service"`
+	Version string `yaml:"version"`
+}
+
+// SNMP — данные SNMP-агента хоста (порт 161/udp открыт автоматически).
+type SNMP struct {
+	Community  string         `yaml:"community"` // пусто — "public"
+	SysName    string         `yaml:"sys_name"`
+	SysDescr   string         `yaml:"sys_descr"`
+	Interfaces []Interface    `yaml:"interfaces"`
+	FDB        map[string]int `yaml:"fdb"` // MAC -> ifIndex (BRIDGE-MIB)
+	LLDP       []Neighbor     `yaml:"lldp"`
+}
+
+// Interface — строка ifTable.
+type Interface struct {
+	Index       int    `yaml:"index"`
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+}
+
+// Neighbor — сосед LLDP на локальном интерфейсе LocalIf.
+type Neighbor struct {
+	LocalIf   int    `yaml:"local_if"`
+	SysName   string `yaml:"sys_name"`
+	PortID    string `yaml:"port_id"`
+	ChassisID string `yaml:"chassis_id"` // обычно MAC соседа
+}
+
+// Duration — time.Duration, записываемая строкой ("5ms", "1.5s").
+type Duration time.Duration
+
+// UnmarshalYAML разбирает длительность из строки time.ParseDuration.
+func (d *Duration) UnmarshalYAML(value *yaml.Node) error {
+	var s string
+	if err := value.Decode(&s); err != nil {
+		return err
+	}
+	if strings.TrimSpace(s) == "" {
+		*d = 0
+		return nil
+	}
+	v, err := time.ParseDuration(strings.TrimSpace(s))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Load читает описание сети из файла (YAML или JSON).
+func Load(path string) (*Network, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	n, err := Parse(data)
+	if err != nil {
+		return nil, apperrors.WrapErrorf(err, "netsim: %s", path)
+	}
+	return n, nil
+}
+
+// Parse разбирает и проверяет описание сети (YAML или JSON). Неизвестные поля — ошибка.
+func Parse(data []byte) (*Network, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	n := &Network{}
+	if err := dec.Decode(n); err != nil {
+		return nil, apperrors.NewInvalidInputError("netsim", err.Error())
+	}
+	if err := n.init(); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// init проверяет описание и строит индексы.
+func (n *Network) init() error {
+	n.byIP = make(map[string]*Host, len(n.Hosts))
+	n.attempts = make(map[string]uint64)
+	for i := range n.Hosts {
+		h := &n.Hosts[i]
+		field := fmt.Sprintf("hosts[%d]", i)
+		h.ip = net.ParseIP(strings.TrimSpace(h.IP))
+		if h.ip == nil {
+			return apperrors.NewInvalidInputError(field+".ip", fmt.Sprintf("некорректный адрес %q", h.IP))
+		}
+		if _, dup := n.byIP[h.ip.String()]; dup {
+			return apperrors.NewInvalidInputError(field+".ip", "адрес "+h.ip.String()+" повторяется")
+		}
+		if strings.TrimSpace(h.MAC) != "" {
+			mac, err := net.ParseMAC(strings.TrimSpace(h.MAC))
+			if err != nil {
+				return apperrors.NewInvalidInputError(field+".mac", err.Error())
+			}
+			h.mac = mac
+		}
+		if h.Loss < 0 || h.Loss > 1 {
+			return apperrors.NewInvalidInputError(field+".loss", "доля потерь должна быть от 0 до 1")
+		}
+		if h.Latency < 0 {
+			return apperrors.NewInvalidInputError(field+".latency", "задержка не может быть отрицательной")
+		}
+		for k, ports := range [][]Port{h.TCP, h.UDP} {
+			proto := [...]string{"tcp", "udp"}[k]
+			for j, p := range ports {
+				pf := fmt.Sprintf("%s.%s[%d]", field, proto, j)
+				if p.Port < 1 || p.Port > 65535 {
+					return apperrors.NewInvalidInputError(pf+".port", fmt.Sprintf("порт %d вне диапазона 1-65535", p.Port))
+				}
+				switch p.State {
+				case "", StateOpen, StateClosed, StateFiltered:
+				default:
+					return apperrors.NewInvalidInputError(pf+".state", fmt.Sprintf("неизвестное состояние %q", p.State))
+				}
+			}
+		}
+		if h.SNMP != nil {
+			for mac := range h.SNMP.FDB {
+				if _, err := net.ParseMAC(mac); err != nil {
+					return apperrors.NewInvalidInputError(field+".snmp.fdb", err.Error())
+				}
+			}
+		}
+		n.byIP[h.ip.String()] = h
+	}
+	return nil
+}
+
+// Host возвращает хост по адресу; nil — адреса в сети нет.
+func (n *Network) Host(ip string) *Host {
+	parsed := net.ParseIP(strings.TrimSpace(ip))
+	if parsed == nil {
+		return nil
+	}
+	return n.byIP[parsed.String()]
+}
+
+// Addresses возвращает адреса всех хостов в порядке описания.
+func (n *Network) Addresses() []string {
+	out := make([]string, 0, len(n.Hosts))
+	for _, h := range n.Hosts {
+		out = append(out, h.ip.String())
+	}
+	return out
+}
+
+// dropped сообщает, потеряна ли очередная проба по ключу key хоста h.
+func (n *Network) dropped(h *Host, key string) bool {
+	if h.Loss <= 0 {
+		return false
+	}
+	if h.Loss >= 1 {
+		return true
+	}
+	n.mu.Lock()
+	attempt := n.attempts[key]
+	n.attempts[key] = attempt + 1
+	n.mu.Unlock()
+
+	hash := fnv.New64a()
+	var buf [16]byte
+	binary.BigEndian.PutUint64(buf[:8], uint64(n.Seed))
+	binary.BigEndian.PutUint64(buf[8:], attempt)
+	hash.Write(buf[:])
+	hash.Write([]byte(key))
+	// Финальное перемешивание splitmix64: у FNV старшие биты слабо зависят от конца ключа
+	x := hash.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return float64(x>>11)/(1<<53) < h.Loss
+}
+
+// port ищет порт proto/port хоста и возвращает его состояние.
+func (h *Host) port(proto string, port int) (Port, string) {
+	ports := h.TCP
+	if proto == "udp" {
+		ports = h.UDP
+	}
+	for _, p := range ports {
+		if p.Port == port {
+			if p.State == "" {
+				return p, StateOpen
+			}
+			return p, p.State
+		}
+	}
+	if proto == "udp" && port == snmpPort && h.SNMP != nil {
+		return Port{Port: snmpPort, Service: "snmp"}, StateOpen
+	}
+	if h.Firewall {
+		return Port{Port: port}, StateFiltered
+	}
+	return Port{Port: port}, StateClosed
+}
+
+// latency — задержка ответа хоста.
+func (h *Host) latency() time.Duration {
+	return time.Duration(h.Latency)
+}
@@ -0,0 +1,219 @@
+package netsim
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"network-scanner/internal/banner"
+	apperrors "network-scanner/internal/errors"
+	"network-scanner/internal/network"
+)
+
+const testNet = `
+name: test
+seed: 3
+hosts:
+  - ip: 10.0.0.1
+    mac: "00:11:22:33:44:55"
+    hostname: gw.test
+    tcp:
+      - port: 22
+        banner: "SSH-2.0-OpenSSH_9.6\r\n"
+      - port: 80
+        banner: "HTTP/1.1 200 OK\r\nServer: nginx/1.24.0\r\n\r\n"
+      - port: 8080
+        state: filtered
+    udp:
+      - port: 53
+        service: dns
+    snmp:
+      community: secret
+      sys_name: gw
+      sys_descr: Linux router
+      interfaces: [{index: 1, name: eth0}]
+      fdb: {"AA:BB:CC:00:00:01": 1}
+      lldp: [{local_if: 1, sys_name: sw, port_id: ge-0/0/1, chassis_id: "AA:BB:CC:00:00:02"}]
+  - ip: 10.0.0.2
+    firewall: true
+    latency: 50ms
+  - ip: 10.0.0.3
+    loss: 0.5
+`
+
+func mustParse(t *testing.T, data string) *Network {
+	t.Helper()
+	n, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	return n
+}
+
+func TestFixtures(t *testing.T) {
+	names := Fixtures()
+	if strings.Join(names, ",") != "campus,home,office" {
+		t.Fatalf("Fixtures() = %v", names)
+	}
+	for _, name := range names {
+		n, err := LoadFixture(name)
+		if err != nil {
+			t.Fatalf("LoadFixture(%q) error = %v", name, err)
+		}
+		if n.Name != name || len(n.Hosts) == 0 {
+			t.Errorf("LoadFixture(%q) = %q, %d хостов", name, n.Name, len(n.Hosts))
+		}
+	}
+	if _, err := LoadFixture("datacenter"); !apperrors.IsNotFound(err) {
+		t.Errorf("LoadFixture(unknown) error = %v, want NotFound", err)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := map[string]string{
+		"адрес":     "hosts: [{ip: 10.0.0.300}]",
+		"повтор":    "hosts: [{ip: 10.0.0.1}, {ip: 10.0.0.1}]",
+		"MAC":       "hosts: [{ip: 10.0.0.1, mac: zz}]",
+		"потери":    "hosts: [{ip: 10.0.0.1, loss: 1.5}]",
+		"порт":      "hosts: [{ip: 10.0.0.1, tcp: [{port: 70000}]}]",
+		"состояние": "hosts: [{ip: 10.0.0.1, tcp: [{port: 22, state: half-open}]}]",
+		"задержка":  "hosts: [{ip: 10.0.0.1, latency: fast}]",
+		"поле":      "hosts: [{ip: 10.0.0.1, os: linux}]",
+	}
+	for name, data := range tests {
+		if _, err := Parse([]byte(data)); !apperrors.IsInvalidInput(err) {
+			t.Errorf("%s: Parse() error = %v, want InvalidInputError", name, err)
+		}
+	}
+	// JSON — подмножество YAML
+	n, err := Parse([]byte(`{"name": "json", "hosts": [{"ip": "10.0.0.1", "latency": "2ms", "tcp": [{"port": 443}]}]}`))
+	if err != nil || n.Host("10.0.0.1") == nil || n.Host("10.0.0.1").latency() != 2*time.Millisecond {
+		t.Errorf("Parse(JSON) = %+v, %v", n, err)
+	}
+}
+
+func TestProbes(t *testing.T) {
+	n := mustParse(t, testNet)
+
+	if ok, _ := n.Ping("10.0.0.1"); !ok {
+		t.Error("Ping(10.0.0.1) = false")
+	}
+	if ok, _ := n.Ping("10.0.0.9"); ok {
+		t.Error("Ping(неизвестный адрес) = true")
+	}
+	if mac, err := n.ResolveMAC("10.0.0.1"); err != nil || mac.String() != "00:11:22:33:44:55" {
+		t.Errorf("ResolveMAC() = %v, %v", mac, err)
+	}
+	if names, err := n.LookupAddr("10.0.0.1"); err != nil || len(names) != 1 || names[0] != "gw.test" {
+		t.Errorf("LookupAddr() = %v, %v", names, err)
+	}
+
+	tcp := []struct {
+		ip     string
+		port   int
+		state  network.PortState
+		reason string
+	}{
+		{"10.0.0.1", 22, network.PortOpen, network.ReasonSynAck},
+		{"10.0.0.1", 23, network.PortClosed, network.ReasonConnRefused},
+		{"10.0.0.1", 8080, network.PortFiltered, network.ReasonNoResponse},
+		{"10.0.0.2", 22, network.PortFiltered, network.ReasonNoResponse},
+		{"10.0.0.9", 22, network.PortFiltered, network.ReasonHostUnreachable},
+	}
+	for _, tt := range tcp {
+		res, err := n.ProbeTCP(tt.ip, tt.port, 20*time.Millisecond)
+		if err != nil || res.State != tt.state || res.Reason != tt.reason {
+			t.Errorf("ProbeTCP(%s, %d) = %+v, %v; want %v/%s", tt.ip, tt.port, res, err, tt.state, tt.reason)
+		}
+	}
+
+	udp := []struct {
+		ip      string
+		port    int
+		state   network.PortState
+		service string
+	}{
+		{"10.0.0.1", 53, network.PortOpen, "dns"},
+		{"10.0.0.1", 161, network.PortOpen, "snmp"},
+		{"10.0.0.1", 123, network.PortClosed, ""},
+		{"10.0.0.2", 123, network.PortOpenFiltered, ""},
+	}
+	for _, tt := range udp {
+		res, err := n.ProbeUDP(tt.ip, tt.port)
+		if err != nil || res.State != tt.state || res.Service != tt.service {
+			t.Errorf("ProbeUDP(%s, %d) = %+v, %v", tt.ip, tt.port, res, err)
+		}
+	}
+
+	if open, err := n.ScanPorts("10.0.0.1", []int{21, 22, 80}, "tcp"); err != nil || len(open) != 2 {
+		t.Errorf("ScanPorts() = %v, %v", open, err)
+	}
+}
+
+func TestDialTimeoutBanners(t *testing.T) {
+	n := mustParse(t, testNet)
+	if b, err := banner.GrabTCPWith(n.DialTimeout, "10.0.0.1", 22, time.Second); err != nil || !strings.Contains(b, "OpenSSH_9.6") {
+		t.Errorf("баннер SSH = %q, %v", b, err)
+	}
+	if b, err := banner.GrabTCPWith(n.DialTimeout, "10.0.0.1", 80, time.Second); err != nil || !strings.Contains(b, "nginx/1.24.0") {
+		t.Errorf("баннер HTTP = %q, %v", b, err)
+	}
+	if _, err := n.DialTimeout("tcp", "10.0.0.1:23", time.Second); err == nil || !strings.Contains(err.Error(), "refused") {
+		t.Errorf("DialTimeout(закрытый порт) error = %v", err)
+	}
+	if _, err := n.DialTimeout("tcp", "10.0.0.1:8080", 10*time.Millisecond); err == nil {
+		t.Error("DialTimeout(фильтрованный порт) должен вернуть ошибку")
+	}
+}
+
+func TestLossIsDeterministic(t *testing.T) {
+	run := func() []bool {
+		n := mustParse(t, testNet)
+		out := make([]bool, 0, 100)
+		for i := 0; i < 100; i++ {
+			res, _ := n.ProbeUDP("10.0.0.3", 2000+i)
+			out = append(out, res.State == network.PortOpenFiltered)
+		}
+		return out
+	}
+	a, b := run(), run()
+	lost := 0
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("проба %d: потери различаются между запусками", i)
+		}
+		if a[i] {
+			lost++
+		}
+	}
+	if lost < 30 || lost > 70 {
+		t.Errorf("потеряно %d из 100 проб при loss 0.5", lost)
+	}
+}
+
+func TestSNMPClient(t *testing.T) {
+	n := mustParse(t, testNet)
+	c := n.NewSNMPClient()
+	if err := c.Connect("10.0.0.1", "public"); err == nil {
+		t.Error("Connect() с неверным community должен вернуть ошибку")
+	}
+	if err := c.Connect("10.0.0.2", "secret"); err == nil {
+		t.Error("Connect() к хосту без SNMP должен вернуть ошибку")
+	}
+	if err := c.Connect("10.0.0.1", "secret"); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer c.Close()
+	if name, _ := c.GetSysName(); name != "gw" {
+		t.Errorf("GetSysName() = %q", name)
+	}
+	if ifs, _ := c.GetIfTable(); len(ifs) != 1 || ifs[1].Name != "eth0" {
+		t.Errorf("GetIfTable() = %v", ifs)
+	}
+	if fdb, _ := c.GetMacTable(); fdb["aa:bb:cc:00:00:01"] != 1 {
+		t.Errorf("GetMacTable() = %v", fdb)
+	}
+	if lldp, _ := c.GetLldpNeighbors(); len(lldp) != 1 || lldp[0].RemoteMac != "aa:bb:cc:00:00:02" || lldp[0].LocalIfIndex != 1 {
+		t.Errorf("GetLldpNeighbors() = %+v", lldp)
+	}
+}
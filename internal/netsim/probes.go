@@ -0,0 +1,214 @@
+package netsim
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"network-scanner/internal/network"
+)
+
+const snmpPort = 161
+
+// Ping отвечает, доступен ли хост (с задержкой хоста); неизвестный адрес или потеря — false.
+func (n *Network) Ping(ip string) (bool, error) {
+	return n.PingContext(ip, nil)
+}
+
+// PingContext — Ping с отменой через done (scanner.ContextNetworkProber).
+func (n *Network) PingContext(ip string, done <-chan struct{}) (bool, error) {
+	h := n.Host(ip)
+	if h == nil {
+		return false, nil
+	}
+	if n.dropped(h, "ping/"+h.ip.String()) {
+		return false, nil
+	}
+	if !sleep(h.latency(), done) {
+		return false, nil
+	}
+	return true, nil
+}
+
+// ResolveMAC возвращает MAC хоста из описания сети.
+func (n *Network) ResolveMAC(ip string) (net.HardwareAddr, error) {
+	h := n.Host(ip)
+	if h == nil || h.mac == nil {
+		return nil, fmt.Errorf("netsim: MAC для %s не найден", ip)
+	}
+	return h.mac, nil
+}
+
+// LookupAddr возвращает имя хоста (scanner.HostnameResolver) вместо обратного DNS.
+func (n *Network) LookupAddr(ip string) ([]string, error) {
+	h := n.Host(ip)
+	if h == nil || h.Hostname == "" {
+		return nil, &net.DNSError{Err: "no such host", Name: ip, IsNotFound: true}
+	}
+	return []string{h.Hostname}, nil
+}
+
+// ProbeTCP классифицирует TCP-порт (scanner.TCPProber): открытый — syn-ack после задержки
+// хоста, закрытый — conn-refused, фильтрованный или потерянный — no-response после таймаута;
+// задержка больше таймаута — тоже no-response. Нулевой timeout — DefaultTimeout.
+func (n *Network) ProbeTCP(ip string, port int, timeout time.Duration) (network.TCPProbeResult, error) {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	h := n.Host(ip)
+	if h == nil {
+		return network.TCPProbeResult{State: network.PortFiltered, Reason: network.ReasonHostUnreachable}, nil
+	}
+	_, state := h.port("tcp", port)
+	if state == StateFiltered || h.latency() > timeout || n.dropped(h, probeKey(h, "tcp", port)) {
+		time.Sleep(timeout)
+		return network.TCPProbeResult{State: network.PortFiltered, Reason: network.ReasonNoResponse}, nil
+	}
+	time.Sleep(h.latency())
+	if state == StateClosed {
+		return network.TCPProbeResult{State: network.PortClosed, Reason: network.ReasonConnRefused, Latency: h.latency()}, nil
+	}
+	return network.TCPProbeResult{State: network.PortOpen, Reason: network.ReasonSynAck, Latency: h.latency()}, nil
+}
+
+// ProbeUDP классифицирует UDP-порт (scanner.UDPProber): перечисленный — open с его службой,
+// остальные — closed (port-unreach), у хоста с Firewall или при потере — open|filtered.
+func (n *Network) ProbeUDP(ip string, port int) (network.UDPProbeResult, error) {
+	h := n.Host(ip)
+	if h == nil {
+		return network.UDPProbeResult{State: network.PortOpenFiltered}, nil
+	}
+	p, state := h.port("udp", port)
+	if n.dropped(h, probeKey(h, "udp", port)) {
+		state = StateFiltered
+	}
+	time.Sleep(h.latency())
+	switch state {
+	case StateOpen:
+		return network.UDPProbeResult{State: network.PortOpen, Service: p.Service, Version: p.Version}, nil
+	case StateClosed:
+		return network.UDPProbeResult{State: network.PortClosed}, nil
+	default:
+		return network.UDPProbeResult{State: network.PortOpenFiltered}, nil
+	}
+}
+
+// ScanPort сообщает, открыт ли порт proto ("tcp" или "udp") хоста.
+func (n *Network) ScanPort(ip string, port int, proto string) (bool, error) {
+	return n.ScanPortTimeout(ip, port, proto, DefaultTimeout)
+}
+
+// ScanPortTimeout — ScanPort с таймаутом TCP-пробы (scanner.TimeoutPortScanner).
+func (n *Network) ScanPortTimeout(ip string, port int, proto string, timeout time.Duration) (bool, error) {
+	switch strings.ToLower(proto) {
+	case "tcp":
+		res, err := n.ProbeTCP(ip, port, timeout)
+		return res.State == network.PortOpen, err
+	case "udp":
+		res, err := n.ProbeUDP(ip, port)
+		return res.State == network.PortOpen, err
+	default:
+		return false, fmt.Errorf("netsim: неизвестный протокол %q", proto)
+	}
+}
+
+// ScanPorts возвращает открытые порты из ports.
+func (n *Network) ScanPorts(ip string, ports []int, proto string) ([]int, error) {
+	open := make([]int, 0)
+	for _, p := range ports {
+		ok, err := n.ScanPort(ip, p, proto)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			open = append(open, p)
+		}
+	}
+	return open, nil
+}
+
+// DialTimeout открывает TCP-соединение со службой симулированного хоста
+// (banner.DialFunc, scanner.ConnDialer). Служба отправляет баннер порта и закрывает
+// соединение; ошибки соответствуют net.DialTimeout (отказ, таймаут).
+func (n *Network) DialTimeout(proto, address string, timeout time.Duration) (net.Conn, error) {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, fmt.Errorf("netsim: некорректный порт в %q", address)
+	}
+	if !strings.HasPrefix(proto, "tcp") {
+		return nil, fmt.Errorf("netsim: соединения %s не поддерживаются", proto)
+	}
+	opErr := func(e error) error {
+		return &net.OpError{Op: "dial", Net: proto, Addr: simAddr(address), Err: e}
+	}
+	h := n.Host(host)
+	if h == nil {
+		time.Sleep(timeout)
+		return nil, opErr(os.ErrDeadlineExceeded)
+	}
+	p, state := h.port("tcp", port)
+	if state == StateFiltered || h.latency() > timeout {
+		time.Sleep(timeout)
+		return nil, opErr(os.ErrDeadlineExceeded)
+	}
+	time.Sleep(h.latency())
+	if state == StateClosed {
+		return nil, opErr(syscall.ECONNREFUSED)
+	}
+	client, server := net.Pipe()
+	go serve(server, p.Banner, timeout)
+	return client, nil
+}
+
+// serve отвечает клиенту баннером службы: HTTP — после запроса, остальные — сразу.
+func serve(conn net.Conn, banner string, timeout time.Duration) {
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+	if banner == "" {
+		return
+	}
+	if strings.HasPrefix(banner, "HTTP/") {
+		buf := make([]byte, 4096)
+		if _, err := conn.Read(buf); err != nil {
+			return
+		}
+	}
+	_, _ = conn.Write([]byte(banner))
+}
+
+// probeKey — ключ пробы для детерминированных потерь.
+func probeKey(h *Host, proto string, port int) string {
+	return proto + "/" + h.ip.String() + "/" + strconv.Itoa(port)
+}
+
+// sleep ждёт d; false — ожидание прервано done.
+func sleep(d time.Duration, done <-chan struct{}) bool {
+	if d <= 0 {
+		return true
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-done:
+		return false
+	}
+}
+
+// simAddr — адрес симулированного соединения (net.Addr).
+type simAddr string
+
+func (a simAddr) Network() string { return "tcp" }
+func (a simAddr) String() string  { return string(a) }
@@ -0,0 +1,100 @@
+package netsim
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"network-scanner/internal/snmpcollector"
+)
+
+// NewSNMPClient создаёт SNMP-клиента симулированной сети (snmpcollector.ClientFactory).
+func (n *Network) NewSNMPClient() snmpcollector.SNMPClient {
+	return &snmpClient{net: n}
+}
+
+// snmpClient — snmpcollector.SNMPClient поверх данных SNMP хостов сети.
+type snmpClient struct {
+	net   *Network
+	agent *SNMP
+}
+
+// Connect подключается к агенту хоста ip. Хост без SNMP, неверное community или
+// потерянный запрос — ошибка таймаута, как у SNMPv2c без ответа.
+func (c *snmpClient) Connect(ip, community string) error {
+	h := c.net.Host(ip)
+	if h == nil || h.SNMP == nil {
+		return fmt.Errorf("netsim: %s: request timeout (no SNMP agent)", ip)
+	}
+	want := h.SNMP.Community
+	if want == "" {
+		want = "public"
+	}
+	if community != want {
+		return fmt.Errorf("netsim: %s: request timeout (community %q)", ip, community)
+	}
+	if c.net.dropped(h, probeKey(h, "snmp", snmpPort)) {
+		return fmt.Errorf("netsim: %s: request timeout (loss)", ip)
+	}
+	c.agent = h.SNMP
+	return nil
+}
+
+func (c *snmpClient) Close() error {
+	c.agent = nil
+	return nil
+}
+
+func (c *snmpClient) GetSysName() (string, error) {
+	if c.agent == nil {
+		return "", errNotConnected
+	}
+	return c.agent.SysName, nil
+}
+
+func (c *snmpClient) GetSysDescr() (string, error) {
+	if c.agent == nil {
+		return "", errNotConnected
+	}
+	return c.agent.SysDescr, nil
+}
+
+func (c *snmpClient) GetIfTable() (map[int]*snmpcollector.IfEntry, error) {
+	if c.agent == nil {
+		return nil, errNotConnected
+	}
+	out := make(map[int]*snmpcollector.IfEntry, len(c.agent.Interfaces))
+	for _, ifc := range c.agent.Interfaces {
+		out[ifc.Index] = &snmpcollector.IfEntry{Index: ifc.Index, Name: ifc.Name, Description: ifc.Description}
+	}
+	return out, nil
+}
+
+func (c *snmpClient) GetMacTable() (map[string]int, error) {
+	if c.agent == nil {
+		return nil, errNotConnected
+	}
+	out := make(map[string]int, len(c.agent.FDB))
+	for mac, ifIndex := range c.agent.FDB {
+		out[strings.ToLower(mac)] = ifIndex
+	}
+	return out, nil
+}
+
+func (c *snmpClient) GetLldpNeighbors() ([]*snmpcollector.LldpNeighbor, error) {
+	if c.agent == nil {
+		return nil, errNotConnected
+	}
+	out := make([]*snmpcollector.LldpNeighbor, 0, len(c.agent.LLDP))
+	for _, nb := range c.agent.LLDP {
+		out = append(out, &snmpcollector.LldpNeighbor{
+			LocalIfIndex: nb.LocalIf,
+			RemotePortID: nb.PortID,
+			RemoteSys:    nb.SysName,
+			RemoteMac:    strings.ToLower(nb.ChassisID),
+		})
+	}
+	return out, nil
+}
+
+var errNotConnected = errors.New("netsim: SNMP-клиент не подключён")
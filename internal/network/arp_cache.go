@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"os"
 	"os/exec"
 	"regexp"
 	"runtime"
@@ -108,16 +109,26 @@ func (c *ARPCache) Refresh() error {
 }
 
 // GetBatch возвращает MAC-адреса для списка IP.
-// Оптимизация: один вызов refreshFunc для всех IP.
+// Оптимизация: один вызов refreshFunc для всех IP; ожидание обновления — до 2 секунд.
 func (c *ARPCache) GetBatch(ips []string) map[string]string {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	return c.GetBatchContext(ctx, ips)
+}
+
+// GetBatchContext возвращает MAC-адреса для списка IP. Если часть IP нет в свежем кэше,
+// таблица перечитывается один раз, а ожидание ограничено ctx: при отмене возвращается
+// то, что уже есть в кэше.
+func (c *ARPCache) GetBatchContext(ctx context.Context, ips []string) map[string]string {
 	results := make(map[string]string)
 
 	// Собираем IP, которых нет в кэше
 	missing := make([]string, 0)
 	c.mu.RLock()
+	fresh := time.Since(c.freshAt) < c.ttl
 	for _, ip := range ips {
 		mac, cached := c.entries[ip]
-		if cached && time.Since(c.freshAt) < c.ttl {
+		if cached && fresh {
 			results[ip] = mac
 		} else {
 			missing = append(missing, ip)
@@ -126,24 +137,19 @@ func (c *ARPCache) GetBatch(ips []string) map[string]string {
 	c.mu.RUnlock()
 
 	// Если все IP есть в кэше — возвращаем
-	if len(missing) == 0 {
+	if len(missing) == 0 || c.refreshFunc == nil {
 		return results
 	}
 
-	// Обновляем кэш
-	c.RefreshAsync()
-
-	// Ждём завершения обновления (до 2 секунд)
+	// Обновляем кэш и ждём завершения, пока не отменён ctx
 	done := make(chan struct{})
 	go func() {
-		time.Sleep(2 * time.Second)
+		_ = c.Refresh()
 		close(done)
 	}()
-
 	select {
 	case <-done:
-	case <-time.After(2 * time.Second):
-		// Таймаут, возвращаем что есть
+	case <-ctx.Done():
 	}
 
 	// Собираем результаты после обновления
@@ -291,6 +297,43 @@ func parseLinuxARPFromArpString(output string) map[string]string {
 	return entries
 }
 
+// parseProcNetARP парсит /proc/net/arp (Linux):
+// IP address  HW type  Flags  HW address  Mask  Device
+func parseProcNetARP(output string) map[string]string {
+	entries := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 6 || net.ParseIP(fields[0]) == nil {
+			continue
+		}
+		if mac := fields[3]; mac != "00:00:00:00:00:00" {
+			entries[fields[0]] = mac
+		}
+	}
+	return entries
+}
+
+// parseDarwinARP парсит ARP-таблицу macOS/BSD (cmd: "arp -an"):
+// ? (192.168.1.1) at 0:1b:2c:3d:4e:5f on en0 ifscope [ethernet]
+// Ведущие нули октетов macOS не печатает, они восстанавливаются.
+func parseDarwinARP(output string) map[string]string {
+	entries := make(map[string]string)
+	re := regexp.MustCompile(`\((\d+\.\d+\.\d+\.\d+)\) at ([\da-fA-F:]+)`)
+	for _, match := range re.FindAllStringSubmatch(output, -1) {
+		octets := strings.Split(match[2], ":")
+		if len(octets) != 6 {
+			continue
+		}
+		for i, o := range octets {
+			if len(o) == 1 {
+				octets[i] = "0" + o
+			}
+		}
+		entries[match[1]] = strings.Join(octets, ":")
+	}
+	return entries
+}
+
 // GetARPTabaleWindows запускает "arp -a" и парсит результат.
 func GetARPTabaleWindows() (map[string]string, error) {
 	cmd := exec.Command("cmd", "/c", "arp", "-a")
@@ -307,7 +350,10 @@ func GetARPTabaleLinux() (map[string]string, error) {
 	cmd := exec.Command("ip", "neigh")
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		// Fallback на "arp -n"
+		// Fallback на /proc/net/arp, затем на "arp -n"
+		if data, readErr := os.ReadFile("/proc/net/arp"); readErr == nil {
+			return parseProcNetARP(string(data)), nil
+		}
 		cmd = exec.Command("arp", "-n")
 		output, err = cmd.CombinedOutput()
 		if err != nil {
@@ -320,10 +366,22 @@ func GetARPTabaleLinux() (map[string]string, error) {
 	return parseLinuxARP(string(output)), nil
 }
 
+// GetARPTabaleDarwin запускает "arp -an" и парсит результат.
+func GetARPTabaleDarwin() (map[string]string, error) {
+	output, err := exec.Command("arp", "-an").CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run arp -an: %w", err)
+	}
+	return parseDarwinARP(string(output)), nil
+}
+
 // GetARPTabale — кроссплатформенная функция для получения ARP-таблицы.
 func GetARPTabale() (map[string]string, error) {
-	if runtime.GOOS == "windows" {
+	switch runtime.GOOS {
+	case "windows":
 		return GetARPTabaleWindows()
+	case "darwin", "freebsd", "openbsd", "netbsd":
+		return GetARPTabaleDarwin()
 	}
 	return GetARPTabaleLinux()
 }
@@ -336,10 +394,12 @@ func NewDefaultARPCache(ttl time.Duration) *ARPCache {
 }
 
 // ResolveMACBatch — вспомогательная функция для разрешения MAC-адресов для списка IP.
+// Ожидание обновления таблицы ограничено ctx; IP без записи или с некорректным MAC
+// в результат не попадают.
 func ResolveMACBatch(ctx context.Context, ips []string, cache *ARPCache) map[string]net.HardwareAddr {
 	results := make(map[string]net.HardwareAddr)
 
-	macMap := cache.GetBatch(ips)
+	macMap := cache.GetBatchContext(ctx, ips)
 	for ip, mac := range macMap {
 		hw, err := net.ParseMAC(mac)
 		if err == nil {
@@ -276,6 +276,42 @@ func TestGetBatchPartialCached(t *testing.T) {
 	}
 }
 
+func TestGetBatchContextWaitsForRefresh(t *testing.T) {
+	refreshFunc := func() (map[string]string, error) {
+		time.Sleep(20 * time.Millisecond)
+		return map[string]string{"192.168.1.99": "aa:bb:cc:dd:ee:ff"}, nil
+	}
+
+	cache := NewARPCache(5*time.Minute, refreshFunc)
+
+	results := cache.GetBatchContext(context.Background(), []string{"192.168.1.99"})
+	if results["192.168.1.99"] != "aa:bb:cc:dd:ee:ff" {
+		t.Errorf("GetBatchContext() = %v, want entry from refreshed table", results)
+	}
+}
+
+func TestGetBatchContextCancelled(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	refreshFunc := func() (map[string]string, error) {
+		<-release
+		return nil, nil
+	}
+
+	cache := NewARPCache(5*time.Minute, refreshFunc)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	results := cache.GetBatchContext(ctx, []string{"192.168.1.1"})
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("GetBatchContext() waited %v after ctx deadline", elapsed)
+	}
+	if len(results) != 0 {
+		t.Errorf("GetBatchContext() returned %d results, want 0", len(results))
+	}
+}
+
 func TestGetBatchEmpty(t *testing.T) {
 	cache := NewARPCache(5*time.Minute, nil)
 
@@ -562,6 +598,37 @@ func TestParseLinuxARPEmpty(t *testing.T) {
 	}
 }
 
+// --- Test parseProcNetARP / parseDarwinARP ---
+
+func TestParseProcNetARP(t *testing.T) {
+	output := `IP address       HW type     Flags       HW address            Mask     Device
+192.168.1.1      0x1         0x2         0a:1b:2c:3d:4e:5f     *        eth0
+192.168.1.7      0x1         0x0         00:00:00:00:00:00     *        eth0
+`
+
+	entries := parseProcNetARP(output)
+
+	if len(entries) != 1 || entries["192.168.1.1"] != "0a:1b:2c:3d:4e:5f" {
+		t.Errorf("parseProcNetARP() = %v, want only 192.168.1.1", entries)
+	}
+}
+
+func TestParseDarwinARP(t *testing.T) {
+	output := `? (192.168.1.1) at 0:1b:2c:3d:4e:5f on en0 ifscope [ethernet]
+? (192.168.1.20) at (incomplete) on en0 ifscope [ethernet]
+router.lan (192.168.1.254) at a4:b1:c2:d3:e4:f5 on en0 ifscope permanent [ethernet]
+`
+
+	entries := parseDarwinARP(output)
+
+	if len(entries) != 2 {
+		t.Errorf("parseDarwinARP() returned %d entries, want 2", len(entries))
+	}
+	if entries["192.168.1.1"] != "00:1b:2c:3d:4e:5f" {
+		t.Errorf("parseDarwinARP() mac for 192.168.1.1 = %v, want 00:1b:2c:3d:4e:5f", entries["192.168.1.1"])
+	}
+}
+
 // --- Test ARPCache with short TTL ---
 
 func TestARPCacheRapidRefresh(t *testing.T) {
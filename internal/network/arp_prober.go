@@ -0,0 +1,381 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcap"
+)
+
+const (
+	arpSnapLen     = 128
+	arpReadTimeout = 100 * time.Millisecond
+)
+
+// ErrNotAttached is returned by ARPProber for addresses outside directly attached IPv4 subnets.
+var ErrNotAttached = errors.New("address is not on a directly attached IPv4 subnet")
+
+// ARPInterface describes an interface with an IPv4 subnet that ARPProber can sweep.
+type ARPInterface struct {
+	Name         string
+	HardwareAddr net.HardwareAddr
+	IP           net.IP
+	Network      *net.IPNet
+}
+
+// ARPProber discovers hosts on directly attached IPv4 subnets with ARP requests.
+//
+// Sweep sends requests for a batch of addresses over one pcap handle per interface
+// and collects replies in a shared receive loop. Ping and ResolveMAC answer from
+// the sweep results; addresses that were not swept yet are swept one by one.
+// Raw capture needs root/CAP_NET_RAW (Npcap on Windows).
+type ARPProber struct {
+	Timeout time.Duration
+	// OpenHandle opens a capture handle on the named interface; nil uses pcap.OpenLive.
+	OpenHandle func(iface string) (PacketHandle, error)
+	// Interfaces lists sweepable interfaces; nil uses the system interfaces.
+	Interfaces func() ([]ARPInterface, error)
+	// Interface restricts sweeps to one interface; empty means all.
+	Interface string
+	// Throttle, if set, is called before each ARP request and may delay it (rate limiting).
+	Throttle func(ctx context.Context) error
+
+	mu    sync.Mutex
+	macs  map[string]net.HardwareAddr
+	swept map[string]bool
+}
+
+// NewARPProber creates an ARPProber that uses live pcap handles.
+func NewARPProber(timeout time.Duration) *ARPProber {
+	return &ARPProber{Timeout: timeout}
+}
+
+// Sweep sends an ARP request to every address on an attached subnet and returns
+// the addresses that replied with their MACs. Addresses outside attached subnets
+// are ignored. An error is returned only if no interface could be swept.
+func (p *ARPProber) Sweep(ctx context.Context, ips []net.IP) (map[string]net.HardwareAddr, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ifaces, err := p.interfaces()
+	if err != nil {
+		return nil, err
+	}
+	groups := make(map[int][]net.IP)
+	for _, ip := range ips {
+		if i := attachedInterface(ifaces, ip); i >= 0 {
+			groups[i] = append(groups[i], ip.To4())
+		}
+	}
+	found := make(map[string]net.HardwareAddr)
+	if len(groups) == 0 {
+		return found, nil
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		swept    []net.IP
+	)
+	for i, group := range groups {
+		wg.Add(1)
+		go func(iface ARPInterface, group []net.IP) {
+			defer wg.Done()
+			macs, err := p.sweepInterface(ctx, iface, group)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			swept = append(swept, group...)
+			for ip, mac := range macs {
+				found[ip] = mac
+			}
+		}(ifaces[i], group)
+	}
+	wg.Wait()
+	if len(swept) == 0 {
+		return nil, firstErr
+	}
+
+	// Адреса прерванного sweep не запоминаем: отсутствие ответа ещё ничего не значит.
+	p.mu.Lock()
+	if p.macs == nil {
+		p.macs = make(map[string]net.HardwareAddr)
+		p.swept = make(map[string]bool)
+	}
+	if ctx.Err() == nil {
+		for _, ip := range swept {
+			p.swept[ip.String()] = true
+		}
+	}
+	for ip, mac := range found {
+		p.macs[ip] = mac
+	}
+	p.mu.Unlock()
+	return found, nil
+}
+
+// Ping reports whether ip answered an ARP request. Addresses outside attached
+// subnets return ErrNotAttached so a discovery strategy can try other methods.
+func (p *ARPProber) Ping(ip string) (bool, error) {
+	return p.PingContext(ip, nil)
+}
+
+// PingContext is Ping with cancellation via done.
+func (p *ARPProber) PingContext(ip string, done <-chan struct{}) (bool, error) {
+	parsed, _ := SplitZone(ip)
+	if parsed == nil {
+		return false, fmt.Errorf("invalid IP: %s", ip)
+	}
+	key := parsed.String()
+	p.mu.Lock()
+	_, alive := p.macs[key]
+	swept := p.swept[key]
+	p.mu.Unlock()
+	if swept {
+		return alive, nil
+	}
+
+	ctx, cancel := contextFromDone(done)
+	defer cancel()
+	ifaces, err := p.interfaces()
+	if err != nil {
+		return false, err
+	}
+	if attachedInterface(ifaces, parsed) < 0 {
+		return false, ErrNotAttached
+	}
+	found, err := p.Sweep(ctx, []net.IP{parsed})
+	if err != nil {
+		return false, err
+	}
+	_, alive = found[key]
+	return alive, nil
+}
+
+// ResolveMAC returns the MAC learned by a sweep, sweeping ip if needed.
+func (p *ARPProber) ResolveMAC(ip string) (net.HardwareAddr, error) {
+	alive, err := p.Ping(ip)
+	if err != nil {
+		return nil, err
+	}
+	if !alive {
+		return nil, fmt.Errorf("no ARP reply from %s", ip)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.macs[net.ParseIP(ip).String()], nil
+}
+
+func (p *ARPProber) sweepInterface(ctx context.Context, iface ARPInterface, ips []net.IP) (map[string]net.HardwareAddr, error) {
+	open := p.OpenHandle
+	if open == nil {
+		open = func(name string) (PacketHandle, error) {
+			return pcap.OpenLive(name, arpSnapLen, false, arpReadTimeout)
+		}
+	}
+	handle, err := open(iface.Name)
+	if err != nil {
+		return nil, fmt.Errorf("arp sweep: open %s: %w", iface.Name, err)
+	}
+	if h, ok := handle.(interface{ SetBPFFilter(string) error }); ok {
+		_ = h.SetBPFFilter("arp")
+	}
+
+	pending := make(map[string]bool, len(ips))
+	for _, ip := range ips {
+		pending[ip.String()] = true
+	}
+	var (
+		mu    sync.Mutex
+		found = make(map[string]net.HardwareAddr)
+		stop  = make(chan struct{})
+		all   = make(chan struct{})
+		done  = make(chan struct{})
+	)
+	go func() {
+		defer close(done)
+		for {
+			data, _, err := handle.ReadPacketData()
+			if err != nil {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				if err == pcap.NextErrorTimeoutExpired {
+					continue
+				}
+				// io.EOF (pcap-файл) и прочие ошибки чтения завершают приём ответов
+				return
+			}
+			packet := gopacket.NewPacket(data, layers.LayerTypeEthernet, gopacket.DecodeOptions{Lazy: true, NoCopy: true})
+			arp, _ := packet.Layer(layers.LayerTypeARP).(*layers.ARP)
+			if arp == nil || arp.Operation != layers.ARPReply {
+				continue
+			}
+			src := net.IP(arp.SourceProtAddress).String()
+			mu.Lock()
+			if pending[src] {
+				delete(pending, src)
+				found[src] = append(net.HardwareAddr(nil), arp.SourceHwAddress...)
+				if len(pending) == 0 {
+					close(all)
+				}
+			}
+			mu.Unlock()
+		}
+	}()
+
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = time.Second
+	}
+	sendErr := error(nil)
+	for _, ip := range ips {
+		if ctx.Err() != nil {
+			break
+		}
+		if p.Throttle != nil && p.Throttle(ctx) != nil {
+			break
+		}
+		frame, err := arpRequestFrame(iface, ip)
+		if err == nil {
+			err = handle.WritePacketData(frame)
+		}
+		if err != nil {
+			sendErr = fmt.Errorf("arp sweep: send on %s: %w", iface.Name, err)
+			break
+		}
+	}
+	if sendErr == nil {
+		select {
+		case <-all:
+		case <-time.After(timeout):
+		case <-ctx.Done():
+		}
+	}
+	close(stop)
+	handle.Close()
+	<-done
+
+	if sendErr != nil {
+		return nil, sendErr
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	return found, nil
+}
+
+func arpRequestFrame(iface ARPInterface, target net.IP) ([]byte, error) {
+	eth := layers.Ethernet{
+		SrcMAC:       iface.HardwareAddr,
+		DstMAC:       net.HardwareAddr{0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		EthernetType: layers.EthernetTypeARP,
+	}
+	arp := layers.ARP{
+		AddrType:          layers.LinkTypeEthernet,
+		Protocol:          layers.EthernetTypeIPv4,
+		HwAddressSize:     6,
+		ProtAddressSize:   4,
+		Operation:         layers.ARPRequest,
+		SourceHwAddress:   []byte(iface.HardwareAddr),
+		SourceProtAddress: []byte(iface.IP.To4()),
+		DstHwAddress:      []byte{0, 0, 0, 0, 0, 0},
+		DstProtAddress:    []byte(target.To4()),
+	}
+	buf := gopacket.NewSerializeBuffer()
+	if err := gopacket.SerializeLayers(buf, gopacket.SerializeOptions{}, &eth, &arp); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (p *ARPProber) interfaces() ([]ARPInterface, error) {
+	list := SystemARPInterfaces
+	if p.Interfaces != nil {
+		list = p.Interfaces
+	}
+	ifaces, err := list()
+	if err != nil || p.Interface == "" {
+		return ifaces, err
+	}
+	out := make([]ARPInterface, 0, 1)
+	for _, iface := range ifaces {
+		if iface.Name == p.Interface {
+			out = append(out, iface)
+		}
+	}
+	return out, nil
+}
+
+// SystemARPInterfaces returns up, non-loopback Ethernet interfaces with their IPv4 subnets.
+func SystemARPInterfaces() ([]ARPInterface, error) {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return nil, fmt.Errorf("list interfaces: %w", err)
+	}
+	out := make([]ARPInterface, 0)
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 || len(iface.HardwareAddr) != 6 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, a := range addrs {
+			ipnet, ok := a.(*net.IPNet)
+			if !ok || ipnet.IP.To4() == nil {
+				continue
+			}
+			out = append(out, ARPInterface{
+				Name:         iface.Name,
+				HardwareAddr: iface.HardwareAddr,
+				IP:           ipnet.IP.To4(),
+				Network:      &net.IPNet{IP: ipnet.IP.Mask(ipnet.Mask).To4(), Mask: ipnet.Mask},
+			})
+		}
+	}
+	return out, nil
+}
+
+// attachedInterface возвращает индекс интерфейса, в подсеть которого входит ip, или -1.
+func attachedInterface(ifaces []ARPInterface, ip net.IP) int {
+	ip4 := ip.To4()
+	if ip4 == nil {
+		return -1
+	}
+	for i, iface := range ifaces {
+		if iface.Network != nil && iface.Network.Contains(ip4) && !iface.IP.Equal(ip4) {
+			return i
+		}
+	}
+	return -1
+}
+
+// contextFromDone превращает канал отмены в context.
+func contextFromDone(done <-chan struct{}) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	if done != nil {
+		go func() {
+			select {
+			case <-done:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+	}
+	return ctx, cancel
+}
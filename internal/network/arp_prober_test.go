@@ -0,0 +1,149 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// fakeARPHandle отвечает ARP-reply на запросы к адресам из hosts.
+type fakeARPHandle struct {
+	hosts   map[string]net.HardwareAddr
+	packets chan []byte
+
+	mu     sync.Mutex
+	sent   int
+	closed bool
+}
+
+func newFakeARPHandle(hosts map[string]net.HardwareAddr) *fakeARPHandle {
+	return &fakeARPHandle{hosts: hosts, packets: make(chan []byte, 256)}
+}
+
+func (h *fakeARPHandle) LinkType() layers.LinkType { return layers.LinkTypeEthernet }
+
+func (h *fakeARPHandle) ReadPacketData() ([]byte, gopacket.CaptureInfo, error) {
+	data, ok := <-h.packets
+	if !ok {
+		return nil, gopacket.CaptureInfo{}, io.EOF
+	}
+	return data, gopacket.CaptureInfo{CaptureLength: len(data), Length: len(data)}, nil
+}
+
+func (h *fakeARPHandle) WritePacketData(data []byte) error {
+	h.mu.Lock()
+	h.sent++
+	h.mu.Unlock()
+
+	packet := gopacket.NewPacket(data, layers.LinkTypeEthernet, gopacket.Default)
+	req, _ := packet.Layer(layers.LayerTypeARP).(*layers.ARP)
+	if req == nil || req.Operation != layers.ARPRequest {
+		return errors.New("fake handle: not an ARP request")
+	}
+	mac, ok := h.hosts[net.IP(req.DstProtAddress).String()]
+	if !ok {
+		return nil
+	}
+	eth := &layers.Ethernet{SrcMAC: mac, DstMAC: net.HardwareAddr(req.SourceHwAddress), EthernetType: layers.EthernetTypeARP}
+	reply := &layers.ARP{
+		AddrType:          layers.LinkTypeEthernet,
+		Protocol:          layers.EthernetTypeIPv4,
+		HwAddressSize:     6,
+		ProtAddressSize:   4,
+		Operation:         layers.ARPReply,
+		SourceHwAddress:   []byte(mac),
+		SourceProtAddress: req.DstProtAddress,
+		DstHwAddress:      req.SourceHwAddress,
+		DstProtAddress:    req.SourceProtAddress,
+	}
+	buf := gopacket.NewSerializeBuffer()
+	if err := gopacket.SerializeLayers(buf, gopacket.SerializeOptions{}, eth, reply); err != nil {
+		return err
+	}
+	h.packets <- buf.Bytes()
+	return nil
+}
+
+func (h *fakeARPHandle) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.closed {
+		h.closed = true
+		close(h.packets)
+	}
+}
+
+func newTestARPProber(hosts map[string]net.HardwareAddr) (*ARPProber, *int) {
+	opened := 0
+	_, subnet, _ := net.ParseCIDR("192.0.2.0/24")
+	p := NewARPProber(100 * time.Millisecond)
+	p.Interfaces = func() ([]ARPInterface, error) {
+		return []ARPInterface{{
+			Name:         "eth0",
+			HardwareAddr: net.HardwareAddr{0x02, 0, 0, 0, 0, 0x10},
+			IP:           net.ParseIP("192.0.2.10").To4(),
+			Network:      subnet,
+		}}, nil
+	}
+	p.OpenHandle = func(string) (PacketHandle, error) {
+		opened++
+		return newFakeARPHandle(hosts), nil
+	}
+	return p, &opened
+}
+
+func TestARPProber_SweepBatchesRequests(t *testing.T) {
+	mac := net.HardwareAddr{0x02, 0, 0, 0, 0, 0x20}
+	p, opened := newTestARPProber(map[string]net.HardwareAddr{"192.0.2.20": mac})
+
+	ips := []net.IP{net.ParseIP("192.0.2.20"), net.ParseIP("192.0.2.21"), net.ParseIP("198.51.100.1")}
+	found, err := p.Sweep(context.Background(), ips)
+	if err != nil {
+		t.Fatalf("Sweep() error = %v", err)
+	}
+	if len(found) != 1 || found["192.0.2.20"].String() != mac.String() {
+		t.Errorf("Sweep() = %v, want only 192.0.2.20", found)
+	}
+	if *opened != 1 {
+		t.Errorf("opened %d handles, want 1", *opened)
+	}
+
+	// Ping и ResolveMAC отвечают из результатов sweep без новых хэндлов.
+	if alive, err := p.Ping("192.0.2.20"); err != nil || !alive {
+		t.Errorf("Ping(192.0.2.20) = %v, %v", alive, err)
+	}
+	if alive, err := p.Ping("192.0.2.21"); err != nil || alive {
+		t.Errorf("Ping(192.0.2.21) = %v, %v", alive, err)
+	}
+	if got, err := p.ResolveMAC("192.0.2.20"); err != nil || got.String() != mac.String() {
+		t.Errorf("ResolveMAC() = %v, %v", got, err)
+	}
+	if *opened != 1 {
+		t.Errorf("opened %d handles after cached lookups, want 1", *opened)
+	}
+}
+
+func TestARPProber_NotAttached(t *testing.T) {
+	p, opened := newTestARPProber(nil)
+	if _, err := p.Ping("198.51.100.1"); !errors.Is(err, ErrNotAttached) {
+		t.Errorf("Ping() error = %v, want ErrNotAttached", err)
+	}
+	if *opened != 0 {
+		t.Errorf("opened %d handles, want 0", *opened)
+	}
+}
+
+func TestARPProber_OpenError(t *testing.T) {
+	p, _ := newTestARPProber(nil)
+	p.OpenHandle = func(string) (PacketHandle, error) { return nil, errors.New("permission denied") }
+	if _, err := p.Sweep(context.Background(), []net.IP{net.ParseIP("192.0.2.20")}); err == nil {
+		t.Error("Sweep() ожидалась ошибка открытия хэндла")
+	}
+}
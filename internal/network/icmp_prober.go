@@ -0,0 +1,151 @@
+package network
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"os"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+const (
+	icmpProtocolIPv4 = 1
+	icmpProtocolIPv6 = 58
+)
+
+// ICMPProber checks liveness with ICMP echo requests.
+//
+// It prefers unprivileged datagram ping sockets (Linux with
+// net.ipv4.ping_group_range covering the process group, macOS) and falls back
+// to raw ICMP sockets when the process is privileged. If neither can be opened,
+// Ping returns an error so a discovery strategy can try other methods.
+type ICMPProber struct {
+	Timeout time.Duration
+	// Source selects the local address echo requests are sent from; zero means OS choice.
+	Source Source
+}
+
+// Ping sends one echo request and waits for the matching reply.
+func (p ICMPProber) Ping(ip string) (bool, error) {
+	return p.PingContext(ip, nil)
+}
+
+// PingContext is Ping with cancellation via done.
+func (p ICMPProber) PingContext(ip string, done <-chan struct{}) (bool, error) {
+	dst, zone := SplitZone(ip)
+	if dst == nil {
+		return false, fmt.Errorf("invalid IP: %s", ip)
+	}
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = time.Second
+	}
+
+	var local net.IP
+	if !dst.IsLinkLocalUnicast() {
+		local = p.Source.LocalIP(dst)
+	}
+	conn, privileged, err := listenICMP(dst.To4() == nil, local)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+	if done != nil {
+		stop := make(chan struct{})
+		defer close(stop)
+		go func() {
+			select {
+			case <-done:
+				_ = conn.SetDeadline(time.Now())
+			case <-stop:
+			}
+		}()
+	}
+
+	proto := icmpProtocolIPv4
+	var echoType, replyType icmp.Type = ipv4.ICMPTypeEcho, ipv4.ICMPTypeEchoReply
+	if dst.To4() == nil {
+		proto = icmpProtocolIPv6
+		echoType, replyType = ipv6.ICMPTypeEchoRequest, ipv6.ICMPTypeEchoReply
+	}
+	// Для датаграммных сокетов ядро подставляет свой идентификатор, поэтому ответ
+	// сопоставляем по адресу и номеру последовательности.
+	seq := rand.IntN(1 << 16)
+	msg := icmp.Message{
+		Type: echoType,
+		Body: &icmp.Echo{ID: os.Getpid() & 0xffff, Seq: seq, Data: []byte("network-scanner")},
+	}
+	payload, err := msg.Marshal(nil)
+	if err != nil {
+		return false, fmt.Errorf("icmp: marshal echo: %w", err)
+	}
+	var addr net.Addr = &net.UDPAddr{IP: dst, Zone: zone}
+	if privileged {
+		addr = &net.IPAddr{IP: dst, Zone: zone}
+	}
+	if _, err := conn.WriteTo(payload, addr); err != nil {
+		return false, fmt.Errorf("icmp: send echo to %s: %w", ip, err)
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(timeout))
+	buf := make([]byte, 1500)
+	for {
+		n, peer, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				return false, nil
+			}
+			return false, fmt.Errorf("icmp: read reply: %w", err)
+		}
+		if !peerIP(peer).Equal(dst) {
+			continue
+		}
+		reply, err := icmp.ParseMessage(proto, buf[:n])
+		if err != nil || reply.Type != replyType {
+			continue
+		}
+		if echo, ok := reply.Body.(*icmp.Echo); ok && echo.Seq == seq {
+			return true, nil
+		}
+	}
+}
+
+// ResolveMAC is not supported by ICMP discovery.
+func (p ICMPProber) ResolveMAC(ip string) (net.HardwareAddr, error) {
+	return nil, fmt.Errorf("MAC resolution is not supported by ICMP prober")
+}
+
+// listenICMP открывает ICMP-сокет: сначала непривилегированный (udp4/udp6), затем raw.
+// Сокет привязывается к local, если адрес задан.
+func listenICMP(v6 bool, local net.IP) (*icmp.PacketConn, bool, error) {
+	dgram, raw, wildcard := "udp4", "ip4:icmp", "0.0.0.0"
+	if v6 {
+		dgram, raw, wildcard = "udp6", "ip6:ipv6-icmp", "::"
+	}
+	if local != nil {
+		wildcard = local.String()
+	}
+	conn, err := icmp.ListenPacket(dgram, wildcard)
+	if err == nil {
+		return conn, false, nil
+	}
+	conn, rawErr := icmp.ListenPacket(raw, wildcard)
+	if rawErr == nil {
+		return conn, true, nil
+	}
+	return nil, false, fmt.Errorf("icmp: ping socket unavailable (%v), raw socket unavailable (%v)", err, rawErr)
+}
+
+func peerIP(addr net.Addr) net.IP {
+	switch a := addr.(type) {
+	case *net.UDPAddr:
+		return a.IP
+	case *net.IPAddr:
+		return a.IP
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+package network
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// InterfaceInfo — сетевой интерфейс с адресами и шлюзом по умолчанию.
+type InterfaceInfo struct {
+	Name    string
+	MAC     net.HardwareAddr
+	Flags   net.Flags
+	Addrs   []*net.IPNet
+	Gateway net.IP // IPv4-шлюз маршрута по умолчанию через интерфейс; nil — нет
+	Default bool   // сеть интерфейса выбирает DetectLocalNetwork
+}
+
+// HasAddr сообщает, назначен ли ip интерфейсу.
+func (i InterfaceInfo) HasAddr(ip net.IP) bool {
+	for _, a := range i.Addrs {
+		if a.IP.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ListInterfaces возвращает интерфейсы системы с адресами и шлюзами по умолчанию и
+// отмечает тот, чью сеть выбирает DetectLocalNetwork. Шлюзы, которые не удалось
+// определить, остаются пустыми.
+func ListInterfaces() ([]InterfaceInfo, error) {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return nil, fmt.Errorf("list interfaces: %w", err)
+	}
+	gateways, _ := readDefaultGateways()
+	infos := make([]InterfaceInfo, 0, len(interfaces))
+	for _, iface := range interfaces {
+		info := InterfaceInfo{Name: iface.Name, MAC: iface.HardwareAddr, Flags: iface.Flags}
+		if addrs, err := iface.Addrs(); err == nil {
+			for _, a := range addrs {
+				if ipnet, ok := a.(*net.IPNet); ok {
+					info.Addrs = append(info.Addrs, ipnet)
+				}
+			}
+		}
+		for _, gw := range gateways {
+			// Windows указывает интерфейс маршрута его адресом, остальные ОС — именем
+			if gw.Interface == info.Name || info.HasAddr(net.ParseIP(gw.Interface)) {
+				info.Gateway = gw.Gateway
+				break
+			}
+		}
+		infos = append(infos, info)
+	}
+	if detected, err := DetectLocalNetwork(); err == nil {
+		markDefaultInterface(infos, detected)
+	}
+	return infos, nil
+}
+
+// DetectSourceNetwork определяет сеть для сканирования по источнику src: сеть его
+// адреса, иначе сеть интерфейса в порядке DetectLocalNetwork (IPv4, глобальный IPv6,
+// link-local с зоной). Нулевой src — DetectLocalNetwork.
+func DetectSourceNetwork(src Source) (string, error) {
+	if src.IsZero() {
+		return DetectLocalNetwork()
+	}
+	infos, err := ListInterfaces()
+	if err != nil {
+		return "", err
+	}
+	for _, info := range infos {
+		if info.Name != src.Interface {
+			continue
+		}
+		if network := interfaceNetwork(info, src); network != "" {
+			return network, nil
+		}
+		return "", fmt.Errorf("у интерфейса %s нет сети для сканирования", info.Name)
+	}
+	return "", fmt.Errorf("интерфейс %s не найден", src.Interface)
+}
+
+// interfaceNetwork возвращает сеть адреса источника на интерфейсе info, а без него —
+// первую IPv4-сеть, глобальный IPv6-префикс или link-local префикс с зоной.
+func interfaceNetwork(info InterfaceInfo, src Source) string {
+	prefix := func(a *net.IPNet) string {
+		ones, _ := a.Mask.Size()
+		p := fmt.Sprintf("%s/%d", a.IP.Mask(a.Mask), ones)
+		if a.IP.To4() == nil && a.IP.IsLinkLocalUnicast() {
+			p += "%" + info.Name
+		}
+		return p
+	}
+	var v4, v6, linkLocal string
+	for _, a := range info.Addrs {
+		if a.IP.IsLoopback() {
+			continue
+		}
+		if (src.IPv4 != nil && a.IP.Equal(src.IPv4)) || (src.IPv6 != nil && a.IP.Equal(src.IPv6)) {
+			return prefix(a)
+		}
+		switch {
+		case a.IP.To4() != nil:
+			if v4 == "" {
+				v4 = prefix(a)
+			}
+		case a.IP.IsLinkLocalUnicast():
+			if linkLocal == "" {
+				linkLocal = prefix(a)
+			}
+		case v6 == "":
+			v6 = prefix(a)
+		}
+	}
+	for _, network := range []string{v4, v6, linkLocal} {
+		if network != "" {
+			return network
+		}
+	}
+	return ""
+}
+
+// markDefaultInterface отмечает первый интерфейс, которому принадлежит сеть network
+// в формате DetectLocalNetwork ("192.168.1.0/24", "fe80::/64%eth0").
+func markDefaultInterface(infos []InterfaceInfo, network string) {
+	prefix, zone, _ := strings.Cut(network, "%")
+	for i := range infos {
+		if zone != "" && infos[i].Name != zone {
+			continue
+		}
+		for _, a := range infos[i].Addrs {
+			ones, _ := a.Mask.Size()
+			if fmt.Sprintf("%s/%d", a.IP.Mask(a.Mask), ones) == prefix {
+				infos[i].Default = true
+				return
+			}
+		}
+	}
+}
+
+// defaultGateway — маршрут по умолчанию: интерфейс (имя или, в Windows, адрес) и шлюз.
+type defaultGateway struct {
+	Interface string
+	Gateway   net.IP
+}
+
+// parseLinuxDefaultGateways извлекает маршруты по умолчанию из /proc/net/route.
+func parseLinuxDefaultGateways(rd io.Reader) ([]defaultGateway, error) {
+	var out []defaultGateway
+	sc := bufio.NewScanner(rd)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 3 || fields[1] != "00000000" {
+			continue
+		}
+		raw, err := hex.DecodeString(fields[2])
+		if err != nil || len(raw) != 4 {
+			continue
+		}
+		gw := make(net.IP, 4)
+		binary.BigEndian.PutUint32(gw, binary.LittleEndian.Uint32(raw))
+		out = append(out, defaultGateway{Interface: fields[0], Gateway: gw})
+	}
+	return out, sc.Err()
+}
+
+// parseNetstatGateways извлекает IPv4-маршруты по умолчанию из "netstat -rn":
+// строки "default <шлюз> <флаги> ... <интерфейс>" (macOS/BSD) и
+// "0.0.0.0 0.0.0.0 <шлюз> <адрес интерфейса> <метрика>" (Windows).
+func parseNetstatGateways(output string) []defaultGateway {
+	var out []defaultGateway
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		var gw defaultGateway
+		switch {
+		case fields[0] == "default":
+			gw.Gateway = net.ParseIP(fields[1]).To4()
+			for _, f := range fields[3:] {
+				if _, err := strconv.Atoi(f); err != nil {
+					gw.Interface = f
+					break
+				}
+			}
+		case fields[0] == "0.0.0.0" && fields[1] == "0.0.0.0":
+			gw.Gateway = net.ParseIP(fields[2]).To4()
+			gw.Interface = fields[3]
+		}
+		if gw.Gateway != nil && gw.Interface != "" {
+			out = append(out, gw)
+		}
+	}
+	return out
+}
+
+// readDefaultGateways читает маршруты по умолчанию: /proc/net/route в Linux,
+// "netstat -rn" в остальных ОС.
+func readDefaultGateways() ([]defaultGateway, error) {
+	if runtime.GOOS == "linux" {
+		f, err := os.Open("/proc/net/route")
+		if err != nil {
+			return nil, fmt.Errorf("read routing table: %w", err)
+		}
+		defer f.Close()
+		return parseLinuxDefaultGateways(f)
+	}
+	output, err := exec.Command("netstat", "-rn").Output()
+	if err != nil {
+		return nil, fmt.Errorf("netstat -rn: %w", err)
+	}
+	return parseNetstatGateways(string(output)), nil
+}
@@ -0,0 +1,80 @@
+package network
+
+import (
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestParseDefaultGateways(t *testing.T) {
+	route := "Iface\tDestination\tGateway \tFlags\tRefCnt\tUse\tMetric\tMask\n" +
+		"eth0\t00000000\t0101A8C0\t0003\t0\t0\t100\t00000000\n" +
+		"eth0\t0001A8C0\t00000000\t0001\t0\t0\t100\t00FFFFFF\n" +
+		"wlan0\t00000000\t01000A0A\t0003\t0\t0\t600\t00000000\n"
+	linux, err := parseLinuxDefaultGateways(strings.NewReader(route))
+	if err != nil {
+		t.Fatalf("parseLinuxDefaultGateways() error = %v", err)
+	}
+	netstat := parseNetstatGateways("Routing tables\n\nInternet:\n" +
+		"Destination        Gateway            Flags        Netif Expire\n" +
+		"default            192.168.1.1        UGScg          en0\n" +
+		"default            link#14            UCSIg        utun3\n" +
+		"127                127.0.0.1          UCS            lo0\n" +
+		"default            10.0.0.1           UGS   2   41   em0\n" +
+		"Network Destination        Netmask          Gateway       Interface  Metric\r\n" +
+		"          0.0.0.0          0.0.0.0      172.16.0.1    172.16.0.20     25\r\n" +
+		"          0.0.0.0          0.0.0.0         On-link    172.16.0.21     25\r\n")
+	tests := []struct {
+		name string
+		got  []defaultGateway
+		want []string
+	}{
+		{"proc", linux, []string{"eth0 192.168.1.1", "wlan0 10.10.0.1"}},
+		{"netstat", netstat, []string{"en0 192.168.1.1", "em0 10.0.0.1", "172.16.0.20 172.16.0.1"}},
+	}
+	for _, tt := range tests {
+		if len(tt.got) != len(tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, tt.got, tt.want)
+			continue
+		}
+		for i, gw := range tt.got {
+			if s := gw.Interface + " " + gw.Gateway.String(); s != tt.want[i] {
+				t.Errorf("%s: entry %d = %q, want %q", tt.name, i, s, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestInterfaceNetworks(t *testing.T) {
+	infos := []InterfaceInfo{
+		{Name: "lo", Addrs: []*net.IPNet{testIPNet(t, "127.0.0.1/8")}},
+		{Name: "eth0", Addrs: []*net.IPNet{testIPNet(t, "fe80::1/64"), testIPNet(t, "2001:db8::10/64"), testIPNet(t, "192.168.1.10/24"), testIPNet(t, "10.1.0.10/16")}},
+		{Name: "eth1", Addrs: []*net.IPNet{testIPNet(t, "fe80::2/64")}},
+	}
+	tests := []struct {
+		info InterfaceInfo
+		src  Source
+		want string
+	}{
+		{infos[1], Source{}, "192.168.1.0/24"},
+		{infos[1], Source{IPv4: net.ParseIP("10.1.0.10")}, "10.1.0.0/16"},
+		{infos[1], Source{IPv6: net.ParseIP("2001:db8::10")}, "2001:db8::/64"},
+		{infos[2], Source{}, "fe80::/64%eth1"},
+		{infos[0], Source{}, ""},
+	}
+	for _, tt := range tests {
+		if got := interfaceNetwork(tt.info, tt.src); got != tt.want {
+			t.Errorf("interfaceNetwork(%s, %v) = %q, want %q", tt.info.Name, tt.src, got, tt.want)
+		}
+	}
+
+	markDefaultInterface(infos, "fe80::/64%eth1")
+	if infos[1].Default || !infos[2].Default {
+		t.Errorf("Default = %v/%v, want eth1", infos[1].Default, infos[2].Default)
+	}
+	infos[2].Default = false
+	markDefaultInterface(infos, "192.168.1.0/24")
+	if !infos[1].Default {
+		t.Error("eth0 должен быть отмечен как интерфейс по умолчанию")
+	}
+}
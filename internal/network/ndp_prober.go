@@ -0,0 +1,284 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv6"
+)
+
+// ErrNotIPv6 is returned by NDPProber for IPv4 addresses.
+var ErrNotIPv6 = errors.New("address is not IPv6")
+
+// allNodesMulticast — группа всех узлов канала (ff02::1).
+var allNodesMulticast = net.ParseIP("ff02::1")
+
+// NDPProber discovers IPv6 hosts on attached links the way IPv6 networks allow
+// it: a /64 cannot be swept address by address, so the prober sends one ICMPv6
+// echo request to the all-nodes group ff02::1 on every interface and merges the
+// responders with the kernel neighbor cache (NDP), which also yields MACs and
+// global addresses of hosts that talked to this machine recently.
+//
+// Discovery runs once per prober; Ping and ResolveMAC answer from its results.
+type NDPProber struct {
+	Timeout time.Duration
+	// Echo sends the multicast echo and returns responders; nil uses MulticastEcho.
+	Echo func(ctx context.Context, timeout time.Duration) ([]Neighbor, error)
+	// Neighbors reads the neighbor table; nil uses ReadNeighbors.
+	Neighbors func() ([]Neighbor, error)
+	// Interface restricts discovery to one interface; empty means all.
+	Interface string
+
+	mu         sync.Mutex
+	discovered bool
+	found      []Neighbor
+	byIP       map[string]Neighbor
+}
+
+// NewNDPProber creates an NDPProber that uses system sockets and the neighbor table.
+func NewNDPProber(timeout time.Duration) *NDPProber {
+	return &NDPProber{Timeout: timeout}
+}
+
+// Discover returns IPv6 neighbors: hosts that answered the multicast echo and
+// IPv6 entries of the neighbor table, one entry per address (with MAC when known).
+// The first call does the network work; later calls return the same result.
+// An error is returned only if both the echo and the neighbor table failed.
+func (p *NDPProber) Discover(ctx context.Context) ([]Neighbor, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovered {
+		return p.found, nil
+	}
+
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = time.Second
+	}
+	echo := p.Echo
+	if echo == nil {
+		echo = MulticastEcho
+		if p.Interface != "" {
+			iface := p.Interface
+			echo = func(ctx context.Context, timeout time.Duration) ([]Neighbor, error) {
+				return multicastEcho(ctx, timeout, []string{iface})
+			}
+		}
+	}
+	read := p.Neighbors
+	if read == nil {
+		read = ReadNeighbors
+	}
+	responders, echoErr := echo(ctx, timeout)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	// Ответы на echo заполняют кэш соседей, поэтому таблица читается после них
+	table, tableErr := read()
+	if echoErr != nil && tableErr != nil {
+		return nil, fmt.Errorf("ndp discovery: echo: %v; neighbor table: %w", echoErr, tableErr)
+	}
+
+	p.byIP = make(map[string]Neighbor)
+	p.found = nil
+	add := func(n Neighbor) {
+		if n.IP == nil || n.IP.To4() != nil || (p.Interface != "" && n.Interface != p.Interface) {
+			return
+		}
+		key := n.IP.String()
+		if prev, ok := p.byIP[key]; ok {
+			if prev.MAC == nil && n.MAC != nil {
+				prev.MAC = n.MAC
+				p.byIP[key] = prev
+				for i := range p.found {
+					if p.found[i].IP.Equal(n.IP) {
+						p.found[i].MAC = n.MAC
+					}
+				}
+			}
+			return
+		}
+		p.byIP[key] = n
+		p.found = append(p.found, n)
+	}
+	for _, n := range table {
+		add(n)
+	}
+	for _, n := range responders {
+		add(n)
+	}
+	p.discovered = true
+	return p.found, nil
+}
+
+// Ping reports whether ip was found by neighbor discovery. IPv4 addresses
+// return ErrNotIPv6 so a discovery strategy can try other methods.
+func (p *NDPProber) Ping(ip string) (bool, error) {
+	return p.PingContext(ip, nil)
+}
+
+// PingContext is Ping with cancellation via done.
+func (p *NDPProber) PingContext(ip string, done <-chan struct{}) (bool, error) {
+	parsed, _ := SplitZone(ip)
+	if parsed == nil {
+		return false, fmt.Errorf("invalid IP: %s", ip)
+	}
+	if parsed.To4() != nil {
+		return false, ErrNotIPv6
+	}
+	ctx, cancel := contextFromDone(done)
+	defer cancel()
+	if _, err := p.Discover(ctx); err != nil {
+		return false, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.byIP[parsed.String()]
+	return ok, nil
+}
+
+// ResolveMAC returns the MAC learned by neighbor discovery.
+func (p *NDPProber) ResolveMAC(ip string) (net.HardwareAddr, error) {
+	alive, err := p.Ping(ip)
+	if err != nil {
+		return nil, err
+	}
+	parsed, _ := SplitZone(ip)
+	p.mu.Lock()
+	n := p.byIP[parsed.String()]
+	p.mu.Unlock()
+	if !alive || n.MAC == nil {
+		return nil, fmt.Errorf("no neighbor entry with MAC for %s", ip)
+	}
+	return n.MAC, nil
+}
+
+// MulticastEcho sends an ICMPv6 echo request to ff02::1 on every up multicast
+// interface with IPv6 and collects responders until timeout. Link-local
+// responders carry the interface as zone.
+func MulticastEcho(ctx context.Context, timeout time.Duration) ([]Neighbor, error) {
+	ifaces, err := ipv6Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	return multicastEcho(ctx, timeout, ifaces)
+}
+
+// multicastEcho рассылает echo на ff02::1 через интерфейсы ifaces.
+func multicastEcho(ctx context.Context, timeout time.Duration, ifaces []string) ([]Neighbor, error) {
+	if len(ifaces) == 0 {
+		return nil, fmt.Errorf("ndp discovery: no IPv6 multicast interfaces")
+	}
+	conn, privileged, err := listenICMP(true, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.SetDeadline(time.Now())
+		case <-stop:
+		}
+	}()
+
+	seq := rand.IntN(1 << 16)
+	msg := icmp.Message{
+		Type: ipv6.ICMPTypeEchoRequest,
+		Body: &icmp.Echo{ID: os.Getpid() & 0xffff, Seq: seq, Data: []byte("network-scanner")},
+	}
+	payload, err := msg.Marshal(nil)
+	if err != nil {
+		return nil, fmt.Errorf("icmp: marshal echo: %w", err)
+	}
+	sent := 0
+	var sendErr error
+	for _, iface := range ifaces {
+		var addr net.Addr = &net.UDPAddr{IP: allNodesMulticast, Zone: iface}
+		if privileged {
+			addr = &net.IPAddr{IP: allNodesMulticast, Zone: iface}
+		}
+		if _, err := conn.WriteTo(payload, addr); err != nil {
+			sendErr = err
+			continue
+		}
+		sent++
+	}
+	if sent == 0 {
+		return nil, fmt.Errorf("icmp: send echo to %s: %w", allNodesMulticast, sendErr)
+	}
+
+	var out []Neighbor
+	seen := make(map[string]bool)
+	_ = conn.SetReadDeadline(time.Now().Add(timeout))
+	buf := make([]byte, 1500)
+	for {
+		n, peer, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Timeout() || ctx.Err() != nil {
+				return out, nil
+			}
+			return out, fmt.Errorf("icmp: read reply: %w", err)
+		}
+		reply, err := icmp.ParseMessage(icmpProtocolIPv6, buf[:n])
+		if err != nil || reply.Type != ipv6.ICMPTypeEchoReply {
+			continue
+		}
+		if echo, ok := reply.Body.(*icmp.Echo); !ok || echo.Seq != seq {
+			continue
+		}
+		ip, zone := peerIP(peer), peerZone(peer)
+		if ip == nil || seen[ip.String()+"%"+zone] {
+			continue
+		}
+		seen[ip.String()+"%"+zone] = true
+		out = append(out, Neighbor{IP: ip, Interface: zone})
+	}
+}
+
+// ipv6Interfaces возвращает имена поднятых интерфейсов с multicast и IPv6-адресом.
+func ipv6Interfaces() ([]string, error) {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return nil, fmt.Errorf("list interfaces: %w", err)
+	}
+	var out []string
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 || iface.Flags&net.FlagMulticast == 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, a := range addrs {
+			if ipnet, ok := a.(*net.IPNet); ok && ipnet.IP.To4() == nil {
+				out = append(out, iface.Name)
+				break
+			}
+		}
+	}
+	return out, nil
+}
+
+func peerZone(addr net.Addr) string {
+	switch a := addr.(type) {
+	case *net.UDPAddr:
+		return a.Zone
+	case *net.IPAddr:
+		return a.Zone
+	}
+	return ""
+}
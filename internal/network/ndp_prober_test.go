@@ -0,0 +1,87 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestNDPProber_DiscoverMergesEchoAndNeighborTable(t *testing.T) {
+	mac := net.HardwareAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x55}
+	echoes, reads := 0, 0
+	p := &NDPProber{
+		Timeout: 50 * time.Millisecond,
+		Echo: func(ctx context.Context, timeout time.Duration) ([]Neighbor, error) {
+			echoes++
+			return []Neighbor{
+				{IP: net.ParseIP("fe80::1"), Interface: "eth0"},
+				{IP: net.ParseIP("fe80::9"), Interface: "eth0"},
+			}, nil
+		},
+		Neighbors: func() ([]Neighbor, error) {
+			reads++
+			return []Neighbor{
+				{IP: net.ParseIP("fe80::1"), MAC: mac, Interface: "eth0"},
+				{IP: net.ParseIP("2001:db8::5"), MAC: mac, Interface: "eth0"},
+				{IP: net.ParseIP("192.168.1.1"), MAC: mac, Interface: "eth0"},
+			}, nil
+		},
+	}
+	found, err := p.Discover(context.Background())
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+	got := make(map[string]string)
+	for _, n := range found {
+		got[n.Addr()] = n.MAC.String()
+	}
+	want := map[string]string{"fe80::1%eth0": mac.String(), "2001:db8::5": mac.String(), "fe80::9%eth0": ""}
+	if len(got) != len(want) {
+		t.Fatalf("Discover() = %v, want %v", got, want)
+	}
+	for addr, m := range want {
+		if got[addr] != m {
+			t.Errorf("Discover()[%s] = %q, want %q", addr, got[addr], m)
+		}
+	}
+
+	if alive, err := p.Ping("fe80::9%eth0"); err != nil || !alive {
+		t.Errorf("Ping(responder) = %v, %v", alive, err)
+	}
+	if alive, err := p.Ping("2001:db8::77"); err != nil || alive {
+		t.Errorf("Ping(unknown) = %v, %v", alive, err)
+	}
+	if _, err := p.Ping("192.168.1.1"); !errors.Is(err, ErrNotIPv6) {
+		t.Errorf("Ping(IPv4) error = %v, want ErrNotIPv6", err)
+	}
+	if got, err := p.ResolveMAC("2001:db8::5"); err != nil || got.String() != mac.String() {
+		t.Errorf("ResolveMAC() = %v, %v", got, err)
+	}
+	if _, err := p.ResolveMAC("fe80::9%eth0"); err == nil {
+		t.Error("ResolveMAC() без записи в таблице соседей должен вернуть ошибку")
+	}
+	if echoes != 1 || reads != 1 {
+		t.Errorf("обнаружение выполнено %d/%d раз, want 1/1", echoes, reads)
+	}
+}
+
+func TestNDPProber_DiscoverErrors(t *testing.T) {
+	fail := errors.New("no access")
+	p := &NDPProber{
+		Echo:      func(context.Context, time.Duration) ([]Neighbor, error) { return nil, fail },
+		Neighbors: func() ([]Neighbor, error) { return nil, fail },
+	}
+	if _, err := p.Discover(context.Background()); err == nil {
+		t.Error("Discover() должен вернуть ошибку, если недоступны и echo, и таблица соседей")
+	}
+
+	// Одной таблицы соседей достаточно
+	p.Neighbors = func() ([]Neighbor, error) {
+		return []Neighbor{{IP: net.ParseIP("2001:db8::5"), MAC: net.HardwareAddr{2, 0, 0, 0, 0, 1}}}, nil
+	}
+	if found, err := p.Discover(context.Background()); err != nil || len(found) != 1 {
+		t.Errorf("Discover() = %v, %v", found, err)
+	}
+}
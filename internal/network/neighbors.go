@@ -0,0 +1,164 @@
+package network
+
+import (
+	"net"
+	"net/netip"
+	"strings"
+)
+
+// Neighbor is an entry of the system neighbor table (ARP for IPv4, NDP for IPv6).
+type Neighbor struct {
+	IP        net.IP
+	MAC       net.HardwareAddr
+	Interface string // имя интерфейса; для link-local адресов оно же — зона
+}
+
+// Addr returns the neighbor address as a string usable for dialing: link-local
+// IPv6 addresses carry the interface zone (fe80::1%eth0).
+func (n Neighbor) Addr() string {
+	if n.Interface != "" && n.IP.To4() == nil && n.IP.IsLinkLocalUnicast() {
+		return n.IP.String() + "%" + n.Interface
+	}
+	return n.IP.String()
+}
+
+// SplitZone parses an address with an optional IPv6 zone ("fe80::1%eth0").
+// It returns nil for invalid addresses.
+func SplitZone(addr string) (net.IP, string) {
+	a, err := netip.ParseAddr(strings.TrimSpace(addr))
+	if err != nil {
+		return nil, ""
+	}
+	return net.IP(a.Unmap().WithZone("").AsSlice()), a.Zone()
+}
+
+// StripZone returns the address without the IPv6 zone.
+func StripZone(addr string) string {
+	if i := strings.IndexByte(addr, '%'); i >= 0 {
+		return addr[:i]
+	}
+	return addr
+}
+
+// parseIPNeighOutput разбирает вывод "ip neigh show" (обе семьи адресов):
+//
+//	fe80::1 dev eth0 lladdr 00:11:22:33:44:55 router REACHABLE
+//	192.168.1.1 dev eth0 lladdr 0a:1b:2c:3d:4e:5f STALE
+//
+// Записи без lladdr (FAILED, INCOMPLETE) пропускаются.
+func parseIPNeighOutput(output string) []Neighbor {
+	var out []Neighbor
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		ip, zone := SplitZone(fields[0])
+		if ip == nil {
+			continue
+		}
+		n := Neighbor{IP: ip, Interface: zone}
+		for i := 1; i+1 < len(fields); i++ {
+			switch fields[i] {
+			case "dev":
+				n.Interface = fields[i+1]
+			case "lladdr":
+				n.MAC, _ = net.ParseMAC(fields[i+1])
+			}
+		}
+		if isUsableNeighbor(n) {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// parseNDPOutput разбирает вывод "ndp -an" (macOS/BSD):
+//
+//	Neighbor                        Linklayer Address  Netif Expire    St Flgs Prbs
+//	fe80::1%en0                     0:11:22:33:44:55   en0   23h59m58s S  R
+func parseNDPOutput(output string) []Neighbor {
+	var out []Neighbor
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		ip, _ := SplitZone(fields[0])
+		mac, err := net.ParseMAC(normalizeShortMAC(fields[1]))
+		if ip == nil || err != nil {
+			continue
+		}
+		n := Neighbor{IP: ip, MAC: mac, Interface: fields[2]}
+		if isUsableNeighbor(n) {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// parseNetshNeighbors разбирает вывод "netsh interface ipv6 show neighbors" (Windows):
+//
+//	Interface 12: Ethernet
+//
+//	Internet Address                              Physical Address   Type
+//	--------------------------------------------  -----------------  -----------
+//	fe80::1                                       00-11-22-33-44-55  Reachable (Router)
+func parseNetshNeighbors(output string) []Neighbor {
+	var out []Neighbor
+	iface := ""
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if rest, ok := strings.CutPrefix(line, "Interface "); ok {
+			if i := strings.Index(rest, ":"); i >= 0 {
+				iface = strings.TrimSpace(rest[i+1:])
+			}
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		ip, _ := SplitZone(fields[0])
+		mac, err := net.ParseMAC(strings.ReplaceAll(fields[1], "-", ":"))
+		if ip == nil || err != nil {
+			continue
+		}
+		n := Neighbor{IP: ip, MAC: mac, Interface: iface}
+		if isUsableNeighbor(n) {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// normalizeShortMAC дополняет октеты без ведущего нуля ("0:11:2:33:44:55" в выводе BSD).
+func normalizeShortMAC(s string) string {
+	parts := strings.Split(s, ":")
+	if len(parts) != 6 {
+		return s
+	}
+	for i, p := range parts {
+		if len(p) == 1 {
+			parts[i] = "0" + p
+		}
+	}
+	return strings.Join(parts, ":")
+}
+
+// isUsableNeighbor отбрасывает записи без MAC, с нулевым или групповым MAC и
+// групповые/неуказанные адреса.
+func isUsableNeighbor(n Neighbor) bool {
+	if n.IP == nil || n.IP.IsUnspecified() || n.IP.IsMulticast() || n.IP.IsLoopback() {
+		return false
+	}
+	if len(n.MAC) == 0 || n.MAC[0]&1 != 0 {
+		return false
+	}
+	for _, b := range n.MAC {
+		if b != 0 {
+			return true
+		}
+	}
+	return false
+}
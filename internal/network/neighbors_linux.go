@@ -0,0 +1,95 @@
+package network
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os/exec"
+	"syscall"
+)
+
+// Атрибуты и состояния neighbor-записей netlink (linux/neighbour.h).
+const (
+	ndaDst        = 1
+	ndaLLAddr     = 2
+	nudIncomplete = 0x01
+	nudFailed     = 0x20
+	nudNoARP      = 0x40
+	ndMsgLen      = 12 // struct ndmsg
+)
+
+// ReadNeighbors returns the kernel neighbor table (IPv4 ARP and IPv6 NDP entries)
+// read over netlink, falling back to "ip neigh show".
+func ReadNeighbors() ([]Neighbor, error) {
+	data, err := syscall.NetlinkRIB(syscall.RTM_GETNEIGH, syscall.AF_UNSPEC)
+	if err == nil {
+		names := make(map[int]string)
+		out, perr := parseNeighborRIB(data, func(index int) string {
+			if name, ok := names[index]; ok {
+				return name
+			}
+			name := ""
+			if iface, err := net.InterfaceByIndex(index); err == nil {
+				name = iface.Name
+			}
+			names[index] = name
+			return name
+		})
+		if perr == nil {
+			return out, nil
+		}
+		err = perr
+	}
+	output, cmdErr := exec.Command("ip", "neigh", "show").Output()
+	if cmdErr != nil {
+		return nil, fmt.Errorf("neighbor table: netlink: %v; ip neigh: %w", err, cmdErr)
+	}
+	return parseIPNeighOutput(string(output)), nil
+}
+
+// parseNeighborRIB разбирает ответ netlink на RTM_GETNEIGH; ifname возвращает имя
+// интерфейса по индексу.
+func parseNeighborRIB(data []byte, ifname func(int) string) ([]Neighbor, error) {
+	msgs, err := syscall.ParseNetlinkMessage(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse netlink: %w", err)
+	}
+	var out []Neighbor
+	for _, m := range msgs {
+		if m.Header.Type != syscall.RTM_NEWNEIGH || len(m.Data) < ndMsgLen {
+			continue
+		}
+		family := m.Data[0]
+		index := int(int32(binary.NativeEndian.Uint32(m.Data[4:8])))
+		state := binary.NativeEndian.Uint16(m.Data[8:10])
+		if state&(nudIncomplete|nudFailed|nudNoARP) != 0 {
+			continue
+		}
+		var n Neighbor
+		for attrs := m.Data[ndMsgLen:]; len(attrs) >= syscall.SizeofRtAttr; {
+			alen := int(binary.NativeEndian.Uint16(attrs[0:2]))
+			if alen < syscall.SizeofRtAttr || alen > len(attrs) {
+				break
+			}
+			value := attrs[syscall.SizeofRtAttr:alen]
+			switch binary.NativeEndian.Uint16(attrs[2:4]) {
+			case ndaDst:
+				if (family == syscall.AF_INET && len(value) == net.IPv4len) || (family == syscall.AF_INET6 && len(value) == net.IPv6len) {
+					n.IP = append(net.IP(nil), value...)
+				}
+			case ndaLLAddr:
+				n.MAC = append(net.HardwareAddr(nil), value...)
+			}
+			next := (alen + syscall.RTA_ALIGNTO - 1) &^ (syscall.RTA_ALIGNTO - 1)
+			if next > len(attrs) {
+				break
+			}
+			attrs = attrs[next:]
+		}
+		n.Interface = ifname(index)
+		if isUsableNeighbor(n) {
+			out = append(out, n)
+		}
+	}
+	return out, nil
+}
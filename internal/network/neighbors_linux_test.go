@@ -0,0 +1,59 @@
+package network
+
+import (
+	"encoding/binary"
+	"net"
+	"syscall"
+	"testing"
+)
+
+// neighborMessage собирает netlink-сообщение RTM_NEWNEIGH с атрибутами NDA_DST и NDA_LLADDR.
+func neighborMessage(family uint8, index int32, state uint16, ip net.IP, mac net.HardwareAddr) []byte {
+	body := make([]byte, ndMsgLen)
+	body[0] = family
+	binary.NativeEndian.PutUint32(body[4:8], uint32(index))
+	binary.NativeEndian.PutUint16(body[8:10], state)
+	attr := func(typ uint16, value []byte) {
+		a := make([]byte, syscall.SizeofRtAttr, syscall.SizeofRtAttr+len(value)+3)
+		binary.NativeEndian.PutUint16(a[0:2], uint16(syscall.SizeofRtAttr+len(value)))
+		binary.NativeEndian.PutUint16(a[2:4], typ)
+		a = append(a, value...)
+		for len(a)%syscall.RTA_ALIGNTO != 0 {
+			a = append(a, 0)
+		}
+		body = append(body, a...)
+	}
+	attr(ndaDst, ip)
+	if mac != nil {
+		attr(ndaLLAddr, mac)
+	}
+	msg := make([]byte, syscall.NLMSG_HDRLEN, syscall.NLMSG_HDRLEN+len(body))
+	binary.NativeEndian.PutUint32(msg[0:4], uint32(syscall.NLMSG_HDRLEN+len(body)))
+	binary.NativeEndian.PutUint16(msg[4:6], syscall.RTM_NEWNEIGH)
+	return append(msg, body...)
+}
+
+func TestParseNeighborRIB(t *testing.T) {
+	mac := net.HardwareAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x55}
+	const reachable, stale = 0x02, 0x04
+	var data []byte
+	data = append(data, neighborMessage(syscall.AF_INET6, 2, reachable, net.ParseIP("fe80::1"), mac)...)
+	data = append(data, neighborMessage(syscall.AF_INET6, 2, stale, net.ParseIP("2001:db8::5"), mac)...)
+	data = append(data, neighborMessage(syscall.AF_INET, 2, reachable, net.ParseIP("192.168.1.1").To4(), mac)...)
+	data = append(data, neighborMessage(syscall.AF_INET6, 2, nudFailed, net.ParseIP("fe80::2"), nil)...)
+	data = append(data, neighborMessage(syscall.AF_INET6, 2, nudIncomplete, net.ParseIP("fe80::3"), nil)...)
+
+	got, err := parseNeighborRIB(data, func(int) string { return "eth0" })
+	if err != nil {
+		t.Fatalf("parseNeighborRIB() error = %v", err)
+	}
+	want := []string{"fe80::1%eth0", "2001:db8::5", "192.168.1.1"}
+	if len(got) != len(want) {
+		t.Fatalf("parseNeighborRIB() = %v, want %v", got, want)
+	}
+	for i, n := range got {
+		if n.Addr() != want[i] || n.MAC.String() != mac.String() {
+			t.Errorf("entry %d = %s %s, want %s %s", i, n.Addr(), n.MAC, want[i], mac)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+//go:build !linux
+
+package network
+
+import (
+	"fmt"
+	"net"
+	"os/exec"
+	"runtime"
+)
+
+// ReadNeighbors returns the system neighbor table: IPv6 entries from
+// "ndp -an" (macOS/BSD) or "netsh interface ipv6 show neighbors" (Windows)
+// and IPv4 entries from the ARP table.
+func ReadNeighbors() ([]Neighbor, error) {
+	var out []Neighbor
+	var v6Err error
+	if runtime.GOOS == "windows" {
+		output, err := exec.Command("netsh", "interface", "ipv6", "show", "neighbors").Output()
+		if err == nil {
+			out = parseNetshNeighbors(string(output))
+		}
+		v6Err = err
+	} else {
+		output, err := exec.Command("ndp", "-an").Output()
+		if err == nil {
+			out = parseNDPOutput(string(output))
+		}
+		v6Err = err
+	}
+
+	arp, v4Err := GetARPTabale()
+	for ip, mac := range arp {
+		n := Neighbor{IP: net.ParseIP(ip)}
+		n.MAC, _ = net.ParseMAC(mac)
+		if isUsableNeighbor(n) {
+			out = append(out, n)
+		}
+	}
+	if v6Err != nil && v4Err != nil {
+		return nil, fmt.Errorf("neighbor table: %v; arp: %w", v6Err, v4Err)
+	}
+	return out, nil
+}
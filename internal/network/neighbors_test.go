@@ -0,0 +1,121 @@
+package network
+
+import (
+	"context"
+	"net"
+	"testing"
+)
+
+func TestParseNeighborOutputs(t *testing.T) {
+	tests := []struct {
+		name  string
+		parse func(string) []Neighbor
+		input string
+		want  []string // Addr MAC
+	}{
+		{
+			name:  "ip neigh",
+			parse: parseIPNeighOutput,
+			input: "fe80::1 dev eth0 lladdr 00:11:22:33:44:55 router REACHABLE\n" +
+				"2001:db8::5 dev eth0 lladdr 00:11:22:33:44:66 STALE\n" +
+				"192.168.1.1 dev eth0 lladdr 0a:1b:2c:3d:4e:5f DELAY\n" +
+				"fe80::2 dev eth0 FAILED\n" +
+				"ff02::1 dev eth0 lladdr 33:33:00:00:00:01 NOARP\n",
+			want: []string{"fe80::1%eth0 00:11:22:33:44:55", "2001:db8::5 00:11:22:33:44:66", "192.168.1.1 0a:1b:2c:3d:4e:5f"},
+		},
+		{
+			name:  "ndp -an",
+			parse: parseNDPOutput,
+			input: "Neighbor                        Linklayer Address  Netif Expire    St Flgs Prbs\n" +
+				"fe80::1%en0                     0:11:22:33:44:55   en0   23h59m58s S  R\n" +
+				"2001:db8::7                     a:b:c:d:e:f        en0   permanent R\n" +
+				"fe80::9%en0                     (incomplete)       en0   expired   N\n",
+			want: []string{"fe80::1%en0 00:11:22:33:44:55", "2001:db8::7 0a:0b:0c:0d:0e:0f"},
+		},
+		{
+			name:  "netsh",
+			parse: parseNetshNeighbors,
+			input: "Interface 12: Ethernet\r\n\r\n" +
+				"Internet Address                              Physical Address   Type\r\n" +
+				"--------------------------------------------  -----------------  -----------\r\n" +
+				"fe80::1                                       00-11-22-33-44-55  Reachable (Router)\r\n" +
+				"fe80::3                                       00-00-00-00-00-00  Unreachable\r\n" +
+				"ff02::1                                       33-33-00-00-00-01  Permanent\r\n",
+			want: []string{"fe80::1%Ethernet 00:11:22:33:44:55"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.parse(tt.input)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d entries (%v), want %d", len(got), got, len(tt.want))
+			}
+			for i, n := range got {
+				if s := n.Addr() + " " + n.MAC.String(); s != tt.want[i] {
+					t.Errorf("entry %d = %q, want %q", i, s, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSplitZone(t *testing.T) {
+	ip, zone := SplitZone("fe80::1%eth0")
+	if !ip.Equal(net.ParseIP("fe80::1")) || zone != "eth0" {
+		t.Errorf("SplitZone() = %v, %q", ip, zone)
+	}
+	if ip, zone := SplitZone("::ffff:10.0.0.1"); ip.String() != "10.0.0.1" || zone != "" {
+		t.Errorf("SplitZone(mapped) = %v, %q", ip, zone)
+	}
+	if ip, _ := SplitZone("not-an-ip"); ip != nil {
+		t.Errorf("SplitZone(invalid) = %v", ip)
+	}
+	if got := StripZone("fe80::1%eth0"); got != "fe80::1" {
+		t.Errorf("StripZone() = %q", got)
+	}
+}
+
+func TestNeighborTarget(t *testing.T) {
+	tests := []struct {
+		spec   string
+		prefix string
+		zone   string
+		ok     bool
+	}{
+		{"2001:db8:1::/64", "2001:db8:1::/64", "", true},
+		{"fe80::/64%eth0", "fe80::/64", "eth0", true},
+		{"fe80::%wlan0/64", "fe80::/64", "wlan0", true},
+		{"fe80::/120%eth0", "fe80::/120", "eth0", true},
+		{"2001:db8::/112", "", "", false},
+		{"10.0.0.0/8", "", "", false},
+		{"fe80::1%eth0", "", "", false},
+	}
+	for _, tt := range tests {
+		prefix, zone, ok := NeighborTarget(tt.spec)
+		if ok != tt.ok || (ok && (prefix.String() != tt.prefix || zone != tt.zone)) {
+			t.Errorf("NeighborTarget(%q) = %v, %q, %v; want %s, %q, %v", tt.spec, prefix, zone, ok, tt.prefix, tt.zone, tt.ok)
+		}
+	}
+	if err := ValidateTargets([]string{"2001:db8::/64", "fe80::/64%eth0", "fe80::1%eth0"}); err != nil {
+		t.Errorf("ValidateTargets() error = %v", err)
+	}
+	if err := ValidateTargets([]string{"10.0.0.0/24%eth0"}); err == nil {
+		t.Error("ожидалась ошибка для IPv4-сети с зоной")
+	}
+	if n, err := EstimateTargetCount([]string{"2001:db8::/64", "10.0.0.1"}); err != nil || n != 1 {
+		t.Errorf("EstimateTargetCount() = %d, %v; want 1", n, err)
+	}
+}
+
+func TestTargetIterator_Zones(t *testing.T) {
+	it, err := NewTargetIterator(context.Background(), []string{"fe80::1%eth0", "fe80::1%eth1", "fe80::2"}, offlineResolver)
+	if err != nil {
+		t.Fatalf("NewTargetIterator() error = %v", err)
+	}
+	if got := drain(it); len(got) != 2 || got[0] != "fe80::1" || got[1] != "fe80::2" {
+		t.Errorf("Next() = %v", got)
+	}
+	if zones := it.Zones(); len(zones) != 1 || zones["fe80::1"] != "eth0" {
+		t.Errorf("Zones() = %v", zones)
+	}
+}
@@ -3,7 +3,6 @@ package network
 import (
 	"fmt"
 	"net"
-	"math"
 	"strings"
 	"time"
 
@@ -12,33 +11,20 @@ import (
 
 const maxEnumeratedHosts = 65536
 
-// EstimateHostCount оценивает количество адресов-хостов в CIDR диапазоне без полной генерации списка IP.
+// EstimateHostCount возвращает число адресов-хостов в CIDR диапазоне без генерации списка IP:
+// в IPv4-сетях шире /31 адрес сети и broadcast не считаются (так же перебирает TargetIterator).
 func EstimateHostCount(cidr string) (int, error) {
-	_, ipnet, err := net.ParseCIDR(strings.TrimSpace(cidr))
+	r, err := targetBounds(targetCIDR, cidr)
 	if err != nil {
 		return 0, err
 	}
-	ones, bits := ipnet.Mask.Size()
-	hostBits := bits - ones
-	if hostBits <= 0 {
-		return 1, nil
-	}
-	if bits == 32 {
-		// Для IPv4 исключаем network/broadcast только когда это применимо.
-		if hostBits == 1 {
-			return 2, nil // /31: оба адреса используются
-		}
-		if hostBits >= 2 {
-			return int(math.Pow(2, float64(hostBits))) - 2, nil
-		}
-	}
-	if hostBits > 30 {
-		return 0, fmt.Errorf("слишком большой диапазон для оценки: %s", cidr)
-	}
-	return 1 << hostBits, nil
+	n, _ := rangeSize(r.start, r.end)
+	return int(n), nil
 }
 
-// DetectLocalNetwork определяет локальную сеть автоматически
+// DetectLocalNetwork определяет локальную сеть автоматически. Предпочитается IPv4-сеть;
+// без неё возвращается IPv6-префикс интерфейса (глобальный/ULA, иначе link-local с зоной,
+// например "fe80::/64%eth0") — его хосты сканер находит обнаружением соседей.
 func DetectLocalNetwork() (string, error) {
 	// Получаем интерфейсы с таймаутом (избегаем зависания в Windows)
 	interfacesChan := make(chan []net.Interface, 1)
@@ -62,6 +48,7 @@ func DetectLocalNetwork() (string, error) {
 		return "", fmt.Errorf("таймаут получения сетевых интерфейсов")
 	}
 
+	var ipv6Network, linkLocalNetwork string
 	for _, iface := range interfaces {
 		// Пропускаем неактивные интерфейсы и loopback
 		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
@@ -100,8 +87,27 @@ func DetectLocalNetwork() (string, error) {
 				ip = v.IP
 			}
 
-			// Пропускаем IPv6 и loopback
-			if ip == nil || ip.IsLoopback() || ip.To4() == nil {
+			// Пропускаем loopback; IPv6 запоминаем на случай, если IPv4-сети нет
+			if ip == nil || ip.IsLoopback() {
+				continue
+			}
+			if ip.To4() == nil {
+				ipnet, ok := addr.(*net.IPNet)
+				if !ok {
+					continue
+				}
+				ones, bits := ipnet.Mask.Size()
+				if bits != 128 || ones == 0 {
+					continue
+				}
+				prefix := fmt.Sprintf("%s/%d", ip.Mask(ipnet.Mask).String(), ones)
+				if ip.IsLinkLocalUnicast() {
+					if linkLocalNetwork == "" {
+						linkLocalNetwork = prefix + "%" + iface.Name
+					}
+				} else if ipv6Network == "" {
+					ipv6Network = prefix
+				}
 				continue
 			}
 
@@ -117,6 +123,12 @@ func DetectLocalNetwork() (string, error) {
 		}
 	}
 
+	if ipv6Network != "" {
+		return ipv6Network, nil
+	}
+	if linkLocalNetwork != "" {
+		return linkLocalNetwork, nil
+	}
 	return "", fmt.Errorf("не найдена активная сеть")
 }
 
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"net"
 	"os"
-	"strconv"
 	"strings"
 )
 
@@ -73,62 +72,57 @@ func ParseTargetsFromFile(path string) ([]string, error) {
 	return ips, nil
 }
 
-// parseIPRange parses an IP range like "192.168.1.1-10" and returns a list of IPs.
-// The range is inclusive: "1-3" returns 1,2,3 (3 addresses).
-func parseIPRange(rangeStr string) ([]string, error) {
-	parts := strings.SplitN(rangeStr, "-", 2)
-	if len(parts) != 2 {
-		return nil, fmt.Errorf("invalid IP range format: %s", rangeStr)
+// ReadTargetsFile reads a targets file without expanding it and returns the target
+// specifications in file order. In addition to the formats of ParseTargetsFromFile it
+// accepts hostnames (resolved at scan time) and several targets per line separated by
+// commas or spaces. Each target is validated; see ExpandTargets for expansion.
+func ReadTargetsFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open targets file: %w", err)
 	}
+	defer file.Close()
+
+	targets := make([]string, 0)
+	scanner := bufio.NewScanner(file)
+
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
 
-	baseIP := net.ParseIP(parts[0])
-	if baseIP == nil {
-		return nil, fmt.Errorf("invalid base IP in range: %s", parts[0])
+		specs := SplitTargets(line)
+		if err := ValidateTargets(specs); err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		targets = append(targets, specs...)
 	}
 
-	endStr := strings.TrimSpace(parts[1])
-	end, err := strconv.Atoi(endStr)
-	if err != nil {
-		return nil, fmt.Errorf("invalid end IP in range: %s", endStr)
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading targets file: %w", err)
 	}
 
-	if end < 0 {
-		return nil, fmt.Errorf("invalid end IP in range (must be >= 0): %s", endStr)
+	return targets, nil
+}
+
+// parseIPRange parses an IP range like "192.168.1.1-10" and returns a list of IPs.
+// The range is inclusive: "1-3" returns 1,2,3 (3 addresses). The end may also be
+// a full address ("192.168.1.250-192.168.2.10").
+func parseIPRange(rangeStr string) ([]string, error) {
+	start, end, err := parseRangeBounds(rangeStr)
+	if err != nil {
+		return nil, err
 	}
 
-	// Determine the IP version from baseIP
-	isIPv4 := baseIP.To4() != nil
-
-	ips := make([]string, 0, end+1)
-
-	if isIPv4 {
-		// For IPv4, work with the last 4 bytes
-		baseBytes := baseIP.To4()
-		baseInt := (int(baseBytes[0]) << 24) | (int(baseBytes[1]) << 16) | (int(baseBytes[2]) << 8) | int(baseBytes[3])
-
-		for i := 0; i < end; i++ {
-			ipInt := baseInt + i
-			ip := net.IPv4(
-				byte((ipInt>>24)&0xFF),
-				byte((ipInt>>16)&0xFF),
-				byte((ipInt>>8)&0xFF),
-				byte(ipInt&0xFF),
-			).String()
-			ips = append(ips, ip)
-		}
-	} else {
-		// For IPv6, increment the last 2 bytes (little-endian)
-		baseBytes := baseIP.To16()
-		for i := 0; i < end; i++ {
-			ipBytes := make([]byte, 16)
-			copy(ipBytes, baseBytes)
-
-			offset := i
-			ipBytes[14] = byte(offset & 0xFF)
-			ipBytes[15] = byte((offset >> 8) & 0xFF)
-
-			ip := net.IP(ipBytes)
-			ips = append(ips, ip.String())
+	n, _ := rangeSize(start, end)
+	ips := make([]string, 0, n)
+	for addr := start; ; addr = addr.Next() {
+		ips = append(ips, addr.String())
+		if addr == end {
+			break
 		}
 	}
 
@@ -166,3 +166,31 @@ func createTempFile(t *testing.T, content string) *os.File {
 
 	return file
 }
+
+func TestReadTargetsFile(t *testing.T) {
+	content := "# office\n192.168.1.0/30, 192.168.1.10-12\nnas.local\n"
+	tmpFile := createTempFile(t, content)
+
+	targets, err := ReadTargetsFile(tmpFile.Name())
+	if err != nil {
+		t.Fatalf("ReadTargetsFile() error = %v", err)
+	}
+
+	expected := []string{"192.168.1.0/30", "192.168.1.10-12", "nas.local"}
+	if len(targets) != len(expected) {
+		t.Fatalf("Ожидалось %d целей, получено %d: %v", len(expected), len(targets), targets)
+	}
+	for i, target := range expected {
+		if targets[i] != target {
+			t.Errorf("Ожидалась цель %s на позиции %d, получено %s", target, i, targets[i])
+		}
+	}
+}
+
+func TestReadTargetsFile_Invalid(t *testing.T) {
+	tmpFile := createTempFile(t, "192.168.1.1\n192.168.1.20-10\n")
+
+	if _, err := ReadTargetsFile(tmpFile.Name()); err == nil {
+		t.Error("Ожидалась ошибка для диапазона с концом меньше начала")
+	}
+}
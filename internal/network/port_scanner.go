@@ -8,20 +8,42 @@ import (
 // TCPPortScanner scans TCP ports via net dial checks.
 type TCPPortScanner struct {
 	Timeout time.Duration
+	Source  Source        // interface/address to connect from; zero means OS choice
+	Dialer  ContextDialer // connects through a proxy instead of Source when set
 }
 
 // ScanPort scans a single TCP port.
 func (s TCPPortScanner) ScanPort(ip string, port int, proto string) (bool, error) {
+	return s.ScanPortTimeout(ip, port, proto, s.Timeout)
+}
+
+// ScanPortTimeout scans a single TCP port with the given timeout instead of s.Timeout.
+func (s TCPPortScanner) ScanPortTimeout(ip string, port int, proto string, timeout time.Duration) (bool, error) {
 	if proto != "" && proto != "tcp" {
 		return false, fmt.Errorf("tcp scanner does not support protocol: %s", proto)
 	}
-	timeout := s.Timeout
 	if timeout <= 0 {
 		timeout = time.Second
 	}
+	if s.Dialer != nil || !s.Source.IsZero() {
+		res, _ := s.ProbeTCP(ip, port, timeout)
+		return res.State == PortOpen, nil
+	}
 	return IsPortOpen(ip, port, timeout), nil
 }
 
+// ProbeTCP classifies a TCP port (open, closed, filtered) with the reason and latency.
+// A zero timeout means s.Timeout.
+func (s TCPPortScanner) ProbeTCP(ip string, port int, timeout time.Duration) (TCPProbeResult, error) {
+	if timeout <= 0 {
+		timeout = s.Timeout
+	}
+	if s.Dialer != nil {
+		return ProbeTCPVia(s.Dialer, ip, port, timeout)
+	}
+	return ProbeTCPFrom(s.Source, ip, port, timeout)
+}
+
 // ScanPorts scans the provided ports for TCP.
 func (s TCPPortScanner) ScanPorts(ip string, ports []int, proto string) ([]int, error) {
 	open := make([]int, 0, len(ports))
@@ -37,21 +59,31 @@ func (s TCPPortScanner) ScanPorts(ip string, ports []int, proto string) ([]int,
 	return open, nil
 }
 
-// UDPPortScanner scans UDP ports via probe checks.
+// UDPPortScanner scans UDP ports with protocol-aware probes (see ProbeUDP).
 type UDPPortScanner struct {
 	Timeout time.Duration
+	Source  Source // interface/address to send from; zero means OS choice
 }
 
-// ScanPort scans a single UDP port.
+// ScanPort reports whether a UDP port answered the probe; open|filtered ports are not open.
 func (s UDPPortScanner) ScanPort(ip string, port int, proto string) (bool, error) {
 	if proto != "" && proto != "udp" {
 		return false, fmt.Errorf("udp scanner does not support protocol: %s", proto)
 	}
+	res, err := s.ProbeUDP(ip, port)
+	if err != nil {
+		return false, err
+	}
+	return res.State == PortOpen, nil
+}
+
+// ProbeUDP probes a single UDP port and returns its state with the recognised service.
+func (s UDPPortScanner) ProbeUDP(ip string, port int) (UDPProbeResult, error) {
 	timeout := s.Timeout
 	if timeout <= 0 {
 		timeout = time.Second
 	}
-	return IsUDPPortOpen(ip, port, timeout), nil
+	return ProbeUDPFrom(s.Source, ip, port, timeout)
 }
 
 // ScanPorts scans the provided ports for UDP.
@@ -10,6 +10,8 @@ import (
 // DefaultNetworkProber is the default implementation for liveness and MAC probes.
 type DefaultNetworkProber struct {
 	Timeout time.Duration
+	Source  Source        // interface/address to connect from; zero means OS choice
+	Dialer  ContextDialer // connects through a proxy instead of Source when set
 }
 
 // Ping checks host availability using a short TCP probe set.
@@ -52,8 +54,13 @@ func (p DefaultNetworkProber) PingContext(ip string, done <-chan struct{}) (bool
 	results := make(chan bool, len(ports))
 	for _, port := range ports {
 		go func(port int) {
-			dialer := &net.Dialer{Timeout: probeTimeout}
-			conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(ip, fmt.Sprintf("%d", port)))
+			var dialer ContextDialer = p.Source.Dialer("tcp", ip, probeTimeout)
+			if p.Dialer != nil {
+				dialer = p.Dialer
+			}
+			dialCtx, cancelDial := context.WithTimeout(ctx, probeTimeout)
+			defer cancelDial()
+			conn, err := dialer.DialContext(dialCtx, "tcp", net.JoinHostPort(ip, fmt.Sprintf("%d", port)))
 			if err == nil {
 				if conn != nil {
 					_ = conn.Close()
@@ -0,0 +1,156 @@
+package network
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Source — откуда уходят пробы: интерфейс и локальные адреса по семействам.
+// Нулевое значение — выбор ОС по таблице маршрутизации.
+type Source struct {
+	Interface string // имя интерфейса; пусто — любой
+	IPv4      net.IP // локальный адрес для IPv4-целей; nil — выбор ОС
+	IPv6      net.IP // локальный адрес для IPv6-целей; nil — выбор ОС
+}
+
+// ResolveSource проверяет выбор интерфейса и/или адреса источника и дополняет его:
+// для адреса определяется интерфейс, которому он назначен; для интерфейса без адреса
+// берутся его первый IPv4 и первый глобальный IPv6. Пустые iface и ip — нулевой Source.
+func ResolveSource(iface, ip string) (Source, error) {
+	iface, ip = strings.TrimSpace(iface), strings.TrimSpace(ip)
+	if iface == "" && ip == "" {
+		return Source{}, nil
+	}
+	infos, err := ListInterfaces()
+	if err != nil {
+		return Source{}, err
+	}
+	return resolveSource(iface, ip, infos)
+}
+
+func resolveSource(iface, ip string, infos []InterfaceInfo) (Source, error) {
+	var src Source
+	if ip != "" {
+		addr := net.ParseIP(ip)
+		if addr == nil {
+			return Source{}, fmt.Errorf("неверный адрес источника: %s", ip)
+		}
+		owner := ""
+		for _, info := range infos {
+			if info.HasAddr(addr) && (iface == "" || info.Name == iface) {
+				owner = info.Name
+				break
+			}
+		}
+		if owner == "" {
+			if iface != "" {
+				return Source{}, fmt.Errorf("адрес %s не назначен интерфейсу %s", ip, iface)
+			}
+			return Source{}, fmt.Errorf("адрес %s не назначен ни одному интерфейсу", ip)
+		}
+		src.Interface = owner
+		if v4 := addr.To4(); v4 != nil {
+			src.IPv4 = v4
+		} else {
+			src.IPv6 = addr
+		}
+		return src, nil
+	}
+
+	for _, info := range infos {
+		if info.Name != iface {
+			continue
+		}
+		if info.Flags&net.FlagUp == 0 {
+			return Source{}, fmt.Errorf("интерфейс %s не активен", iface)
+		}
+		src.Interface = info.Name
+		for _, a := range info.Addrs {
+			if v4 := a.IP.To4(); v4 != nil {
+				if src.IPv4 == nil {
+					src.IPv4 = v4
+				}
+			} else if src.IPv6 == nil && !a.IP.IsLinkLocalUnicast() {
+				src.IPv6 = a.IP
+			}
+		}
+		return src, nil
+	}
+	return Source{}, fmt.Errorf("интерфейс %s не найден", iface)
+}
+
+// IsZero сообщает, что источник не выбран.
+func (s Source) IsZero() bool {
+	return s.Interface == "" && s.IPv4 == nil && s.IPv6 == nil
+}
+
+// String описывает источник для логов: "eth0 (192.168.1.10)".
+func (s Source) String() string {
+	var addrs []string
+	if s.IPv4 != nil {
+		addrs = append(addrs, s.IPv4.String())
+	}
+	if s.IPv6 != nil {
+		addrs = append(addrs, s.IPv6.String())
+	}
+	switch {
+	case s.Interface == "":
+		return strings.Join(addrs, ", ")
+	case len(addrs) == 0:
+		return s.Interface
+	}
+	return s.Interface + " (" + strings.Join(addrs, ", ") + ")"
+}
+
+// LocalIP возвращает адрес источника для семейства dst (nil — выбор ОС).
+// Для имени хоста (dst == nil) берётся IPv4-адрес, если он выбран.
+func (s Source) LocalIP(dst net.IP) net.IP {
+	if dst != nil && dst.To4() == nil {
+		return s.IPv6
+	}
+	if s.IPv4 == nil && dst == nil {
+		return s.IPv6
+	}
+	return s.IPv4
+}
+
+// Dialer возвращает net.Dialer, соединения которого уходят с адреса источника для
+// семейства host и привязаны к интерфейсу источника (где ОС это позволяет).
+// network — "tcp" или "udp" с необязательным суффиксом семейства.
+func (s Source) Dialer(network, host string, timeout time.Duration) *net.Dialer {
+	d := &net.Dialer{Timeout: timeout}
+	if s.IsZero() {
+		return d
+	}
+	dst, _ := SplitZone(host)
+	if local := s.LocalIP(dst); local != nil {
+		if strings.HasPrefix(network, "udp") {
+			d.LocalAddr = &net.UDPAddr{IP: local}
+		} else {
+			d.LocalAddr = &net.TCPAddr{IP: local}
+		}
+	}
+	if s.Interface != "" {
+		name := s.Interface
+		d.Control = func(_, _ string, c syscall.RawConn) error {
+			var bindErr error
+			if err := c.Control(func(fd uintptr) { bindErr = bindToDevice(fd, name) }); err != nil {
+				return err
+			}
+			return bindErr
+		}
+	}
+	return d
+}
+
+// DialTimeout — net.DialTimeout с адреса источника.
+func (s Source) DialTimeout(network, address string, timeout time.Duration) (net.Conn, error) {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+	}
+	return s.Dialer(network, host, timeout).Dial(network, address)
+}
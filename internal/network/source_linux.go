@@ -0,0 +1,16 @@
+package network
+
+import (
+	"errors"
+	"syscall"
+)
+
+// bindToDevice привязывает сокет к интерфейсу (SO_BINDTODEVICE). До Linux 5.7 это
+// требует CAP_NET_RAW; без прав сокет остаётся привязан только к адресу источника.
+func bindToDevice(fd uintptr, iface string) error {
+	err := syscall.SetsockoptString(int(fd), syscall.SOL_SOCKET, syscall.SO_BINDTODEVICE, iface)
+	if errors.Is(err, syscall.EPERM) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package network
+
+// bindToDevice — на этих ОС интерфейс выбирается адресом источника.
+func bindToDevice(fd uintptr, iface string) error {
+	return nil
+}
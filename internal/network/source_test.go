@@ -0,0 +1,105 @@
+package network
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func testIPNet(t *testing.T, cidr string) *net.IPNet {
+	t.Helper()
+	ip, ipnet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ipnet.IP = ip
+	return ipnet
+}
+
+func TestResolveSource(t *testing.T) {
+	infos := []InterfaceInfo{
+		{Name: "lo", Flags: net.FlagUp | net.FlagLoopback, Addrs: []*net.IPNet{testIPNet(t, "127.0.0.1/8")}},
+		{Name: "eth0", Flags: net.FlagUp, Addrs: []*net.IPNet{
+			testIPNet(t, "fe80::1/64"), testIPNet(t, "192.168.1.10/24"), testIPNet(t, "2001:db8::10/64"), testIPNet(t, "192.168.2.10/24"),
+		}},
+		{Name: "wlan0", Addrs: []*net.IPNet{testIPNet(t, "10.0.0.5/24")}},
+	}
+	tests := []struct {
+		iface, ip string
+		want      string // Source.String(); пусто — ожидается ошибка
+	}{
+		{"eth0", "", "eth0 (192.168.1.10, 2001:db8::10)"},
+		{"", "192.168.2.10", "eth0 (192.168.2.10)"},
+		{"eth0", "2001:db8::10", "eth0 (2001:db8::10)"},
+		{"eth0", "10.0.0.5", ""},
+		{"", "203.0.113.1", ""},
+		{"", "not-an-ip", ""},
+		{"eth9", "", ""},
+		{"wlan0", "", ""},
+	}
+	for _, tt := range tests {
+		src, err := resolveSource(tt.iface, tt.ip, infos)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("resolveSource(%q, %q) = %v, want error", tt.iface, tt.ip, src)
+			}
+			continue
+		}
+		if err != nil || src.String() != tt.want {
+			t.Errorf("resolveSource(%q, %q) = %q, %v; want %q", tt.iface, tt.ip, src, err, tt.want)
+		}
+	}
+	if src, err := ResolveSource(" ", ""); err != nil || !src.IsZero() {
+		t.Errorf("ResolveSource(empty) = %v, %v; want zero Source", src, err)
+	}
+}
+
+func TestSourceDialer(t *testing.T) {
+	src := Source{IPv4: net.ParseIP("192.168.1.10").To4(), IPv6: net.ParseIP("2001:db8::10")}
+	if d := src.Dialer("tcp", "10.0.0.1", time.Second); d.LocalAddr.String() != "192.168.1.10:0" {
+		t.Errorf("Dialer(tcp, IPv4).LocalAddr = %v", d.LocalAddr)
+	}
+	if d := src.Dialer("udp", "2001:db8::1", time.Second); d.LocalAddr.String() != "[2001:db8::10]:0" {
+		t.Errorf("Dialer(udp, IPv6).LocalAddr = %v", d.LocalAddr)
+	}
+	if _, ok := src.Dialer("udp", "10.0.0.1", time.Second).LocalAddr.(*net.UDPAddr); !ok {
+		t.Error("Dialer(udp) должен задавать UDPAddr")
+	}
+	if d := (Source{IPv6: src.IPv6}).Dialer("tcp", "10.0.0.1", time.Second); d.LocalAddr != nil {
+		t.Errorf("Dialer без IPv4-адреса для IPv4-цели: LocalAddr = %v", d.LocalAddr)
+	}
+	if d := (Source{}).Dialer("tcp", "10.0.0.1", time.Second); d.LocalAddr != nil || d.Control != nil || d.Timeout != time.Second {
+		t.Errorf("нулевой Source: %+v", d)
+	}
+}
+
+func TestProbeTCPFrom_Loopback(t *testing.T) {
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("listen: %v", err)
+	}
+	defer ln.Close()
+	accepted := make(chan net.Addr, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		accepted <- conn.RemoteAddr()
+		conn.Close()
+	}()
+	port := ln.Addr().(*net.TCPAddr).Port
+	src := Source{IPv4: net.ParseIP("127.0.0.1").To4()}
+	res, err := ProbeTCPFrom(src, "127.0.0.1", port, time.Second)
+	if err != nil || res.State != PortOpen {
+		t.Fatalf("ProbeTCPFrom() = %+v, %v", res, err)
+	}
+	select {
+	case addr := <-accepted:
+		if ip := addr.(*net.TCPAddr).IP; !ip.Equal(src.IPv4) {
+			t.Errorf("соединение пришло с %v, want %v", ip, src.IPv4)
+		}
+	case <-time.After(time.Second):
+		t.Error("соединение не принято")
+	}
+}
@@ -0,0 +1,637 @@
+package network
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/netip"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcap"
+)
+
+// PortState is the classification of a probed port.
+type PortState int
+
+const (
+	PortFiltered     PortState = iota // no answer within the timeout
+	PortOpen                          // SYN-ACK (TCP) or any reply (UDP) received
+	PortClosed                        // RST (TCP) or ICMP port unreachable (UDP) received
+	PortOpenFiltered                  // UDP: no reply, the port is open or filtered
+)
+
+// String returns the state name used in scan results ("open", "closed", "filtered", "open|filtered").
+func (s PortState) String() string {
+	switch s {
+	case PortOpen:
+		return "open"
+	case PortClosed:
+		return "closed"
+	case PortOpenFiltered:
+		return "open|filtered"
+	default:
+		return "filtered"
+	}
+}
+
+const (
+	synSnapLen        = 128
+	synReadTimeout    = 100 * time.Millisecond
+	synWindowSize     = 1024
+	synSrcPortMin     = 40000
+	synSrcPortSpan    = 20000
+	nextHopARPTimeout = 300 * time.Millisecond
+)
+
+// PacketHandle is the subset of *pcap.Handle used by SYNScanner.
+// Tests inject a fake implementation instead of a live capture handle.
+type PacketHandle interface {
+	ReadPacketData() ([]byte, gopacket.CaptureInfo, error)
+	WritePacketData(data []byte) error
+	LinkType() layers.LinkType
+	Close()
+}
+
+// SYNScannerConfig configures a SYNScanner.
+type SYNScannerConfig struct {
+	Handle PacketHandle
+	SrcIP  net.IP
+	SrcMAC net.HardwareAddr
+	// NextHopMAC returns the destination MAC for frames to dst (the host itself
+	// on the local segment, otherwise the gateway). Required for Ethernet handles.
+	NextHopMAC func(dst net.IP) (net.HardwareAddr, error)
+	// SrcPort is the TCP source port of all probes; 0 picks a random high port.
+	SrcPort uint16
+	Timeout time.Duration
+}
+
+// SYNScanner performs half-open TCP scans over a raw packet handle.
+//
+// A probe sends a single SYN and classifies the reply: SYN-ACK is open, RST is
+// closed, ICMP destination unreachable or silence until the timeout is filtered.
+// All replies are read by one
+// shared receive loop and matched to pending probes by (address, port).
+// SYNScanner implements scanner.PortScanner for the "tcp" protocol.
+type SYNScanner struct {
+	handle     PacketHandle
+	linkType   layers.LinkType
+	srcIP      net.IP
+	srcMAC     net.HardwareAddr
+	nextHopMAC func(dst net.IP) (net.HardwareAddr, error)
+	srcPort    uint16
+	seq        uint32
+	timeout    time.Duration
+
+	mu      sync.Mutex
+	pending map[synKey]*synProbe
+	loopErr error
+	closed  bool
+
+	writeMu  sync.Mutex
+	loopDone chan struct{}
+}
+
+type synKey struct {
+	addr netip.Addr
+	port uint16
+}
+
+type synProbe struct {
+	done   chan struct{}
+	sent   time.Time
+	result TCPProbeResult
+}
+
+// NewSYNScanner creates a scanner on top of cfg.Handle and starts its receive loop.
+// The scanner owns the handle: Close closes it.
+func NewSYNScanner(cfg SYNScannerConfig) (*SYNScanner, error) {
+	if cfg.Handle == nil {
+		return nil, fmt.Errorf("syn scanner: packet handle is required")
+	}
+	srcIP := cfg.SrcIP.To4()
+	if srcIP == nil {
+		return nil, fmt.Errorf("syn scanner: IPv4 source address is required")
+	}
+	linkType := cfg.Handle.LinkType()
+	switch linkType {
+	case layers.LinkTypeEthernet:
+		if cfg.NextHopMAC == nil {
+			return nil, fmt.Errorf("syn scanner: next-hop MAC resolver is required for Ethernet")
+		}
+	case layers.LinkTypeNull, layers.LinkTypeLoop, layers.LinkTypeRaw, layers.LinkTypeIPv4:
+	default:
+		return nil, fmt.Errorf("syn scanner: unsupported link type %s", linkType)
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = time.Second
+	}
+	srcPort := cfg.SrcPort
+	if srcPort == 0 {
+		srcPort = uint16(synSrcPortMin + rand.IntN(synSrcPortSpan))
+	}
+	s := &SYNScanner{
+		handle:     cfg.Handle,
+		linkType:   linkType,
+		srcIP:      srcIP,
+		srcMAC:     cfg.SrcMAC,
+		nextHopMAC: cfg.NextHopMAC,
+		srcPort:    srcPort,
+		seq:        rand.Uint32(),
+		timeout:    timeout,
+		pending:    make(map[synKey]*synProbe),
+		loopDone:   make(chan struct{}),
+	}
+	go s.receiveLoop()
+	return s, nil
+}
+
+// OpenSYNScanner opens a live capture on the interface that routes to target
+// and returns a SYN scanner bound to it. It fails without raw-socket privileges
+// (root/CAP_NET_RAW, Npcap on Windows); callers fall back to connect scans.
+func OpenSYNScanner(target net.IP, timeout time.Duration) (*SYNScanner, error) {
+	return OpenSYNScannerFrom(target, Source{}, timeout)
+}
+
+// OpenSYNScannerFrom is OpenSYNScanner that captures on the interface owning the
+// source IPv4 address of src instead of the routed one.
+func OpenSYNScannerFrom(target net.IP, src Source, timeout time.Duration) (*SYNScanner, error) {
+	target = target.To4()
+	if target == nil {
+		return nil, fmt.Errorf("syn scanner: only IPv4 targets are supported")
+	}
+	if src.Interface != "" && src.IPv4 == nil {
+		return nil, fmt.Errorf("syn scanner: interface %s has no IPv4 address", src.Interface)
+	}
+	iface, ipnet, err := routeInterface(target, src.IPv4)
+	if err != nil {
+		return nil, err
+	}
+	handle, err := pcap.OpenLive(iface.Name, synSnapLen, false, synReadTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("syn scanner: open %s: %w", iface.Name, err)
+	}
+	resolver := &nextHopResolver{iface: iface, ipnet: ipnet, cache: make(map[string]net.HardwareAddr)}
+	s, err := NewSYNScanner(SYNScannerConfig{
+		Handle:     handle,
+		SrcIP:      ipnet.IP,
+		SrcMAC:     iface.HardwareAddr,
+		NextHopMAC: resolver.Resolve,
+		Timeout:    timeout,
+	})
+	if err != nil {
+		handle.Close()
+		return nil, err
+	}
+	// Фильтр сокращает поток пакетов до ответов на наши пробы и ICMP unreachable;
+	// без него сканер тоже работает.
+	_ = handle.SetBPFFilter(fmt.Sprintf("(tcp and dst port %d) or icmp[icmptype] == icmp-unreach", s.srcPort))
+	return s, nil
+}
+
+// Close stops the receive loop and closes the packet handle.
+func (s *SYNScanner) Close() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	s.mu.Unlock()
+	s.handle.Close()
+	<-s.loopDone
+}
+
+// ScanPort reports whether the TCP port answers with SYN-ACK.
+func (s *SYNScanner) ScanPort(ip string, port int, proto string) (bool, error) {
+	if proto != "" && proto != "tcp" {
+		return false, fmt.Errorf("syn scanner does not support protocol: %s", proto)
+	}
+	state, err := s.Probe(ip, port)
+	if err != nil {
+		return false, err
+	}
+	return state == PortOpen, nil
+}
+
+// ScanPorts probes all ports at once and returns the open ones.
+func (s *SYNScanner) ScanPorts(ip string, ports []int, proto string) ([]int, error) {
+	if proto != "" && proto != "tcp" {
+		return nil, fmt.Errorf("syn scanner does not support protocol: %s", proto)
+	}
+	states, err := s.ProbePorts(ip, ports)
+	if err != nil {
+		return nil, err
+	}
+	open := make([]int, 0, len(ports))
+	for _, port := range ports {
+		if states[port] == PortOpen {
+			open = append(open, port)
+		}
+	}
+	return open, nil
+}
+
+// Probe sends one SYN and classifies the reply.
+func (s *SYNScanner) Probe(ip string, port int) (PortState, error) {
+	res, err := s.ProbeTCP(ip, port, 0)
+	return res.State, err
+}
+
+// ProbeTCP sends one SYN and classifies the reply with its reason and latency,
+// waiting up to timeout (0 means the scanner's timeout).
+func (s *SYNScanner) ProbeTCP(ip string, port int, timeout time.Duration) (TCPProbeResult, error) {
+	results, err := s.probe(ip, []int{port}, timeout)
+	if err != nil {
+		return TCPProbeResult{State: PortFiltered}, err
+	}
+	return results[port], nil
+}
+
+// ProbePorts sends a SYN to every port and waits for replies until the timeout.
+// Ports without a reply are reported as PortFiltered.
+func (s *SYNScanner) ProbePorts(ip string, ports []int) (map[int]PortState, error) {
+	results, err := s.probe(ip, ports, s.timeout)
+	if err != nil {
+		return nil, err
+	}
+	states := make(map[int]PortState, len(results))
+	for port, res := range results {
+		states[port] = res.State
+	}
+	return states, nil
+}
+
+// probe sends a SYN to every port and collects the classified replies until timeout;
+// ports without a reply are PortFiltered with ReasonNoResponse.
+func (s *SYNScanner) probe(ip string, ports []int, timeout time.Duration) (map[int]TCPProbeResult, error) {
+	if timeout <= 0 {
+		timeout = s.timeout
+	}
+	dst := net.ParseIP(strings.TrimSpace(ip)).To4()
+	if dst == nil {
+		return nil, fmt.Errorf("syn scanner: only IPv4 targets are supported: %s", ip)
+	}
+	addr, _ := netip.AddrFromSlice(dst)
+
+	s.mu.Lock()
+	err := s.loopErr
+	if s.closed {
+		err = fmt.Errorf("syn scanner is closed")
+	}
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	var hop net.HardwareAddr
+	if s.linkType == layers.LinkTypeEthernet {
+		if hop, err = s.nextHopMAC(dst); err != nil {
+			return nil, fmt.Errorf("syn scanner: next hop for %s: %w", ip, err)
+		}
+	}
+
+	probes := make(map[int]*synProbe, len(ports))
+	owned := make(map[int]bool, len(ports))
+	defer func() {
+		s.mu.Lock()
+		for port, p := range probes {
+			key := synKey{addr: addr, port: uint16(port)}
+			if owned[port] && s.pending[key] == p {
+				delete(s.pending, key)
+			}
+		}
+		s.mu.Unlock()
+	}()
+
+	for _, port := range ports {
+		if port < 1 || port > 65535 {
+			return nil, fmt.Errorf("syn scanner: invalid port %d", port)
+		}
+		if _, dup := probes[port]; dup {
+			continue
+		}
+		key := synKey{addr: addr, port: uint16(port)}
+		s.mu.Lock()
+		p, shared := s.pending[key]
+		if !shared {
+			p = &synProbe{done: make(chan struct{}), sent: time.Now()}
+			s.pending[key] = p
+		}
+		s.mu.Unlock()
+		probes[port] = p
+		if shared {
+			// Такая же проба уже в полёте — ждём её ответ, не отправляя повторный SYN.
+			continue
+		}
+		owned[port] = true
+		if err := s.sendSYN(dst, hop, uint16(port)); err != nil {
+			return nil, err
+		}
+	}
+
+	results := make(map[int]TCPProbeResult, len(probes))
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	for port, p := range probes {
+		select {
+		case <-p.done:
+			results[port] = p.result
+		case <-deadline.C:
+			// Таймер истёк: оставшиеся пробы без ответа считаются filtered.
+			for port, p := range probes {
+				if _, ok := results[port]; ok {
+					continue
+				}
+				select {
+				case <-p.done:
+					results[port] = p.result
+				default:
+					results[port] = TCPProbeResult{State: PortFiltered, Reason: ReasonNoResponse}
+				}
+			}
+			return results, nil
+		}
+	}
+	return results, nil
+}
+
+func (s *SYNScanner) sendSYN(dst net.IP, hop net.HardwareAddr, dstPort uint16) error {
+	ip := &layers.IPv4{
+		Version:  4,
+		TTL:      64,
+		Id:       uint16(rand.Uint32()),
+		Protocol: layers.IPProtocolTCP,
+		SrcIP:    s.srcIP,
+		DstIP:    dst,
+	}
+	tcp := &layers.TCP{
+		SrcPort: layers.TCPPort(s.srcPort),
+		DstPort: layers.TCPPort(dstPort),
+		Seq:     s.seq,
+		SYN:     true,
+		Window:  synWindowSize,
+	}
+	if err := tcp.SetNetworkLayerForChecksum(ip); err != nil {
+		return fmt.Errorf("syn scanner: %w", err)
+	}
+
+	var link gopacket.SerializableLayer
+	switch s.linkType {
+	case layers.LinkTypeEthernet:
+		link = &layers.Ethernet{SrcMAC: s.srcMAC, DstMAC: hop, EthernetType: layers.EthernetTypeIPv4}
+	case layers.LinkTypeNull, layers.LinkTypeLoop:
+		link = &layers.Loopback{Family: layers.ProtocolFamilyIPv4}
+	}
+	buf := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	var err error
+	if link != nil {
+		err = gopacket.SerializeLayers(buf, opts, link, ip, tcp)
+	} else {
+		err = gopacket.SerializeLayers(buf, opts, ip, tcp)
+	}
+	if err != nil {
+		return fmt.Errorf("syn scanner: build packet: %w", err)
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if err := s.handle.WritePacketData(buf.Bytes()); err != nil {
+		return fmt.Errorf("syn scanner: send: %w", err)
+	}
+	return nil
+}
+
+// receiveLoop читает все пакеты с хэндла и завершает ожидающие пробы.
+// Таймаут чтения — штатная ситуация; io.EOF (pcap-файл) и Close завершают цикл,
+// прочие ошибки запоминаются, и последующие пробы возвращают ошибку.
+func (s *SYNScanner) receiveLoop() {
+	defer close(s.loopDone)
+	for {
+		data, _, err := s.handle.ReadPacketData()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			switch {
+			case closed || errors.Is(err, io.EOF):
+				return
+			case err == pcap.NextErrorTimeoutExpired:
+				continue
+			default:
+				s.mu.Lock()
+				s.loopErr = fmt.Errorf("syn scanner: receive: %w", err)
+				s.mu.Unlock()
+				return
+			}
+		}
+		s.handlePacket(data)
+	}
+}
+
+func (s *SYNScanner) handlePacket(data []byte) {
+	packet := gopacket.NewPacket(data, s.linkType, gopacket.DecodeOptions{Lazy: true, NoCopy: true})
+	ip, _ := packet.Layer(layers.LayerTypeIPv4).(*layers.IPv4)
+	if ip == nil {
+		return
+	}
+	if icmp, ok := packet.Layer(layers.LayerTypeICMPv4).(*layers.ICMPv4); ok {
+		s.handleUnreachable(icmp)
+		return
+	}
+	tcp, _ := packet.Layer(layers.LayerTypeTCP).(*layers.TCP)
+	if tcp == nil || uint16(tcp.DstPort) != s.srcPort {
+		return
+	}
+	if tcp.ACK && tcp.Ack != s.seq+1 {
+		return
+	}
+	var res TCPProbeResult
+	switch {
+	case tcp.SYN && tcp.ACK:
+		res = TCPProbeResult{State: PortOpen, Reason: ReasonSynAck}
+	case tcp.RST:
+		res = TCPProbeResult{State: PortClosed, Reason: ReasonReset}
+	default:
+		return
+	}
+	addr, ok := netip.AddrFromSlice(ip.SrcIP)
+	if !ok {
+		return
+	}
+	s.complete(synKey{addr: addr.Unmap(), port: uint16(tcp.SrcPort)}, res)
+}
+
+// handleUnreachable завершает пробу, на которую пришёл ICMP destination unreachable:
+// порт filtered с причиной по коду ICMP. Проба определяется по заголовкам исходного
+// SYN, процитированным в ICMP (IPv4-заголовок и первые 8 байт TCP).
+func (s *SYNScanner) handleUnreachable(icmp *layers.ICMPv4) {
+	if icmp.TypeCode.Type() != layers.ICMPv4TypeDestinationUnreachable {
+		return
+	}
+	orig := icmp.Payload
+	if len(orig) < 20 || orig[9] != uint8(layers.IPProtocolTCP) {
+		return
+	}
+	ihl := int(orig[0]&0x0f) * 4
+	if ihl < 20 || len(orig) < ihl+4 || binary.BigEndian.Uint16(orig[ihl:]) != s.srcPort {
+		return
+	}
+	addr, _ := netip.AddrFromSlice(orig[16:20])
+	res := TCPProbeResult{State: PortFiltered, Reason: ReasonHostUnreachable}
+	switch icmp.TypeCode.Code() {
+	case layers.ICMPv4CodeNet, layers.ICMPv4CodeNetUnknown:
+		res.Reason = ReasonNetUnreachable
+	case layers.ICMPv4CodePort:
+		res.Reason = ReasonPortUnreachable
+	case layers.ICMPv4CodeNetAdminProhibited, layers.ICMPv4CodeHostAdminProhibited, layers.ICMPv4CodeCommAdminProhibited:
+		res.Reason = ReasonAdminProhibited
+	}
+	s.complete(synKey{addr: addr, port: binary.BigEndian.Uint16(orig[ihl+2:])}, res)
+}
+
+// complete передаёт результат ожидающей пробе key, если она есть.
+func (s *SYNScanner) complete(key synKey, res TCPProbeResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if p, ok := s.pending[key]; ok {
+		res.Latency = time.Since(p.sent)
+		p.result = res
+		close(p.done)
+		delete(s.pending, key)
+	}
+}
+
+// routeInterface находит интерфейс и адрес, через которые ОС отправляет пакеты к target;
+// если local задан, — интерфейс, которому назначен local.
+func routeInterface(target, local net.IP) (*net.Interface, *net.IPNet, error) {
+	if local == nil {
+		conn, err := net.Dial("udp4", net.JoinHostPort(target.String(), "9"))
+		if err != nil {
+			return nil, nil, fmt.Errorf("syn scanner: no route to %s: %w", target, err)
+		}
+		local = conn.LocalAddr().(*net.UDPAddr).IP
+		_ = conn.Close()
+	}
+
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return nil, nil, fmt.Errorf("syn scanner: list interfaces: %w", err)
+	}
+	for i := range interfaces {
+		addrs, err := interfaces[i].Addrs()
+		if err != nil {
+			continue
+		}
+		for _, a := range addrs {
+			if ipnet, ok := a.(*net.IPNet); ok && ipnet.IP.Equal(local) {
+				return &interfaces[i], &net.IPNet{IP: local.To4(), Mask: ipnet.Mask}, nil
+			}
+		}
+	}
+	return nil, nil, fmt.Errorf("syn scanner: interface for %s not found", local)
+}
+
+// nextHopResolver определяет MAC следующего узла: самого хоста в локальном сегменте
+// или шлюза по умолчанию. Адреса берутся из ARP-таблицы ОС; отсутствующую запись
+// провоцируем UDP-датаграммой, после которой ядро само выполняет ARP-запрос.
+type nextHopResolver struct {
+	iface *net.Interface
+	ipnet *net.IPNet
+
+	mu      sync.Mutex
+	cache   map[string]net.HardwareAddr
+	gateway net.IP
+}
+
+func (r *nextHopResolver) Resolve(dst net.IP) (net.HardwareAddr, error) {
+	if r.iface.Flags&net.FlagLoopback != 0 || len(r.iface.HardwareAddr) == 0 {
+		// loopback и point-to-point: адресов канального уровня нет, кадр с нулевыми MAC
+		return make(net.HardwareAddr, 6), nil
+	}
+	hop := dst
+	if !r.ipnet.Contains(dst) {
+		gw, err := r.defaultGateway()
+		if err != nil {
+			return nil, err
+		}
+		hop = gw
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if mac, ok := r.cache[hop.String()]; ok {
+		return mac, nil
+	}
+	mac, err := lookupARP(hop)
+	if err != nil {
+		if conn, dialErr := net.Dial("udp4", net.JoinHostPort(hop.String(), "9")); dialErr == nil {
+			_, _ = conn.Write([]byte{0})
+			_ = conn.Close()
+		}
+		time.Sleep(nextHopARPTimeout)
+		if mac, err = lookupARP(hop); err != nil {
+			return nil, err
+		}
+	}
+	r.cache[hop.String()] = mac
+	return mac, nil
+}
+
+func (r *nextHopResolver) defaultGateway() (net.IP, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.gateway != nil {
+		return r.gateway, nil
+	}
+	if runtime.GOOS != "linux" {
+		return nil, fmt.Errorf("default gateway lookup is not supported on %s", runtime.GOOS)
+	}
+	f, err := os.Open("/proc/net/route")
+	if err != nil {
+		return nil, fmt.Errorf("read routing table: %w", err)
+	}
+	defer f.Close()
+	gw, err := parseLinuxDefaultGateway(f, r.iface.Name)
+	if err != nil {
+		return nil, err
+	}
+	r.gateway = gw
+	return gw, nil
+}
+
+// parseLinuxDefaultGateway извлекает шлюз маршрута по умолчанию для iface из /proc/net/route.
+func parseLinuxDefaultGateway(rd io.Reader, iface string) (net.IP, error) {
+	gateways, err := parseLinuxDefaultGateways(rd)
+	if err != nil {
+		return nil, err
+	}
+	for _, gw := range gateways {
+		if gw.Interface == iface {
+			return gw.Gateway, nil
+		}
+	}
+	return nil, fmt.Errorf("default gateway for %s not found", iface)
+}
+
+func lookupARP(ip net.IP) (net.HardwareAddr, error) {
+	table, err := GetARPTabale()
+	if err != nil {
+		return nil, err
+	}
+	raw, ok := table[ip.String()]
+	if !ok {
+		return nil, fmt.Errorf("MAC for %s not found in ARP table", ip)
+	}
+	return net.ParseMAC(raw)
+}
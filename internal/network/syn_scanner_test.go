@@ -0,0 +1,235 @@
+package network
+
+import (
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// fakeHandle имитирует pcap-хэндл: на каждый отправленный SYN отвечает по таблице replies
+// (порт -> "synack" | "rst" | "unreach" | "prohibited" — ICMP host unreachable или
+// administratively prohibited); порты без записи молчат (filtered).
+type fakeHandle struct {
+	replies map[uint16]string
+	packets chan []byte
+
+	mu      sync.Mutex
+	sent    int
+	closed  bool
+	readErr error
+}
+
+func newFakeHandle(replies map[uint16]string) *fakeHandle {
+	return &fakeHandle{replies: replies, packets: make(chan []byte, 64)}
+}
+
+func (h *fakeHandle) LinkType() layers.LinkType { return layers.LinkTypeEthernet }
+
+func (h *fakeHandle) ReadPacketData() ([]byte, gopacket.CaptureInfo, error) {
+	h.mu.Lock()
+	err := h.readErr
+	h.mu.Unlock()
+	if err != nil {
+		return nil, gopacket.CaptureInfo{}, err
+	}
+	data, ok := <-h.packets
+	if !ok {
+		return nil, gopacket.CaptureInfo{}, io.EOF
+	}
+	return data, gopacket.CaptureInfo{CaptureLength: len(data), Length: len(data)}, nil
+}
+
+func (h *fakeHandle) WritePacketData(data []byte) error {
+	h.mu.Lock()
+	h.sent++
+	h.mu.Unlock()
+
+	packet := gopacket.NewPacket(data, layers.LinkTypeEthernet, gopacket.Default)
+	eth, _ := packet.Layer(layers.LayerTypeEthernet).(*layers.Ethernet)
+	ip, _ := packet.Layer(layers.LayerTypeIPv4).(*layers.IPv4)
+	tcp, _ := packet.Layer(layers.LayerTypeTCP).(*layers.TCP)
+	if eth == nil || ip == nil || tcp == nil || !tcp.SYN {
+		return errors.New("fake handle: not a SYN")
+	}
+	kind, ok := h.replies[uint16(tcp.DstPort)]
+	if !ok {
+		return nil
+	}
+	replyEth := &layers.Ethernet{SrcMAC: eth.DstMAC, DstMAC: eth.SrcMAC, EthernetType: layers.EthernetTypeIPv4}
+	buf := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	if kind == "unreach" || kind == "prohibited" {
+		code := uint8(layers.ICMPv4CodeHost)
+		if kind == "prohibited" {
+			code = layers.ICMPv4CodeCommAdminProhibited
+		}
+		// ICMP цитирует IPv4-заголовок и первые 8 байт TCP исходного SYN
+		quoted := append([]byte(nil), data[14:14+20+8]...)
+		icmpIP := &layers.IPv4{Version: 4, TTL: 64, Protocol: layers.IPProtocolICMPv4, SrcIP: net.IPv4(192, 0, 2, 1), DstIP: ip.SrcIP}
+		icmp := &layers.ICMPv4{TypeCode: layers.CreateICMPv4TypeCode(layers.ICMPv4TypeDestinationUnreachable, code)}
+		if err := gopacket.SerializeLayers(buf, opts, replyEth, icmpIP, icmp, gopacket.Payload(quoted)); err != nil {
+			return err
+		}
+		h.packets <- buf.Bytes()
+		return nil
+	}
+	reply := &layers.TCP{
+		SrcPort: tcp.DstPort,
+		DstPort: tcp.SrcPort,
+		Seq:     1000,
+		Ack:     tcp.Seq + 1,
+		ACK:     true,
+		SYN:     kind == "synack",
+		RST:     kind == "rst",
+	}
+	replyIP := &layers.IPv4{Version: 4, TTL: 64, Protocol: layers.IPProtocolTCP, SrcIP: ip.DstIP, DstIP: ip.SrcIP}
+	_ = reply.SetNetworkLayerForChecksum(replyIP)
+	if err := gopacket.SerializeLayers(buf, opts, replyEth, replyIP, reply); err != nil {
+		return err
+	}
+	h.packets <- buf.Bytes()
+	return nil
+}
+
+func (h *fakeHandle) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.closed {
+		h.closed = true
+		close(h.packets)
+	}
+}
+
+func newTestSYNScanner(t *testing.T, h PacketHandle) *SYNScanner {
+	t.Helper()
+	s, err := NewSYNScanner(SYNScannerConfig{
+		Handle: h,
+		SrcIP:  net.ParseIP("192.0.2.10"),
+		SrcMAC: net.HardwareAddr{0x02, 0, 0, 0, 0, 0x10},
+		NextHopMAC: func(dst net.IP) (net.HardwareAddr, error) {
+			return net.HardwareAddr{0x02, 0, 0, 0, 0, 0x01}, nil
+		},
+		Timeout: 200 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewSYNScanner() error = %v", err)
+	}
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestSYNScanner_ClassifiesReplies(t *testing.T) {
+	h := newFakeHandle(map[uint16]string{22: "synack", 80: "synack", 81: "rst"})
+	s := newTestSYNScanner(t, h)
+
+	states, err := s.ProbePorts("192.0.2.20", []int{22, 80, 81, 82})
+	if err != nil {
+		t.Fatalf("ProbePorts() error = %v", err)
+	}
+	want := map[int]PortState{22: PortOpen, 80: PortOpen, 81: PortClosed, 82: PortFiltered}
+	for port, st := range want {
+		if states[port] != st {
+			t.Errorf("port %d: state = %s, want %s", port, states[port], st)
+		}
+	}
+
+	open, err := s.ScanPorts("192.0.2.20", []int{22, 81, 82}, "tcp")
+	if err != nil {
+		t.Fatalf("ScanPorts() error = %v", err)
+	}
+	if len(open) != 1 || open[0] != 22 {
+		t.Errorf("ScanPorts() = %v, want [22]", open)
+	}
+	if _, err := s.ScanPort("192.0.2.20", 53, "udp"); err == nil {
+		t.Error("ScanPort(udp) ожидалась ошибка")
+	}
+}
+
+func TestSYNScanner_ProbeTCPReasons(t *testing.T) {
+	h := newFakeHandle(map[uint16]string{22: "synack", 81: "rst", 82: "unreach", 83: "prohibited"})
+	s := newTestSYNScanner(t, h)
+
+	want := map[int]TCPProbeResult{
+		22: {State: PortOpen, Reason: ReasonSynAck},
+		81: {State: PortClosed, Reason: ReasonReset},
+		82: {State: PortFiltered, Reason: ReasonHostUnreachable},
+		83: {State: PortFiltered, Reason: ReasonAdminProhibited},
+		84: {State: PortFiltered, Reason: ReasonNoResponse},
+	}
+	for port, w := range want {
+		res, err := s.ProbeTCP("192.0.2.20", port, 100*time.Millisecond)
+		if err != nil {
+			t.Fatalf("ProbeTCP(%d) error = %v", port, err)
+		}
+		if res.State != w.State || res.Reason != w.Reason {
+			t.Errorf("port %d: %s/%s, want %s/%s", port, res.State, res.Reason, w.State, w.Reason)
+		}
+		if (res.Reason == ReasonNoResponse) != (res.Latency == 0) {
+			t.Errorf("port %d: Latency = %v", port, res.Latency)
+		}
+	}
+}
+
+func TestSYNScanner_ConcurrentProbesShareReceiveLoop(t *testing.T) {
+	h := newFakeHandle(map[uint16]string{443: "synack"})
+	s := newTestSYNScanner(t, h)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			state, err := s.Probe("192.0.2.30", 443)
+			if err != nil || state != PortOpen {
+				errs <- errors.New("unexpected result")
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestSYNScanner_ReceiveErrorFailsProbes(t *testing.T) {
+	h := newFakeHandle(nil)
+	h.readErr = errors.New("device gone")
+	s := newTestSYNScanner(t, h)
+	<-s.loopDone
+
+	if _, err := s.Probe("192.0.2.20", 80); err == nil {
+		t.Fatal("Probe() ожидалась ошибка после сбоя цикла приёма")
+	}
+}
+
+func TestSYNScanner_RejectsIPv6(t *testing.T) {
+	s := newTestSYNScanner(t, newFakeHandle(nil))
+	if _, err := s.Probe("2001:db8::1", 80); err == nil {
+		t.Error("Probe(IPv6) ожидалась ошибка")
+	}
+}
+
+func TestParseLinuxDefaultGateway(t *testing.T) {
+	table := "Iface\tDestination\tGateway \tFlags\tRefCnt\tUse\tMetric\tMask\n" +
+		"eth0\t00000000\t0101A8C0\t0003\t0\t0\t100\t00000000\n" +
+		"eth0\t0001A8C0\t00000000\t0001\t0\t0\t100\t00FFFFFF\n"
+	gw, err := parseLinuxDefaultGateway(strings.NewReader(table), "eth0")
+	if err != nil {
+		t.Fatalf("parseLinuxDefaultGateway() error = %v", err)
+	}
+	if gw.String() != "192.168.1.1" {
+		t.Errorf("gateway = %s, want 192.168.1.1", gw)
+	}
+	if _, err := parseLinuxDefaultGateway(strings.NewReader(table), "wlan0"); err == nil {
+		t.Error("ожидалась ошибка для интерфейса без маршрута по умолчанию")
+	}
+}
@@ -0,0 +1,445 @@
+package network
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/netip"
+	"sort"
+	"strings"
+)
+
+const (
+	// maxCIDRHostBits ограничивает размер одной цели-CIDR (IPv4 — любая сеть, IPv6 — /96 и уже):
+	// больше 2^32 адресов за разумное время не просканировать.
+	maxCIDRHostBits = 32
+	// maxIteratorAddrs ограничивает суммарную длину нумерации адресов TargetIterator.
+	maxIteratorAddrs = 1 << 62
+)
+
+// addrInterval — включительный диапазон адресов одного семейства.
+type addrInterval struct {
+	start, end netip.Addr
+}
+
+func (r addrInterval) contains(addr netip.Addr) bool {
+	return addr.Is4() == r.start.Is4() && !addr.Less(r.start) && !r.end.Less(addr)
+}
+
+func (r addrInterval) overlaps(o addrInterval) bool {
+	return r.start.Is4() == o.start.Is4() && !r.end.Less(o.start) && !o.end.Less(r.start)
+}
+
+// targetSegment — цель, развёрнутая в диапазон адресов, и её место в общей нумерации.
+type targetSegment struct {
+	addrInterval
+	size    uint64
+	offset  uint64 // номер первого адреса сегмента в нумерации итератора
+	earlier []int  // предыдущие сегменты-диапазоны, пересекающиеся с этим (для отбрасывания повторов)
+}
+
+// TargetIterator перебирает адреса целей (CIDR, диапазоны, IP, имена хостов) по одному,
+// не раскрывая диапазоны в память: цели хранятся как границы, повторы и исключённые адреса
+// пропускаются на лету. Память — O(числа целей) независимо от размера диапазонов.
+//
+// Каждый адрес выдаётся один раз. По умолчанию порядок — порядок целей; Shuffle задаёт
+// псевдослучайную перестановку (детерминированную для seed), которая распределяет пробы
+// по подсетям. Position/Seek позволяют продолжить перебор с сохранённой позиции.
+// Итератор не потокобезопасен.
+type TargetIterator struct {
+	segments   []targetSegment
+	ranges     []int              // индексы сегментов из нескольких адресов
+	singles    map[netip.Addr]int // одиночный адрес -> первый сегмент с ним
+	zones      map[string]string  // link-local IP -> зона (интерфейс), с которой он указан в целях
+	total      uint64             // длина нумерации (с повторами)
+	hostnames  map[string]string
+	unresolved []string
+	exclude    *TargetMatcher
+	perm       *permutation
+	pos        uint64
+	count      int
+	excluded   int
+}
+
+// NewTargetIterator разбирает цели в формате ExpandTargets. Имена хостов разрешаются
+// через resolver (nil — net.DefaultResolver) сразу; неразрешённые имена не считаются
+// ошибкой и доступны через Unresolved. Отмена ctx прерывает разрешение имён.
+func NewTargetIterator(ctx context.Context, specs []string, resolver *net.Resolver) (*TargetIterator, error) {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	it := &TargetIterator{
+		singles:   make(map[netip.Addr]int),
+		zones:     make(map[string]string),
+		hostnames: make(map[string]string),
+	}
+	count := 0
+	for _, raw := range specs {
+		spec := strings.TrimSpace(raw)
+		if spec == "" {
+			continue
+		}
+		count++
+		kind, err := classifyTarget(spec)
+		if err != nil {
+			return nil, fmt.Errorf("цель %q: %w", spec, err)
+		}
+		var r addrInterval
+		switch kind {
+		case targetCIDR, targetRange:
+			r, err = targetBounds(kind, spec)
+			if err != nil {
+				return nil, fmt.Errorf("цель %q: %w", spec, err)
+			}
+		case targetIP:
+			addr := netip.MustParseAddr(spec).Unmap()
+			if zone := addr.Zone(); zone != "" {
+				addr = addr.WithZone("")
+				if _, ok := it.zones[addr.String()]; !ok {
+					it.zones[addr.String()] = zone
+				}
+			}
+			r = addrInterval{addr, addr}
+		case targetNeighbors:
+			return nil, fmt.Errorf("цель %q: IPv6-сеть не перебирается по адресам, её хосты находит обнаружение соседей (NDP)", spec)
+		case targetHostname:
+			addrs, err := resolver.LookupNetIP(ctx, "ip", spec)
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if err != nil || len(addrs) == 0 {
+				it.unresolved = append(it.unresolved, spec)
+				continue
+			}
+			addr := preferIPv4(addrs).Unmap().WithZone("")
+			if _, ok := it.hostnames[addr.String()]; !ok {
+				it.hostnames[addr.String()] = spec
+			}
+			r = addrInterval{addr, addr}
+		}
+		if err := it.add(r); err != nil {
+			return nil, fmt.Errorf("цель %q: %w", spec, err)
+		}
+	}
+	if count == 0 {
+		return nil, fmt.Errorf("не задано ни одной цели")
+	}
+	it.recount()
+	return it, nil
+}
+
+func (it *TargetIterator) add(r addrInterval) error {
+	size, ok := rangeSize(r.start, r.end)
+	if !ok || size > maxIteratorAddrs-it.total {
+		return fmt.Errorf("слишком много адресов в целях")
+	}
+	seg := targetSegment{addrInterval: r, size: size, offset: it.total}
+	idx := len(it.segments)
+	for _, j := range it.ranges {
+		if it.segments[j].overlaps(r) {
+			seg.earlier = append(seg.earlier, j)
+		}
+	}
+	if size == 1 {
+		if _, ok := it.singles[r.start]; !ok {
+			it.singles[r.start] = idx
+		}
+	} else {
+		it.ranges = append(it.ranges, idx)
+	}
+	it.segments = append(it.segments, seg)
+	it.total += size
+	return nil
+}
+
+// Hostnames возвращает соответствие IP -> имя хоста, под которым адрес указан в целях.
+func (it *TargetIterator) Hostnames() map[string]string { return it.hostnames }
+
+// Zones возвращает соответствие IP -> зона для адресов, указанных в целях с зоной
+// ("fe80::1%eth0"). Next выдаёт адрес без зоны; для соединения её нужно добавить.
+// Один и тот же адрес с разными зонами перебирается один раз, с первой зоной.
+func (it *TargetIterator) Zones() map[string]string { return it.zones }
+
+// Unresolved возвращает имена хостов, которые не удалось разрешить.
+func (it *TargetIterator) Unresolved() []string { return it.unresolved }
+
+// Exclude задаёт исключения: адреса из m (и цели, указанные под исключённым именем)
+// не выдаются. m должен быть готов (ResolveNames) до начала перебора.
+func (it *TargetIterator) Exclude(m *TargetMatcher) {
+	it.exclude = m
+	it.recount()
+}
+
+// Shuffle включает псевдослучайный порядок адресов, одинаковый для одного seed,
+// и начинает перебор сначала.
+func (it *TargetIterator) Shuffle(seed int64) {
+	it.perm = newPermutation(it.total, uint64(seed))
+	it.pos = 0
+}
+
+// Count возвращает точное число адресов, которые выдаст полный перебор:
+// без повторов и исключённых адресов.
+func (it *TargetIterator) Count() int { return it.count }
+
+// Excluded возвращает число адресов целей, отброшенных исключениями.
+func (it *TargetIterator) Excluded() int { return it.excluded }
+
+// Position возвращает позицию перебора; Seek(Position()) продолжает с того же места.
+func (it *TargetIterator) Position() uint64 { return it.pos }
+
+// Seek переставляет перебор на позицию pos (0 — сначала).
+func (it *TargetIterator) Seek(pos uint64) {
+	if pos > it.total {
+		pos = it.total
+	}
+	it.pos = pos
+}
+
+// Next возвращает следующий адрес; ok=false — перебор завершён.
+func (it *TargetIterator) Next() (net.IP, bool) {
+	for it.pos < it.total {
+		idx := it.pos
+		if it.perm != nil {
+			idx = it.perm.at(idx)
+		}
+		it.pos++
+		addr, seg := it.addrAt(idx)
+		if it.duplicate(addr, seg) || it.isExcluded(addr) {
+			continue
+		}
+		return net.IP(addr.AsSlice()), true
+	}
+	return nil, false
+}
+
+// addrAt возвращает адрес с номером idx и индекс его сегмента.
+func (it *TargetIterator) addrAt(idx uint64) (netip.Addr, int) {
+	s := sort.Search(len(it.segments), func(i int) bool {
+		return it.segments[i].offset+it.segments[i].size > idx
+	})
+	seg := it.segments[s]
+	return addrAdd(seg.start, idx-seg.offset), s
+}
+
+// duplicate сообщает, что addr уже выдан одним из предыдущих сегментов.
+func (it *TargetIterator) duplicate(addr netip.Addr, seg int) bool {
+	for _, j := range it.segments[seg].earlier {
+		if it.segments[j].contains(addr) {
+			return true
+		}
+	}
+	first, ok := it.singles[addr]
+	return ok && first < seg
+}
+
+func (it *TargetIterator) isExcluded(addr netip.Addr) bool {
+	if it.exclude.Empty() {
+		return false
+	}
+	if it.exclude.containsAddr(addr) {
+		return true
+	}
+	if len(it.exclude.names) == 0 || len(it.hostnames) == 0 {
+		return false
+	}
+	name, ok := it.hostnames[addr.String()]
+	return ok && it.exclude.containsName(name)
+}
+
+// recount пересчитывает Count и Excluded по границам целей и исключений без перебора адресов.
+func (it *TargetIterator) recount() {
+	targets := make([]addrInterval, len(it.segments))
+	for i, seg := range it.segments {
+		targets[i] = seg.addrInterval
+	}
+	targets = mergeIntervals(targets)
+	unique := intervalsSize(targets)
+
+	var excluded uint64
+	if !it.exclude.Empty() {
+		excl := it.exclude.intervals()
+		for ip, name := range it.hostnames {
+			if it.exclude.containsName(name) {
+				addr := netip.MustParseAddr(ip)
+				excl = append(excl, addrInterval{addr, addr})
+			}
+		}
+		excluded = intersectionSize(targets, mergeIntervals(excl))
+	}
+	it.count = int(unique - excluded)
+	it.excluded = int(excluded)
+}
+
+// targetBounds возвращает границы цели-CIDR или диапазона. В IPv4-сетях шире /31
+// адрес сети и broadcast не сканируются.
+func targetBounds(kind targetKind, spec string) (addrInterval, error) {
+	if kind == targetRange {
+		start, end, err := parseRangeBounds(spec)
+		if err != nil {
+			return addrInterval{}, err
+		}
+		return addrInterval{start.WithZone(""), end.WithZone("")}, nil
+	}
+	prefix, err := netip.ParsePrefix(strings.TrimSpace(spec))
+	if err != nil {
+		return addrInterval{}, fmt.Errorf("некорректный CIDR: %w", err)
+	}
+	if prefix.Addr().Is4In6() {
+		prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+	}
+	hostBits := prefix.Addr().BitLen() - prefix.Bits()
+	if hostBits > maxCIDRHostBits {
+		return addrInterval{}, fmt.Errorf("слишком большой диапазон %s: больше 2^%d адресов", spec, maxCIDRHostBits)
+	}
+	r := prefixInterval(prefix)
+	if prefix.Addr().Is4() && hostBits >= 2 {
+		r.start = r.start.Next()
+		r.end = r.end.Prev()
+	}
+	return r, nil
+}
+
+// prefixInterval возвращает первый и последний адрес сети.
+func prefixInterval(prefix netip.Prefix) addrInterval {
+	prefix = prefix.Masked()
+	first := prefix.Addr()
+	b := first.As16()
+	hostBits := first.BitLen() - prefix.Bits()
+	for i := 15; i >= 0 && hostBits > 0; i-- {
+		n := hostBits
+		if n > 8 {
+			n = 8
+		}
+		b[i] |= byte(1<<n - 1)
+		hostBits -= n
+	}
+	last := netip.AddrFrom16(b)
+	if first.Is4() {
+		last = last.Unmap()
+	}
+	return addrInterval{first, last}
+}
+
+// addrAdd возвращает адрес, больший addr на n (без переполнения семейства — n в пределах сегмента).
+func addrAdd(addr netip.Addr, n uint64) netip.Addr {
+	if addr.Is4() {
+		b := addr.As4()
+		binary.BigEndian.PutUint32(b[:], binary.BigEndian.Uint32(b[:])+uint32(n))
+		return netip.AddrFrom4(b)
+	}
+	b := addr.As16()
+	hi, lo := binary.BigEndian.Uint64(b[:8]), binary.BigEndian.Uint64(b[8:])
+	if lo+n < lo {
+		hi++
+	}
+	lo += n
+	binary.BigEndian.PutUint64(b[:8], hi)
+	binary.BigEndian.PutUint64(b[8:], lo)
+	return netip.AddrFrom16(b)
+}
+
+// mergeIntervals сортирует диапазоны и объединяет пересекающиеся и смежные.
+func mergeIntervals(rs []addrInterval) []addrInterval {
+	if len(rs) == 0 {
+		return nil
+	}
+	sort.Slice(rs, func(i, j int) bool { return rs[i].start.Less(rs[j].start) })
+	out := []addrInterval{rs[0]}
+	for _, r := range rs[1:] {
+		last := &out[len(out)-1]
+		if last.overlaps(r) || last.end.Next() == r.start {
+			if last.end.Less(r.end) {
+				last.end = r.end
+			}
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// intervalsSize возвращает число адресов в непересекающихся диапазонах.
+func intervalsSize(rs []addrInterval) uint64 {
+	var n uint64
+	for _, r := range rs {
+		size, _ := rangeSize(r.start, r.end)
+		n += size
+	}
+	return n
+}
+
+// intersectionSize возвращает число адресов, общих для двух наборов
+// отсортированных непересекающихся диапазонов.
+func intersectionSize(a, b []addrInterval) uint64 {
+	var n uint64
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		if a[i].overlaps(b[j]) {
+			lo, hi := a[i].start, a[i].end
+			if lo.Less(b[j].start) {
+				lo = b[j].start
+			}
+			if b[j].end.Less(hi) {
+				hi = b[j].end
+			}
+			size, _ := rangeSize(lo, hi)
+			n += size
+		}
+		if a[i].end.Less(b[j].end) {
+			i++
+		} else {
+			j++
+		}
+	}
+	return n
+}
+
+// permutation — псевдослучайная биекция [0, n): сеть Фейстеля на 2·half бит
+// с «обходом цикла» для номеров за пределами n.
+type permutation struct {
+	n    uint64
+	half uint
+	mask uint64
+	keys [4]uint64
+}
+
+func newPermutation(n, seed uint64) *permutation {
+	p := &permutation{n: n, half: 1}
+	for p.half < 32 && uint64(1)<<(2*p.half) < n {
+		p.half++
+	}
+	p.mask = 1<<p.half - 1
+	for i := range p.keys {
+		seed = splitmix64(seed)
+		p.keys[i] = seed
+	}
+	return p
+}
+
+func (p *permutation) at(i uint64) uint64 {
+	if p.n <= 1 {
+		return i
+	}
+	for {
+		i = p.encrypt(i)
+		if i < p.n {
+			return i
+		}
+	}
+}
+
+func (p *permutation) encrypt(x uint64) uint64 {
+	l, r := x>>p.half, x&p.mask
+	for _, k := range p.keys {
+		l, r = r, l^(splitmix64(r^k)&p.mask)
+	}
+	return l<<p.half | r
+}
+
+// splitmix64 — перемешивающая функция генератора SplitMix64.
+func splitmix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ x>>30) * 0xbf58476d1ce4e5b9
+	x = (x ^ x>>27) * 0x94d049bb133111eb
+	return x ^ x>>31
+}
@@ -0,0 +1,132 @@
+package network
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+)
+
+// drain возвращает все оставшиеся адреса итератора.
+func drain(it *TargetIterator) []string {
+	var out []string
+	for ip, ok := it.Next(); ok; ip, ok = it.Next() {
+		out = append(out, ip.String())
+	}
+	return out
+}
+
+func TestTargetIterator_OrderDedupAndExclusions(t *testing.T) {
+	specs := []string{"192.168.1.0/29", "192.168.1.5-9", "192.168.1.2", "10.0.0.1/32", "localhost"}
+	it, err := NewTargetIterator(context.Background(), specs, offlineResolver)
+	if err != nil {
+		t.Fatalf("NewTargetIterator() error = %v", err)
+	}
+	if it.Count() != 11 {
+		t.Errorf("Count() = %d, want 11", it.Count())
+	}
+
+	m, err := NewTargetMatcher([]string{"192.168.1.4-6", "10.0.0.0/8", "localhost"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.ResolveNames(context.Background(), offlineResolver); err != nil {
+		t.Fatal(err)
+	}
+	it.Exclude(m)
+	want := []string{"192.168.1.1", "192.168.1.2", "192.168.1.3", "192.168.1.7", "192.168.1.8", "192.168.1.9"}
+	if got := drain(it); !reflect.DeepEqual(got, want) {
+		t.Errorf("Next() = %v, want %v", got, want)
+	}
+	if it.Count() != len(want) || it.Excluded() != 5 {
+		t.Errorf("Count/Excluded = %d/%d, want %d/5", it.Count(), it.Excluded(), len(want))
+	}
+}
+
+func TestTargetIterator_ShuffleAndSeek(t *testing.T) {
+	specs := []string{"10.1.0.0/24", "10.1.0.100-10.1.1.20", "10.2.0.7"}
+	seq, err := NewTargetIterator(context.Background(), specs, offlineResolver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ordered := drain(seq)
+
+	shuffled := func() *TargetIterator {
+		it, err := NewTargetIterator(context.Background(), specs, offlineResolver)
+		if err != nil {
+			t.Fatal(err)
+		}
+		it.Shuffle(42)
+		return it
+	}
+	it := shuffled()
+	got := drain(it)
+	if len(got) != len(ordered) || len(got) != it.Count() {
+		t.Fatalf("перемешанный перебор выдал %d адресов, want %d", len(got), len(ordered))
+	}
+	seen := make(map[string]bool)
+	for _, ip := range got {
+		if seen[ip] {
+			t.Fatalf("адрес %s выдан повторно", ip)
+		}
+		seen[ip] = true
+	}
+	for _, ip := range ordered {
+		if !seen[ip] {
+			t.Fatalf("адрес %s пропущен", ip)
+		}
+	}
+	if reflect.DeepEqual(got, ordered) {
+		t.Error("Shuffle не изменил порядок")
+	}
+
+	// Продолжение с сохранённой позиции выдаёт оставшуюся часть той же перестановки
+	first := shuffled()
+	head := []string{}
+	for i := 0; i < 100; i++ {
+		ip, _ := first.Next()
+		head = append(head, ip.String())
+	}
+	rest := shuffled()
+	rest.Seek(first.Position())
+	if all := append(head, drain(rest)...); !reflect.DeepEqual(all, got) {
+		t.Error("Seek(Position()) должен продолжать ту же перестановку")
+	}
+}
+
+func TestTargetIterator_LargeRanges(t *testing.T) {
+	it, err := NewTargetIterator(context.Background(), []string{"10.0.0.0/8", "2001:db8::/96"}, offlineResolver)
+	if err != nil {
+		t.Fatalf("NewTargetIterator() error = %v", err)
+	}
+	if want := 1<<24 - 2 + 1<<32; it.Count() != want {
+		t.Errorf("Count() = %d, want %d", it.Count(), want)
+	}
+	it.Shuffle(7)
+	_, v4, _ := net.ParseCIDR("10.0.0.0/8")
+	_, v6, _ := net.ParseCIDR("2001:db8::/96")
+	for i := 0; i < 1000; i++ {
+		ip, ok := it.Next()
+		if !ok || !(v4.Contains(ip) || v6.Contains(ip)) {
+			t.Fatalf("Next() = %v, %v", ip, ok)
+		}
+	}
+
+	if _, err := NewTargetIterator(context.Background(), []string{"2001:db8::/64"}, offlineResolver); err == nil {
+		t.Error("ожидалась ошибка для IPv6 /64")
+	}
+}
+
+func TestPermutationIsBijection(t *testing.T) {
+	for n := uint64(1); n <= 300; n++ {
+		p := newPermutation(n, n)
+		seen := make([]bool, n)
+		for i := uint64(0); i < n; i++ {
+			v := p.at(i)
+			if v >= n || seen[v] {
+				t.Fatalf("n=%d: at(%d) = %d повторяется или вне диапазона", n, i, v)
+			}
+			seen[v] = true
+		}
+	}
+}
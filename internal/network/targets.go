@@ -0,0 +1,424 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// maxRangeHosts ограничивает размер диапазона вида a.b.c.d-w.x.y.z (не больше IPv4 /8),
+// чтобы опечатка в границе не приводила к генерации миллиардов адресов.
+const maxRangeHosts = 1 << 24
+
+type targetKind int
+
+const (
+	targetCIDR targetKind = iota
+	targetRange
+	targetIP
+	targetHostname
+	targetNeighbors // IPv6-сеть шире /96 или с зоной: адреса ищутся обнаружением соседей
+)
+
+// TargetSet — раскрытый набор целей сканирования.
+type TargetSet struct {
+	IPs        []net.IP          // уникальные адреса в порядке первого появления
+	Hostnames  map[string]string // IP -> имя хоста, под которым адрес указан в целях
+	Unresolved []string          // имена хостов, которые не удалось разрешить
+}
+
+// SplitTargets разбивает строку со списком целей ("10.0.0.0/24, 10.0.1.5-20 nas.local")
+// на отдельные записи. Разделители — запятая, точка с запятой и пробельные символы.
+func SplitTargets(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ';' || unicode.IsSpace(r)
+	})
+}
+
+// ValidateTargets проверяет синтаксис целей без обращения к DNS.
+func ValidateTargets(specs []string) error {
+	for _, spec := range specs {
+		if _, err := classifyTarget(strings.TrimSpace(spec)); err != nil {
+			return fmt.Errorf("цель %q: %w", spec, err)
+		}
+	}
+	return nil
+}
+
+// ExpandTargets раскрывает цели в список адресов без повторов.
+// Поддерживаются CIDR, диапазоны a.b.c.d-e и a.b.c.d-w.x.y.z, отдельные IP и имена хостов.
+// Имена разрешаются через resolver (nil — net.DefaultResolver) в момент вызова;
+// из адресов имени берётся один, IPv4 предпочтительнее. Неразрешённые имена не считаются
+// ошибкой и возвращаются в TargetSet.Unresolved. Отмена ctx прерывает разрешение имён.
+// Для больших диапазонов используйте NewTargetIterator: он не держит адреса в памяти.
+func ExpandTargets(ctx context.Context, specs []string, resolver *net.Resolver) (*TargetSet, error) {
+	it, err := NewTargetIterator(ctx, specs, resolver)
+	if err != nil {
+		return nil, err
+	}
+	set := &TargetSet{
+		IPs:        make([]net.IP, 0, it.Count()),
+		Hostnames:  it.Hostnames(),
+		Unresolved: it.Unresolved(),
+	}
+	for ip, ok := it.Next(); ok; ip, ok = it.Next() {
+		set.IPs = append(set.IPs, ip)
+	}
+	return set, nil
+}
+
+// EstimateTargetCount считает адреса в наборе целей без раскрытия диапазонов
+// и обращения к DNS: пересечения целей учитываются один раз, имя хоста считается
+// за один адрес. IPv6-сети, адреса которых ищутся обнаружением соседей (NeighborTarget),
+// не учитываются: число адресов в них до обнаружения неизвестно.
+func EstimateTargetCount(specs []string) (int, error) {
+	var intervals []addrInterval
+	names := 0
+	for _, raw := range specs {
+		spec := strings.TrimSpace(raw)
+		if spec == "" {
+			continue
+		}
+		kind, err := classifyTarget(spec)
+		if err != nil {
+			return 0, fmt.Errorf("цель %q: %w", spec, err)
+		}
+		switch kind {
+		case targetCIDR, targetRange:
+			r, err := targetBounds(kind, spec)
+			if err != nil {
+				return 0, fmt.Errorf("цель %q: %w", spec, err)
+			}
+			intervals = append(intervals, r)
+		case targetIP:
+			addr := netip.MustParseAddr(spec).Unmap().WithZone("")
+			intervals = append(intervals, addrInterval{addr, addr})
+		case targetHostname:
+			names++
+		}
+	}
+	return int(intervalsSize(mergeIntervals(intervals))) + names, nil
+}
+
+// classifyTarget определяет вид цели и проверяет её синтаксис.
+func classifyTarget(spec string) (targetKind, error) {
+	switch {
+	case spec == "":
+		return 0, fmt.Errorf("пустая цель")
+	case strings.Contains(spec, "/"):
+		prefix, zone, err := parseZonedPrefix(spec)
+		if err != nil {
+			return 0, fmt.Errorf("некорректный CIDR: %w", err)
+		}
+		if prefix.Addr().Is6() && !prefix.Addr().Is4In6() && (zone != "" || prefix.Addr().BitLen()-prefix.Bits() > maxCIDRHostBits) {
+			return targetNeighbors, nil
+		}
+		if zone != "" {
+			return 0, fmt.Errorf("зона указана для IPv4-сети")
+		}
+		return targetCIDR, nil
+	}
+	if _, err := netip.ParseAddr(spec); err == nil {
+		return targetIP, nil
+	}
+	if i := strings.Index(spec, "-"); i > 0 {
+		if _, err := netip.ParseAddr(strings.TrimSpace(spec[:i])); err == nil {
+			if _, _, err := parseRangeBounds(spec); err != nil {
+				return 0, err
+			}
+			return targetRange, nil
+		}
+	}
+	if isHostname(spec) {
+		return targetHostname, nil
+	}
+	return 0, fmt.Errorf("не является IP, CIDR, диапазоном или именем хоста")
+}
+
+// NeighborTarget сообщает, что цель — IPv6-сеть, адреса которой нельзя перебрать
+// (шире /96, например /64) или которая привязана к интерфейсу зоной ("fe80::/64%eth0").
+// Такие цели сканер раскрывает обнаружением соседей (NDPProber): хостами считаются
+// ответившие на multicast echo и записи кэша соседей из prefix (и интерфейса zone).
+func NeighborTarget(spec string) (prefix netip.Prefix, zone string, ok bool) {
+	spec = strings.TrimSpace(spec)
+	if kind, err := classifyTarget(spec); err != nil || kind != targetNeighbors {
+		return netip.Prefix{}, "", false
+	}
+	prefix, zone, _ = parseZonedPrefix(spec)
+	return prefix.Masked(), zone, true
+}
+
+// parseZonedPrefix разбирает CIDR с необязательной зоной: "fe80::/64%eth0" или "fe80::%eth0/64".
+func parseZonedPrefix(spec string) (netip.Prefix, string, error) {
+	zone := ""
+	if i := strings.IndexByte(spec, '%'); i >= 0 {
+		rest := spec[i+1:]
+		if j := strings.IndexByte(rest, '/'); j >= 0 {
+			zone, spec = rest[:j], spec[:i]+rest[j:]
+		} else {
+			zone, spec = rest, spec[:i]
+		}
+		if zone == "" {
+			return netip.Prefix{}, "", fmt.Errorf("пустая зона в %q", spec)
+		}
+	}
+	prefix, err := netip.ParsePrefix(strings.TrimSpace(spec))
+	if err != nil {
+		return netip.Prefix{}, "", err
+	}
+	return prefix, zone, nil
+}
+
+// parseRangeBounds разбирает диапазон "a.b.c.d-e" (e — последний октет), "a.b.c.d-w.x.y.z"
+// или IPv6 "x::a-b" (b — последний hextet в hex) и возвращает включительные границы.
+func parseRangeBounds(rangeStr string) (netip.Addr, netip.Addr, error) {
+	parts := strings.SplitN(rangeStr, "-", 2)
+	if len(parts) != 2 {
+		return netip.Addr{}, netip.Addr{}, fmt.Errorf("invalid IP range format: %s", rangeStr)
+	}
+	start, err := netip.ParseAddr(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return netip.Addr{}, netip.Addr{}, fmt.Errorf("invalid base IP in range: %s", parts[0])
+	}
+	start = start.Unmap()
+
+	endStr := strings.TrimSpace(parts[1])
+	var end netip.Addr
+	switch {
+	case strings.ContainsAny(endStr, ".:"):
+		end, err = netip.ParseAddr(endStr)
+		if err != nil {
+			return netip.Addr{}, netip.Addr{}, fmt.Errorf("invalid end IP in range: %s", endStr)
+		}
+		end = end.Unmap()
+	case start.Is4():
+		n, err := strconv.Atoi(endStr)
+		if err != nil || n < 0 || n > 255 {
+			return netip.Addr{}, netip.Addr{}, fmt.Errorf("invalid end IP in range: %s", endStr)
+		}
+		b := start.As4()
+		b[3] = byte(n)
+		end = netip.AddrFrom4(b)
+	default:
+		n, err := strconv.ParseUint(endStr, 16, 16)
+		if err != nil {
+			return netip.Addr{}, netip.Addr{}, fmt.Errorf("invalid end IP in range: %s", endStr)
+		}
+		b := start.As16()
+		b[14] = byte(n >> 8)
+		b[15] = byte(n)
+		end = netip.AddrFrom16(b).WithZone(start.Zone())
+	}
+
+	if start.Is4() != end.Is4() {
+		return netip.Addr{}, netip.Addr{}, fmt.Errorf("границы диапазона %s из разных семейств адресов", rangeStr)
+	}
+	if end.Less(start) {
+		return netip.Addr{}, netip.Addr{}, fmt.Errorf("конец диапазона %s меньше начала", rangeStr)
+	}
+	if n, ok := rangeSize(start, end); !ok || n > maxRangeHosts {
+		return netip.Addr{}, netip.Addr{}, fmt.Errorf("слишком большой диапазон %s (максимум %d адресов)", rangeStr, maxRangeHosts)
+	}
+	return start, end, nil
+}
+
+// rangeSize возвращает число адресов в диапазоне [start, end]; ok=false, если оно не помещается в uint64.
+func rangeSize(start, end netip.Addr) (uint64, bool) {
+	s, e := start.As16(), end.As16()
+	for i := 0; i < 8; i++ {
+		if s[i] != e[i] {
+			return 0, false
+		}
+	}
+	var lo, hi uint64
+	for i := 8; i < 16; i++ {
+		lo = lo<<8 | uint64(s[i])
+		hi = hi<<8 | uint64(e[i])
+	}
+	if hi-lo == ^uint64(0) {
+		return 0, false
+	}
+	return hi - lo + 1, true
+}
+
+// preferIPv4 выбирает IPv4-адрес из результатов DNS, иначе первый адрес.
+func preferIPv4(addrs []netip.Addr) netip.Addr {
+	for _, a := range addrs {
+		if a.Unmap().Is4() {
+			return a
+		}
+	}
+	return addrs[0]
+}
+
+// isHostname проверяет синтаксис DNS-имени. Имя должно содержать хотя бы одну букву,
+// чтобы некорректные IP вроде 192.168.1.999 не принимались за имена.
+func isHostname(s string) bool {
+	s = strings.TrimSuffix(s, ".")
+	if s == "" || len(s) > 253 {
+		return false
+	}
+	hasLetter := false
+	for _, label := range strings.Split(s, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, r := range label {
+			switch {
+			case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+				hasLetter = true
+			case r >= '0' && r <= '9', r == '-', r == '_':
+			default:
+				return false
+			}
+		}
+	}
+	return hasLetter
+}
+
+// TargetMatcher проверяет принадлежность адреса набору целей без раскрытия диапазонов.
+// Используется для списков исключений: CIDR и диапазоны хранятся как границы,
+// имена хостов сопоставляются по имени и (после ResolveNames) по всем их адресам.
+// Нулевой или nil TargetMatcher не содержит ни одного адреса.
+type TargetMatcher struct {
+	prefixes []netip.Prefix
+	ranges   [][2]netip.Addr
+	addrs    map[netip.Addr]struct{}
+	names    map[string]struct{}
+}
+
+// NewTargetMatcher строит TargetMatcher из целей в формате ExpandTargets.
+func NewTargetMatcher(specs []string) (*TargetMatcher, error) {
+	m := &TargetMatcher{
+		addrs: make(map[netip.Addr]struct{}),
+		names: make(map[string]struct{}),
+	}
+	for _, raw := range specs {
+		spec := strings.TrimSpace(raw)
+		if spec == "" {
+			continue
+		}
+		kind, err := classifyTarget(spec)
+		if err != nil {
+			return nil, fmt.Errorf("цель %q: %w", spec, err)
+		}
+		switch kind {
+		case targetCIDR, targetNeighbors:
+			prefix, _, err := parseZonedPrefix(spec)
+			if err != nil {
+				return nil, fmt.Errorf("цель %q: %w", spec, err)
+			}
+			m.prefixes = append(m.prefixes, prefix.Masked())
+		case targetRange:
+			start, end, err := parseRangeBounds(spec)
+			if err != nil {
+				return nil, fmt.Errorf("цель %q: %w", spec, err)
+			}
+			m.ranges = append(m.ranges, [2]netip.Addr{start, end})
+		case targetIP:
+			m.addrs[netip.MustParseAddr(spec).Unmap().WithZone("")] = struct{}{}
+		case targetHostname:
+			m.names[normalizeHostname(spec)] = struct{}{}
+		}
+	}
+	return m, nil
+}
+
+// ResolveNames разрешает имена хостов из набора и добавляет все их адреса.
+// Возвращает имена, которые не удалось разрешить; отмена ctx прерывает разрешение.
+func (m *TargetMatcher) ResolveNames(ctx context.Context, resolver *net.Resolver) ([]string, error) {
+	if m == nil {
+		return nil, nil
+	}
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	var unresolved []string
+	for name := range m.names {
+		addrs, err := resolver.LookupNetIP(ctx, "ip", name)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err != nil || len(addrs) == 0 {
+			unresolved = append(unresolved, name)
+			continue
+		}
+		for _, a := range addrs {
+			m.addrs[a.Unmap().WithZone("")] = struct{}{}
+		}
+	}
+	return unresolved, nil
+}
+
+// Empty сообщает, что набор не содержит ни одной цели.
+func (m *TargetMatcher) Empty() bool {
+	return m == nil || len(m.prefixes)+len(m.ranges)+len(m.addrs)+len(m.names) == 0
+}
+
+// Contains проверяет, входит ли адрес в набор.
+func (m *TargetMatcher) Contains(ip net.IP) bool {
+	if m.Empty() {
+		return false
+	}
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return false
+	}
+	return m.containsAddr(addr.Unmap())
+}
+
+func (m *TargetMatcher) containsAddr(addr netip.Addr) bool {
+	if _, ok := m.addrs[addr]; ok {
+		return true
+	}
+	for _, p := range m.prefixes {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	for _, r := range m.ranges {
+		if addr.Is4() == r[0].Is4() && !addr.Less(r[0]) && !r[1].Less(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ContainsHost проверяет хост по адресу и, для целей-имён, по имени хоста.
+func (m *TargetMatcher) ContainsHost(ip, hostname string) bool {
+	if m.Empty() {
+		return false
+	}
+	if hostname != "" && m.containsName(hostname) {
+		return true
+	}
+	return m.Contains(net.ParseIP(strings.TrimSpace(ip)))
+}
+
+func (m *TargetMatcher) containsName(hostname string) bool {
+	_, ok := m.names[normalizeHostname(hostname)]
+	return ok
+}
+
+// intervals возвращает адреса набора в виде диапазонов (имена — по разрешённым адресам).
+func (m *TargetMatcher) intervals() []addrInterval {
+	out := make([]addrInterval, 0, len(m.prefixes)+len(m.ranges)+len(m.addrs))
+	for _, p := range m.prefixes {
+		out = append(out, prefixInterval(p))
+	}
+	for _, r := range m.ranges {
+		out = append(out, addrInterval{r[0].WithZone(""), r[1].WithZone("")})
+	}
+	for a := range m.addrs {
+		out = append(out, addrInterval{a, a})
+	}
+	return out
+}
+
+func normalizeHostname(name string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(name), "."))
+}
@@ -0,0 +1,145 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+)
+
+// offlineResolver не обращается к DNS-серверам: имена разрешаются только через hosts-файл.
+var offlineResolver = &net.Resolver{
+	PreferGo: true,
+	Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+		return nil, errors.New("dns disabled in tests")
+	},
+}
+
+func TestSplitTargets(t *testing.T) {
+	got := SplitTargets(" 10.0.0.0/24, 10.0.1.5-20;nas.local\t192.168.1.1 ")
+	want := []string{"10.0.0.0/24", "10.0.1.5-20", "nas.local", "192.168.1.1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SplitTargets() = %v, want %v", got, want)
+	}
+}
+
+func TestExpandTargets_Deduplicates(t *testing.T) {
+	specs := []string{"192.168.1.0/30", "192.168.1.2-4", "192.168.1.1", "10.0.0.1"}
+	set, err := ExpandTargets(context.Background(), specs, offlineResolver)
+	if err != nil {
+		t.Fatalf("ExpandTargets() error = %v", err)
+	}
+
+	want := []string{"192.168.1.1", "192.168.1.2", "192.168.1.3", "192.168.1.4", "10.0.0.1"}
+	got := make([]string, len(set.IPs))
+	for i, ip := range set.IPs {
+		got[i] = ip.String()
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExpandTargets() IPs = %v, want %v", got, want)
+	}
+}
+
+func TestExpandTargets_Hostnames(t *testing.T) {
+	set, err := ExpandTargets(context.Background(), []string{"localhost", "missing-host.invalid"}, offlineResolver)
+	if err != nil {
+		t.Fatalf("ExpandTargets() error = %v", err)
+	}
+	if len(set.IPs) != 1 || !set.IPs[0].IsLoopback() {
+		t.Fatalf("localhost должен разрешиться в loopback, получено %v", set.IPs)
+	}
+	if name := set.Hostnames[set.IPs[0].String()]; name != "localhost" {
+		t.Errorf("Hostnames[%s] = %q, want localhost", set.IPs[0], name)
+	}
+	if !reflect.DeepEqual(set.Unresolved, []string{"missing-host.invalid"}) {
+		t.Errorf("Unresolved = %v", set.Unresolved)
+	}
+}
+
+func TestExpandTargets_Invalid(t *testing.T) {
+	tests := [][]string{
+		nil,
+		{"192.168.1.999/24"},
+		{"192.168.1.10-5"},
+		{"192.168.1.1-abc"},
+		{"10.0.0.1-2001:db8::1"},
+		{"not a host!"},
+	}
+	for _, specs := range tests {
+		if _, err := ExpandTargets(context.Background(), specs, offlineResolver); err == nil {
+			t.Errorf("ExpandTargets(%q) ожидалась ошибка", specs)
+		}
+	}
+}
+
+func TestParseRangeBounds(t *testing.T) {
+	tests := []struct {
+		spec  string
+		count uint64
+	}{
+		{"192.168.1.10-12", 3},
+		{"192.168.1.250-192.168.2.5", 12},
+		{"2001:db8::10-1f", 16},
+	}
+	for _, tt := range tests {
+		start, end, err := parseRangeBounds(tt.spec)
+		if err != nil {
+			t.Fatalf("parseRangeBounds(%q) error = %v", tt.spec, err)
+		}
+		if n, _ := rangeSize(start, end); n != tt.count {
+			t.Errorf("parseRangeBounds(%q) size = %d, want %d", tt.spec, n, tt.count)
+		}
+	}
+
+	if _, _, err := parseRangeBounds("10.0.0.0-11.0.0.1"); err == nil {
+		t.Error("ожидалась ошибка для диапазона больше /8")
+	}
+}
+
+func TestEstimateTargetCount(t *testing.T) {
+	n, err := EstimateTargetCount([]string{"192.168.1.0/24", "10.0.0.1-10", "nas.local", "10.0.0.99"})
+	if err != nil {
+		t.Fatalf("EstimateTargetCount() error = %v", err)
+	}
+	if n != 254+10+1+1 {
+		t.Errorf("EstimateTargetCount() = %d, want %d", n, 254+10+1+1)
+	}
+}
+
+func TestTargetMatcher(t *testing.T) {
+	m, err := NewTargetMatcher([]string{"10.0.0.0/30", "192.168.1.10-20", "172.16.0.5", "localhost", "printer.lan"})
+	if err != nil {
+		t.Fatalf("NewTargetMatcher() error = %v", err)
+	}
+	if _, err := m.ResolveNames(context.Background(), offlineResolver); err != nil {
+		t.Fatalf("ResolveNames() error = %v", err)
+	}
+
+	tests := []struct {
+		ip, hostname string
+		want         bool
+	}{
+		{"10.0.0.2", "", true},
+		{"10.0.0.4", "", false},
+		{"192.168.1.15", "", true},
+		{"192.168.1.21", "", false},
+		{"172.16.0.5", "", true},
+		{"127.0.0.1", "", true},
+		{"192.168.5.5", "Printer.lan.", true},
+		{"192.168.5.5", "nas.lan", false},
+	}
+	for _, tt := range tests {
+		if got := m.ContainsHost(tt.ip, tt.hostname); got != tt.want {
+			t.Errorf("ContainsHost(%q, %q) = %v, want %v", tt.ip, tt.hostname, got, tt.want)
+		}
+	}
+
+	var empty *TargetMatcher
+	if empty.Contains(net.ParseIP("10.0.0.1")) {
+		t.Error("nil TargetMatcher не должен содержать адресов")
+	}
+	if _, err := NewTargetMatcher([]string{"10.0.0.1/33"}); err == nil {
+		t.Error("ожидалась ошибка для некорректного CIDR")
+	}
+}
@@ -0,0 +1,97 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"time"
+)
+
+// Причины состояния TCP-порта (TCPProbeResult.Reason) — те же имена, что в атрибуте
+// reason XML-отчёта nmap.
+const (
+	ReasonSynAck          = "syn-ack"          // SYN-ACK: соединение установлено
+	ReasonConnRefused     = "conn-refused"     // connect: RST, соединение отклонено
+	ReasonReset           = "reset"            // SYN-скан: получен RST
+	ReasonNoResponse      = "no-response"      // ответа не было до таймаута
+	ReasonHostUnreachable = "host-unreach"     // ICMP host unreachable
+	ReasonNetUnreachable  = "net-unreach"      // ICMP network unreachable
+	ReasonPortUnreachable = "port-unreach"     // ICMP port unreachable
+	ReasonAdminProhibited = "admin-prohibited" // ICMP communication administratively prohibited
+)
+
+// TCPProbeResult — итог TCP-пробы одного порта.
+type TCPProbeResult struct {
+	State   PortState     // PortOpen, PortClosed или PortFiltered
+	Reason  string        // почему порт получил State (Reason*); пусто — причина неизвестна
+	Latency time.Duration // время до ответа; 0, если ответа не было
+}
+
+// ProbeTCP устанавливает TCP-соединение и классифицирует порт по результату:
+// соединение — PortOpen (syn-ack), RST — PortClosed (conn-refused), ICMP unreachable —
+// PortFiltered (host-unreach, net-unreach), тишина до таймаута — PortFiltered (no-response).
+// Прочие ошибки соединения возвращаются вместе с результатом PortFiltered без причины.
+func ProbeTCP(host string, port int, timeout time.Duration) (TCPProbeResult, error) {
+	return ProbeTCPFrom(Source{}, host, port, timeout)
+}
+
+// ProbeTCPFrom — ProbeTCP с соединением от источника src.
+func ProbeTCPFrom(src Source, host string, port int, timeout time.Duration) (TCPProbeResult, error) {
+	if timeout <= 0 {
+		timeout = time.Second
+	}
+	return ProbeTCPVia(src.Dialer("tcp", host, timeout), host, port, timeout)
+}
+
+// ProbeTCPVia — ProbeTCP с соединением через dialer (например, Proxy). Отказ прокси
+// соединиться с целью классифицируется так же, как ответ цели напрямую.
+func ProbeTCPVia(dialer ContextDialer, host string, port int, timeout time.Duration) (TCPProbeResult, error) {
+	if timeout <= 0 {
+		timeout = time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, fmt.Sprintf("%d", port)))
+	latency := time.Since(start)
+	if err == nil {
+		conn.Close()
+		return TCPProbeResult{State: PortOpen, Reason: ReasonSynAck, Latency: latency}, nil
+	}
+	reason, state, ok := classifyDialError(err)
+	if !ok {
+		reason, state, ok = classifyProxyError(err)
+	}
+	if ok {
+		res := TCPProbeResult{State: state, Reason: reason}
+		if reason != ReasonNoResponse {
+			res.Latency = latency
+		}
+		return res, nil
+	}
+	return TCPProbeResult{State: PortFiltered}, err
+}
+
+// classifyDialError определяет причину по ошибке соединения; ok=false — ошибка не
+// говорит о состоянии порта (нет ресурсов, неверный адрес и т.п.).
+func classifyDialError(err error) (reason string, state PortState, ok bool) {
+	var errno syscall.Errno
+	if errors.As(err, &errno) {
+		switch errno {
+		case errConnRefused:
+			return ReasonConnRefused, PortClosed, true
+		case errHostUnreachable:
+			return ReasonHostUnreachable, PortFiltered, true
+		case errNetUnreachable:
+			return ReasonNetUnreachable, PortFiltered, true
+		case errTimedOut:
+			return ReasonNoResponse, PortFiltered, true
+		}
+	}
+	if ne, ok := err.(net.Error); ok && ne.Timeout() {
+		return ReasonNoResponse, PortFiltered, true
+	}
+	return "", PortFiltered, false
+}
@@ -0,0 +1,13 @@
+//go:build !windows
+
+package network
+
+import "syscall"
+
+// Ошибки connect, по которым classifyDialError определяет состояние порта.
+const (
+	errConnRefused     = syscall.ECONNREFUSED
+	errHostUnreachable = syscall.EHOSTUNREACH
+	errNetUnreachable  = syscall.ENETUNREACH
+	errTimedOut        = syscall.ETIMEDOUT
+)
@@ -0,0 +1,72 @@
+package network
+
+import (
+	"errors"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestProbeTCP_States(t *testing.T) {
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("TCP listen недоступен: %v", err)
+	}
+	defer ln.Close()
+	open := ln.Addr().(*net.TCPAddr).Port
+
+	res, err := ProbeTCP("127.0.0.1", open, time.Second)
+	if err != nil || res.State != PortOpen || res.Reason != ReasonSynAck || res.Latency <= 0 {
+		t.Errorf("open port: %+v, %v", res, err)
+	}
+
+	// Закрытый порт: loopback отвечает RST
+	probe, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("TCP listen недоступен: %v", err)
+	}
+	closed := probe.Addr().(*net.TCPAddr).Port
+	probe.Close()
+	res, err = ProbeTCP("127.0.0.1", closed, time.Second)
+	if err != nil || res.State != PortClosed || res.Reason != ReasonConnRefused {
+		t.Errorf("closed port: %+v, %v", res, err)
+	}
+
+	if res, err := (TCPPortScanner{Timeout: time.Second}).ProbeTCP("127.0.0.1", open, 0); err != nil || res.State != PortOpen {
+		t.Errorf("TCPPortScanner.ProbeTCP() = %+v, %v", res, err)
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestClassifyDialError(t *testing.T) {
+	dialErr := func(err error) error {
+		return &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", err)}
+	}
+	tests := []struct {
+		name   string
+		err    error
+		reason string
+		state  PortState
+		ok     bool
+	}{
+		{"refused", dialErr(errConnRefused), ReasonConnRefused, PortClosed, true},
+		{"host unreachable", dialErr(errHostUnreachable), ReasonHostUnreachable, PortFiltered, true},
+		{"net unreachable", dialErr(errNetUnreachable), ReasonNetUnreachable, PortFiltered, true},
+		{"timeout", &net.OpError{Op: "dial", Net: "tcp", Err: timeoutError{}}, ReasonNoResponse, PortFiltered, true},
+		{"no buffers", dialErr(syscall.ENOBUFS), "", PortFiltered, false},
+		{"other", errors.New("boom"), "", PortFiltered, false},
+	}
+	for _, tt := range tests {
+		reason, state, ok := classifyDialError(tt.err)
+		if reason != tt.reason || state != tt.state || ok != tt.ok {
+			t.Errorf("%s: classifyDialError() = %q, %s, %v; want %q, %s, %v", tt.name, reason, state, ok, tt.reason, tt.state, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+package network
+
+import "syscall"
+
+// Коды Winsock, по которым classifyDialError определяет состояние порта: пакет syscall
+// не сопоставляет их с ECONNREFUSED и т.п.
+const (
+	errConnRefused     = syscall.Errno(10061) // WSAECONNREFUSED
+	errHostUnreachable = syscall.Errno(10065) // WSAEHOSTUNREACH
+	errNetUnreachable  = syscall.Errno(10051) // WSAENETUNREACH
+	errTimedOut        = syscall.Errno(10060) // WSAETIMEDOUT
+)
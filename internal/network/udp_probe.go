@@ -0,0 +1,360 @@
+package network
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// DefaultUDPPorts — UDP-порты, опрашиваемые по умолчанию (формат ParsePortRange).
+const DefaultUDPPorts = "53,67,68,69,123,137,161,162,500,514,1194,1900,5353"
+
+// UDPProbeResult — итог UDP-пробы одного порта.
+type UDPProbeResult struct {
+	State   PortState // PortOpen, PortClosed или PortOpenFiltered
+	Service string    // служба, распознанная по ответу (пусто, если ответа не было)
+	Version string    // версия/сигнатура из ответа (опционально)
+}
+
+// udpPayload описывает протокольную пробу: build собирает запрос, parse разбирает ответ
+// и возвращает службу/версию; ok=false — ответ не относится к нашему запросу.
+type udpPayload struct {
+	service string
+	build   func() ([]byte, any)
+	parse   func(resp []byte, state any) (version string, ok bool)
+}
+
+// udpPayloads — пробы по номеру порта; для остальных портов отправляется пустая датаграмма.
+var udpPayloads = map[int]udpPayload{
+	53:   {service: "dns", build: buildDNSVersionQuery, parse: parseDNSVersion},
+	69:   {service: "tftp", build: buildTFTPRead, parse: parseTFTP},
+	123:  {service: "ntp", build: buildNTPRequest, parse: parseNTP},
+	137:  {service: "netbios-ns", build: buildNBSTATQuery, parse: parseNBSTAT},
+	161:  {service: "snmp", build: buildSNMPGet, parse: parseSNMPSysDescr},
+	500:  {service: "isakmp", build: buildIKEMainMode, parse: parseIKE},
+	1900: {service: "ssdp", build: buildSSDPSearch, parse: parseSSDP},
+	5353: {service: "mdns", build: buildMDNSQuery, parse: parseMDNS},
+}
+
+// ProbeUDP отправляет на порт протокольную пробу (DNS, NTP, SNMP, SSDP, NetBIOS, mDNS,
+// TFTP, IKE; для прочих портов — пустую датаграмму) и классифицирует порт:
+// ответ — PortOpen, ICMP port unreachable — PortClosed, тишина — PortOpenFiltered.
+// Ошибка возвращается, только если пробу не удалось отправить.
+func ProbeUDP(host string, port int, timeout time.Duration) (UDPProbeResult, error) {
+	return ProbeUDPFrom(Source{}, host, port, timeout)
+}
+
+// ProbeUDPFrom — ProbeUDP с сокета, привязанного к источнику src.
+func ProbeUDPFrom(src Source, host string, port int, timeout time.Duration) (UDPProbeResult, error) {
+	if timeout <= 0 {
+		timeout = time.Second
+	}
+	conn, err := src.DialTimeout("udp", net.JoinHostPort(host, fmt.Sprintf("%d", port)), timeout)
+	if err != nil {
+		return UDPProbeResult{}, err
+	}
+	defer conn.Close()
+
+	payload, probe := udpPayloads[port]
+	var request []byte
+	var state any
+	if probe {
+		request, state = payload.build()
+	}
+	if _, err := conn.Write(request); err != nil {
+		if isPortUnreachable(err) {
+			return UDPProbeResult{State: PortClosed}, nil
+		}
+		return UDPProbeResult{}, err
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(timeout))
+	buf := make([]byte, 4096)
+	answered := false
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			switch {
+			case answered:
+				return UDPProbeResult{State: PortOpen}, nil
+			case isPortUnreachable(err):
+				return UDPProbeResult{State: PortClosed}, nil
+			}
+			// Таймаут и прочие ошибки чтения: ответа нет, порт открыт или фильтруется
+			return UDPProbeResult{State: PortOpenFiltered}, nil
+		}
+		if !probe {
+			return UDPProbeResult{State: PortOpen}, nil
+		}
+		if version, ok := payload.parse(buf[:n], state); ok {
+			return UDPProbeResult{State: PortOpen, Service: payload.service, Version: version}, nil
+		}
+		// Посторонний ответ: порт открыт, но ждём ответ на нашу пробу до дедлайна
+		answered = true
+	}
+}
+
+// isPortUnreachable сообщает, что ядро получило ICMP port unreachable
+// (Linux/macOS — ECONNREFUSED, Windows — WSAECONNRESET).
+func isPortUnreachable(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET)
+}
+
+func randomID() uint16 {
+	var b [2]byte
+	_, _ = rand.Read(b[:])
+	return binary.BigEndian.Uint16(b[:])
+}
+
+// --- DNS (53): CHAOS TXT version.bind ---
+
+func buildDNSVersionQuery() ([]byte, any) {
+	id := randomID()
+	msg := dnsmessage.Message{
+		Header: dnsmessage.Header{ID: id, RecursionDesired: false},
+		Questions: []dnsmessage.Question{{
+			Name:  dnsmessage.MustNewName("version.bind."),
+			Type:  dnsmessage.TypeTXT,
+			Class: dnsmessage.ClassCHAOS,
+		}},
+	}
+	b, _ := msg.Pack()
+	return b, id
+}
+
+func parseDNSVersion(resp []byte, state any) (string, bool) {
+	var p dnsmessage.Parser
+	h, err := p.Start(resp)
+	if err != nil || !h.Response || h.ID != state.(uint16) {
+		return "", false
+	}
+	if err := p.SkipAllQuestions(); err != nil {
+		return "", true
+	}
+	for {
+		rh, err := p.AnswerHeader()
+		if err != nil {
+			return "", true
+		}
+		if rh.Type != dnsmessage.TypeTXT {
+			if err := p.SkipAnswer(); err != nil {
+				return "", true
+			}
+			continue
+		}
+		txt, err := p.TXTResource()
+		if err != nil {
+			return "", true
+		}
+		return strings.Join(txt.TXT, " "), true
+	}
+}
+
+// --- TFTP (69): RRQ несуществующего файла, сервер отвечает DATA или ERROR ---
+
+func buildTFTPRead() ([]byte, any) {
+	req := []byte{0, 1}
+	req = append(req, fmt.Sprintf("ns-probe-%d", randomID())...)
+	req = append(req, 0)
+	req = append(req, "octet"...)
+	req = append(req, 0)
+	return req, nil
+}
+
+func parseTFTP(resp []byte, _ any) (string, bool) {
+	if len(resp) < 4 || resp[0] != 0 {
+		return "", false
+	}
+	return "", resp[1] == 3 || resp[1] == 5
+}
+
+// --- NTP (123): клиентский запрос mode 3 ---
+
+func buildNTPRequest() ([]byte, any) {
+	req := make([]byte, 48)
+	req[0] = 0x23 // LI=0, VN=4, Mode=3 (client)
+	return req, nil
+}
+
+func parseNTP(resp []byte, _ any) (string, bool) {
+	if len(resp) < 48 || resp[0]&0x07 != 4 {
+		return "", false
+	}
+	return fmt.Sprintf("NTPv%d stratum %d", (resp[0]>>3)&0x07, resp[1]), true
+}
+
+// --- NetBIOS (137): NBSTAT-запрос имени "*" ---
+
+func buildNBSTATQuery() ([]byte, any) {
+	id := randomID()
+	req := make([]byte, 12, 50)
+	binary.BigEndian.PutUint16(req[0:], id)
+	binary.BigEndian.PutUint16(req[4:], 1) // QDCOUNT
+	// Имя "*" в кодировке first-level NetBIOS: '*' + 15 нулей, каждый полубайт + 'A'
+	name := make([]byte, 16)
+	name[0] = '*'
+	req = append(req, 32)
+	for _, c := range name {
+		req = append(req, 'A'+c>>4, 'A'+c&0x0f)
+	}
+	req = append(req, 0, 0x00, 0x21, 0x00, 0x01) // NBSTAT, IN
+	return req, id
+}
+
+func parseNBSTAT(resp []byte, state any) (string, bool) {
+	if len(resp) < 12 || binary.BigEndian.Uint16(resp[0:]) != state.(uint16) || resp[2]&0x80 == 0 {
+		return "", false
+	}
+	names := parseNBSTATNames(resp)
+	if len(names) == 0 {
+		return "", true
+	}
+	return names[0], true
+}
+
+// parseNBSTATNames возвращает имена из ответа NBSTAT (без суффикса типа и пробелов).
+func parseNBSTATNames(resp []byte) []string {
+	// 12 байт заголовка, имя (34 байта), type, class, TTL, RDLENGTH, затем число имён
+	off := 12 + 34 + 2 + 2 + 4 + 2
+	if len(resp) <= off {
+		return nil
+	}
+	count := int(resp[off])
+	off++
+	names := make([]string, 0, count)
+	for i := 0; i < count && off+18 <= len(resp); i++ {
+		name := strings.TrimRight(string(resp[off:off+15]), " \x00")
+		if name != "" {
+			names = append(names, name)
+		}
+		off += 18
+	}
+	return names
+}
+
+// --- SNMP (161): v2c GetRequest sysDescr.0 с community public ---
+
+const sysDescrOID = ".1.3.6.1.2.1.1.1.0"
+
+func buildSNMPGet() ([]byte, any) {
+	id := uint32(randomID())
+	packet := &gosnmp.SnmpPacket{
+		Version:   gosnmp.Version2c,
+		Community: "public",
+		PDUType:   gosnmp.GetRequest,
+		RequestID: id,
+		Variables: []gosnmp.SnmpPDU{{Name: sysDescrOID, Type: gosnmp.Null}},
+	}
+	b, err := packet.MarshalMsg()
+	if err != nil {
+		return nil, id
+	}
+	return b, id
+}
+
+func parseSNMPSysDescr(resp []byte, state any) (string, bool) {
+	decoder := &gosnmp.GoSNMP{Version: gosnmp.Version2c, Community: "public", MaxOids: gosnmp.MaxOids}
+	packet, err := decoder.SnmpDecodePacket(resp)
+	if err != nil || packet.RequestID != state.(uint32) {
+		return "", false
+	}
+	for _, v := range packet.Variables {
+		if b, ok := v.Value.([]byte); ok && v.Type == gosnmp.OctetString {
+			return firstLine(string(b)), true
+		}
+	}
+	return "", true
+}
+
+// --- IKE (500): ISAKMP Main Mode с одним предложением 3DES-SHA1-PSK-MODP1024 ---
+
+func buildIKEMainMode() ([]byte, any) {
+	var cookie [8]byte
+	_, _ = rand.Read(cookie[:])
+
+	transform := []byte{0, 0, 0, 32, 1, 1, 0, 0}
+	for _, attr := range [][2]uint16{{1, 5}, {2, 2}, {3, 1}, {4, 2}, {11, 1}, {12, 28800}} {
+		transform = binary.BigEndian.AppendUint16(transform, 0x8000|attr[0])
+		transform = binary.BigEndian.AppendUint16(transform, attr[1])
+	}
+	proposal := append([]byte{0, 0, 0, byte(8 + len(transform)), 1, 1, 0, 1}, transform...)
+	sa := []byte{0, 0, 0, byte(12 + len(proposal)), 0, 0, 0, 1, 0, 0, 0, 1}
+	sa = append(sa, proposal...)
+
+	msg := append([]byte{}, cookie[:]...)
+	msg = append(msg, make([]byte, 8)...) // responder cookie
+	msg = append(msg, 1, 0x10, 2, 0)      // next payload SA, version 1.0, Identity Protection
+	msg = append(msg, 0, 0, 0, 0)         // message ID
+	msg = binary.BigEndian.AppendUint32(msg, uint32(28+len(sa)))
+	msg = append(msg, sa...)
+	return msg, cookie
+}
+
+func parseIKE(resp []byte, state any) (string, bool) {
+	cookie := state.([8]byte)
+	if len(resp) < 28 || !bytes.Equal(resp[:8], cookie[:]) {
+		return "", false
+	}
+	return fmt.Sprintf("IKEv%d", resp[17]>>4), true
+}
+
+// --- SSDP (1900): M-SEARCH ssdp:all ---
+
+func buildSSDPSearch() ([]byte, any) {
+	return []byte("M-SEARCH * HTTP/1.1\r\n" +
+		"HOST: 239.255.255.250:1900\r\n" +
+		"MAN: \"ssdp:discover\"\r\n" +
+		"MX: 1\r\n" +
+		"ST: ssdp:all\r\n\r\n"), nil
+}
+
+func parseSSDP(resp []byte, _ any) (string, bool) {
+	if !bytes.HasPrefix(resp, []byte("HTTP/1.")) {
+		return "", false
+	}
+	for _, line := range strings.Split(string(resp), "\r\n") {
+		if k, v, ok := strings.Cut(line, ":"); ok && strings.EqualFold(strings.TrimSpace(k), "server") {
+			return strings.TrimSpace(v), true
+		}
+	}
+	return "", true
+}
+
+// --- mDNS (5353): unicast-запрос PTR _services._dns-sd._udp.local ---
+
+func buildMDNSQuery() ([]byte, any) {
+	msg := dnsmessage.Message{
+		Questions: []dnsmessage.Question{{
+			Name:  dnsmessage.MustNewName("_services._dns-sd._udp.local."),
+			Type:  dnsmessage.TypePTR,
+			Class: dnsmessage.ClassINET | 0x8000, // QU: ответ unicast
+		}},
+	}
+	b, _ := msg.Pack()
+	return b, nil
+}
+
+func parseMDNS(resp []byte, _ any) (string, bool) {
+	var p dnsmessage.Parser
+	h, err := p.Start(resp)
+	if err != nil || !h.Response {
+		return "", false
+	}
+	return "", true
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexAny(s, "\r\n"); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s)
+}
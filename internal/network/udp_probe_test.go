@@ -0,0 +1,167 @@
+package network
+
+import (
+	"encoding/binary"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// startUDPServer поднимает UDP-сервер на loopback; reply формирует ответ (nil — молчать).
+func startUDPServer(t *testing.T, reply func(req []byte) []byte) int {
+	t.Helper()
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("UDP listen недоступен: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buf := make([]byte, 2048)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if resp := reply(append([]byte(nil), buf[:n]...)); resp != nil {
+				_, _ = conn.WriteTo(resp, addr)
+			}
+		}
+	}()
+	return conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+func TestProbeUDP_States(t *testing.T) {
+	open := startUDPServer(t, func(req []byte) []byte { return []byte("pong") })
+	silent := startUDPServer(t, func(req []byte) []byte { return nil })
+
+	res, err := ProbeUDP("127.0.0.1", open, 300*time.Millisecond)
+	if err != nil || res.State != PortOpen {
+		t.Errorf("open port: %+v, %v", res, err)
+	}
+	res, err = ProbeUDP("127.0.0.1", silent, 200*time.Millisecond)
+	if err != nil || res.State != PortOpenFiltered {
+		t.Errorf("silent port: %+v, %v", res, err)
+	}
+
+	// Закрытый порт: loopback отвечает ICMP port unreachable
+	probe, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("UDP listen недоступен: %v", err)
+	}
+	closed := probe.LocalAddr().(*net.UDPAddr).Port
+	probe.Close()
+	res, err = ProbeUDP("127.0.0.1", closed, 300*time.Millisecond)
+	if err != nil || res.State != PortClosed {
+		t.Errorf("closed port: %+v, %v", res, err)
+	}
+}
+
+func TestProbeUDP_UsesPayloadAndParsesReply(t *testing.T) {
+	port := startUDPServer(t, func(req []byte) []byte {
+		if len(req) != 48 || req[0] != 0x23 {
+			return []byte("unexpected")
+		}
+		resp := make([]byte, 48)
+		resp[0] = 0x24 // VN=4, Mode=4 (server)
+		resp[1] = 2
+		return resp
+	})
+	udpPayloads[port] = udpPayloads[123]
+	t.Cleanup(func() { delete(udpPayloads, port) })
+
+	res, err := ProbeUDP("127.0.0.1", port, 300*time.Millisecond)
+	if err != nil {
+		t.Fatalf("ProbeUDP() error = %v", err)
+	}
+	if res.State != PortOpen || res.Service != "ntp" || res.Version != "NTPv4 stratum 2" {
+		t.Errorf("ProbeUDP() = %+v", res)
+	}
+}
+
+func TestUDPPayloadParsers(t *testing.T) {
+	t.Run("dns", func(t *testing.T) {
+		req, state := buildDNSVersionQuery()
+		var q dnsmessage.Message
+		if err := q.Unpack(req); err != nil {
+			t.Fatalf("unpack query: %v", err)
+		}
+		resp := dnsmessage.Message{
+			Header:    dnsmessage.Header{ID: q.Header.ID, Response: true},
+			Questions: q.Questions,
+			Answers: []dnsmessage.Resource{{
+				Header: dnsmessage.ResourceHeader{Name: q.Questions[0].Name, Type: dnsmessage.TypeTXT, Class: dnsmessage.ClassCHAOS},
+				Body:   &dnsmessage.TXTResource{TXT: []string{"9.18.24"}},
+			}},
+		}
+		b, err := resp.Pack()
+		if err != nil {
+			t.Fatalf("pack response: %v", err)
+		}
+		if v, ok := parseDNSVersion(b, state); !ok || v != "9.18.24" {
+			t.Errorf("parseDNSVersion() = %q, %v", v, ok)
+		}
+		if _, ok := parseDNSVersion(b, state.(uint16)+1); ok {
+			t.Error("ответ с чужим ID не должен приниматься")
+		}
+	})
+
+	t.Run("snmp", func(t *testing.T) {
+		_, state := buildSNMPGet()
+		resp := &gosnmp.SnmpPacket{
+			Version:   gosnmp.Version2c,
+			Community: "public",
+			PDUType:   gosnmp.GetResponse,
+			RequestID: state.(uint32),
+			Variables: []gosnmp.SnmpPDU{{Name: sysDescrOID, Type: gosnmp.OctetString, Value: []byte("RouterOS RB4011\r\nrev 2")}},
+		}
+		b, err := resp.MarshalMsg()
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		if v, ok := parseSNMPSysDescr(b, state); !ok || v != "RouterOS RB4011" {
+			t.Errorf("parseSNMPSysDescr() = %q, %v", v, ok)
+		}
+	})
+
+	t.Run("ssdp", func(t *testing.T) {
+		resp := []byte("HTTP/1.1 200 OK\r\nST: upnp:rootdevice\r\nSERVER: Linux/5.4 UPnP/1.0 MiniUPnPd/2.2\r\n\r\n")
+		if v, ok := parseSSDP(resp, nil); !ok || v != "Linux/5.4 UPnP/1.0 MiniUPnPd/2.2" {
+			t.Errorf("parseSSDP() = %q, %v", v, ok)
+		}
+	})
+
+	t.Run("nbstat", func(t *testing.T) {
+		req, state := buildNBSTATQuery()
+		resp := append([]byte(nil), req[:12]...)
+		resp[2] = 0x84 // response, authoritative
+		binary.BigEndian.PutUint16(resp[4:], 0)
+		binary.BigEndian.PutUint16(resp[6:], 1)
+		resp = append(resp, req[12:12+34]...)
+		resp = append(resp, 0, 0x21, 0, 1, 0, 0, 0, 0, 0, 0, 1)
+		resp = append(resp, []byte(strings.Repeat(" ", 15))...)
+		copy(resp[len(resp)-15:], "FILESRV")
+		resp = append(resp, 0x00, 0x04, 0x00)
+		if v, ok := parseNBSTAT(resp, state); !ok || v != "FILESRV" {
+			t.Errorf("parseNBSTAT() = %q, %v", v, ok)
+		}
+	})
+
+	t.Run("ike", func(t *testing.T) {
+		req, state := buildIKEMainMode()
+		if len(req) != 80 || binary.BigEndian.Uint32(req[24:]) != 80 {
+			t.Fatalf("IKE request length = %d", len(req))
+		}
+		resp := append([]byte(nil), req...)
+		if v, ok := parseIKE(resp, state); !ok || v != "IKEv1" {
+			t.Errorf("parseIKE() = %q, %v", v, ok)
+		}
+		resp[0] ^= 0xff
+		if _, ok := parseIKE(resp, state); ok {
+			t.Error("ответ с чужим cookie не должен приниматься")
+		}
+	})
+}
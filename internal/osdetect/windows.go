@@ -0,0 +1,70 @@
+package osdetect
+
+import "fmt"
+
+// windows10Builds — выпуски с ядром 10.0 по номеру сборки.
+var windows10Builds = map[int]string{
+	10240: "Windows 10 1507",
+	10586: "Windows 10 1511",
+	14393: "Windows 10 1607 / Server 2016",
+	15063: "Windows 10 1703",
+	16299: "Windows 10 1709",
+	17134: "Windows 10 1803",
+	17763: "Windows 10 1809 / Server 2019",
+	18362: "Windows 10 1903",
+	18363: "Windows 10 1909",
+	19041: "Windows 10 2004",
+	19042: "Windows 10 20H2",
+	19043: "Windows 10 21H1",
+	19044: "Windows 10 21H2",
+	19045: "Windows 10 22H2",
+	20348: "Windows Server 2022",
+	22000: "Windows 11 21H2",
+	22621: "Windows 11 22H2",
+	22631: "Windows 11 23H2",
+	25398: "Windows Server 2022 23H2",
+	26100: "Windows 11 24H2 / Server 2025",
+}
+
+// GuessFromNTLMVersion определяет выпуск Windows по версии ОС из NTLM CHALLENGE (SMB,
+// стадия smb). Samba сообщает 6.1 со сборкой 0.
+func GuessFromNTLMVersion(major, minor, build int) (osName, confidence, reason string) {
+	reason = fmt.Sprintf("версия ОС из NTLM (SMB): %d.%d.%d", major, minor, build)
+	if build == 0 {
+		return "Linux/Unix (Samba)", "средняя", reason + ", сборка 0 — Samba"
+	}
+	switch {
+	case major == 10 && minor == 0:
+		if name, ok := windows10Builds[build]; ok {
+			return name, "высокая", reason
+		}
+		if build >= 22000 {
+			return "Windows 11", "высокая", reason
+		}
+		return "Windows 10", "высокая", reason
+	case major == 6 && minor == 3:
+		return "Windows 8.1 / Server 2012 R2", "высокая", reason
+	case major == 6 && minor == 2:
+		return "Windows 8 / Server 2012", "высокая", reason
+	case major == 6 && minor == 1:
+		return "Windows 7 / Server 2008 R2", "высокая", reason
+	case major == 6 && minor == 0:
+		return "Windows Vista / Server 2008", "высокая", reason
+	case major == 5 && minor == 2:
+		return "Windows XP x64 / Server 2003", "высокая", reason
+	case major == 5 && minor == 1:
+		return "Windows XP", "высокая", reason
+	case major == 5 && minor == 0:
+		return "Windows 2000", "высокая", reason
+	}
+	return "Windows", "средняя", reason
+}
+
+// GuessFromNetBIOS оценивает ОС по ответу на запрос имён NetBIOS (стадия netbios):
+// Windows сообщает MAC адаптера, Samba — нули.
+func GuessFromNetBIOS(hasMAC bool) (osName, confidence, reason string) {
+	if hasMAC {
+		return "Windows", "средняя", "отвечает на запрос имён NetBIOS"
+	}
+	return "Linux/Unix (Samba)", "низкая", "отвечает на запрос имён NetBIOS с нулевым MAC"
+}
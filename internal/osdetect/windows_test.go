@@ -0,0 +1,22 @@
+package osdetect
+
+import "testing"
+
+func TestGuessFromNTLMVersion(t *testing.T) {
+	cases := []struct {
+		major, minor, build int
+		want, confidence    string
+	}{
+		{10, 0, 19045, "Windows 10 22H2", "высокая"},
+		{10, 0, 26100, "Windows 11 24H2 / Server 2025", "высокая"},
+		{10, 0, 22700, "Windows 11", "высокая"},
+		{6, 1, 7601, "Windows 7 / Server 2008 R2", "высокая"},
+		{6, 1, 0, "Linux/Unix (Samba)", "средняя"},
+	}
+	for _, c := range cases {
+		osName, confidence, reason := GuessFromNTLMVersion(c.major, c.minor, c.build)
+		if osName != c.want || confidence != c.confidence || reason == "" {
+			t.Errorf("%d.%d.%d: got %q/%q (%s), want %q/%q", c.major, c.minor, c.build, osName, confidence, reason, c.want, c.confidence)
+		}
+	}
+}
@@ -20,8 +20,6 @@ var (
 	udpNames map[int]string
 	tcpDesc  map[int]string
 	udpDesc  map[int]string
-
-	titleEn = cases.Title(language.English)
 )
 
 // portLabelOverrides сохраняют прежние удобочитаемые подписи там, где они расходятся с сырыми именами IANA.
@@ -215,7 +213,8 @@ func formatIANAServiceName(raw string) string {
 	if !strings.Contains(raw, "-") && len(raw) > 3 {
 		result := func() string {
 			defer func() { recover() }()
-			return titleEn.String(strings.ToLower(raw))
+			// cases.Caser хранит состояние и небезопасен для параллельных вызовов, поэтому создаётся заново
+			return cases.Title(language.English).String(strings.ToLower(raw))
 		}()
 		if result != "" {
 			return result
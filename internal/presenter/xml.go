@@ -4,9 +4,14 @@ import (
 	"encoding/xml"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
+	"network-scanner/internal/httpinspect"
 	"network-scanner/internal/scanner"
+	"network-scanner/internal/smbinspect"
+	"network-scanner/internal/sshinspect"
+	"network-scanner/internal/tlsinspect"
 )
 
 // XMLPresenter exports scan results to an XML file.
@@ -40,10 +45,11 @@ type xmlAddress struct {
 
 // xmlPort represents a port in XML.
 type xmlPort struct {
-	Protocol string     `xml:"protocol,attr"`
-	PortID   int        `xml:"portid,attr"`
-	State    xmlState   `xml:"state"`
-	Service  xmlService `xml:"service"`
+	Protocol string      `xml:"protocol,attr"`
+	PortID   int         `xml:"portid,attr"`
+	State    xmlState    `xml:"state"`
+	Service  xmlService  `xml:"service"`
+	Scripts  []xmlScript `xml:"script,omitempty"`
 }
 
 // xmlState represents port state.
@@ -54,8 +60,19 @@ type xmlState struct {
 
 // xmlService represents a service running on a port.
 type xmlService struct {
-	Name    string `xml:"name,attr,omitempty"`
-	Version string `xml:"version,attr,omitempty"`
+	Name      string `xml:"name,attr,omitempty"`
+	Product   string `xml:"product,attr,omitempty"`
+	Version   string `xml:"version,attr,omitempty"`
+	ExtraInfo string `xml:"extrainfo,attr,omitempty"`
+	CPE       string `xml:"cpe,omitempty"`
+}
+
+// xmlScript carries extra port details in nmap script form (ssl-cert, ssl-enum-ciphers,
+// ssh-hostkey, ssh2-enum-algos, http-title, http-server-header, smb-protocols,
+// smb2-security-mode).
+type xmlScript struct {
+	ID     string `xml:"id,attr"`
+	Output string `xml:"output,attr"`
 }
 
 // xmlHostname represents a hostname.
@@ -104,13 +121,20 @@ func (p XMLPresenter) Export(results []scanner.HostResult, format string) error
 	for _, r := range results {
 		host := xmlHost{
 			Addresses: []xmlAddress{
-				{Addr: r.IP, AddrType: "ipv4"},
+				{Addr: r.IP, AddrType: xmlAddrType(r.IP)},
 			},
 			Ports:      make([]xmlPort, 0, len(r.Ports)),
 			Hostnames:  make([]xmlHostname, 0),
 			DeviceType: r.DeviceType,
 		}
 
+		// Остальные адреса устройства (dual-stack): первый из Addresses совпадает с IP
+		for i, addr := range r.Addresses {
+			if i > 0 {
+				host.Addresses = append(host.Addresses, xmlAddress{Addr: addr, AddrType: xmlAddrType(addr)})
+			}
+		}
+
 		if r.MAC != "" {
 			host.Addresses = append(host.Addresses, xmlAddress{
 				Addr:     r.MAC,
@@ -132,12 +156,16 @@ func (p XMLPresenter) Export(results []scanner.HostResult, format string) error
 				PortID:   port.Port,
 				State: xmlState{
 					State:  port.State,
-					Reason: "syn-ack",
+					Reason: port.Reason,
 				},
 				Service: xmlService{
-					Name:    port.Service,
-					Version: port.Version,
+					Name:      port.Service,
+					Product:   port.Product,
+					Version:   port.Version,
+					ExtraInfo: port.Info,
+					CPE:       port.CPE,
 				},
+				Scripts: xmlPortScripts(port),
 			})
 		}
 
@@ -177,3 +205,124 @@ func (p XMLPresenter) Export(results []scanner.HostResult, format string) error
 	fmt.Println("XML report saved to: scan-results.xml")
 	return nil
 }
+
+// xmlPortScripts collects the nmap-style scripts of all enrichment details of a port.
+func xmlPortScripts(port scanner.PortInfo) []xmlScript {
+	scripts := xmlTLSScripts(port.TLS)
+	scripts = append(scripts, xmlSSHScripts(port.SSH)...)
+	scripts = append(scripts, xmlHTTPScripts(port.HTTP)...)
+	return append(scripts, xmlSMBScripts(port.SMB)...)
+}
+
+// xmlTLSScripts renders TLS details the way nmap's ssl-cert and ssl-enum-ciphers do.
+func xmlTLSScripts(info *tlsinspect.Info) []xmlScript {
+	if info == nil {
+		return nil
+	}
+	var scripts []xmlScript
+	if leaf := info.Leaf(); leaf != nil {
+		out := []string{"Subject: " + leaf.Subject}
+		if len(leaf.SANs) > 0 {
+			out = append(out, "Subject Alternative Name: "+strings.Join(leaf.SANs, ", "))
+		}
+		out = append(out,
+			"Issuer: "+leaf.Issuer,
+			fmt.Sprintf("Public Key type: %s", strings.ToLower(leaf.KeyType)),
+			fmt.Sprintf("Public Key bits: %d", leaf.KeyBits),
+			"Signature Algorithm: "+leaf.SignatureAlgorithm,
+			"Not valid before: "+leaf.NotBefore.Format("2006-01-02T15:04:05"),
+			"Not valid after:  "+leaf.NotAfter.Format("2006-01-02T15:04:05"),
+			"SHA-256: "+leaf.SHA256,
+		)
+		scripts = append(scripts, xmlScript{ID: "ssl-cert", Output: strings.Join(out, "\n")})
+	}
+	if len(info.Protocols) > 0 {
+		out := make([]string, 0, len(info.Protocols))
+		for _, p := range info.Protocols {
+			out = append(out, p.Version+": "+p.CipherSuite)
+		}
+		scripts = append(scripts, xmlScript{ID: "ssl-enum-ciphers", Output: strings.Join(out, "\n")})
+	}
+	return scripts
+}
+
+// xmlSSHScripts renders SSH details the way nmap's ssh-hostkey and ssh2-enum-algos do.
+func xmlSSHScripts(info *sshinspect.Info) []xmlScript {
+	if info == nil {
+		return nil
+	}
+	var scripts []xmlScript
+	if len(info.HostKeys) > 0 {
+		out := make([]string, 0, len(info.HostKeys))
+		for _, k := range info.HostKeys {
+			out = append(out, fmt.Sprintf("%d %s (%s)", k.Bits, k.Fingerprint, k.Type))
+		}
+		scripts = append(scripts, xmlScript{ID: "ssh-hostkey", Output: strings.Join(out, "\n")})
+	}
+	if len(info.KexAlgorithms) > 0 {
+		var out []string
+		for _, group := range []struct {
+			name  string
+			algos []string
+		}{
+			{"kex_algorithms", info.KexAlgorithms},
+			{"server_host_key_algorithms", info.HostKeyAlgorithms},
+			{"encryption_algorithms", info.Ciphers},
+			{"mac_algorithms", info.MACs},
+			{"compression_algorithms", info.Compression},
+		} {
+			out = append(out, fmt.Sprintf("%s: (%d)", group.name, len(group.algos)))
+			for _, a := range group.algos {
+				out = append(out, "    "+a)
+			}
+		}
+		scripts = append(scripts, xmlScript{ID: "ssh2-enum-algos", Output: strings.Join(out, "\n")})
+	}
+	return scripts
+}
+
+// xmlHTTPScripts renders web fingerprint details the way nmap's http-title and
+// http-server-header do.
+func xmlHTTPScripts(info *httpinspect.Info) []xmlScript {
+	if info == nil {
+		return nil
+	}
+	var scripts []xmlScript
+	if info.Title != "" {
+		scripts = append(scripts, xmlScript{ID: "http-title", Output: info.Title})
+	}
+	if server := info.Header("Server"); server != "" {
+		scripts = append(scripts, xmlScript{ID: "http-server-header", Output: server})
+	}
+	return scripts
+}
+
+// xmlSMBScripts renders SMB details the way nmap's smb-protocols and smb2-security-mode do.
+func xmlSMBScripts(info *smbinspect.Info) []xmlScript {
+	if info == nil {
+		return nil
+	}
+	protocols := append([]string(nil), info.Dialects...)
+	if info.SMB1 {
+		protocols = append([]string{"NT LM 0.12 (SMBv1)"}, protocols...)
+	}
+	mode := "Message signing enabled but not required"
+	switch {
+	case info.SigningRequired:
+		mode = "Message signing enabled and required"
+	case !info.SigningEnabled:
+		mode = "Message signing disabled"
+	}
+	return []xmlScript{
+		{ID: "smb-protocols", Output: strings.Join(protocols, ", ")},
+		{ID: "smb2-security-mode", Output: mode},
+	}
+}
+
+// xmlAddrType возвращает addrtype адреса в формате nmap: "ipv4" или "ipv6".
+func xmlAddrType(addr string) string {
+	if strings.Contains(addr, ":") {
+		return "ipv6"
+	}
+	return "ipv4"
+}
@@ -31,6 +31,10 @@ type Signature struct {
 	MatchAnyBanner  []string `json:"match_any_banner,omitempty"`
 	MatchDeviceType []string `json:"match_device_type,omitempty"`
 	MatchVendor     []string `json:"match_vendor,omitempty"`
+	// MatchHTTPProduct and MatchHTTPCategory match web applications recognised by the
+	// http enrichment stage (product name substring, deviceclassifier category).
+	MatchHTTPProduct  []string `json:"match_http_product,omitempty"`
+	MatchHTTPCategory []string `json:"match_http_category,omitempty"`
 }
 
 // Finding is a matched signature for a host.
@@ -115,6 +119,9 @@ func matchSignature(host scanner.Result, sig Signature) (string, bool) {
 	if reason, ok := matchStringAny(host.DeviceVendor, sig.MatchVendor, "vendor"); ok {
 		reasons = append(reasons, reason)
 	}
+	if reason, ok := matchHTTPProducts(host, sig.MatchHTTPProduct, sig.MatchHTTPCategory); ok {
+		reasons = append(reasons, reason)
+	}
 	if len(reasons) == 0 {
 		return "", false
 	}
@@ -176,6 +183,28 @@ func matchBanners(host scanner.Result, patterns []string) (string, bool) {
 	return "", false
 }
 
+func matchHTTPProducts(host scanner.Result, products, categories []string) (string, bool) {
+	if len(products) == 0 && len(categories) == 0 {
+		return "", false
+	}
+	for _, p := range host.Ports {
+		if p.State != "open" || p.HTTP == nil {
+			continue
+		}
+		for _, prod := range p.HTTP.Products {
+			if _, ok := matchStringAny(prod.Name, products, ""); ok {
+				return fmt.Sprintf("http_product=%s port=%d", prod.Name, p.Port), true
+			}
+			for _, c := range categories {
+				if strings.EqualFold(strings.TrimSpace(c), prod.Category) {
+					return fmt.Sprintf("http_product=%s category=%s port=%d", prod.Name, prod.Category, p.Port), true
+				}
+			}
+		}
+	}
+	return "", false
+}
+
 func matchStringAny(value string, wanted []string, key string) (string, bool) {
 	if len(wanted) == 0 {
 		return "", false
@@ -1,8 +1,10 @@
 package risksignature
 
 import (
+	"strings"
 	"testing"
 
+	"network-scanner/internal/httpinspect"
 	"network-scanner/internal/scanner"
 )
 
@@ -27,6 +29,36 @@ func TestEvaluate_MatchesByPortAndDeviceType(t *testing.T) {
 	}
 }
 
+func TestEvaluate_MatchesHTTPProducts(t *testing.T) {
+	db, err := LoadDefault()
+	if err != nil {
+		t.Fatalf("LoadDefault() error = %v", err)
+	}
+	results := []scanner.Result{
+		{
+			IP: "192.168.1.20",
+			Ports: []scanner.PortInfo{
+				{Port: 5000, State: "open", Protocol: "tcp", Service: "HTTP", HTTP: &httpinspect.Info{
+					Products: []httpinspect.Product{{Name: "Synology DiskStation Manager", Category: "NAS"}},
+				}},
+				{Port: 8080, State: "open", Protocol: "tcp", Service: "HTTP-Proxy", HTTP: &httpinspect.Info{
+					Products: []httpinspect.Product{{Name: "Jenkins", Category: "Server", Version: "2.426.1"}},
+				}},
+			},
+		},
+	}
+	got := map[string]string{}
+	for _, f := range Evaluate(results, db) {
+		got[f.SignatureID] = f.Reason
+	}
+	if !strings.Contains(got["home.http.nas.admin"], "category=NAS") || !strings.Contains(got["home.http.jenkins"], "port=8080") {
+		t.Fatalf("expected NAS and Jenkins findings, got %v", got)
+	}
+	if _, ok := got["home.http.router.admin"]; ok {
+		t.Errorf("unexpected router finding: %v", got)
+	}
+}
+
 func TestLoad_InvalidJSON(t *testing.T) {
 	_, err := Load([]byte("{bad-json"))
 	if err == nil {
@@ -1,7 +1,10 @@
 package scanner
 
 import (
+	"context"
 	"fmt"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -36,12 +39,19 @@ func DefaultAdaptiveConfig() AdaptiveConfig {
 }
 
 // AdaptiveScanner обёртка над NetworkScanner с адаптивными лимитами.
+// NetworkScanner пропускает каждую TCP-пробу через AcquireProbe/ReleaseProbe,
+// поэтому budget — это текущее окно одновременных проб всего запуска.
 type AdaptiveScanner struct {
 	config        AdaptiveConfig
 	scanner       *NetworkScanner
 	metrics       *ScanMetrics
 	adaptMu       sync.Mutex
 	currentBudget int64
+	trajectory    []int // значения budget по мере адаптации (защищено adaptMu)
+
+	slotMu   sync.Mutex
+	inflight int
+	wake     chan struct{} // закрывается, когда освобождается слот или растёт budget
 }
 
 // ScanMetrics метрики сканирования для адаптации.
@@ -54,6 +64,7 @@ type ScanMetrics struct {
 	lastAdaptTime    time.Time
 	probesSinceAdapt int64
 	openSinceAdapt   int64
+	errorSinceAdapt  int64
 }
 
 // NewAdaptiveScanner создаёт новый адаптивный сканер.
@@ -73,6 +84,8 @@ func NewAdaptiveScanner(ns *NetworkScanner, config AdaptiveConfig) *AdaptiveScan
 		scanner:       ns,
 		metrics:       &ScanMetrics{startTime: time.Now()},
 		currentBudget: int64(config.InitialBudget),
+		trajectory:    []int{config.InitialBudget},
+		wake:          make(chan struct{}),
 	}
 }
 
@@ -93,7 +106,58 @@ func (a *AdaptiveScanner) SetBudget(budget int) {
 		budget = a.config.MaxBudget
 	}
 
-	atomic.StoreInt64(&a.currentBudget, int64(budget))
+	a.storeBudget(int64(budget))
+}
+
+// storeBudget сохраняет budget, дописывает его в траекторию и будит ожидающих проб.
+// Вызывается под adaptMu.
+func (a *AdaptiveScanner) storeBudget(budget int64) {
+	if atomic.SwapInt64(&a.currentBudget, budget) == budget {
+		return
+	}
+	a.trajectory = append(a.trajectory, int(budget))
+	a.signal()
+}
+
+// AcquireProbe ждёт свободного места в окне budget. Ошибка — только при отмене ctx.
+func (a *AdaptiveScanner) AcquireProbe(ctx context.Context) error {
+	for {
+		a.slotMu.Lock()
+		if a.inflight < a.GetBudget() {
+			a.inflight++
+			a.slotMu.Unlock()
+			return nil
+		}
+		wake := a.wake
+		a.slotMu.Unlock()
+		select {
+		case <-wake:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// ReleaseProbe освобождает место, занятое AcquireProbe.
+func (a *AdaptiveScanner) ReleaseProbe() {
+	a.slotMu.Lock()
+	a.inflight--
+	a.slotMu.Unlock()
+	a.signal()
+}
+
+func (a *AdaptiveScanner) signal() {
+	a.slotMu.Lock()
+	close(a.wake)
+	a.wake = make(chan struct{})
+	a.slotMu.Unlock()
+}
+
+// GetBudgetTrajectory возвращает последовательность значений budget, начиная с начального.
+func (a *AdaptiveScanner) GetBudgetTrajectory() []int {
+	a.adaptMu.Lock()
+	defer a.adaptMu.Unlock()
+	return append([]int(nil), a.trajectory...)
 }
 
 // RecordProbe записывает метрику probe.
@@ -103,6 +167,7 @@ func (a *AdaptiveScanner) RecordProbe(isOpen bool, isError bool) {
 
 	if isError {
 		atomic.AddInt64(&a.metrics.probesError, 1)
+		atomic.AddInt64(&a.metrics.errorSinceAdapt, 1)
 	} else if isOpen {
 		atomic.AddInt64(&a.metrics.probesOpen, 1)
 		atomic.AddInt64(&a.metrics.openSinceAdapt, 1)
@@ -127,7 +192,7 @@ func (a *AdaptiveScanner) Adapt() {
 	}
 
 	openSinceAdapt := atomic.LoadInt64(&a.metrics.openSinceAdapt)
-	errorSinceAdapt := atomic.LoadInt64(&a.metrics.probesError)
+	errorSinceAdapt := atomic.LoadInt64(&a.metrics.errorSinceAdapt)
 
 	// Рассчитываем процент ошибок
 	errorRate := float64(errorSinceAdapt) / float64(probesSinceAdapt)
@@ -158,13 +223,13 @@ func (a *AdaptiveScanner) Adapt() {
 	}
 
 	if newBudget != currentBudget {
-		atomic.StoreInt64(&a.currentBudget, newBudget)
+		a.storeBudget(newBudget)
 	}
 
 	// Сбрасываем счётчики для следующего интервала
 	atomic.StoreInt64(&a.metrics.probesSinceAdapt, 0)
 	atomic.StoreInt64(&a.metrics.openSinceAdapt, 0)
-	atomic.StoreInt64(&a.metrics.probesError, 0)
+	atomic.StoreInt64(&a.metrics.errorSinceAdapt, 0)
 
 	a.metrics.lastAdaptTime = time.Now()
 }
@@ -180,6 +245,7 @@ func (a *AdaptiveScanner) GetMetrics() ScanMetrics {
 		lastAdaptTime:    a.metrics.lastAdaptTime,
 		probesSinceAdapt: atomic.LoadInt64(&a.metrics.probesSinceAdapt),
 		openSinceAdapt:   atomic.LoadInt64(&a.metrics.openSinceAdapt),
+		errorSinceAdapt:  atomic.LoadInt64(&a.metrics.errorSinceAdapt),
 	}
 }
 
@@ -217,16 +283,33 @@ func (a *AdaptiveScanner) GetSummary() string {
 	budget := a.GetBudget()
 
 	return fmt.Sprintf("Адаптивное сканирование:\n"+
-		"  Budget: %d\n"+
+		"  Budget: %d (%s)\n"+
 		"  Probe всего: %d\n"+
 		"  Open: %d (%.1f%%)\n"+
 		"  Closed: %d\n"+
 		"  Error: %d (%.1f%%)\n"+
 		"  Duration: %v",
-		budget,
+		budget, formatBudgetTrajectory(a.GetBudgetTrajectory()),
 		metrics.probesTotal,
 		metrics.probesOpen, openRate*100,
 		metrics.probesClosed,
 		metrics.probesError, errorRate*100,
 		duration)
 }
+
+// formatBudgetTrajectory сворачивает траекторию budget в строку "512→614→430";
+// длинная траектория сокращается до начала и конца.
+func formatBudgetTrajectory(trajectory []int) string {
+	const maxShown = 8
+	parts := make([]string, 0, maxShown+1)
+	for i, b := range trajectory {
+		if len(trajectory) > maxShown && i == maxShown/2 {
+			parts = append(parts, fmt.Sprintf("…(%d)…", len(trajectory)-maxShown))
+		}
+		if len(trajectory) > maxShown && i >= maxShown/2 && i < len(trajectory)-maxShown/2 {
+			continue
+		}
+		parts = append(parts, strconv.Itoa(b))
+	}
+	return strings.Join(parts, "→")
+}
@@ -1,6 +1,7 @@
 package scanner
 
 import (
+	"context"
 	"testing"
 	"time"
 )
@@ -343,6 +344,62 @@ func TestAdaptLowOpenRate(t *testing.T) {
 	}
 }
 
+func TestAdaptBudgetTrajectory(t *testing.T) {
+	ns := NewNetworkScanner("127.0.0.1/32", 200*time.Millisecond, "1-5", 5, false)
+	scanner := NewAdaptiveScanner(ns, AdaptiveConfig{MinBudget: 64, MaxBudget: 1024, InitialBudget: 512, ErrorThreshold: 0.3})
+
+	for i := 0; i < 20; i++ {
+		scanner.RecordProbe(false, i < 8)
+	}
+	scanner.Adapt()
+
+	trajectory := scanner.GetBudgetTrajectory()
+	if len(trajectory) != 2 || trajectory[0] != 512 || trajectory[1] != scanner.GetBudget() {
+		t.Errorf("GetBudgetTrajectory() = %v", trajectory)
+	}
+	// Общий процент ошибок не сбрасывается при адаптации
+	if rate := scanner.GetErrorRate(); rate != 0.4 {
+		t.Errorf("GetErrorRate() after Adapt = %v, want 0.4", rate)
+	}
+}
+
+func TestAdaptiveScannerProbeWindow(t *testing.T) {
+	ns := NewNetworkScanner("127.0.0.1/32", 200*time.Millisecond, "1-5", 5, false)
+	scanner := NewAdaptiveScanner(ns, AdaptiveConfig{MinBudget: 1, MaxBudget: 4, InitialBudget: 2})
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if err := scanner.AcquireProbe(ctx); err != nil {
+			t.Fatalf("AcquireProbe() error = %v", err)
+		}
+	}
+
+	// Окно заполнено: третья проба ждёт освобождения слота
+	acquired := make(chan struct{})
+	go func() {
+		if err := scanner.AcquireProbe(ctx); err == nil {
+			close(acquired)
+		}
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("AcquireProbe() не должен проходить при заполненном окне")
+	case <-time.After(50 * time.Millisecond):
+	}
+	scanner.ReleaseProbe()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("AcquireProbe() не дождался освободившегося слота")
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := scanner.AcquireProbe(cancelled); err == nil {
+		t.Error("AcquireProbe() с отменённым контекстом должен вернуть ошибку")
+	}
+}
+
 // --- Test GetDuration ---
 
 func TestGetDuration(t *testing.T) {
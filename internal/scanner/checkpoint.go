@@ -0,0 +1,343 @@
+package scanner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	apperrors "network-scanner/internal/errors"
+	"network-scanner/internal/logger"
+	"network-scanner/internal/network"
+)
+
+// DefaultCheckpointDir — каталог контрольных точек по умолчанию (относительно рабочего
+// каталога, как и база inventory).
+const DefaultCheckpointDir = "checkpoints"
+
+// checkpointInterval — период сохранения контрольной точки во время запуска.
+var checkpointInterval = 5 * time.Second
+
+// CheckpointConfig — параметры запуска, по которым прерванное сканирование продолжается
+// с теми же настройками.
+type CheckpointConfig struct {
+	Network         string        `json:"network"`
+	Targets         []string      `json:"targets,omitempty"`
+	ExpandedTargets []string      `json:"expanded_targets,omitempty"` // цели после обнаружения соседей в IPv6-сетях
+	Exclude         []string      `json:"exclude,omitempty"`
+	RandomizeHosts  bool          `json:"randomize_hosts,omitempty"`
+	HostOrderSeed   int64         `json:"host_order_seed,omitempty"` // seed перестановки адресов запуска
+	ExcludePorts    string        `json:"exclude_ports,omitempty"`
+	ScanType        string        `json:"scan_type,omitempty"`
+	Interface       string        `json:"interface,omitempty"`
+	SourceIP        string        `json:"source_ip,omitempty"`
+	Proxy           string        `json:"proxy,omitempty"`
+	Discovery       []string      `json:"discovery,omitempty"`
+	Timing          string        `json:"timing,omitempty"`
+	RateLimit       RateLimit     `json:"rate_limit"`
+	PortRange       string        `json:"port_range"`
+	Timeout         time.Duration `json:"timeout"`
+	Threads         int           `json:"threads"`
+	ShowClosed      bool          `json:"show_closed,omitempty"`
+	ScanTCPPorts    bool          `json:"scan_tcp_ports"`
+	ScanUDP         bool          `json:"scan_udp,omitempty"`
+	UDPPorts        string        `json:"udp_ports,omitempty"`
+	GrabBanners     bool          `json:"grab_banners,omitempty"`
+	OSDetectActive  bool          `json:"os_detect_active,omitempty"`
+	VerbosePortLogs bool          `json:"verbose_port_logs,omitempty"`
+	Enrich          string        `json:"enrich,omitempty"`
+	LookupTimeout   time.Duration `json:"lookup_timeout,omitempty"`
+}
+
+// Checkpoint — сохранённое состояние запуска: параметры, позиция перебора целей,
+// завершённые хосты и выданные перебором, но не завершённые адреса. Хост считается
+// завершённым, когда он признан недоступным или полностью просканирован; частично
+// просканированные хосты остаются в Pending. Адреса после Cursor ещё не выдавались.
+type Checkpoint struct {
+	ID        string           `json:"id"`
+	Config    CheckpointConfig `json:"config"`
+	Cursor    uint64           `json:"cursor"` // позиция перебора целей (network.TargetIterator.Position)
+	Issued    int              `json:"issued"` // адресов выдано до Cursor
+	Completed []Result         `json:"completed"`
+	Pending   []string         `json:"pending"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
+
+// CheckpointPath возвращает путь файла контрольной точки запуска id в каталоге dir
+// (пустой dir — DefaultCheckpointDir).
+func CheckpointPath(dir, id string) string {
+	if strings.TrimSpace(dir) == "" {
+		dir = DefaultCheckpointDir
+	}
+	return filepath.Join(dir, filepath.Base(strings.TrimSpace(id))+".json")
+}
+
+// LoadCheckpoint читает контрольную точку из файла. Отсутствующий файл — NotFoundError.
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, apperrors.NewNotFoundError("checkpoint", strings.TrimSuffix(filepath.Base(path), ".json"))
+		}
+		return nil, fmt.Errorf("read checkpoint: %w", err)
+	}
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, apperrors.NewInvalidInputError("checkpoint", fmt.Sprintf("повреждённый файл %s: %v", path, err))
+	}
+	return &cp, nil
+}
+
+// saveCheckpoint атомарно записывает контрольную точку (временный файл и rename),
+// чтобы прерывание во время записи не портило предыдущее состояние.
+func saveCheckpoint(path string, cp *Checkpoint) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create checkpoint dir: %w", err)
+	}
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return fmt.Errorf("marshal checkpoint: %w", err)
+	}
+	tmp := path + ".tmp"
+	// Параметры могут содержать пароль прокси: файл доступен только владельцу
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+	return nil
+}
+
+// NewScannerFromCheckpoint создаёт сканер с параметрами контрольной точки,
+// который продолжит запуск с места остановки.
+func NewScannerFromCheckpoint(cp *Checkpoint) *NetworkScanner {
+	c := cp.Config
+	ns := NewNetworkScanner(c.Network, c.Timeout, c.PortRange, c.Threads, c.ShowClosed)
+	if len(c.Targets) > 0 {
+		ns.SetTargets(c.Targets)
+	}
+	ns.SetExclude(c.Exclude)
+	ns.SetRandomizeHosts(c.RandomizeHosts)
+	ns.SetExcludePorts(c.ExcludePorts)
+	ns.SetScanType(c.ScanType)
+	ns.SetSource(c.Interface, c.SourceIP)
+	ns.SetProxy(c.Proxy)
+	ns.SetDiscovery(c.Discovery)
+	ns.SetTiming(c.Timing)
+	ns.SetRateLimit(c.RateLimit)
+	ns.SetScanTCPPorts(c.ScanTCPPorts)
+	ns.SetScanUDP(c.ScanUDP)
+	ns.SetUDPPorts(c.UDPPorts)
+	ns.SetGrabBanners(c.GrabBanners)
+	ns.SetOSDetectActive(c.OSDetectActive)
+	ns.SetVerbosePortLogs(c.VerbosePortLogs)
+	ns.SetEnrich(c.Enrich)
+	ns.SetLookupTimeout(c.LookupTimeout)
+	ns.Resume(cp)
+	return ns
+}
+
+// CheckpointConfig возвращает текущие параметры сканера в виде, сохраняемом в контрольной точке.
+func (ns *NetworkScanner) CheckpointConfig() CheckpointConfig {
+	return CheckpointConfig{
+		Network:         ns.network,
+		Targets:         ns.targets,
+		ExpandedTargets: ns.expandedTargets,
+		Exclude:         ns.exclude,
+		RandomizeHosts:  ns.randomizeHosts,
+		HostOrderSeed:   ns.hostOrderSeed,
+		ExcludePorts:    ns.excludePorts,
+		ScanType:        ns.scanType,
+		Interface:       ns.sourceInterface,
+		SourceIP:        ns.sourceIP,
+		Proxy:           ns.proxyURL,
+		Discovery:       ns.discovery,
+		Timing:          ns.timing,
+		RateLimit:       ns.rateLimit,
+		PortRange:       ns.portRange,
+		Timeout:         ns.timeout,
+		Threads:         ns.threads,
+		ShowClosed:      ns.showClosed,
+		ScanTCPPorts:    ns.scanTCPPorts,
+		ScanUDP:         ns.scanUDP,
+		UDPPorts:        ns.udpPorts,
+		GrabBanners:     ns.grabBanners,
+		OSDetectActive:  ns.osDetectActive,
+		VerbosePortLogs: ns.verbosePortLogs,
+		Enrich:          ns.enrichSpec,
+		LookupTimeout:   ns.lookupTimeout,
+	}
+}
+
+// SetCheckpoint включает контрольные точки: во время ScanContext состояние запуска id
+// каждые checkpointInterval и при остановке сохраняется в path. После успешного
+// завершения файл удаляется.
+func (ns *NetworkScanner) SetCheckpoint(path, id string) {
+	ns.checkpointPath = path
+	ns.checkpointID = id
+}
+
+// Resume задаёт контрольную точку, с которой продолжит следующий ScanContext: завершённые
+// хосты сразу попадают в результаты (и в HostCallback), сканируются только адреса из Pending
+// и ещё не выданные перебором целей.
+// Параметры сканера должны совпадать с cp.Config (см. NewScannerFromCheckpoint).
+func (ns *NetworkScanner) Resume(cp *Checkpoint) {
+	ns.resume = cp
+	if ns.checkpointID == "" && cp != nil {
+		ns.checkpointID = cp.ID
+	}
+}
+
+// checkpointState — позиция перебора целей и незавершённые адреса текущего запуска.
+// Завершённые адреса не хранятся: ими считаются все выданные до cursor, кроме inflight.
+type checkpointState struct {
+	mu       sync.Mutex
+	cursor   uint64
+	issued   int
+	inflight map[string]struct{}
+}
+
+func newCheckpointState() *checkpointState {
+	return &checkpointState{inflight: make(map[string]struct{})}
+}
+
+// issue отмечает адрес, выданный перебором целей; pos — позиция перебора после него (nil-безопасно).
+func (s *checkpointState) issue(ip string, pos uint64) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.inflight[ip] = struct{}{}
+	s.issued++
+	s.cursor = pos
+	s.mu.Unlock()
+}
+
+// markFinished отмечает хост завершённым (nil-безопасно).
+func (s *checkpointState) markFinished(ip string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	delete(s.inflight, ip)
+	s.mu.Unlock()
+}
+
+// applyResume публикует завершённые хосты контрольной точки, переводит перебор целей
+// на сохранённую позицию и возвращает незавершённые адреса, а также число адресов,
+// проверенных до прерывания.
+func (ns *NetworkScanner) applyResume(targets *network.TargetIterator) ([]net.IP, int) {
+	cp := ns.resume
+	ns.resume = nil
+	for _, r := range cp.Completed {
+		ns.publishHost(r)
+	}
+	targets.Seek(cp.Cursor)
+	pending := make([]net.IP, 0, len(cp.Pending))
+	for _, s := range cp.Pending {
+		if ip := net.ParseIP(s); ip != nil {
+			pending = append(pending, ip)
+		}
+	}
+	if st := ns.checkpoint; st != nil {
+		st.cursor, st.issued = cp.Cursor, cp.Issued
+		for _, ip := range pending {
+			st.inflight[ip.String()] = struct{}{}
+		}
+	}
+	checked := cp.Issued - len(pending)
+	if checked < 0 {
+		checked = 0
+	}
+	logger.Log("Продолжение сканирования %s: завершено хостов %d, проверено адресов %d, незавершённых %d", cp.ID, len(cp.Completed), checked, len(pending))
+	return pending, checked
+}
+
+// snapshotCheckpoint собирает контрольную точку из текущего состояния запуска.
+func (ns *NetworkScanner) snapshotCheckpoint(config CheckpointConfig) *Checkpoint {
+	s := ns.checkpoint
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cp := &Checkpoint{
+		ID:        ns.checkpointID,
+		Config:    config,
+		Cursor:    s.cursor,
+		Issued:    s.issued,
+		Completed: make([]Result, 0),
+		Pending:   make([]string, 0, len(s.inflight)),
+		UpdatedAt: time.Now().UTC(),
+	}
+	for ip := range s.inflight {
+		cp.Pending = append(cp.Pending, ip)
+	}
+	sort.Strings(cp.Pending)
+	// Результаты копируются под ns.mu: отложенные поиски MAC и имён обновляют их на месте.
+	ns.mu.RLock()
+	results := append([]Result(nil), ns.results...)
+	ns.mu.RUnlock()
+	seen := make(map[string]struct{})
+	for _, r := range results {
+		if _, ok := s.inflight[network.StripZone(r.IP)]; ok {
+			continue
+		}
+		if _, dup := seen[r.IP]; dup {
+			continue
+		}
+		seen[r.IP] = struct{}{}
+		cp.Completed = append(cp.Completed, r)
+	}
+	return cp
+}
+
+// startCheckpointing периодически сохраняет контрольную точку, пока не будет вызвана
+// возвращённая функция. Она записывает итоговое состояние либо, если запуск
+// завершён полностью (complete), удаляет файл.
+func (ns *NetworkScanner) startCheckpointing() func(complete bool) {
+	if ns.checkpointPath == "" {
+		return func(bool) {}
+	}
+	config := ns.CheckpointConfig()
+	path := ns.checkpointPath
+	save := func() {
+		if err := saveCheckpoint(path, ns.snapshotCheckpoint(config)); err != nil {
+			logger.Log("Не удалось сохранить контрольную точку %s: %v", path, err)
+		}
+	}
+	save()
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(checkpointInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				save()
+			case <-stop:
+				return
+			}
+		}
+	}()
+	return func(complete bool) {
+		close(stop)
+		<-done
+		if complete {
+			if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				logger.LogDebug("Не удалось удалить контрольную точку %s: %v", path, err)
+			}
+			return
+		}
+		save()
+		logger.Log("Состояние сканирования сохранено: %s (продолжить: --resume %s)", path, ns.checkpointID)
+	}
+}
@@ -0,0 +1,247 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	apperrors "network-scanner/internal/errors"
+)
+
+func TestCheckpointSaveLoad(t *testing.T) {
+	path := CheckpointPath(t.TempDir(), "scan-1")
+	if filepath.Base(path) != "scan-1.json" {
+		t.Fatalf("CheckpointPath() = %q", path)
+	}
+	if _, err := LoadCheckpoint(path); !apperrors.IsNotFound(err) {
+		t.Fatalf("LoadCheckpoint() error = %v, want NotFoundError", err)
+	}
+
+	cp := &Checkpoint{
+		ID:        "scan-1",
+		Config:    CheckpointConfig{Network: "192.0.2.0/30", PortRange: "80", Timeout: time.Second, Threads: 4, Discovery: []string{DiscoveryTCP}},
+		Completed: []Result{{IP: "192.0.2.1", Ports: []PortInfo{{Port: 80, State: "open", Protocol: "tcp"}}}},
+		Pending:   []string{"192.0.2.2"},
+	}
+	if err := saveCheckpoint(path, cp); err != nil {
+		t.Fatalf("saveCheckpoint() error = %v", err)
+	}
+	got, err := LoadCheckpoint(path)
+	if err != nil {
+		t.Fatalf("LoadCheckpoint() error = %v", err)
+	}
+	if got.Config.Timeout != time.Second || len(got.Completed) != 1 || got.Completed[0].Ports[0].Port != 80 || got.Pending[0] != "192.0.2.2" {
+		t.Errorf("LoadCheckpoint() = %+v", got)
+	}
+
+	if err := os.WriteFile(path, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadCheckpoint(path); !apperrors.IsInvalidInput(err) {
+		t.Errorf("LoadCheckpoint() повреждённого файла error = %v, want InvalidInputError", err)
+	}
+}
+
+// resultSet возвращает хосты и их открытые порты в сравнимом виде.
+func resultSet(results []Result) []string {
+	out := make([]string, 0, len(results))
+	for _, r := range results {
+		ports := make([]string, 0, len(r.Ports))
+		for _, p := range r.Ports {
+			ports = append(ports, fmt.Sprintf("%d/%s", p.Port, p.Protocol))
+		}
+		out = append(out, r.IP+" "+strings.Join(ports, ","))
+	}
+	sort.Strings(out)
+	return out
+}
+
+// cancelProber отменяет запуск после заданного числа проверок доступности.
+type cancelProber struct {
+	setProber
+	after  int32
+	pinged *int32
+	cancel func()
+}
+
+func (p cancelProber) Ping(ip string) (bool, error) {
+	if atomic.AddInt32(p.pinged, 1) == p.after {
+		p.cancel()
+	}
+	return p.setProber.Ping(ip)
+}
+
+func TestScanContextResumeFromCheckpoint(t *testing.T) {
+	alive := setProber{alive: map[string]bool{"192.0.2.1": true, "192.0.2.3": true, "192.0.2.5": true, "192.0.2.6": true}}
+	newScanner := func() *NetworkScanner {
+		ns := NewScanner("192.0.2.1-8", 100*time.Millisecond, "80-81", 1, false, alive, stubPortScanner{openPort: 80}, nil)
+		ns.SetExcludePorts("161")
+		return ns
+	}
+
+	full, err := newScanner().ScanContext(context.Background())
+	if err != nil {
+		t.Fatalf("ScanContext() error = %v", err)
+	}
+	want := resultSet(full.Results)
+
+	// Прерываем запуск после первого готового хоста
+	path := CheckpointPath(t.TempDir(), "scan-resume")
+	ns := newScanner()
+	ns.SetCheckpoint(path, "scan-resume")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ns.SetHostCallback(func(Result) { cancel() })
+	if _, err := ns.ScanContext(ctx); !apperrors.IsCancelled(err) {
+		t.Fatalf("ScanContext() error = %v, want CancelledError", err)
+	}
+	cp, err := LoadCheckpoint(path)
+	if err != nil {
+		t.Fatalf("после прерывания контрольная точка не сохранена: %v", err)
+	}
+	if cp.ID != "scan-resume" || cp.Config.PortRange != "80-81" || cp.Config.ExcludePorts != "161" {
+		t.Errorf("контрольная точка = %+v", cp)
+	}
+	if len(cp.Pending) == 0 || len(cp.Completed)+len(cp.Pending) > 8 {
+		t.Fatalf("Completed/Pending = %d/%d", len(cp.Completed), len(cp.Pending))
+	}
+
+	// Продолжение сканирует только незавершённые адреса
+	resumed := newScanner()
+	resumed.SetCheckpoint(path, "scan-resume")
+	resumed.Resume(cp)
+	var mu sync.Mutex
+	var published []Result
+	resumed.SetHostCallback(func(r Result) {
+		mu.Lock()
+		published = append(published, r)
+		mu.Unlock()
+	})
+	summary, err := resumed.ScanContext(context.Background())
+	if err != nil {
+		t.Fatalf("ScanContext() после Resume error = %v", err)
+	}
+	if got := resultSet(summary.Results); strings.Join(got, ";") != strings.Join(want, ";") {
+		t.Errorf("результат продолжения = %v, want %v", got, want)
+	}
+	if got := resultSet(published); strings.Join(got, ";") != strings.Join(want, ";") {
+		t.Errorf("HostCallback получил %v, want %v", got, want)
+	}
+	if summary.TotalHosts != full.TotalHosts || summary.AliveHosts != full.AliveHosts {
+		t.Errorf("TotalHosts/AliveHosts = %d/%d, want %d/%d", summary.TotalHosts, summary.AliveHosts, full.TotalHosts, full.AliveHosts)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("после успешного завершения контрольная точка должна быть удалена: %v", err)
+	}
+}
+
+func TestScanContextInvalidConfigWritesNoCheckpoint(t *testing.T) {
+	for name, configure := range map[string]func(*NetworkScanner){
+		"scan_type":  func(ns *NetworkScanner) { ns.SetScanType("xmas") },
+		"timing":     func(ns *NetworkScanner) { ns.SetTiming("ludicrous") },
+		"rate_limit": func(ns *NetworkScanner) { ns.SetRateLimit(RateLimit{MaxPerHost: -1}) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			ns := NewScanner("192.0.2.1-2", 100*time.Millisecond, "80", 1, false, setProber{}, stubPortScanner{}, nil)
+			configure(ns)
+			path := CheckpointPath(t.TempDir(), "scan-invalid")
+			ns.SetCheckpoint(path, "scan-invalid")
+			if _, err := ns.ScanContext(context.Background()); !apperrors.IsInvalidInput(err) {
+				t.Fatalf("ScanContext() error = %v, want InvalidInputError", err)
+			}
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Errorf("неверные параметры не должны оставлять контрольную точку: %v", err)
+			}
+		})
+	}
+}
+
+func TestSnapshotCheckpointConcurrentLookups(t *testing.T) {
+	ns := NewScanner("192.0.2.1", 100*time.Millisecond, "80", 1, false, setProber{}, stubPortScanner{}, nil)
+	ns.checkpoint = newCheckpointState()
+	ns.results = []Result{{IP: "192.0.2.1"}}
+
+	// Параллельная запись имитирует completeLookups, обновляющий ns.results на месте.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			ns.mu.Lock()
+			ns.results[0] = Result{IP: "192.0.2.1", Hostname: fmt.Sprintf("host-%d", i)}
+			ns.mu.Unlock()
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		if cp := ns.snapshotCheckpoint(CheckpointConfig{}); len(cp.Completed) != 1 {
+			t.Fatalf("Completed = %d, want 1", len(cp.Completed))
+		}
+	}
+	<-done
+}
+
+func TestScanContextResumeDuringDiscovery(t *testing.T) {
+	orig := discoveryBatchSize
+	discoveryBatchSize = 8
+	t.Cleanup(func() { discoveryBatchSize = orig })
+
+	alive := setProber{alive: map[string]bool{}}
+	for i := 1; i <= 64; i += 5 {
+		alive.alive[fmt.Sprintf("198.51.100.%d", i)] = true
+	}
+	for _, randomize := range []bool{false, true} {
+		t.Run(fmt.Sprintf("randomize=%v", randomize), func(t *testing.T) {
+			newScanner := func(prober NetworkProber) *NetworkScanner {
+				ns := NewScanner("198.51.100.1-64", 100*time.Millisecond, "80", 4, false, prober, stubPortScanner{openPort: 80}, nil)
+				ns.SetExcludePorts("161")
+				ns.SetRandomizeHosts(randomize)
+				return ns
+			}
+			full, err := newScanner(alive).ScanContext(context.Background())
+			if err != nil {
+				t.Fatalf("ScanContext() error = %v", err)
+			}
+
+			path := CheckpointPath(t.TempDir(), "scan-discovery")
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			var pinged int32
+			ns := newScanner(cancelProber{setProber: alive, after: 20, pinged: &pinged, cancel: cancel})
+			ns.SetCheckpoint(path, "scan-discovery")
+			if _, err := ns.ScanContext(ctx); !apperrors.IsCancelled(err) {
+				t.Fatalf("ScanContext() error = %v, want CancelledError", err)
+			}
+			cp, err := LoadCheckpoint(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cp.Issued >= 64 || cp.Cursor == 0 {
+				t.Fatalf("перебор должен прерваться на середине: Cursor=%d Issued=%d", cp.Cursor, cp.Issued)
+			}
+			if randomize != (cp.Config.HostOrderSeed != 0) {
+				t.Errorf("HostOrderSeed = %d при randomize=%v", cp.Config.HostOrderSeed, randomize)
+			}
+
+			var resumedPings int32
+			resumed := newScanner(cancelProber{setProber: alive, pinged: &resumedPings, cancel: func() {}})
+			resumed.SetCheckpoint(path, "scan-discovery")
+			resumed.Resume(cp)
+			summary, err := resumed.ScanContext(context.Background())
+			if err != nil {
+				t.Fatalf("ScanContext() после Resume error = %v", err)
+			}
+			if got, want := resultSet(summary.Results), resultSet(full.Results); strings.Join(got, ";") != strings.Join(want, ";") {
+				t.Errorf("результат продолжения = %v, want %v", got, want)
+			}
+			if int(resumedPings) != 64-(cp.Issued-len(cp.Pending)) {
+				t.Errorf("после продолжения проверено %d адресов, want %d", resumedPings, 64-(cp.Issued-len(cp.Pending)))
+			}
+		})
+	}
+}
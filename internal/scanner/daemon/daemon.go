@@ -7,6 +7,7 @@ import (
 	"sync"
 	"time"
 
+	"network-scanner/internal/network"
 	"network-scanner/internal/scanner"
 )
 
@@ -14,6 +15,7 @@ type EventKind string
 
 const (
 	EventProgress EventKind = "progress"
+	EventHost     EventKind = "host"
 	EventDone     EventKind = "done"
 	EventError    EventKind = "error"
 	EventStopped  EventKind = "stopped"
@@ -26,22 +28,78 @@ type Event struct {
 	Total       int
 	Message     string
 	Percent     float64
+	Host        *scanner.Result // только для EventHost
 	Results     []scanner.Result
 	Diagnostics string
-	Err         error
+	Err         error // для EventError: типизированная ошибка из internal/errors
 }
 
 type Config struct {
-	NetworkCIDR    string
+	NetworkCIDR    string            // сеть или список целей через запятую
+	Targets        []string          // дополнительные цели (CIDR, диапазоны, IP, имена хостов)
+	Exclude        []string          // цели, которые не сканируются (тот же формат)
+	ExcludePorts   string            // порты, которые не опрашиваются (формат PortRange)
+	RandomizeHosts bool              // проверять адреса в псевдослучайном порядке
+	ScanType       string            // scanner.ScanTypeConnect (по умолчанию) или scanner.ScanTypeSYN
+	Interface      string            // интерфейс, с которого уходят пробы; пусто — выбор ОС
+	SourceIP       string            // адрес источника проб; пусто — выбор ОС
+	Proxy          string            // прокси для TCP-проб (socks5:// или ssh://); пусто — напрямую
+	Discovery      []string          // способы обнаружения хостов (scanner.DiscoveryARP/ICMP/TCP); пусто — TCP
+	Timing         string            // профиль скорости (scanner.TimingPolite/Normal/Aggressive); задаёт Timeout и Threads, если они нулевые
+	RateLimit      scanner.RateLimit // ограничения интенсивности проб; ненулевые поля перекрывают профиль
 	Timeout        time.Duration
 	PortRange      string
 	Threads        int
 	ShowClosed     bool
 	ScanTCPPorts   bool
 	ScanUDP        bool
+	UDPPorts       string // UDP-порты (формат PortRange); пусто — network.DefaultUDPPorts
 	GrabBanners    bool
 	OSDetectActive bool
 	VerbosePortLog bool
+	Enrich         string        // настройка стадий обогащения (scanner.ParseEnrichConfig); пусто — все стадии
+	LookupTimeout  time.Duration // предел завершающего прохода MAC и имён; 0 — scanner.DefaultLookupTimeout
+	// CheckpointID включает контрольные точки запуска (файл CheckpointDir/<id>.json);
+	// пусто — без контрольных точек. CheckpointDir пуст — scanner.DefaultCheckpointDir.
+	CheckpointID  string
+	CheckpointDir string
+	// Resume — продолжить прерванный запуск CheckpointID; параметры сканирования
+	// берутся из контрольной точки.
+	Resume bool
+}
+
+// configFromCheckpoint возвращает параметры запуска, сохранённые в контрольной точке.
+func configFromCheckpoint(cfg Config, cp *scanner.Checkpoint) Config {
+	c := cp.Config
+	return Config{
+		NetworkCIDR:    c.Network,
+		Targets:        c.Targets,
+		Exclude:        c.Exclude,
+		ExcludePorts:   c.ExcludePorts,
+		RandomizeHosts: c.RandomizeHosts,
+		ScanType:       c.ScanType,
+		Interface:      c.Interface,
+		SourceIP:       c.SourceIP,
+		Proxy:          c.Proxy,
+		Discovery:      c.Discovery,
+		Timing:         c.Timing,
+		RateLimit:      c.RateLimit,
+		Timeout:        c.Timeout,
+		PortRange:      c.PortRange,
+		Threads:        c.Threads,
+		ShowClosed:     c.ShowClosed,
+		ScanTCPPorts:   c.ScanTCPPorts,
+		ScanUDP:        c.ScanUDP,
+		UDPPorts:       c.UDPPorts,
+		GrabBanners:    c.GrabBanners,
+		OSDetectActive: c.OSDetectActive,
+		VerbosePortLog: c.VerbosePortLogs,
+		Enrich:         c.Enrich,
+		LookupTimeout:  c.LookupTimeout,
+		CheckpointID:   cfg.CheckpointID,
+		CheckpointDir:  cfg.CheckpointDir,
+		Resume:         true,
+	}
 }
 
 type Runner struct {
@@ -79,8 +137,22 @@ func (r *Runner) Start(cfg Config) error {
 		r.mu.Unlock()
 		return errors.New("scan runner is already running")
 	}
+	var resume *scanner.Checkpoint
+	if cfg.Resume {
+		cp, err := scanner.LoadCheckpoint(scanner.CheckpointPath(cfg.CheckpointDir, cfg.CheckpointID))
+		if err != nil {
+			r.mu.Unlock()
+			r.emit(Event{Kind: EventError, Message: err.Error(), Err: err})
+			return err
+		}
+		resume = cp
+		cfg = configFromCheckpoint(cfg, cp)
+	}
 	ctx, cancel := context.WithCancel(context.Background())
 	r.cancel = cancel
+	if tmpl, ok := scanner.LookupTimingTemplate(cfg.Timing); ok {
+		cfg.Timeout, cfg.Threads = tmpl.Defaults(cfg.Timeout, cfg.Threads)
+	}
 	ns := r.factory(cfg)
 	if ns == nil {
 		r.cancel = nil
@@ -91,11 +163,32 @@ func (r *Runner) Start(cfg Config) error {
 		return err
 	}
 	r.running = true
+	if len(cfg.Targets) > 0 {
+		ns.SetTargets(append(network.SplitTargets(cfg.NetworkCIDR), cfg.Targets...))
+	}
+	ns.SetExclude(cfg.Exclude)
+	ns.SetRandomizeHosts(cfg.RandomizeHosts)
+	ns.SetExcludePorts(cfg.ExcludePorts)
+	ns.SetScanType(cfg.ScanType)
+	ns.SetSource(cfg.Interface, cfg.SourceIP)
+	ns.SetProxy(cfg.Proxy)
+	ns.SetDiscovery(cfg.Discovery)
+	ns.SetTiming(cfg.Timing)
+	ns.SetRateLimit(cfg.RateLimit)
 	ns.SetScanTCPPorts(cfg.ScanTCPPorts)
 	ns.SetScanUDP(cfg.ScanUDP)
+	ns.SetUDPPorts(cfg.UDPPorts)
 	ns.SetGrabBanners(cfg.GrabBanners)
 	ns.SetOSDetectActive(cfg.OSDetectActive)
 	ns.SetVerbosePortLogs(cfg.VerbosePortLog)
+	ns.SetEnrich(cfg.Enrich)
+	ns.SetLookupTimeout(cfg.LookupTimeout)
+	if cfg.CheckpointID != "" {
+		ns.SetCheckpoint(scanner.CheckpointPath(cfg.CheckpointDir, cfg.CheckpointID), cfg.CheckpointID)
+	}
+	if resume != nil {
+		ns.Resume(resume)
+	}
 	ns.SetProgressCallback(func(stage string, current int, total int, message string) {
 		percent := 0.0
 		if total > 0 {
@@ -110,6 +203,17 @@ func (r *Runner) Start(cfg Config) error {
 			Percent: percent,
 		})
 	})
+	// Хосты отправляются по мере готовности и никогда не отбрасываются:
+	// при заполненном канале воркер сканера ждёт читателя или остановки runner.
+	ns.SetHostCallback(func(result scanner.Result) {
+		host := result
+		r.emitWait(ctx, Event{
+			Kind:    EventHost,
+			Current: len(ns.GetResults()),
+			Message: fmt.Sprintf("Хост готов: %s", host.IP),
+			Host:    &host,
+		})
+	})
 	r.scanner = ns
 	r.mu.Unlock()
 
@@ -126,21 +230,16 @@ func (r *Runner) Start(cfg Config) error {
 			r.mu.Unlock()
 		}()
 
-		done := make(chan struct{})
-		go func() {
-			defer close(done)
-			ns.Scan()
-		}()
-
-		select {
-		case <-ctx.Done():
-			ns.Stop()
+		summary, err := ns.ScanContext(ctx)
+		switch {
+		case ctx.Err() != nil:
 			r.emit(Event{Kind: EventStopped, Message: "Сканирование остановлено"})
-			return
-		case <-done:
-			r.emit(Event{
+		case err != nil:
+			r.emitWait(ctx, Event{Kind: EventError, Message: err.Error(), Err: err})
+		default:
+			r.emitWait(ctx, Event{
 				Kind:        EventDone,
-				Results:     ns.GetResults(),
+				Results:     summary.Results,
 				Diagnostics: ns.GetDiagnosticsSummary(),
 			})
 		}
@@ -169,9 +268,20 @@ func (r *Runner) IsRunning() bool {
 	return r.running
 }
 
+// emit отправляет событие без блокировки; при заполненном канале событие отбрасывается.
+// Подходит для progress-событий, где важно только последнее значение.
 func (r *Runner) emit(ev Event) {
 	select {
 	case r.events <- ev:
 	default:
 	}
 }
+
+// emitWait отправляет событие, ожидая свободного места в канале, пока не отменён ctx.
+// Используется для событий, которые нельзя терять (EventHost, EventDone, EventError).
+func (r *Runner) emitWait(ctx context.Context, ev Event) {
+	select {
+	case r.events <- ev:
+	case <-ctx.Done():
+	}
+}
@@ -1,9 +1,13 @@
 package daemon
 
 import (
+	"encoding/json"
+	"net"
+	"os"
 	"testing"
 	"time"
 
+	apperrors "network-scanner/internal/errors"
 	"network-scanner/internal/scanner"
 )
 
@@ -56,3 +60,136 @@ func TestStartEmitsErrorWhenFactoryReturnsNil(t *testing.T) {
 		t.Fatal("expected error event in channel")
 	}
 }
+
+type stubProber struct{}
+
+func (stubProber) Ping(ip string) (bool, error)                   { return true, nil }
+func (stubProber) ResolveMAC(ip string) (net.HardwareAddr, error) { return nil, nil }
+
+type stubPortScanner struct{}
+
+func (stubPortScanner) ScanPort(ip string, port int, proto string) (bool, error) {
+	return port == 80, nil
+}
+
+func (stubPortScanner) ScanPorts(ip string, ports []int, proto string) ([]int, error) {
+	return nil, nil
+}
+
+func TestHostEventsPrecedeDone(t *testing.T) {
+	r := NewRunnerWithFactory(func(cfg Config) *scanner.NetworkScanner {
+		return scanner.NewScanner(cfg.NetworkCIDR, cfg.Timeout, cfg.PortRange, cfg.Threads, false, stubProber{}, stubPortScanner{}, nil)
+	})
+	if err := r.Start(Config{NetworkCIDR: "127.0.0.0/30", Timeout: 200 * time.Millisecond, PortRange: "80", Threads: 2}); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	hosts := 0
+	deadline := time.After(10 * time.Second)
+	for {
+		select {
+		case ev := <-r.Events():
+			switch ev.Kind {
+			case EventHost:
+				if ev.Host == nil {
+					t.Fatal("host event without payload")
+				}
+				hosts++
+			case EventDone:
+				if hosts == 0 {
+					t.Fatal("expected host events before done")
+				}
+				if hosts != len(ev.Results) {
+					t.Fatalf("streamed %d hosts, done has %d results", hosts, len(ev.Results))
+				}
+				return
+			case EventError:
+				t.Fatalf("unexpected error event: %v", ev.Err)
+			}
+		case <-deadline:
+			t.Fatal("scan did not finish")
+		}
+	}
+}
+
+func TestStartEmitsTypedErrorForInvalidNetwork(t *testing.T) {
+	r := NewRunnerWithFactory(func(cfg Config) *scanner.NetworkScanner {
+		return scanner.NewScanner(cfg.NetworkCIDR, cfg.Timeout, cfg.PortRange, cfg.Threads, false, stubProber{}, stubPortScanner{}, nil)
+	})
+	if err := r.Start(Config{NetworkCIDR: "not-a-network", PortRange: "80", Threads: 1}); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case ev := <-r.Events():
+			switch ev.Kind {
+			case EventError:
+				if !apperrors.IsInvalidInput(ev.Err) {
+					t.Fatalf("expected InvalidInputError, got %v", ev.Err)
+				}
+				return
+			case EventDone:
+				t.Fatal("invalid network must not finish successfully")
+			}
+		case <-deadline:
+			t.Fatal("error event not received")
+		}
+	}
+}
+
+func TestStartResume(t *testing.T) {
+	factory := func(cfg Config) *scanner.NetworkScanner {
+		return scanner.NewScanner(cfg.NetworkCIDR, cfg.Timeout, cfg.PortRange, cfg.Threads, false, stubProber{}, stubPortScanner{}, nil)
+	}
+	dir := t.TempDir()
+
+	r := NewRunnerWithFactory(factory)
+	if err := r.Start(Config{CheckpointID: "scan-missing", CheckpointDir: dir, Resume: true}); !apperrors.IsNotFound(err) {
+		t.Fatalf("Start() без контрольной точки error = %v, want NotFoundError", err)
+	}
+
+	// Прерванный запуск: 127.0.0.1 уже готов, 127.0.0.2 ещё не проверен
+	cp := scanner.Checkpoint{
+		ID:        "scan-1",
+		Config:    scanner.CheckpointConfig{Network: "127.0.0.1-2", PortRange: "80", Timeout: 200 * time.Millisecond, Threads: 1, ScanTCPPorts: true},
+		Cursor:    2,
+		Issued:    2,
+		Completed: []scanner.Result{{IP: "127.0.0.1", Ports: []scanner.PortInfo{{Port: 80, State: "open", Protocol: "tcp"}}}},
+		Pending:   []string{"127.0.0.2"},
+	}
+	data, err := json.Marshal(cp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := scanner.CheckpointPath(dir, cp.ID)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r = NewRunnerWithFactory(factory)
+	if err := r.Start(Config{CheckpointID: cp.ID, CheckpointDir: dir, Resume: true}); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	deadline := time.After(10 * time.Second)
+	for {
+		select {
+		case ev := <-r.Events():
+			switch ev.Kind {
+			case EventDone:
+				if len(ev.Results) != 2 {
+					t.Fatalf("results = %+v, want завершённый и досканированный хосты", ev.Results)
+				}
+				if _, err := os.Stat(path); !os.IsNotExist(err) {
+					t.Errorf("контрольная точка должна быть удалена после завершения: %v", err)
+				}
+				return
+			case EventError:
+				t.Fatalf("unexpected error event: %v", ev.Err)
+			}
+		case <-deadline:
+			t.Fatal("scan did not finish")
+		}
+	}
+}
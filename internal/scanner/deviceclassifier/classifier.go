@@ -25,6 +25,21 @@ type Input struct {
 	Ports        []Port
 	DeviceVendor string
 	Hostname     string
+	// HTTPCategories — категории веб-приложений, распознанных на портах (админка роутера,
+	// NAS, принтер); сильнее эвристики по портам.
+	HTTPCategories []string
+}
+
+// httpCategoryPriority — порядок выбора среди категорий веб-приложений: интерфейс
+// устройства важнее серверного ПО, которое могло быть установлено на нём.
+var httpCategoryPriority = []string{
+	CategoryPrinter,
+	CategoryCamera,
+	CategoryNAS,
+	CategoryRouterSwitch,
+	CategoryAccessPoint,
+	CategoryIoT,
+	CategoryServer,
 }
 
 func Classify(in Input) string {
@@ -37,6 +52,9 @@ func Classify(in Input) string {
 	if len(open) == 0 {
 		return CategoryUnknown
 	}
+	if category := httpCategory(in.HTTPCategories); category != "" {
+		return category
+	}
 	vendor := strings.ToLower(strings.TrimSpace(in.DeviceVendor))
 	host := strings.ToLower(strings.TrimSpace(in.Hostname))
 
@@ -82,6 +100,18 @@ func Classify(in Input) string {
 	return CategoryUnknown
 }
 
+// httpCategory выбирает из категорий веб-приложений самую приоритетную; "" — известных нет.
+func httpCategory(categories []string) string {
+	for _, c := range httpCategoryPriority {
+		for _, got := range categories {
+			if strings.EqualFold(strings.TrimSpace(got), c) {
+				return c
+			}
+		}
+	}
+	return ""
+}
+
 func containsAny(s string, parts ...string) bool {
 	for _, p := range parts {
 		if strings.Contains(s, p) {
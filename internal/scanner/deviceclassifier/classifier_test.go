@@ -28,6 +28,14 @@ func TestClassify(t *testing.T) {
 			in:   Input{Ports: []Port{{Port: 3389, State: "open"}}},
 			want: CategoryDesktopLaptop,
 		},
+		{
+			name: "nas by http product despite router ports",
+			in: Input{
+				Ports:          []Port{{Port: 22, State: "open"}, {Port: 80, State: "open"}},
+				HTTPCategories: []string{CategoryServer, CategoryNAS},
+			},
+			want: CategoryNAS,
+		},
 		{
 			name: "unknown empty",
 			in:   Input{},
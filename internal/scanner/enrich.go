@@ -0,0 +1,595 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	apperrors "network-scanner/internal/errors"
+	"network-scanner/internal/logger"
+	"network-scanner/internal/network"
+	"network-scanner/internal/osdetect"
+)
+
+// Встроенные стадии обогащения хоста (порядок выполнения задают зависимости).
+const (
+	EnrichMAC      = "mac"      // MAC-адрес и производитель (ARP sweep, таблицы ARP/соседей, ARP-запрос)
+	EnrichHostname = "hostname" // обратный DNS (HostnameResolver сетевого prober-а)
+	EnrichSNMP     = "snmp"     // короткая UDP-проба 161, если SNMP не найден сканированием портов
+	EnrichDevice   = "device"   // тип устройства (deviceclassifier); после mac, hostname и http
+	EnrichNetBIOS  = "netbios"  // имя, рабочая группа и MAC из таблицы имён NetBIOS (UDP 137) хостов с открытыми 139/445; после mac и hostname
+	EnrichMDNS     = "mdns"     // имя, службы DNS-SD и TXT (модель, прошивка) из просмотра mDNS, если выбрано обнаружение mdns; после hostname
+	EnrichUPnP     = "upnp"     // описание устройства UPnP и пробросы портов шлюза (upnp) хостов, ответивших на SSDP; после mac
+	EnrichOS       = "os"       // эвристика ОС (osdetect); после hostname, netbios и smb
+	EnrichService  = "service"  // служба, продукт, версия и CPE открытых портов по базе проб (servicedetect); выключена по умолчанию
+	EnrichTLS      = "tls"      // версии, шифры и сертификаты TLS открытых TCP-портов (tlsinspect); после service, выключена по умолчанию
+	EnrichSSH      = "ssh"      // алгоритмы и ключи хоста SSH-серверов (sshinspect); после service, выключена по умолчанию
+	EnrichHTTP     = "http"     // отпечаток веб-приложений и распознанные продукты (httpinspect); после service и tls, выключена по умолчанию
+	EnrichSMB      = "smb"      // диалекты, подпись, имя и версия ОС SMB-серверов (smbinspect); после service и netbios, выключена по умолчанию
+)
+
+// DefaultEnrichTimeout — предел времени стадии на хост, если стадия и настройка его не задают.
+const DefaultEnrichTimeout = 2 * time.Second
+
+// ResultUpdate вносит изменения стадии в результат хоста.
+type ResultUpdate func(result *Result)
+
+// Enricher — стадия обогащения результата хоста после сканирования портов.
+type Enricher interface {
+	// Name — уникальное имя стадии: на него ссылаются зависимости, настройка и диагностика.
+	Name() string
+	// Enrich читает result — снимок после предыдущих стадий, только для чтения — и
+	// возвращает изменения (nil — изменений нет). ctx отменяется по таймауту стадии и
+	// при остановке сканирования; изменения, полученные после таймаута, отбрасываются.
+	Enrich(ctx context.Context, result Result) (ResultUpdate, error)
+}
+
+// EnricherFunc — функция стадии для NewEnricher.
+type EnricherFunc func(ctx context.Context, result Result) (ResultUpdate, error)
+
+// NewEnricher создаёт Enricher с именем name из функции fn.
+func NewEnricher(name string, fn EnricherFunc) Enricher {
+	return funcEnricher{name: name, fn: fn}
+}
+
+type funcEnricher struct {
+	name string
+	fn   EnricherFunc
+}
+
+func (e funcEnricher) Name() string { return e.name }
+
+func (e funcEnricher) Enrich(ctx context.Context, result Result) (ResultUpdate, error) {
+	return e.fn(ctx, result)
+}
+
+// EnrichStage — стадия конвейера обогащения с зависимостями и таймаутом.
+type EnrichStage struct {
+	Enricher Enricher
+	// After — стадии, которые должны завершиться раньше (их изменения видны в снимке).
+	// Выключенная зависимость не мешает запуску стадии.
+	After []string
+	// Timeout — предел времени стадии на хост; 0 — DefaultEnrichTimeout.
+	Timeout time.Duration
+	// Disabled — стадия выключена, пока её не включит EnrichConfig.Enable.
+	Disabled bool
+}
+
+// EnrichConfig — настройка стадий запуска (SetEnrich, ParseEnrichConfig).
+type EnrichConfig struct {
+	Enable   []string                 // включить стадии, выключенные по умолчанию
+	Disable  []string                 // не выполнять стадии
+	Timeouts map[string]time.Duration // таймауты стадий вместо заданных при регистрации
+}
+
+var (
+	enrichRegistryMu sync.Mutex
+	enrichRegistry   []EnrichStage
+)
+
+// RegisterEnricher добавляет стадию всем сканерам процесса; вызывается из init пакета
+// расширения. Пустое или занятое имя — паника, как у database/sql.Register.
+func RegisterEnricher(stage EnrichStage) {
+	if stage.Enricher == nil || stage.Enricher.Name() == "" {
+		panic("scanner: RegisterEnricher без имени стадии")
+	}
+	name := stage.Enricher.Name()
+	enrichRegistryMu.Lock()
+	defer enrichRegistryMu.Unlock()
+	for _, builtin := range builtinEnrichers {
+		if builtin == name {
+			panic("scanner: RegisterEnricher: имя встроенной стадии " + name)
+		}
+	}
+	for _, s := range enrichRegistry {
+		if s.Enricher.Name() == name {
+			panic("scanner: RegisterEnricher: стадия " + name + " уже зарегистрирована")
+		}
+	}
+	enrichRegistry = append(enrichRegistry, stage)
+}
+
+// builtinEnrichers — имена встроенных стадий в порядке регистрации.
+var builtinEnrichers = []string{EnrichMAC, EnrichHostname, EnrichSNMP, EnrichDevice, EnrichNetBIOS, EnrichMDNS, EnrichUPnP, EnrichOS, EnrichService, EnrichTLS, EnrichSSH, EnrichHTTP, EnrichSMB}
+
+// EnricherNames возвращает имена встроенных и зарегистрированных стадий.
+func EnricherNames() []string {
+	names := append([]string(nil), builtinEnrichers...)
+	enrichRegistryMu.Lock()
+	defer enrichRegistryMu.Unlock()
+	for _, s := range enrichRegistry {
+		names = append(names, s.Enricher.Name())
+	}
+	return names
+}
+
+// AddEnricher добавляет стадию только этому сканеру (до вызова ScanContext).
+func (ns *NetworkScanner) AddEnricher(stage EnrichStage) {
+	ns.enrichers = append(ns.enrichers, stage)
+}
+
+// SetEnrich задаёт настройку стадий строкой ParseEnrichConfig; ошибка разбора или
+// неизвестная стадия — InvalidInputError из ScanContext.
+func (ns *NetworkScanner) SetEnrich(spec string) {
+	ns.enrichSpec = spec
+}
+
+// ParseEnrichConfig разбирает настройку стадий: имена через запятую, "-имя" выключает
+// стадию, "имя" включает, "имя=500ms" задаёт таймаут (и включает). Имена не проверяются —
+// набор стадий известен только сканеру.
+func ParseEnrichConfig(spec string) (EnrichConfig, error) {
+	var cfg EnrichConfig
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if name, ok := strings.CutPrefix(item, "-"); ok {
+			if name = strings.TrimSpace(name); name == "" {
+				return EnrichConfig{}, apperrors.NewInvalidInputError("enrich", "пустое имя стадии в "+item)
+			}
+			cfg.Disable = append(cfg.Disable, name)
+			continue
+		}
+		name, value, hasTimeout := strings.Cut(item, "=")
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return EnrichConfig{}, apperrors.NewInvalidInputError("enrich", "пустое имя стадии в "+item)
+		}
+		cfg.Enable = append(cfg.Enable, name)
+		if !hasTimeout {
+			continue
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || d <= 0 {
+			return EnrichConfig{}, apperrors.NewInvalidInputError("enrich", fmt.Sprintf("некорректный таймаут стадии %s: %q", name, value))
+		}
+		if cfg.Timeouts == nil {
+			cfg.Timeouts = make(map[string]time.Duration)
+		}
+		cfg.Timeouts[name] = d
+	}
+	return cfg, nil
+}
+
+// enrichPipeline — стадии запуска, сгруппированные по уровням зависимостей: стадии
+// одного уровня выполняются параллельно над одним снимком результата.
+type enrichPipeline struct {
+	levels [][]enrichStep
+	stats  map[string]*enrichStats
+	order  []string // включённые стадии в порядке выполнения (для диагностики)
+}
+
+type enrichStep struct {
+	enricher Enricher
+	after    []string
+	timeout  time.Duration
+	stats    *enrichStats
+}
+
+// enrichStats — время и исходы стадии за запуск.
+type enrichStats struct {
+	mu       sync.Mutex
+	runs     int
+	errors   int
+	timeouts int
+	total    time.Duration
+	max      time.Duration
+}
+
+func (s *enrichStats) record(d time.Duration, err error, timedOut bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.runs++
+	s.total += d
+	if d > s.max {
+		s.max = d
+	}
+	switch {
+	case timedOut:
+		s.timeouts++
+	case err != nil:
+		s.errors++
+	}
+}
+
+// newEnrichPipeline проверяет стадии и настройку и упорядочивает включённые стадии.
+func newEnrichPipeline(stages []EnrichStage, cfg EnrichConfig) (*enrichPipeline, error) {
+	byName := make(map[string]EnrichStage, len(stages))
+	names := make([]string, 0, len(stages))
+	for _, s := range stages {
+		if s.Enricher == nil || s.Enricher.Name() == "" {
+			return nil, apperrors.NewInvalidInputError("enrich", "стадия без имени")
+		}
+		name := s.Enricher.Name()
+		if _, dup := byName[name]; dup {
+			return nil, apperrors.NewInvalidInputError("enrich", "стадия "+name+" добавлена дважды")
+		}
+		byName[name] = s
+		names = append(names, name)
+	}
+	known := func(list []string) error {
+		for _, name := range list {
+			if _, ok := byName[name]; !ok {
+				return apperrors.NewInvalidInputError("enrich", fmt.Sprintf("неизвестная стадия %q (доступны: %s)", name, strings.Join(names, ", ")))
+			}
+		}
+		return nil
+	}
+	timeoutNames := make([]string, 0, len(cfg.Timeouts))
+	for name := range cfg.Timeouts {
+		timeoutNames = append(timeoutNames, name)
+	}
+	sort.Strings(timeoutNames)
+	for _, list := range [][]string{cfg.Enable, cfg.Disable, timeoutNames} {
+		if err := known(list); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range names {
+		if err := known(byName[name].After); err != nil {
+			return nil, apperrors.WrapErrorf(err, "зависимость стадии %s", name)
+		}
+	}
+
+	enabled := make(map[string]bool, len(names))
+	for _, name := range names {
+		enabled[name] = !byName[name].Disabled
+	}
+	for _, name := range cfg.Enable {
+		enabled[name] = true
+	}
+	for _, name := range cfg.Disable {
+		enabled[name] = false
+	}
+
+	// Уровень стадии — на единицу больше наибольшего уровня её включённых зависимостей
+	p := &enrichPipeline{stats: make(map[string]*enrichStats)}
+	level := make(map[string]int, len(names))
+	for placed := 0; placed < len(names); {
+		progress := false
+		for _, name := range names {
+			if _, done := level[name]; done {
+				continue
+			}
+			l, ready := 0, true
+			for _, dep := range byName[name].After {
+				if !enabled[dep] {
+					continue
+				}
+				dl, ok := level[dep]
+				if !ok {
+					ready = false
+					break
+				}
+				if dl+1 > l {
+					l = dl + 1
+				}
+			}
+			if !ready {
+				continue
+			}
+			level[name] = l
+			placed++
+			progress = true
+			if !enabled[name] {
+				continue
+			}
+			for len(p.levels) <= l {
+				p.levels = append(p.levels, nil)
+			}
+			timeout := byName[name].Timeout
+			if t, ok := cfg.Timeouts[name]; ok {
+				timeout = t
+			}
+			if timeout <= 0 {
+				timeout = DefaultEnrichTimeout
+			}
+			stats := &enrichStats{}
+			p.stats[name] = stats
+			p.levels[l] = append(p.levels[l], enrichStep{enricher: byName[name].Enricher, after: byName[name].After, timeout: timeout, stats: stats})
+		}
+		if !progress {
+			cycle := make([]string, 0)
+			for _, name := range names {
+				if _, done := level[name]; !done {
+					cycle = append(cycle, name)
+				}
+			}
+			return nil, apperrors.NewInvalidInputError("enrich", "циклическая зависимость стадий: "+strings.Join(cycle, ", "))
+		}
+	}
+	for _, steps := range p.levels {
+		for _, step := range steps {
+			p.order = append(p.order, step.enricher.Name())
+		}
+	}
+	return p, nil
+}
+
+// run выполняет стадии над result. Стадии уровня получают общий снимок, их изменения
+// применяются в порядке регистрации после завершения уровня. Отмена ctx прекращает запуск
+// следующих уровней.
+func (p *enrichPipeline) run(ctx context.Context, result *Result) {
+	p.runSteps(ctx, result, nil)
+}
+
+// runAfter повторно выполняет только стадии, зависящие (прямо или через другие стадии)
+// от changed, — после того как завершающий проход дополнил результат.
+func (p *enrichPipeline) runAfter(ctx context.Context, result *Result, changed ...string) {
+	if p == nil {
+		return
+	}
+	selected := make(map[string]bool)
+	for _, name := range changed {
+		selected[name] = false
+	}
+	for _, steps := range p.levels {
+		for _, step := range steps {
+			for _, dep := range step.after {
+				if _, ok := selected[dep]; ok {
+					selected[step.enricher.Name()] = true
+					break
+				}
+			}
+		}
+	}
+	p.runSteps(ctx, result, selected)
+}
+
+// enabled сообщает, выполняется ли стадия name в этом запуске.
+func (p *enrichPipeline) enabled(name string) bool {
+	return p != nil && p.stats[name] != nil
+}
+
+// runSteps выполняет уровни конвейера; selected != nil ограничивает запуск стадиями,
+// отмеченными true.
+func (p *enrichPipeline) runSteps(ctx context.Context, result *Result, selected map[string]bool) {
+	if p == nil {
+		return
+	}
+	for _, all := range p.levels {
+		if ctx.Err() != nil {
+			return
+		}
+		steps := all
+		if selected != nil {
+			steps = make([]enrichStep, 0, len(all))
+			for _, step := range all {
+				if selected[step.enricher.Name()] {
+					steps = append(steps, step)
+				}
+			}
+			if len(steps) == 0 {
+				continue
+			}
+		}
+		snapshot := cloneResult(*result)
+		updates := make([]ResultUpdate, len(steps))
+		var wg sync.WaitGroup
+		for i, step := range steps {
+			wg.Add(1)
+			go func(i int, step enrichStep) {
+				defer wg.Done()
+				updates[i] = step.run(ctx, snapshot)
+			}(i, step)
+		}
+		wg.Wait()
+		for _, update := range updates {
+			if update != nil {
+				update(result)
+			}
+		}
+	}
+}
+
+// run выполняет стадию с её таймаутом; стадия, не уложившаяся в таймаут, продолжает
+// работу в фоне, но её изменения не применяются.
+func (s enrichStep) run(ctx context.Context, snapshot Result) ResultUpdate {
+	type outcome struct {
+		update ResultUpdate
+		err    error
+	}
+	stageCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	name := s.enricher.Name()
+	start := time.Now()
+	done := make(chan outcome, 1)
+	go func() {
+		update, err := s.enricher.Enrich(stageCtx, snapshot)
+		done <- outcome{update, err}
+	}()
+	select {
+	case o := <-done:
+		s.stats.record(time.Since(start), o.err, false)
+		if o.err != nil {
+			logger.LogDebug("Хост %s: стадия %s: %v", snapshot.IP, name, o.err)
+			return nil
+		}
+		return o.update
+	case <-stageCtx.Done():
+		s.stats.record(time.Since(start), nil, ctx.Err() == nil)
+		logger.LogDebug("Хост %s: стадия %s не завершилась за %v", snapshot.IP, name, s.timeout)
+		return nil
+	}
+}
+
+// summary форматирует время стадий для GetDiagnosticsSummary.
+func (p *enrichPipeline) summary() string {
+	if p == nil || len(p.order) == 0 {
+		return "n/a"
+	}
+	parts := make([]string, 0, len(p.order))
+	for _, name := range p.order {
+		s := p.stats[name]
+		s.mu.Lock()
+		avg := time.Duration(0)
+		if s.runs > 0 {
+			avg = s.total / time.Duration(s.runs)
+		}
+		parts = append(parts, fmt.Sprintf("%s=%d/%v/%v/%d/%d", name, s.runs, avg.Round(time.Microsecond), s.max.Round(time.Microsecond), s.timeouts, s.errors))
+		s.mu.Unlock()
+	}
+	return strings.Join(parts, " ")
+}
+
+// cloneResult копирует результат вместе со срезами, чтобы стадии, продолжающие работу
+// после таймаута, не читали изменяемые данные.
+func cloneResult(r Result) Result {
+	r.Ports = append([]PortInfo(nil), r.Ports...)
+	r.Protocols = append([]string(nil), r.Protocols...)
+	r.Addresses = append([]string(nil), r.Addresses...)
+	return r
+}
+
+// builtinEnrichStages — встроенные стадии сканера; таймауты MAC и имени короткие,
+// чтобы медленный ARP/DNS не задерживал выдачу хоста. Стадии service, tls, ssh, http и smb
+// выключены по умолчанию: они открывают по нескольку соединений на каждый проверяемый порт.
+func (ns *NetworkScanner) builtinEnrichStages() []EnrichStage {
+	return []EnrichStage{
+		{Enricher: NewEnricher(EnrichMAC, ns.enrichMAC), Timeout: macTimeout},
+		{Enricher: NewEnricher(EnrichHostname, ns.enrichHostname), Timeout: hostnameTimeout},
+		{Enricher: NewEnricher(EnrichSNMP, ns.enrichSNMP), Timeout: 2 * snmpProbeTimeoutMax},
+		{Enricher: NewEnricher(EnrichDevice, ns.enrichDevice), After: []string{EnrichMAC, EnrichHostname, EnrichHTTP}},
+		{Enricher: NewEnricher(EnrichNetBIOS, ns.enrichNetBIOS), After: []string{EnrichMAC, EnrichHostname}, Timeout: 2 * netbiosProbeTimeoutMax},
+		{Enricher: NewEnricher(EnrichMDNS, ns.enrichMDNS), After: []string{EnrichHostname}, Timeout: mdnsStageTimeout},
+		{Enricher: NewEnricher(EnrichUPnP, ns.enrichUPnP), After: []string{EnrichMAC}, Timeout: upnpInventoryTimeout},
+		{Enricher: NewEnricher(EnrichOS, ns.enrichOS), After: []string{EnrichHostname, EnrichNetBIOS, EnrichSMB}},
+		{Enricher: NewEnricher(EnrichService, ns.enrichService), Timeout: serviceDetectTimeout, Disabled: true},
+		{Enricher: NewEnricher(EnrichTLS, ns.enrichTLS), After: []string{EnrichService}, Timeout: tlsInventoryTimeout, Disabled: true},
+		{Enricher: NewEnricher(EnrichSSH, ns.enrichSSH), After: []string{EnrichService}, Timeout: sshInventoryTimeout, Disabled: true},
+		{Enricher: NewEnricher(EnrichHTTP, ns.enrichHTTP), After: []string{EnrichService, EnrichTLS}, Timeout: httpInventoryTimeout, Disabled: true},
+		{Enricher: NewEnricher(EnrichSMB, ns.enrichSMB), After: []string{EnrichService, EnrichNetBIOS}, Timeout: smbInventoryTimeout, Disabled: true},
+	}
+}
+
+// enrichPipeline собирает конвейер запуска: встроенные, зарегистрированные и добавленные
+// сканеру стадии с настройкой SetEnrich.
+func (ns *NetworkScanner) enrichPipeline() (*enrichPipeline, error) {
+	cfg, err := ParseEnrichConfig(ns.enrichSpec)
+	if err != nil {
+		return nil, err
+	}
+	stages := ns.builtinEnrichStages()
+	enrichRegistryMu.Lock()
+	stages = append(stages, enrichRegistry...)
+	enrichRegistryMu.Unlock()
+	stages = append(stages, ns.enrichers...)
+	return newEnrichPipeline(stages, cfg)
+}
+
+// ValidateEnrich проверяет настройку стадий (формат ParseEnrichConfig) по встроенным и
+// зарегистрированным стадиям; ошибка — InvalidInputError.
+func ValidateEnrich(spec string) error {
+	_, err := (&NetworkScanner{enrichSpec: spec}).enrichPipeline()
+	return err
+}
+
+// enrichMAC определяет MAC и производителя; за прокси хост не в локальной сети, MAC недоступен.
+func (ns *NetworkScanner) enrichMAC(_ context.Context, r Result) (ResultUpdate, error) {
+	if ns.proxy != nil {
+		return nil, nil
+	}
+	ip, _ := network.SplitZone(r.IP)
+	mac, err := ns.getMACAddress(ip)
+	if err != nil {
+		return nil, err
+	}
+	vendor := getVendorFromMAC(mac)
+	return func(res *Result) {
+		res.MAC = mac
+		res.DeviceVendor = vendor
+	}, nil
+}
+
+// enrichHostname заменяет имя хоста результатом обратного DNS (имя из списка целей
+// остаётся, если DNS ничего не дал).
+func (ns *NetworkScanner) enrichHostname(ctx context.Context, r Result) (ResultUpdate, error) {
+	name, err := ns.lookupHostname(ctx, r.IP)
+	if err != nil || name == "" {
+		return nil, err
+	}
+	return func(res *Result) { res.Hostname = name }, nil
+}
+
+// enrichSNMP проверяет SNMP короткой UDP-пробой, если порт 161 не найден сканированием
+// портов и не исключён; через прокси UDP недоступен.
+func (ns *NetworkScanner) enrichSNMP(_ context.Context, r Result) (ResultUpdate, error) {
+	if _, excluded := ns.excludedPortSet[snmpPort]; r.SNMPEnabled || excluded || ns.proxy != nil {
+		return nil, nil
+	}
+	timeout := ns.timeout
+	if timeout > snmpProbeTimeoutMax {
+		timeout = snmpProbeTimeoutMax
+	}
+	release, ok := ns.acquireProbe(r.IP, 1)
+	if !ok {
+		return nil, nil
+	}
+	enabled := ns.scanUDPPortWithTimeout(r.IP, snmpPort, timeout)
+	release()
+	if !enabled {
+		return nil, nil
+	}
+	return func(res *Result) { res.SNMPEnabled = true }, nil
+}
+
+// enrichDevice определяет тип устройства по портам, производителю и имени.
+func (ns *NetworkScanner) enrichDevice(_ context.Context, r Result) (ResultUpdate, error) {
+	deviceType := ns.detectDeviceType(r)
+	return func(res *Result) { res.DeviceType = deviceType }, nil
+}
+
+// enrichOS оценивает ОС по версии Windows из NTLM (стадия smb), иначе по имени хоста и
+// открытым TCP-портам, иначе по ответу NetBIOS.
+func (ns *NetworkScanner) enrichOS(_ context.Context, r Result) (ResultUpdate, error) {
+	if major, minor, build, ok := smbInfo(r).WindowsVersion(); ok {
+		osName, conf, reason := osdetect.GuessFromNTLMVersion(major, minor, build)
+		return func(res *Result) {
+			res.GuessOS = osName
+			res.GuessOSConfidence = conf
+			res.GuessOSReason = reason
+		}, nil
+	}
+	openTCPPorts := make([]int, 0)
+	for _, p := range r.Ports {
+		if p.State == "open" && p.Protocol == "tcp" {
+			openTCPPorts = append(openTCPPorts, p.Port)
+		}
+	}
+	osName, conf, reason := osdetect.GuessFromHostAndPorts(r.Hostname, openTCPPorts, ns.osDetectActive)
+	if osName == "" && r.NetBIOS != nil {
+		osName, conf, reason = osdetect.GuessFromNetBIOS(r.NetBIOS.MAC != "")
+	}
+	if osName == "" {
+		return nil, nil
+	}
+	return func(res *Result) {
+		res.GuessOS = osName
+		res.GuessOSConfidence = conf
+		res.GuessOSReason = reason
+	}, nil
+}
@@ -0,0 +1,235 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	apperrors "network-scanner/internal/errors"
+)
+
+// stage создаёт стадию, которая записывает своё имя в GuessOSReason после уже записанных.
+func stage(name string, after ...string) EnrichStage {
+	return EnrichStage{
+		Enricher: NewEnricher(name, func(_ context.Context, r Result) (ResultUpdate, error) {
+			seen := r.GuessOSReason
+			return func(res *Result) { res.GuessOSReason += name + "(" + seen + ");" }, nil
+		}),
+		After: after,
+	}
+}
+
+func pipelineOrder(p *enrichPipeline) [][]string {
+	out := make([][]string, 0, len(p.levels))
+	for _, steps := range p.levels {
+		names := make([]string, 0, len(steps))
+		for _, s := range steps {
+			names = append(names, s.enricher.Name())
+		}
+		out = append(out, names)
+	}
+	return out
+}
+
+func TestParseEnrichConfig(t *testing.T) {
+	cfg, err := ParseEnrichConfig(" -hostname, snmp=1s ,cmdb,, ")
+	if err != nil {
+		t.Fatalf("ParseEnrichConfig() error = %v", err)
+	}
+	want := EnrichConfig{
+		Enable:   []string{"snmp", "cmdb"},
+		Disable:  []string{"hostname"},
+		Timeouts: map[string]time.Duration{"snmp": time.Second},
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("ParseEnrichConfig() = %+v, want %+v", cfg, want)
+	}
+	for _, spec := range []string{"-", "=1s", "snmp=fast", "snmp=-1s"} {
+		if _, err := ParseEnrichConfig(spec); !apperrors.IsInvalidInput(err) {
+			t.Errorf("ParseEnrichConfig(%q) error = %v, want InvalidInputError", spec, err)
+		}
+	}
+}
+
+func TestNewEnrichPipeline(t *testing.T) {
+	stages := []EnrichStage{stage("a"), stage("b", "a"), stage("c"), stage("d", "b", "c")}
+
+	p, err := newEnrichPipeline(stages, EnrichConfig{})
+	if err != nil {
+		t.Fatalf("newEnrichPipeline() error = %v", err)
+	}
+	if got, want := pipelineOrder(p), [][]string{{"a", "c"}, {"b"}, {"d"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("уровни = %v, want %v", got, want)
+	}
+
+	// Выключенная зависимость не задерживает стадию
+	p, err = newEnrichPipeline(stages, EnrichConfig{Disable: []string{"b"}, Timeouts: map[string]time.Duration{"d": time.Second}})
+	if err != nil {
+		t.Fatalf("newEnrichPipeline(-b) error = %v", err)
+	}
+	if got, want := pipelineOrder(p), [][]string{{"a", "c"}, {"d"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("уровни без b = %v, want %v", got, want)
+	}
+	if p.levels[1][0].timeout != time.Second || p.levels[0][0].timeout != DefaultEnrichTimeout {
+		t.Errorf("таймауты = %v, %v", p.levels[1][0].timeout, p.levels[0][0].timeout)
+	}
+
+	// Выключенная по умолчанию стадия включается настройкой
+	optional := stage("e")
+	optional.Disabled = true
+	p, _ = newEnrichPipeline([]EnrichStage{stage("a"), optional}, EnrichConfig{})
+	if got := pipelineOrder(p); !reflect.DeepEqual(got, [][]string{{"a"}}) {
+		t.Errorf("уровни с выключенной e = %v", got)
+	}
+	p, _ = newEnrichPipeline([]EnrichStage{stage("a"), optional}, EnrichConfig{Enable: []string{"e"}})
+	if got := pipelineOrder(p); !reflect.DeepEqual(got, [][]string{{"a", "e"}}) {
+		t.Errorf("уровни с включённой e = %v", got)
+	}
+
+	invalid := map[string]struct {
+		stages []EnrichStage
+		cfg    EnrichConfig
+	}{
+		"повтор":              {[]EnrichStage{stage("a"), stage("a")}, EnrichConfig{}},
+		"неизвестная":         {stages, EnrichConfig{Disable: []string{"z"}}},
+		"неизвестный таймаут": {stages, EnrichConfig{Timeouts: map[string]time.Duration{"z": time.Second}}},
+		"зависимость":         {[]EnrichStage{stage("a", "z")}, EnrichConfig{}},
+		"цикл":                {[]EnrichStage{stage("a", "b"), stage("b", "a")}, EnrichConfig{}},
+		"без имени":           {[]EnrichStage{{}}, EnrichConfig{}},
+	}
+	for name, tt := range invalid {
+		if _, err := newEnrichPipeline(tt.stages, tt.cfg); !apperrors.IsInvalidInput(err) {
+			t.Errorf("%s: error = %v, want InvalidInputError", name, err)
+		}
+	}
+}
+
+func TestEnrichPipelineRun(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	slow := EnrichStage{
+		Enricher: NewEnricher("slow", func(ctx context.Context, r Result) (ResultUpdate, error) {
+			<-release
+			return func(res *Result) { res.Hostname = "late" }, nil
+		}),
+		Timeout: 20 * time.Millisecond,
+	}
+	failing := EnrichStage{Enricher: NewEnricher("failing", func(context.Context, Result) (ResultUpdate, error) {
+		return func(res *Result) { res.Hostname = "failed" }, errors.New("boom")
+	})}
+	p, err := newEnrichPipeline([]EnrichStage{stage("a"), stage("b"), stage("c", "a", "b"), slow, failing}, EnrichConfig{})
+	if err != nil {
+		t.Fatalf("newEnrichPipeline() error = %v", err)
+	}
+
+	result := Result{IP: "10.0.0.1"}
+	p.run(context.Background(), &result)
+	// a и b видят один снимок, c — изменения обеих
+	if want := "a();b();c(a();b(););"; result.GuessOSReason != want {
+		t.Errorf("GuessOSReason = %q, want %q", result.GuessOSReason, want)
+	}
+	if result.Hostname != "" {
+		t.Errorf("Hostname = %q: изменения стадии после таймаута или с ошибкой применены", result.Hostname)
+	}
+	if s := p.stats["slow"]; s.runs != 1 || s.timeouts != 1 {
+		t.Errorf("slow: runs=%d timeouts=%d", s.runs, s.timeouts)
+	}
+	if s := p.stats["failing"]; s.errors != 1 {
+		t.Errorf("failing: errors=%d", s.errors)
+	}
+	if summary := p.summary(); !strings.Contains(summary, "slow=1/") || !strings.Contains(summary, "c=1/") {
+		t.Errorf("summary() = %q", summary)
+	}
+
+	// Отменённый запуск не выполняет стадии
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cancelled := Result{}
+	p.run(ctx, &cancelled)
+	if cancelled.GuessOSReason != "" {
+		t.Errorf("отменённый запуск применил изменения: %q", cancelled.GuessOSReason)
+	}
+}
+
+func TestScanContextEnrichers(t *testing.T) {
+	var mu sync.Mutex
+	seen := make(map[string]string)
+	ns := newStubScanner()
+	ns.AddEnricher(EnrichStage{
+		Enricher: NewEnricher("owner", func(_ context.Context, r Result) (ResultUpdate, error) {
+			mu.Lock()
+			seen[r.IP] = r.DeviceType
+			mu.Unlock()
+			return func(res *Result) { res.DeviceType = "owned " + res.DeviceType }, nil
+		}),
+		After: []string{EnrichDevice},
+	})
+	ns.SetEnrich("-hostname,-snmp,mac=50ms")
+
+	summary, err := ns.ScanContext(context.Background())
+	if err != nil {
+		t.Fatalf("ScanContext() error = %v", err)
+	}
+	if len(summary.Results) == 0 {
+		t.Fatal("нет результатов")
+	}
+	for _, r := range summary.Results {
+		if seen[r.IP] == "" || r.DeviceType != "owned "+seen[r.IP] {
+			t.Errorf("%s: DeviceType = %q, стадия видела %q", r.IP, r.DeviceType, seen[r.IP])
+		}
+	}
+	diag := ns.GetDiagnosticsSummary()
+	if !strings.Contains(diag, "owner=") || strings.Contains(diag, "hostname=") {
+		t.Errorf("диагностика стадий: %s", diag)
+	}
+	if got := ns.CheckpointConfig().Enrich; got != "-hostname,-snmp,mac=50ms" {
+		t.Errorf("CheckpointConfig().Enrich = %q", got)
+	}
+
+	bad := newStubScanner()
+	bad.SetEnrich("-dns")
+	if _, err := bad.ScanContext(context.Background()); !apperrors.IsInvalidInput(err) {
+		t.Errorf("ScanContext(неизвестная стадия) error = %v, want InvalidInputError", err)
+	}
+}
+
+func TestRegisterEnricher(t *testing.T) {
+	saved := enrichRegistry
+	t.Cleanup(func() { enrichRegistry = saved })
+	enrichRegistry = nil
+
+	RegisterEnricher(stage("cmdb"))
+	if names := EnricherNames(); names[len(names)-1] != "cmdb" {
+		t.Errorf("EnricherNames() = %v", names)
+	}
+	if err := ValidateEnrich("cmdb=1s,-os"); err != nil {
+		t.Errorf("ValidateEnrich() error = %v", err)
+	}
+	if err := ValidateEnrich("crm"); !apperrors.IsInvalidInput(err) {
+		t.Errorf("ValidateEnrich(unknown) error = %v", err)
+	}
+	for _, name := range []string{"cmdb", EnrichMAC, EnrichNetBIOS, EnrichSMB, EnrichMDNS, EnrichUPnP, ""} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("RegisterEnricher(%q) без паники", name)
+				}
+			}()
+			RegisterEnricher(stage(name))
+		}()
+	}
+}
+
+func TestBuiltinEnrichersMatchStages(t *testing.T) {
+	var names []string
+	for _, s := range NewScanner("192.0.2.1", time.Second, "80", 1, false, nil, nil, nil).builtinEnrichStages() {
+		names = append(names, s.Enricher.Name())
+	}
+	if !reflect.DeepEqual(names, builtinEnrichers) {
+		t.Errorf("builtinEnrichers = %v, стадии сканера %v", builtinEnrichers, names)
+	}
+}
@@ -0,0 +1,156 @@
+package scanner
+
+import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"network-scanner/internal/contracts"
+)
+
+// stubProber считает живыми все хосты, MAC не определяется.
+type stubProber struct{}
+
+func (stubProber) Ping(ip string) (bool, error)                   { return true, nil }
+func (stubProber) ResolveMAC(ip string) (net.HardwareAddr, error) { return nil, nil }
+
+// stubPortScanner сообщает открытым только порт openPort.
+type stubPortScanner struct {
+	openPort int
+}
+
+func (s stubPortScanner) ScanPort(ip string, port int, proto string) (bool, error) {
+	return port == s.openPort, nil
+}
+
+func (s stubPortScanner) ScanPorts(ip string, ports []int, proto string) ([]int, error) {
+	var open []int
+	for _, p := range ports {
+		if p == s.openPort {
+			open = append(open, p)
+		}
+	}
+	return open, nil
+}
+
+func newStubScanner() *NetworkScanner {
+	return NewScanner("127.0.0.0/29", 200*time.Millisecond, "80", 4, false, stubProber{}, stubPortScanner{openPort: 80}, nil)
+}
+
+func TestHostCallbackOrderMatchesResults(t *testing.T) {
+	ns := newStubScanner()
+
+	var mu sync.Mutex
+	var streamed []string
+	var inFlight, overlap int32
+	ns.SetHostCallback(func(result Result) {
+		if atomic.AddInt32(&inFlight, 1) > 1 {
+			atomic.StoreInt32(&overlap, 1)
+		}
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		streamed = append(streamed, result.IP)
+		mu.Unlock()
+		atomic.AddInt32(&inFlight, -1)
+	})
+
+	if _, err := ns.ScanContext(context.Background()); err != nil {
+		t.Fatalf("ScanContext() error = %v", err)
+	}
+
+	if atomic.LoadInt32(&overlap) != 0 {
+		t.Fatal("HostCallback must not be called concurrently")
+	}
+	results := ns.GetResults()
+	if len(results) == 0 {
+		t.Fatal("expected alive hosts")
+	}
+	if len(streamed) != len(results) {
+		t.Fatalf("streamed %d hosts, results contain %d", len(streamed), len(results))
+	}
+	for i := range results {
+		if streamed[i] != results[i].IP {
+			t.Fatalf("host %d: streamed %s, results %s", i, streamed[i], results[i].IP)
+		}
+	}
+}
+
+func TestHostCallbackBackpressure(t *testing.T) {
+	ns := newStubScanner()
+
+	var calls int32
+	ns.SetHostCallback(func(result Result) {
+		// Медленный потребитель не должен приводить к потере хостов.
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&calls, 1)
+	})
+
+	if _, err := ns.ScanContext(context.Background()); err != nil {
+		t.Fatalf("ScanContext() error = %v", err)
+	}
+
+	if got, want := int(atomic.LoadInt32(&calls)), len(ns.GetResults()); got != want {
+		t.Fatalf("HostCallback called %d times, want %d", got, want)
+	}
+}
+
+func TestIncrementalScannerStreamsHostsBeforeSummary(t *testing.T) {
+	inc := NewIncrementalScanner(newStubScanner())
+
+	events, errs := inc.ScanWithEvents(context.Background(), contracts.ScanConfig{})
+
+	var hosts int
+	var summarySeen bool
+	for ev := range events {
+		switch ev.Type {
+		case "host":
+			if summarySeen {
+				t.Fatal("host event after summary")
+			}
+			if ev.Result == nil || ev.Result.IP == "" {
+				t.Fatal("host event without result")
+			}
+			hosts++
+		case "summary":
+			summarySeen = true
+		}
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !summarySeen {
+		t.Fatal("summary event not received")
+	}
+	if got := len(inc.inner.GetResults()); hosts != got {
+		t.Fatalf("streamed %d hosts, results contain %d", hosts, got)
+	}
+}
+
+func TestServiceScanCallsOnHost(t *testing.T) {
+	svc := NewService("info")
+
+	var streamed []contracts.ScanResult
+	results, err := svc.Scan(context.Background(), contracts.ScanConfig{
+		NetworkCIDR: "127.0.0.1/32",
+		PortRange:   "1",
+		Timeout:     200 * time.Millisecond,
+		Threads:     1,
+		OnHost: func(result contracts.ScanResult) {
+			streamed = append(streamed, result)
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if len(streamed) != len(results) {
+		t.Fatalf("OnHost called %d times, results contain %d", len(streamed), len(results))
+	}
+	for i := range results {
+		if streamed[i].IP != results[i].IP {
+			t.Fatalf("host %d: streamed %s, results %s", i, streamed[i].IP, results[i].IP)
+		}
+	}
+}
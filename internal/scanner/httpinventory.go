@@ -0,0 +1,105 @@
+package scanner
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"network-scanner/internal/httpinspect"
+	"network-scanner/internal/logger"
+	"network-scanner/internal/network"
+)
+
+const (
+	httpInventoryTimeout = 30 * time.Second // предел стадии http на хост
+	httpInventoryWorkers = 4                // веб-портов хоста, проверяемых одновременно
+)
+
+// enrichHTTP снимает отпечаток веб-приложений хоста: главная страница (GET с
+// переадресациями в пределах хоста), заголовок, favicon, заголовки ответа, cookie, realm и
+// meta generator, и сопоставляет их с правилами продуктов httpinspect. Проверяются
+// открытые TCP-порты, служба которых содержит "http" (таблица портов или стадия service).
+// HTTPS выбирается, если порт согласовал TLS (стадия tls) или служба — https/ssl; при
+// ошибке или ответе "используйте HTTPS" пробуется другая схема.
+func (ns *NetworkScanner) enrichHTTP(ctx context.Context, r Result) (ResultUpdate, error) {
+	timeout := ns.timeout
+	if timeout <= 0 || timeout > httpinspect.DefaultTimeout {
+		timeout = httpinspect.DefaultTimeout
+	}
+	ip, _ := network.SplitZone(r.IP)
+	if ip == nil {
+		return nil, nil
+	}
+	host := ip.String()
+
+	var candidates []PortInfo
+	for _, p := range r.Ports {
+		if p.State == "open" && p.Protocol == "tcp" && strings.Contains(strings.ToLower(p.Service), "http") {
+			candidates = append(candidates, p)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	rules, err := httpinspect.LoadDefault()
+	if err != nil {
+		return nil, err
+	}
+
+	var mu sync.Mutex
+	found := make(map[int]*httpinspect.Info)
+	ns.probePorts(ctx, r.IP, candidates, httpInventoryWorkers, func(p PortInfo) {
+		opts := httpinspect.Options{Timeout: timeout, Dial: ns.dialTimeout, TLS: httpOverTLS(p)}
+		info, err := httpinspect.Inspect(ctx, host, p.Port, opts)
+		if (err != nil || (!opts.TLS && info.WantsTLS())) && ctx.Err() == nil {
+			opts.TLS = !opts.TLS
+			if retry, retryErr := httpinspect.Inspect(ctx, host, p.Port, opts); retryErr == nil || err != nil {
+				info, err = retry, retryErr
+			}
+		}
+		if err != nil {
+			return
+		}
+		info.Products = rules.Match(info)
+		mu.Lock()
+		found[p.Port] = info
+		mu.Unlock()
+	})
+	if len(found) == 0 {
+		return nil, nil
+	}
+	logger.LogDebug("Хост %s: снят отпечаток HTTP на %d портах", r.IP, len(found))
+	return func(res *Result) {
+		for i := range res.Ports {
+			if p := &res.Ports[i]; p.Protocol == "tcp" && found[p.Port] != nil {
+				p.HTTP = found[p.Port]
+			}
+		}
+	}, nil
+}
+
+// httpOverTLS сообщает, что веб-порт следует опрашивать по HTTPS.
+func httpOverTLS(p PortInfo) bool {
+	if p.TLS != nil && p.TLS.STARTTLS == "" {
+		return true
+	}
+	service := strings.ToLower(p.Service)
+	return strings.Contains(service, "https") || strings.HasPrefix(service, "ssl/")
+}
+
+// httpCategories возвращает категории deviceclassifier продуктов, распознанных стадией http.
+func httpCategories(r Result) []string {
+	var out []string
+	for _, p := range r.Ports {
+		if p.HTTP == nil {
+			continue
+		}
+		for _, prod := range p.HTTP.Products {
+			if prod.Category != "" {
+				out = append(out, prod.Category)
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,48 @@
+package scanner
+
+import (
+	"context"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"network-scanner/internal/scanner/deviceclassifier"
+)
+
+func TestEnrichHTTP(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html><head><title>TrueNAS - 192.168.1.5</title></head></html>"))
+	}))
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	defer srv.Close()
+	_, portStr, _ := net.SplitHostPort(srv.Listener.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+
+	ns := NewNetworkScanner("127.0.0.1/32", time.Second, "1-5", 5, false)
+	// Стадия tls не запускалась: HTTPS находится по ответу сервера на запрос без TLS
+	r := Result{IP: "127.0.0.1", Ports: []PortInfo{
+		{Port: port, Protocol: "tcp", State: "open", Service: "HTTP-Alt"},
+		{Port: 22, Protocol: "tcp", State: "open", Service: "SSH"},
+	}}
+	update, err := ns.enrichHTTP(context.Background(), r)
+	if err != nil || update == nil {
+		t.Fatalf("enrichHTTP: update=%v err=%v", update != nil, err)
+	}
+	update(&r)
+	info := r.Ports[0].HTTP
+	if info == nil || info.Status != http.StatusOK || len(info.Products) != 1 || info.Products[0].Name != "TrueNAS" {
+		t.Fatalf("HTTP-порт: %+v", info)
+	}
+	if r.Ports[1].HTTP != nil {
+		t.Errorf("HTTP записан для SSH: %+v", r.Ports[1].HTTP)
+	}
+	if got := ns.detectDeviceType(r); got != deviceclassifier.CategoryNAS {
+		t.Errorf("detectDeviceType = %q, want %q", got, deviceclassifier.CategoryNAS)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"network-scanner/internal/contracts"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -45,87 +46,40 @@ func NewIncrementalScanner(ns *NetworkScanner) *IncrementalScanner {
 	}
 }
 
+// incrementalEventBuffer — размер буфера канала событий IncrementalScanner.
+const incrementalEventBuffer = 100
+
 // ScanWithEvents запускает сканирование и отправляет события в канал events.
 // Возвращает канал, через который отправляются события, и канал ошибок.
-// События отправляются асинхронно, поэтому caller должен читать их быстро.
+//
+// Событие "host" отправляется в момент завершения сканирования хоста (через
+// NetworkScanner.SetHostCallback), а не после окончания всего Scan(). Гарантии:
+//   - события "host" идут в порядке появления хостов в GetResults();
+//   - события не теряются: при заполненном буфере сканирование ждёт читателя
+//     (backpressure), поэтому caller должен читать канал до закрытия;
+//   - отмена ctx останавливает сканирование и закрывает канал без события "summary";
+//   - ошибка ScanContext (типизированная, из internal/errors) отправляется в канал ошибок.
 func (s *IncrementalScanner) ScanWithEvents(
 	ctx context.Context,
 	config contracts.ScanConfig,
 ) (<-chan ScanEvent, <-chan error) {
-	events := make(chan ScanEvent, 100) // Буферизованный канал
-	errChan := make(chan error, 1)
-
-	go func() {
-		defer close(events)
-		defer close(errChan)
-
-		startTime := time.Now()
-
-		// Отправляем начальное событие
-		events <- ScanEvent{
-			Type:      "start",
-			Stage:     "init",
-			StartTime: startTime,
-			Message:   fmt.Sprintf("Начало сканирования сети: %s", s.inner.network),
-		}
-
-		// Устанавливаем progress callback, который отправляет события
-		s.inner.SetProgressCallback(func(stage string, current, total int, message string) {
-			events <- ScanEvent{
-				Type:      "progress",
-				Stage:     stage,
-				Current:   current,
-				Total:     total,
-				Message:   message,
-				StartTime: startTime,
-			}
-		})
-
-		// Запускаем сканирование
-		s.inner.Scan()
-
-		// Отправляем результаты по хостам
-		results := s.inner.GetResults()
-		for i, result := range results {
-			select {
-			case events <- ScanEvent{
-				Type:      "host",
-				Stage:     "complete",
-				Current:   i + 1,
-				Total:     len(results),
-				Result:    &result,
-				StartTime: startTime,
-				Message:   fmt.Sprintf("Обработан хост %d/%d: %s", i+1, len(results), result.IP),
-			}:
-			case <-ctx.Done():
-				return
-			}
-		}
-
-		// Отправляем итоговую сводку
-		events <- ScanEvent{
-			Type:      "summary",
-			Stage:     "complete",
-			Current:   len(results),
-			Total:     len(results),
-			Duration:  time.Since(startTime),
-			StartTime: startTime,
-			Message:   fmt.Sprintf("Сканирование завершено. Найдено устройств: %d", len(results)),
-		}
-
-		// Отправляем ошибку, если была
-		// (в текущей реализации ошибки логируются, но не возвращаются)
-	}()
-
-	return events, errChan
+	return s.run(ctx, fmt.Sprintf("Начало сканирования сети: %s", s.inner.network))
 }
 
 // ScanWithEventsAndConfig запускает сканирование с конфигурацией и отправляет события.
+// Семантика событий совпадает с ScanWithEvents.
 func (s *IncrementalScanner) ScanWithEventsAndConfig(
 	ctx context.Context,
 	config contracts.ScanConfig,
 ) (<-chan ScanEvent, <-chan error) {
-	events := make(chan ScanEvent, 100)
+	return s.run(ctx, fmt.Sprintf("Начало сканирования: %s, порты: %s", config.NetworkCIDR, config.PortRange))
+}
+
+func (s *IncrementalScanner) run(ctx context.Context, startMessage string) (<-chan ScanEvent, <-chan error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	events := make(chan ScanEvent, incrementalEventBuffer)
 	errChan := make(chan error, 1)
 
 	go func() {
@@ -133,50 +87,62 @@ func (s *IncrementalScanner) ScanWithEventsAndConfig(
 		defer close(errChan)
 
 		startTime := time.Now()
+		send := func(event ScanEvent) bool {
+			select {
+			case events <- event:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
 
-		// Отправляем начальное событие
+		// Буфер пуст, поэтому событие start доставляется всегда, даже при уже отменённом ctx.
 		events <- ScanEvent{
 			Type:      "start",
 			Stage:     "init",
 			StartTime: startTime,
-			Message:   fmt.Sprintf("Начало сканирования: %s, порты: %s", config.NetworkCIDR, config.PortRange),
+			Message:   startMessage,
 		}
 
-		// Устанавливаем progress callback
+		var hostsTotal int64
 		s.inner.SetProgressCallback(func(stage string, current, total int, message string) {
-			events <- ScanEvent{
+			if stage == "ports" {
+				atomic.StoreInt64(&hostsTotal, int64(total))
+			}
+			send(ScanEvent{
 				Type:      "progress",
 				Stage:     stage,
 				Current:   current,
 				Total:     total,
 				Message:   message,
 				StartTime: startTime,
-			}
+			})
 		})
 
-		// Запускаем сканирование
-		s.inner.Scan()
-
-		// Отправляем результаты
-		results := s.inner.GetResults()
-		for i, result := range results {
-			select {
-			case events <- ScanEvent{
+		// hostCallback вызывается сериализованно, поэтому счётчик не требует синхронизации.
+		hostsDone := 0
+		s.inner.SetHostCallback(func(result Result) {
+			hostsDone++
+			host := result
+			send(ScanEvent{
 				Type:      "host",
-				Stage:     "complete",
-				Current:   i + 1,
-				Total:     len(results),
-				Result:    &result,
+				Stage:     "ports",
+				Current:   hostsDone,
+				Total:     int(atomic.LoadInt64(&hostsTotal)),
+				Result:    &host,
 				StartTime: startTime,
-				Message:   fmt.Sprintf("Обработан хост %d/%d: %s", i+1, len(results), result.IP),
-			}:
-			case <-ctx.Done():
-				return
-			}
+				Message:   fmt.Sprintf("Хост %d готов: %s", hostsDone, host.IP),
+			})
+		})
+
+		summary, err := s.inner.ScanContext(ctx)
+		if err != nil {
+			errChan <- err
+			return
 		}
 
-		// Итоговая сводка
-		events <- ScanEvent{
+		results := summary.Results
+		send(ScanEvent{
 			Type:      "summary",
 			Stage:     "complete",
 			Current:   len(results),
@@ -184,7 +150,7 @@ func (s *IncrementalScanner) ScanWithEventsAndConfig(
 			Duration:  time.Since(startTime),
 			StartTime: startTime,
 			Message:   fmt.Sprintf("Сканирование завершено. Найдено устройств: %d", len(results)),
-		}
+		})
 	}()
 
 	return events, errChan
@@ -1,6 +1,11 @@
 package scanner
 
-import "net"
+import (
+	"net"
+	"time"
+
+	"network-scanner/internal/network"
+)
 
 // HostResult is a backward-compatible alias for scan results.
 type HostResult = Result
@@ -18,12 +23,44 @@ type ContextNetworkProber interface {
 	PingContext(ip string, done <-chan struct{}) (bool, error)
 }
 
+// HostnameResolver resolves host names by address (net.LookupAddr semantics).
+// A NetworkProber that also implements HostnameResolver replaces reverse DNS.
+type HostnameResolver interface {
+	LookupAddr(ip string) ([]string, error)
+}
+
 // PortScanner scans ports for a given host and protocol.
 type PortScanner interface {
 	ScanPort(ip string, port int, proto string) (bool, error)
 	ScanPorts(ip string, ports []int, proto string) ([]int, error)
 }
 
+// TimeoutPortScanner is a PortScanner that accepts a per-probe timeout.
+// NetworkScanner uses it to apply per-host RTT-based timeouts.
+type TimeoutPortScanner interface {
+	ScanPortTimeout(ip string, port int, proto string, timeout time.Duration) (bool, error)
+}
+
+// TCPProber classifies a TCP port (open, closed, filtered) with the reason and latency.
+// A TCP PortScanner that also implements TCPProber is used through ProbeTCP;
+// a zero timeout means the scanner's own timeout.
+type TCPProber interface {
+	ProbeTCP(ip string, port int, timeout time.Duration) (network.TCPProbeResult, error)
+}
+
+// UDPProber classifies a UDP port (open, closed, open|filtered) and recognises its service.
+// A UDP PortScanner that also implements UDPProber is used through ProbeUDP.
+type UDPProber interface {
+	ProbeUDP(ip string, port int) (network.UDPProbeResult, error)
+}
+
+// ConnDialer opens connections for banner grabbing (banner.DialFunc signature).
+// A TCP PortScanner that also implements ConnDialer is used for banners instead of
+// the probe source or proxy.
+type ConnDialer interface {
+	DialTimeout(network, address string, timeout time.Duration) (net.Conn, error)
+}
+
 // ResultPresenter displays and exports scan results.
 type ResultPresenter interface {
 	DisplayHeader()
@@ -0,0 +1,257 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"network-scanner/internal/cache"
+	"network-scanner/internal/logger"
+	"network-scanner/internal/network"
+)
+
+// DefaultLookupTimeout — предел завершающего прохода MAC и имён, если SetLookupTimeout
+// его не задал.
+const DefaultLookupTimeout = 5 * time.Second
+
+const (
+	lookupWorkers       = 32 // одновременных обратных DNS-запросов завершающего прохода
+	arpBatchTTL         = 30 * time.Second
+	reverseDNSCacheTTL  = 5 * time.Minute
+	reverseDNSCacheSize = 4096
+)
+
+// reverseDNSCache — ответы системного резолвера на обратные запросы, общие для запусков
+// процесса; пустое имя — у адреса нет PTR-записи.
+var reverseDNSCache = cache.NewDNSCache(reverseDNSCacheTTL, reverseDNSCacheSize)
+
+// newARPCache создаёт кэш ARP-таблицы завершающего прохода.
+// Переменная подменяется в тестах, чтобы не зависеть от состояния сети.
+var newARPCache = func() *network.ARPCache {
+	return network.NewDefaultARPCache(arpBatchTTL)
+}
+
+// SetLookupTimeout задаёт предел завершающего прохода MAC и имён после сканирования
+// портов; 0 — DefaultLookupTimeout.
+func (ns *NetworkScanner) SetLookupTimeout(d time.Duration) {
+	ns.lookupTimeout = d
+}
+
+// completeLookups — завершающий проход после сканирования портов: хостам, у которых
+// стадии mac и hostname не уложились в свои короткие таймауты, MAC дочитывается одним
+// чтением ARP-таблицы (network.ResolveMACBatch), а имена — параллельными обратными
+// запросами с кэшем. Стадии, зависящие от mac и hostname (device, os, расширения),
+// выполняются повторно для дополненных хостов. Проход ограничен lookupTimeout и ctx;
+// выключенные стадии mac и hostname он не выполняет.
+func (ns *NetworkScanner) completeLookups(ctx context.Context) {
+	macOn := ns.enrich.enabled(EnrichMAC) && ns.proxy == nil
+	nameOn := ns.enrich.enabled(EnrichHostname)
+	if !macOn && !nameOn {
+		return
+	}
+	start := time.Now()
+	ns.mu.RLock()
+	var macIPs, nameIPs []string
+	for _, r := range ns.results {
+		if macOn && r.MAC == "" {
+			macIPs = append(macIPs, r.IP)
+		}
+		if nameOn && r.Hostname == "" {
+			nameIPs = append(nameIPs, r.IP)
+		}
+	}
+	ns.mu.RUnlock()
+	if len(macIPs) == 0 && len(nameIPs) == 0 {
+		return
+	}
+
+	timeout := ns.lookupTimeout
+	if timeout <= 0 {
+		timeout = DefaultLookupTimeout
+	}
+	lookupCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	var macs, names map[string]string
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		macs = ns.lookupMACs(lookupCtx, macIPs)
+	}()
+	go func() {
+		defer wg.Done()
+		names = ns.lookupHostnames(lookupCtx, nameIPs)
+	}()
+	wg.Wait()
+
+	ns.mu.Lock()
+	type completed struct {
+		index   int
+		result  Result
+		changed []string
+	}
+	updated := make([]completed, 0, len(macs)+len(names))
+	for i := range ns.results {
+		r := &ns.results[i]
+		var changed []string
+		if mac, ok := macs[r.IP]; ok && r.MAC == "" {
+			r.MAC = mac
+			r.DeviceVendor = getVendorFromMAC(mac)
+			changed = append(changed, EnrichMAC)
+		}
+		if name, ok := names[r.IP]; ok && r.Hostname == "" {
+			r.Hostname = name
+			changed = append(changed, EnrichHostname)
+		}
+		if changed != nil {
+			updated = append(updated, completed{index: i, result: cloneResult(*r), changed: changed})
+		}
+	}
+	ns.mu.Unlock()
+
+	for i := range updated {
+		ns.enrich.runAfter(ctx, &updated[i].result, updated[i].changed...)
+	}
+	ns.mu.Lock()
+	for _, u := range updated {
+		ns.results[u.index] = u.result
+	}
+	ns.mu.Unlock()
+
+	atomic.StoreInt64(&ns.lookupMACsFound, int64(len(macs)))
+	atomic.StoreInt64(&ns.lookupNamesFound, int64(len(names)))
+	atomic.StoreInt64(&ns.lastLookupNs, time.Since(start).Nanoseconds())
+	logger.Log("Завершающий проход: MAC %d/%d, имён %d/%d за %v", len(macs), len(macIPs), len(names), len(nameIPs), time.Since(start))
+}
+
+// lookupMACs находит MAC адресов ips: сначала среди уже известных запуску (knownMAC),
+// затем для остальных IPv4 — одним чтением ARP-таблицы.
+func (ns *NetworkScanner) lookupMACs(ctx context.Context, ips []string) map[string]string {
+	found := make(map[string]string)
+	pending := make(map[string]string) // адрес без зоны -> IP результата
+	batch := make([]string, 0, len(ips))
+	for _, ipStr := range ips {
+		ip, _ := network.SplitZone(ipStr)
+		if ip == nil {
+			continue
+		}
+		if mac, ok := ns.knownMAC(ip); ok {
+			found[ipStr] = mac
+			continue
+		}
+		if ip.To4() != nil {
+			pending[ip.String()] = ipStr
+			batch = append(batch, ip.String())
+		}
+	}
+	if len(batch) == 0 || ctx.Err() != nil {
+		return found
+	}
+	for ip, hw := range network.ResolveMACBatch(ctx, batch, newARPCache()) {
+		if isZeroMAC(hw) {
+			continue
+		}
+		found[pending[ip]] = hw.String()
+	}
+	return found
+}
+
+// lookupHostnames выполняет обратные запросы для ips не более чем lookupWorkers
+// одновременно; в результат попадают только найденные имена.
+func (ns *NetworkScanner) lookupHostnames(ctx context.Context, ips []string) map[string]string {
+	found := make(map[string]string)
+	var mu sync.Mutex
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	workers := lookupWorkers
+	if len(ips) < workers {
+		workers = len(ips)
+	}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ipStr := range jobs {
+				name, err := ns.lookupHostname(ctx, ipStr)
+				if err != nil || name == "" {
+					continue
+				}
+				mu.Lock()
+				found[ipStr] = name
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, ipStr := range ips {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- ipStr
+	}
+	close(jobs)
+	wg.Wait()
+	return found
+}
+
+// lookupHostname возвращает первое имя обратного DNS адреса ipStr ("" — имени нет).
+// Ответы системного резолвера кэшируются в reverseDNSCache, включая отсутствие имени;
+// HostnameResolver сетевого prober-а вызывается без кэша.
+func (ns *NetworkScanner) lookupHostname(ctx context.Context, ipStr string) (string, error) {
+	ip, _ := network.SplitZone(ipStr)
+	if ip == nil {
+		return "", nil
+	}
+	if resolver, ok := ns.networkProber.(HostnameResolver); ok {
+		// LookupAddr не принимает ctx: ответ после отмены не ждём
+		type answer struct {
+			names []string
+			err   error
+		}
+		done := make(chan answer, 1)
+		go func() {
+			names, err := resolver.LookupAddr(ip.String())
+			done <- answer{names, err}
+		}()
+		select {
+		case a := <-done:
+			if a.err != nil || len(a.names) == 0 {
+				return "", a.err
+			}
+			return a.names[0], nil
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	key := "ptr:" + ip.String()
+	if name, ok := reverseDNSCache.Get(key); ok {
+		return name, nil
+	}
+	names, err := net.DefaultResolver.LookupAddr(ctx, ip.String())
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		reverseDNSCache.Set(key, "")
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	name := ""
+	if len(names) > 0 {
+		name = names[0]
+	}
+	reverseDNSCache.Set(key, name)
+	return name, nil
+}
+
+// isZeroMAC сообщает, что запись ARP-таблицы не содержит адреса (неполная запись).
+func isZeroMAC(hw net.HardwareAddr) bool {
+	for _, b := range hw {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
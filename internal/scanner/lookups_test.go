@@ -0,0 +1,121 @@
+package scanner
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"network-scanner/internal/network"
+)
+
+// slowNameProber отвечает на обратные запросы дольше таймаута стадии hostname.
+type slowNameProber struct {
+	stubProber
+	delay time.Duration
+}
+
+func (p slowNameProber) LookupAddr(ip string) ([]string, error) {
+	time.Sleep(p.delay)
+	return []string{"host-" + ip[strings.LastIndex(ip, ".")+1:] + ".lan"}, nil
+}
+
+// stubARPTable подменяет ARP-таблицу завершающего прохода на refresh.
+func stubARPTable(t *testing.T, refresh func() (map[string]string, error)) {
+	t.Helper()
+	saved := newARPCache
+	t.Cleanup(func() { newARPCache = saved })
+	newARPCache = func() *network.ARPCache { return network.NewARPCache(time.Minute, refresh) }
+}
+
+func TestCompleteLookups(t *testing.T) {
+	stubARPTable(t, func() (map[string]string, error) {
+		return map[string]string{
+			"127.0.0.1": "00:1a:2b:3c:4d:01",
+			"127.0.0.2": "00:00:00:00:00:00", // неполная запись
+		}, nil
+	})
+	var mu sync.Mutex
+	seen := make(map[string]string)
+	ns := NewScanner("127.0.0.1-2", 200*time.Millisecond, "80", 4, false,
+		slowNameProber{delay: 3 * hostnameTimeout}, stubPortScanner{openPort: 80}, nil)
+	ns.AddEnricher(EnrichStage{
+		Enricher: NewEnricher("owner", func(_ context.Context, r Result) (ResultUpdate, error) {
+			mu.Lock()
+			seen[r.IP] += r.MAC + "|" + r.Hostname + ";"
+			mu.Unlock()
+			return nil, nil
+		}),
+		After: []string{EnrichMAC, EnrichHostname},
+	})
+	ns.SetEnrich("-snmp,mac=20ms")
+
+	summary, err := ns.ScanContext(context.Background())
+	if err != nil {
+		t.Fatalf("ScanContext() error = %v", err)
+	}
+	if len(summary.Results) != 2 {
+		t.Fatalf("результатов %d, want 2", len(summary.Results))
+	}
+	for _, r := range summary.Results {
+		if want := "host-" + r.IP[len("127.0.0."):] + ".lan"; r.Hostname != want {
+			t.Errorf("%s: Hostname = %q, want %q", r.IP, r.Hostname, want)
+		}
+		// Стадия, зависящая от mac и hostname, выполнена повторно с дополненными данными
+		if !strings.HasSuffix(seen[r.IP], r.MAC+"|"+r.Hostname+";") {
+			t.Errorf("%s: стадия owner видела %q", r.IP, seen[r.IP])
+		}
+	}
+	byIP := map[string]Result{summary.Results[0].IP: summary.Results[0], summary.Results[1].IP: summary.Results[1]}
+	if got := byIP["127.0.0.1"].MAC; got != "00:1a:2b:3c:4d:01" {
+		t.Errorf("127.0.0.1: MAC = %q", got)
+	}
+	if got := byIP["127.0.0.2"].MAC; got != "" {
+		t.Errorf("127.0.0.2: MAC из неполной записи = %q", got)
+	}
+	if diag := ns.GetDiagnosticsSummary(); !strings.Contains(diag, "lookups MAC/names=1/2") {
+		t.Errorf("диагностика прохода: %s", diag)
+	}
+}
+
+func TestCompleteLookupsDeadline(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	stubARPTable(t, func() (map[string]string, error) {
+		<-release
+		return nil, nil
+	})
+	ns := NewScanner("127.0.0.1", 200*time.Millisecond, "80", 4, false,
+		slowNameProber{delay: time.Second}, stubPortScanner{openPort: 80}, nil)
+	ns.SetEnrich("-snmp,mac=20ms")
+	ns.SetLookupTimeout(100 * time.Millisecond)
+
+	start := time.Now()
+	summary, err := ns.ScanContext(context.Background())
+	if err != nil {
+		t.Fatalf("ScanContext() error = %v", err)
+	}
+	// Ни ARP-таблица, ни резолвер не ответили: проход ждёт их не дольше своего предела
+	if elapsed := time.Since(start); elapsed > 800*time.Millisecond {
+		t.Errorf("ScanContext() занял %v", elapsed)
+	}
+	if len(summary.Results) != 1 || summary.Results[0].MAC != "" || summary.Results[0].Hostname != "" {
+		t.Errorf("результаты = %+v", summary.Results)
+	}
+	if got := ns.CheckpointConfig().LookupTimeout; got != 100*time.Millisecond {
+		t.Errorf("CheckpointConfig().LookupTimeout = %v", got)
+	}
+
+	// Выключенные стадии не дополняются
+	off := NewScanner("127.0.0.1", 200*time.Millisecond, "80", 4, false,
+		slowNameProber{delay: 3 * hostnameTimeout}, stubPortScanner{openPort: 80}, nil)
+	off.SetEnrich("-snmp,-hostname,-mac")
+	summary, err = off.ScanContext(context.Background())
+	if err != nil {
+		t.Fatalf("ScanContext() error = %v", err)
+	}
+	if len(summary.Results) != 1 || summary.Results[0].Hostname != "" {
+		t.Errorf("результаты без стадий = %+v", summary.Results)
+	}
+}
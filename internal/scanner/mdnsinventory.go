@@ -0,0 +1,43 @@
+package scanner
+
+import (
+	"context"
+	"time"
+
+	"network-scanner/internal/logger"
+	"network-scanner/internal/mdns"
+)
+
+// mdnsStageTimeout — предел стадии mdns на хост: первый хост ждёт окончания просмотра.
+const mdnsStageTimeout = mdns.DefaultWindow + time.Second
+
+// mdnsBrowser — prober, находящий устройства просмотром mDNS/DNS-SD (*mdns.Prober).
+type mdnsBrowser interface {
+	Discover(ctx context.Context) ([]mdns.Host, error)
+	Lookup(ip string) (*mdns.Host, bool)
+}
+
+// enrichMDNS дополняет хост сведениями просмотра mDNS (способ обнаружения mdns): имя из SRV
+// заполняет пустое имя хоста, службы DNS-SD и TXT (модель, прошивка) сохраняются в
+// Result.MDNS. Если хост обнаружен другим способом раньше, чем был выполнен просмотр,
+// стадия запускает его сама.
+func (ns *NetworkScanner) enrichMDNS(ctx context.Context, r Result) (ResultUpdate, error) {
+	browser := ns.mdnsProber
+	if browser == nil || r.MDNS != nil {
+		return nil, nil
+	}
+	if _, err := browser.Discover(ctx); err != nil {
+		return nil, nil
+	}
+	h, ok := browser.Lookup(r.IP)
+	if !ok {
+		return nil, nil
+	}
+	logger.LogDebug("Хост %s: mDNS %s (%s)", r.IP, h.Hostname, h.Summary())
+	return func(res *Result) {
+		res.MDNS = h
+		if res.Hostname == "" {
+			res.Hostname = h.Hostname
+		}
+	}, nil
+}
@@ -0,0 +1,59 @@
+package scanner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"network-scanner/internal/mdns"
+	"network-scanner/internal/network"
+)
+
+func TestScanContextMDNSDiscovery(t *testing.T) {
+	browses := 0
+	orig := newDiscoveryProber
+	t.Cleanup(func() { newDiscoveryProber = orig })
+	newDiscoveryProber = func(method string, _ time.Duration, _ network.Source) NetworkProber {
+		p := mdns.NewProber(time.Second)
+		p.Browse = func(context.Context, mdns.Options) ([]mdns.Host, error) {
+			browses++
+			return []mdns.Host{
+				{Hostname: "chromecast.local", Addresses: []string{"192.0.2.2"}, Services: []mdns.Service{
+					{Instance: "Living Room", Type: "_googlecast._tcp", Port: 8009, TXT: map[string]string{"md": "Chromecast"}},
+				}},
+				{Hostname: "printer.local", Addresses: []string{"192.0.2.3"}, Services: []mdns.Service{
+					{Instance: "Office", Type: "_ipp._tcp", Port: 631, TXT: map[string]string{"ty": "HP LaserJet 400", "fv": "2.1"}},
+				}},
+			}, nil
+		}
+		return p
+	}
+	tcp := setProber{alive: map[string]bool{"192.0.2.3": true}}
+
+	ns := NewScanner("192.0.2.1-4", 100*time.Millisecond, "80", 2, false, tcp, stubPortScanner{openPort: 80}, nil)
+	ns.SetExcludePorts("161")
+	ns.SetDiscovery([]string{"tcp", "mdns"})
+	summary, err := ns.ScanContext(context.Background())
+	if err != nil {
+		t.Fatalf("ScanContext() error = %v", err)
+	}
+	got := make(map[string]Result)
+	for _, r := range summary.Results {
+		got[r.IP] = r
+	}
+	if len(got) != 2 {
+		t.Fatalf("найдено хостов %d, want 2: %+v", len(got), summary.Results)
+	}
+	if browses != 1 {
+		t.Errorf("просмотров mDNS %d, want 1", browses)
+	}
+
+	cast := got["192.0.2.2"]
+	if cast.DiscoveryMethod != DiscoveryMDNS || cast.Hostname != "chromecast.local" || cast.MDNS.Model() != "Chromecast" {
+		t.Errorf("хост, найденный только по mDNS: %+v, mdns=%+v", cast, cast.MDNS)
+	}
+	printer := got["192.0.2.3"]
+	if printer.DiscoveryMethod != DiscoveryTCP || printer.Hostname != "printer.local" || printer.MDNS.Firmware() != "2.1" {
+		t.Errorf("хост, найденный по TCP: %+v, mdns=%+v", printer, printer.MDNS)
+	}
+}
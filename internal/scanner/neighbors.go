@@ -0,0 +1,263 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	apperrors "network-scanner/internal/errors"
+	"network-scanner/internal/logger"
+	"network-scanner/internal/network"
+)
+
+// neighborTableTTL — как долго прочитанная таблица соседей считается актуальной.
+const neighborTableTTL = 2 * time.Second
+
+// readNeighbors читает таблицу соседей системы.
+// Переменная подменяется в тестах, чтобы не зависеть от состояния сети.
+var readNeighbors = network.ReadNeighbors
+
+// neighborCache — таблица соседей системы (ARP и NDP), перечитываемая не чаще
+// neighborTableTTL: её читают все сканируемые IPv6-хосты. Методы nil-кэша читают
+// таблицу каждый раз.
+type neighborCache struct {
+	mu      sync.Mutex
+	readAt  time.Time
+	entries []network.Neighbor
+}
+
+// all возвращает записи таблицы соседей; при ошибке чтения — nil.
+func (c *neighborCache) all() []network.Neighbor {
+	if c == nil {
+		entries, _ := readNeighbors()
+		return entries
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.readAt.IsZero() || time.Since(c.readAt) > neighborTableTTL {
+		entries, err := readNeighbors()
+		if err != nil {
+			logger.LogDebug("Не удалось прочитать таблицу соседей: %v", err)
+		}
+		c.entries = entries
+		c.readAt = time.Now()
+	}
+	return c.entries
+}
+
+// lookup ищет MAC адреса ip в таблице соседей.
+func (c *neighborCache) lookup(ip net.IP) (net.HardwareAddr, bool) {
+	for _, n := range c.all() {
+		if n.IP.Equal(ip) {
+			return n.MAC, true
+		}
+	}
+	return nil, false
+}
+
+// neighborProber возвращает prober обнаружения соседей текущего запуска, создавая его
+// при первом обращении: один опрос ff02::1 и таблицы соседей на запуск.
+func (ns *NetworkScanner) neighborProber() NetworkProber {
+	if ns.ndpProber == nil {
+		ns.ndpProber = newDiscoveryProber(DiscoveryNDP, ns.timeout, ns.source)
+	}
+	return ns.ndpProber
+}
+
+// expandNeighborTargets заменяет IPv6-сети, которые нельзя перебрать по адресам
+// (network.NeighborTarget: шире /96 или с зоной), адресами, найденными обнаружением
+// соседей; остальные цели не меняются. Link-local адреса получают зону интерфейса.
+func (ns *NetworkScanner) expandNeighborTargets(ctx context.Context, specs []string) ([]string, error) {
+	ns.expandedTargets = nil
+	out := make([]string, 0, len(specs))
+	var (
+		neighbors []network.Neighbor
+		networks  []string
+	)
+	for _, spec := range specs {
+		prefix, zone, ok := network.NeighborTarget(spec)
+		if !ok {
+			out = append(out, spec)
+			continue
+		}
+		if ns.proxy != nil {
+			return nil, apperrors.NewInvalidInputError("network", fmt.Sprintf("IPv6-сеть %s нельзя перебрать через прокси: обнаружение соседей работает только в локальной сети", spec))
+		}
+		if networks == nil {
+			d, ok := ns.neighborProber().(neighborDiscoverer)
+			if !ok {
+				return nil, apperrors.NewInvalidInputError("network", fmt.Sprintf("IPv6-сеть %s: обнаружение соседей недоступно", spec))
+			}
+			var err error
+			neighbors, err = d.Discover(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				logger.Log("Обнаружение соседей IPv6 не удалось: %v", err)
+			}
+		}
+		networks = append(networks, spec)
+		found := 0
+		for _, n := range neighbors {
+			addr, ok := netip.AddrFromSlice(n.IP)
+			if !ok || !prefix.Contains(addr.Unmap()) || (zone != "" && n.Interface != zone) {
+				continue
+			}
+			out = append(out, n.Addr())
+			found++
+		}
+		logger.Log("IPv6-сеть %s: обнаружением соседей найдено адресов: %d", spec, found)
+	}
+	if networks == nil {
+		return specs, nil
+	}
+	if len(out) == 0 {
+		return nil, apperrors.NewInvalidInputError("network", "в IPv6-сетях "+strings.Join(networks, ", ")+" не найдено соседей")
+	}
+	ns.expandedTargets = out
+	return out, nil
+}
+
+// hostAddr возвращает адрес хоста для соединений: link-local IPv6 — с зоной из целей.
+func (ns *NetworkScanner) hostAddr(ip net.IP) string {
+	s := ip.String()
+	if zone, ok := ns.zones[s]; ok {
+		return s + "%" + zone
+	}
+	return s
+}
+
+// correlateResults объединяет результаты одного устройства (dual-stack) по MAC и
+// дополняет адреса устройств записями таблицы соседей с тем же MAC.
+func (ns *NetworkScanner) correlateResults(results []Result) []Result {
+	for _, r := range results {
+		if r.MAC != "" {
+			return correlateDualStack(results, ns.neighbors.all())
+		}
+	}
+	return results
+}
+
+// correlateDualStack объединяет результаты с одинаковым MAC в один: основной — первый
+// IPv4-результат (иначе первый), порты и протоколы объединяются, адреса всех результатов
+// и соседей с этим MAC попадают в Addresses. Результаты без MAC не меняются.
+// Порядок — порядок первого появления устройства.
+func correlateDualStack(results []Result, neighbors []network.Neighbor) []Result {
+	groups := make(map[string][]int)
+	order := make([]string, 0)
+	for i, r := range results {
+		mac := normalizeMAC(r.MAC)
+		if mac == "" {
+			continue
+		}
+		if _, ok := groups[mac]; !ok {
+			order = append(order, mac)
+		}
+		groups[mac] = append(groups[mac], i)
+	}
+	if len(groups) == 0 {
+		return results
+	}
+	neighborAddrs := make(map[string][]string)
+	for _, n := range neighbors {
+		mac := normalizeMAC(n.MAC.String())
+		if _, ok := groups[mac]; ok {
+			neighborAddrs[mac] = append(neighborAddrs[mac], n.Addr())
+		}
+	}
+
+	merged := make(map[string]Result, len(groups))
+	for _, mac := range order {
+		idx := groups[mac]
+		primary := idx[0]
+		for _, i := range idx {
+			if ip, _ := network.SplitZone(results[i].IP); ip != nil && ip.To4() != nil {
+				primary = i
+				break
+			}
+		}
+		device := results[primary]
+		device.Ports = append([]PortInfo(nil), device.Ports...)
+		device.Protocols = append([]string(nil), device.Protocols...)
+		addrs := []string{device.IP}
+		for _, i := range idx {
+			if i == primary {
+				continue
+			}
+			r := results[i]
+			addrs = appendIfNotExists(addrs, r.IP)
+			for _, p := range r.Ports {
+				if !hasPort(device.Ports, p.Port, p.Protocol) {
+					device.Ports = append(device.Ports, p)
+				}
+			}
+			for _, proto := range r.Protocols {
+				device.Protocols = appendIfNotExists(device.Protocols, proto)
+			}
+			if device.Hostname == "" {
+				device.Hostname = r.Hostname
+			}
+			if device.GuessOS == "" {
+				device.GuessOS, device.GuessOSConfidence, device.GuessOSReason = r.GuessOS, r.GuessOSConfidence, r.GuessOSReason
+			}
+			device.SNMPEnabled = device.SNMPEnabled || r.SNMPEnabled
+		}
+		for _, addr := range neighborAddrs[mac] {
+			if !containsAddr(addrs, addr) {
+				addrs = append(addrs, addr)
+			}
+		}
+		if len(addrs) > 1 {
+			device.Addresses = addrs
+		}
+		merged[mac] = device
+	}
+
+	out := make([]Result, 0, len(results))
+	for _, r := range results {
+		mac := normalizeMAC(r.MAC)
+		if mac == "" {
+			out = append(out, r)
+			continue
+		}
+		if device, ok := merged[mac]; ok {
+			out = append(out, device)
+			delete(merged, mac)
+		}
+	}
+	return out
+}
+
+// containsAddr сравнивает адреса без учёта зоны (fe80::1 и fe80::1%eth0 — один адрес).
+func containsAddr(addrs []string, addr string) bool {
+	for _, a := range addrs {
+		if network.StripZone(a) == network.StripZone(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPort проверяет, есть ли порт с протоколом в списке (в любом состоянии).
+func hasPort(ports []PortInfo, port int, protocol string) bool {
+	for _, p := range ports {
+		if p.Port == port && p.Protocol == protocol {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeMAC приводит MAC к каноническому виду; пусто — MAC неизвестен или некорректен.
+func normalizeMAC(mac string) string {
+	hw, err := net.ParseMAC(strings.TrimSpace(mac))
+	if err != nil {
+		return ""
+	}
+	return hw.String()
+}
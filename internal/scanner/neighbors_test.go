@@ -0,0 +1,119 @@
+package scanner
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	apperrors "network-scanner/internal/errors"
+	"network-scanner/internal/network"
+)
+
+// stubNeighbors подменяет обнаружение соседей и таблицу соседей системы на время теста.
+func stubNeighbors(t *testing.T, echo, table []network.Neighbor) {
+	t.Helper()
+	origProber, origRead := newDiscoveryProber, readNeighbors
+	t.Cleanup(func() { newDiscoveryProber, readNeighbors = origProber, origRead })
+	readNeighbors = func() ([]network.Neighbor, error) { return table, nil }
+	newDiscoveryProber = func(method string, timeout time.Duration, src network.Source) NetworkProber {
+		if method != DiscoveryNDP {
+			return origProber(method, timeout, src)
+		}
+		return &network.NDPProber{
+			Echo:      func(context.Context, time.Duration) ([]network.Neighbor, error) { return echo, nil },
+			Neighbors: readNeighbors,
+		}
+	}
+}
+
+func TestScanContextIPv6NeighborTargets(t *testing.T) {
+	macA := net.HardwareAddr{0x02, 0, 0, 0, 0, 0x0a}
+	macB := net.HardwareAddr{0x02, 0, 0, 0, 0, 0x0b}
+	stubNeighbors(t,
+		[]network.Neighbor{{IP: net.ParseIP("fe80::b"), Interface: "eth0"}},
+		[]network.Neighbor{
+			{IP: net.ParseIP("2001:db8:1::a"), MAC: macA, Interface: "eth0"},
+			{IP: net.ParseIP("fe80::a"), MAC: macA, Interface: "eth0"},
+			{IP: net.ParseIP("fe80::c"), MAC: macB, Interface: "eth1"},
+			{IP: net.ParseIP("2001:db8:2::1"), MAC: macB, Interface: "eth0"},
+		},
+	)
+
+	ns := NewScanner("2001:db8:1::/64 fe80::/64%eth0", 100*time.Millisecond, "80", 2, false, setProber{}, stubPortScanner{openPort: 80}, nil)
+	ns.SetExcludePorts("161")
+	summary, err := ns.ScanContext(context.Background())
+	if err != nil {
+		t.Fatalf("ScanContext() error = %v", err)
+	}
+	if summary.TotalHosts != 3 || summary.AliveHosts != 3 {
+		t.Errorf("TotalHosts/AliveHosts = %d/%d, want 3/3", summary.TotalHosts, summary.AliveHosts)
+	}
+
+	// 2001:db8:1::a и fe80::a%eth0 — одно устройство; fe80::b ответил на echo без записи в таблице
+	byIP := make(map[string]Result)
+	for _, r := range summary.Results {
+		byIP[r.IP] = r
+	}
+	if len(byIP) != 2 {
+		t.Fatalf("Results = %+v, want 2 устройства", summary.Results)
+	}
+	dev, ok := byIP["2001:db8:1::a"]
+	if !ok {
+		dev = byIP["fe80::a%eth0"]
+	}
+	addrs := append([]string(nil), dev.Addresses...)
+	sort.Strings(addrs)
+	if dev.MAC != macA.String() || !reflect.DeepEqual(addrs, []string{"2001:db8:1::a", "fe80::a%eth0"}) {
+		t.Errorf("устройство = MAC %s, Addresses %v", dev.MAC, dev.Addresses)
+	}
+	if dev.DiscoveryMethod != DiscoveryNDP || len(dev.Ports) != 1 || dev.Ports[0].Port != 80 {
+		t.Errorf("устройство = %+v", dev)
+	}
+	if r, ok := byIP["fe80::b%eth0"]; !ok || r.MAC != "" || r.Addresses != nil {
+		t.Errorf("fe80::b%%eth0 = %+v, %v", r, ok)
+	}
+
+	// Без найденных соседей IPv6-сеть сканировать нечего
+	stubNeighbors(t, nil, nil)
+	ns = NewScanner("2001:db8:9::/64", 100*time.Millisecond, "80", 2, false, setProber{}, stubPortScanner{openPort: 80}, nil)
+	if _, err := ns.ScanContext(context.Background()); !apperrors.IsInvalidInput(err) {
+		t.Errorf("ScanContext() error = %v, want InvalidInputError", err)
+	}
+}
+
+func TestCorrelateDualStack(t *testing.T) {
+	mac := "02:00:00:00:00:01"
+	results := []Result{
+		{IP: "fe80::1%eth0", MAC: mac, Ports: []PortInfo{{Port: 22, State: "open", Protocol: "tcp"}}, Hostname: "nas.local"},
+		{IP: "192.0.2.9", MAC: "", Ports: []PortInfo{{Port: 80, State: "open", Protocol: "tcp"}}},
+		{IP: "192.0.2.1", MAC: "02-00-00-00-00-01", Ports: []PortInfo{{Port: 22, State: "open", Protocol: "tcp"}, {Port: 443, State: "open", Protocol: "tcp"}}},
+	}
+	neighbors := []network.Neighbor{
+		{IP: net.ParseIP("2001:db8::1"), MAC: net.HardwareAddr{0x02, 0, 0, 0, 0, 0x01}, Interface: "eth0"},
+		{IP: net.ParseIP("fe80::1"), MAC: net.HardwareAddr{0x02, 0, 0, 0, 0, 0x01}, Interface: "eth0"},
+		{IP: net.ParseIP("192.0.2.50"), MAC: net.HardwareAddr{0x02, 0, 0, 0, 0, 0x50}},
+	}
+	got := correlateDualStack(results, neighbors)
+	if len(got) != 2 {
+		t.Fatalf("correlateDualStack() = %+v, want 2 результата", got)
+	}
+	dev := got[0]
+	if dev.IP != "192.0.2.1" || dev.Hostname != "nas.local" {
+		t.Errorf("основной адрес/имя = %s/%s, want 192.0.2.1/nas.local", dev.IP, dev.Hostname)
+	}
+	if want := []string{"192.0.2.1", "fe80::1%eth0", "2001:db8::1"}; !reflect.DeepEqual(dev.Addresses, want) {
+		t.Errorf("Addresses = %v, want %v", dev.Addresses, want)
+	}
+	if len(dev.Ports) != 2 {
+		t.Errorf("Ports = %+v, want 22 и 443 без повторов", dev.Ports)
+	}
+	if got[1].IP != "192.0.2.9" || got[1].Addresses != nil {
+		t.Errorf("результат без MAC изменён: %+v", got[1])
+	}
+	if len(results[2].Ports) != 2 {
+		t.Error("correlateDualStack не должен менять исходные результаты")
+	}
+}
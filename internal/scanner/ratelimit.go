@@ -0,0 +1,253 @@
+package scanner
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimit ограничивает интенсивность проб: discovery, TCP, UDP, баннеры и SNMP.
+// Нулевое значение поля — без ограничения.
+type RateLimit struct {
+	ProbesPerSecond float64       // общий лимит проб в секунду на весь запуск
+	MaxPerHost      int           // одновременных проб к одному хосту
+	ProbeDelay      time.Duration // минимальная пауза между пробами к одному хосту
+}
+
+// IsZero сообщает, что ограничения не заданы.
+func (r RateLimit) IsZero() bool {
+	return r.ProbesPerSecond <= 0 && r.MaxPerHost <= 0 && r.ProbeDelay <= 0
+}
+
+// merge возвращает r, в котором незаданные поля взяты из base.
+func (r RateLimit) merge(base RateLimit) RateLimit {
+	if r.ProbesPerSecond <= 0 {
+		r.ProbesPerSecond = base.ProbesPerSecond
+	}
+	if r.MaxPerHost <= 0 {
+		r.MaxPerHost = base.MaxPerHost
+	}
+	if r.ProbeDelay <= 0 {
+		r.ProbeDelay = base.ProbeDelay
+	}
+	return r
+}
+
+// TimingTemplate — именованный профиль скорости: значения таймаута и потоков по умолчанию
+// и ограничения интенсивности.
+type TimingTemplate struct {
+	Name    string
+	Timeout time.Duration
+	Threads int
+	Rate    RateLimit
+}
+
+// Имена профилей скорости (SetTiming).
+const (
+	TimingPolite     = "polite"     // бережно: для сетей с IDS и маломощных маршрутизаторов
+	TimingNormal     = "normal"     // поведение по умолчанию
+	TimingAggressive = "aggressive" // быстро: для надёжных локальных сетей
+)
+
+// TimingTemplates — встроенные профили скорости по имени.
+var TimingTemplates = map[string]TimingTemplate{
+	TimingPolite: {
+		Name:    TimingPolite,
+		Timeout: 3 * time.Second,
+		Threads: 10,
+		Rate:    RateLimit{ProbesPerSecond: 50, MaxPerHost: 2, ProbeDelay: 100 * time.Millisecond},
+	},
+	TimingNormal: {
+		Name:    TimingNormal,
+		Timeout: 2 * time.Second,
+		Threads: 50,
+	},
+	TimingAggressive: {
+		Name:    TimingAggressive,
+		Timeout: 1 * time.Second,
+		Threads: 120,
+	},
+}
+
+// Defaults подставляет таймаут и число потоков профиля вместо незаданных (нулевых) значений.
+func (t TimingTemplate) Defaults(timeout time.Duration, threads int) (time.Duration, int) {
+	if timeout <= 0 {
+		timeout = t.Timeout
+	}
+	if threads <= 0 {
+		threads = t.Threads
+	}
+	return timeout, threads
+}
+
+// LookupTimingTemplate возвращает профиль скорости по имени (без учёта регистра).
+func LookupTimingTemplate(name string) (TimingTemplate, bool) {
+	t, ok := TimingTemplates[strings.ToLower(strings.TrimSpace(name))]
+	return t, ok
+}
+
+// TimingTemplateNames возвращает имена профилей в алфавитном порядке.
+func TimingTemplateNames() []string {
+	names := make([]string, 0, len(TimingTemplates))
+	for name := range TimingTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// rateLimiter — общий для запуска token bucket плюс ограничения на хост.
+// Ёмкость корзины — 1/20 секунды трафика (не меньше одной пробы), поэтому
+// кратковременные всплески сглаживаются, а средняя скорость не превышает лимит.
+type rateLimiter struct {
+	limit    RateLimit
+	interval time.Duration // время на одну пробу при ProbesPerSecond
+	burst    time.Duration // допустимый «запас» токенов в единицах времени
+
+	mu      sync.Mutex
+	next    time.Time             // момент, с которого доступна следующая проба
+	hosts   map[string]*hostLimit // состояние хостов с пробами в работе или незавершённой паузой
+	sweepAt int                   // размер hosts, при котором удаляются простаивающие хосты
+}
+
+// hostLimit — ограничения одного хоста. Запись удаляется, когда у хоста не остаётся проб
+// в работе и истекла пауза ProbeDelay, поэтому размер карты не растёт с числом
+// проверенных за запуск хостов.
+type hostLimit struct {
+	slots    chan struct{} // семафор MaxPerHost; nil — без ограничения
+	inflight int           // выданных и не освобождённых разрешений
+	next     time.Time     // следующий разрешённый момент для хоста (ProbeDelay)
+}
+
+// minHostSweep — размер карты хостов, начиная с которого выполняется очистка.
+const minHostSweep = 256
+
+// newRateLimiter создаёт ограничитель; для нулевого RateLimit возвращает nil
+// (методы nil-ограничителя ничего не ждут).
+func newRateLimiter(limit RateLimit) *rateLimiter {
+	if limit.IsZero() {
+		return nil
+	}
+	l := &rateLimiter{
+		limit:   limit,
+		hosts:   make(map[string]*hostLimit),
+		sweepAt: minHostSweep,
+	}
+	if limit.ProbesPerSecond > 0 {
+		l.interval = time.Duration(float64(time.Second) / limit.ProbesPerSecond)
+		// Первая проба корзины выдаётся сразу, остальные — из накопленного запаса
+		burst := math.Max(1, math.Ceil(limit.ProbesPerSecond/20))
+		l.burst = time.Duration(burst-1) * l.interval
+	}
+	return l
+}
+
+// acquire ждёт разрешения на n проб к host (пустой host — без ограничений на хост)
+// и возвращает функцию освобождения слота. Ошибка — только при отмене ctx.
+func (l *rateLimiter) acquire(ctx context.Context, host string, n int) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	if n < 1 {
+		n = 1
+	}
+	release := func() {}
+	var h *hostLimit
+	if host != "" && (l.limit.MaxPerHost > 0 || l.limit.ProbeDelay > 0) {
+		h = l.holdHost(host)
+		release = func() { l.releaseHost(host, h) }
+	}
+	if h != nil && h.slots != nil {
+		select {
+		case h.slots <- struct{}{}:
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		}
+		slotRelease := release
+		release = func() {
+			<-h.slots
+			slotRelease()
+		}
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	at := now
+	if h != nil && l.limit.ProbeDelay > 0 {
+		if h.next.After(at) {
+			at = h.next
+		}
+		h.next = at.Add(l.limit.ProbeDelay)
+	}
+	if l.interval > 0 {
+		if floor := now.Add(-l.burst); l.next.Before(floor) {
+			l.next = floor
+		}
+		if l.next.After(at) {
+			at = l.next
+		}
+		l.next = l.next.Add(time.Duration(n) * l.interval)
+	}
+	l.mu.Unlock()
+
+	if err := sleepContext(ctx, time.Until(at)); err != nil {
+		release()
+		return nil, err
+	}
+	return release, nil
+}
+
+// holdHost возвращает состояние host (создаёт при необходимости) и учитывает пробу в работе.
+// Когда карта достигает sweepAt, из неё удаляются простаивающие хосты.
+func (l *rateLimiter) holdHost(host string) *hostLimit {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	h, ok := l.hosts[host]
+	if !ok {
+		if len(l.hosts) >= l.sweepAt {
+			now := time.Now()
+			for key, idle := range l.hosts {
+				if idle.inflight == 0 && !idle.next.After(now) {
+					delete(l.hosts, key)
+				}
+			}
+			l.sweepAt = max(minHostSweep, 2*len(l.hosts))
+		}
+		h = &hostLimit{}
+		if l.limit.MaxPerHost > 0 {
+			h.slots = make(chan struct{}, l.limit.MaxPerHost)
+		}
+		l.hosts[host] = h
+	}
+	h.inflight++
+	return h
+}
+
+// releaseHost снимает учёт пробы; хост без проб в работе и с истёкшей паузой забывается.
+func (l *rateLimiter) releaseHost(host string, h *hostLimit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	h.inflight--
+	if h.inflight == 0 && !h.next.After(time.Now()) && l.hosts[host] == h {
+		delete(l.hosts, host)
+	}
+}
+
+// sleepContext ждёт d или отмены ctx.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,150 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	apperrors "network-scanner/internal/errors"
+)
+
+func TestRateLimiterNilDoesNotWait(t *testing.T) {
+	l := newRateLimiter(RateLimit{})
+	if l != nil {
+		t.Fatal("newRateLimiter(zero) должен возвращать nil")
+	}
+	release, err := l.acquire(context.Background(), "10.0.0.1", 5)
+	if err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+	release()
+}
+
+func TestRateLimiterProbesPerSecond(t *testing.T) {
+	l := newRateLimiter(RateLimit{ProbesPerSecond: 20})
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		release, err := l.acquire(context.Background(), "", 1)
+		if err != nil {
+			t.Fatalf("acquire() error = %v", err)
+		}
+		release()
+	}
+	// Первая проба из запаса корзины, остальные четыре — по 50 мс
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("5 проб при 20/с заняли %v, ожидалось не меньше ~200 мс", elapsed)
+	}
+}
+
+func TestRateLimiterPerHost(t *testing.T) {
+	l := newRateLimiter(RateLimit{MaxPerHost: 1, ProbeDelay: 80 * time.Millisecond})
+
+	release, err := l.acquire(context.Background(), "10.0.0.1", 1)
+	if err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+	// Второй слот к тому же хосту занят до release
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	if _, err := l.acquire(ctx, "10.0.0.1", 1); err == nil {
+		t.Error("acquire() должен ждать освобождения слота хоста")
+	}
+	// Другой хост не ограничен ни слотом, ни паузой
+	start := time.Now()
+	other, err := l.acquire(context.Background(), "10.0.0.2", 1)
+	if err != nil || time.Since(start) > 40*time.Millisecond {
+		t.Errorf("другой хост: err = %v, ожидание %v", err, time.Since(start))
+	}
+	other()
+	release()
+
+	// Пауза между пробами к одному хосту
+	start = time.Now()
+	release, err = l.acquire(context.Background(), "10.0.0.1", 1)
+	if err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+	release()
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("пауза между пробами к хосту %v, ожидалось ~80 мс", elapsed)
+	}
+}
+
+func TestRateLimiterForgetsIdleHosts(t *testing.T) {
+	l := newRateLimiter(RateLimit{MaxPerHost: 2})
+	for i := 0; i < 1000; i++ {
+		release, err := l.acquire(context.Background(), fmt.Sprintf("10.0.%d.%d", i/256, i%256), 1)
+		if err != nil {
+			t.Fatalf("acquire() error = %v", err)
+		}
+		release()
+	}
+	if n := len(l.hosts); n != 0 {
+		t.Errorf("после освобождения всех проб осталось хостов: %d", n)
+	}
+
+	// Хост с незавершённой паузой остаётся до очистки карты
+	l = newRateLimiter(RateLimit{ProbeDelay: time.Millisecond})
+	for i := 0; i < minHostSweep; i++ {
+		release, err := l.acquire(context.Background(), fmt.Sprintf("10.1.%d.%d", i/256, i%256), 1)
+		if err != nil {
+			t.Fatalf("acquire() error = %v", err)
+		}
+		release()
+	}
+	time.Sleep(10 * time.Millisecond)
+	release, err := l.acquire(context.Background(), "10.2.0.1", 1)
+	if err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+	release()
+	if n := len(l.hosts); n != 1 {
+		t.Errorf("после очистки осталось хостов: %d, ожидался 1", n)
+	}
+}
+
+func TestLookupTimingTemplate(t *testing.T) {
+	tmpl, ok := LookupTimingTemplate(" Polite ")
+	if !ok || tmpl.Name != TimingPolite || tmpl.Rate.IsZero() {
+		t.Errorf("LookupTimingTemplate(polite) = %+v, %v", tmpl, ok)
+	}
+	if _, ok := LookupTimingTemplate("insane"); ok {
+		t.Error("неизвестный профиль не должен находиться")
+	}
+	if names := TimingTemplateNames(); len(names) != 3 || names[0] != TimingAggressive {
+		t.Errorf("TimingTemplateNames() = %v", names)
+	}
+	if timeout, threads := tmpl.Defaults(0, 80); timeout != 3*time.Second || threads != 80 {
+		t.Errorf("Defaults(0, 80) = %v, %d", timeout, threads)
+	}
+}
+
+func TestScanContextRateLimit(t *testing.T) {
+	ns := NewScanner("127.0.0.1", 100*time.Millisecond, "1-10", 2, false, stubProber{}, stubPortScanner{openPort: 5}, nil)
+	ns.SetExcludePorts("161")
+	ns.SetRateLimit(RateLimit{ProbesPerSecond: 50})
+	start := time.Now()
+	summary, err := ns.ScanContext(context.Background())
+	if err != nil {
+		t.Fatalf("ScanContext() error = %v", err)
+	}
+	if len(summary.Results) != 1 || !hasOpenPort(summary.Results[0].Ports, 5, "tcp") {
+		t.Errorf("results = %+v", summary.Results)
+	}
+	// 6 токенов на проверку доступности и 10 TCP-проб при 50/с
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("сканирование с лимитом 50 проб/с заняло %v", elapsed)
+	}
+
+	ns.SetRateLimit(RateLimit{})
+	ns.SetTiming("insane")
+	if _, err := ns.ScanContext(context.Background()); !apperrors.IsInvalidInput(err) {
+		t.Errorf("ScanContext() error = %v, want InvalidInputError", err)
+	}
+	ns.SetTiming("")
+	ns.SetRateLimit(RateLimit{MaxPerHost: -1})
+	if _, err := ns.ScanContext(context.Background()); !apperrors.IsInvalidInput(err) {
+		t.Errorf("ScanContext() error = %v, want InvalidInputError", err)
+	}
+}
@@ -0,0 +1,105 @@
+package scanner
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// hostTimeoutMin — нижняя граница таймаута пробы, выведенного из RTT хоста.
+	hostTimeoutMin = 100 * time.Millisecond
+	// rttVarianceFactor — множитель RTTVAR в таймауте (SRTT + 4·RTTVAR, как RTO в RFC 6298).
+	rttVarianceFactor = 4
+)
+
+// rttEstimator оценивает RTT до хостов по ответам discovery и открытых портов
+// (SRTT/RTTVAR по RFC 6298) и выводит из них таймауты проб: LAN-хосты получают
+// короткий таймаут, медленные WAN/VPN — до полного таймаута сканирования.
+// Методы nil-оценщика безопасны: таймаут всегда равен переданному максимуму.
+type rttEstimator struct {
+	mu      sync.Mutex
+	hosts   map[string]hostRTT
+	samples []time.Duration // все замеры запуска — для перцентилей в диагностике
+}
+
+type hostRTT struct {
+	srtt   time.Duration
+	rttvar time.Duration
+}
+
+func newRTTEstimator() *rttEstimator {
+	return &rttEstimator{hosts: make(map[string]hostRTT)}
+}
+
+// observe добавляет замер RTT до host.
+func (e *rttEstimator) observe(host string, rtt time.Duration) {
+	if e == nil || rtt <= 0 {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.samples = append(e.samples, rtt)
+	h, ok := e.hosts[host]
+	if !ok {
+		e.hosts[host] = hostRTT{srtt: rtt, rttvar: rtt / 2}
+		return
+	}
+	diff := h.srtt - rtt
+	if diff < 0 {
+		diff = -diff
+	}
+	h.rttvar = (3*h.rttvar + diff) / 4
+	h.srtt = (7*h.srtt + rtt) / 8
+	e.hosts[host] = h
+}
+
+// timeout возвращает таймаут пробы к host: SRTT + 4·RTTVAR в пределах
+// [hostTimeoutMin, max]. Без замеров — max.
+func (e *rttEstimator) timeout(host string, max time.Duration) time.Duration {
+	if e == nil {
+		return max
+	}
+	e.mu.Lock()
+	h, ok := e.hosts[host]
+	e.mu.Unlock()
+	if !ok {
+		return max
+	}
+	t := h.srtt + rttVarianceFactor*h.rttvar
+	if t < hostTimeoutMin {
+		t = hostTimeoutMin
+	}
+	if t > max {
+		t = max
+	}
+	return t
+}
+
+// percentiles возвращает перцентили замеров (p — доли от 0 до 1); nil, если замеров нет.
+func (e *rttEstimator) percentiles(ps ...float64) []time.Duration {
+	if e == nil {
+		return nil
+	}
+	e.mu.Lock()
+	sorted := append([]time.Duration(nil), e.samples...)
+	e.mu.Unlock()
+	if len(sorted) == 0 {
+		return nil
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	out := make([]time.Duration, len(ps))
+	for i, p := range ps {
+		// nearest-rank: наименьший замер, не меньше которого доля p всех замеров
+		rank := int(math.Ceil(p * float64(len(sorted))))
+		if rank < 1 {
+			rank = 1
+		}
+		if rank > len(sorted) {
+			rank = len(sorted)
+		}
+		out[i] = sorted[rank-1]
+	}
+	return out
+}
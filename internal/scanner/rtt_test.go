@@ -0,0 +1,60 @@
+package scanner
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRTTEstimatorTimeout(t *testing.T) {
+	e := newRTTEstimator()
+	if got := e.timeout("10.0.0.1", 2*time.Second); got != 2*time.Second {
+		t.Errorf("timeout без замеров = %v, want 2s", got)
+	}
+
+	// LAN: таймаут упирается в нижнюю границу
+	e.observe("10.0.0.1", 500*time.Microsecond)
+	if got := e.timeout("10.0.0.1", 2*time.Second); got != hostTimeoutMin {
+		t.Errorf("timeout LAN = %v, want %v", got, hostTimeoutMin)
+	}
+
+	// VPN: SRTT + 4·RTTVAR = 200ms + 4·100ms
+	e.observe("10.8.0.1", 200*time.Millisecond)
+	if got := e.timeout("10.8.0.1", 2*time.Second); got != 600*time.Millisecond {
+		t.Errorf("timeout VPN = %v, want 600ms", got)
+	}
+	// Верхняя граница — таймаут сканирования
+	if got := e.timeout("10.8.0.1", 300*time.Millisecond); got != 300*time.Millisecond {
+		t.Errorf("timeout с малым максимумом = %v, want 300ms", got)
+	}
+
+	// Последующие замеры сглаживаются: стабильный RTT сужает разброс
+	for i := 0; i < 20; i++ {
+		e.observe("10.8.0.1", 200*time.Millisecond)
+	}
+	if got := e.timeout("10.8.0.1", 2*time.Second); got >= 300*time.Millisecond {
+		t.Errorf("timeout после стабильных замеров = %v, want < 300ms", got)
+	}
+}
+
+func TestRTTEstimatorPercentiles(t *testing.T) {
+	var nilEstimator *rttEstimator
+	nilEstimator.observe("10.0.0.1", time.Millisecond)
+	if got := nilEstimator.timeout("10.0.0.1", time.Second); got != time.Second {
+		t.Errorf("nil timeout = %v", got)
+	}
+	if p := newRTTEstimator().percentiles(0.5); p != nil {
+		t.Errorf("percentiles без замеров = %v, want nil", p)
+	}
+
+	e := newRTTEstimator()
+	for i := 1; i <= 100; i++ {
+		e.observe("10.0.0.1", time.Duration(i)*time.Millisecond)
+	}
+	p := e.percentiles(0.5, 0.9, 0.99)
+	want := []time.Duration{50 * time.Millisecond, 90 * time.Millisecond, 99 * time.Millisecond}
+	for i := range want {
+		if p[i] != want[i] {
+			t.Errorf("percentiles()[%d] = %v, want %v", i, p[i], want[i])
+		}
+	}
+}
@@ -3,11 +3,14 @@ package scanner
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"os"
 	"os/exec"
+	"os/user"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -18,11 +21,17 @@ import (
 	"github.com/google/gopacket/pcap"
 
 	"network-scanner/internal/banner"
+	apperrors "network-scanner/internal/errors"
+	"network-scanner/internal/httpinspect"
 	"network-scanner/internal/logger"
+	"network-scanner/internal/mdns"
 	"network-scanner/internal/network"
-	"network-scanner/internal/osdetect"
 	portdb "network-scanner/internal/ports"
 	"network-scanner/internal/scanner/deviceclassifier"
+	"network-scanner/internal/smbinspect"
+	"network-scanner/internal/sshinspect"
+	"network-scanner/internal/tlsinspect"
+	"network-scanner/internal/upnp"
 )
 
 // Package scanner предоставляет основной движок сканирования сети.
@@ -33,13 +42,15 @@ import (
 //
 //	NewNetworkScanner() — создает сканер с дефолтными зависимостями.
 //	NewScanner() — создает сканер с внедренными зависимостями (DI).
-//	Scan() — запускает сканирование сети.
-//	Stop() — останавливает сканирование.
+//	ScanContext() — запускает сканирование сети с отменой через context и типизированными ошибками.
+//	Scan() — устаревшая обёртка над ScanContext без контекста.
+//	Stop() — останавливает текущее сканирование.
 //	GetResults() — возвращает результаты сканирования.
 //
 // # Процесс сканирования
 //
-//  1. ParseNetworkRange — парсит CIDR диапазон (например 192.168.1.0/24)
+//  1. TargetIterator — лениво перебирает цели (CIDR, диапазоны, IP, имена хостов) без повторов,
+//     пропуская адреса из списка исключений (SetExclude); порядок — по целям или случайный (SetRandomizeHosts)
 //  2. Ping discovery — проверяет доступность хостов через ICMP/ports
 //  3. Port scanning — сканирует TCP/UDP порты на активных хостах
 //  4. MAC/Hostname — получает MAC адрес и hostname для каждого хоста
@@ -48,10 +59,9 @@ import (
 //
 // # UDP сканирование
 //
-// UDP сканирование включает известные порты:
-//
-//	const knownUDPPorts = 9 // 53, 67, 68, 69, 123, 161, 162, 514, 1194
-//
+// UDP сканирование опрашивает порты из SetUDPPorts (по умолчанию network.DefaultUDPPorts)
+// протокольными пробами network.ProbeUDP (DNS, NTP, SNMP, SSDP, NetBIOS, mDNS, TFTP, IKE):
+// ответ — open, ICMP port unreachable — closed, тишина — open|filtered.
 // Для каждого хоста запускается параллельное сканирование с ограничением
 // параллельности (udpSemaphoreSize=50).
 //
@@ -85,6 +95,10 @@ import (
 //  2. Системную ARP таблицу (/proc/net/arp, arp -a, arp -n)
 //  3. PCAP ARP request (требует root прав)
 //
+// Для IPv6 вместо ARP — обнаружение соседей (DiscoveryNDP) и таблица соседей системы
+// (на Linux через netlink). После запуска результаты с одним MAC (IPv4 и IPv6 адреса
+// одного устройства) объединяются в один Result с полем Addresses.
+//
 // # Конфигурация таймаутов
 //
 //	const (
@@ -108,17 +122,23 @@ import (
 //	    ns.SetProgressCallback(func(stage string, current, total int, msg string) {
 //	        fmt.Printf("%s: %d/%d - %s\n", stage, current, total, msg)
 //	    })
-//	    ns.Scan()
-//	    results := ns.GetResults()
+//	    ns.SetHostCallback(func(host scanner.Result) {
+//	        fmt.Printf("Хост готов: %s (%d портов)\n", host.IP, len(host.Ports))
+//	    })
+//	    summary, err := ns.ScanContext(ctx)
+//	    if err != nil {
+//	        // errors.IsInvalidInput / IsPermission / IsTimeout / IsCancelled
+//	    }
+//	    results := summary.Results
 //	}
 //
 // # Потокобезопасность
 //
 // NetworkScanner потокобезопасен для вызовов:
 //
-//   - SetScanUDP, SetScanTCPPorts, SetGrabBanners — до вызова Scan()
-//   - GetResults — во время и после Scan()
-//   - Stop — во время Scan() для отмены
+//   - SetTargets, SetExclude, SetExcludePorts, SetScanType, SetSource, SetProxy, SetDiscovery, SetTiming, SetRateLimit, SetAdaptiveConfig, SetCheckpoint, Resume, SetScanUDP, SetUDPPorts, SetUDPPortScanner, SetScanTCPPorts, SetGrabBanners, SetEnrich, AddEnricher, SetLookupTimeout, SetHostCallback — до вызова ScanContext()
+//   - GetResults — во время и после ScanContext()
+//   - Stop — во время ScanContext() для отмены (предпочтительнее отменять ctx)
 //
 //内部的 results слайс защищен sync.RWMutex.
 
@@ -136,39 +156,132 @@ type Result struct {
 	GuessOS           string // эвристическая оценка ОС (опционально)
 	GuessOSConfidence string // низкая/средняя/высокая
 	GuessOSReason     string // краткое обоснование эвристики
+	DiscoveryMethod   string // способ обнаружения хоста: DiscoveryARP, DiscoveryICMP, DiscoveryNDP, DiscoveryMDNS, DiscoverySSDP или DiscoveryTCP
+	// Addresses — все известные адреса устройства (IPv4 и IPv6, первый — IP), если их больше
+	// одного: результаты с одним MAC объединяются, адреса дополняются из таблицы соседей.
+	Addresses []string
+	// Via — прокси, через который сканировался хост (адрес без пароля); пусто — напрямую.
+	Via string
+	// NetBIOS — таблица имён NetBIOS (стадия netbios); nil — хост не ответил или не проверялся.
+	NetBIOS *smbinspect.NetBIOS
+	// MDNS — имя, службы DNS-SD и метаданные TXT устройства (стадия mdns); nil — устройство
+	// не объявляло служб или обнаружение mdns не выбрано.
+	MDNS *mdns.Host
+	// UPnP — описания корневых устройств UPnP хоста (стадия upnp): производитель, модель,
+	// серийный номер, прошивка, службы и пробросы портов шлюза; nil — хост не отвечал на SSDP.
+	UPnP []upnp.Device
 }
 
 // PortInfo содержит информацию о порте
 type PortInfo struct {
 	Port     int
-	State    string // "open", "closed", "filtered"
+	State    string // "open", "closed", "filtered", "open|filtered" (UDP)
 	Protocol string // "tcp", "udp"
 	Service  string
-	Banner   string // сырой ответ службы (опционально)
-	Version  string // краткая версия/сигнатура службы (опционально)
+	Banner   string            // сырой ответ службы (опционально)
+	Version  string            // краткая версия/сигнатура службы (опционально)
+	Product  string            // продукт службы, распознанный стадией service ("OpenSSH", "nginx")
+	Info     string            // дополнительные сведения о службе (ОС, протокол, модель)
+	CPE      string            // CPE 2.3 продукта и версии (стадия service)
+	TLS      *tlsinspect.Info  // версии, шифры и цепочка сертификатов TLS (стадия tls); nil — не проверялся
+	SSH      *sshinspect.Info  // алгоритмы и ключи хоста SSH-сервера (стадия ssh); nil — не проверялся
+	HTTP     *httpinspect.Info // отпечаток веб-приложения и распознанные продукты (стадия http); nil — не проверялся
+	SMB      *smbinspect.Info  // диалекты, подпись, имя и версия ОС SMB-сервера (стадия smb); nil — не проверялся
+	Reason   string            // причина состояния TCP-порта: network.ReasonSynAck, ReasonConnRefused, ReasonNoResponse и т.п.
+	Latency  time.Duration     // время до ответа на TCP-пробу (0 — ответа не было)
+}
+
+// ScanSummary содержит итоги одного запуска ScanContext.
+type ScanSummary struct {
+	Network          string
+	TotalHosts       int    // адресов в диапазоне (после исключений)
+	ExcludedHosts    int    // адресов, отброшенных списком исключений
+	AliveHosts       int    // прошли проверку доступности
+	PortsPerHost     int    // TCP-портов на хост
+	ScanType         string // фактический способ TCP-сканирования (после fallback)
+	Proxy            string // прокси, через который шли пробы (адрес без пароля); пусто — напрямую
+	Results          []Result
+	PingDuration     time.Duration
+	PortScanDuration time.Duration
+	TotalDuration    time.Duration
 }
 
 // ProgressCallback функция для передачи прогресса сканирования
 type ProgressCallback func(stage string, current int, total int, message string)
 
+// HostCallback вызывается для каждого хоста сразу после завершения его сканирования.
+// MAC и имена, найденные завершающим проходом (SetLookupTimeout), в уже переданные
+// результаты не попадают — только в ScanSummary.Results и GetResults().
+//
+// Вызовы сериализованы: callback никогда не выполняется параллельно сам с собой,
+// а порядок вызовов совпадает с порядком результатов в GetResults(). Callback
+// выполняется синхронно в горутине воркера, поэтому медленный получатель
+// притормаживает сканирование (backpressure), но ни один хост не теряется.
+type HostCallback func(result Result)
+
 // NetworkScanner выполняет сканирование сети
 type NetworkScanner struct {
 	network          string
+	targets          []string          // цели сканирования; пусто — цели берутся из network
+	targetNames      map[string]string // IP -> имя хоста из списка целей (заполняется в ScanContext)
+	exclude          []string          // исключаемые цели (тот же формат, что и targets)
+	zones            map[string]string // link-local IP -> зона (интерфейс) для соединений
+	expandedTargets  []string          // цели после раскрытия IPv6-сетей обнаружением соседей
+	randomizeHosts   bool              // перебирать адреса в псевдослучайном порядке
+	hostOrderSeed    int64             // seed порядка адресов текущего запуска (для контрольных точек)
+	excludePorts     string            // исключаемые порты (формат portRange), TCP и UDP
+	excludedPortSet  map[int]struct{}  // разобранный excludePorts (заполняется в ScanContext)
+	scanType         string            // ScanTypeConnect (по умолчанию) или ScanTypeSYN
+	sourceInterface  string            // интерфейс, с которого уходят пробы (SetSource); пусто — выбор ОС
+	sourceIP         string            // адрес источника проб (SetSource); пусто — выбор ОС
+	source           network.Source    // источник проб текущего запуска (заполняется в ScanContext)
+	proxyURL         string            // прокси для TCP-проб (SetProxy); пусто — напрямую
+	proxy            proxyDialer       // прокси текущего запуска (nil — напрямую)
+	discovery        []string          // способы обнаружения хостов по порядку; пусто — только TCP
+	discoveryMethods []string          // способы текущего запуска (discovery и NDP для IPv6-сетей)
+	discoveryProbers map[string]NetworkProber
+	ndpProber        NetworkProber // обнаружение соседей текущего запуска (создаётся по требованию)
+	mdnsProber       mdnsBrowser   // просмотр mDNS текущего запуска (способ mdns); nil — не выбран
+	ssdpProber       ssdpSearcher  // поиск SSDP текущего запуска (способ ssdp); nil — не выбран
+	neighbors        *neighborCache
+	discoveredBy     map[string]string           // IP -> способ, которым хост обнаружен
+	discoveredMACs   map[string]net.HardwareAddr // MAC, полученные ARP sweep
+	discoveryMu      sync.Mutex                  // защищает discoveredBy и discoveredMACs
+	timing           string                      // имя профиля скорости (TimingTemplates); пусто — без профиля
+	rateLimit        RateLimit                   // явные ограничения; перекрывают профиль
+	limiter          *rateLimiter                // ограничитель текущего запуска (nil — без ограничений)
+	adaptiveConfig   AdaptiveConfig              // границы окна одновременных TCP-проб
+	adaptive         *AdaptiveScanner            // окно одновременных TCP-проб текущего запуска
+	rtt              *rttEstimator               // RTT до хостов текущего запуска → таймауты проб
+	checkpointPath   string                      // файл контрольной точки (SetCheckpoint); пусто — без контрольных точек
+	checkpointID     string                      // идентификатор запуска в контрольной точке
+	resume           *Checkpoint                 // контрольная точка, с которой продолжит следующий запуск
+	checkpoint       *checkpointState            // завершённые хосты текущего запуска (nil — контрольные точки выключены)
 	timeout          time.Duration
 	portRange        string
 	threads          int
 	showClosed       bool
-	scanTCPPorts     bool // Сканировать TCP-порты из portRange (если false — только ping/MAC/hostname)
-	scanUDP          bool // Включить UDP сканирование
-	grabBanners      bool // Читать баннеры с типовых TCP-портов (медленнее)
-	osDetectActive   bool // Активный режим эвристик ОС (дополнительные сигнатуры)
-	verbosePortLogs  bool // Подробные логи по каждому порту/пробе (шумно, медленнее)
+	scanTCPPorts     bool            // Сканировать TCP-порты из portRange (если false — только ping/MAC/hostname)
+	scanUDP          bool            // Включить UDP сканирование
+	udpPorts         string          // UDP-порты (формат portRange); пусто — network.DefaultUDPPorts
+	udpPortList      []int           // разобранный udpPorts без исключённых (заполняется в ScanContext)
+	grabBanners      bool            // Читать баннеры с типовых TCP-портов (медленнее)
+	osDetectActive   bool            // Активный режим эвристик ОС (дополнительные сигнатуры)
+	verbosePortLogs  bool            // Подробные логи по каждому порту/пробе (шумно, медленнее)
+	enrichers        []EnrichStage   // стадии обогащения этого сканера (AddEnricher)
+	enrichSpec       string          // настройка стадий (SetEnrich, формат ParseEnrichConfig)
+	enrich           *enrichPipeline // конвейер обогащения текущего запуска
+	lookupTimeout    time.Duration   // предел завершающего прохода MAC и имён (SetLookupTimeout); 0 — DefaultLookupTimeout
 	results          []Result
 	mu               sync.RWMutex
-	ctx              context.Context
-	cancel           context.CancelFunc
+	ctx              context.Context    // контекст текущего запуска (производный от ctx caller-а)
+	cancel           context.CancelFunc // отменяет текущий запуск
+	ctxMu            sync.Mutex         // защищает ctx/cancel/stopped между ScanContext и Stop
+	stopped          bool               // Stop() вызван: новые запуски сразу завершаются отменой
 	wg               sync.WaitGroup
 	progressCallback ProgressCallback
+	hostCallback     HostCallback
+	hostMu           sync.Mutex // сериализует публикацию хостов и вызовы hostCallback
 	networkProber    NetworkProber
 	portScanner      PortScanner
 	udpPortScanner   PortScanner
@@ -185,6 +298,11 @@ type NetworkScanner struct {
 	lastPingNs       int64
 	lastPortscanNs   int64
 	lastTotalNs      int64
+	lastLookupNs     int64 // длительность завершающего прохода MAC и имён
+	lookupMACsFound  int64 // MAC, найденных завершающим проходом
+	lookupNamesFound int64 // имён, найденных завершающим проходом
+	// pingPermissionDenied — число хостов, проверка которых упёрлась в нехватку прав
+	pingPermissionDenied int64
 }
 
 const (
@@ -194,12 +312,8 @@ const (
 	minPerHostPortThreads = 8
 	maxPerHostPortThreads = 64
 
-	// UDP порты для сканирования
-	knownUDPPorts = 9
-
 	// Магические числа для сканирования
 	udpSemaphoreSize       = 50
-	udpResultBufferSize    = 9 // равно knownUDPPorts
 	udpCollectTimeout      = 100 * time.Millisecond
 	udpProbeTimeoutDivisor = 3
 
@@ -218,7 +332,7 @@ const (
 	// SNMP UDP/TCP порт
 	snmpPort = 161
 
-	// Задержки для неблокирующих операций
+	// Таймауты стадий обогащения mac и hostname по умолчанию
 	macTimeout         = 100 * time.Millisecond
 	hostnameTimeout    = 100 * time.Millisecond
 	arpCommandTimeout  = 3 * time.Second
@@ -239,6 +353,91 @@ const (
 	pcapBufferSize = 1024
 )
 
+// Способы TCP-сканирования портов (SetScanType).
+const (
+	ScanTypeConnect = "connect" // полное TCP-соединение, права не нужны
+	ScanTypeSYN     = "syn"     // half-open SYN через pcap, нужны root/CAP_NET_RAW
+)
+
+// Способы обнаружения хостов (SetDiscovery).
+const (
+	DiscoveryARP  = "arp"  // ARP sweep по подключённым IPv4-подсетям, нужны root/CAP_NET_RAW
+	DiscoveryICMP = "icmp" // ICMP echo через непривилегированные ping-сокеты или raw-сокет
+	DiscoveryNDP  = "ndp"  // IPv6: ICMPv6 echo на ff02::1 и кэш соседей (NDP)
+	DiscoveryMDNS = "mdns" // устройства, объявившие службы mDNS/DNS-SD в локальной сети
+	DiscoverySSDP = "ssdp" // устройства UPnP, ответившие на SSDP M-SEARCH в локальной сети
+	DiscoveryTCP  = "tcp"  // TCP connect на типовые порты (по умолчанию)
+)
+
+// newDiscoveryProber создаёт prober для способа обнаружения.
+// Переменная подменяется в тестах, чтобы не зависеть от прав и сети.
+var newDiscoveryProber = func(method string, timeout time.Duration, src network.Source) NetworkProber {
+	switch method {
+	case DiscoveryARP:
+		p := network.NewARPProber(timeout)
+		p.Interface = src.Interface
+		return p
+	case DiscoveryICMP:
+		return network.ICMPProber{Timeout: timeout, Source: src}
+	case DiscoveryNDP:
+		p := network.NewNDPProber(timeout)
+		p.Interface = src.Interface
+		return p
+	case DiscoveryMDNS:
+		p := mdns.NewProber(mdns.DefaultWindow)
+		p.Interface = src.Interface
+		return p
+	case DiscoverySSDP:
+		p := upnp.NewProber(upnp.DefaultWindow)
+		p.Interface = src.Interface
+		return p
+	}
+	return nil
+}
+
+// discoveryBatchSize — сколько адресов перебора берётся за раз для проверки доступности
+// (и одного ARP sweep).
+var discoveryBatchSize = 4096
+
+// neighborDiscoverer — prober, находящий IPv6-соседей без перебора адресов (NDPProber).
+type neighborDiscoverer interface {
+	Discover(ctx context.Context) ([]network.Neighbor, error)
+}
+
+// arpSweeper — prober, умеющий опрашивать пачку адресов за один проход (ARPProber).
+type arpSweeper interface {
+	Sweep(ctx context.Context, ips []net.IP) (map[string]net.HardwareAddr, error)
+}
+
+// openSYNScanner открывает SYN-сканер для сети, в которой находится target
+// (на интерфейсе источника src, если он выбран).
+// Переменная подменяется в тестах, чтобы не зависеть от прав и наличия libpcap.
+var openSYNScanner = func(target net.IP, src network.Source, timeout time.Duration) (PortScanner, func(), error) {
+	s, err := network.OpenSYNScannerFrom(target, src, timeout)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s, s.Close, nil
+}
+
+// proxyDialer — открытый прокси запуска (*network.Proxy).
+type proxyDialer interface {
+	network.ContextDialer
+	DialTimeout(network, address string, timeout time.Duration) (net.Conn, error)
+	String() string
+	Close() error
+}
+
+// openProxy подключается к прокси запуска.
+// Переменная подменяется в тестах, чтобы не зависеть от прокси-сервера.
+var openProxy = func(rawURL string, src network.Source, timeout time.Duration) (proxyDialer, error) {
+	p, err := network.OpenProxy(rawURL, src, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
 // NewNetworkScanner создает новый сканер
 func NewNetworkScanner(networkCIDR string, timeout time.Duration, portRange string, threads int, showClosed bool) *NetworkScanner {
 	return NewScanner(
@@ -265,7 +464,7 @@ func NewScanner(
 	resultPresenter ResultPresenter,
 ) *NetworkScanner {
 	ctx, cancel := context.WithCancel(context.Background())
-	return &NetworkScanner{
+	ns := &NetworkScanner{
 		network:          networkCIDR,
 		timeout:          timeout,
 		portRange:        portRange,
@@ -284,7 +483,11 @@ func NewScanner(
 		portScanner:      portScanner,
 		udpPortScanner:   network.UDPPortScanner{Timeout: timeout},
 		resultPresenter:  resultPresenter,
+		adaptiveConfig:   DefaultAdaptiveConfig(),
+		rtt:              newRTTEstimator(),
 	}
+	ns.adaptive = NewAdaptiveScanner(ns, ns.adaptiveConfig)
+	return ns
 }
 
 // SetProgressCallback устанавливает callback для передачи прогресса
@@ -292,11 +495,131 @@ func (ns *NetworkScanner) SetProgressCallback(callback ProgressCallback) {
 	ns.progressCallback = callback
 }
 
+// SetHostCallback устанавливает callback, получающий каждый хост по мере завершения его сканирования.
+func (ns *NetworkScanner) SetHostCallback(callback HostCallback) {
+	ns.hostMu.Lock()
+	defer ns.hostMu.Unlock()
+	ns.hostCallback = callback
+}
+
+// SetTargets задаёт набор целей: CIDR, диапазоны a.b.c.d-e, отдельные IP и имена хостов
+// (разрешаются в момент сканирования). Пересекающиеся цели сканируются один раз.
+// Без SetTargets цели берутся из строки сети конструктора (допускается список через запятую).
+func (ns *NetworkScanner) SetTargets(targets []string) {
+	ns.targets = append([]string(nil), targets...)
+	if strings.TrimSpace(ns.network) == "" {
+		ns.network = strings.Join(ns.targets, ",")
+	}
+}
+
+// SetRandomizeHosts включает псевдослучайный порядок проверки адресов: пробы
+// распределяются по подсетям, а не идут подряд по одной сети.
+func (ns *NetworkScanner) SetRandomizeHosts(enable bool) {
+	ns.randomizeHosts = enable
+}
+
+// SetExclude задаёт цели, которые не сканируются: формат тот же, что и у SetTargets.
+// Исключения применяются до проверки доступности, имена хостов разрешаются в момент сканирования.
+func (ns *NetworkScanner) SetExclude(targets []string) {
+	ns.exclude = append([]string(nil), targets...)
+}
+
+// SetExcludePorts задаёт порты (формат диапазона портов, например "23,135-139"),
+// которые не опрашиваются ни по TCP, ни по UDP.
+func (ns *NetworkScanner) SetExcludePorts(portRange string) {
+	ns.excludePorts = portRange
+}
+
+// SetScanType выбирает способ TCP-сканирования: ScanTypeConnect или ScanTypeSYN.
+// Если SYN-сканирование недоступно (нет прав или libpcap), ScanContext
+// переключается на connect; фактический способ попадает в ScanSummary.ScanType.
+func (ns *NetworkScanner) SetScanType(scanType string) {
+	ns.scanType = strings.ToLower(strings.TrimSpace(scanType))
+}
+
+// SetSource выбирает интерфейс и/или адрес, с которых уходят все пробы: TCP- и
+// UDP-соединения, ICMP, ARP и NDP, SYN-сканирование и чтение баннеров. Пустые
+// значения — выбор ОС. Адрес без интерфейса определяет интерфейс, интерфейс без
+// адреса — свои адреса (network.ResolveSource); проверка выполняется в ScanContext.
+func (ns *NetworkScanner) SetSource(iface, ip string) {
+	ns.sourceInterface = strings.TrimSpace(iface)
+	ns.sourceIP = strings.TrimSpace(ip)
+}
+
+// SetProxy направляет TCP-пробы, чтение баннеров и TLS через прокси rawURL:
+// socks5://[user:password@]host[:port] или ssh://user@bastion[:port]
+// (network.ParseProxyURL); пусто — напрямую. Через прокси доступны только TCP-соединения:
+// обнаружение хостов выполняется TCP connect, SYN-сканирование заменяется connect,
+// UDP, SNMP и определение MAC пропускаются. Прокси открывается в ScanContext.
+func (ns *NetworkScanner) SetProxy(rawURL string) {
+	ns.proxyURL = strings.TrimSpace(rawURL)
+}
+
+// SetDiscovery задаёт способы обнаружения хостов (DiscoveryARP, DiscoveryICMP, DiscoveryNDP,
+// DiscoveryMDNS, DiscoverySSDP, DiscoveryTCP) в порядке применения: хост считается активным
+// по первому сработавшему способу, и этот способ попадает в Result.DiscoveryMethod.
+// Без SetDiscovery используется только TCP.
+func (ns *NetworkScanner) SetDiscovery(methods []string) {
+	ns.discovery = ns.discovery[:0]
+	for _, m := range methods {
+		if m = strings.ToLower(strings.TrimSpace(m)); m != "" {
+			ns.discovery = append(ns.discovery, m)
+		}
+	}
+}
+
+// ValidateDiscovery проверяет способы обнаружения в формате SetDiscovery; ошибка — InvalidInputError.
+func ValidateDiscovery(methods []string) error {
+	for _, m := range methods {
+		switch m = strings.ToLower(strings.TrimSpace(m)); m {
+		case "", DiscoveryARP, DiscoveryICMP, DiscoveryNDP, DiscoveryMDNS, DiscoverySSDP, DiscoveryTCP:
+		default:
+			return unknownDiscoveryError(m)
+		}
+	}
+	return nil
+}
+
+func unknownDiscoveryError(method string) error {
+	return apperrors.NewInvalidInputError("discovery", fmt.Sprintf("неизвестный способ обнаружения %q (ожидается %s, %s, %s, %s, %s или %s)", method, DiscoveryARP, DiscoveryICMP, DiscoveryNDP, DiscoveryMDNS, DiscoverySSDP, DiscoveryTCP))
+}
+
+// SetTiming выбирает профиль скорости (TimingPolite, TimingNormal, TimingAggressive).
+// Профиль задаёт ограничения интенсивности проб; таймаут и число потоков профиля
+// применяют вызывающие (CLI, API, daemon), если они не заданы явно.
+func (ns *NetworkScanner) SetTiming(name string) {
+	ns.timing = strings.ToLower(strings.TrimSpace(name))
+}
+
+// SetRateLimit задаёт ограничения интенсивности проб. Ненулевые поля перекрывают
+// значения профиля SetTiming.
+func (ns *NetworkScanner) SetRateLimit(limit RateLimit) {
+	ns.rateLimit = limit
+}
+
+// SetAdaptiveConfig задаёт границы окна одновременных TCP-проб (AdaptiveScanner).
+// Окно растёт, пока пробы отвечают, и сужается, когда они остаются без ответа.
+func (ns *NetworkScanner) SetAdaptiveConfig(config AdaptiveConfig) {
+	ns.adaptiveConfig = config
+}
+
 // SetScanUDP включает или выключает UDP сканирование
 func (ns *NetworkScanner) SetScanUDP(enable bool) {
 	ns.scanUDP = enable
 }
 
+// SetUDPPorts задаёт UDP-порты для сканирования в формате диапазона портов ("53,123,161-162").
+// Пустая строка — порты по умолчанию (network.DefaultUDPPorts).
+func (ns *NetworkScanner) SetUDPPorts(portRange string) {
+	ns.udpPorts = portRange
+}
+
+// SetUDPPortScanner заменяет сканер UDP-портов (по умолчанию network.UDPPortScanner);
+// он же проверяет SNMP (161/udp). nil — встроенные UDP-пробы.
+func (ns *NetworkScanner) SetUDPPortScanner(ps PortScanner) {
+	ns.udpPortScanner = ps
+}
+
 // SetScanTCPPorts включает или отключает перебор TCP-портов (при false выполняется только обнаружение хостов и сбор MAC/имени).
 func (ns *NetworkScanner) SetScanTCPPorts(enable bool) {
 	ns.scanTCPPorts = enable
@@ -317,9 +640,49 @@ func (ns *NetworkScanner) SetVerbosePortLogs(enable bool) {
 	ns.verbosePortLogs = enable
 }
 
-// Scan запускает сканирование сети
+// Scan запускает сканирование сети.
+//
+// Deprecated: используйте ScanContext — Scan не поддерживает отмену через контекст
+// и не сообщает об ошибках (они только пишутся в лог).
 func (ns *NetworkScanner) Scan() {
+	if _, err := ns.ScanContext(context.Background()); err != nil {
+		logger.LogError(err, "Сканирование")
+	}
+}
+
+// ScanContext запускает сканирование сети и блокируется до его завершения.
+//
+// Отменой управляет caller через ctx; Stop() по-прежнему прерывает текущий запуск.
+// Ошибки типизированы (network-scanner/internal/errors):
+//   - InvalidInputError — некорректная сеть, диапазон портов (в том числе UDP), исключения,
+//     способ сканирования, профиль скорости, лимит частоты проб, способ обнаружения, стадии
+//     обогащения, источник проб или прокси (поля "network", "ports", "udp_ports", "exclude",
+//     "exclude_ports", "scan_type", "timing", "rate_limit", "discovery", "enrich", "source",
+//     "proxy");
+//   - PermissionError — все проверки доступности упёрлись в недостаток прав;
+//   - TimeoutError — истёк дедлайн ctx;
+//   - CancelledError — ctx отменён или вызван Stop().
+//
+// При отмене и таймауте возвращается сводка по уже просканированным хостам.
+// Движок ничего не пишет в stdout: прогресс доступен только через callbacks.
+func (ns *NetworkScanner) ScanContext(ctx context.Context) (ScanSummary, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
 	scanStartTime := time.Now()
+	summary := ScanSummary{Network: ns.network}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ns.ctxMu.Lock()
+	if ns.stopped {
+		ns.ctxMu.Unlock()
+		return summary, apperrors.NewCancelledError("scan " + ns.network)
+	}
+	ns.ctx = runCtx
+	ns.cancel = cancel
+	ns.ctxMu.Unlock()
+
 	atomic.StoreInt64(&ns.tcpCancelBefore, 0)
 	atomic.StoreInt64(&ns.tcpCancelWait, 0)
 	atomic.StoreInt64(&ns.udpCancelHosts, 0)
@@ -332,21 +695,86 @@ func (ns *NetworkScanner) Scan() {
 	atomic.StoreInt64(&ns.lastPingNs, 0)
 	atomic.StoreInt64(&ns.lastPortscanNs, 0)
 	atomic.StoreInt64(&ns.lastTotalNs, 0)
-	fmt.Println("Начинаю сканирование сети...")
+	atomic.StoreInt64(&ns.lastLookupNs, 0)
+	atomic.StoreInt64(&ns.lookupMACsFound, 0)
+	atomic.StoreInt64(&ns.lookupNamesFound, 0)
+	atomic.StoreInt64(&ns.pingPermissionDenied, 0)
+	ns.adaptive = NewAdaptiveScanner(ns, ns.adaptiveConfig)
+	ns.rtt = newRTTEstimator()
 	logger.Log("Начинаю сканирование сети: %s", ns.network)
 	logger.LogDebug("Параметры сканирования: сеть=%s, порты=%s, таймаут=%v, потоков=%d, showClosed=%v",
 		ns.network, ns.portRange, ns.timeout, ns.threads, ns.showClosed)
 
-	// Парсим диапазон сети
+	// Цели (CIDR, диапазоны, IP, имена хостов) перебираются лениво, без раскрытия в список
 	parseStartTime := time.Now()
-	ips, err := network.ParseNetworkRange(ns.network)
+	specs := ns.targets
+	if len(specs) == 0 {
+		specs = network.SplitTargets(ns.network)
+	}
+	ns.ndpProber = nil
+	ns.neighbors = &neighborCache{}
+	var err error
+	if ns.source, err = network.ResolveSource(ns.sourceInterface, ns.sourceIP); err != nil {
+		return summary, apperrors.NewInvalidInputError("source", err.Error())
+	}
+	ns.proxy = nil
+	if ns.proxyURL != "" {
+		proxy, err := openProxy(ns.proxyURL, ns.source, ns.timeout)
+		if err != nil {
+			return summary, apperrors.NewInvalidInputError("proxy", err.Error())
+		}
+		ns.proxy = proxy
+		defer proxy.Close()
+		summary.Proxy = proxy.String()
+	}
+	ns.applySource()
+	if ns.resume != nil && len(ns.resume.Config.ExpandedTargets) > 0 {
+		// Продолжение перебирает тот же набор адресов, что и прерванный запуск
+		specs = ns.resume.Config.ExpandedTargets
+		ns.expandedTargets = specs
+		ns.neighborProber()
+	} else if specs, err = ns.expandNeighborTargets(runCtx, specs); err != nil {
+		if runCtx.Err() != nil {
+			return ns.finishSummary(summary, scanStartTime), ns.scanContextError(runCtx, ctx, scanStartTime)
+		}
+		return summary, err
+	}
+	targets, err := network.NewTargetIterator(runCtx, specs, nil)
 	if err != nil {
+		if runCtx.Err() != nil {
+			return ns.finishSummary(summary, scanStartTime), ns.scanContextError(runCtx, ctx, scanStartTime)
+		}
 		logger.LogError(err, "Парсинг сети")
-		fmt.Printf("Ошибка парсинга сети: %v\n", err)
-		return
+		return summary, apperrors.NewInvalidInputError("network", err.Error())
+	}
+	if unresolved := targets.Unresolved(); len(unresolved) > 0 {
+		logger.Log("Не удалось разрешить имена хостов: %s", strings.Join(unresolved, ", "))
+		if targets.Count() == 0 {
+			return summary, apperrors.NewInvalidInputError("network", "не удалось разрешить имена хостов: "+strings.Join(unresolved, ", "))
+		}
+	}
+	ns.targetNames = targets.Hostnames()
+	ns.zones = targets.Zones()
+	excluded, err := ns.applyExclusions(runCtx, targets)
+	if err != nil {
+		if runCtx.Err() != nil {
+			return ns.finishSummary(summary, scanStartTime), ns.scanContextError(runCtx, ctx, scanStartTime)
+		}
+		logger.LogError(err, "Список исключений")
+		return summary, err
+	}
+	summary.ExcludedHosts = excluded
+	ns.hostOrderSeed = 0
+	if ns.resume != nil {
+		ns.hostOrderSeed = ns.resume.Config.HostOrderSeed
+	} else if ns.randomizeHosts {
+		ns.hostOrderSeed = time.Now().UnixNano()
+	}
+	if ns.hostOrderSeed != 0 {
+		targets.Shuffle(ns.hostOrderSeed)
 	}
 	parseDuration := time.Since(parseStartTime)
-	logger.LogDebug("Парсинг сети завершен: %d IP адресов за %v", len(ips), parseDuration)
+	logger.LogDebug("Парсинг сети завершен: %d IP адресов за %v", targets.Count(), parseDuration)
 
 	// Парсим диапазон портов
 	var ports []int
@@ -355,127 +783,250 @@ func (ns *NetworkScanner) Scan() {
 		ports, err = network.ParsePortRange(ns.portRange)
 		if err != nil {
 			logger.LogError(err, "Парсинг портов")
-			fmt.Printf("Ошибка парсинга портов: %v\n", err)
-			return
+			return summary, apperrors.NewInvalidInputError("ports", err.Error())
 		}
+		ports = ns.filterExcludedPorts(ports)
 		logger.LogDebug("Парсинг портов завершен: %d портов", len(ports))
 	} else {
 		logger.LogDebug("TCP сканирование портов отключено")
 	}
+	ns.udpPortList = nil
+	if ns.scanUDP && ns.proxy != nil {
+		logger.Log("UDP-сканирование через прокси недоступно, UDP-порты не проверяются")
+	} else if ns.scanUDP {
+		udpRange := ns.udpPorts
+		if strings.TrimSpace(udpRange) == "" {
+			udpRange = network.DefaultUDPPorts
+		}
+		udpPorts, err := network.ParsePortRange(udpRange)
+		if err != nil {
+			logger.LogError(err, "Парсинг UDP-портов")
+			return summary, apperrors.NewInvalidInputError("udp_ports", err.Error())
+		}
+		ns.udpPortList = ns.filterExcludedPorts(udpPorts)
+	}
+	if ns.enrich, err = ns.enrichPipeline(); err != nil {
+		return summary, err
+	}
+	summary.TotalHosts = targets.Count()
+	summary.PortsPerHost = len(ports)
+	ns.checkpoint = nil
+	if ns.checkpointPath != "" {
+		ns.checkpoint = newCheckpointState()
+	}
+	// При продолжении сначала проверяются незавершённые адреса контрольной точки,
+	// затем перебор продолжается с сохранённой позиции.
+	var pending []net.IP
+	resumedHosts, checkedBefore := 0, 0
+	if ns.resume != nil {
+		resumedHosts = len(ns.resume.Completed)
+		pending, checkedBefore = ns.applyResume(targets)
+	}
+	nextTarget := func() (net.IP, bool) {
+		if len(pending) > 0 {
+			ip := pending[0]
+			pending = pending[1:]
+			return ip, true
+		}
+		ip, ok := targets.Next()
+		if ok {
+			ns.checkpoint.issue(ip.String(), targets.Position())
+		}
+		return ip, ok
+	}
+	// Параметры проверяются до запуска контрольных точек: неверный запрос не оставляет файла
+	switch ns.scanType {
+	case "", ScanTypeConnect, ScanTypeSYN:
+	default:
+		return summary, apperrors.NewInvalidInputError("scan_type", fmt.Sprintf("неизвестный способ сканирования %q (ожидается %s или %s)", ns.scanType, ScanTypeConnect, ScanTypeSYN))
+	}
+	limit := ns.rateLimit
+	if ns.timing != "" {
+		tmpl, ok := LookupTimingTemplate(ns.timing)
+		if !ok {
+			return summary, apperrors.NewInvalidInputError("timing", fmt.Sprintf("неизвестный профиль скорости %q (ожидается %s)", ns.timing, strings.Join(TimingTemplateNames(), ", ")))
+		}
+		limit = limit.merge(tmpl.Rate)
+	}
+	if limit.ProbesPerSecond < 0 || limit.MaxPerHost < 0 || limit.ProbeDelay < 0 {
+		return summary, apperrors.NewInvalidInputError("rate_limit", "ограничения скорости не могут быть отрицательными")
+	}
+	ns.limiter = newRateLimiter(limit)
+	if !limit.IsZero() {
+		logger.Log("Ограничение скорости: %.0f проб/с, на хост %d одновременно, пауза %v", limit.ProbesPerSecond, limit.MaxPerHost, limit.ProbeDelay)
+	}
+	if err := ns.prepareDiscovery(); err != nil {
+		return summary, err
+	}
+
+	complete := false
+	finishCheckpoint := ns.startCheckpointing()
+	defer func() { finishCheckpoint(complete) }()
+	summary.ScanType = ScanTypeConnect
+	if ns.scanType == ScanTypeSYN {
+		if ns.proxy != nil {
+			logger.Log("SYN-сканирование через прокси недоступно, используется connect-сканирование")
+		} else if first, ok := firstTarget(pending, targets); ok && len(ports) > 0 {
+			restore, err := ns.useSYNScanner(first)
+			if err != nil {
+				logger.Log("SYN-сканирование недоступно (%v), используется connect-сканирование", err)
+			} else {
+				defer restore()
+				summary.ScanType = ScanTypeSYN
+			}
+		}
+	}
 
-	fmt.Printf("Сканирование %d хостов, порты: %d\n", len(ips), len(ports))
-	logger.Log("Сканирование %d хостов, порты: %d, таймаут: %v, потоков: %d", len(ips), len(ports), ns.timeout, ns.threads)
+	total := targets.Count()
+	remaining := total - checkedBefore
+	logger.Log("Сканирование %d хостов, порты: %d, таймаут: %v, потоков: %d", remaining, len(ports), ns.timeout, ns.threads)
 
 	// Создаем пул горутин для сканирования
 	sem := make(chan struct{}, ns.threads)
 
-	// Сначала проверяем доступность хостов (ping)
+	// Сначала проверяем доступность хостов (ping). Адреса берутся из перебора пачками:
+	// ARP sweep (если выбран) опрашивает пачку перед проверкой её адресов.
 	pingStartTime := time.Now()
-	fmt.Println("Проверка доступности хостов...")
-	logger.Log("Начало проверки доступности хостов: %d хостов", len(ips))
+	logger.Log("Начало проверки доступности хостов: %d хостов", remaining)
 	if ns.progressCallback != nil {
-		ns.progressCallback("ping", 0, len(ips), "Проверка доступности хостов...")
+		ns.progressCallback("ping", checkedBefore, total, "Проверка доступности хостов...")
 	}
 	aliveIPs := make([]net.IP, 0)
 	aliveMutex := sync.Mutex{}
-	checkedCount := 0
+	checkedCount := checkedBefore
 	checkedMutex := sync.Mutex{}
+	checkedThisRun := 0
 
 	cancelledDuringPing := false
-	for _, ip := range ips {
-		select {
-		case <-ns.ctx.Done():
-			cancelledDuringPing = true
-			logger.LogDebug("Сканирование отменено во время проверки доступности (остановка запуска новых проверок)")
-		default:
+	batch := make([]net.IP, 0, discoveryBatchSize)
+	for !cancelledDuringPing {
+		batch = batch[:0]
+		for len(batch) < discoveryBatchSize {
+			ip, ok := nextTarget()
+			if !ok {
+				break
+			}
+			batch = append(batch, ip)
 		}
-		if cancelledDuringPing {
+		if len(batch) == 0 {
 			break
 		}
+		checkedThisRun += len(batch)
+		if ns.hasARPSweep() {
+			// Sweep может отключить ARP: дожидаемся проверок предыдущей пачки
+			ns.wg.Wait()
+			ns.sweepARP(runCtx, batch)
+		}
 
-		sem <- struct{}{}
-		ns.wg.Add(1)
-		go func(ip net.IP) {
-			defer func() { <-sem }()
-			defer ns.wg.Done()
+		for _, ip := range batch {
+			select {
+			case <-runCtx.Done():
+				cancelledDuringPing = true
+				logger.LogDebug("Сканирование отменено во время проверки доступности (остановка запуска новых проверок)")
+			default:
+			}
+			if cancelledDuringPing {
+				break
+			}
 
-			hostCheckStart := time.Now()
-			isAlive := ns.isHostAlive(ip.String())
-			hostCheckDuration := time.Since(hostCheckStart)
+			sem <- struct{}{}
+			ns.wg.Add(1)
+			go func(ip net.IP) {
+				defer func() { <-sem }()
+				defer ns.wg.Done()
 
-			if isAlive {
-				logger.LogDebug("Хост %s доступен (проверка заняла %v)", ip.String(), hostCheckDuration)
+				hostCheckStart := time.Now()
+				deniedBefore := atomic.LoadInt64(&ns.pingPermissionDenied)
+				isAlive, method := ns.discoverHost(ns.hostAddr(ip))
+				hostCheckDuration := time.Since(hostCheckStart)
+
+				// Недоступный хост завершён, если проверку не прервали отмена или нехватка прав
+				if !isAlive && runCtx.Err() == nil && atomic.LoadInt64(&ns.pingPermissionDenied) == deniedBefore {
+					ns.checkpoint.markFinished(ip.String())
+				}
+				if isAlive {
+					logger.LogDebug("Хост %s доступен (%s, проверка заняла %v)", ip.String(), method, hostCheckDuration)
+					ns.discoveryMu.Lock()
+					ns.discoveredBy[ns.hostAddr(ip)] = method
+					ns.discoveryMu.Unlock()
+					aliveMutex.Lock()
+					aliveIPs = append(aliveIPs, ip)
+					aliveMutex.Unlock()
+				} else {
+					logger.LogDebug("Хост %s недоступен (проверка заняла %v)", ip.String(), hostCheckDuration)
+				}
+
+				// Обновляем счетчик прогресса
+				checkedMutex.Lock()
+				checkedCount++
+				progress := checkedCount
+				checkedMutex.Unlock()
 				aliveMutex.Lock()
-				aliveIPs = append(aliveIPs, ip)
+				aliveCount := len(aliveIPs)
 				aliveMutex.Unlock()
-			} else {
-				logger.LogDebug("Хост %s недоступен (проверка заняла %v)", ip.String(), hostCheckDuration)
-			}
 
-			// Обновляем счетчик прогресса
-			checkedMutex.Lock()
-			checkedCount++
-			progress := checkedCount
-			checkedMutex.Unlock()
-			aliveMutex.Lock()
-			aliveCount := len(aliveIPs)
-			aliveMutex.Unlock()
-
-			// Обновляем прогресс через callback и консоль
-			if progress%10 == 0 || progress == len(ips) {
-				fmt.Printf("\rПроверено хостов: %d/%d, найдено активных: %d", progress, len(ips), aliveCount)
-				if ns.progressCallback != nil {
-					ns.progressCallback("ping", progress, len(ips), fmt.Sprintf("Проверено хостов: %d/%d, найдено активных: %d", progress, len(ips), aliveCount))
+				// Обновляем прогресс через callback
+				if progress%10 == 0 || progress == total {
+					if ns.progressCallback != nil {
+						ns.progressCallback("ping", progress, total, fmt.Sprintf("Проверено хостов: %d/%d, найдено активных: %d", progress, total, aliveCount))
+					}
 				}
-			}
-		}(ip)
+			}(ip)
+		}
 	}
 	ns.wg.Wait()
+	pingDuration := time.Since(pingStartTime)
+	atomic.StoreInt64(&ns.lastPingNs, pingDuration.Nanoseconds())
+	summary.AliveHosts = len(aliveIPs) + resumedHosts
+	summary.PingDuration = pingDuration
 	if cancelledDuringPing {
 		logger.LogDebug("Сканирование остановлено после завершения активных проверок доступности")
-		return
+		return ns.finishSummary(summary, scanStartTime), ns.scanContextError(runCtx, ctx, scanStartTime)
 	}
-	pingDuration := time.Since(pingStartTime)
-	atomic.StoreInt64(&ns.lastPingNs, pingDuration.Nanoseconds())
-	fmt.Println() // Новая строка после прогресса
 
-	fmt.Printf("Найдено %d активных хостов\n", len(aliveIPs))
-	logger.Log("Найдено активных хостов: %d из %d (проверка заняла %v)", len(aliveIPs), len(ips), pingDuration)
+	logger.Log("Найдено активных хостов: %d из %d (проверка заняла %v)", len(aliveIPs), checkedThisRun, pingDuration)
 	// Логируем список активных хостов
 	aliveIPsList := make([]string, len(aliveIPs))
 	for i, ip := range aliveIPs {
 		aliveIPsList[i] = ip.String()
 	}
 	logger.LogDebug("Список активных хостов (%d): %v", len(aliveIPs), aliveIPsList)
+	if len(aliveIPs) == 0 && checkedThisRun > 0 && atomic.LoadInt64(&ns.pingPermissionDenied) == int64(checkedThisRun) {
+		return ns.finishSummary(summary, scanStartTime), apperrors.NewPermissionError(currentUserName(), ns.network, "probe")
+	}
 	if ns.progressCallback != nil {
-		ns.progressCallback("ping", len(ips), len(ips), fmt.Sprintf("Найдено %d активных хостов", len(aliveIPs)))
+		ns.progressCallback("ping", total, total, fmt.Sprintf("Найдено %d активных хостов", len(aliveIPs)))
 	}
 
 	// Сканируем порты на активных хостах
 	portsScanDuration := time.Duration(0)
+	cancelledDuringPorts := false
 	if len(aliveIPs) > 0 {
 		portsScanStartTime := time.Now()
+		portsMessage := "Сканирование портов..."
 		if len(ports) > 0 {
-			fmt.Println("Сканирование портов...")
 			logger.Log("Начало сканирования портов на %d хостах, портов на хост: %d", len(aliveIPs), len(ports))
 		} else {
-			fmt.Println("Сбор данных о хостах (TCP-порты не сканируются)...")
+			portsMessage = "Сбор данных о хостах (TCP-порты не сканируются)..."
 			logger.Log("Сбор данных о хостах на %d адресах без перебора TCP-портов", len(aliveIPs))
 		}
 		logger.LogDebug("Всего портов для сканирования: %d хостов × %d портов = %d проверок", len(aliveIPs), len(ports), len(aliveIPs)*len(ports))
 		if ns.progressCallback != nil {
-			ns.progressCallback("ports", 0, len(aliveIPs), "Сканирование портов...")
+			ns.progressCallback("ports", 0, len(aliveIPs), portsMessage)
 		}
 		scannedCount := 0
 		scannedMutex := sync.Mutex{}
 
 		for _, ip := range aliveIPs {
 			select {
-			case <-ns.ctx.Done():
+			case <-runCtx.Done():
+				cancelledDuringPorts = true
 				logger.LogDebug("Сканирование отменено во время сканирования портов")
-				return
 			default:
 			}
+			if cancelledDuringPorts {
+				break
+			}
 
 			sem <- struct{}{}
 			ns.wg.Add(1)
@@ -494,13 +1045,12 @@ func (ns *NetworkScanner) Scan() {
 				progress := scannedCount
 				scannedMutex.Unlock()
 
-				// Обновляем прогресс через callback и консоль (ограничиваем частоту для избежания блокировки UI)
+				// Обновляем прогресс через callback (ограничиваем частоту для избежания блокировки UI)
 				if progress%5 == 0 || progress == len(aliveIPs) {
-					fmt.Printf("\rСканирование портов: %d/%d хостов", progress, len(aliveIPs))
 					if ns.progressCallback != nil {
 						// Вызываем callback в неблокирующем режиме
 						select {
-						case <-ns.ctx.Done():
+						case <-runCtx.Done():
 							return
 						default:
 							ns.progressCallback("ports", progress, len(aliveIPs), fmt.Sprintf("Сканирование портов: %d/%d хостов", progress, len(aliveIPs)))
@@ -512,7 +1062,6 @@ func (ns *NetworkScanner) Scan() {
 		ns.wg.Wait()
 		portsScanDuration = time.Since(portsScanStartTime)
 		atomic.StoreInt64(&ns.lastPortscanNs, portsScanDuration.Nanoseconds())
-		fmt.Println() // Новая строка после прогресса
 		tcpCancelBefore := atomic.LoadInt64(&ns.tcpCancelBefore)
 		tcpCancelWait := atomic.LoadInt64(&ns.tcpCancelWait)
 		udpCancelHosts := atomic.LoadInt64(&ns.udpCancelHosts)
@@ -528,13 +1077,19 @@ func (ns *NetworkScanner) Scan() {
 	} else {
 		logger.Log("Активные хосты не найдены, пропускаем сканирование портов")
 	}
+	if !cancelledDuringPorts && runCtx.Err() == nil {
+		ns.completeLookups(runCtx)
+	}
+	summary.PortScanDuration = portsScanDuration
+	summary = ns.finishSummary(summary, scanStartTime)
+	if cancelledDuringPorts || runCtx.Err() != nil {
+		return summary, ns.scanContextError(runCtx, ctx, scanStartTime)
+	}
 
-	totalDuration := time.Since(scanStartTime)
-	atomic.StoreInt64(&ns.lastTotalNs, totalDuration.Nanoseconds())
-	fmt.Println("Сканирование завершено")
-	logger.Log("Сканирование завершено. Найдено устройств: %d (общее время: %v)", len(ns.results), totalDuration)
+	totalDuration := summary.TotalDuration
+	logger.Log("Сканирование завершено. Найдено устройств: %d (общее время: %v)", len(summary.Results), totalDuration)
 	logger.LogDebug("Статистика сканирования: хостов проверено=%d, активных хостов=%d, устройств найдено=%d",
-		len(ips), len(aliveIPs), len(ns.results))
+		checkedThisRun, len(aliveIPs), len(summary.Results))
 	logger.Log(
 		"Диагностическая сводка: ping=%v, portscan=%v, total=%v; TCP probes total/open/closed=%d/%d/%d; UDP probes total/open/no-open=%d/%d/%d",
 		pingDuration,
@@ -548,8 +1103,323 @@ func (ns *NetworkScanner) Scan() {
 		atomic.LoadInt64(&ns.udpProbeNoOpen),
 	)
 	if ns.progressCallback != nil {
-		ns.progressCallback("complete", len(ns.results), len(ns.results), fmt.Sprintf("Сканирование завершено. Найдено устройств: %d", len(ns.results)))
+		ns.progressCallback("complete", len(summary.Results), len(summary.Results), fmt.Sprintf("Сканирование завершено. Найдено устройств: %d", len(summary.Results)))
+	}
+	complete = true
+	return summary, nil
+}
+
+// applyExclusions разбирает списки исключений (ns.exclude, ns.excludePorts) и передаёт
+// исключаемые адреса перебору целей. Возвращает число отброшенных адресов.
+// Исключённое имя хоста отбрасывает все его адреса, а также цель, указанную под этим именем.
+func (ns *NetworkScanner) applyExclusions(ctx context.Context, targets *network.TargetIterator) (int, error) {
+	ns.excludedPortSet = nil
+	if strings.TrimSpace(ns.excludePorts) != "" {
+		ports, err := network.ParsePortRange(ns.excludePorts)
+		if err != nil {
+			return 0, apperrors.NewInvalidInputError("exclude_ports", err.Error())
+		}
+		ns.excludedPortSet = make(map[int]struct{}, len(ports))
+		for _, p := range ports {
+			ns.excludedPortSet[p] = struct{}{}
+		}
+	}
+
+	matcher, err := network.NewTargetMatcher(ns.exclude)
+	if err != nil {
+		return 0, apperrors.NewInvalidInputError("exclude", err.Error())
 	}
+	if matcher.Empty() {
+		return 0, nil
+	}
+	unresolved, err := matcher.ResolveNames(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	if len(unresolved) > 0 {
+		logger.Log("Не удалось разрешить исключаемые имена хостов: %s", strings.Join(unresolved, ", "))
+	}
+
+	targets.Exclude(matcher)
+	excluded := targets.Excluded()
+	if excluded > 0 {
+		logger.Log("Исключено из сканирования адресов: %d", excluded)
+	}
+	return excluded, nil
+}
+
+// prepareDiscovery проверяет выбранные способы обнаружения и создаёт для них prober-ы.
+func (ns *NetworkScanner) prepareDiscovery() error {
+	ns.discoveryMu.Lock()
+	ns.discoveredBy = make(map[string]string)
+	ns.discoveredMACs = make(map[string]net.HardwareAddr)
+	ns.discoveryMu.Unlock()
+	ns.discoveryProbers = make(map[string]NetworkProber)
+	ns.discoveryMethods = ns.discovery
+	ns.mdnsProber = nil
+	ns.ssdpProber = nil
+
+	for _, method := range ns.discovery {
+		switch method {
+		case DiscoveryTCP:
+		case DiscoveryNDP:
+			ns.discoveryProbers[method] = ns.neighborProber()
+		case DiscoveryARP, DiscoveryICMP, DiscoveryMDNS, DiscoverySSDP:
+			if _, ok := ns.discoveryProbers[method]; !ok {
+				ns.discoveryProbers[method] = newDiscoveryProber(method, ns.timeout, ns.source)
+			}
+		default:
+			return unknownDiscoveryError(method)
+		}
+	}
+	if ns.proxy != nil {
+		// Через прокси доступны только TCP-соединения: доступность проверяется TCP connect
+		for _, method := range ns.discovery {
+			if method != DiscoveryTCP {
+				logger.Log("Обнаружение %s через прокси недоступно, используется TCP connect", method)
+			}
+		}
+		ns.discoveryProbers = make(map[string]NetworkProber)
+		ns.discoveryMethods = nil
+		return nil
+	}
+	// Результаты просмотра mDNS и поиска SSDP нужны и стадиям mdns и upnp, которые работают
+	// параллельно обнаружению
+	ns.mdnsProber, _ = ns.discoveryProbers[DiscoveryMDNS].(mdnsBrowser)
+	ns.ssdpProber, _ = ns.discoveryProbers[DiscoverySSDP].(ssdpSearcher)
+	// Адреса IPv6-сетей найдены обнаружением соседей — им же они и проверяются в первую очередь
+	if _, ok := ns.discoveryProbers[DiscoveryNDP]; !ok && ns.ndpProber != nil {
+		ns.discoveryProbers[DiscoveryNDP] = ns.ndpProber
+		methods := []string{DiscoveryNDP}
+		if len(ns.discovery) == 0 {
+			methods = append(methods, DiscoveryTCP)
+		}
+		ns.discoveryMethods = append(methods, ns.discovery...)
+	}
+
+	if arp, ok := ns.discoveryProbers[DiscoveryARP].(*network.ARPProber); ok && ns.limiter != nil {
+		arp.Throttle = func(ctx context.Context) error {
+			release, err := ns.limiter.acquire(ctx, "", 1)
+			if err == nil {
+				release()
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// hasARPSweep сообщает, что в запуске используется ARP sweep.
+func (ns *NetworkScanner) hasARPSweep() bool {
+	_, ok := ns.discoveryProbers[DiscoveryARP].(arpSweeper)
+	return ok
+}
+
+// sweepARP опрашивает пачку адресов одним ARP sweep до их проверки; при ошибке sweep
+// (нет прав или libpcap) ARP пропускается до конца запуска.
+func (ns *NetworkScanner) sweepARP(ctx context.Context, ips []net.IP) {
+	sweeper, ok := ns.discoveryProbers[DiscoveryARP].(arpSweeper)
+	if !ok || len(ips) == 0 {
+		return
+	}
+	found, err := sweeper.Sweep(ctx, ips)
+	if err != nil {
+		logger.Log("ARP-обнаружение недоступно (%v), способ пропускается", err)
+		delete(ns.discoveryProbers, DiscoveryARP)
+		return
+	}
+	logger.LogDebug("ARP sweep: ответили %d из %d адресов", len(found), len(ips))
+	ns.discoveryMu.Lock()
+	for ip, mac := range found {
+		ns.discoveredMACs[ip] = mac
+	}
+	ns.discoveryMu.Unlock()
+}
+
+// firstTarget возвращает первый адрес запуска, не сдвигая перебор.
+func firstTarget(pending []net.IP, targets *network.TargetIterator) (net.IP, bool) {
+	if len(pending) > 0 {
+		return pending[0], true
+	}
+	pos := targets.Position()
+	ip, ok := targets.Next()
+	targets.Seek(pos)
+	return ip, ok
+}
+
+// discoverHost проверяет доступность хоста выбранными способами по порядку
+// и возвращает способ, которым хост обнаружен.
+func (ns *NetworkScanner) discoverHost(ip string) (bool, string) {
+	if len(ns.discoveryMethods) == 0 {
+		return ns.isHostAlive(ip), DiscoveryTCP
+	}
+	for _, method := range ns.discoveryMethods {
+		if method == DiscoveryTCP {
+			if ns.isHostAlive(ip) {
+				return true, DiscoveryTCP
+			}
+			continue
+		}
+		prober, ok := ns.discoveryProbers[method]
+		if !ok {
+			continue
+		}
+		// ARP-пробы ограничиваются при sweep (Throttle), ответы ARP, NDP, mDNS и SSDP берутся из кэша
+		release := func() {}
+		if !cachedDiscovery(method) {
+			if release, ok = ns.acquireProbe(ip, 1); !ok {
+				return false, ""
+			}
+		}
+		var (
+			alive bool
+			err   error
+		)
+		start := time.Now()
+		if cp, ok := prober.(ContextNetworkProber); ok {
+			alive, err = cp.PingContext(ip, ns.ctx.Done())
+		} else {
+			alive, err = prober.Ping(ip)
+		}
+		rtt := time.Since(start)
+		release()
+		if err != nil {
+			logger.LogDebug("Обнаружение %s для %s не удалось: %v", method, ip, err)
+			continue
+		}
+		if alive {
+			// Ответы ARP, NDP, mDNS и SSDP берутся из кэша и не отражают RTT
+			if !cachedDiscovery(method) {
+				ns.rtt.observe(ip, rtt)
+			}
+			return true, method
+		}
+	}
+	return false, ""
+}
+
+// cachedDiscovery сообщает, что способ отвечает из результатов общего опроса
+// (ARP sweep, обнаружение соседей, просмотр mDNS, поиск SSDP), а не пробой к отдельному хосту.
+func cachedDiscovery(method string) bool {
+	return method == DiscoveryARP || method == DiscoveryNDP || method == DiscoveryMDNS || method == DiscoverySSDP
+}
+
+// acquireProbe ждёт разрешения ограничителя скорости на n проб к host.
+// ok=false — запуск отменён, пробу выполнять не нужно.
+func (ns *NetworkScanner) acquireProbe(host string, n int) (release func(), ok bool) {
+	release, err := ns.limiter.acquire(ns.ctx, host, n)
+	if err != nil {
+		return func() {}, false
+	}
+	return release, true
+}
+
+// applySource передаёт источник проб и прокси текущего запуска встроенным сканерам
+// портов и prober-у; внедрённые реализации отвечают за источник и прокси сами.
+func (ns *NetworkScanner) applySource() {
+	var dialer network.ContextDialer
+	if ns.proxy != nil {
+		dialer = ns.proxy
+	}
+	if ps, ok := ns.portScanner.(network.TCPPortScanner); ok {
+		ps.Source = ns.source
+		ps.Dialer = dialer
+		ns.portScanner = ps
+	}
+	if us, ok := ns.udpPortScanner.(network.UDPPortScanner); ok {
+		us.Source = ns.source
+		ns.udpPortScanner = us
+	}
+	if p, ok := ns.networkProber.(network.DefaultNetworkProber); ok {
+		p.Source = ns.source
+		p.Dialer = dialer
+		ns.networkProber = p
+	}
+	if !ns.source.IsZero() {
+		logger.Log("Источник проб: %s", ns.source)
+	}
+	if ns.proxy != nil {
+		logger.Log("TCP-пробы идут через прокси %s", ns.proxy)
+	}
+}
+
+// tcpDialer возвращает dialer TCP-проб к host: прокси запуска или источник проб.
+func (ns *NetworkScanner) tcpDialer(host string, timeout time.Duration) network.ContextDialer {
+	if ns.proxy != nil {
+		return ns.proxy
+	}
+	return ns.source.Dialer("tcp", host, timeout)
+}
+
+// dialTimeout открывает соединение через внедрённый ConnDialer, прокси запуска или от
+// источника проб (banner.DialFunc).
+func (ns *NetworkScanner) dialTimeout(proto, address string, timeout time.Duration) (net.Conn, error) {
+	if d, ok := ns.portScanner.(ConnDialer); ok {
+		return d.DialTimeout(proto, address, timeout)
+	}
+	if ns.proxy != nil {
+		return ns.proxy.DialTimeout(proto, address, timeout)
+	}
+	return ns.source.DialTimeout(proto, address, timeout)
+}
+
+// useSYNScanner подменяет TCP PortScanner на SYN-сканер на время текущего запуска.
+// Возвращённая функция закрывает SYN-сканер и восстанавливает прежний PortScanner.
+func (ns *NetworkScanner) useSYNScanner(target net.IP) (func(), error) {
+	syn, closeSYN, err := openSYNScanner(target, ns.source, ns.timeout)
+	if err != nil {
+		return nil, err
+	}
+	prev := ns.portScanner
+	ns.portScanner = syn
+	logger.Log("Используется SYN-сканирование портов")
+	return func() {
+		ns.portScanner = prev
+		closeSYN()
+	}, nil
+}
+
+// filterExcludedPorts убирает из ports порты, указанные в списке исключений.
+func (ns *NetworkScanner) filterExcludedPorts(ports []int) []int {
+	if len(ns.excludedPortSet) == 0 {
+		return ports
+	}
+	kept := make([]int, 0, len(ports))
+	for _, p := range ports {
+		if _, ok := ns.excludedPortSet[p]; !ok {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+// finishSummary дополняет сводку результатами и общим временем запуска.
+func (ns *NetworkScanner) finishSummary(summary ScanSummary, scanStartTime time.Time) ScanSummary {
+	totalDuration := time.Since(scanStartTime)
+	atomic.StoreInt64(&ns.lastTotalNs, totalDuration.Nanoseconds())
+	summary.TotalDuration = totalDuration
+	summary.Results = ns.correlateResults(ns.GetResults())
+	return summary
+}
+
+// scanContextError переводит причину остановки запуска в типизированную ошибку:
+// дедлайн caller-контекста — TimeoutError, иначе (отмена ctx или Stop) — CancelledError.
+func (ns *NetworkScanner) scanContextError(runCtx, callerCtx context.Context, scanStartTime time.Time) error {
+	if errors.Is(callerCtx.Err(), context.DeadlineExceeded) {
+		return apperrors.NewTimeoutError("scan "+ns.network, time.Since(scanStartTime))
+	}
+	if runCtx.Err() == nil {
+		return nil
+	}
+	return apperrors.NewCancelledError("scan " + ns.network)
+}
+
+// currentUserName возвращает имя пользователя процесса для PermissionError.
+func currentUserName() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return strconv.Itoa(os.Getuid())
 }
 
 // GetDiagnosticsSummary returns condensed diagnostics for the last scan run.
@@ -571,24 +1441,31 @@ func (ns *NetworkScanner) GetDiagnosticsSummary() string {
 		atomic.LoadInt64(&ns.tcpCancelBefore),
 		atomic.LoadInt64(&ns.tcpCancelWait),
 		atomic.LoadInt64(&ns.udpCancelHosts),
+	) + fmt.Sprintf(" | budget=%s | RTT p50/p90/p99=%s | enrich runs/avg/max/timeouts/errors: %s | lookups MAC/names=%d/%d (%v)",
+		formatBudgetTrajectory(ns.adaptive.GetBudgetTrajectory()),
+		ns.rttPercentilesSummary(),
+		ns.enrich.summary(),
+		atomic.LoadInt64(&ns.lookupMACsFound),
+		atomic.LoadInt64(&ns.lookupNamesFound),
+		time.Duration(atomic.LoadInt64(&ns.lastLookupNs)),
 	)
 }
 
+// rttPercentilesSummary форматирует перцентили RTT последнего запуска ("n/a" без замеров).
+func (ns *NetworkScanner) rttPercentilesSummary() string {
+	p := ns.rtt.percentiles(0.5, 0.9, 0.99)
+	if p == nil {
+		return "n/a"
+	}
+	return fmt.Sprintf("%v/%v/%v", p[0].Round(time.Microsecond), p[1].Round(time.Microsecond), p[2].Round(time.Microsecond))
+}
+
 // isHostAlive проверяет, доступен ли хост
 func (ns *NetworkScanner) isHostAlive(ip string) bool {
 	if ns.networkProber != nil {
-		if contextAwareProber, ok := ns.networkProber.(ContextNetworkProber); ok {
-			isAlive, err := contextAwareProber.PingContext(ip, ns.ctx.Done())
-			if err == nil {
-				return isAlive
-			}
-			logger.LogDebug("Падение до встроенного пинга для %s из-за ошибки context prober: %v", ip, err)
-		}
-		isAlive, err := ns.networkProber.Ping(ip)
-		if err == nil {
+		if isAlive, handled := ns.pingWithNetworkProber(ip); handled {
 			return isAlive
 		}
-		logger.LogDebug("Падение до встроенного пинга для %s из-за ошибки prober: %v", ip, err)
 	}
 
 	// Быстрая проверка живости: запускаем probe по нескольким портам параллельно
@@ -612,6 +1489,8 @@ func (ns *NetworkScanner) isHostAlive(ip string) bool {
 	ctx, cancel := context.WithCancel(ns.ctx)
 	defer cancel()
 	results := make(chan bool, len(commonPorts))
+	// deniedPorts считает dial-ошибки из-за нехватки прав (EPERM/EACCES, например sandbox или политика).
+	var deniedPorts int32
 
 	for _, port := range commonPorts {
 		go func(port string) {
@@ -621,16 +1500,24 @@ func (ns *NetworkScanner) isHostAlive(ip string) bool {
 				return
 			default:
 			}
+			release, err := ns.limiter.acquire(ctx, ip, 1)
+			if err != nil {
+				results <- false
+				return
+			}
+			defer release()
 
 			portCheckStart := time.Now()
-			dialer := &net.Dialer{Timeout: probeTimeout}
-			conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(ip, port))
+			dialCtx, cancelDial := context.WithTimeout(ctx, probeTimeout)
+			defer cancelDial()
+			conn, err := ns.tcpDialer(ip, probeTimeout).DialContext(dialCtx, "tcp", net.JoinHostPort(ip, port))
 			portCheckDuration := time.Since(portCheckStart)
 
 			if err == nil {
 				if conn != nil {
 					conn.Close()
 				}
+				ns.rtt.observe(ip, portCheckDuration)
 				if ns.verbosePortLogs {
 					logger.LogDebug("Хост %s доступен через порт %s (проверка заняла %v)", ip, port, portCheckDuration)
 				}
@@ -638,6 +1525,9 @@ func (ns *NetworkScanner) isHostAlive(ip string) bool {
 				cancel()
 				return
 			}
+			if errors.Is(err, os.ErrPermission) {
+				atomic.AddInt32(&deniedPorts, 1)
+			}
 			if ns.verbosePortLogs {
 				logger.LogDebug("Хост %s не отвечает на порт %s: %v (проверка заняла %v)", ip, port, err, portCheckDuration)
 			}
@@ -657,20 +1547,95 @@ func (ns *NetworkScanner) isHostAlive(ip string) bool {
 		}
 	}
 
+	if int(atomic.LoadInt32(&deniedPorts)) == len(commonPorts) {
+		atomic.AddInt64(&ns.pingPermissionDenied, 1)
+		logger.LogDebug("Хост %s: проверка доступности запрещена (недостаточно прав)", ip)
+		return false
+	}
 	logger.LogDebug("Хост %s недоступен (ни один из проверенных портов не ответил)", ip)
 	return false
 }
 
+// pingWithNetworkProber проверяет хост внедрённым NetworkProber (один probe-набор
+// commonHostPorts под ограничителем скорости). handled=false — prober вернул ошибку
+// или запуск отменён, нужна встроенная проверка.
+func (ns *NetworkScanner) pingWithNetworkProber(ip string) (alive bool, handled bool) {
+	release, ok := ns.acquireProbe(ip, commonHostPorts)
+	if !ok {
+		return false, true
+	}
+	defer release()
+	// Prober завершается по первому ответу, поэтому время проверки живого хоста — оценка RTT
+	start := time.Now()
+	if contextAwareProber, ok := ns.networkProber.(ContextNetworkProber); ok {
+		isAlive, err := contextAwareProber.PingContext(ip, ns.ctx.Done())
+		if err == nil {
+			if isAlive {
+				ns.rtt.observe(ip, time.Since(start))
+			}
+			return isAlive, true
+		}
+		logger.LogDebug("Падение до встроенного пинга для %s из-за ошибки context prober: %v", ip, err)
+	}
+	start = time.Now()
+	isAlive, err := ns.networkProber.Ping(ip)
+	if err == nil {
+		if isAlive {
+			ns.rtt.observe(ip, time.Since(start))
+		}
+		return isAlive, true
+	}
+	logger.LogDebug("Падение до встроенного пинга для %s из-за ошибки prober: %v", ip, err)
+	return false, false
+}
+
 // scanTCPPort checks a single TCP port using injected scanner when available.
 func (ns *NetworkScanner) scanTCPPort(ip string, port int) bool {
+	return ns.scanTCPPortTimeout(ip, port, ns.timeout)
+}
+
+// scanTCPPortTimeout checks a single TCP port with the given probe timeout.
+func (ns *NetworkScanner) scanTCPPortTimeout(ip string, port int, timeout time.Duration) bool {
+	return ns.probeTCPPort(ip, port, timeout).State == network.PortOpen
+}
+
+// probeTCPPort классифицирует TCP-порт внедрённым сканером (TCPProber или PortScanner)
+// либо network.ProbeTCP. PortScanner без TCPProber не различает closed и filtered и не
+// сообщает причину: неоткрытые порты считаются closed. PortScanner без TimeoutPortScanner
+// использует собственный таймаут.
+func (ns *NetworkScanner) probeTCPPort(ip string, port int, timeout time.Duration) network.TCPProbeResult {
 	if ns.portScanner != nil {
-		isOpen, err := ns.portScanner.ScanPort(ip, port, "tcp")
-		if err == nil {
-			return isOpen
+		if prober, ok := ns.portScanner.(TCPProber); ok {
+			res, err := prober.ProbeTCP(ip, port, timeout)
+			if err == nil {
+				return res
+			}
+			logger.LogDebug("TCP prober вернул ошибку для %s:%d, fallback на ProbeTCP: %v", ip, port, err)
+		} else {
+			var (
+				isOpen bool
+				err    error
+			)
+			start := time.Now()
+			if ts, ok := ns.portScanner.(TimeoutPortScanner); ok {
+				isOpen, err = ts.ScanPortTimeout(ip, port, "tcp", timeout)
+			} else {
+				isOpen, err = ns.portScanner.ScanPort(ip, port, "tcp")
+			}
+			if err == nil {
+				if isOpen {
+					return network.TCPProbeResult{State: network.PortOpen, Latency: time.Since(start)}
+				}
+				return network.TCPProbeResult{State: network.PortClosed}
+			}
+			logger.LogDebug("PortScanner вернул ошибку для %s:%d, fallback на ProbeTCP: %v", ip, port, err)
 		}
-		logger.LogDebug("PortScanner вернул ошибку для %s:%d, fallback на IsPortOpen: %v", ip, port, err)
 	}
-	return network.IsPortOpen(ip, port, ns.timeout)
+	res, err := network.ProbeTCPVia(ns.tcpDialer(ip, timeout), ip, port, timeout)
+	if err != nil {
+		logger.LogDebug("TCP проба %s:%d: %v", ip, port, err)
+	}
+	return res
 }
 
 // scanUDPPort checks a single UDP port using injected UDP scanner when available.
@@ -679,17 +1644,40 @@ func (ns *NetworkScanner) scanUDPPort(ip string, port int) bool {
 }
 
 func (ns *NetworkScanner) scanUDPPortWithTimeout(ip string, port int, timeout time.Duration) bool {
+	return ns.probeUDPPort(ip, port, timeout).State == network.PortOpen
+}
+
+// probeUDPPort классифицирует UDP-порт внедрённым сканером (UDPProber или PortScanner)
+// либо network.ProbeUDP. PortScanner без UDPProber не различает closed и open|filtered,
+// поэтому неответившие порты считаются open|filtered.
+func (ns *NetworkScanner) probeUDPPort(ip string, port int, timeout time.Duration) network.UDPProbeResult {
 	if ns.udpPortScanner != nil && timeout == ns.timeout {
-		isOpen, err := ns.udpPortScanner.ScanPort(ip, port, "udp")
-		if err == nil {
-			return isOpen
+		if prober, ok := ns.udpPortScanner.(UDPProber); ok {
+			res, err := prober.ProbeUDP(ip, port)
+			if err == nil {
+				return res
+			}
+			logger.LogDebug("UDP prober вернул ошибку для %s:%d, fallback на ProbeUDP: %v", ip, port, err)
+		} else {
+			isOpen, err := ns.udpPortScanner.ScanPort(ip, port, "udp")
+			if err == nil {
+				if isOpen {
+					return network.UDPProbeResult{State: network.PortOpen}
+				}
+				return network.UDPProbeResult{State: network.PortOpenFiltered}
+			}
+			logger.LogDebug("UDP PortScanner вернул ошибку для %s:%d, fallback на ProbeUDP: %v", ip, port, err)
 		}
-		logger.LogDebug("UDP PortScanner вернул ошибку для %s:%d, fallback на IsUDPPortOpen: %v", ip, port, err)
 	}
 	if timeout <= 0 {
 		timeout = ns.timeout
 	}
-	return network.IsUDPPortOpen(ip, port, timeout)
+	res, err := network.ProbeUDPFrom(ns.source, ip, port, timeout)
+	if err != nil {
+		logger.LogDebug("UDP проба %s:%d не отправлена: %v", ip, port, err)
+		return network.UDPProbeResult{State: network.PortFiltered}
+	}
+	return res
 }
 
 // checkARP проверяет наличие хоста через ARP (не используется в быстром сканировании)
@@ -701,7 +1689,7 @@ func (ns *NetworkScanner) checkARP(ip string) bool {
 
 // scanHost сканирует один хост
 func (ns *NetworkScanner) scanHost(ip net.IP, ports []int) {
-	ipStr := ip.String()
+	ipStr := ns.hostAddr(ip)
 	logger.LogDebug("Сканирование хоста: %s, портов: %d", ipStr, len(ports))
 	result := Result{
 		IP:        ipStr,
@@ -711,51 +1699,21 @@ func (ns *NetworkScanner) scanHost(ip net.IP, ports []int) {
 		Protocols: make([]string, 0),
 		IsAlive:   true,
 	}
-
-	// Получаем MAC адрес и hostname асинхронно, не блокируя сканирование портов
-	// Запускаем в фоне и собираем результаты после сканирования портов
-	macChan := make(chan string, 1)
-	macErrChan := make(chan error, 1)
-	hostnameChan := make(chan []string, 1)
-	hostnameErrChan := make(chan error, 1)
-
-	// Запускаем получение MAC в фоне
-	go func() {
-		macStartTime := time.Now()
-		logger.LogDebug("Начало получения MAC адреса для хоста %s", ipStr)
-		mac, err := ns.getMACAddress(ip)
-		macDuration := time.Since(macStartTime)
-		if err != nil {
-			logger.LogDebug("Не удалось получить MAC адрес для %s: %v (заняло %v)", ipStr, err, macDuration)
-			macErrChan <- err
-			return
-		}
-		logger.LogDebug("MAC адрес для %s получен: %s (заняло %v)", ipStr, mac, macDuration)
-		macChan <- mac
-	}()
-
-	// Запускаем получение hostname в фоне
-	go func() {
-		hostnameStartTime := time.Now()
-		logger.LogDebug("Начало получения hostname для хоста %s", ipStr)
-		hostname, err := net.LookupAddr(ipStr)
-		hostnameDuration := time.Since(hostnameStartTime)
-		if err != nil {
-			logger.LogDebug("Не удалось получить hostname для %s: %v (заняло %v)", ipStr, err, hostnameDuration)
-			hostnameErrChan <- err
-			return
-		}
-		if len(hostname) > 0 {
-			logger.LogDebug("Hostname для %s получен: %v (заняло %v)", ipStr, hostname, hostnameDuration)
-		}
-		hostnameChan <- hostname
-	}()
+	if ns.proxy != nil {
+		result.Via = ns.proxy.String()
+	}
+	ns.discoveryMu.Lock()
+	result.DiscoveryMethod = ns.discoveredBy[ipStr]
+	ns.discoveryMu.Unlock()
 
 	// Сканируем порты параллельно, но с динамическим ограничением.
 	// Ранее здесь был фиксированный лимит 100 на хост, что при большом количестве
 	// параллельных хостов раздувало общее число соединений и вызывало просадки.
 	portThreads := ns.portThreadsForHost(len(ports))
 	portSem := make(chan struct{}, portThreads)
+	if len(ports) > 0 {
+		logger.LogDebug("Хост %s: таймаут пробы %v (по RTT, максимум %v)", ipStr, ns.rtt.timeout(ipStr, ns.timeout), ns.timeout)
+	}
 	portResults := make(chan PortInfo, len(ports))
 	portWg := sync.WaitGroup{}
 
@@ -784,11 +1742,30 @@ func (ns *NetworkScanner) scanHost(ip net.IP, ports []int) {
 			default:
 			}
 
+			release, ok := ns.acquireProbe(ipStr, 1)
+			if !ok {
+				return
+			}
+			// Общее окно одновременных проб запуска (AdaptiveScanner)
+			if err := ns.adaptive.AcquireProbe(ns.ctx); err != nil {
+				release()
+				return
+			}
+			probeTimeout := ns.rtt.timeout(ipStr, ns.timeout)
 			portCheckStart := time.Now()
 			atomic.AddInt64(&ns.tcpProbeTotal, 1)
-			isOpen := ns.scanTCPPort(ipStr, p)
+			probe := ns.probeTCPPort(ipStr, p, probeTimeout)
+			isOpen := probe.State == network.PortOpen
 			portCheckDuration := time.Since(portCheckStart)
+			ns.adaptive.ReleaseProbe()
+			release()
+			// Проба без ответа до таймаута — признак перегрузки: окно сужается
+			timedOut := probe.Reason == network.ReasonNoResponse ||
+				(probe.Reason == "" && !isOpen && portCheckDuration >= probeTimeout*9/10)
+			ns.adaptive.RecordProbe(isOpen, timedOut)
+			ns.adaptive.Adapt()
 			if isOpen {
+				ns.rtt.observe(ipStr, portCheckDuration)
 				atomic.AddInt64(&ns.tcpProbeOpen, 1)
 			} else {
 				atomic.AddInt64(&ns.tcpProbeClosed, 1)
@@ -798,21 +1775,18 @@ func (ns *NetworkScanner) scanHost(ip net.IP, ports []int) {
 				if isOpen {
 					logger.LogDebug("Хост %s: порт %d/%s открыт (проверка заняла %v)", ipStr, p, "tcp", portCheckDuration)
 				} else if ns.showClosed {
-					logger.LogDebug("Хост %s: порт %d/%s закрыт (проверка заняла %v)", ipStr, p, "tcp", portCheckDuration)
+					logger.LogDebug("Хост %s: порт %d/%s %s, %s (проверка заняла %v)", ipStr, p, "tcp", probe.State, probe.Reason, portCheckDuration)
 				}
 			}
 
 			if isOpen || ns.showClosed {
-				state := "open"
-				if !isOpen {
-					state = "closed"
-				}
-
 				portInfo := PortInfo{
 					Port:     p,
-					State:    state,
+					State:    probe.State.String(),
 					Protocol: "tcp",
 					Service:  network.GetServiceName(p),
+					Reason:   probe.Reason,
+					Latency:  probe.Latency,
 				}
 				if isOpen && ns.grabBanners && shouldGrabBannerPort(p) {
 					bt := ns.timeout / bannerGrabTimeoutDivisor
@@ -822,12 +1796,15 @@ func (ns *NetworkScanner) scanHost(ip net.IP, ports []int) {
 					if bt > bannerGrabTimeoutMax {
 						bt = bannerGrabTimeoutMax
 					}
-					if b, err := banner.GrabTCP(ipStr, p, bt); err == nil && strings.TrimSpace(b) != "" {
-						portInfo.Banner = b
-						portInfo.Version = banner.ExtractVersionHint(p, b)
-					} else {
-						portInfo.Banner = "нет ответа"
-						portInfo.Version = ""
+					if release, ok := ns.acquireProbe(ipStr, 1); ok {
+						if b, err := banner.GrabTCPWith(ns.dialTimeout, ipStr, p, bt); err == nil && strings.TrimSpace(b) != "" {
+							portInfo.Banner = b
+							portInfo.Version = banner.ExtractVersionHint(p, b)
+						} else {
+							portInfo.Banner = "нет ответа"
+							portInfo.Version = ""
+						}
+						release()
 					}
 				}
 
@@ -898,77 +1875,44 @@ func (ns *NetworkScanner) scanHost(ip net.IP, ports []int) {
 		}
 	}
 
-	// UDP сканирование (если включено)
-	if ns.scanUDP {
+	// UDP сканирование (если включено; через прокси недоступно)
+	if ns.scanUDP && ns.proxy == nil {
 		ns.scanHostUDP(ipStr, &result)
 	}
 
-	// Собираем результаты MAC и hostname (неблокирующе)
-	select {
-	case mac := <-macChan:
-		result.MAC = mac
-		result.DeviceVendor = getVendorFromMAC(mac)
-		logger.LogDebug("Хост %s: MAC адрес установлен: %s, производитель: %s", ipStr, mac, result.DeviceVendor)
-	case <-macErrChan:
-		logger.LogDebug("Хост %s: MAC адрес не получен (ошибка или таймаут)", ipStr)
-		// Игнорируем ошибку получения MAC
-	case <-time.After(macTimeout):
-		logger.LogDebug("Хост %s: MAC адрес не получен (таймаут ожидания)", ipStr)
-		// Не ждем долго, если MAC еще не получен
-	default:
-		logger.LogDebug("Хост %s: MAC адрес еще не готов", ipStr)
-		// Продолжаем без MAC, если он еще не готов
-	}
+	// Имя из списка целей остаётся, если обратный DNS ничего не даст
+	result.Hostname = ns.targetNames[ip.String()]
+	// SNMP по уже собранным данным; активную пробу при необходимости выполнит стадия snmp
+	result.SNMPEnabled = hasOpenPort(result.Ports, snmpPort, "udp") || hasOpenPort(result.Ports, snmpPort, "tcp")
 
-	select {
-	case hostname := <-hostnameChan:
-		if len(hostname) > 0 {
-			result.Hostname = hostname[0]
-			logger.LogDebug("Хост %s: hostname установлен: %s", ipStr, hostname[0])
-		}
-	case <-hostnameErrChan:
-		logger.LogDebug("Хост %s: hostname не получен (ошибка DNS)", ipStr)
-		// Игнорируем ошибку DNS
-	case <-time.After(hostnameTimeout):
-		logger.LogDebug("Хост %s: hostname не получен (таймаут ожидания)", ipStr)
-		// Не ждем долго, если hostname еще не получен
-	default:
-		logger.LogDebug("Хост %s: hostname еще не готов", ipStr)
-		// Продолжаем без hostname, если он еще не готов
+	// Стадии обогащения: MAC, имя, SNMP, тип устройства, ОС и зарегистрированные расширения
+	ns.enrich.run(ns.ctx, &result)
+	logger.LogDebug("Хост %s: определен тип устройства: %s", ipStr, result.DeviceType)
+
+	// Сохраняем результат и сразу отдаём его подписчику. Хост, скан которого прервала
+	// отмена, остаётся незавершённым в контрольной точке и при продолжении сканируется заново.
+	ns.publishHost(result)
+	if ns.ctx.Err() == nil {
+		ns.checkpoint.markFinished(ip.String())
 	}
 
-	// Определяем тип устройства
-	result.DeviceType = ns.detectDeviceType(result)
+	logger.LogDebug("Хост %s: найдено открытых портов: %d", ipStr, openPorts)
+}
 
-	openTCPPorts := make([]int, 0)
-	for _, p := range result.Ports {
-		if p.State == "open" && p.Protocol == "tcp" {
-			openTCPPorts = append(openTCPPorts, p.Port)
-		}
-	}
-	if osName, conf, reason := osdetect.GuessFromHostAndPorts(result.Hostname, openTCPPorts, ns.osDetectActive); osName != "" {
-		result.GuessOS = osName
-		result.GuessOSConfidence = conf
-		result.GuessOSReason = reason
-	}
-	// SNMP определяем по уже собранным данным; активный probe используем только при необходимости
-	// и с коротким таймаутом, чтобы не замедлять массовое сканирование.
-	result.SNMPEnabled = hasOpenPort(result.Ports, snmpPort, "udp") || hasOpenPort(result.Ports, snmpPort, "tcp")
-	if !result.SNMPEnabled {
-		snmpProbeTimeout := ns.timeout
-		if snmpProbeTimeout > snmpProbeTimeoutMax {
-			snmpProbeTimeout = snmpProbeTimeoutMax
-		}
-		result.SNMPEnabled = ns.scanUDPPortWithTimeout(ipStr, snmpPort, snmpProbeTimeout)
-	}
-	logger.LogDebug("Хост %s: определен тип устройства: %s", ipStr, result.DeviceType)
+// publishHost добавляет результат хоста в ns.results и передаёт его в hostCallback.
+// Добавление и вызов callback выполняются под одним мьютексом, поэтому подписчик
+// видит хосты строго в том порядке, в котором они попадают в GetResults().
+func (ns *NetworkScanner) publishHost(result Result) {
+	ns.hostMu.Lock()
+	defer ns.hostMu.Unlock()
 
-	// Сохраняем результат
 	ns.mu.Lock()
 	ns.results = append(ns.results, result)
 	ns.mu.Unlock()
 
-	logger.LogDebug("Хост %s: найдено открытых портов: %d", ipStr, openPorts)
+	if ns.hostCallback != nil {
+		ns.hostCallback(result)
+	}
 }
 
 func (ns *NetworkScanner) portThreadsForHost(portCount int) int {
@@ -1002,10 +1946,10 @@ func (ns *NetworkScanner) scanHostUDP(ipStr string, result *Result) {
 	logger.LogDebug("Начинаю UDP сканирование для хоста %s", ipStr)
 	defer logger.LogDebug("UDP сканирование для хоста %s завершено", ipStr)
 
-	udpPorts := []int{53, 67, 68, 69, 123, 161, 162, 514, 1194}
+	udpPorts := ns.udpPortList
 	udpSem := make(chan struct{}, udpSemaphoreSize)
 	udpWg := sync.WaitGroup{}
-	udpResults := make(chan PortInfo, udpResultBufferSize)
+	udpResults := make(chan PortInfo, len(udpPorts))
 	udpDone := make(chan struct{})
 
 	udpScanCancelled := false
@@ -1034,12 +1978,21 @@ udpPortLoop:
 			default:
 			}
 
+			release, ok := ns.acquireProbe(ipStr, 1)
+			if !ok {
+				return
+			}
 			udpCheckStart := time.Now()
 			atomic.AddInt64(&ns.udpProbeTotal, 1)
-			isOpen := ns.scanUDPPort(ipStr, p)
+			probe := ns.probeUDPPort(ipStr, p, ns.timeout)
 			udpCheckDuration := time.Since(udpCheckStart)
+			release()
+			service := probe.Service
+			if service == "" {
+				service = network.GetServiceName(p)
+			}
 
-			if isOpen {
+			if probe.State == network.PortOpen {
 				atomic.AddInt64(&ns.udpProbeOpen, 1)
 				if ns.verbosePortLogs {
 					logger.LogDebug("Хост %s: UDP порт %d открыт (проверка заняла %v)", ipStr, p, udpCheckDuration)
@@ -1048,7 +2001,8 @@ udpPortLoop:
 					Port:     p,
 					State:    "open",
 					Protocol: "udp",
-					Service:  network.GetServiceName(p),
+					Service:  service,
+					Version:  probe.Version,
 				}
 				select {
 				case udpResults <- portInfo:
@@ -1058,13 +2012,13 @@ udpPortLoop:
 			} else if ns.showClosed {
 				atomic.AddInt64(&ns.udpProbeNoOpen, 1)
 				if ns.verbosePortLogs {
-					logger.LogDebug("Хост %s: UDP порт %d закрыт/фильтруется (проверка заняла %v)", ipStr, p, udpCheckDuration)
+					logger.LogDebug("Хост %s: UDP порт %d %s (проверка заняла %v)", ipStr, p, probe.State, udpCheckDuration)
 				}
 				portInfo := PortInfo{
 					Port:     p,
-					State:    "filtered",
+					State:    probe.State.String(),
 					Protocol: "udp",
-					Service:  network.GetServiceName(p),
+					Service:  service,
 				}
 				select {
 				case udpResults <- portInfo:
@@ -1110,16 +2064,16 @@ udpPortLoop:
 	}
 }
 
-// getMACAddress получает MAC адрес через ARP
+// getMACAddress получает MAC адрес через ARP (IPv4) или таблицу соседей (IPv6)
 func (ns *NetworkScanner) getMACAddress(ip net.IP) (string, error) {
-	if ip == nil || ip.To4() == nil {
-		return "", fmt.Errorf("MAC адрес через ARP доступен только для IPv4")
+	if ip == nil {
+		return "", fmt.Errorf("пустой IP адрес")
 	}
-
-	if ns.networkProber != nil {
-		if hwAddr, err := ns.networkProber.ResolveMAC(ip.String()); err == nil && hwAddr != nil {
-			return hwAddr.String(), nil
-		}
+	if mac, ok := ns.knownMAC(ip); ok {
+		return mac, nil
+	}
+	if ip.To4() == nil {
+		return "", fmt.Errorf("MAC адрес для %s не найден в таблице соседей", ip)
 	}
 
 	// Сначала пытаемся прочитать из ARP таблицы системы (если доступно)
@@ -1133,6 +2087,36 @@ func (ns *NetworkScanner) getMACAddress(ip net.IP) (string, error) {
 	return ns.getMACViaARPRequest(ip)
 }
 
+// knownMAC возвращает MAC, уже известный запуску без обращения к системе: из ARP sweep,
+// от сетевого prober-а, а для IPv6 — из обнаружения соседей и таблицы соседей.
+func (ns *NetworkScanner) knownMAC(ip net.IP) (string, bool) {
+	ns.discoveryMu.Lock()
+	hwAddr := ns.discoveredMACs[ip.String()]
+	ns.discoveryMu.Unlock()
+	if hwAddr != nil {
+		return hwAddr.String(), true
+	}
+
+	if ns.networkProber != nil {
+		if hwAddr, err := ns.networkProber.ResolveMAC(ip.String()); err == nil && hwAddr != nil {
+			return hwAddr.String(), true
+		}
+	}
+
+	// IPv6: ARP нет, MAC берётся из обнаружения соседей и таблицы соседей системы
+	if ip.To4() == nil {
+		if ndp, ok := ns.discoveryProbers[DiscoveryNDP]; ok {
+			if hwAddr, err := ndp.ResolveMAC(ip.String()); err == nil && hwAddr != nil {
+				return hwAddr.String(), true
+			}
+		}
+		if mac, ok := ns.neighbors.lookup(ip); ok {
+			return mac.String(), true
+		}
+	}
+	return "", false
+}
+
 // readMACFromARPTable читает MAC из системной ARP таблицы
 func (ns *NetworkScanner) readMACFromARPTable(ip net.IP) (string, error) {
 	ipStr := ip.String()
@@ -1336,6 +2320,10 @@ func (ns *NetworkScanner) getMACViaARPRequest(ip net.IP) (string, error) {
 		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
 			continue
 		}
+		// Выбран интерфейс источника — ARP-запросы уходят только через него
+		if ns.source.Interface != "" && iface.Name != ns.source.Interface {
+			continue
+		}
 
 		// Получаем IP интерфейса с таймаутом (избегаем зависания в Windows)
 		addrsChan := make(chan []net.Addr, 1)
@@ -1440,7 +2428,8 @@ func (ns *NetworkScanner) getMACViaARPRequest(ip net.IP) (string, error) {
 }
 
 // detectDeviceType определяет тип устройства по открытым портам, MAC и hostname
-// Использует улучшенную эвристику с учетом производителя и комбинаций портов
+// Использует улучшенную эвристику с учетом производителя и комбинаций портов; продукты,
+// распознанные стадией http, имеют приоритет
 func (ns *NetworkScanner) detectDeviceType(result Result) string {
 	ports := make([]deviceclassifier.Port, 0, len(result.Ports))
 	for _, p := range result.Ports {
@@ -1451,15 +2440,21 @@ func (ns *NetworkScanner) detectDeviceType(result Result) string {
 		})
 	}
 	return deviceclassifier.Classify(deviceclassifier.Input{
-		Ports:        ports,
-		DeviceVendor: result.DeviceVendor,
-		Hostname:     result.Hostname,
+		Ports:          ports,
+		DeviceVendor:   result.DeviceVendor,
+		Hostname:       result.Hostname,
+		HTTPCategories: httpCategories(result),
 	})
 }
 
-// Stop останавливает сканирование
+// Stop останавливает текущее сканирование и ждёт завершения воркеров.
+// После Stop новые вызовы ScanContext сразу возвращают CancelledError.
 func (ns *NetworkScanner) Stop() {
-	ns.cancel()
+	ns.ctxMu.Lock()
+	ns.stopped = true
+	cancel := ns.cancel
+	ns.ctxMu.Unlock()
+	cancel()
 	ns.wg.Wait()
 }
 
@@ -2,13 +2,24 @@ package scanner
 
 import (
 	"context"
+	"sync"
 
 	"network-scanner/internal/contracts"
+	"network-scanner/internal/httpinspect"
+	"network-scanner/internal/mdns"
+	"network-scanner/internal/network"
+	"network-scanner/internal/smbinspect"
+	"network-scanner/internal/sshinspect"
+	"network-scanner/internal/tlsinspect"
+	"network-scanner/internal/upnp"
 )
 
 // scannerServiceImpl реализация ScannerService
 type scannerServiceImpl struct {
 	logLevel string
+
+	mu     sync.Mutex
+	cancel context.CancelFunc // отменяет текущее сканирование (для Stop)
 }
 
 // NewService создаёт ScannerService
@@ -22,73 +33,364 @@ func (s *scannerServiceImpl) Scan(ctx context.Context, cfg contracts.ScanConfig,
 		ctx = context.Background()
 	}
 
-	// Создаём NetworkScanner с параметрами из ScanConfig
+	var ns *NetworkScanner
+	if cfg.Resume {
+		cp, err := LoadCheckpoint(CheckpointPath(cfg.CheckpointDir, cfg.CheckpointID))
+		if err != nil {
+			return nil, err
+		}
+		ns = NewScannerFromCheckpoint(cp)
+	} else {
+		ns = newScannerFromConfig(cfg)
+	}
+	if cfg.CheckpointID != "" {
+		ns.SetCheckpoint(CheckpointPath(cfg.CheckpointDir, cfg.CheckpointID), cfg.CheckpointID)
+	}
+
+	// Обёртка для ProgressHandler
+	if onProgress != nil {
+		ns.SetProgressCallback(func(stage string, current, total int, message string) {
+			onProgress(stage, current, total, message)
+		})
+	}
+
+	if cfg.OnHost != nil {
+		ns.SetHostCallback(func(result Result) {
+			cfg.OnHost(toContractResult(result))
+		})
+	}
+
+	scanCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	s.mu.Lock()
+	s.cancel = cancel
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.cancel = nil
+		s.mu.Unlock()
+	}()
+
+	// При отмене и таймауте summary содержит уже просканированные хосты.
+	summary, err := ns.ScanContext(scanCtx)
+
+	// Конвертируем результаты
+	results := make([]contracts.ScanResult, 0, len(summary.Results))
+	for _, r := range summary.Results {
+		results = append(results, toContractResult(r))
+	}
+
+	return results, err
+}
+
+// newScannerFromConfig создаёт NetworkScanner с параметрами из ScanConfig.
+func newScannerFromConfig(cfg contracts.ScanConfig) *NetworkScanner {
+	// Профиль скорости заполняет незаданные таймаут и число потоков
+	if tmpl, ok := LookupTimingTemplate(cfg.Timing); ok {
+		cfg.Timeout, cfg.Threads = tmpl.Defaults(cfg.Timeout, cfg.Threads)
+	}
+
 	ns := NewNetworkScanner(
 		cfg.NetworkCIDR,
 		cfg.Timeout,
 		cfg.PortRange,
 		cfg.Threads,
-		false, // showClosed
+		cfg.ShowClosed,
 	)
 
+	if len(cfg.Targets) > 0 {
+		ns.SetTargets(append(network.SplitTargets(cfg.NetworkCIDR), cfg.Targets...))
+	}
+	ns.SetExclude(cfg.Exclude)
+	ns.SetRandomizeHosts(cfg.RandomizeHosts)
+	ns.SetExcludePorts(cfg.ExcludePorts)
+	ns.SetScanType(cfg.ScanType)
+	ns.SetSource(cfg.Interface, cfg.SourceIP)
+	ns.SetProxy(cfg.Proxy)
+	ns.SetDiscovery(cfg.Discovery)
+	ns.SetTiming(cfg.Timing)
+	ns.SetRateLimit(RateLimit{ProbesPerSecond: cfg.MaxRate, MaxPerHost: cfg.MaxHostInflight, ProbeDelay: cfg.ScanDelay})
 	ns.SetScanUDP(cfg.ScanUDP)
+	ns.SetUDPPorts(cfg.UDPPorts)
 	ns.SetGrabBanners(cfg.GrabBanners)
 	ns.SetOSDetectActive(cfg.OSActive)
 	ns.SetVerbosePortLogs(cfg.VerboseLogs)
+	ns.SetEnrich(cfg.Enrich)
+	ns.SetLookupTimeout(cfg.LookupTimeout)
+	return ns
+}
 
-	// Обёртка для ProgressHandler
-	if onProgress != nil {
-		ns.SetProgressCallback(func(stage string, current, total int, message string) {
-			onProgress(stage, current, total, message)
+// toContractResult конвертирует внутренний Result в contracts.ScanResult.
+func toContractResult(r Result) contracts.ScanResult {
+	ports := make([]contracts.PortInfo, 0, len(r.Ports))
+	for _, p := range r.Ports {
+		ports = append(ports, contracts.PortInfo{
+			Port:     p.Port,
+			State:    p.State,
+			Protocol: p.Protocol,
+			Service:  p.Service,
+			Banner:   p.Banner,
+			Version:  p.Version,
+			Product:  p.Product,
+			Info:     p.Info,
+			CPE:      p.CPE,
+			TLS:      ToContractTLS(p.TLS),
+			SSH:      ToContractSSH(p.SSH),
+			HTTP:     ToContractHTTP(p.HTTP),
+			SMB:      ToContractSMB(p.SMB),
+			Reason:   p.Reason,
+			Latency:  p.Latency,
 		})
 	}
+	return contracts.ScanResult{
+		IP:              r.IP,
+		Hostname:        r.Hostname,
+		MAC:             r.MAC,
+		Ports:           ports,
+		DeviceType:      r.DeviceType,
+		DeviceVendor:    r.DeviceVendor,
+		GuessOS:         r.GuessOS,
+		DiscoveryMethod: r.DiscoveryMethod,
+		Addresses:       r.Addresses,
+		Via:             r.Via,
+		NetBIOS:         ToContractNetBIOS(r.NetBIOS),
+		MDNS:            ToContractMDNS(r.MDNS),
+		UPnP:            ToContractUPnP(r.UPnP),
+	}
+}
 
-	// Запускаем сканирование в отдельной горутине с контекстом
-	done := make(chan struct{})
-	go func() {
-		ns.Scan()
-		close(done)
-	}()
+// ToContractTLS копирует сведения tlsinspect в contracts.TLSInfo.
+func ToContractTLS(info *tlsinspect.Info) *contracts.TLSInfo {
+	if info == nil {
+		return nil
+	}
+	out := &contracts.TLSInfo{STARTTLS: info.STARTTLS}
+	for _, p := range info.Protocols {
+		out.Protocols = append(out.Protocols, contracts.TLSProtocol(p))
+	}
+	for _, c := range info.Chain {
+		out.Chain = append(out.Chain, contracts.TLSCertificate(c))
+	}
+	return out
+}
 
-	// Ждём завершения или отмены контекста
-	select {
-	case <-ctx.Done():
-		// Отмена сканирования
-		<-done
-		return nil, ctx.Err()
-	case <-done:
+// FromContractTLS восстанавливает tlsinspect.Info из contracts.TLSInfo (для аудита и инвентаризации).
+func FromContractTLS(info *contracts.TLSInfo) *tlsinspect.Info {
+	if info == nil {
+		return nil
 	}
+	out := &tlsinspect.Info{STARTTLS: info.STARTTLS}
+	for _, p := range info.Protocols {
+		out.Protocols = append(out.Protocols, tlsinspect.Protocol(p))
+	}
+	for _, c := range info.Chain {
+		out.Chain = append(out.Chain, tlsinspect.Certificate(c))
+	}
+	return out
+}
 
-	// Конвертируем результаты
-	rawResults := ns.GetResults()
-	results := make([]contracts.ScanResult, 0, len(rawResults))
-	for _, r := range rawResults {
-		ports := make([]contracts.PortInfo, 0, len(r.Ports))
-		for _, p := range r.Ports {
-			ports = append(ports, contracts.PortInfo{
-				Port:     p.Port,
-				State:    p.State,
-				Protocol: p.Protocol,
-				Service:  p.Service,
-				Banner:   p.Banner,
-				Version:  p.Version,
-			})
-		}
+// ToContractSSH копирует отпечаток sshinspect в contracts.SSHInfo.
+func ToContractSSH(info *sshinspect.Info) *contracts.SSHInfo {
+	if info == nil {
+		return nil
+	}
+	out := &contracts.SSHInfo{
+		Banner:            info.Banner,
+		KexAlgorithms:     info.KexAlgorithms,
+		HostKeyAlgorithms: info.HostKeyAlgorithms,
+		Ciphers:           info.Ciphers,
+		MACs:              info.MACs,
+		Compression:       info.Compression,
+	}
+	for _, k := range info.HostKeys {
+		out.HostKeys = append(out.HostKeys, contracts.SSHHostKey(k))
+	}
+	return out
+}
 
-		results = append(results, contracts.ScanResult{
-			IP:           r.IP,
-			Hostname:     r.Hostname,
-			MAC:          r.MAC,
-			Ports:        ports,
-			DeviceType:   r.DeviceType,
-			DeviceVendor: r.DeviceVendor,
-			GuessOS:      r.GuessOS,
-		})
+// FromContractSSH восстанавливает sshinspect.Info из contracts.SSHInfo.
+func FromContractSSH(info *contracts.SSHInfo) *sshinspect.Info {
+	if info == nil {
+		return nil
+	}
+	out := &sshinspect.Info{
+		Banner:            info.Banner,
+		KexAlgorithms:     info.KexAlgorithms,
+		HostKeyAlgorithms: info.HostKeyAlgorithms,
+		Ciphers:           info.Ciphers,
+		MACs:              info.MACs,
+		Compression:       info.Compression,
+	}
+	for _, k := range info.HostKeys {
+		out.HostKeys = append(out.HostKeys, sshinspect.HostKey(k))
+	}
+	return out
+}
+
+// ToContractHTTP копирует отпечаток httpinspect в contracts.HTTPInfo.
+func ToContractHTTP(info *httpinspect.Info) *contracts.HTTPInfo {
+	if info == nil {
+		return nil
+	}
+	out := &contracts.HTTPInfo{
+		URL:         info.URL,
+		Status:      info.Status,
+		Title:       info.Title,
+		Headers:     info.Headers,
+		Cookies:     info.Cookies,
+		Realm:       info.Realm,
+		Generator:   info.Generator,
+		FaviconHash: info.FaviconHash,
+	}
+	for _, p := range info.Products {
+		out.Products = append(out.Products, contracts.HTTPProduct(p))
+	}
+	return out
+}
+
+// FromContractHTTP восстанавливает httpinspect.Info из contracts.HTTPInfo.
+func FromContractHTTP(info *contracts.HTTPInfo) *httpinspect.Info {
+	if info == nil {
+		return nil
+	}
+	out := &httpinspect.Info{
+		URL:         info.URL,
+		Status:      info.Status,
+		Title:       info.Title,
+		Headers:     info.Headers,
+		Cookies:     info.Cookies,
+		Realm:       info.Realm,
+		Generator:   info.Generator,
+		FaviconHash: info.FaviconHash,
+	}
+	for _, p := range info.Products {
+		out.Products = append(out.Products, httpinspect.Product(p))
+	}
+	return out
+}
+
+// ToContractSMB копирует сведения smbinspect в contracts.SMBInfo.
+func ToContractSMB(info *smbinspect.Info) *contracts.SMBInfo {
+	if info == nil {
+		return nil
+	}
+	out := contracts.SMBInfo(*info)
+	return &out
+}
+
+// FromContractSMB восстанавливает smbinspect.Info из contracts.SMBInfo.
+func FromContractSMB(info *contracts.SMBInfo) *smbinspect.Info {
+	if info == nil {
+		return nil
+	}
+	out := smbinspect.Info(*info)
+	return &out
+}
+
+// ToContractNetBIOS копирует таблицу имён smbinspect в contracts.NetBIOSInfo.
+func ToContractNetBIOS(nb *smbinspect.NetBIOS) *contracts.NetBIOSInfo {
+	if nb == nil {
+		return nil
 	}
+	out := &contracts.NetBIOSInfo{Name: nb.Name, Workgroup: nb.Workgroup, MAC: nb.MAC}
+	for _, n := range nb.Names {
+		out.Names = append(out.Names, contracts.NetBIOSName(n))
+	}
+	return out
+}
+
+// FromContractNetBIOS восстанавливает smbinspect.NetBIOS из contracts.NetBIOSInfo.
+func FromContractNetBIOS(nb *contracts.NetBIOSInfo) *smbinspect.NetBIOS {
+	if nb == nil {
+		return nil
+	}
+	out := &smbinspect.NetBIOS{Name: nb.Name, Workgroup: nb.Workgroup, MAC: nb.MAC}
+	for _, n := range nb.Names {
+		out.Names = append(out.Names, smbinspect.NetBIOSName(n))
+	}
+	return out
+}
 
-	return results, nil
+// ToContractMDNS копирует сведения mdns в contracts.MDNSHost.
+func ToContractMDNS(h *mdns.Host) *contracts.MDNSHost {
+	if h == nil {
+		return nil
+	}
+	out := &contracts.MDNSHost{Hostname: h.Hostname, Addresses: h.Addresses}
+	for _, svc := range h.Services {
+		out.Services = append(out.Services, contracts.MDNSService(svc))
+	}
+	return out
+}
+
+// FromContractMDNS восстанавливает mdns.Host из contracts.MDNSHost.
+func FromContractMDNS(h *contracts.MDNSHost) *mdns.Host {
+	if h == nil {
+		return nil
+	}
+	out := &mdns.Host{Hostname: h.Hostname, Addresses: h.Addresses}
+	for _, svc := range h.Services {
+		out.Services = append(out.Services, mdns.Service(svc))
+	}
+	return out
 }
 
+// ToContractUPnP копирует описания устройств upnp в contracts.UPnPDevice.
+func ToContractUPnP(devices []upnp.Device) []contracts.UPnPDevice {
+	var out []contracts.UPnPDevice
+	for _, d := range devices {
+		c := contracts.UPnPDevice{
+			Location:     d.Location,
+			Server:       d.Server,
+			DeviceType:   d.DeviceType,
+			FriendlyName: d.FriendlyName,
+			Manufacturer: d.Manufacturer,
+			ModelName:    d.ModelName,
+			ModelNumber:  d.ModelNumber,
+			SerialNumber: d.SerialNumber,
+			Firmware:     d.Firmware,
+			UDN:          d.UDN,
+			Services:     d.Services,
+		}
+		for _, m := range d.PortMappings {
+			c.PortMappings = append(c.PortMappings, contracts.UPnPPortMapping(m))
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// FromContractUPnP восстанавливает upnp.Device из contracts.UPnPDevice.
+func FromContractUPnP(devices []contracts.UPnPDevice) []upnp.Device {
+	var out []upnp.Device
+	for _, c := range devices {
+		d := upnp.Device{
+			Location:     c.Location,
+			Server:       c.Server,
+			DeviceType:   c.DeviceType,
+			FriendlyName: c.FriendlyName,
+			Manufacturer: c.Manufacturer,
+			ModelName:    c.ModelName,
+			ModelNumber:  c.ModelNumber,
+			SerialNumber: c.SerialNumber,
+			Firmware:     c.Firmware,
+			UDN:          c.UDN,
+			Services:     c.Services,
+		}
+		for _, m := range c.PortMappings {
+			d.PortMappings = append(d.PortMappings, upnp.PortMapping(m))
+		}
+		out = append(out, d)
+	}
+	return out
+}
+
+// Stop отменяет текущее сканирование; Scan вернёт CancelledError.
 func (s *scannerServiceImpl) Stop() {
-	// Реализация остановки (может потребовать глобального состояния)
+	s.mu.Lock()
+	cancel := s.cancel
+	s.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
 }
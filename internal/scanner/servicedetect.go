@@ -0,0 +1,135 @@
+package scanner
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"network-scanner/internal/logger"
+	"network-scanner/internal/network"
+	"network-scanner/internal/servicedetect"
+)
+
+const (
+	serviceDetectTimeout = 30 * time.Second // предел стадии service на хост
+	serviceDetectWorkers = 4                // портов хоста, определяемых одновременно
+)
+
+// detectedPort — итог определения службы порта стадией service.
+type detectedPort struct {
+	port     int
+	protocol string
+	result   servicedetect.Result
+}
+
+// enrichService определяет службу, продукт, версию и CPE открытых портов хоста пробами
+// встроенной базы servicedetect, независимо от номера порта. TCP-пробы идут через диалер
+// запуска (симулятор, прокси, источник); UDP через прокси недоступен.
+func (ns *NetworkScanner) enrichService(ctx context.Context, r Result) (ResultUpdate, error) {
+	db, err := servicedetect.Default()
+	if err != nil {
+		return nil, err
+	}
+	timeout := ns.timeout
+	if timeout <= 0 || timeout > servicedetect.DefaultTimeout {
+		timeout = servicedetect.DefaultTimeout
+	}
+	opts := servicedetect.Options{Timeout: timeout, Dial: ns.dialTimeout}
+	ip, _ := network.SplitZone(r.IP)
+	if ip == nil {
+		return nil, nil
+	}
+	host := ip.String()
+
+	var open []PortInfo
+	for _, p := range r.Ports {
+		if p.State != "open" && p.State != "open|filtered" {
+			continue
+		}
+		if p.Protocol == "udp" && ns.proxy != nil {
+			continue
+		}
+		open = append(open, p)
+	}
+	if len(open) == 0 {
+		return nil, nil
+	}
+
+	var mu sync.Mutex
+	var found []detectedPort
+	ns.probePorts(ctx, r.IP, open, serviceDetectWorkers, func(p PortInfo) {
+		res, matched := db.Detect(ctx, host, p.Port, p.Protocol, opts)
+		if !matched {
+			return
+		}
+		mu.Lock()
+		found = append(found, detectedPort{port: p.Port, protocol: p.Protocol, result: res})
+		mu.Unlock()
+	})
+	if len(found) == 0 {
+		return nil, nil
+	}
+	logger.LogDebug("Хост %s: распознано служб %d из %d открытых портов", r.IP, len(found), len(open))
+	return func(res *Result) {
+		for _, d := range found {
+			for i := range res.Ports {
+				if p := &res.Ports[i]; p.Port == d.port && p.Protocol == d.protocol {
+					applyServiceMatch(p, d.result)
+				}
+			}
+		}
+	}, nil
+}
+
+// probePorts вызывает probe для каждого порта из ports не более чем в workers потоках,
+// занимая для каждой пробы слот общего лимита проб хоста ip. Новые порты не выдаются
+// после отмены ctx.
+func (ns *NetworkScanner) probePorts(ctx context.Context, ip string, ports []PortInfo, workers int, probe func(p PortInfo)) {
+	jobs := make(chan PortInfo)
+	var wg sync.WaitGroup
+	if len(ports) < workers {
+		workers = len(ports)
+	}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range jobs {
+				release, ok := ns.acquireProbe(ip, 1)
+				if !ok {
+					continue
+				}
+				probe(p)
+				release()
+			}
+		}()
+	}
+	for _, p := range ports {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- p
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// applyServiceMatch переносит распознанную службу в порт: soft-совпадение задаёт только
+// имя службы и сведения, полное — также продукт, версию и CPE. Ответ службы становится
+// баннером порта, если баннер ещё не получен.
+func applyServiceMatch(p *PortInfo, res servicedetect.Result) {
+	p.Service = res.Service
+	if res.Info != "" {
+		p.Info = res.Info
+	}
+	if !res.Soft {
+		p.Product = res.Product
+		p.CPE = res.CPE
+		if res.Version != "" {
+			p.Version = res.Version
+		}
+	}
+	if p.Banner == "" {
+		p.Banner = res.Banner
+	}
+}
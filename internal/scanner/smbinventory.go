@@ -0,0 +1,134 @@
+package scanner
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"network-scanner/internal/logger"
+	"network-scanner/internal/network"
+	"network-scanner/internal/smbinspect"
+)
+
+const (
+	netbiosProbeTimeoutMax = 500 * time.Millisecond // предел ожидания ответа NBSTAT
+	smbInventoryTimeout    = 20 * time.Second       // предел стадии smb на хост
+	smbInventoryWorkers    = 1                      // SMB-портов хоста, проверяемых одновременно
+)
+
+// enrichNetBIOS запрашивает таблицу имён NetBIOS (NBSTAT, UDP 137) у хостов с открытыми
+// портами NetBIOS/SMB (TCP 139, 445 или UDP 137): имя компьютера заполняет пустое имя
+// хоста, MAC адаптера — пустой MAC (хост за маршрутизатором, ARP недоступен). Через
+// прокси UDP недоступен.
+func (ns *NetworkScanner) enrichNetBIOS(ctx context.Context, r Result) (ResultUpdate, error) {
+	if _, excluded := ns.excludedPortSet[smbinspect.NetBIOSPort]; excluded || ns.proxy != nil || r.NetBIOS != nil {
+		return nil, nil
+	}
+	if !hasOpenPort(r.Ports, 139, "tcp") && !hasOpenPort(r.Ports, 445, "tcp") && !hasOpenPort(r.Ports, smbinspect.NetBIOSPort, "udp") {
+		return nil, nil
+	}
+	ip, _ := network.SplitZone(r.IP)
+	if ip == nil {
+		return nil, nil
+	}
+	timeout := ns.timeout
+	if timeout <= 0 || timeout > netbiosProbeTimeoutMax {
+		timeout = netbiosProbeTimeoutMax
+	}
+	release, ok := ns.acquireProbe(r.IP, 1)
+	if !ok {
+		return nil, nil
+	}
+	nb, err := smbinspect.QueryNetBIOS(ctx, ip.String(), smbinspect.NetBIOSPort, smbinspect.Options{Timeout: timeout, Dial: ns.dialTimeout})
+	release()
+	if err != nil {
+		return nil, nil
+	}
+	logger.LogDebug("Хост %s: имя NetBIOS %s", r.IP, nb.Summary())
+	return func(res *Result) {
+		res.NetBIOS = nb
+		if res.Hostname == "" {
+			res.Hostname = nb.Name
+		}
+		if res.MAC == "" && nb.MAC != "" {
+			res.MAC = nb.MAC
+			res.DeviceVendor = getVendorFromMAC(nb.MAC)
+		}
+	}, nil
+}
+
+// enrichSMB согласует SMB без аутентификации на открытых TCP-портах 445 и портах со
+// службой microsoft-ds/smb: диалекты SMB2/3, SMBv1, режим подписи, GUID сервера, а также
+// имя, домен и версию ОС из NTLM. Полное имя из NTLM заменяет пустое имя хоста или
+// короткое имя NetBIOS.
+func (ns *NetworkScanner) enrichSMB(ctx context.Context, r Result) (ResultUpdate, error) {
+	timeout := ns.timeout
+	if timeout <= 0 || timeout > smbinspect.DefaultTimeout {
+		timeout = smbinspect.DefaultTimeout
+	}
+	ip, _ := network.SplitZone(r.IP)
+	if ip == nil {
+		return nil, nil
+	}
+	host := ip.String()
+
+	var candidates []PortInfo
+	for _, p := range r.Ports {
+		if p.State != "open" || p.Protocol != "tcp" {
+			continue
+		}
+		service := strings.ToLower(p.Service)
+		if p.Port == 445 || service == "microsoft-ds" || service == "smb" {
+			candidates = append(candidates, p)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	var mu sync.Mutex
+	found := make(map[int]*smbinspect.Info)
+	ns.probePorts(ctx, r.IP, candidates, smbInventoryWorkers, func(p PortInfo) {
+		info, err := smbinspect.Inspect(ctx, host, p.Port, smbinspect.Options{Timeout: timeout, Dial: ns.dialTimeout})
+		if err != nil {
+			return
+		}
+		mu.Lock()
+		found[p.Port] = info
+		mu.Unlock()
+	})
+	if len(found) == 0 {
+		return nil, nil
+	}
+	logger.LogDebug("Хост %s: согласован SMB на %d портах", r.IP, len(found))
+	return func(res *Result) {
+		for i := range res.Ports {
+			if p := &res.Ports[i]; p.Protocol == "tcp" && found[p.Port] != nil {
+				p.SMB = found[p.Port]
+				name := p.SMB.Hostname()
+				if name != "" && (res.Hostname == "" || res.NetBIOS != nil && strings.EqualFold(res.Hostname, res.NetBIOS.Name)) {
+					res.Hostname = name
+				}
+			}
+		}
+	}, nil
+}
+
+// smbInfo возвращает сведения первого SMB-порта с версией ОС из NTLM, иначе первого
+// SMB-порта; nil — стадия smb ничего не нашла.
+func smbInfo(r Result) *smbinspect.Info {
+	var first *smbinspect.Info
+	for _, p := range r.Ports {
+		if p.SMB == nil {
+			continue
+		}
+		if p.SMB.OSVersion != "" {
+			return p.SMB
+		}
+		if first == nil {
+			first = p.SMB
+		}
+	}
+	return first
+}
@@ -0,0 +1,115 @@
+package scanner
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"slices"
+	"testing"
+	"time"
+
+	"network-scanner/internal/smbinspect"
+)
+
+// serveSMB2 запускает SMB-сервер, который согласует только диалект 3.0.2 с обязательной
+// подписью, отклоняет SESSION_SETUP и обрывает соединение на SMBv1.
+func serveSMB2(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+				for {
+					var hdr [4]byte
+					if _, err := io.ReadFull(conn, hdr[:]); err != nil {
+						return
+					}
+					req := make([]byte, binary.BigEndian.Uint32(hdr[:]))
+					if _, err := io.ReadFull(conn, req); err != nil || req[0] != 0xFE {
+						return
+					}
+					resp := make([]byte, 64+65)
+					copy(resp, req[:64])
+					resp[16] = 0x01 // ответ сервера
+					status := uint32(0xC000006D)
+					if binary.LittleEndian.Uint16(req[12:]) == 0 {
+						count := int(binary.LittleEndian.Uint16(req[64+2:]))
+						status = 0xC00000BB
+						for i := 0; i < count; i++ {
+							if binary.LittleEndian.Uint16(req[64+36+2*i:]) == 0x0302 {
+								status = 0
+							}
+						}
+						binary.LittleEndian.PutUint16(resp[64:], 65)
+						binary.LittleEndian.PutUint16(resp[64+2:], 0x03) // подпись включена и обязательна
+						binary.LittleEndian.PutUint16(resp[64+4:], 0x0302)
+					}
+					binary.LittleEndian.PutUint32(resp[8:], status)
+					frame := binary.BigEndian.AppendUint32(nil, uint32(len(resp)))
+					if _, err := conn.Write(append(frame, resp...)); err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func TestEnrichSMB(t *testing.T) {
+	port := serveSMB2(t)
+	ns := NewNetworkScanner("127.0.0.1/32", time.Second, "1-5", 5, false)
+	r := Result{IP: "127.0.0.1", Ports: []PortInfo{
+		{Port: port, Protocol: "tcp", State: "open", Service: "microsoft-ds"},
+		{Port: 80, Protocol: "tcp", State: "open", Service: "HTTP"},
+	}}
+	update, err := ns.enrichSMB(context.Background(), r)
+	if err != nil || update == nil {
+		t.Fatalf("enrichSMB: update=%v err=%v", update != nil, err)
+	}
+	update(&r)
+	info := r.Ports[0].SMB
+	if info == nil || !slices.Equal(info.Dialects, []string{"3.0.2"}) || !info.SigningRequired || info.SMB1 {
+		t.Fatalf("SMB-порт: %+v", info)
+	}
+	if r.Ports[1].SMB != nil || r.Hostname != "" {
+		t.Errorf("http smb=%+v, hostname=%q", r.Ports[1].SMB, r.Hostname)
+	}
+}
+
+func TestEnrichOSFromSMBAndNetBIOS(t *testing.T) {
+	ns := NewNetworkScanner("127.0.0.1/32", time.Second, "1-5", 5, false)
+	r := Result{IP: "10.0.0.5", Ports: []PortInfo{
+		{Port: 135, Protocol: "tcp", State: "open"},
+		{Port: 445, Protocol: "tcp", State: "open", SMB: &smbinspect.Info{Dialects: []string{"3.1.1"}, OSVersion: "10.0.22631"}},
+	}}
+	update, err := ns.enrichOS(context.Background(), r)
+	if err != nil || update == nil {
+		t.Fatalf("enrichOS: update=%v err=%v", update != nil, err)
+	}
+	update(&r)
+	if r.GuessOS != "Windows 11 23H2" || r.GuessOSConfidence != "высокая" {
+		t.Errorf("по SMB: %q (%s)", r.GuessOS, r.GuessOSConfidence)
+	}
+
+	r = Result{IP: "10.0.0.6", Ports: []PortInfo{{Port: 139, Protocol: "tcp", State: "open"}}, NetBIOS: &smbinspect.NetBIOS{Name: "NAS"}}
+	update, err = ns.enrichOS(context.Background(), r)
+	if err != nil || update == nil {
+		t.Fatalf("enrichOS: update=%v err=%v", update != nil, err)
+	}
+	update(&r)
+	if r.GuessOS != "Linux/Unix (Samba)" {
+		t.Errorf("по NetBIOS: %q (%s)", r.GuessOS, r.GuessOSConfidence)
+	}
+}
@@ -0,0 +1,72 @@
+package scanner
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"network-scanner/internal/logger"
+	"network-scanner/internal/network"
+	"network-scanner/internal/sshinspect"
+)
+
+const (
+	sshInventoryTimeout = 20 * time.Second // предел стадии ssh на хост
+	sshInventoryWorkers = 2                // SSH-портов хоста, проверяемых одновременно
+)
+
+// enrichSSH снимает отпечаток SSH-серверов хоста: строку версии, алгоритмы из KEXINIT и
+// ключи хоста каждого предложенного типа (без аутентификации). Проверяются открытые
+// TCP-порты 22, порты со службой ssh (после стадии service — и нестандартные) и порты,
+// баннер которых начинается с "SSH-".
+func (ns *NetworkScanner) enrichSSH(ctx context.Context, r Result) (ResultUpdate, error) {
+	timeout := ns.timeout
+	if timeout <= 0 || timeout > sshinspect.DefaultTimeout {
+		timeout = sshinspect.DefaultTimeout
+	}
+	ip, _ := network.SplitZone(r.IP)
+	if ip == nil {
+		return nil, nil
+	}
+	host := ip.String()
+
+	var candidates []PortInfo
+	for _, p := range r.Ports {
+		if p.State != "open" || p.Protocol != "tcp" {
+			continue
+		}
+		if p.Port == 22 || strings.EqualFold(p.Service, "ssh") || strings.HasPrefix(p.Banner, "SSH-") {
+			candidates = append(candidates, p)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	var mu sync.Mutex
+	found := make(map[int]*sshinspect.Info)
+	ns.probePorts(ctx, r.IP, candidates, sshInventoryWorkers, func(p PortInfo) {
+		info, err := sshinspect.Inspect(ctx, host, p.Port, sshinspect.Options{Timeout: timeout, Dial: ns.dialTimeout})
+		if err != nil {
+			return
+		}
+		mu.Lock()
+		found[p.Port] = info
+		mu.Unlock()
+	})
+	if len(found) == 0 {
+		return nil, nil
+	}
+	logger.LogDebug("Хост %s: снят отпечаток SSH на %d портах", r.IP, len(found))
+	return func(res *Result) {
+		for i := range res.Ports {
+			if p := &res.Ports[i]; p.Protocol == "tcp" && found[p.Port] != nil {
+				p.SSH = found[p.Port]
+				if p.Banner == "" {
+					p.Banner = p.SSH.Banner
+				}
+			}
+		}
+	}, nil
+}
@@ -0,0 +1,67 @@
+package scanner
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestEnrichSSH(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &ssh.ServerConfig{
+		ServerVersion: "SSH-2.0-dropbear_2022.83",
+		PasswordCallback: func(ssh.ConnMetadata, []byte) (*ssh.Permissions, error) {
+			return nil, ssh.ErrNoAuth
+		},
+	}
+	cfg.AddHostKey(signer)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+				_, _, _, _ = ssh.NewServerConn(conn, cfg)
+			}()
+		}
+	}()
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	ns := NewNetworkScanner("127.0.0.1/32", time.Second, "1-5", 5, false)
+	r := Result{IP: "127.0.0.1", Ports: []PortInfo{
+		{Port: port, Protocol: "tcp", State: "open", Service: "ssh"},
+		{Port: 80, Protocol: "tcp", State: "open", Service: "HTTP"},
+	}}
+	update, err := ns.enrichSSH(context.Background(), r)
+	if err != nil || update == nil {
+		t.Fatalf("enrichSSH: update=%v err=%v", update != nil, err)
+	}
+	update(&r)
+	info := r.Ports[0].SSH
+	if info == nil || len(info.HostKeys) != 1 || info.HostKeys[0].Fingerprint != ssh.FingerprintSHA256(signer.PublicKey()) {
+		t.Fatalf("SSH-порт: %+v", info)
+	}
+	if r.Ports[0].Banner != "SSH-2.0-dropbear_2022.83" || r.Ports[1].SSH != nil {
+		t.Errorf("banner=%q, http ssh=%+v", r.Ports[0].Banner, r.Ports[1].SSH)
+	}
+}
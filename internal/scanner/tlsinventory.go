@@ -0,0 +1,75 @@
+package scanner
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"network-scanner/internal/logger"
+	"network-scanner/internal/network"
+	"network-scanner/internal/tlsinspect"
+)
+
+const (
+	tlsInventoryTimeout = 30 * time.Second // предел стадии tls на хост
+	tlsInventoryWorkers = 4                // портов хоста, проверяемых одновременно
+)
+
+// tlsSkipServices — службы, которые заведомо не согласуют TLS на своём порту.
+var tlsSkipServices = map[string]bool{"ssh": true, "telnet": true}
+
+// enrichTLS проверяет открытые TCP-порты хоста рукопожатием TLS (для SMTP, IMAP, POP3 и
+// FTP — после STARTTLS) и записывает в порт поддерживаемые версии, наборы шифров и цепочку
+// сертификатов. Порты, не согласовавшие TLS, не меняются. После стадии service протокол
+// STARTTLS выбирается по распознанной службе, иначе — по номеру порта.
+func (ns *NetworkScanner) enrichTLS(ctx context.Context, r Result) (ResultUpdate, error) {
+	timeout := ns.timeout
+	if timeout <= 0 || timeout > tlsinspect.DefaultTimeout {
+		timeout = tlsinspect.DefaultTimeout
+	}
+	ip, _ := network.SplitZone(r.IP)
+	if ip == nil {
+		return nil, nil
+	}
+	host := ip.String()
+
+	var candidates []PortInfo
+	for _, p := range r.Ports {
+		if p.State != "open" || p.Protocol != "tcp" || tlsSkipServices[strings.ToLower(p.Service)] {
+			continue
+		}
+		candidates = append(candidates, p)
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	var mu sync.Mutex
+	found := make(map[int]*tlsinspect.Info)
+	ns.probePorts(ctx, r.IP, candidates, tlsInventoryWorkers, func(p PortInfo) {
+		opts := tlsinspect.Options{
+			Timeout:  timeout,
+			Dial:     ns.dialTimeout,
+			STARTTLS: tlsinspect.STARTTLSFor(p.Port, p.Service),
+		}
+		info, err := tlsinspect.Inspect(ctx, host, p.Port, opts)
+		if err != nil {
+			return
+		}
+		mu.Lock()
+		found[p.Port] = info
+		mu.Unlock()
+	})
+	if len(found) == 0 {
+		return nil, nil
+	}
+	logger.LogDebug("Хост %s: TLS согласован на %d из %d открытых TCP-портов", r.IP, len(found), len(candidates))
+	return func(res *Result) {
+		for i := range res.Ports {
+			if p := &res.Ports[i]; p.Protocol == "tcp" && found[p.Port] != nil {
+				p.TLS = found[p.Port]
+			}
+		}
+	}, nil
+}
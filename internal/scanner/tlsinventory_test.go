@@ -0,0 +1,57 @@
+package scanner
+
+import (
+	"context"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestEnrichTLS(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0) // отказы в TLS 1.0/1.1 ожидаемы
+	srv.StartTLS()
+	defer srv.Close()
+	_, portStr, _ := net.SplitHostPort(srv.Listener.Addr().String())
+	tlsPort, _ := strconv.Atoi(portStr)
+
+	plain, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer plain.Close()
+	go func() {
+		for {
+			conn, err := plain.Accept()
+			if err != nil {
+				return
+			}
+			conn.Write([]byte("SSH-2.0-OpenSSH_9.6\r\n"))
+			conn.Close()
+		}
+	}()
+	plainPort := plain.Addr().(*net.TCPAddr).Port
+
+	ns := NewNetworkScanner("127.0.0.1/32", time.Second, "1-5", 5, false)
+	r := Result{IP: "127.0.0.1", Ports: []PortInfo{
+		{Port: tlsPort, Protocol: "tcp", State: "open", Service: "Unknown"},
+		{Port: plainPort, Protocol: "tcp", State: "open", Service: "Unknown"},
+		{Port: tlsPort, Protocol: "udp", State: "open|filtered"},
+	}}
+	update, err := ns.enrichTLS(context.Background(), r)
+	if err != nil || update == nil {
+		t.Fatalf("enrichTLS: update=%v err=%v", update != nil, err)
+	}
+	update(&r)
+	if info := r.Ports[0].TLS; info == nil || len(info.Protocols) == 0 || info.Leaf() == nil {
+		t.Errorf("TLS-порт: %+v", r.Ports[0].TLS)
+	}
+	if r.Ports[1].TLS != nil || r.Ports[2].TLS != nil {
+		t.Errorf("TLS записан для порта без TLS: tcp=%+v udp=%+v", r.Ports[1].TLS, r.Ports[2].TLS)
+	}
+}
@@ -0,0 +1,101 @@
+package scanner
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"time"
+
+	"network-scanner/internal/logger"
+	"network-scanner/internal/network"
+	"network-scanner/internal/upnp"
+)
+
+const (
+	upnpInventoryTimeout = 15 * time.Second // предел стадии upnp на хост
+	upnpSearchWindow     = time.Second      // ожидание ответов на unicast M-SEARCH
+)
+
+// ssdpSearcher — prober, находящий устройства поиском SSDP (*upnp.Prober).
+type ssdpSearcher interface {
+	Discover(ctx context.Context) ([]upnp.Response, error)
+	Lookup(ip string) []upnp.Response
+}
+
+// enrichUPnP читает описания устройств UPnP хоста: адреса LOCATION берутся из поиска SSDP
+// (способ обнаружения ssdp), а у хоста с открытым 1900/udp без них — из unicast M-SEARCH.
+// Описание сохраняется в Result.UPnP (у Internet Gateway Device — вместе с таблицей
+// пробросов портов), производитель заполняет пустой или неизвестный DeviceVendor.
+// Описания на других хостах не запрашиваются.
+func (ns *NetworkScanner) enrichUPnP(ctx context.Context, r Result) (ResultUpdate, error) {
+	ip, _ := network.SplitZone(r.IP)
+	if ip == nil || r.UPnP != nil {
+		return nil, nil
+	}
+	var responses []upnp.Response
+	if s := ns.ssdpProber; s != nil {
+		if _, err := s.Discover(ctx); err == nil {
+			responses = s.Lookup(r.IP)
+		}
+	}
+	_, excluded := ns.excludedPortSet[upnp.SSDPPort]
+	if len(responses) == 0 && !excluded && ns.proxy == nil && ip.To4() != nil && hasOpenPort(r.Ports, upnp.SSDPPort, "udp") {
+		release, ok := ns.acquireProbe(r.IP, 1)
+		if !ok {
+			return nil, nil
+		}
+		found, err := upnp.Search(ctx, upnp.SearchOptions{Window: upnpSearchWindow, Target: &net.UDPAddr{IP: ip, Port: upnp.SSDPPort}})
+		release()
+		if err == nil {
+			responses = found
+		}
+	}
+	if len(responses) == 0 {
+		return nil, nil
+	}
+
+	timeout := ns.timeout
+	if timeout <= 0 || timeout > upnp.DefaultTimeout {
+		timeout = upnp.DefaultTimeout
+	}
+	var devices []upnp.Device
+	seen := make(map[string]bool)
+	for _, resp := range responses {
+		if seen[resp.Location] || !locationOnHost(resp.Location, ip) {
+			continue
+		}
+		seen[resp.Location] = true
+		d, err := upnp.Describe(ctx, resp.Location, upnp.Options{Timeout: timeout, Dial: ns.dialTimeout})
+		if err != nil {
+			logger.LogDebug("Хост %s: описание UPnP %s недоступно: %v", r.IP, resp.Location, err)
+			continue
+		}
+		d.Server = resp.Server
+		devices = append(devices, *d)
+	}
+	if len(devices) == 0 {
+		return nil, nil
+	}
+	logger.LogDebug("Хост %s: устройств UPnP %d (%s)", r.IP, len(devices), devices[0].Summary())
+	return func(res *Result) {
+		res.UPnP = devices
+		if res.DeviceVendor == "" || res.DeviceVendor == "Unknown" {
+			for _, d := range devices {
+				if d.Manufacturer != "" {
+					res.DeviceVendor = d.Manufacturer
+					break
+				}
+			}
+		}
+	}, nil
+}
+
+// locationOnHost сообщает, что адрес описания указывает на сам хост ip.
+func locationOnHost(location string, ip net.IP) bool {
+	u, err := url.Parse(location)
+	if err != nil {
+		return false
+	}
+	host := net.ParseIP(u.Hostname())
+	return host != nil && host.Equal(ip)
+}
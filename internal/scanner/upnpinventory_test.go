@@ -0,0 +1,64 @@
+package scanner
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"network-scanner/internal/network"
+	"network-scanner/internal/upnp"
+)
+
+func TestScanContextSSDPDiscovery(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/desc.xml" {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, `<?xml version="1.0"?><root xmlns="urn:schemas-upnp-org:device-1-0"><device>`+
+			`<deviceType>urn:schemas-upnp-org:device:MediaRenderer:1</deviceType><friendlyName>Living Room TV</friendlyName>`+
+			`<manufacturer>Samsung Electronics</manufacturer><modelName>UE55</modelName><serialNumber>0AB1</serialNumber>`+
+			`<serviceList><service><serviceType>urn:schemas-upnp-org:service:RenderingControl:1</serviceType></service></serviceList>`+
+			`</device></root>`)
+	}))
+	defer srv.Close()
+
+	orig := newDiscoveryProber
+	t.Cleanup(func() { newDiscoveryProber = orig })
+	newDiscoveryProber = func(method string, _ time.Duration, _ network.Source) NetworkProber {
+		p := upnp.NewProber(time.Second)
+		p.Search = func(context.Context, upnp.SearchOptions) ([]upnp.Response, error) {
+			return []upnp.Response{
+				{IP: "127.0.0.1", Location: srv.URL + "/desc.xml", Server: "Linux UPnP/1.0 Samsung"},
+				// Описание на другом хосте не запрашивается
+				{IP: "127.0.0.1", Location: "http://192.0.2.50/desc.xml"},
+			}, nil
+		}
+		return p
+	}
+
+	ns := NewScanner("127.0.0.1", 100*time.Millisecond, "80", 2, false, setProber{}, stubPortScanner{openPort: 80}, nil)
+	ns.SetExcludePorts("161")
+	ns.SetDiscovery([]string{"ssdp"})
+	summary, err := ns.ScanContext(context.Background())
+	if err != nil {
+		t.Fatalf("ScanContext() error = %v", err)
+	}
+	if len(summary.Results) != 1 {
+		t.Fatalf("найдено хостов %d, want 1: %+v", len(summary.Results), summary.Results)
+	}
+	r := summary.Results[0]
+	if r.DiscoveryMethod != DiscoverySSDP || len(r.UPnP) != 1 {
+		t.Fatalf("хост: %+v", r)
+	}
+	d := r.UPnP[0]
+	if d.FriendlyName != "Living Room TV" || d.ModelName != "UE55" || d.SerialNumber != "0AB1" || d.Server != "Linux UPnP/1.0 Samsung" || len(d.Services) != 1 {
+		t.Errorf("описание: %+v", d)
+	}
+	if r.DeviceVendor != "Samsung Electronics" {
+		t.Errorf("DeviceVendor = %q", r.DeviceVendor)
+	}
+}
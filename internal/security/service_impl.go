@@ -2,6 +2,7 @@ package security
 
 import (
 	"context"
+	"time"
 
 	"network-scanner/internal/audit"
 	"network-scanner/internal/contracts"
@@ -30,6 +31,15 @@ func (s *securityServiceImpl) AnalyzeRun(ctx context.Context, results []contract
 				Service:  p.Service,
 				Banner:   p.Banner,
 				Version:  p.Version,
+				Product:  p.Product,
+				Info:     p.Info,
+				CPE:      p.CPE,
+				TLS:      scanner.FromContractTLS(p.TLS),
+				SSH:      scanner.FromContractSSH(p.SSH),
+				HTTP:     scanner.FromContractHTTP(p.HTTP),
+				SMB:      scanner.FromContractSMB(p.SMB),
+				Reason:   p.Reason,
+				Latency:  p.Latency,
 			})
 		}
 
@@ -41,6 +51,7 @@ func (s *securityServiceImpl) AnalyzeRun(ctx context.Context, results []contract
 			DeviceType:   r.DeviceType,
 			DeviceVendor: r.DeviceVendor,
 			GuessOS:      r.GuessOS,
+			UPnP:         scanner.FromContractUPnP(r.UPnP),
 		})
 	}
 
@@ -56,6 +67,54 @@ func (s *securityServiceImpl) AnalyzeRun(ctx context.Context, results []contract
 		})
 	}
 
+	// Аудит TLS: сертификаты и версии протокола
+	tlsFindings := audit.EvaluateTLS(rawResults, time.Now())
+	tlsAudit := make([]contracts.Finding, 0, len(tlsFindings))
+	for _, f := range tlsFindings {
+		tlsAudit = append(tlsAudit, contracts.Finding{
+			Severity:       f.Severity,
+			Host:           f.Host,
+			Title:          f.Title,
+			Recommendation: f.Recommendation,
+		})
+	}
+
+	// Аудит SSH: алгоритмы и ключи хоста, в том числе общие для нескольких устройств
+	sshFindings := audit.EvaluateSSH(rawResults)
+	sshAudit := make([]contracts.Finding, 0, len(sshFindings))
+	for _, f := range sshFindings {
+		sshAudit = append(sshAudit, contracts.Finding{
+			Severity:       f.Severity,
+			Host:           f.Host,
+			Title:          f.Title,
+			Recommendation: f.Recommendation,
+		})
+	}
+
+	// Аудит SMB: SMBv1 и необязательная подпись
+	smbFindings := audit.EvaluateSMB(rawResults)
+	smbAudit := make([]contracts.Finding, 0, len(smbFindings))
+	for _, f := range smbFindings {
+		smbAudit = append(smbAudit, contracts.Finding{
+			Severity:       f.Severity,
+			Host:           f.Host,
+			Title:          f.Title,
+			Recommendation: f.Recommendation,
+		})
+	}
+
+	// Аудит UPnP: пробросы портов шлюзов IGD
+	upnpFindings := audit.EvaluateUPnP(rawResults)
+	upnpAudit := make([]contracts.Finding, 0, len(upnpFindings))
+	for _, f := range upnpFindings {
+		upnpAudit = append(upnpAudit, contracts.Finding{
+			Severity:       f.Severity,
+			Host:           f.Host,
+			Title:          f.Title,
+			Recommendation: f.Recommendation,
+		})
+	}
+
 	// Risk signatures
 	riskFindings := []risksignature.Finding{}
 	if db, err := risksignature.LoadDefault(); err == nil {
@@ -76,6 +135,18 @@ func (s *securityServiceImpl) AnalyzeRun(ctx context.Context, results []contract
 	for _, f := range portAudit {
 		severityCounts[f.Severity]++
 	}
+	for _, f := range tlsAudit {
+		severityCounts[f.Severity]++
+	}
+	for _, f := range sshAudit {
+		severityCounts[f.Severity]++
+	}
+	for _, f := range smbAudit {
+		severityCounts[f.Severity]++
+	}
+	for _, f := range upnpAudit {
+		severityCounts[f.Severity]++
+	}
 	for _, f := range riskSig {
 		severityCounts[f.Severity]++
 	}
@@ -83,6 +154,10 @@ func (s *securityServiceImpl) AnalyzeRun(ctx context.Context, results []contract
 
 	return &contracts.SecurityReport{
 		PortAudit: portAudit,
+		TLSAudit:  tlsAudit,
+		SSHAudit:  sshAudit,
+		SMBAudit:  smbAudit,
+		UPnPAudit: upnpAudit,
 		RiskSig:   riskSig,
 		Score:     score,
 	}, nil
@@ -0,0 +1,230 @@
+package servicedetect
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DialFunc открывает соединение; совпадает с banner.DialFunc, чтобы сканер подставлял
+// свой диалер (прокси, адрес источника, симулятор).
+type DialFunc func(network, address string, timeout time.Duration) (net.Conn, error)
+
+// Options — настройки активного определения службы.
+type Options struct {
+	Timeout   time.Duration // ожидание ответа на одну пробу; 0 — DefaultTimeout
+	Intensity int           // наибольшая редкость проб без подсказки порта; 0 — DefaultIntensity
+	Dial      DialFunc      // nil — net.DialTimeout
+}
+
+// Result — итог определения службы порта.
+type Result struct {
+	Match
+	Banner string // печатаемая часть распознанного ответа
+}
+
+// tailWait — ожидание продолжения ответа после первой порции данных.
+const tailWait = 150 * time.Millisecond
+
+// maxResponse — наибольший читаемый ответ на пробу.
+const maxResponse = 16 << 10
+
+// tlsServices — имена служб поверх TLS для служб, распознанных в туннеле.
+var tlsServices = map[string]string{
+	"http": "https",
+	"imap": "imaps",
+	"pop3": "pop3s",
+	"smtp": "smtps",
+	"ftp":  "ftps",
+	"ldap": "ldaps",
+}
+
+// Detect отправляет пробы базы на порт port хоста host (protocol — "tcp" или "udp") и
+// возвращает первое жёсткое совпадение; soft-совпадение — если жёсткого нет. Каждая
+// TCP-проба открывает новое соединение; неудачное соединение прекращает определение.
+// Если порт ответил на TLS-пробу рукопожатием, пробы повторяются через TLS, а служба
+// получает имя поверх TLS ("https"). ok=false — порт не распознан.
+func (db *DB) Detect(ctx context.Context, host string, port int, protocol string, opts Options) (Result, bool) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.Intensity <= 0 {
+		opts.Intensity = DefaultIntensity
+	}
+	if opts.Dial == nil {
+		opts.Dial = net.DialTimeout
+	}
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+	switch protocol {
+	case "tcp":
+		return db.detectTCP(ctx, addr, port, opts, false)
+	case "udp":
+		return db.detectUDP(ctx, addr, port, opts)
+	}
+	return Result{}, false
+}
+
+func (db *DB) detectTCP(ctx context.Context, addr string, port int, opts Options, overTLS bool) (Result, bool) {
+	var soft *Result
+	for _, p := range db.probesFor("tcp", port, opts.Intensity) {
+		if ctx.Err() != nil {
+			break
+		}
+		if p.tls {
+			if overTLS || !handshakeTLS(ctx, addr, opts) {
+				continue
+			}
+			if res, ok := db.detectTCP(ctx, addr, port, opts, true); ok {
+				return res, true
+			}
+			return Result{Match: Match{Service: "ssl", Probe: p.name, Soft: true, TLS: true}}, true
+		}
+		resp, err := exchangeTCP(ctx, addr, p.payload, opts, overTLS)
+		if err != nil {
+			break // порт не принимает соединения: остальные пробы тоже не пройдут
+		}
+		match, ok := db.MatchResponse(p.name, resp)
+		if !ok {
+			continue
+		}
+		res := Result{Match: match, Banner: sanitize(resp)}
+		if overTLS {
+			res.TLS = true
+			res.Service = tlsServiceName(res.Service)
+		}
+		if !match.Soft {
+			return res, true
+		}
+		if soft == nil {
+			soft = &res
+		}
+	}
+	if soft != nil {
+		return *soft, true
+	}
+	return Result{}, false
+}
+
+func (db *DB) detectUDP(ctx context.Context, addr string, port int, opts Options) (Result, bool) {
+	var soft *Result
+	for _, p := range db.probesFor("udp", port, opts.Intensity) {
+		if ctx.Err() != nil || len(p.payload) == 0 {
+			continue
+		}
+		resp, err := exchangeUDP(ctx, addr, p.payload, opts)
+		if err != nil || len(resp) == 0 {
+			continue
+		}
+		match, ok := db.MatchResponse(p.name, resp)
+		if !ok {
+			continue
+		}
+		res := Result{Match: match, Banner: sanitize(resp)}
+		if !match.Soft {
+			return res, true
+		}
+		if soft == nil {
+			soft = &res
+		}
+	}
+	if soft != nil {
+		return *soft, true
+	}
+	return Result{}, false
+}
+
+// exchangeTCP открывает соединение, отправляет payload (пустой — только ждёт приветствия)
+// и читает ответ до таймаута или закрытия соединения. Ошибка — только отказ соединения;
+// несостоявшееся TLS-рукопожатие — пустой ответ.
+func exchangeTCP(ctx context.Context, addr string, payload []byte, opts Options, overTLS bool) ([]byte, error) {
+	conn, err := opts.Dial("tcp", addr, opts.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+	if overTLS {
+		tc := tls.Client(conn, &tls.Config{InsecureSkipVerify: true, ServerName: hostOf(addr)})
+		_ = tc.SetDeadline(time.Now().Add(opts.Timeout))
+		if tc.Handshake() != nil {
+			return nil, nil
+		}
+		conn = tc
+	}
+	return exchange(conn, payload, opts.Timeout), nil
+}
+
+func exchangeUDP(ctx context.Context, addr string, payload []byte, opts Options) ([]byte, error) {
+	conn, err := opts.Dial("udp", addr, opts.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+	_ = conn.SetDeadline(time.Now().Add(opts.Timeout))
+	if _, err := conn.Write(payload); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 4096)
+	n, err := conn.Read(buf)
+	return buf[:n], err
+}
+
+// exchange отправляет payload и собирает ответ: первую порцию ждёт timeout, следующие —
+// tailWait, чтобы многострочные приветствия читались целиком.
+func exchange(conn net.Conn, payload []byte, timeout time.Duration) []byte {
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+	if len(payload) > 0 {
+		if _, err := conn.Write(payload); err != nil {
+			return nil
+		}
+	}
+	var resp []byte
+	buf := make([]byte, 4096)
+	for len(resp) < maxResponse {
+		n, err := conn.Read(buf)
+		resp = append(resp, buf[:n]...)
+		if err != nil {
+			break
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(tailWait))
+	}
+	return resp
+}
+
+// handshakeTLS сообщает, что порт принимает TLS-рукопожатие.
+func handshakeTLS(ctx context.Context, addr string, opts Options) bool {
+	conn, err := opts.Dial("tcp", addr, opts.Timeout)
+	if err != nil {
+		return false
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+	tc := tls.Client(conn, &tls.Config{InsecureSkipVerify: true, ServerName: hostOf(addr)})
+	_ = tc.SetDeadline(time.Now().Add(opts.Timeout))
+	return tc.Handshake() == nil
+}
+
+func tlsServiceName(service string) string {
+	if name, ok := tlsServices[service]; ok {
+		return name
+	}
+	if strings.HasPrefix(service, "ssl/") {
+		return service
+	}
+	return "ssl/" + service
+}
+
+func hostOf(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil || net.ParseIP(host) != nil {
+		return ""
+	}
+	return host
+}
@@ -0,0 +1,418 @@
+// Package servicedetect определяет службу, продукт, версию и CPE открытого порта по
+// базе проб и сигнатур независимо от номера порта.
+//
+// База (формат Parse) — версионированный YAML: пробы с полезной нагрузкой для TCP или
+// UDP и сигнатуры-регулярные выражения, чьи группы подставляются в продукт, версию,
+// дополнительные сведения и CPE ($1…$9). Встроенная база — probes/service-probes.v1.yaml;
+// Default возвращает её разобранной.
+package servicedetect
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+
+	apperrors "network-scanner/internal/errors"
+	"network-scanner/internal/network"
+)
+
+//go:embed probes/service-probes.v1.yaml
+var defaultProbesRaw []byte
+
+// DB — разобранная база проб и сигнатур.
+type DB struct {
+	Version string
+	probes  []*probe
+	byName  map[string]*probe
+}
+
+// Match — служба, распознанная по ответу на пробу.
+type Match struct {
+	Service string // имя службы ("ssh", "http", "https" поверх TLS)
+	Product string // продукт ("OpenSSH", "nginx")
+	Version string // версия продукта ("9.3p1")
+	Info    string // дополнительные сведения (ОС, протокол)
+	CPE     string // CPE 2.3 ("cpe:2.3:a:openbsd:openssh:9.3p1:*:*:*:*:*:*:*")
+	Probe   string // проба, ответ на которую распознан
+	Soft    bool   // распознана только служба (сигнатура soft), без продукта
+	TLS     bool   // служба работает поверх TLS
+}
+
+// probe — проба базы.
+type probe struct {
+	name     string
+	protocol string
+	payload  []byte
+	ports    map[int]struct{}
+	rarity   int
+	tls      bool     // проба — TLS-рукопожатие: при успехе остальные пробы идут через TLS
+	fallback []string // пробы, чьи сигнатуры проверяются, если свои не подошли
+	matches  []matcher
+}
+
+// matcher — сигнатура пробы.
+type matcher struct {
+	service string
+	re      *regexp.Regexp
+	product string
+	version string
+	info    string
+	cpe     string
+	soft    bool
+}
+
+type dbFile struct {
+	Version string      `yaml:"version"`
+	Probes  []probeFile `yaml:"probes"`
+}
+
+type probeFile struct {
+	Name     string      `yaml:"name"`
+	Protocol string      `yaml:"protocol"`
+	Payload  string      `yaml:"payload"`
+	Ports    string      `yaml:"ports"`
+	Rarity   int         `yaml:"rarity"`
+	TLS      bool        `yaml:"tls"`
+	Fallback []string    `yaml:"fallback"`
+	Matches  []matchFile `yaml:"matches"`
+}
+
+type matchFile struct {
+	Service string `yaml:"service"`
+	Pattern string `yaml:"pattern"`
+	Product string `yaml:"product"`
+	Version string `yaml:"version"`
+	Info    string `yaml:"info"`
+	CPE     string `yaml:"cpe"`
+	Soft    bool   `yaml:"soft"`
+}
+
+var (
+	embeddedOnce sync.Once
+	embeddedDB   *DB
+	embeddedErr  error
+)
+
+// Default возвращает встроенную базу; разбирается один раз.
+func Default() (*DB, error) {
+	embeddedOnce.Do(func() {
+		embeddedDB, embeddedErr = Parse(defaultProbesRaw)
+	})
+	return embeddedDB, embeddedErr
+}
+
+// Load читает базу из файла (формат Parse).
+func Load(path string) (*DB, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	db, err := Parse(data)
+	if err != nil {
+		return nil, apperrors.WrapErrorf(err, "servicedetect: %s", path)
+	}
+	return db, nil
+}
+
+// Parse разбирает и проверяет базу. Полезная нагрузка — строка, каждый символ которой
+// (U+0000–U+00FF) — один байт: в YAML "\r\n", "\x00" и "\xff" дают байты 0x0d 0x0a,
+// 0x00 и 0xff. Сигнатуры — регулярные выражения regexp (RE2), применяемые к ответу в
+// той же кодировке, поэтому \xNN в выражении совпадает с байтом NN. Неизвестные поля,
+// повтор имени пробы, неизвестная проба в fallback — ошибка InvalidInputError.
+func Parse(data []byte) (*DB, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	var f dbFile
+	if err := dec.Decode(&f); err != nil {
+		return nil, apperrors.NewInvalidInputError("servicedetect", err.Error())
+	}
+	if strings.TrimSpace(f.Version) == "" {
+		return nil, apperrors.NewInvalidInputError("version", "версия базы обязательна")
+	}
+	db := &DB{Version: f.Version, byName: make(map[string]*probe, len(f.Probes))}
+	for i, pf := range f.Probes {
+		field := fmt.Sprintf("probes[%d]", i)
+		if pf.Name == "" {
+			return nil, apperrors.NewInvalidInputError(field+".name", "имя пробы обязательно")
+		}
+		if _, dup := db.byName[pf.Name]; dup {
+			return nil, apperrors.NewInvalidInputError(field+".name", "проба "+pf.Name+" повторяется")
+		}
+		if pf.Protocol != "tcp" && pf.Protocol != "udp" {
+			return nil, apperrors.NewInvalidInputError(field+".protocol", fmt.Sprintf("ожидается tcp или udp, получено %q", pf.Protocol))
+		}
+		if pf.TLS && pf.Protocol != "tcp" {
+			return nil, apperrors.NewInvalidInputError(field+".tls", "TLS-проба возможна только для tcp")
+		}
+		payload, err := latin1Bytes(pf.Payload)
+		if err != nil {
+			return nil, apperrors.NewInvalidInputError(field+".payload", err.Error())
+		}
+		p := &probe{
+			name:     pf.Name,
+			protocol: pf.Protocol,
+			payload:  payload,
+			ports:    make(map[int]struct{}),
+			rarity:   pf.Rarity,
+			tls:      pf.TLS,
+			fallback: pf.Fallback,
+		}
+		if p.rarity <= 0 {
+			p.rarity = 1
+		}
+		if strings.TrimSpace(pf.Ports) != "" {
+			ports, err := network.ParsePortRange(pf.Ports)
+			if err != nil {
+				return nil, apperrors.NewInvalidInputError(field+".ports", err.Error())
+			}
+			for _, port := range ports {
+				if port < 1 || port > 65535 {
+					return nil, apperrors.NewInvalidInputError(field+".ports", fmt.Sprintf("порт %d вне диапазона 1-65535", port))
+				}
+				p.ports[port] = struct{}{}
+			}
+		}
+		for j, mf := range pf.Matches {
+			mfield := fmt.Sprintf("%s.matches[%d]", field, j)
+			if mf.Service == "" {
+				return nil, apperrors.NewInvalidInputError(mfield+".service", "служба обязательна")
+			}
+			re, err := regexp.Compile(mf.Pattern)
+			if err != nil {
+				return nil, apperrors.NewInvalidInputError(mfield+".pattern", err.Error())
+			}
+			p.matches = append(p.matches, matcher{
+				service: mf.Service,
+				re:      re,
+				product: mf.Product,
+				version: mf.Version,
+				info:    mf.Info,
+				cpe:     mf.CPE,
+				soft:    mf.Soft,
+			})
+		}
+		db.probes = append(db.probes, p)
+		db.byName[p.name] = p
+	}
+	for i, p := range db.probes {
+		for _, name := range p.fallback {
+			if _, ok := db.byName[name]; !ok {
+				return nil, apperrors.NewInvalidInputError(fmt.Sprintf("probes[%d].fallback", i), "неизвестная проба "+name)
+			}
+		}
+	}
+	return db, nil
+}
+
+// latin1Bytes переводит строку, каждый символ которой — байт, в байты.
+func latin1Bytes(s string) ([]byte, error) {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r > 0xff {
+			return nil, fmt.Errorf("символ %q вне диапазона байта", r)
+		}
+		out = append(out, byte(r))
+	}
+	return out, nil
+}
+
+// latin1String переводит байты ответа в строку символов U+0000–U+00FF.
+func latin1String(b []byte) string {
+	var sb strings.Builder
+	sb.Grow(len(b))
+	for _, c := range b {
+		sb.WriteRune(rune(c))
+	}
+	return sb.String()
+}
+
+// MatchResponse распознаёт ответ resp на пробу probeName: сначала сигнатуры самой пробы,
+// затем её fallback-проб. Жёсткая сигнатура возвращается сразу; soft — если жёсткой нет.
+func (db *DB) MatchResponse(probeName string, resp []byte) (Match, bool) {
+	p, ok := db.byName[probeName]
+	if !ok || len(resp) == 0 {
+		return Match{}, false
+	}
+	text := latin1String(resp)
+	var soft *Match
+	for _, candidate := range append([]*probe{p}, db.fallbacks(p)...) {
+		for _, m := range candidate.matches {
+			groups := m.re.FindStringSubmatch(text)
+			if groups == nil {
+				continue
+			}
+			match := m.build(groups)
+			match.Probe = p.name
+			if !m.soft {
+				return match, true
+			}
+			if soft == nil {
+				soft = &match
+			}
+		}
+	}
+	if soft != nil {
+		return *soft, true
+	}
+	return Match{}, false
+}
+
+func (db *DB) fallbacks(p *probe) []*probe {
+	out := make([]*probe, 0, len(p.fallback))
+	for _, name := range p.fallback {
+		out = append(out, db.byName[name])
+	}
+	return out
+}
+
+// build подставляет группы сигнатуры в поля совпадения.
+func (m matcher) build(groups []string) Match {
+	match := Match{
+		Service: m.service,
+		Product: expand(m.product, groups),
+		Version: expand(m.version, groups),
+		Info:    expand(m.info, groups),
+		Soft:    m.soft,
+	}
+	if cpe := expand(m.cpe, groups); cpe != "" {
+		match.CPE = normalizeCPE(cpe)
+	}
+	return match
+}
+
+// expand заменяет $1…$9 группами совпадения; непечатаемые символы отбрасываются.
+func expand(template string, groups []string) string {
+	if template == "" {
+		return ""
+	}
+	var sb strings.Builder
+	for i := 0; i < len(template); i++ {
+		c := template[i]
+		if c == '$' && i+1 < len(template) && template[i+1] >= '1' && template[i+1] <= '9' {
+			n := int(template[i+1] - '0')
+			if n < len(groups) {
+				sb.WriteString(printable(groups[n]))
+			}
+			i++
+			continue
+		}
+		sb.WriteByte(c)
+	}
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+// printable оставляет печатаемые ASCII-символы группы.
+func printable(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		if r >= 0x20 && r < 0x7f {
+			sb.WriteRune(r)
+		} else if r == '\t' {
+			sb.WriteByte(' ')
+		}
+	}
+	return sb.String()
+}
+
+// normalizeCPE дополняет сокращённый CPE 2.3 ("cpe:2.3:a:vendor:product:version") до
+// 13 компонентов; пробелы в компонентах заменяются на "_", регистр — нижний.
+func normalizeCPE(cpe string) string {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(cpe)), ":")
+	for i, p := range parts {
+		parts[i] = strings.ReplaceAll(p, " ", "_")
+		if parts[i] == "" {
+			parts[i] = "*"
+		}
+	}
+	for len(parts) < 13 {
+		parts = append(parts, "*")
+	}
+	return strings.Join(parts, ":")
+}
+
+// CPE — компоненты CPE 2.3, по которым сопоставляются уязвимости.
+type CPE struct {
+	Part    string // "a" — приложение, "o" — ОС, "h" — оборудование
+	Vendor  string
+	Product string
+	Version string // "*" или "" — версия не известна
+}
+
+// ParseCPE разбирает CPE 2.3 ("cpe:2.3:a:vendor:product:version:...") или CPE 2.2
+// ("cpe:/a:vendor:product:version"); ok=false — строка не CPE.
+func ParseCPE(s string) (CPE, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	var parts []string
+	switch {
+	case strings.HasPrefix(s, "cpe:2.3:"):
+		parts = strings.Split(strings.TrimPrefix(s, "cpe:2.3:"), ":")
+	case strings.HasPrefix(s, "cpe:/"):
+		parts = strings.Split(strings.TrimPrefix(s, "cpe:/"), ":")
+	default:
+		return CPE{}, false
+	}
+	if len(parts) < 3 || parts[1] == "" || parts[2] == "" {
+		return CPE{}, false
+	}
+	c := CPE{Part: parts[0], Vendor: parts[1], Product: parts[2]}
+	if len(parts) > 3 && parts[3] != "*" && parts[3] != "-" {
+		c.Version = parts[3]
+	}
+	return c, true
+}
+
+// probesFor возвращает пробы протокола в порядке отправки на порт port: пробы без
+// полезной нагрузки (ожидание приветствия), затем пробы, указавшие порт, затем остальные
+// с редкостью не выше intensity по возрастанию редкости.
+func (db *DB) probesFor(protocol string, port int, intensity int) []*probe {
+	var null, hinted, rest []*probe
+	for _, p := range db.probes {
+		if p.protocol != protocol {
+			continue
+		}
+		_, forPort := p.ports[port]
+		switch {
+		case len(p.payload) == 0 && !p.tls:
+			null = append(null, p)
+		case forPort:
+			hinted = append(hinted, p)
+		case p.rarity <= intensity:
+			rest = append(rest, p)
+		}
+	}
+	sort.SliceStable(rest, func(i, j int) bool { return rest[i].rarity < rest[j].rarity })
+	out := append(null, hinted...)
+	return append(out, rest...)
+}
+
+// Probes возвращает имена проб базы в порядке описания (для диагностики и тестов).
+func (db *DB) Probes() []string {
+	out := make([]string, 0, len(db.probes))
+	for _, p := range db.probes {
+		out = append(out, p.name)
+	}
+	return out
+}
+
+// DefaultTimeout — ожидание ответа на одну пробу, если Options.Timeout не задан.
+const DefaultTimeout = 2 * time.Second
+
+// DefaultIntensity — наибольшая редкость проб, отправляемых на порт без подсказки.
+const DefaultIntensity = 7
+
+// sanitize переводит ответ в печатаемую строку для PortInfo.Banner.
+func sanitize(b []byte) string {
+	if utf8.Valid(b) {
+		return strings.Join(strings.Fields(printable(string(b))), " ")
+	}
+	return strings.Join(strings.Fields(printable(latin1String(b))), " ")
+}
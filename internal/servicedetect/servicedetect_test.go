@@ -0,0 +1,261 @@
+package servicedetect
+
+import (
+	"context"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	apperrors "network-scanner/internal/errors"
+)
+
+func defaultDB(t *testing.T) *DB {
+	t.Helper()
+	db, err := Default()
+	if err != nil {
+		t.Fatalf("Default() error = %v", err)
+	}
+	return db
+}
+
+func TestDefault(t *testing.T) {
+	db := defaultDB(t)
+	if db.Version != "service-probes/v1" {
+		t.Errorf("Version = %q", db.Version)
+	}
+	if names := db.Probes(); len(names) == 0 || names[0] != "NULL" {
+		t.Errorf("Probes() = %v", names)
+	}
+	// Пробы отправляются в порядке: приветствие, подсказанные порту, остальные по редкости
+	var order []string
+	for _, p := range db.probesFor("tcp", 6379, DefaultIntensity) {
+		order = append(order, p.name)
+	}
+	if len(order) < 3 || order[0] != "NULL" || order[1] != "RedisInfo" {
+		t.Errorf("probesFor(6379) = %v", order)
+	}
+	for _, p := range db.probesFor("tcp", 2222, 1) {
+		if p.rarity > 1 {
+			t.Errorf("probesFor(2222, 1) содержит %s с редкостью %d", p.name, p.rarity)
+		}
+	}
+}
+
+func TestMatchResponse(t *testing.T) {
+	db := defaultDB(t)
+	tests := []struct {
+		probe, resp string
+		want        Match
+	}{
+		{"NULL", "SSH-2.0-OpenSSH_9.3p1 Ubuntu-1ubuntu3\r\n", Match{
+			Service: "ssh", Product: "OpenSSH", Version: "9.3p1", Info: "protocol 2.0 Ubuntu-1ubuntu3",
+			CPE: "cpe:2.3:a:openbsd:openssh:9.3p1:*:*:*:*:*:*:*",
+		}},
+		{"NULL", "SSH-2.0-dropbear_2022.83\r\n", Match{
+			Service: "ssh", Product: "Dropbear sshd", Version: "2022.83", Info: "protocol 2.0",
+			CPE: "cpe:2.3:a:dropbear_ssh_project:dropbear_ssh:2022.83:*:*:*:*:*:*:*",
+		}},
+		{"NULL", "SSH-2.0-RomSShell_4.31\r\n", Match{Service: "ssh", Info: "protocol 2.0 RomSShell_4.31", Soft: true}},
+		{"NULL", "220 (vsFTPd 2.3.4)\r\n", Match{
+			Service: "ftp", Product: "vsftpd", Version: "2.3.4", CPE: "cpe:2.3:a:vsftpd_project:vsftpd:2.3.4:*:*:*:*:*:*:*",
+		}},
+		{"NULL", "220 mail.example.org ESMTP Exim 4.96 Mon, 01 Jan 2024\r\n", Match{
+			Service: "smtp", Product: "Exim smtpd", Version: "4.96", Info: "host mail.example.org",
+			CPE: "cpe:2.3:a:exim:exim:4.96:*:*:*:*:*:*:*",
+		}},
+		{"NULL", "J\x00\x00\x00\x0a5.5.5-10.6.12-MariaDB-0ubuntu0.22.04.1\x00", Match{
+			Service: "mysql", Product: "MariaDB", Version: "10.6.12", CPE: "cpe:2.3:a:mariadb:mariadb:10.6.12:*:*:*:*:*:*:*",
+		}},
+		{"NULL", "\xff\xfd\x18\xff\xfd\x20", Match{Service: "telnet", Soft: true}},
+		{"GenericLines", "220 ProFTPD 1.3.5 Server (Debian)\r\n", Match{
+			Service: "ftp", Product: "ProFTPD", Version: "1.3.5", CPE: "cpe:2.3:a:proftpd:proftpd:1.3.5:*:*:*:*:*:*:*",
+		}},
+		{"GetRequest", "HTTP/1.1 200 OK\r\nDate: Mon, 01 Jan 2024\r\nServer: Apache/2.4.49 (Unix)\r\n\r\n", Match{
+			Service: "http", Product: "Apache httpd", Version: "2.4.49", Info: "Unix",
+			CPE: "cpe:2.3:a:apache:http_server:2.4.49:*:*:*:*:*:*:*",
+		}},
+		{"GetRequest", "HTTP/1.1 404 Not Found\r\nserver: nginx\r\n\r\n", Match{
+			Service: "http", Product: "nginx", CPE: "cpe:2.3:a:f5:nginx:*:*:*:*:*:*:*:*",
+		}},
+		{"GetRequest", "HTTP/1.0 200 OK\r\nServer: RouterOS\r\n\r\n", Match{Service: "http", Info: "RouterOS", Soft: true}},
+		{"DNSVersionBindReq", "\x00\x06\x85\x80\x00\x01\x00\x01\x00\x00\x00\x00\x07version\x04bind\x00\x00\x10\x00\x03" +
+			"\xc0\x0c\x00\x10\x00\x03\x00\x00\x00\x00\x00\x0e\x0ddnsmasq-2.89", Match{
+			Service: "domain", Product: "dnsmasq", Version: "2.89", CPE: "cpe:2.3:a:thekelleys:dnsmasq:2.89:*:*:*:*:*:*:*",
+		}},
+	}
+	for _, tt := range tests {
+		got, ok := db.MatchResponse(tt.probe, []byte(tt.resp))
+		tt.want.Probe = tt.probe
+		if !ok || got != tt.want {
+			t.Errorf("MatchResponse(%s, %q) = %+v, %v; want %+v", tt.probe, tt.resp, got, ok, tt.want)
+		}
+	}
+	for _, resp := range []string{"", "random noise"} {
+		if got, ok := db.MatchResponse("NULL", []byte(resp)); ok {
+			t.Errorf("MatchResponse(NULL, %q) = %+v", resp, got)
+		}
+	}
+	if _, ok := db.MatchResponse("Unknown", []byte("SSH-2.0-OpenSSH_9.3\r\n")); ok {
+		t.Error("MatchResponse(неизвестная проба) распознал ответ")
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	invalid := map[string]string{
+		"без версии":          "probes: []",
+		"неизвестное поле":    "version: v1\nprobes:\n  - name: a\n    protocol: tcp\n    color: red",
+		"протокол":            "version: v1\nprobes:\n  - name: a\n    protocol: sctp",
+		"повтор":              "version: v1\nprobes:\n  - {name: a, protocol: tcp}\n  - {name: a, protocol: udp}",
+		"выражение":           "version: v1\nprobes:\n  - name: a\n    protocol: tcp\n    matches: [{service: x, pattern: '(?<'}]",
+		"fallback":            "version: v1\nprobes:\n  - {name: a, protocol: tcp, fallback: [b]}",
+		"tls для udp":         "version: v1\nprobes:\n  - {name: a, protocol: udp, tls: true}",
+		"символ вне байта":    "version: v1\nprobes:\n  - {name: a, protocol: tcp, payload: \"\\u0100\"}",
+		"порты":               "version: v1\nprobes:\n  - {name: a, protocol: tcp, ports: '70000'}",
+		"сигнатура без имени": "version: v1\nprobes:\n  - name: a\n    protocol: tcp\n    matches: [{pattern: x}]",
+	}
+	for name, data := range invalid {
+		if _, err := Parse([]byte(data)); !apperrors.IsInvalidInput(err) {
+			t.Errorf("%s: Parse() error = %v, want InvalidInputError", name, err)
+		}
+	}
+}
+
+func TestParseCPE(t *testing.T) {
+	tests := []struct {
+		in   string
+		want CPE
+		ok   bool
+	}{
+		{"cpe:2.3:a:openbsd:openssh:9.3p1:*:*:*:*:*:*:*", CPE{Part: "a", Vendor: "openbsd", Product: "openssh", Version: "9.3p1"}, true},
+		{"cpe:2.3:a:f5:nginx:*:*:*:*:*:*:*:*", CPE{Part: "a", Vendor: "f5", Product: "nginx"}, true},
+		{"cpe:/a:Apache:HTTP_Server:2.4.49", CPE{Part: "a", Vendor: "apache", Product: "http_server", Version: "2.4.49"}, true},
+		{"cpe:2.3:a:openbsd", CPE{}, false},
+		{"OpenSSH_9.3", CPE{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseCPE(tt.in)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("ParseCPE(%q) = %+v, %v; want %+v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// serveTCP принимает соединения и отвечает handle.
+func serveTCP(t *testing.T, handle func(net.Conn)) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				handle(conn)
+			}()
+		}
+	}()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func TestDetect(t *testing.T) {
+	db := defaultDB(t)
+	opts := Options{Timeout: 300 * time.Millisecond}
+
+	// SSH на нестандартном порту распознаётся по приветствию
+	sshPort := serveTCP(t, func(c net.Conn) {
+		c.Write([]byte("SSH-2.0-OpenSSH_8.9p1 Ubuntu-3ubuntu0.1\r\n"))
+		time.Sleep(100 * time.Millisecond)
+	})
+	res, ok := db.Detect(context.Background(), "127.0.0.1", sshPort, "tcp", opts)
+	if !ok || res.Product != "OpenSSH" || res.Version != "8.9p1" || res.Probe != "NULL" {
+		t.Errorf("Detect(ssh) = %+v, %v", res, ok)
+	}
+	if res.Banner != "SSH-2.0-OpenSSH_8.9p1 Ubuntu-3ubuntu0.1" {
+		t.Errorf("Banner = %q", res.Banner)
+	}
+
+	// HTTP молчит до запроса
+	httpSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", "nginx/1.25.2")
+	}))
+	defer httpSrv.Close()
+	httpPort := httpSrv.Listener.Addr().(*net.TCPAddr).Port
+	res, ok = db.Detect(context.Background(), "127.0.0.1", httpPort, "tcp", opts)
+	if !ok || res.Service != "http" || res.Version != "1.25.2" || res.CPE != "cpe:2.3:a:f5:nginx:1.25.2:*:*:*:*:*:*:*" {
+		t.Errorf("Detect(http) = %+v, %v", res, ok)
+	}
+
+	// TLS: пробы повторяются в туннеле
+	tlsSrv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", "Apache/2.4.57 (Debian)")
+	}))
+	tlsSrv.Config.ErrorLog = log.New(io.Discard, "", 0) // открытые текстом пробы рвут рукопожатие
+	tlsSrv.StartTLS()
+	defer tlsSrv.Close()
+	tlsPort := tlsSrv.Listener.Addr().(*net.TCPAddr).Port
+	res, ok = db.Detect(context.Background(), "127.0.0.1", tlsPort, "tcp", opts)
+	if !ok || res.Service != "https" || !res.TLS || res.Product != "Apache httpd" || res.Version != "2.4.57" {
+		t.Errorf("Detect(https) = %+v, %v", res, ok)
+	}
+
+	// Закрытый порт: определение прекращается на первом соединении
+	start := time.Now()
+	var dials int
+	dial := func(network, address string, timeout time.Duration) (net.Conn, error) {
+		dials++
+		return nil, &net.OpError{Op: "dial", Net: network, Err: &net.AddrError{Err: "refused", Addr: address}}
+	}
+	if res, ok := db.Detect(context.Background(), "127.0.0.1", 2222, "tcp", Options{Dial: dial}); ok || dials != 1 {
+		t.Errorf("Detect(refused) = %+v, %v; соединений %d", res, ok, dials)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("Detect(refused) занял %v", time.Since(start))
+	}
+
+	// Отменённый контекст
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if res, ok := db.Detect(ctx, "127.0.0.1", sshPort, "tcp", opts); ok {
+		t.Errorf("Detect(cancelled) = %+v", res)
+	}
+}
+
+func TestDetectUDP(t *testing.T) {
+	db := defaultDB(t)
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket() error = %v", err)
+	}
+	defer pc.Close()
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if n < 12 || string(buf[12:20]) != "\x07version" {
+				continue // отвечаем только на запрос version.bind
+			}
+			answer := append([]byte{}, buf[:n]...)
+			answer[2], answer[3], answer[7] = 0x85, 0x80, 1
+			answer = append(answer, "\xc0\x0c\x00\x10\x00\x03\x00\x00\x00\x00\x00\x07\x069.18.4"...)
+			pc.WriteTo(answer, addr)
+		}
+	}()
+	port := pc.LocalAddr().(*net.UDPAddr).Port
+	res, ok := db.Detect(context.Background(), "127.0.0.1", port, "udp", Options{Timeout: 200 * time.Millisecond, Intensity: 1})
+	if !ok || res.Product != "ISC BIND" || res.Version != "9.18.4" || res.Probe != "DNSVersionBindReq" {
+		t.Errorf("Detect(udp) = %+v, %v", res, ok)
+	}
+}
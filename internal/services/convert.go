@@ -18,16 +18,31 @@ func ConvertToInternalResults(results []contracts.ScanResult) []scanner.Result {
 				Service:  p.Service,
 				Banner:   p.Banner,
 				Version:  p.Version,
+				Product:  p.Product,
+				Info:     p.Info,
+				CPE:      p.CPE,
+				TLS:      scanner.FromContractTLS(p.TLS),
+				SSH:      scanner.FromContractSSH(p.SSH),
+				HTTP:     scanner.FromContractHTTP(p.HTTP),
+				SMB:      scanner.FromContractSMB(p.SMB),
+				Reason:   p.Reason,
+				Latency:  p.Latency,
 			})
 		}
 		out = append(out, scanner.Result{
-			IP:           r.IP,
-			Hostname:     r.Hostname,
-			MAC:          r.MAC,
-			Ports:        ports,
-			DeviceType:   r.DeviceType,
-			DeviceVendor: r.DeviceVendor,
-			GuessOS:      r.GuessOS,
+			IP:              r.IP,
+			Hostname:        r.Hostname,
+			MAC:             r.MAC,
+			Ports:           ports,
+			DeviceType:      r.DeviceType,
+			DeviceVendor:    r.DeviceVendor,
+			GuessOS:         r.GuessOS,
+			DiscoveryMethod: r.DiscoveryMethod,
+			Addresses:       r.Addresses,
+			Via:             r.Via,
+			NetBIOS:         scanner.FromContractNetBIOS(r.NetBIOS),
+			MDNS:            scanner.FromContractMDNS(r.MDNS),
+			UPnP:            scanner.FromContractUPnP(r.UPnP),
 		})
 	}
 	return out
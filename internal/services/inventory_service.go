@@ -16,6 +16,10 @@ type InventoryService struct {
 }
 
 func (s *InventoryService) SaveSnapshot(ctx context.Context, id string, data []contracts.ScanResult) error {
+	return s.SaveSnapshotWithMetadata(ctx, id, data, contracts.ScanMetadata{})
+}
+
+func (s *InventoryService) SaveSnapshotWithMetadata(ctx context.Context, id string, data []contracts.ScanResult, meta contracts.ScanMetadata) error {
 	// Конвертация в internal формат
 	internalResults := make([]scanner.Result, 0, len(data))
 	for _, r := range data {
@@ -28,17 +32,32 @@ func (s *InventoryService) SaveSnapshot(ctx context.Context, id string, data []c
 				Service:  p.Service,
 				Banner:   p.Banner,
 				Version:  p.Version,
+				Product:  p.Product,
+				Info:     p.Info,
+				CPE:      p.CPE,
+				TLS:      scanner.FromContractTLS(p.TLS),
+				SSH:      scanner.FromContractSSH(p.SSH),
+				HTTP:     scanner.FromContractHTTP(p.HTTP),
+				SMB:      scanner.FromContractSMB(p.SMB),
+				Reason:   p.Reason,
+				Latency:  p.Latency,
 			})
 		}
 
 		internalResults = append(internalResults, scanner.Result{
-			IP:           r.IP,
-			Hostname:     r.Hostname,
-			MAC:          r.MAC,
-			Ports:        ports,
-			DeviceType:   r.DeviceType,
-			DeviceVendor: r.DeviceVendor,
-			GuessOS:      r.GuessOS,
+			IP:              r.IP,
+			Hostname:        r.Hostname,
+			MAC:             r.MAC,
+			Ports:           ports,
+			DeviceType:      r.DeviceType,
+			DeviceVendor:    r.DeviceVendor,
+			GuessOS:         r.GuessOS,
+			DiscoveryMethod: r.DiscoveryMethod,
+			Addresses:       r.Addresses,
+			Via:             r.Via,
+			NetBIOS:         scanner.FromContractNetBIOS(r.NetBIOS),
+			MDNS:            scanner.FromContractMDNS(r.MDNS),
+			UPnP:            scanner.FromContractUPnP(r.UPnP),
 		})
 	}
 
@@ -48,7 +67,13 @@ func (s *InventoryService) SaveSnapshot(ctx context.Context, id string, data []c
 	}
 	defer store.Close()
 
-	if err := store.SaveSnapshot(id, time.Now().UTC(), internalResults); err != nil {
+	storeMeta := inventory.ScanMetadata{
+		Targets:      meta.Targets,
+		Exclude:      meta.Exclude,
+		ExcludePorts: meta.ExcludePorts,
+		Proxy:        meta.Proxy,
+	}
+	if err := store.SaveSnapshotWithMetadata(id, time.Now().UTC(), internalResults, storeMeta); err != nil {
 		return fmt.Errorf("сохранение снапшота: %w", err)
 	}
 
@@ -106,6 +131,14 @@ func (s *InventoryService) Diff(ctx context.Context, idA, idB string) (*contract
 		})
 	}
 
+	excludedResults := make([]contracts.ScanResult, 0, len(diff.Excluded))
+	for _, r := range diff.Excluded {
+		excludedResults = append(excludedResults, contracts.ScanResult{
+			IP:       r.IP,
+			Hostname: r.Hostname,
+		})
+	}
+
 	changedList := make([]contracts.Change, 0, len(diff.Changed))
 	for _, c := range diff.Changed {
 		changedList = append(changedList, contracts.Change{
@@ -115,10 +148,11 @@ func (s *InventoryService) Diff(ctx context.Context, idA, idB string) (*contract
 	}
 
 	return &contracts.Diff{
-		ScanIDA: diff.ScanIDA,
-		ScanIDB: diff.ScanIDB,
-		New:     newResults,
-		Missing: missingResults,
-		Changed: changedList,
+		ScanIDA:  diff.ScanIDA,
+		ScanIDB:  diff.ScanIDB,
+		New:      newResults,
+		Missing:  missingResults,
+		Excluded: excludedResults,
+		Changed:  changedList,
 	}, nil
 }
@@ -159,9 +159,15 @@ func TestConvertToInternalResults(t *testing.T) {
 				{Port: 80, State: "open", Protocol: "tcp", Service: "http", Banner: "Apache", Version: "2.4"},
 				{Port: 443, State: "open", Protocol: "tcp", Service: "https"},
 			},
-			DeviceType:   "server",
-			DeviceVendor: "Dell",
-			GuessOS:      "Linux",
+			DeviceType:      "server",
+			DeviceVendor:    "Dell",
+			GuessOS:         "Linux",
+			DiscoveryMethod: "arp",
+			Addresses:       []string{"192.168.1.1", "fe80::1"},
+			Via:             "socks5://10.0.0.9:1080",
+			NetBIOS:         &contracts.NetBIOSInfo{Name: "TEST-HOST", Workgroup: "WORKGROUP"},
+			MDNS:            &contracts.MDNSHost{Hostname: "test-host.local"},
+			UPnP:            []contracts.UPnPDevice{{Location: "http://192.168.1.1:5000/rootDesc.xml", ModelName: "R7000"}},
 		},
 	}
 
@@ -187,6 +193,24 @@ func TestConvertToInternalResults(t *testing.T) {
 	if r.DeviceType != "server" {
 		t.Fatalf("expected DeviceType 'server', got '%s'", r.DeviceType)
 	}
+	if r.DiscoveryMethod != "arp" {
+		t.Fatalf("expected DiscoveryMethod 'arp', got '%s'", r.DiscoveryMethod)
+	}
+	if len(r.Addresses) != 2 || r.Addresses[1] != "fe80::1" {
+		t.Fatalf("expected Addresses [192.168.1.1 fe80::1], got %v", r.Addresses)
+	}
+	if r.Via != "socks5://10.0.0.9:1080" {
+		t.Fatalf("expected Via 'socks5://10.0.0.9:1080', got '%s'", r.Via)
+	}
+	if r.NetBIOS == nil || r.NetBIOS.Name != "TEST-HOST" {
+		t.Fatalf("expected NetBIOS name 'TEST-HOST', got %+v", r.NetBIOS)
+	}
+	if r.MDNS == nil || r.MDNS.Hostname != "test-host.local" {
+		t.Fatalf("expected mDNS hostname 'test-host.local', got %+v", r.MDNS)
+	}
+	if len(r.UPnP) != 1 || r.UPnP[0].ModelName != "R7000" {
+		t.Fatalf("expected UPnP device 'R7000', got %+v", r.UPnP)
+	}
 }
 
 func TestConvertToInternalResults_Empty(t *testing.T) {
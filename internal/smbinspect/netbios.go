@@ -0,0 +1,174 @@
+package smbinspect
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NetBIOSPort — порт службы имён NetBIOS (UDP).
+const NetBIOSPort = 137
+
+// NetBIOS — таблица имён NetBIOS хоста из ответа NBSTAT (RFC 1002, 4.2.18).
+type NetBIOS struct {
+	Name      string        `json:"name"`                // имя компьютера
+	Workgroup string        `json:"workgroup,omitempty"` // рабочая группа или домен
+	MAC       string        `json:"mac,omitempty"`       // адрес адаптера из статистики; пусто — нули (Samba)
+	Names     []NetBIOSName `json:"names,omitempty"`     // все зарегистрированные имена
+}
+
+// NetBIOSName — запись таблицы имён.
+type NetBIOSName struct {
+	Name   string `json:"name"`
+	Suffix byte   `json:"suffix"`          // тип имени: 0x00 рабочая станция, 0x20 файловый сервер, 0x1C контроллер домена
+	Group  bool   `json:"group,omitempty"` // групповое имя (рабочая группа, домен)
+}
+
+// DomainController сообщает, что хост зарегистрировал групповое имя контроллера домена (0x1C).
+func (n *NetBIOS) DomainController() bool {
+	if n == nil {
+		return false
+	}
+	for _, name := range n.Names {
+		if name.Group && name.Suffix == 0x1C {
+			return true
+		}
+	}
+	return false
+}
+
+// Summary кратко описывает таблицу имён для текстового вывода ("PC01 (WORKGROUP)").
+func (n *NetBIOS) Summary() string {
+	if n == nil {
+		return ""
+	}
+	if n.Workgroup == "" {
+		return n.Name
+	}
+	return n.Name + " (" + n.Workgroup + ")"
+}
+
+var errNotNBSTAT = errors.New("netbios: не ответ NBSTAT")
+
+// QueryNetBIOS отправляет на UDP-порт port хоста host запрос NBSTAT имени "*" и
+// разбирает таблицу имён. Ошибка — ответа нет или он не относится к запросу.
+func QueryNetBIOS(ctx context.Context, host string, port int, opts Options) (*NetBIOS, error) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.Dial == nil {
+		opts.Dial = net.DialTimeout
+	}
+	conn, err := opts.Dial("udp", net.JoinHostPort(host, strconv.Itoa(port)), opts.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+	_ = conn.SetDeadline(time.Now().Add(opts.Timeout))
+
+	req, id := nbstatQuery()
+	if _, err := conn.Write(req); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 1500)
+	// Чужие датаграммы (ответы на прежние запросы) пропускаются до истечения срока
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		if nb, err := parseNBSTAT(buf[:n], id); err == nil {
+			return nb, nil
+		}
+	}
+}
+
+// nbstatQuery собирает запрос NBSTAT имени "*" (RFC 1002, 4.2.17) и возвращает его с
+// идентификатором транзакции.
+func nbstatQuery() ([]byte, uint16) {
+	var rnd [2]byte
+	_, _ = rand.Read(rnd[:])
+	id := binary.BigEndian.Uint16(rnd[:])
+	req := make([]byte, 12, 50)
+	binary.BigEndian.PutUint16(req[0:], id)
+	binary.BigEndian.PutUint16(req[4:], 1) // QDCOUNT
+	// Имя "*" в кодировке first-level NetBIOS: '*' + 15 нулей, каждый полубайт + 'A'
+	name := make([]byte, 16)
+	name[0] = '*'
+	req = append(req, 32)
+	for _, c := range name {
+		req = append(req, 'A'+c>>4, 'A'+c&0x0f)
+	}
+	req = append(req, 0, 0x00, 0x21, 0x00, 0x01) // NBSTAT, IN
+	return req, id
+}
+
+// parseNBSTAT разбирает ответ NBSTAT с идентификатором id: таблицу имён и MAC из
+// статистики. Имя компьютера — первое уникальное имя с суффиксом 0x00 или 0x20, рабочая
+// группа — первое групповое имя с суффиксом 0x00.
+func parseNBSTAT(resp []byte, id uint16) (*NetBIOS, error) {
+	if len(resp) < 12 || binary.BigEndian.Uint16(resp) != id || resp[2]&0x80 == 0 || binary.BigEndian.Uint16(resp[6:]) == 0 {
+		return nil, errNotNBSTAT
+	}
+	// Имя записи ответа: метки или указатель сжатия
+	off := 12
+	for off < len(resp) {
+		l := int(resp[off])
+		if l == 0 {
+			off++
+			break
+		}
+		if l&0xC0 == 0xC0 {
+			off += 2
+			break
+		}
+		off += 1 + l
+	}
+	// TYPE, CLASS, TTL, RDLENGTH, затем число имён
+	if off+11 > len(resp) || binary.BigEndian.Uint16(resp[off:]) != 0x21 {
+		return nil, errNotNBSTAT
+	}
+	off += 10
+	count := int(resp[off])
+	off++
+	nb := &NetBIOS{}
+	for i := 0; i < count; i++ {
+		if off+18 > len(resp) {
+			return nil, errNotNBSTAT
+		}
+		entry := NetBIOSName{
+			Name:   strings.ToValidUTF8(strings.TrimRight(string(resp[off:off+15]), " \x00"), "?"),
+			Suffix: resp[off+15],
+			Group:  binary.BigEndian.Uint16(resp[off+16:])&0x8000 != 0,
+		}
+		nb.Names = append(nb.Names, entry)
+		switch {
+		case !entry.Group && nb.Name == "" && (entry.Suffix == 0x00 || entry.Suffix == 0x20):
+			nb.Name = entry.Name
+		case entry.Group && nb.Workgroup == "" && entry.Suffix == 0x00:
+			nb.Workgroup = entry.Name
+		}
+		off += 18
+	}
+	if off+6 <= len(resp) {
+		if mac := net.HardwareAddr(resp[off : off+6]); strings.Trim(string(mac), "\x00") != "" {
+			nb.MAC = mac.String()
+		}
+	}
+	if nb.Name == "" {
+		for _, n := range nb.Names {
+			if !n.Group {
+				nb.Name = n.Name
+				break
+			}
+		}
+	}
+	return nb, nil
+}
@@ -0,0 +1,90 @@
+package smbinspect
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"slices"
+	"testing"
+	"time"
+)
+
+// nbstatResponse собирает ответ NBSTAT на запрос req с таблицей names и MAC.
+func nbstatResponse(req []byte, names []NetBIOSName, mac net.HardwareAddr) []byte {
+	resp := make([]byte, 12)
+	copy(resp, req[:2])
+	resp[2] = 0x84                                 // ответ, авторитетный
+	binary.BigEndian.PutUint16(resp[6:], 1)        // ANCOUNT
+	resp = append(resp, req[12:12+34]...)          // имя из запроса
+	resp = append(resp, 0, 0x21, 0, 1, 0, 0, 0, 0) // NBSTAT, IN, TTL
+	rdata := []byte{byte(len(names))}
+	for _, n := range names {
+		entry := make([]byte, 18)
+		copy(entry, n.Name+"               ")
+		entry[15] = n.Suffix
+		flags := uint16(0x0400) // ACTIVE
+		if n.Group {
+			flags |= 0x8000
+		}
+		binary.BigEndian.PutUint16(entry[16:], flags)
+		rdata = append(rdata, entry...)
+	}
+	rdata = append(rdata, mac...)
+	rdata = append(rdata, make([]byte, 40)...) // остаток статистики
+	resp = binary.BigEndian.AppendUint16(resp, uint16(len(rdata)))
+	return append(resp, rdata...)
+}
+
+func TestQueryNetBIOS(t *testing.T) {
+	names := []NetBIOSName{
+		{Name: "PC01", Suffix: 0x00},
+		{Name: "CORP", Suffix: 0x00, Group: true},
+		{Name: "PC01", Suffix: 0x20},
+		{Name: "CORP", Suffix: 0x1C, Group: true},
+	}
+	mac := net.HardwareAddr{0x00, 0x15, 0x5d, 0x01, 0x02, 0x03}
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+	go func() {
+		buf := make([]byte, 512)
+		n, addr, err := pc.ReadFrom(buf)
+		if err != nil || n < 50 {
+			return
+		}
+		// Сначала чужой ответ, затем настоящий
+		stale := nbstatResponse(buf[:n], names[:1], mac)
+		stale[0] ^= 0xff
+		_, _ = pc.WriteTo(stale, addr)
+		_, _ = pc.WriteTo(nbstatResponse(buf[:n], names, mac), addr)
+	}()
+	port := pc.LocalAddr().(*net.UDPAddr).Port
+
+	nb, err := QueryNetBIOS(context.Background(), "127.0.0.1", port, Options{Timeout: 2 * time.Second})
+	if err != nil {
+		t.Fatalf("QueryNetBIOS: %v", err)
+	}
+	if nb.Name != "PC01" || nb.Workgroup != "CORP" || nb.MAC != "00:15:5d:01:02:03" {
+		t.Errorf("NetBIOS = %+v", nb)
+	}
+	if !slices.Equal(nb.Names, names) || !nb.DomainController() || nb.Summary() != "PC01 (CORP)" {
+		t.Errorf("names=%+v dc=%v summary=%q", nb.Names, nb.DomainController(), nb.Summary())
+	}
+}
+
+func TestParseNBSTATZeroMAC(t *testing.T) {
+	req, id := nbstatQuery()
+	resp := nbstatResponse(req, []NetBIOSName{{Name: "__MSBROWSE__", Suffix: 0x01, Group: true}, {Name: "NAS", Suffix: 0x20}}, make(net.HardwareAddr, 6))
+	nb, err := parseNBSTAT(resp, id)
+	if err != nil {
+		t.Fatalf("parseNBSTAT: %v", err)
+	}
+	if nb.Name != "NAS" || nb.Workgroup != "" || nb.MAC != "" {
+		t.Errorf("NetBIOS = %+v", nb)
+	}
+	if _, err := parseNBSTAT(resp, id+1); err == nil {
+		t.Error("ответ с чужим идентификатором принят")
+	}
+}
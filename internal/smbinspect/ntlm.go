@@ -0,0 +1,143 @@
+package smbinspect
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"unicode/utf16"
+)
+
+// ntlmSignature открывает каждое сообщение NTLMSSP (MS-NLMP, 2.2.1).
+var ntlmSignature = []byte("NTLMSSP\x00")
+
+// Флаги NTLMSSP NEGOTIATE (MS-NLMP, 2.2.2.5): Unicode и OEM, запрос имени цели и
+// TARGET_INFO, NTLM, расширенная сессионная безопасность, версия, 128 и 56 бит.
+const (
+	ntlmNegotiateVersion = 0x02000000
+	ntlmNegotiateFlags   = 0xA2888207
+)
+
+// Идентификаторы AV_PAIR в TargetInfo (MS-NLMP, 2.2.2.1).
+const (
+	avEOL             = 0
+	avNbComputerName  = 1
+	avNbDomainName    = 2
+	avDNSComputerName = 3
+	avDNSDomainName   = 4
+)
+
+// OID механизмов SPNEGO (1.3.6.1.5.5.2) и NTLMSSP (1.3.6.1.4.1.311.2.2.10) в DER.
+var (
+	oidSPNEGO  = []byte{0x2b, 0x06, 0x01, 0x05, 0x05, 0x02}
+	oidNTLMSSP = []byte{0x2b, 0x06, 0x01, 0x04, 0x01, 0x82, 0x37, 0x02, 0x02, 0x0a}
+)
+
+// ntlmInfo — сведения о сервере из NTLM CHALLENGE.
+type ntlmInfo struct {
+	computer    string
+	domain      string
+	dnsComputer string
+	dnsDomain   string
+	version     string
+}
+
+func (n ntlmInfo) apply(info *Info) {
+	info.ComputerName = n.computer
+	info.Domain = n.domain
+	info.DNSComputerName = n.dnsComputer
+	info.DNSDomain = n.dnsDomain
+	info.OSVersion = n.version
+}
+
+// ntlmNegotiate собирает NTLMSSP NEGOTIATE без имён домена и рабочей станции
+// (MS-NLMP, 2.2.1.1).
+func ntlmNegotiate() []byte {
+	b := make([]byte, 40)
+	copy(b, ntlmSignature)
+	binary.LittleEndian.PutUint32(b[8:], 1) // NtLmNegotiate
+	binary.LittleEndian.PutUint32(b[12:], ntlmNegotiateFlags)
+	copy(b[32:], []byte{6, 1, 0xb1, 0x1d, 0, 0, 0, 15}) // Version: 6.1.7601, NTLMSSP_REVISION_W2K3
+	return b
+}
+
+// spnegoInit заворачивает маркер NTLMSSP в NegTokenInit SPNEGO (RFC 4178, 4.2.1).
+func spnegoInit(mechToken []byte) []byte {
+	mechTypes := der(0x30, der(0x06, oidNTLMSSP))
+	negTokenInit := der(0x30, der(0xa0, mechTypes), der(0xa2, der(0x04, mechToken)))
+	return der(0x60, der(0x06, oidSPNEGO), der(0xa0, negTokenInit))
+}
+
+// der собирает элемент DER с тегом tag и содержимым parts.
+func der(tag byte, parts ...[]byte) []byte {
+	n := 0
+	for _, p := range parts {
+		n += len(p)
+	}
+	b := []byte{tag}
+	switch {
+	case n < 0x80:
+		b = append(b, byte(n))
+	case n < 0x100:
+		b = append(b, 0x81, byte(n))
+	default:
+		b = append(b, 0x82, byte(n>>8), byte(n))
+	}
+	for _, p := range parts {
+		b = append(b, p...)
+	}
+	return b
+}
+
+// parseChallenge находит в ответе SESSION_SETUP сообщение NTLMSSP CHALLENGE (MS-NLMP,
+// 2.2.1.2) и извлекает версию ОС и имена из TargetInfo. Маркер SPNEGO не разбирается:
+// сообщение ищется по сигнатуре.
+func parseChallenge(buf []byte) (ntlmInfo, bool) {
+	var n ntlmInfo
+	i := bytes.Index(buf, ntlmSignature)
+	if i < 0 {
+		return n, false
+	}
+	m := buf[i:]
+	if len(m) < 48 || binary.LittleEndian.Uint32(m[8:]) != 2 {
+		return n, false
+	}
+	flags := binary.LittleEndian.Uint32(m[20:])
+	if flags&ntlmNegotiateVersion != 0 && len(m) >= 56 && m[48] != 0 {
+		n.version = fmt.Sprintf("%d.%d.%d", m[48], m[49], binary.LittleEndian.Uint16(m[50:]))
+	}
+	infoLen := int(binary.LittleEndian.Uint16(m[40:]))
+	infoOff := int(binary.LittleEndian.Uint32(m[44:]))
+	if infoLen == 0 || infoOff+infoLen > len(m) {
+		return n, true
+	}
+	av := m[infoOff : infoOff+infoLen]
+	for len(av) >= 4 {
+		id := binary.LittleEndian.Uint16(av)
+		size := int(binary.LittleEndian.Uint16(av[2:]))
+		if id == avEOL || 4+size > len(av) {
+			break
+		}
+		value := decodeUTF16(av[4 : 4+size])
+		switch id {
+		case avNbComputerName:
+			n.computer = value
+		case avNbDomainName:
+			n.domain = value
+		case avDNSComputerName:
+			n.dnsComputer = value
+		case avDNSDomainName:
+			n.dnsDomain = value
+		}
+		av = av[4+size:]
+	}
+	return n, true
+}
+
+// decodeUTF16 декодирует строку UTF-16LE.
+func decodeUTF16(b []byte) string {
+	u := make([]uint16, len(b)/2)
+	for i := range u {
+		u[i] = binary.LittleEndian.Uint16(b[2*i:])
+	}
+	return string(utf16.Decode(u))
+}
@@ -0,0 +1,417 @@
+// Package smbinspect собирает сведения о Windows-хосте без аутентификации: таблицу имён
+// NetBIOS (NBSTAT, UDP 137) и согласование SMB (TCP 445) — диалекты SMB2/3, поддержку
+// SMBv1, требования к подписи, GUID сервера, а также имя, домен и версию ОС из NTLM
+// CHALLENGE, который сервер присылает на первый шаг SESSION_SETUP.
+package smbinspect
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DialFunc открывает соединение; совпадает с banner.DialFunc, чтобы сканер подставлял
+// свой диалер (прокси, адрес источника, симулятор).
+type DialFunc func(network, address string, timeout time.Duration) (net.Conn, error)
+
+// DefaultTimeout — ожидание одного соединения или ответа по умолчанию.
+const DefaultTimeout = 3 * time.Second
+
+// maxMessage — наибольшее принимаемое сообщение SMB.
+const maxMessage = 64 << 10
+
+// Options — настройки опроса.
+type Options struct {
+	Timeout time.Duration // ожидание соединения и ответа; 0 — DefaultTimeout
+	Dial    DialFunc      // nil — net.DialTimeout
+}
+
+// Info — сведения SMB-сервера.
+type Info struct {
+	Dialects        []string `json:"dialects,omitempty"`          // принимаемые диалекты SMB2/3 по возрастанию ("2.0.2", "3.1.1")
+	SMB1            bool     `json:"smb1"`                        // сервер согласует SMBv1 (NT LM 0.12)
+	SigningEnabled  bool     `json:"signing_enabled"`             // сервер поддерживает подпись
+	SigningRequired bool     `json:"signing_required"`            // сервер требует подпись
+	ServerGUID      string   `json:"server_guid,omitempty"`       // из ответа NEGOTIATE SMB2
+	ComputerName    string   `json:"computer_name,omitempty"`     // NetBIOS-имя из NTLM
+	Domain          string   `json:"domain,omitempty"`            // NetBIOS-имя домена или рабочей группы из NTLM
+	DNSComputerName string   `json:"dns_computer_name,omitempty"` // полное DNS-имя из NTLM
+	DNSDomain       string   `json:"dns_domain,omitempty"`        // DNS-имя домена из NTLM
+	OSVersion       string   `json:"os_version,omitempty"`        // версия ОС из NTLM ("10.0.19045")
+}
+
+// MaxDialect возвращает старший принимаемый диалект SMB2/3; "" — только SMBv1.
+func (i *Info) MaxDialect() string {
+	if i == nil || len(i.Dialects) == 0 {
+		return ""
+	}
+	return i.Dialects[len(i.Dialects)-1]
+}
+
+// Hostname возвращает имя хоста из NTLM: полное DNS-имя, иначе NetBIOS-имя.
+func (i *Info) Hostname() string {
+	if i == nil {
+		return ""
+	}
+	if i.DNSComputerName != "" {
+		return i.DNSComputerName
+	}
+	return i.ComputerName
+}
+
+// WindowsVersion разбирает OSVersion на старший и младший номер и номер сборки.
+func (i *Info) WindowsVersion() (major, minor, build int, ok bool) {
+	if i == nil {
+		return 0, 0, 0, false
+	}
+	parts := strings.Split(i.OSVersion, ".")
+	if len(parts) != 3 {
+		return 0, 0, 0, false
+	}
+	var nums [3]int
+	for k, s := range parts {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return 0, 0, 0, false
+		}
+		nums[k] = n
+	}
+	return nums[0], nums[1], nums[2], true
+}
+
+// Summary кратко описывает сервер для текстового вывода
+// ("SMB 2.0.2–3.1.1, SMBv1, подпись не обязательна, ОС 10.0.19045").
+func (i *Info) Summary() string {
+	if i == nil {
+		return ""
+	}
+	var parts []string
+	switch len(i.Dialects) {
+	case 0:
+	case 1:
+		parts = append(parts, "SMB "+i.Dialects[0])
+	default:
+		parts = append(parts, "SMB "+i.Dialects[0]+"–"+i.MaxDialect())
+	}
+	if i.SMB1 {
+		parts = append(parts, "SMBv1")
+	}
+	if i.SigningRequired {
+		parts = append(parts, "подпись обязательна")
+	} else {
+		parts = append(parts, "подпись не обязательна")
+	}
+	if i.OSVersion != "" {
+		parts = append(parts, "ОС "+i.OSVersion)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// dialect — диалект SMB2/3 и его код в NEGOTIATE.
+type dialect struct {
+	code uint16
+	name string
+}
+
+// dialects — диалекты SMB2/3 по возрастанию (MS-SMB2, 2.2.3).
+var dialects = []dialect{
+	{0x0202, "2.0.2"},
+	{0x0210, "2.1"},
+	{0x0300, "3.0"},
+	{0x0302, "3.0.2"},
+	{0x0311, "3.1.1"},
+}
+
+const dialect311 = 0x0311
+
+// Коды и флаги SMB2 (MS-SMB2, 2.2).
+const (
+	smb2HeaderSize = 64
+
+	cmdNegotiate    = 0x0000
+	cmdSessionSetup = 0x0001
+
+	statusSuccess                = 0x00000000
+	statusMoreProcessingRequired = 0xC0000016
+
+	securitySigningEnabled  = 0x0001
+	securitySigningRequired = 0x0002
+
+	contextPreauthIntegrity = 0x0001
+	hashSHA512              = 0x0001
+)
+
+var (
+	smb2Magic = []byte{0xFE, 'S', 'M', 'B'}
+	smb1Magic = []byte{0xFF, 'S', 'M', 'B'}
+)
+
+var errNotSMB = errors.New("smb: не ответ SMB")
+
+// Inspect опрашивает SMB-сервер на порту port хоста host. Первое соединение предлагает
+// все диалекты SMB2/3: ответ даёт старший диалект, режим подписи и GUID сервера, а
+// анонимный SESSION_SETUP с NTLMSSP NEGOTIATE — CHALLENGE с именем, доменом и версией ОС.
+// Затем каждый младший диалект проверяется отдельным соединением, и последним — SMBv1.
+// Ошибка — сервер не согласовал ни SMB2/3, ни SMBv1.
+func Inspect(ctx context.Context, host string, port int, opts Options) (*Info, error) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.Dial == nil {
+		opts.Dial = net.DialTimeout
+	}
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+
+	info := &Info{}
+	codes := make([]uint16, 0, len(dialects))
+	for _, d := range dialects {
+		codes = append(codes, d.code)
+	}
+	neg, err := negotiate(ctx, addr, codes, true, opts)
+	if err == nil {
+		info.SigningEnabled = neg.securityMode&securitySigningEnabled != 0
+		info.SigningRequired = neg.securityMode&securitySigningRequired != 0
+		info.ServerGUID = formatGUID(neg.guid)
+		neg.ntlm.apply(info)
+		for _, d := range dialects {
+			if d.code > neg.dialect || ctx.Err() != nil {
+				break
+			}
+			if d.code == neg.dialect {
+				info.Dialects = append(info.Dialects, d.name)
+				break
+			}
+			if _, err := negotiate(ctx, addr, []uint16{d.code}, false, opts); err == nil {
+				info.Dialects = append(info.Dialects, d.name)
+			}
+		}
+	}
+	if ctx.Err() == nil {
+		if mode, ok := probeSMB1(ctx, addr, opts); ok {
+			info.SMB1 = true
+			if len(info.Dialects) == 0 {
+				info.SigningEnabled = mode&smb1SigningEnabled != 0
+				info.SigningRequired = mode&smb1SigningRequired != 0
+			}
+		}
+	}
+	if len(info.Dialects) == 0 && !info.SMB1 {
+		if err == nil {
+			err = errNotSMB
+		}
+		return nil, err
+	}
+	return info, nil
+}
+
+// negotiateResult — разобранный ответ NEGOTIATE и, если запрошен, CHALLENGE NTLM.
+type negotiateResult struct {
+	dialect      uint16
+	securityMode uint16
+	guid         [16]byte
+	ntlm         ntlmInfo
+}
+
+// negotiate открывает соединение и согласует один из диалектов codes; с session
+// дополнительно отправляет SESSION_SETUP с NTLMSSP NEGOTIATE и разбирает CHALLENGE.
+// Ошибка — сервер не ответил успешным NEGOTIATE SMB2.
+func negotiate(ctx context.Context, addr string, codes []uint16, session bool, opts Options) (negotiateResult, error) {
+	var res negotiateResult
+	conn, stop, err := dial(ctx, addr, opts)
+	if err != nil {
+		return res, err
+	}
+	defer conn.Close()
+	defer stop()
+
+	msg, err := roundTrip(conn, negotiateRequest(codes))
+	if err != nil {
+		return res, err
+	}
+	if err := checkSMB2(msg, cmdNegotiate, statusSuccess); err != nil {
+		return res, err
+	}
+	body := msg[smb2HeaderSize:]
+	if len(body) < 64 {
+		return res, fmt.Errorf("smb2 negotiate: короткий ответ (%d байт)", len(msg))
+	}
+	res.securityMode = binary.LittleEndian.Uint16(body[2:])
+	res.dialect = binary.LittleEndian.Uint16(body[4:])
+	copy(res.guid[:], body[8:24])
+	if !slices.Contains(codes, res.dialect) {
+		return res, fmt.Errorf("smb2 negotiate: диалект 0x%04x не предлагался", res.dialect)
+	}
+	if !session || ctx.Err() != nil {
+		return res, nil
+	}
+	// CHALLENGE необязателен: без него остаются диалект и режим подписи
+	msg, err = roundTrip(conn, sessionSetupRequest(spnegoInit(ntlmNegotiate())))
+	if err == nil && checkSMB2(msg, cmdSessionSetup, statusMoreProcessingRequired) == nil {
+		res.ntlm, _ = parseChallenge(msg[smb2HeaderSize:])
+	}
+	return res, nil
+}
+
+// dial открывает TCP-соединение со сроком opts.Timeout на весь обмен; отмена ctx
+// прерывает чтение. stop снимает привязку к ctx.
+func dial(ctx context.Context, addr string, opts Options) (net.Conn, func() bool, error) {
+	conn, err := opts.Dial("tcp", addr, opts.Timeout)
+	if err != nil {
+		return nil, nil, err
+	}
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	_ = conn.SetDeadline(time.Now().Add(opts.Timeout))
+	return conn, stop, nil
+}
+
+// roundTrip отправляет сообщение в кадре Direct TCP (MS-SMB2, 2.1) и читает ответ.
+func roundTrip(conn net.Conn, msg []byte) ([]byte, error) {
+	frame := make([]byte, 4, 4+len(msg))
+	binary.BigEndian.PutUint32(frame, uint32(len(msg)))
+	if _, err := conn.Write(append(frame, msg...)); err != nil {
+		return nil, err
+	}
+	var hdr [4]byte
+	if _, err := io.ReadFull(conn, hdr[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(hdr[:])
+	if hdr[0] != 0 || n > maxMessage {
+		return nil, errNotSMB
+	}
+	resp := make([]byte, n)
+	if _, err := io.ReadFull(conn, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// checkSMB2 проверяет заголовок ответа SMB2: сигнатуру, команду и статус.
+func checkSMB2(msg []byte, cmd uint16, status uint32) error {
+	if len(msg) < smb2HeaderSize || !bytes.HasPrefix(msg, smb2Magic) || binary.LittleEndian.Uint16(msg[12:]) != cmd {
+		return errNotSMB
+	}
+	if got := binary.LittleEndian.Uint32(msg[8:]); got != status {
+		return fmt.Errorf("smb2: команда 0x%04x, статус 0x%08x", cmd, got)
+	}
+	return nil
+}
+
+// smb2Header собирает заголовок запроса SMB2 (MS-SMB2, 2.2.1.2).
+func smb2Header(cmd uint16, messageID uint64) []byte {
+	h := make([]byte, smb2HeaderSize)
+	copy(h, smb2Magic)
+	binary.LittleEndian.PutUint16(h[4:], smb2HeaderSize)
+	binary.LittleEndian.PutUint16(h[12:], cmd)
+	binary.LittleEndian.PutUint16(h[14:], 1) // CreditRequest
+	binary.LittleEndian.PutUint64(h[24:], messageID)
+	return h
+}
+
+// negotiateRequest собирает NEGOTIATE с диалектами codes (MS-SMB2, 2.2.3); для 3.1.1
+// добавляется обязательный контекст целостности предварительной аутентификации.
+func negotiateRequest(codes []uint16) []byte {
+	b := smb2Header(cmdNegotiate, 0)
+	body := make([]byte, 36)
+	binary.LittleEndian.PutUint16(body[0:], 36)
+	binary.LittleEndian.PutUint16(body[2:], uint16(len(codes)))
+	binary.LittleEndian.PutUint16(body[4:], securitySigningEnabled)
+	// ClientGuid; при единственном диалекте 2.0.2 должен быть нулевым
+	if len(codes) > 1 || codes[0] != 0x0202 {
+		_, _ = rand.Read(body[12:28])
+	}
+	b = append(b, body...)
+	for _, c := range codes {
+		b = binary.LittleEndian.AppendUint16(b, c)
+	}
+	if !slices.Contains(codes, dialect311) {
+		return b
+	}
+	for len(b)%8 != 0 {
+		b = append(b, 0)
+	}
+	binary.LittleEndian.PutUint32(b[smb2HeaderSize+28:], uint32(len(b))) // NegotiateContextOffset
+	binary.LittleEndian.PutUint16(b[smb2HeaderSize+32:], 1)              // NegotiateContextCount
+	ctxData := make([]byte, 8+38)
+	binary.LittleEndian.PutUint16(ctxData[0:], contextPreauthIntegrity)
+	binary.LittleEndian.PutUint16(ctxData[2:], 38)
+	binary.LittleEndian.PutUint16(ctxData[8:], 1)   // HashAlgorithmCount
+	binary.LittleEndian.PutUint16(ctxData[10:], 32) // SaltLength
+	binary.LittleEndian.PutUint16(ctxData[12:], hashSHA512)
+	_, _ = rand.Read(ctxData[14:])
+	return append(b, ctxData...)
+}
+
+// sessionSetupRequest собирает SESSION_SETUP с маркером безопасности token (MS-SMB2, 2.2.5).
+func sessionSetupRequest(token []byte) []byte {
+	b := smb2Header(cmdSessionSetup, 1)
+	body := make([]byte, 24)
+	binary.LittleEndian.PutUint16(body[0:], 25)
+	body[3] = securitySigningEnabled
+	binary.LittleEndian.PutUint16(body[12:], smb2HeaderSize+24) // SecurityBufferOffset
+	binary.LittleEndian.PutUint16(body[14:], uint16(len(token)))
+	b = append(b, body...)
+	return append(b, token...)
+}
+
+// formatGUID записывает GUID в каноническом виде (первые три поля — little-endian).
+func formatGUID(g [16]byte) string {
+	if g == [16]byte{} {
+		return ""
+	}
+	return fmt.Sprintf("%08x-%04x-%04x-%x-%x",
+		binary.LittleEndian.Uint32(g[0:]), binary.LittleEndian.Uint16(g[4:]), binary.LittleEndian.Uint16(g[6:]), g[8:10], g[10:])
+}
+
+// Режим безопасности SMBv1 (MS-CIFS, 2.2.4.52.2).
+const (
+	smb1SigningEnabled  = 0x04
+	smb1SigningRequired = 0x08
+)
+
+// probeSMB1 отправляет NEGOTIATE SMBv1 с единственным диалектом "NT LM 0.12" (MS-CIFS,
+// 2.2.4.52) и сообщает, согласовал ли его сервер; mode — режим безопасности ответа.
+// Сервер с отключённым SMBv1 обрывает соединение.
+func probeSMB1(ctx context.Context, addr string, opts Options) (mode byte, ok bool) {
+	conn, stop, err := dial(ctx, addr, opts)
+	if err != nil {
+		return 0, false
+	}
+	defer conn.Close()
+	defer stop()
+
+	req := make([]byte, 32, 48)
+	copy(req, smb1Magic)
+	req[4] = 0x72                                   // SMB_COM_NEGOTIATE
+	req[9] = 0x18                                   // пути без учёта регистра, канонические
+	binary.LittleEndian.PutUint16(req[10:], 0xC001) // Unicode, коды NT, длинные имена
+	binary.LittleEndian.PutUint16(req[26:], 0xFEFF) // PIDLow
+	dialectsField := append([]byte{0x02}, "NT LM 0.12\x00"...)
+	req = append(req, 0) // WordCount
+	req = binary.LittleEndian.AppendUint16(req, uint16(len(dialectsField)))
+	req = append(req, dialectsField...)
+
+	resp, err := roundTrip(conn, req)
+	if err != nil || len(resp) < 35 || !bytes.HasPrefix(resp, smb1Magic) || resp[4] != 0x72 {
+		return 0, false
+	}
+	if binary.LittleEndian.Uint32(resp[5:]) != statusSuccess || resp[32] == 0 {
+		return 0, false
+	}
+	if binary.LittleEndian.Uint16(resp[33:]) != 0 { // DialectIndex: 0xFFFF — ни один не подошёл
+		return 0, false
+	}
+	if resp[32] >= 17 && len(resp) > 35 {
+		mode = resp[35]
+	}
+	return mode, true
+}
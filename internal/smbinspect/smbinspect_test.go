@@ -0,0 +1,223 @@
+package smbinspect
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"slices"
+	"testing"
+	"time"
+	"unicode/utf16"
+)
+
+// fakeSMB — поведение тестового SMB-сервера.
+type fakeSMB struct {
+	dialects []uint16 // принимаемые диалекты SMB2/3; пусто — SMB2 не поддерживается
+	smb1     bool     // принимать NEGOTIATE SMBv1
+	mode     uint16   // SecurityMode ответа NEGOTIATE
+	guid     [16]byte
+	avPairs  map[uint16]string
+	version  [4]byte // major, minor, build (LE)
+}
+
+// serveSMB запускает SMB-сервер, отвечающий по правилам s.
+func serveSMB(t *testing.T, s fakeSMB) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.handle(conn)
+		}
+	}()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func (s fakeSMB) handle(conn net.Conn) {
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+	for {
+		var hdr [4]byte
+		if _, err := io.ReadFull(conn, hdr[:]); err != nil {
+			return
+		}
+		msg := make([]byte, binary.BigEndian.Uint32(hdr[:]))
+		if _, err := io.ReadFull(conn, msg); err != nil {
+			return
+		}
+		var resp []byte
+		switch {
+		case msg[0] == 0xFF:
+			if !s.smb1 {
+				return
+			}
+			resp = make([]byte, 32+1+34+2)
+			copy(resp, smb1Magic)
+			resp[4] = 0x72
+			resp[32] = 17
+			resp[35] = smb1SigningEnabled
+		case binary.LittleEndian.Uint16(msg[12:]) == cmdNegotiate:
+			count := int(binary.LittleEndian.Uint16(msg[smb2HeaderSize+2:]))
+			var chosen uint16
+			for i := 0; i < count; i++ {
+				d := binary.LittleEndian.Uint16(msg[smb2HeaderSize+36+2*i:])
+				if slices.Contains(s.dialects, d) && d > chosen {
+					chosen = d
+				}
+			}
+			if chosen == 0 {
+				resp = smb2Response(cmdNegotiate, 0xC00000BB, make([]byte, 9))
+				break
+			}
+			body := make([]byte, 65)
+			binary.LittleEndian.PutUint16(body[0:], 65)
+			binary.LittleEndian.PutUint16(body[2:], s.mode)
+			binary.LittleEndian.PutUint16(body[4:], chosen)
+			copy(body[8:], s.guid[:])
+			resp = smb2Response(cmdNegotiate, statusSuccess, body)
+		default:
+			challenge := s.challenge()
+			body := make([]byte, 8, 8+len(challenge)+4)
+			binary.LittleEndian.PutUint16(body[0:], 9)
+			binary.LittleEndian.PutUint16(body[4:], smb2HeaderSize+8)
+			binary.LittleEndian.PutUint16(body[6:], uint16(len(challenge)+4))
+			body = append(body, 0xa1, 0x82, 0x01, 0x00) // начало NegTokenResp
+			resp = smb2Response(cmdSessionSetup, statusMoreProcessingRequired, append(body, challenge...))
+		}
+		frame := make([]byte, 4)
+		binary.BigEndian.PutUint32(frame, uint32(len(resp)))
+		if _, err := conn.Write(append(frame, resp...)); err != nil {
+			return
+		}
+	}
+}
+
+func smb2Response(cmd uint16, status uint32, body []byte) []byte {
+	h := smb2Header(cmd, 0)
+	h[16] = 0x01 // SMB2_FLAGS_SERVER_TO_REDIR
+	binary.LittleEndian.PutUint32(h[8:], status)
+	return append(h, body...)
+}
+
+// challenge собирает NTLMSSP CHALLENGE с версией и TargetInfo сервера.
+func (s fakeSMB) challenge() []byte {
+	var info []byte
+	for _, id := range []uint16{avNbDomainName, avNbComputerName, avDNSDomainName, avDNSComputerName} {
+		v, ok := s.avPairs[id]
+		if !ok {
+			continue
+		}
+		u := utf16.Encode([]rune(v))
+		info = binary.LittleEndian.AppendUint16(info, id)
+		info = binary.LittleEndian.AppendUint16(info, uint16(2*len(u)))
+		for _, c := range u {
+			info = binary.LittleEndian.AppendUint16(info, c)
+		}
+	}
+	info = append(info, 0, 0, 0, 0) // MsvAvEOL
+	m := make([]byte, 56)
+	copy(m, ntlmSignature)
+	binary.LittleEndian.PutUint32(m[8:], 2)
+	binary.LittleEndian.PutUint32(m[20:], ntlmNegotiateFlags)
+	binary.LittleEndian.PutUint16(m[40:], uint16(len(info)))
+	binary.LittleEndian.PutUint16(m[42:], uint16(len(info)))
+	binary.LittleEndian.PutUint32(m[44:], 56)
+	copy(m[48:], s.version[:])
+	return append(m, info...)
+}
+
+func TestInspect(t *testing.T) {
+	port := serveSMB(t, fakeSMB{
+		dialects: []uint16{0x0202, 0x0210, 0x0300, 0x0302, 0x0311},
+		smb1:     true,
+		mode:     securitySigningEnabled,
+		guid:     [16]byte{0x78, 0x56, 0x34, 0x12, 0x34, 0x12, 0x78, 0x56, 1, 2, 3, 4, 5, 6, 7, 8},
+		avPairs: map[uint16]string{
+			avNbComputerName:  "PC01",
+			avNbDomainName:    "CORP",
+			avDNSComputerName: "pc01.corp.example",
+			avDNSDomainName:   "corp.example",
+		},
+		version: [4]byte{10, 0, 0x61, 0x4a}, // 10.0.19041
+	})
+	info, err := Inspect(context.Background(), "127.0.0.1", port, Options{Timeout: 2 * time.Second})
+	if err != nil {
+		t.Fatalf("Inspect: %v", err)
+	}
+	if want := []string{"2.0.2", "2.1", "3.0", "3.0.2", "3.1.1"}; !slices.Equal(info.Dialects, want) {
+		t.Errorf("Dialects = %v, want %v", info.Dialects, want)
+	}
+	if !info.SMB1 || !info.SigningEnabled || info.SigningRequired {
+		t.Errorf("smb1=%v signing enabled=%v required=%v", info.SMB1, info.SigningEnabled, info.SigningRequired)
+	}
+	if info.ServerGUID != "12345678-1234-5678-0102-030405060708" {
+		t.Errorf("ServerGUID = %q", info.ServerGUID)
+	}
+	if info.ComputerName != "PC01" || info.Domain != "CORP" || info.DNSDomain != "corp.example" || info.Hostname() != "pc01.corp.example" {
+		t.Errorf("имена: %+v", info)
+	}
+	if major, minor, build, ok := info.WindowsVersion(); !ok || major != 10 || minor != 0 || build != 19041 {
+		t.Errorf("WindowsVersion = %d.%d.%d %v (OSVersion %q)", major, minor, build, ok, info.OSVersion)
+	}
+}
+
+func TestInspectDialectRange(t *testing.T) {
+	port := serveSMB(t, fakeSMB{
+		dialects: []uint16{0x0210, 0x0300},
+		mode:     securitySigningEnabled | securitySigningRequired,
+	})
+	info, err := Inspect(context.Background(), "127.0.0.1", port, Options{Timeout: 2 * time.Second})
+	if err != nil {
+		t.Fatalf("Inspect: %v", err)
+	}
+	if want := []string{"2.1", "3.0"}; !slices.Equal(info.Dialects, want) || info.MaxDialect() != "3.0" {
+		t.Errorf("Dialects = %v, max %q", info.Dialects, info.MaxDialect())
+	}
+	if info.SMB1 || !info.SigningRequired || info.OSVersion != "" {
+		t.Errorf("smb1=%v required=%v os=%q", info.SMB1, info.SigningRequired, info.OSVersion)
+	}
+	if got := info.Summary(); got != "SMB 2.1–3.0, подпись обязательна" {
+		t.Errorf("Summary = %q", got)
+	}
+}
+
+func TestInspectSMB1Only(t *testing.T) {
+	port := serveSMB(t, fakeSMB{smb1: true})
+	info, err := Inspect(context.Background(), "127.0.0.1", port, Options{Timeout: 2 * time.Second})
+	if err != nil {
+		t.Fatalf("Inspect: %v", err)
+	}
+	if !info.SMB1 || len(info.Dialects) != 0 || !info.SigningEnabled || info.SigningRequired {
+		t.Errorf("SMBv1-сервер: %+v", info)
+	}
+}
+
+func TestInspectNotSMB(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			_, _ = conn.Write([]byte("HTTP/1.0 400 Bad Request\r\n\r\n"))
+			conn.Close()
+		}
+	}()
+	port := ln.Addr().(*net.TCPAddr).Port
+	if info, err := Inspect(context.Background(), "127.0.0.1", port, Options{Timeout: time.Second}); err == nil {
+		t.Fatalf("ожидалась ошибка, получено %+v", info)
+	}
+}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/gosnmp/gosnmp"
 
+	"network-scanner/internal/network"
 	"network-scanner/internal/scanner"
 	"network-scanner/internal/topology"
 )
@@ -90,6 +91,7 @@ type SNMPClient interface {
 type GoSNMPClient struct {
 	client  *gosnmp.GoSNMP
 	timeout time.Duration
+	source  network.Source // интерфейс/адрес, с которого уходят запросы; нулевой — выбор ОС
 }
 
 func NewGoSNMPClient(timeoutSeconds int) *GoSNMPClient {
@@ -99,6 +101,13 @@ func NewGoSNMPClient(timeoutSeconds int) *GoSNMPClient {
 	return &GoSNMPClient{timeout: time.Duration(timeoutSeconds) * time.Second}
 }
 
+// NewGoSNMPClientFrom создаёт клиента, запросы которого уходят от источника src.
+func NewGoSNMPClientFrom(timeoutSeconds int, src network.Source) *GoSNMPClient {
+	c := NewGoSNMPClient(timeoutSeconds)
+	c.source = src
+	return c
+}
+
 func (g *GoSNMPClient) Connect(ip, community string) error {
 	c := &gosnmp.GoSNMP{
 		Target:    ip,
@@ -108,6 +117,13 @@ func (g *GoSNMPClient) Connect(ip, community string) error {
 		Timeout:   g.timeout,
 		Retries:   2,
 	}
+	if !g.source.IsZero() {
+		d := g.source.Dialer("udp", ip, g.timeout)
+		if d.LocalAddr != nil {
+			c.LocalAddr = d.LocalAddr.String()
+		}
+		c.Control = d.Control
+	}
 	if err := c.Connect(); err != nil {
 		return err
 	}
@@ -321,6 +337,21 @@ func CollectWithReportProgress(devices []scanner.Result, communities []string, t
 }
 
 func CollectWithReportProgressContext(ctx context.Context, devices []scanner.Result, communities []string, timeout int, progress ProgressCallback) (map[string]*topology.Device, *CollectReport, error) {
+	return CollectFromContext(ctx, network.Source{}, devices, communities, timeout, progress)
+}
+
+// CollectFromContext — CollectWithReportProgressContext с SNMP-запросами от источника src
+// (интерфейс/адрес, выбранные для сканирования).
+func CollectFromContext(ctx context.Context, src network.Source, devices []scanner.Result, communities []string, timeout int, progress ProgressCallback) (map[string]*topology.Device, *CollectReport, error) {
+	return CollectWith(ctx, func() SNMPClient { return NewGoSNMPClientFrom(timeout, src) }, devices, communities, progress)
+}
+
+// ClientFactory создаёт SNMP-клиента для одного подключения к устройству.
+type ClientFactory func() SNMPClient
+
+// CollectWith опрашивает устройства клиентами newClient (например, симулированной сетью
+// в тестах); в остальном совпадает с CollectFromContext.
+func CollectWith(ctx context.Context, newClient ClientFactory, devices []scanner.Result, communities []string, progress ProgressCallback) (map[string]*topology.Device, *CollectReport, error) {
 	if ctx == nil {
 		ctx = context.Background()
 	}
@@ -379,7 +410,7 @@ func CollectWithReportProgressContext(ctx context.Context, devices []scanner.Res
 						return
 					default:
 					}
-					c := NewGoSNMPClient(timeout)
+					c := newClient()
 					trimmedCommunity := strings.TrimSpace(community)
 					if err := c.Connect(d.IP, trimmedCommunity); err != nil {
 						connectErrs = append(connectErrs, fmt.Sprintf("%s: %v", trimmedCommunity, err))
@@ -0,0 +1,345 @@
+// Package sshinspect снимает отпечаток SSH-сервера без аутентификации: строку версии,
+// алгоритмы из KEXINIT сервера и ключи хоста каждого предложенного типа.
+package sshinspect
+
+import (
+	"bufio"
+	"context"
+	"crypto/dsa"
+	"crypto/rsa"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// DialFunc открывает соединение; совпадает с banner.DialFunc, чтобы сканер подставлял
+// свой диалер (прокси, адрес источника, симулятор).
+type DialFunc func(network, address string, timeout time.Duration) (net.Conn, error)
+
+// DefaultTimeout — ожидание одного соединения по умолчанию.
+const DefaultTimeout = 3 * time.Second
+
+// clientVersion — строка версии, которой представляется сканер.
+const clientVersion = "SSH-2.0-network-scanner"
+
+// msgKexInit — номер сообщения SSH_MSG_KEXINIT (RFC 4253, 7.1).
+const msgKexInit = 20
+
+// maxPacket — наибольший принимаемый пакет KEXINIT.
+const maxPacket = 35000
+
+// Options — настройки снятия отпечатка.
+type Options struct {
+	Timeout time.Duration // ожидание соединения и ответа; 0 — DefaultTimeout
+	Dial    DialFunc      // nil — net.DialTimeout
+}
+
+// Info — отпечаток SSH-сервера.
+type Info struct {
+	Banner            string    `json:"banner"`                        // строка версии сервера
+	KexAlgorithms     []string  `json:"kex_algorithms,omitempty"`      // обмен ключами
+	HostKeyAlgorithms []string  `json:"host_key_algorithms,omitempty"` // подписи ключа хоста
+	Ciphers           []string  `json:"ciphers,omitempty"`             // шифры клиент → сервер
+	MACs              []string  `json:"macs,omitempty"`                // MAC клиент → сервер
+	Compression       []string  `json:"compression,omitempty"`         // сжатие клиент → сервер
+	HostKeys          []HostKey `json:"host_keys,omitempty"`
+}
+
+// HostKey — ключ хоста одного типа.
+type HostKey struct {
+	Type        string `json:"type"`           // формат ключа ("ssh-ed25519", "ssh-rsa")
+	Bits        int    `json:"bits,omitempty"` // длина ключа RSA, DSA и ECDSA; 0 — Ed25519
+	Fingerprint string `json:"fingerprint"`    // SHA256:…, как у ssh-keygen -l
+}
+
+// Protocol1 сообщает, что сервер говорит только на SSH-1.
+func (i *Info) Protocol1() bool {
+	return i != nil && strings.HasPrefix(i.Banner, "SSH-1.") && !strings.HasPrefix(i.Banner, "SSH-1.99-")
+}
+
+// Summary кратко описывает ключи хоста для текстового вывода ("ssh-ed25519, ssh-rsa 3072").
+func (i *Info) Summary() string {
+	if i == nil {
+		return ""
+	}
+	if i.Protocol1() {
+		return "SSH-1"
+	}
+	parts := make([]string, 0, len(i.HostKeys))
+	for _, k := range i.HostKeys {
+		if k.Bits == 0 {
+			parts = append(parts, k.Type)
+		} else {
+			parts = append(parts, fmt.Sprintf("%s %d", k.Type, k.Bits))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// WeakKey сообщает, что ключ хоста слаб: DSA любой длины или RSA короче 2048 бит.
+func (k HostKey) WeakKey() bool {
+	switch k.Type {
+	case ssh.InsecureKeyAlgoDSA:
+		return true
+	case ssh.KeyAlgoRSA:
+		return k.Bits > 0 && k.Bits < 2048
+	}
+	return false
+}
+
+// WeakKex возвращает предложенные сервером устаревшие алгоритмы обмена ключами (SHA-1,
+// группа 1 Диффи — Хеллмана).
+func (i *Info) WeakKex() []string {
+	return filter(i.KexAlgorithms, func(a string) bool {
+		return strings.Contains(a, "sha1") || strings.HasPrefix(a, "diffie-hellman-group1-")
+	})
+}
+
+// WeakCiphers возвращает предложенные шифры CBC, RC4 и отсутствие шифрования.
+func (i *Info) WeakCiphers() []string {
+	return filter(i.Ciphers, func(a string) bool {
+		return strings.Contains(a, "cbc") || strings.HasPrefix(a, "arcfour") || a == "none"
+	})
+}
+
+// WeakMACs возвращает предложенные MAC на MD5, SHA-1, RIPEMD и усечённые до 64/96 бит.
+func (i *Info) WeakMACs() []string {
+	return filter(i.MACs, func(a string) bool {
+		return strings.Contains(a, "md5") || strings.Contains(a, "sha1") || strings.Contains(a, "ripemd") ||
+			strings.HasSuffix(a, "-96") || strings.HasPrefix(a, "umac-64") || a == "none"
+	})
+}
+
+// WeakHostKeyAlgorithms возвращает предложенные подписи ключа хоста на SHA-1 (ssh-rsa)
+// и DSA.
+func (i *Info) WeakHostKeyAlgorithms() []string {
+	return filter(i.HostKeyAlgorithms, func(a string) bool {
+		return strings.HasPrefix(a, ssh.KeyAlgoRSA) && !strings.HasPrefix(a, "rsa-sha2") ||
+			strings.HasPrefix(a, ssh.InsecureKeyAlgoDSA)
+	})
+}
+
+func filter(list []string, keep func(string) bool) []string {
+	if len(list) == 0 {
+		return nil
+	}
+	var out []string
+	for _, a := range list {
+		if keep(a) {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// Inspect подключается к SSH-серверу на порту port хоста host, читает строку версии и
+// KEXINIT сервера, а затем для каждого предложенного формата ключа хоста проводит обмен
+// ключами с этим единственным алгоритмом и записывает ключ. Аутентификация не
+// выполняется. Для сервера SSH-1 возвращается только строка версии.
+func Inspect(ctx context.Context, host string, port int, opts Options) (*Info, error) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.Dial == nil {
+		opts.Dial = net.DialTimeout
+	}
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+
+	info, err := readKexInit(ctx, addr, opts)
+	if err != nil || info.Protocol1() {
+		return info, err
+	}
+	for _, alg := range hostKeyProbes(info.HostKeyAlgorithms) {
+		if ctx.Err() != nil {
+			break
+		}
+		key, err := fetchHostKey(ctx, addr, alg, opts)
+		if err != nil {
+			continue
+		}
+		info.HostKeys = append(info.HostKeys, HostKey{
+			Type:        key.Type(),
+			Bits:        keyBits(key),
+			Fingerprint: ssh.FingerprintSHA256(key),
+		})
+	}
+	return info, nil
+}
+
+// readKexInit обменивается строками версии и разбирает первый пакет сервера — KEXINIT,
+// который до обмена ключами идёт открытым текстом (RFC 4253, 6 и 7.1).
+func readKexInit(ctx context.Context, addr string, opts Options) (*Info, error) {
+	conn, err := opts.Dial("tcp", addr, opts.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+	_ = conn.SetDeadline(time.Now().Add(opts.Timeout))
+
+	if _, err := io.WriteString(conn, clientVersion+"\r\n"); err != nil {
+		return nil, err
+	}
+	r := bufio.NewReader(conn)
+	info := &Info{}
+	// До строки версии сервер может прислать другие строки (RFC 4253, 4.2)
+	for lines := 0; ; lines++ {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(line, "SSH-") {
+			info.Banner = strings.TrimRight(line, "\r\n")
+			break
+		}
+		if lines >= 50 {
+			return nil, errors.New("ssh: нет строки версии сервера")
+		}
+	}
+	if info.Protocol1() {
+		return info, nil
+	}
+
+	var hdr [5]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, err
+	}
+	length := binary.BigEndian.Uint32(hdr[:4])
+	padding := uint32(hdr[4])
+	if length < padding+2 || length > maxPacket {
+		return nil, fmt.Errorf("ssh: неверная длина пакета %d", length)
+	}
+	packet := make([]byte, length-1)
+	if _, err := io.ReadFull(r, packet); err != nil {
+		return nil, err
+	}
+	payload := packet[:len(packet)-int(padding)]
+	if len(payload) < 17 || payload[0] != msgKexInit {
+		return nil, errors.New("ssh: первый пакет сервера — не KEXINIT")
+	}
+	// cookie (16 байт), затем списки имён: kex, ключ хоста, шифры, MAC и сжатие в обе стороны
+	lists := make([][]string, 0, 8)
+	rest := payload[17:]
+	for len(lists) < 8 {
+		if len(rest) < 4 {
+			return nil, errors.New("ssh: KEXINIT обрезан")
+		}
+		n := binary.BigEndian.Uint32(rest[:4])
+		if uint32(len(rest)-4) < n {
+			return nil, errors.New("ssh: KEXINIT обрезан")
+		}
+		var names []string
+		if n > 0 {
+			names = strings.Split(string(rest[4:4+n]), ",")
+		}
+		lists = append(lists, names)
+		rest = rest[4+n:]
+	}
+	info.KexAlgorithms = lists[0]
+	info.HostKeyAlgorithms = lists[1]
+	info.Ciphers = lists[2]
+	info.MACs = lists[4]
+	info.Compression = lists[6]
+	return info, nil
+}
+
+// hostKeyProbes выбирает по одному поддерживаемому алгоритму подписи на каждый формат
+// ключа из списка сервера: rsa-sha2-512, rsa-sha2-256 и ssh-rsa проверяют один ключ RSA.
+// Сертификаты и ключи FIDO пропускаются.
+func hostKeyProbes(offered []string) []string {
+	supported := make(map[string]bool)
+	for _, list := range [][]string{ssh.SupportedAlgorithms().HostKeys, ssh.InsecureAlgorithms().HostKeys} {
+		for _, a := range list {
+			supported[a] = true
+		}
+	}
+	seen := make(map[string]bool)
+	var out []string
+	for _, alg := range offered {
+		if !supported[alg] || strings.Contains(alg, "-cert-") {
+			continue
+		}
+		format := alg
+		if strings.HasPrefix(alg, "rsa-sha2-") {
+			format = ssh.KeyAlgoRSA
+		}
+		if seen[format] {
+			continue
+		}
+		seen[format] = true
+		out = append(out, alg)
+	}
+	return out
+}
+
+// errHostKeyCaptured прерывает рукопожатие, как только ключ хоста получен.
+var errHostKeyCaptured = errors.New("ssh: ключ хоста получен")
+
+// fetchHostKey проводит обмен ключами с единственным алгоритмом ключа хоста alg и
+// возвращает ключ, не переходя к аутентификации.
+func fetchHostKey(ctx context.Context, addr, alg string, opts Options) (ssh.PublicKey, error) {
+	conn, err := opts.Dial("tcp", addr, opts.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+	_ = conn.SetDeadline(time.Now().Add(opts.Timeout))
+
+	supported, insecure := ssh.SupportedAlgorithms(), ssh.InsecureAlgorithms()
+	var key ssh.PublicKey
+	cfg := &ssh.ClientConfig{
+		Config: ssh.Config{
+			KeyExchanges: append(supported.KeyExchanges, insecure.KeyExchanges...),
+			Ciphers:      append(supported.Ciphers, insecure.Ciphers...),
+			MACs:         append(supported.MACs, insecure.MACs...),
+		},
+		User:              "network-scanner",
+		ClientVersion:     clientVersion,
+		HostKeyAlgorithms: []string{alg},
+		HostKeyCallback: func(_ string, _ net.Addr, k ssh.PublicKey) error {
+			key = k
+			return errHostKeyCaptured
+		},
+		Timeout: opts.Timeout,
+	}
+	_, _, _, err = ssh.NewClientConn(conn, addr, cfg)
+	if key != nil {
+		return key, nil
+	}
+	if err == nil {
+		err = errors.New("ssh: сервер не передал ключ хоста")
+	}
+	return nil, err
+}
+
+func keyBits(key ssh.PublicKey) int {
+	ck, ok := key.(ssh.CryptoPublicKey)
+	if !ok {
+		return 0
+	}
+	switch k := ck.CryptoPublicKey().(type) {
+	case *rsa.PublicKey:
+		return k.N.BitLen()
+	case *dsa.PublicKey:
+		return k.P.BitLen()
+	}
+	switch key.Type() {
+	case ssh.KeyAlgoECDSA256:
+		return 256
+	case ssh.KeyAlgoECDSA384:
+		return 384
+	case ssh.KeyAlgoECDSA521:
+		return 521
+	}
+	return 0
+}
@@ -0,0 +1,164 @@
+package sshinspect
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"net"
+	"slices"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// serveSSH запускает SSH-сервер с ключами keys, который отклоняет любую аутентификацию.
+func serveSSH(t *testing.T, cfg *ssh.ServerConfig, keys ...any) (int, []ssh.PublicKey) {
+	t.Helper()
+	cfg.PasswordCallback = func(ssh.ConnMetadata, []byte) (*ssh.Permissions, error) {
+		return nil, ssh.ErrNoAuth
+	}
+	var pubs []ssh.PublicKey
+	for _, k := range keys {
+		signer, err := ssh.NewSignerFromKey(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg.AddHostKey(signer)
+		pubs = append(pubs, signer.PublicKey())
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+				_, _, _, _ = ssh.NewServerConn(conn, cfg)
+			}()
+		}
+	}()
+	return ln.Addr().(*net.TCPAddr).Port, pubs
+}
+
+func TestInspect(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &ssh.ServerConfig{
+		Config: ssh.Config{
+			KeyExchanges: []string{ssh.KeyExchangeCurve25519, "diffie-hellman-group14-sha1"},
+			Ciphers:      []string{ssh.CipherAES128GCM, "aes128-cbc"},
+			MACs:         []string{ssh.HMACSHA256ETM, "hmac-sha1-96"},
+		},
+		ServerVersion: "SSH-2.0-OpenSSH_9.6",
+	}
+	port, pubs := serveSSH(t, cfg, edKey, rsaKey)
+
+	info, err := Inspect(context.Background(), "127.0.0.1", port, Options{Timeout: 2 * time.Second})
+	if err != nil {
+		t.Fatalf("Inspect: %v", err)
+	}
+	if info.Banner != "SSH-2.0-OpenSSH_9.6" || info.Protocol1() {
+		t.Errorf("Banner = %q", info.Banner)
+	}
+	// Сервер дополняет kex псевдоалгоритмами (kex-strict-s-v00@openssh.com)
+	if !slices.Contains(info.KexAlgorithms, "diffie-hellman-group14-sha1") || !slices.Equal(info.Ciphers, cfg.Ciphers) || !slices.Equal(info.MACs, cfg.MACs) {
+		t.Errorf("алгоритмы: kex=%v ciphers=%v macs=%v", info.KexAlgorithms, info.Ciphers, info.MACs)
+	}
+	if !slices.Equal(info.Compression, []string{"none"}) || len(info.HostKeyAlgorithms) == 0 {
+		t.Errorf("compression=%v hostkey=%v", info.Compression, info.HostKeyAlgorithms)
+	}
+	if len(info.HostKeys) != 2 {
+		t.Fatalf("HostKeys = %+v, ожидались ed25519 и RSA", info.HostKeys)
+	}
+	want := map[string]HostKey{
+		ssh.KeyAlgoED25519: {Type: ssh.KeyAlgoED25519, Fingerprint: ssh.FingerprintSHA256(pubs[0])},
+		ssh.KeyAlgoRSA:     {Type: ssh.KeyAlgoRSA, Bits: 1024, Fingerprint: ssh.FingerprintSHA256(pubs[1])},
+	}
+	for _, k := range info.HostKeys {
+		if k != want[k.Type] {
+			t.Errorf("ключ %+v, want %+v", k, want[k.Type])
+		}
+	}
+	if info.HostKeys[0].WeakKey() == info.HostKeys[1].WeakKey() {
+		t.Errorf("слабым должен быть только RSA 1024: %+v", info.HostKeys)
+	}
+	if got := info.WeakKex(); !slices.Equal(got, []string{"diffie-hellman-group14-sha1"}) {
+		t.Errorf("WeakKex = %v", got)
+	}
+	if got := info.WeakCiphers(); !slices.Equal(got, []string{"aes128-cbc"}) {
+		t.Errorf("WeakCiphers = %v", got)
+	}
+	if got := info.WeakMACs(); !slices.Equal(got, []string{"hmac-sha1-96"}) {
+		t.Errorf("WeakMACs = %v", got)
+	}
+}
+
+func TestInspectProtocol1(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Write([]byte("SSH-1.5-Cisco-1.25\r\n"))
+		conn.Read(make([]byte, 64))
+	}()
+
+	info, err := Inspect(context.Background(), "127.0.0.1", ln.Addr().(*net.TCPAddr).Port, Options{Timeout: time.Second})
+	if err != nil {
+		t.Fatalf("Inspect: %v", err)
+	}
+	if !info.Protocol1() || info.Summary() != "SSH-1" || len(info.HostKeys) != 0 {
+		t.Errorf("info = %+v", info)
+	}
+}
+
+func TestInspectNotSSH(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		conn.Write([]byte("220 smtp.example.test ESMTP\r\n"))
+		conn.Close()
+	}()
+	if info, err := Inspect(context.Background(), "127.0.0.1", ln.Addr().(*net.TCPAddr).Port, Options{Timeout: time.Second}); err == nil {
+		t.Fatalf("ожидалась ошибка, получено %+v", info)
+	}
+}
+
+func TestWeakHostKeyAlgorithms(t *testing.T) {
+	info := &Info{HostKeyAlgorithms: []string{"rsa-sha2-512", "rsa-sha2-256", "ssh-rsa", "ssh-ed25519", "ssh-dss", "ssh-rsa-cert-v01@openssh.com"}}
+	want := []string{"ssh-rsa", "ssh-dss", "ssh-rsa-cert-v01@openssh.com"}
+	if got := info.WeakHostKeyAlgorithms(); !slices.Equal(got, want) {
+		t.Errorf("WeakHostKeyAlgorithms = %v, want %v", got, want)
+	}
+	if got := hostKeyProbes(info.HostKeyAlgorithms); !slices.Equal(got, []string{"rsa-sha2-512", "ssh-ed25519", "ssh-dss"}) {
+		t.Errorf("hostKeyProbes = %v", got)
+	}
+}
@@ -0,0 +1,121 @@
+package tlsinspect
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// starttlsPorts — протокол STARTTLS для стандартных портов служб с открытым текстом.
+var starttlsPorts = map[int]string{
+	21:  "ftp",
+	25:  "smtp",
+	110: "pop3",
+	143: "imap",
+	587: "smtp",
+}
+
+// starttlsServices — протокол STARTTLS по имени распознанной службы.
+var starttlsServices = map[string]string{
+	"ftp":             "ftp",
+	"smtp":            "smtp",
+	"submission":      "smtp",
+	"smtp-submission": "smtp",
+	"pop3":            "pop3",
+	"imap":            "imap",
+}
+
+// STARTTLSFor возвращает протокол STARTTLS для порта: по имени службы (распознанной или
+// из таблицы портов), иначе по номеру порта. "" — порт проверяется прямым рукопожатием.
+func STARTTLSFor(port int, service string) string {
+	if proto, ok := starttlsServices[strings.ToLower(service)]; ok {
+		return proto
+	}
+	return starttlsPorts[port]
+}
+
+// startTLS переводит соединение в режим TLS командой протокола proto и возвращается,
+// когда сервер готов к рукопожатию.
+func startTLS(conn net.Conn, proto string) error {
+	r := bufio.NewReader(conn)
+	switch proto {
+	case "smtp":
+		if err := expectReply(r, "220"); err != nil {
+			return err
+		}
+		if err := command(conn, r, "EHLO network-scanner", "250"); err != nil {
+			return err
+		}
+		return command(conn, r, "STARTTLS", "220")
+	case "ftp":
+		if err := expectReply(r, "220"); err != nil {
+			return err
+		}
+		return command(conn, r, "AUTH TLS", "234")
+	case "pop3":
+		if err := expectLine(r, "+OK"); err != nil {
+			return err
+		}
+		return command(conn, r, "STLS", "+OK")
+	case "imap":
+		if err := expectLine(r, "* OK"); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(conn, "a1 STARTTLS\r\n"); err != nil {
+			return err
+		}
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return err
+			}
+			if strings.HasPrefix(line, "a1 ") {
+				if strings.HasPrefix(line, "a1 OK") {
+					return nil
+				}
+				return fmt.Errorf("imap STARTTLS: %s", strings.TrimSpace(line))
+			}
+		}
+	}
+	return fmt.Errorf("неизвестный протокол STARTTLS: %s", proto)
+}
+
+// command отправляет строку cmd и ждёт ответа с префиксом want; коды SMTP и FTP
+// читаются с учётом многострочных ответов.
+func command(conn net.Conn, r *bufio.Reader, cmd, want string) error {
+	if _, err := fmt.Fprintf(conn, "%s\r\n", cmd); err != nil {
+		return err
+	}
+	if strings.HasPrefix(want, "+") {
+		return expectLine(r, want)
+	}
+	return expectReply(r, want)
+}
+
+// expectReply читает многострочный ответ SMTP/FTP ("250-...", "250 ...") и проверяет код.
+func expectReply(r *bufio.Reader, code string) error {
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		if len(line) < 4 || line[3] != '-' {
+			if !strings.HasPrefix(line, code) {
+				return fmt.Errorf("ожидался ответ %s, получено: %s", code, strings.TrimSpace(line))
+			}
+			return nil
+		}
+	}
+}
+
+func expectLine(r *bufio.Reader, prefix string) error {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, prefix) {
+		return fmt.Errorf("ожидался ответ %s, получено: %s", prefix, strings.TrimSpace(line))
+	}
+	return nil
+}
@@ -0,0 +1,282 @@
+// Package tlsinspect собирает сведения о TLS порта: цепочку сертификатов, поддерживаемые
+// версии протокола и согласованные наборы шифров, в том числе после STARTTLS.
+package tlsinspect
+
+import (
+	"context"
+	"crypto/dsa"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DialFunc открывает соединение; совпадает с banner.DialFunc, чтобы сканер подставлял
+// свой диалер (прокси, адрес источника, симулятор).
+type DialFunc func(network, address string, timeout time.Duration) (net.Conn, error)
+
+// DefaultTimeout — ожидание одного рукопожатия по умолчанию.
+const DefaultTimeout = 3 * time.Second
+
+// ExpiryWarning — срок до окончания действия сертификата, после которого он считается
+// истекающим.
+const ExpiryWarning = 30 * 24 * time.Hour
+
+// Options — настройки проверки TLS.
+type Options struct {
+	Timeout  time.Duration // ожидание соединения и рукопожатия; 0 — DefaultTimeout
+	Dial     DialFunc      // nil — net.DialTimeout
+	STARTTLS string        // протокол STARTTLS ("smtp", "imap", "pop3", "ftp"); "" — TLS сразу
+}
+
+// Info — сведения о TLS порта.
+type Info struct {
+	STARTTLS  string        `json:"starttls,omitempty"` // протокол, которым включён TLS
+	Protocols []Protocol    `json:"protocols"`          // поддерживаемые версии, от новой к старой
+	Chain     []Certificate `json:"chain"`              // цепочка сервера, первым — сертификат узла
+}
+
+// Protocol — поддерживаемая версия TLS и набор шифров, согласованный для неё.
+type Protocol struct {
+	Version     string `json:"version"`
+	CipherSuite string `json:"cipher_suite"`
+}
+
+// Certificate — сведения о сертификате цепочки.
+type Certificate struct {
+	Subject            string    `json:"subject"`
+	SANs               []string  `json:"sans,omitempty"`
+	Issuer             string    `json:"issuer"`
+	Serial             string    `json:"serial"`
+	NotBefore          time.Time `json:"not_before"`
+	NotAfter           time.Time `json:"not_after"`
+	KeyType            string    `json:"key_type"`
+	KeyBits            int       `json:"key_bits"`
+	SignatureAlgorithm string    `json:"signature_algorithm"`
+	SelfSigned         bool      `json:"self_signed,omitempty"`
+	SHA256             string    `json:"sha256"`
+}
+
+// versions — проверяемые версии TLS, от новой к старой.
+var versions = []uint16{tls.VersionTLS13, tls.VersionTLS12, tls.VersionTLS11, tls.VersionTLS10}
+
+// Leaf возвращает сертификат узла или nil, если цепочка пуста.
+func (i *Info) Leaf() *Certificate {
+	if i == nil || len(i.Chain) == 0 {
+		return nil
+	}
+	return &i.Chain[0]
+}
+
+// LegacyOnly сообщает, что порт поддерживает только TLS 1.0/1.1.
+func (i *Info) LegacyOnly() bool {
+	if i == nil || len(i.Protocols) == 0 {
+		return false
+	}
+	for _, p := range i.Protocols {
+		if p.Version != "TLS 1.0" && p.Version != "TLS 1.1" {
+			return false
+		}
+	}
+	return true
+}
+
+// Summary кратко описывает TLS порта для текстового вывода: версии и срок действия
+// сертификата узла ("TLS 1.3, TLS 1.2; сертификат до 2027-01-15, самоподписанный").
+func (i *Info) Summary() string {
+	if i == nil {
+		return ""
+	}
+	parts := make([]string, 0, len(i.Protocols))
+	for _, p := range i.Protocols {
+		parts = append(parts, p.Version)
+	}
+	s := strings.Join(parts, ", ")
+	if leaf := i.Leaf(); leaf != nil {
+		s += "; сертификат до " + leaf.NotAfter.Format("2006-01-02")
+		if leaf.SelfSigned {
+			s += ", самоподписанный"
+		}
+	}
+	if i.STARTTLS != "" {
+		s = "STARTTLS, " + s
+	}
+	return s
+}
+
+// Expired сообщает, что срок действия сертификата истёк к моменту now.
+func (c *Certificate) Expired(now time.Time) bool {
+	return now.After(c.NotAfter)
+}
+
+// ExpiresWithin сообщает, что сертификат ещё действует, но истекает в пределах window.
+func (c *Certificate) ExpiresWithin(now time.Time, window time.Duration) bool {
+	return !c.Expired(now) && c.NotAfter.Sub(now) <= window
+}
+
+// WeakKey сообщает, что ключ сертификата короче принятых минимумов: RSA и DSA — 2048 бит,
+// ECDSA — 224 бита.
+func (c *Certificate) WeakKey() bool {
+	switch c.KeyType {
+	case "RSA", "DSA":
+		return c.KeyBits > 0 && c.KeyBits < 2048
+	case "ECDSA":
+		return c.KeyBits > 0 && c.KeyBits < 224
+	}
+	return false
+}
+
+// Inspect подключается к порту port хоста host, при необходимости выполняет STARTTLS и
+// рукопожатие без проверки цепочки, а затем проверяет каждую версию TLS отдельным
+// соединением. Ошибка — порт не согласовал TLS ни в одной версии.
+func Inspect(ctx context.Context, host string, port int, opts Options) (*Info, error) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.Dial == nil {
+		opts.Dial = net.DialTimeout
+	}
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+
+	state, err := handshake(ctx, addr, opts, tls.VersionTLS10, tls.VersionTLS13)
+	if err != nil {
+		return nil, err
+	}
+	info := &Info{STARTTLS: opts.STARTTLS, Chain: certificates(state.PeerCertificates)}
+	for _, v := range versions {
+		if ctx.Err() != nil {
+			break
+		}
+		if v > state.Version {
+			continue
+		}
+		st := state
+		if v != state.Version {
+			if st, err = handshake(ctx, addr, opts, v, v); err != nil {
+				continue
+			}
+		}
+		info.Protocols = append(info.Protocols, Protocol{
+			Version:     tls.VersionName(st.Version),
+			CipherSuite: tls.CipherSuiteName(st.CipherSuite),
+		})
+	}
+	return info, nil
+}
+
+// handshake открывает соединение, выполняет STARTTLS и TLS-рукопожатие в пределах версий
+// minVersion..maxVersion. Для TLS 1.2 и ниже предлагаются и небезопасные наборы шифров,
+// чтобы увидеть, что сервер действительно согласует.
+func handshake(ctx context.Context, addr string, opts Options, minVersion, maxVersion uint16) (tls.ConnectionState, error) {
+	conn, err := opts.Dial("tcp", addr, opts.Timeout)
+	if err != nil {
+		return tls.ConnectionState{}, err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+	_ = conn.SetDeadline(time.Now().Add(opts.Timeout))
+	if opts.STARTTLS != "" {
+		if err := startTLS(conn, opts.STARTTLS); err != nil {
+			return tls.ConnectionState{}, err
+		}
+	}
+	cfg := &tls.Config{
+		ServerName:         serverName(addr),
+		InsecureSkipVerify: true, // Инвентаризация: цепочку записываем, а не проверяем.
+		MinVersion:         minVersion,
+		MaxVersion:         maxVersion,
+	}
+	if minVersion < tls.VersionTLS13 {
+		for _, s := range tls.CipherSuites() {
+			cfg.CipherSuites = append(cfg.CipherSuites, s.ID)
+		}
+		for _, s := range tls.InsecureCipherSuites() {
+			cfg.CipherSuites = append(cfg.CipherSuites, s.ID)
+		}
+	}
+	tc := tls.Client(conn, cfg)
+	if err := tc.HandshakeContext(ctx); err != nil {
+		return tls.ConnectionState{}, err
+	}
+	return tc.ConnectionState(), nil
+}
+
+func certificates(certs []*x509.Certificate) []Certificate {
+	out := make([]Certificate, 0, len(certs))
+	for _, c := range certs {
+		keyType, keyBits := publicKey(c.PublicKey)
+		sum := sha256.Sum256(c.Raw)
+		out = append(out, Certificate{
+			Subject:            c.Subject.String(),
+			SANs:               sans(c),
+			Issuer:             c.Issuer.String(),
+			Serial:             serial(c),
+			NotBefore:          c.NotBefore.UTC(),
+			NotAfter:           c.NotAfter.UTC(),
+			KeyType:            keyType,
+			KeyBits:            keyBits,
+			SignatureAlgorithm: c.SignatureAlgorithm.String(),
+			SelfSigned:         selfSigned(c),
+			SHA256:             hex.EncodeToString(sum[:]),
+		})
+	}
+	return out
+}
+
+func publicKey(key any) (string, int) {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return "RSA", k.N.BitLen()
+	case *ecdsa.PublicKey:
+		return "ECDSA", k.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		return "Ed25519", 256
+	case *dsa.PublicKey:
+		return "DSA", k.P.BitLen()
+	}
+	return "unknown", 0
+}
+
+func sans(c *x509.Certificate) []string {
+	var out []string
+	out = append(out, c.DNSNames...)
+	for _, ip := range c.IPAddresses {
+		out = append(out, ip.String())
+	}
+	out = append(out, c.EmailAddresses...)
+	for _, u := range c.URIs {
+		out = append(out, u.String())
+	}
+	return out
+}
+
+func serial(c *x509.Certificate) string {
+	if c.SerialNumber == nil {
+		return ""
+	}
+	return strings.ToUpper(c.SerialNumber.Text(16))
+}
+
+// selfSigned сообщает, что сертификат подписан собственным ключом.
+func selfSigned(c *x509.Certificate) bool {
+	if string(c.RawIssuer) != string(c.RawSubject) {
+		return false
+	}
+	return c.CheckSignature(c.SignatureAlgorithm, c.RawTBSCertificate, c.Signature) == nil
+}
+
+func serverName(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil || net.ParseIP(host) != nil {
+		return ""
+	}
+	return host
+}
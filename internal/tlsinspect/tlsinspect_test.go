@@ -0,0 +1,231 @@
+package tlsinspect
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testCert выпускает самоподписанный сертификат с RSA-ключом bits бит, действующий до notAfter.
+func testCert(t *testing.T, bits int, notAfter time.Time) tls.Certificate {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(0xbeef),
+		Subject:      pkix.Name{CommonName: "mail.example.test", Organization: []string{"Example"}},
+		DNSNames:     []string{"mail.example.test"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// serve принимает соединения на локальном порту: pre ведёт открытый диалог до TLS
+// (nil — сразу рукопожатие), затем сервер выполняет рукопожатие с cfg.
+func serve(t *testing.T, cfg *tls.Config, pre func(conn net.Conn, r *bufio.Reader) bool) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+				if pre != nil && !pre(conn, bufio.NewReader(conn)) {
+					return
+				}
+				if cfg == nil {
+					return
+				}
+				tc := tls.Server(conn, cfg)
+				if tc.Handshake() == nil {
+					_, _ = tc.Read(make([]byte, 1))
+				}
+			}()
+		}
+	}()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func TestInspect(t *testing.T) {
+	notAfter := time.Now().Add(10 * 24 * time.Hour)
+	cfg := &tls.Config{Certificates: []tls.Certificate{testCert(t, 1024, notAfter)}, MinVersion: tls.VersionTLS12}
+	port := serve(t, cfg, nil)
+
+	info, err := Inspect(context.Background(), "127.0.0.1", port, Options{Timeout: 2 * time.Second})
+	if err != nil {
+		t.Fatalf("Inspect: %v", err)
+	}
+	if len(info.Protocols) != 2 || info.Protocols[0].Version != "TLS 1.3" || info.Protocols[1].Version != "TLS 1.2" {
+		t.Fatalf("Protocols = %+v, ожидались TLS 1.3 и TLS 1.2", info.Protocols)
+	}
+	for _, p := range info.Protocols {
+		if p.CipherSuite == "" {
+			t.Errorf("у %s не записан набор шифров", p.Version)
+		}
+	}
+	if info.LegacyOnly() {
+		t.Error("LegacyOnly() = true для TLS 1.3")
+	}
+	leaf := info.Leaf()
+	if leaf == nil {
+		t.Fatal("цепочка пуста")
+	}
+	if !strings.Contains(leaf.Subject, "CN=mail.example.test") || leaf.Issuer != leaf.Subject {
+		t.Errorf("Subject=%q Issuer=%q", leaf.Subject, leaf.Issuer)
+	}
+	if len(leaf.SANs) != 2 || leaf.SANs[0] != "mail.example.test" || leaf.SANs[1] != "127.0.0.1" {
+		t.Errorf("SANs = %v", leaf.SANs)
+	}
+	if leaf.Serial != "BEEF" || leaf.KeyType != "RSA" || leaf.KeyBits != 1024 || leaf.SignatureAlgorithm != "SHA256-RSA" {
+		t.Errorf("leaf = %+v", leaf)
+	}
+	if !leaf.SelfSigned || !leaf.WeakKey() || len(leaf.SHA256) != 64 {
+		t.Errorf("SelfSigned=%v WeakKey=%v SHA256=%q", leaf.SelfSigned, leaf.WeakKey(), leaf.SHA256)
+	}
+	now := time.Now()
+	if leaf.Expired(now) || !leaf.ExpiresWithin(now, ExpiryWarning) || leaf.ExpiresWithin(now, 24*time.Hour) {
+		t.Errorf("срок действия %s определён неверно", leaf.NotAfter)
+	}
+}
+
+func TestInspectLegacyOnly(t *testing.T) {
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{testCert(t, 2048, time.Now().Add(time.Hour))},
+		MinVersion:   tls.VersionTLS10,
+		MaxVersion:   tls.VersionTLS11,
+	}
+	port := serve(t, cfg, nil)
+
+	info, err := Inspect(context.Background(), "127.0.0.1", port, Options{Timeout: 2 * time.Second})
+	if err != nil {
+		t.Fatalf("Inspect: %v", err)
+	}
+	if len(info.Protocols) != 2 || info.Protocols[0].Version != "TLS 1.1" || info.Protocols[1].Version != "TLS 1.0" {
+		t.Fatalf("Protocols = %+v", info.Protocols)
+	}
+	if !info.LegacyOnly() {
+		t.Error("LegacyOnly() = false для TLS 1.0/1.1")
+	}
+	if info.Leaf().WeakKey() {
+		t.Error("RSA 2048 считается слабым ключом")
+	}
+}
+
+func TestInspectSTARTTLS(t *testing.T) {
+	cfg := &tls.Config{Certificates: []tls.Certificate{testCert(t, 2048, time.Now().Add(time.Hour))}}
+	dialogs := map[string]func(conn net.Conn, r *bufio.Reader) bool{
+		"smtp": func(conn net.Conn, r *bufio.Reader) bool {
+			conn.Write([]byte("220-mail.example.test ESMTP\r\n220 ready\r\n"))
+			if line, _ := r.ReadString('\n'); !strings.HasPrefix(line, "EHLO ") {
+				return false
+			}
+			conn.Write([]byte("250-mail.example.test\r\n250 STARTTLS\r\n"))
+			if line, _ := r.ReadString('\n'); line != "STARTTLS\r\n" {
+				return false
+			}
+			conn.Write([]byte("220 go ahead\r\n"))
+			return true
+		},
+		"imap": func(conn net.Conn, r *bufio.Reader) bool {
+			conn.Write([]byte("* OK IMAP4rev1 ready\r\n"))
+			if line, _ := r.ReadString('\n'); line != "a1 STARTTLS\r\n" {
+				return false
+			}
+			conn.Write([]byte("* CAPABILITY IMAP4rev1\r\na1 OK begin TLS\r\n"))
+			return true
+		},
+		"pop3": func(conn net.Conn, r *bufio.Reader) bool {
+			conn.Write([]byte("+OK POP3 ready\r\n"))
+			if line, _ := r.ReadString('\n'); line != "STLS\r\n" {
+				return false
+			}
+			conn.Write([]byte("+OK begin TLS\r\n"))
+			return true
+		},
+		"ftp": func(conn net.Conn, r *bufio.Reader) bool {
+			conn.Write([]byte("220 FTP ready\r\n"))
+			if line, _ := r.ReadString('\n'); line != "AUTH TLS\r\n" {
+				return false
+			}
+			conn.Write([]byte("234 AUTH TLS ok\r\n"))
+			return true
+		},
+	}
+	for proto, dialog := range dialogs {
+		t.Run(proto, func(t *testing.T) {
+			port := serve(t, cfg, dialog)
+			info, err := Inspect(context.Background(), "127.0.0.1", port, Options{Timeout: 2 * time.Second, STARTTLS: proto})
+			if err != nil {
+				t.Fatalf("Inspect: %v", err)
+			}
+			if info.STARTTLS != proto || len(info.Protocols) == 0 || info.Leaf() == nil {
+				t.Errorf("info = %+v", info)
+			}
+		})
+	}
+}
+
+func TestInspectNotTLS(t *testing.T) {
+	port := serve(t, nil, func(conn net.Conn, r *bufio.Reader) bool {
+		conn.Write([]byte("SSH-2.0-OpenSSH_9.6\r\n"))
+		return false
+	})
+	if info, err := Inspect(context.Background(), "127.0.0.1", port, Options{Timeout: time.Second}); err == nil {
+		t.Fatalf("ожидалась ошибка, получено %+v", info)
+	}
+
+	refused := serve(t, nil, nil)
+	if info, err := Inspect(context.Background(), "127.0.0.1", refused, Options{Timeout: time.Second, STARTTLS: "smtp"}); err == nil {
+		t.Fatalf("ожидалась ошибка STARTTLS, получено %+v", info)
+	}
+}
+
+func TestSTARTTLSFor(t *testing.T) {
+	cases := []struct {
+		port    int
+		service string
+		want    string
+	}{
+		{25, "", "smtp"},
+		{587, "unknown", "smtp"},
+		{2525, "smtp", "smtp"},
+		{143, "imap", "imap"},
+		{110, "", "pop3"},
+		{21, "ftp", "ftp"},
+		{587, "SMTP-Submission", "smtp"},
+		{443, "https", ""},
+		{8443, "", ""},
+	}
+	for _, c := range cases {
+		if got := STARTTLSFor(c.port, c.service); got != c.want {
+			t.Errorf("STARTTLSFor(%d, %q) = %q, want %q", c.port, c.service, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package upnp
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// soapEnvelope — запрос GetGenericPortMappingEntry (WANIPConnection:1, 2.4.14).
+const soapEnvelope = `<?xml version="1.0"?>` +
+	`<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">` +
+	`<s:Body><u:GetGenericPortMappingEntry xmlns:u="%s"><NewPortMappingIndex>%d</NewPortMappingIndex></u:GetGenericPortMappingEntry></s:Body>` +
+	`</s:Envelope>`
+
+// xmlPortMapping — ответ GetGenericPortMappingEntry.
+type xmlPortMapping struct {
+	Entry *struct {
+		RemoteHost     string `xml:"NewRemoteHost"`
+		ExternalPort   string `xml:"NewExternalPort"`
+		Protocol       string `xml:"NewProtocol"`
+		InternalPort   string `xml:"NewInternalPort"`
+		InternalClient string `xml:"NewInternalClient"`
+		Enabled        string `xml:"NewEnabled"`
+		Description    string `xml:"NewPortMappingDescription"`
+		LeaseDuration  string `xml:"NewLeaseDuration"`
+	} `xml:"Body>GetGenericPortMappingEntryResponse"`
+}
+
+// portMappings перебирает таблицу пробросов службы serviceType по индексу с нуля, пока
+// шлюз не ответит ошибкой (SOAP fault 713 SpecifiedArrayIndexInvalid — конец таблицы) или
+// не будет достигнут maxPortMappings.
+func portMappings(ctx context.Context, client *http.Client, control, serviceType string) []PortMapping {
+	header := http.Header{
+		"Content-Type": {`text/xml; charset="utf-8"`},
+		"Soapaction":   {`"` + serviceType + `#GetGenericPortMappingEntry"`},
+	}
+	var out []PortMapping
+	for i := 0; i < maxPortMappings; i++ {
+		body := strings.NewReader(fmt.Sprintf(soapEnvelope, serviceType, i))
+		resp, data, err := do(ctx, client, http.MethodPost, control, header, body, maxSOAPResponse)
+		if err != nil || resp.StatusCode != http.StatusOK {
+			break
+		}
+		var parsed xmlPortMapping
+		if xml.Unmarshal(data, &parsed) != nil || parsed.Entry == nil {
+			break
+		}
+		e := parsed.Entry
+		m := PortMapping{
+			RemoteHost:     strings.TrimSpace(e.RemoteHost),
+			Protocol:       strings.ToUpper(strings.TrimSpace(e.Protocol)),
+			InternalClient: strings.TrimSpace(e.InternalClient),
+			Enabled:        soapBool(e.Enabled),
+			Description:    strings.TrimSpace(e.Description),
+		}
+		m.ExternalPort, _ = strconv.Atoi(strings.TrimSpace(e.ExternalPort))
+		m.InternalPort, _ = strconv.Atoi(strings.TrimSpace(e.InternalPort))
+		m.LeaseDuration, _ = strconv.Atoi(strings.TrimSpace(e.LeaseDuration))
+		out = append(out, m)
+	}
+	return out
+}
+
+// soapBool разбирает логическое значение SOAP ("1", "true", "yes").
+func soapBool(s string) bool {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "1", "true", "yes":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package upnp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoMAC — SSDP не сообщает MAC-адреса устройств.
+var ErrNoMAC = errors.New("ssdp: MAC is not announced")
+
+// Prober — обнаружение хостов по SSDP для сканера: поиск M-SEARCH выполняется один раз,
+// Ping и Lookup отвечают по его результатам.
+type Prober struct {
+	// Window — окно сбора ответов; 0 — DefaultWindow.
+	Window time.Duration
+	// Interface — интерфейс рассылки запросов; пусто — выбор ОС.
+	Interface string
+	// Search выполняет поиск; nil — Search пакета.
+	Search func(ctx context.Context, opts SearchOptions) ([]Response, error)
+
+	mu         sync.Mutex
+	discovered bool
+	err        error
+	found      []Response
+	byIP       map[string][]Response
+}
+
+// NewProber создаёт Prober с окном сбора ответов window.
+func NewProber(window time.Duration) *Prober {
+	return &Prober{Window: window}
+}
+
+// Discover возвращает ответы устройств на M-SEARCH. Первый вызов выполняет поиск,
+// последующие возвращают тот же результат (и ту же ошибку, если поиск не удался не из-за
+// отмены ctx).
+func (p *Prober) Discover(ctx context.Context) ([]Response, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovered {
+		return p.found, p.err
+	}
+	search := p.Search
+	if search == nil {
+		search = Search
+	}
+	found, err := search(ctx, SearchOptions{Window: p.Window, Interface: p.Interface})
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	p.discovered = true
+	if err != nil {
+		p.err = err
+		return nil, err
+	}
+	p.found = found
+	p.byIP = make(map[string][]Response)
+	for _, r := range found {
+		if key, err := ipKey(r.IP); err == nil {
+			p.byIP[key] = append(p.byIP[key], r)
+		}
+	}
+	return p.found, nil
+}
+
+// Ping сообщает, что ip ответил на M-SEARCH.
+func (p *Prober) Ping(ip string) (bool, error) {
+	return p.PingContext(ip, nil)
+}
+
+// PingContext — Ping с отменой через done.
+func (p *Prober) PingContext(ip string, done <-chan struct{}) (bool, error) {
+	key, err := ipKey(ip)
+	if err != nil {
+		return false, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if done != nil {
+		stop := make(chan struct{})
+		defer close(stop)
+		go func() {
+			select {
+			case <-done:
+				cancel()
+			case <-stop:
+			}
+		}()
+	}
+	if _, err := p.Discover(ctx); err != nil {
+		return false, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.byIP[key]) > 0, nil
+}
+
+// ResolveMAC всегда возвращает ErrNoMAC: MAC определяют другие способы обнаружения.
+func (p *Prober) ResolveMAC(ip string) (net.HardwareAddr, error) {
+	return nil, ErrNoMAC
+}
+
+// Lookup возвращает ответы устройства с адресом ip из уже выполненного поиска.
+func (p *Prober) Lookup(ip string) []Response {
+	key, err := ipKey(ip)
+	if err != nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Response(nil), p.byIP[key]...)
+}
+
+// ipKey приводит адрес (возможно, с зоной IPv6) к ключу карты адресов.
+func ipKey(ip string) (string, error) {
+	host, _, _ := strings.Cut(ip, "%")
+	parsed := net.ParseIP(host)
+	if parsed == nil {
+		return "", fmt.Errorf("invalid IP: %s", ip)
+	}
+	return parsed.String(), nil
+}
@@ -0,0 +1,188 @@
+// Package upnp находит устройства UPnP и читает их описания: поиск SSDP M-SEARCH, описание
+// устройства по адресу LOCATION (производитель, модель, серийный номер, прошивка, службы) и
+// таблица пробросов портов Internet Gateway Device (GetGenericPortMappingEntry).
+package upnp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/net/ipv4"
+)
+
+// SSDPPort — порт SSDP.
+const SSDPPort = 1900
+
+// DefaultWindow — окно сбора ответов M-SEARCH по умолчанию.
+const DefaultWindow = 2 * time.Second
+
+const (
+	searchAll     = "ssdp:all" // цель поиска: все устройства и службы
+	searchRepeats = 2          // отправок M-SEARCH: UDP теряется, спецификация советует повторять
+	maxResponse   = 4096       // предел размера ответа SSDP
+)
+
+// groupIPv4 — группа SSDP.
+var groupIPv4 = &net.UDPAddr{IP: net.IPv4(239, 255, 255, 250), Port: SSDPPort}
+
+// SearchOptions — параметры поиска SSDP.
+type SearchOptions struct {
+	// Window — сколько ждать ответов; 0 — DefaultWindow.
+	Window time.Duration
+	// Interface — интерфейс рассылки запросов; пусто — выбор ОС.
+	Interface string
+	// Target — адрес запросов; nil — 239.255.255.250:1900. Адрес хоста — unicast-поиск
+	// у одного устройства.
+	Target *net.UDPAddr
+}
+
+// Response — ответ устройства на M-SEARCH.
+type Response struct {
+	IP       string `json:"ip"`               // адрес ответившего устройства
+	Location string `json:"location"`         // адрес описания устройства
+	Server   string `json:"server,omitempty"` // заголовок SERVER: ОС, версия UPnP, продукт
+	ST       string `json:"st,omitempty"`     // тип найденного устройства или службы
+	USN      string `json:"usn,omitempty"`    // уникальное имя
+}
+
+// Search отправляет M-SEARCH ssdp:all и собирает ответы в течение окна. Ответы с одинаковыми
+// адресом и LOCATION объединяются (устройство отвечает отдельно на каждый свой тип);
+// результат упорядочен по адресу и LOCATION.
+func Search(ctx context.Context, opts SearchOptions) ([]Response, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	window := opts.Window
+	if window <= 0 {
+		window = DefaultWindow
+	}
+	target := opts.Target
+	if target == nil {
+		target = groupIPv4
+	}
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{})
+	if err != nil {
+		return nil, fmt.Errorf("ssdp: %w", err)
+	}
+	defer conn.Close()
+	if target.IP.IsMulticast() {
+		pc := ipv4.NewPacketConn(conn)
+		if opts.Interface != "" {
+			ifi, err := net.InterfaceByName(opts.Interface)
+			if err != nil {
+				return nil, fmt.Errorf("ssdp: %w", err)
+			}
+			if err := pc.SetMulticastInterface(ifi); err != nil {
+				return nil, fmt.Errorf("ssdp: %s: %w", opts.Interface, err)
+			}
+		}
+		_ = pc.SetMulticastTTL(2)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(window))
+	stop := context.AfterFunc(ctx, func() { _ = conn.SetReadDeadline(time.Now()) })
+	defer stop()
+
+	msg := searchRequest(target, window)
+	for i := 0; i < searchRepeats; i++ {
+		if _, err := conn.WriteToUDP(msg, target); err != nil {
+			return nil, fmt.Errorf("ssdp: %w", err)
+		}
+	}
+
+	seen := make(map[string]int)
+	var out []Response
+	buf := make([]byte, maxResponse)
+	for {
+		n, from, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				break
+			}
+			return nil, fmt.Errorf("ssdp: %w", err)
+		}
+		r, ok := parseResponse(buf[:n])
+		if !ok {
+			continue
+		}
+		r.IP = from.IP.String()
+		key := r.IP + " " + r.Location
+		if i, ok := seen[key]; ok {
+			// Корневое устройство предпочтительнее: его ST описывает устройство целиком
+			if r.ST == "upnp:rootdevice" {
+				out[i].ST = r.ST
+				out[i].USN = r.USN
+			}
+			continue
+		}
+		seen[key] = len(out)
+		out = append(out, r)
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	slices.SortFunc(out, func(a, b Response) int {
+		if c := compareIP(a.IP, b.IP); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Location, b.Location)
+	})
+	return out, nil
+}
+
+// searchRequest собирает M-SEARCH; MX (максимальная задержка ответа) укладывается в окно.
+func searchRequest(target *net.UDPAddr, window time.Duration) []byte {
+	mx := int(window / time.Second)
+	mx = max(1, min(mx, 5))
+	var b bytes.Buffer
+	b.WriteString("M-SEARCH * HTTP/1.1\r\n")
+	fmt.Fprintf(&b, "HOST: %s\r\n", target)
+	b.WriteString("MAN: \"ssdp:discover\"\r\n")
+	fmt.Fprintf(&b, "MX: %d\r\n", mx)
+	fmt.Fprintf(&b, "ST: %s\r\n", searchAll)
+	b.WriteString("USER-AGENT: network-scanner UPnP/1.1\r\n\r\n")
+	return b.Bytes()
+}
+
+// parseResponse разбирает ответ "HTTP/1.1 200 OK" с заголовком LOCATION.
+func parseResponse(packet []byte) (Response, bool) {
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(packet)), nil)
+	if err != nil {
+		return Response{}, false
+	}
+	resp.Body.Close()
+	r := Response{
+		Location: strings.TrimSpace(resp.Header.Get("Location")),
+		Server:   strings.TrimSpace(resp.Header.Get("Server")),
+		ST:       strings.TrimSpace(resp.Header.Get("St")),
+		USN:      strings.TrimSpace(resp.Header.Get("Usn")),
+	}
+	if resp.StatusCode != http.StatusOK || r.Location == "" {
+		return Response{}, false
+	}
+	return r, true
+}
+
+// compareIP сравнивает адреса численно (IPv4 раньше IPv6), нечисловые — как строки.
+func compareIP(a, b string) int {
+	ipA, ipB := net.ParseIP(a), net.ParseIP(b)
+	if ipA == nil || ipB == nil {
+		return strings.Compare(a, b)
+	}
+	if v4A, v4B := ipA.To4(), ipB.To4(); v4A != nil && v4B != nil {
+		return bytes.Compare(v4A, v4B)
+	} else if v4A != nil {
+		return -1
+	} else if v4B != nil {
+		return 1
+	}
+	return bytes.Compare(ipA.To16(), ipB.To16())
+}
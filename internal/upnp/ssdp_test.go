@@ -0,0 +1,82 @@
+package upnp
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serveSSDP запускает ответчик, который на M-SEARCH отвечает дважды (rootdevice и тип
+// устройства) с одним LOCATION.
+func serveSSDP(t *testing.T) *net.UDPAddr {
+	t.Helper()
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, from, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			req := string(buf[:n])
+			if !strings.HasPrefix(req, "M-SEARCH * HTTP/1.1\r\n") || !strings.Contains(req, "\r\nST: ssdp:all\r\n") {
+				continue
+			}
+			for _, st := range []string{"urn:schemas-upnp-org:device:InternetGatewayDevice:1", "upnp:rootdevice"} {
+				resp := "HTTP/1.1 200 OK\r\nCACHE-CONTROL: max-age=120\r\nST: " + st +
+					"\r\nUSN: uuid:igd::" + st + "\r\nEXT:\r\nSERVER: Linux/3.14 UPnP/1.0 MiniUPnPd/2.1\r\n" +
+					"LOCATION: http://127.0.0.1:5000/rootDesc.xml\r\n\r\n"
+				_, _ = conn.WriteToUDP([]byte(resp), from)
+			}
+		}
+	}()
+	return conn.LocalAddr().(*net.UDPAddr)
+}
+
+func TestSearch(t *testing.T) {
+	target := serveSSDP(t)
+	found, err := Search(context.Background(), SearchOptions{Window: 300 * time.Millisecond, Target: target})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 {
+		t.Fatalf("ответов %d, want 1: %+v", len(found), found)
+	}
+	r := found[0]
+	if r.IP != "127.0.0.1" || r.Location != "http://127.0.0.1:5000/rootDesc.xml" || r.ST != "upnp:rootdevice" || !strings.Contains(r.Server, "MiniUPnPd") {
+		t.Errorf("ответ: %+v", r)
+	}
+}
+
+func TestProber(t *testing.T) {
+	calls := 0
+	p := NewProber(time.Second)
+	p.Search = func(context.Context, SearchOptions) ([]Response, error) {
+		calls++
+		return []Response{
+			{IP: "10.0.0.1", Location: "http://10.0.0.1:5000/rootDesc.xml"},
+			{IP: "10.0.0.1", Location: "http://10.0.0.1:49152/desc.xml"},
+		}, nil
+	}
+	for ip, want := range map[string]bool{"10.0.0.1": true, "10.0.0.2": false} {
+		alive, err := p.Ping(ip)
+		if err != nil || alive != want {
+			t.Errorf("Ping(%s) = %v, %v; want %v", ip, alive, err, want)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("поисков %d, want 1", calls)
+	}
+	if got := p.Lookup("10.0.0.1"); len(got) != 2 {
+		t.Errorf("Lookup: %+v", got)
+	}
+	if _, err := p.ResolveMAC("10.0.0.1"); err != ErrNoMAC {
+		t.Errorf("ResolveMAC: %v", err)
+	}
+}
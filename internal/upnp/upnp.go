@@ -0,0 +1,254 @@
+package upnp
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DialFunc открывает соединение; совпадает с banner.DialFunc, чтобы сканер подставлял
+// свой диалер (прокси, адрес источника, симулятор).
+type DialFunc func(network, address string, timeout time.Duration) (net.Conn, error)
+
+// DefaultTimeout — ожидание одного HTTP-запроса по умолчанию.
+const DefaultTimeout = 3 * time.Second
+
+const (
+	maxDescription  = 256 << 10 // читаемая часть описания устройства
+	maxSOAPResponse = 64 << 10  // читаемая часть ответа SOAP
+	maxPortMappings = 256       // предел записей таблицы пробросов
+	userAgent       = "network-scanner UPnP/1.1"
+)
+
+// Options — параметры чтения описания устройства.
+type Options struct {
+	Timeout time.Duration // ожидание запроса; 0 — DefaultTimeout
+	Dial    DialFunc      // nil — net.DialTimeout
+}
+
+// Device — описание корневого устройства UPnP.
+type Device struct {
+	Location     string        `json:"location"`                // адрес описания
+	Server       string        `json:"server,omitempty"`        // заголовок SERVER ответа SSDP
+	DeviceType   string        `json:"device_type,omitempty"`   // urn:schemas-upnp-org:device:...
+	FriendlyName string        `json:"friendly_name,omitempty"` // имя, заданное владельцем
+	Manufacturer string        `json:"manufacturer,omitempty"`
+	ModelName    string        `json:"model_name,omitempty"`
+	ModelNumber  string        `json:"model_number,omitempty"`
+	SerialNumber string        `json:"serial_number,omitempty"`
+	Firmware     string        `json:"firmware,omitempty"` // firmwareVersion/softwareVersion (расширения производителей)
+	UDN          string        `json:"udn,omitempty"`      // uuid устройства
+	Services     []string      `json:"services,omitempty"` // типы служб устройства и вложенных устройств
+	PortMappings []PortMapping `json:"port_mappings,omitempty"`
+}
+
+// PortMapping — запись таблицы пробросов портов Internet Gateway Device.
+type PortMapping struct {
+	RemoteHost     string `json:"remote_host,omitempty"` // разрешённый внешний адрес; пусто — любой
+	ExternalPort   int    `json:"external_port"`
+	Protocol       string `json:"protocol"` // TCP или UDP
+	InternalClient string `json:"internal_client"`
+	InternalPort   int    `json:"internal_port"`
+	Enabled        bool   `json:"enabled"`
+	Description    string `json:"description,omitempty"`
+	LeaseDuration  int    `json:"lease_duration,omitempty"` // секунд; 0 — бессрочно
+}
+
+// String — "TCP 8080 → 192.168.1.10:80".
+func (m PortMapping) String() string {
+	return fmt.Sprintf("%s %d → %s:%d", m.Protocol, m.ExternalPort, m.InternalClient, m.InternalPort)
+}
+
+// Gateway сообщает, что устройство — Internet Gateway Device (есть служба WAN-подключения).
+func (d *Device) Gateway() bool {
+	if d == nil {
+		return false
+	}
+	if strings.Contains(d.DeviceType, ":device:InternetGatewayDevice:") {
+		return true
+	}
+	return slices.ContainsFunc(d.Services, isWANConnection)
+}
+
+// Summary — краткое описание для текстового вывода: "Netgear R7000 (fw 1.0.9), 2 проброса".
+func (d *Device) Summary() string {
+	if d == nil {
+		return ""
+	}
+	name := strings.TrimSpace(d.Manufacturer + " " + d.ModelName)
+	if d.ModelNumber != "" && !strings.Contains(d.ModelName, d.ModelNumber) {
+		name = strings.TrimSpace(name + " " + d.ModelNumber)
+	}
+	if name == "" {
+		name = d.FriendlyName
+	}
+	if d.Firmware != "" {
+		name += " (fw " + d.Firmware + ")"
+	}
+	if len(d.PortMappings) > 0 {
+		name += fmt.Sprintf(", пробросов: %d", len(d.PortMappings))
+	}
+	return strings.TrimSpace(name)
+}
+
+// xmlRoot — документ описания устройства (UPnP Device Architecture, 2.3).
+type xmlRoot struct {
+	URLBase string    `xml:"URLBase"`
+	Device  xmlDevice `xml:"device"`
+}
+
+type xmlDevice struct {
+	DeviceType      string       `xml:"deviceType"`
+	FriendlyName    string       `xml:"friendlyName"`
+	Manufacturer    string       `xml:"manufacturer"`
+	ModelName       string       `xml:"modelName"`
+	ModelNumber     string       `xml:"modelNumber"`
+	SerialNumber    string       `xml:"serialNumber"`
+	FirmwareVersion string       `xml:"firmwareVersion"`
+	SoftwareVersion string       `xml:"softwareVersion"`
+	UDN             string       `xml:"UDN"`
+	Services        []xmlService `xml:"serviceList>service"`
+	Devices         []xmlDevice  `xml:"deviceList>device"`
+}
+
+type xmlService struct {
+	ServiceType string `xml:"serviceType"`
+	ControlURL  string `xml:"controlURL"`
+}
+
+// Describe читает описание устройства по адресу location (LOCATION ответа SSDP). У Internet
+// Gateway Device дополнительно читается таблица пробросов портов каждой службы
+// WANIPConnection/WANPPPConnection; отказ в ней не считается ошибкой описания.
+func Describe(ctx context.Context, location string, opts Options) (*Device, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.Dial == nil {
+		opts.Dial = net.DialTimeout
+	}
+	base, err := url.Parse(location)
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return nil, fmt.Errorf("upnp: invalid location %q", location)
+	}
+	client := newClient(opts)
+	resp, body, err := do(ctx, client, http.MethodGet, location, nil, nil, maxDescription)
+	if err != nil {
+		return nil, fmt.Errorf("upnp: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("upnp: %s: HTTP %d", location, resp.StatusCode)
+	}
+	var root xmlRoot
+	if err := xml.Unmarshal(body, &root); err != nil {
+		return nil, fmt.Errorf("upnp: %s: %w", location, err)
+	}
+	if root.URLBase != "" {
+		if u, err := url.Parse(strings.TrimSpace(root.URLBase)); err == nil && u.Host == base.Host {
+			base = u
+		}
+	}
+
+	dev := root.Device
+	d := &Device{
+		Location:     location,
+		DeviceType:   strings.TrimSpace(dev.DeviceType),
+		FriendlyName: strings.TrimSpace(dev.FriendlyName),
+		Manufacturer: strings.TrimSpace(dev.Manufacturer),
+		ModelName:    strings.TrimSpace(dev.ModelName),
+		ModelNumber:  strings.TrimSpace(dev.ModelNumber),
+		SerialNumber: strings.TrimSpace(dev.SerialNumber),
+		Firmware:     strings.TrimSpace(dev.FirmwareVersion),
+		UDN:          strings.TrimSpace(dev.UDN),
+	}
+	if d.Firmware == "" {
+		d.Firmware = strings.TrimSpace(dev.SoftwareVersion)
+	}
+	var wan []xmlService
+	walkDevices(dev, func(dev xmlDevice) {
+		for _, s := range dev.Services {
+			typ := strings.TrimSpace(s.ServiceType)
+			if typ == "" || slices.Contains(d.Services, typ) {
+				continue
+			}
+			d.Services = append(d.Services, typ)
+			if isWANConnection(typ) {
+				wan = append(wan, xmlService{ServiceType: typ, ControlURL: strings.TrimSpace(s.ControlURL)})
+			}
+		}
+	})
+
+	for _, s := range wan {
+		control, err := base.Parse(s.ControlURL)
+		// Управляющий адрес на другом хосте не запрашивается
+		if err != nil || control.Host != base.Host || ctx.Err() != nil {
+			continue
+		}
+		d.PortMappings = append(d.PortMappings, portMappings(ctx, client, control.String(), s.ServiceType)...)
+	}
+	return d, nil
+}
+
+// walkDevices обходит устройство и вложенные устройства в глубину.
+func walkDevices(dev xmlDevice, fn func(xmlDevice)) {
+	fn(dev)
+	for _, child := range dev.Devices {
+		walkDevices(child, fn)
+	}
+}
+
+// isWANConnection сообщает, что тип службы — WAN-подключение IGD с таблицей пробросов.
+func isWANConnection(serviceType string) bool {
+	return strings.Contains(serviceType, ":service:WANIPConnection:") || strings.Contains(serviceType, ":service:WANPPPConnection:")
+}
+
+// newClient создаёт клиента без пула соединений, прокси окружения и переадресаций.
+func newClient(opts Options) *http.Client {
+	transport := &http.Transport{
+		Proxy: nil,
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return opts.Dial(network, addr, opts.Timeout)
+		},
+		ResponseHeaderTimeout: opts.Timeout,
+		DisableKeepAlives:     true,
+	}
+	return &http.Client{
+		Transport: transport,
+		Timeout:   opts.Timeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// do выполняет запрос и читает не более limit байт тела.
+func do(ctx context.Context, client *http.Client, method, target string, header http.Header, body io.Reader, limit int64) (*http.Response, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit))
+	if err != nil && len(data) == 0 && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, nil, err
+	}
+	return resp, data, nil
+}
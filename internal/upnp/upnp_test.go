@@ -0,0 +1,121 @@
+package upnp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const igdDescription = `<?xml version="1.0"?>
+<root xmlns="urn:schemas-upnp-org:device-1-0">
+  <specVersion><major>1</major><minor>0</minor></specVersion>
+  <device>
+    <deviceType>urn:schemas-upnp-org:device:InternetGatewayDevice:1</deviceType>
+    <friendlyName>Home Router</friendlyName>
+    <manufacturer>NETGEAR</manufacturer>
+    <modelName>R7000</modelName>
+    <modelNumber>R7000</modelNumber>
+    <serialNumber>4MK1234567</serialNumber>
+    <firmwareVersion>V1.0.9.88</firmwareVersion>
+    <UDN>uuid:824ff22b-8c7d-41c5-a131-44f534e12555</UDN>
+    <serviceList>
+      <service>
+        <serviceType>urn:schemas-upnp-org:service:Layer3Forwarding:1</serviceType>
+        <controlURL>/ctl/L3F</controlURL>
+      </service>
+    </serviceList>
+    <deviceList>
+      <device>
+        <deviceType>urn:schemas-upnp-org:device:WANDevice:1</deviceType>
+        <deviceList>
+          <device>
+            <deviceType>urn:schemas-upnp-org:device:WANConnectionDevice:1</deviceType>
+            <serviceList>
+              <service>
+                <serviceType>urn:schemas-upnp-org:service:WANIPConnection:1</serviceType>
+                <controlURL>/ctl/IPConn</controlURL>
+              </service>
+            </serviceList>
+          </device>
+        </deviceList>
+      </device>
+    </deviceList>
+  </device>
+</root>`
+
+// serveIGD запускает шлюз с двумя пробросами портов; третий индекс — SOAP fault 713.
+func serveIGD(t *testing.T) string {
+	t.Helper()
+	entries := []string{
+		"<NewRemoteHost></NewRemoteHost><NewExternalPort>3389</NewExternalPort><NewProtocol>TCP</NewProtocol><NewInternalPort>3389</NewInternalPort><NewInternalClient>192.168.1.10</NewInternalClient><NewEnabled>1</NewEnabled><NewPortMappingDescription>RDP</NewPortMappingDescription><NewLeaseDuration>0</NewLeaseDuration>",
+		"<NewRemoteHost></NewRemoteHost><NewExternalPort>51413</NewExternalPort><NewProtocol>UDP</NewProtocol><NewInternalPort>51413</NewInternalPort><NewInternalClient>192.168.1.11</NewInternalClient><NewEnabled>0</NewEnabled><NewPortMappingDescription>Transmission</NewPortMappingDescription><NewLeaseDuration>3600</NewLeaseDuration>",
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rootDesc.xml", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, igdDescription)
+	})
+	mux.HandleFunc("/ctl/IPConn", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method != http.MethodPost || r.Header.Get("SOAPAction") != `"urn:schemas-upnp-org:service:WANIPConnection:1#GetGenericPortMappingEntry"` {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		var index int
+		s := string(body)
+		if i := strings.Index(s, "<NewPortMappingIndex>"); i >= 0 {
+			fmt.Sscanf(s[i+len("<NewPortMappingIndex>"):], "%d", &index)
+		}
+		if index >= len(entries) {
+			w.WriteHeader(http.StatusInternalServerError)
+			io.WriteString(w, `<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body><s:Fault><faultstring>UPnPError</faultstring><detail><UPnPError xmlns="urn:schemas-upnp-org:control-1-0"><errorCode>713</errorCode></UPnPError></detail></s:Fault></s:Body></s:Envelope>`)
+			return
+		}
+		io.WriteString(w, `<?xml version="1.0"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body><u:GetGenericPortMappingEntryResponse xmlns:u="urn:schemas-upnp-org:service:WANIPConnection:1">`+
+			entries[index]+`</u:GetGenericPortMappingEntryResponse></s:Body></s:Envelope>`)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func TestDescribeGateway(t *testing.T) {
+	base := serveIGD(t)
+	d, err := Describe(context.Background(), base+"/rootDesc.xml", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Manufacturer != "NETGEAR" || d.ModelName != "R7000" || d.SerialNumber != "4MK1234567" || d.Firmware != "V1.0.9.88" || d.FriendlyName != "Home Router" {
+		t.Errorf("описание: %+v", d)
+	}
+	wantServices := []string{"urn:schemas-upnp-org:service:Layer3Forwarding:1", "urn:schemas-upnp-org:service:WANIPConnection:1"}
+	if !slices.Equal(d.Services, wantServices) || !d.Gateway() {
+		t.Errorf("службы %v, шлюз %v", d.Services, d.Gateway())
+	}
+	if len(d.PortMappings) != 2 {
+		t.Fatalf("пробросов %d, want 2: %+v", len(d.PortMappings), d.PortMappings)
+	}
+	rdp := d.PortMappings[0]
+	if rdp.String() != "TCP 3389 → 192.168.1.10:3389" || !rdp.Enabled || rdp.Description != "RDP" {
+		t.Errorf("проброс RDP: %+v", rdp)
+	}
+	if tr := d.PortMappings[1]; tr.Enabled || tr.LeaseDuration != 3600 || tr.Protocol != "UDP" {
+		t.Errorf("выключенный проброс: %+v", tr)
+	}
+	if got := d.Summary(); got != "NETGEAR R7000 (fw V1.0.9.88), пробросов: 2" {
+		t.Errorf("Summary = %q", got)
+	}
+}
+
+func TestDescribeErrors(t *testing.T) {
+	base := serveIGD(t)
+	for _, location := range []string{"ftp://127.0.0.1/desc.xml", base + "/missing.xml", "not a url"} {
+		if _, err := Describe(context.Background(), location, Options{}); err == nil {
+			t.Errorf("Describe(%q): ожидалась ошибка", location)
+		}
+	}
+}